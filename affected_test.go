@@ -0,0 +1,41 @@
+package goose_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestMaxAffectedLegacy(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_affected.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00001_a.sql"), []byte("-- +goose Up\n"+
+		"CREATE TABLE a (id INTEGER, name TEXT);\nINSERT INTO a VALUES (1, ''), (2, ''), (3, '');\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose max-affected 1\n"+
+		"-- +goose Up\nUPDATE a SET name = 'x';\n"), 0644))
+
+	err = goose.Up(db, dir)
+	require.ErrorIs(t, err, goose.ErrTooManyRowsAffected)
+	require.ErrorContains(t, err, "statement affected 3 rows, more than the 1 allowed")
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, ver)
+	var updated int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM a WHERE name = 'x'").Scan(&updated))
+	require.Zero(t, updated)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose max-affected 1\n"+
+		"-- +goose NO TRANSACTION\n-- +goose Up\nUPDATE a SET name = 'x' WHERE id = 1;\n"), 0644))
+	err = goose.Up(db, dir)
+	require.ErrorContains(t, err, "max-affected requires a transaction to roll back")
+}
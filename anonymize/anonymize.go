@@ -0,0 +1,274 @@
+// Package anonymize implements declarative column transforms for data migrations that anonymize
+// personal data, such as GDPR erasure requests. A [Job] rewrites columns of the rows of a table in
+// chunks, so it can be used from Go migrations on large tables:
+//
+//	func up(ctx context.Context, tx *sql.Tx) error {
+//		job, err := anonymize.New(database.DialectPostgres, "users", "id", []anonymize.Column{
+//			{Name: "email", Transform: anonymize.Hash("salt")},
+//			{Name: "phone", Transform: anonymize.Mask(4)},
+//			{Name: "address", Transform: anonymize.Nullify()},
+//		}, anonymize.WithWhere("deleted_at IS NOT NULL"))
+//		if err != nil {
+//			return err
+//		}
+//		_, err = job.Run(ctx, tx)
+//		return err
+//	}
+package anonymize
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+// DefaultChunkSize is how many rows a Job transforms per chunk.
+const DefaultChunkSize = 1000
+
+// Transform returns the anonymized value of a column. The value is as scanned by the driver, and
+// may be nil.
+type Transform func(value any) (any, error)
+
+// Hash replaces values with the hex-encoded SHA-256 hash of salt and the value, so equal values
+// stay equal, e.g., to keep joins and uniqueness, without being recoverable. NULL stays NULL.
+func Hash(salt string) Transform {
+	return func(value any) (any, error) {
+		if value == nil {
+			return nil, nil
+		}
+		sum := sha256.Sum256([]byte(salt + toString(value)))
+		return hex.EncodeToString(sum[:]), nil
+	}
+}
+
+// Mask replaces all but the last keep characters of values with '*', e.g., "*******1234" for a
+// phone number with keep 4. NULL stays NULL.
+func Mask(keep int) Transform {
+	return func(value any) (any, error) {
+		if value == nil {
+			return nil, nil
+		}
+		runes := []rune(toString(value))
+		for i := 0; i < len(runes)-keep; i++ {
+			runes[i] = '*'
+		}
+		return string(runes), nil
+	}
+}
+
+// Nullify replaces values with NULL.
+func Nullify() Transform {
+	return func(any) (any, error) { return nil, nil }
+}
+
+// Replace replaces values with v, e.g., a placeholder name.
+func Replace(v any) Transform {
+	return func(any) (any, error) { return v, nil }
+}
+
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// Column is a column to anonymize and its transform.
+type Column struct {
+	Name      string
+	Transform Transform
+}
+
+// Progress is reported after each chunk, see [WithProgress].
+type Progress struct {
+	// Done is how many rows were transformed so far.
+	Done int64
+	// Total is how many rows the job transforms, as counted before it started.
+	Total int64
+}
+
+// Job transforms columns of the rows of a table, in chunks ordered by a key column.
+type Job struct {
+	dialect   database.Dialect
+	table     string
+	key       string
+	columns   []Column
+	where     string
+	whereArgs []any
+	chunkSize int
+	progress  func(Progress)
+}
+
+// New returns a Job that transforms the columns of table. The key column must uniquely identify
+// rows and be ordered, such as the primary key, and no transform may change it.
+func New(dialect database.Dialect, table, key string, columns []Column, opts ...JobOption) (*Job, error) {
+	if dialect == "" {
+		return nil, errors.New("dialect must not be empty")
+	}
+	if table == "" || key == "" {
+		return nil, errors.New("table and key must not be empty")
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("at least one column must be transformed")
+	}
+	for _, c := range columns {
+		if c.Name == "" || c.Transform == nil {
+			return nil, errors.New("columns must have a name and a transform")
+		}
+		if c.Name == key {
+			return nil, fmt.Errorf("key column %s must not be transformed", key)
+		}
+	}
+	j := &Job{
+		dialect:   dialect,
+		table:     table,
+		key:       key,
+		columns:   columns,
+		chunkSize: DefaultChunkSize,
+	}
+	for _, opt := range opts {
+		if err := opt.apply(j); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+// Count returns how many rows the job would transform, without changing any, e.g., for a dry
+// run.
+func (j *Job) Count(ctx context.Context, db database.DBTxConn) (int64, error) {
+	q := "SELECT COUNT(*) FROM " + j.table
+	if j.where != "" {
+		q += " WHERE " + j.where
+	}
+	var n int64
+	if err := db.QueryRowContext(ctx, q, j.whereArgs...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count rows of %s: %w", j.table, err)
+	}
+	return n, nil
+}
+
+// Run transforms the rows and returns how many were transformed. Rows are read and updated one
+// chunk at a time; with a *sql.Tx, all chunks are part of the transaction.
+func (j *Job) Run(ctx context.Context, db database.DBTxConn) (int64, error) {
+	var progress Progress
+	if j.progress != nil {
+		total, err := j.Count(ctx, db)
+		if err != nil {
+			return 0, err
+		}
+		progress.Total = total
+	}
+	var last any
+	for {
+		rows, err := j.readChunk(ctx, db, last)
+		if err != nil {
+			return progress.Done, fmt.Errorf("failed to read rows of %s: %w", j.table, err)
+		}
+		for _, row := range rows {
+			if err := j.update(ctx, db, row); err != nil {
+				return progress.Done, fmt.Errorf("failed to anonymize row %v of %s: %w", row[0], j.table, err)
+			}
+			progress.Done++
+		}
+		if len(rows) > 0 && j.progress != nil {
+			j.progress(progress)
+		}
+		if len(rows) < j.chunkSize {
+			return progress.Done, nil
+		}
+		last = rows[len(rows)-1][0]
+	}
+}
+
+// readChunk returns the next chunk of rows after the key last, or the first chunk if last is nil.
+// Each row holds the key followed by the columns to transform. Rows are read in full before they
+// are updated, since a transaction cannot run updates while a query is open.
+func (j *Job) readChunk(ctx context.Context, db database.DBTxConn, last any) (_ [][]any, retErr error) {
+	names := make([]string, 0, len(j.columns)+1)
+	names = append(names, j.key)
+	for _, c := range j.columns {
+		names = append(names, c.Name)
+	}
+	var conds []string
+	args := append([]any(nil), j.whereArgs...)
+	if j.where != "" {
+		conds = append(conds, "("+j.where+")")
+	}
+	if last != nil {
+		args = append(args, last)
+		conds = append(conds, j.key+" > "+j.placeholder(len(args)))
+	}
+	var q string
+	if j.dialect == database.DialectMSSQL {
+		q = fmt.Sprintf("SELECT TOP %d %s FROM %s", j.chunkSize, strings.Join(names, ", "), j.table)
+	} else {
+		q = fmt.Sprintf("SELECT %s FROM %s", strings.Join(names, ", "), j.table)
+	}
+	if len(conds) > 0 {
+		q += " WHERE " + strings.Join(conds, " AND ")
+	}
+	q += " ORDER BY " + j.key
+	if j.dialect != database.DialectMSSQL {
+		q += " LIMIT " + strconv.Itoa(j.chunkSize)
+	}
+	rs, err := db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rs.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	var rows [][]any
+	for rs.Next() {
+		row := make([]any, len(names))
+		dest := make([]any, len(names))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rs.Scan(dest...); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, rs.Err()
+}
+
+func (j *Job) update(ctx context.Context, db database.DBTxConn, row []any) error {
+	sets := make([]string, 0, len(j.columns))
+	args := make([]any, 0, len(j.columns)+1)
+	for i, c := range j.columns {
+		v, err := c.Transform(row[i+1])
+		if err != nil {
+			return fmt.Errorf("column %s: %w", c.Name, err)
+		}
+		args = append(args, v)
+		sets = append(sets, c.Name+" = "+j.placeholder(len(args)))
+	}
+	args = append(args, row[0])
+	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s = %s", j.table, strings.Join(sets, ", "), j.key, j.placeholder(len(args)))
+	_, err := db.ExecContext(ctx, q, args...)
+	return err
+}
+
+// placeholder returns the n-th query parameter placeholder of the dialect, starting at 1.
+func (j *Job) placeholder(n int) string {
+	switch j.dialect {
+	case database.DialectPostgres, database.DialectRedshift:
+		return "$" + strconv.Itoa(n)
+	case database.DialectMSSQL:
+		return "@p" + strconv.Itoa(n)
+	}
+	return "?"
+}
@@ -0,0 +1,57 @@
+package anonymize
+
+import (
+	"errors"
+	"fmt"
+)
+
+// JobOption is used to configure a Job.
+type JobOption interface {
+	apply(*Job) error
+}
+
+// WithWhere restricts the job to the rows matching cond, an SQL condition with optional query
+// parameters in args, e.g., "deleted_at < ?". Placeholders must use the style of the dialect, and
+// are numbered before the placeholders the job adds.
+func WithWhere(cond string, args ...any) JobOption {
+	return jobFunc(func(j *Job) error {
+		if cond == "" {
+			return errors.New("where condition must not be empty")
+		}
+		j.where, j.whereArgs = cond, args
+		return nil
+	})
+}
+
+// WithChunkSize sets how many rows are transformed per chunk.
+//
+// If WithChunkSize is not called, the DefaultChunkSize is used.
+func WithChunkSize(n int) JobOption {
+	return jobFunc(func(j *Job) error {
+		if n <= 0 {
+			return fmt.Errorf("chunk size must be greater than 0: %d", n)
+		}
+		j.chunkSize = n
+		return nil
+	})
+}
+
+// WithProgress sets a callback invoked after each chunk with the progress of the job. The rows to
+// transform are counted before the job starts.
+func WithProgress(fn func(Progress)) JobOption {
+	return jobFunc(func(j *Job) error {
+		if fn == nil {
+			return errors.New("progress func must not be nil")
+		}
+		j.progress = fn
+		return nil
+	})
+}
+
+var _ JobOption = (jobFunc)(nil)
+
+type jobFunc func(*Job) error
+
+func (f jobFunc) apply(j *Job) error {
+	return f(j)
+}
@@ -0,0 +1,109 @@
+package anonymize_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3/anonymize"
+	"github.com/pressly/goose/v3/database"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestTransforms(t *testing.T) {
+	t.Parallel()
+
+	v, err := anonymize.Mask(4)("+15551234567")
+	require.NoError(t, err)
+	require.Equal(t, "********4567", v)
+	v, err = anonymize.Mask(4)("123")
+	require.NoError(t, err)
+	require.Equal(t, "123", v)
+	v, err = anonymize.Hash("salt")([]byte("alice@example.com"))
+	require.NoError(t, err)
+	w, err := anonymize.Hash("salt")("alice@example.com")
+	require.NoError(t, err)
+	require.Equal(t, v, w)
+	require.Len(t, v, 64)
+	w, err = anonymize.Hash("pepper")("alice@example.com")
+	require.NoError(t, err)
+	require.NotEqual(t, v, w)
+	for _, tr := range []anonymize.Transform{anonymize.Hash("salt"), anonymize.Mask(2), anonymize.Nullify()} {
+		v, err := tr(nil)
+		require.NoError(t, err)
+		require.Nil(t, v)
+	}
+	v, err = anonymize.Replace("redacted")("alice")
+	require.NoError(t, err)
+	require.Equal(t, "redacted", v)
+}
+
+func TestJob(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "anonymize.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.ExecContext(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, phone TEXT, address TEXT, deleted BOOLEAN)")
+	require.NoError(t, err)
+	for i := 1; i <= 25; i++ {
+		_, err := db.ExecContext(ctx, "INSERT INTO users VALUES (?, ?, ?, ?, ?)",
+			i, fmt.Sprintf("user%d@example.com", i), fmt.Sprintf("555000%04d", i), "1 Main St", i%2 == 0)
+		require.NoError(t, err)
+	}
+
+	var progress []anonymize.Progress
+	job, err := anonymize.New(database.DialectSQLite3, "users", "id", []anonymize.Column{
+		{Name: "email", Transform: anonymize.Hash("salt")},
+		{Name: "phone", Transform: anonymize.Mask(4)},
+		{Name: "address", Transform: anonymize.Nullify()},
+	},
+		anonymize.WithWhere("deleted = ?", true),
+		anonymize.WithChunkSize(5),
+		anonymize.WithProgress(func(p anonymize.Progress) { progress = append(progress, p) }),
+	)
+	require.NoError(t, err)
+
+	// Counting is a dry run.
+	n, err := job.Count(ctx, db)
+	require.NoError(t, err)
+	require.EqualValues(t, 12, n)
+	var remaining int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE address IS NOT NULL").Scan(&remaining))
+	require.Equal(t, 25, remaining)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	n, err = job.Run(ctx, tx)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	require.EqualValues(t, 12, n)
+	require.Equal(t, []anonymize.Progress{
+		{Done: 5, Total: 12}, {Done: 10, Total: 12}, {Done: 12, Total: 12},
+	}, progress)
+
+	var email, phone string
+	var address sql.NullString
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT email, phone, address FROM users WHERE id = 2").Scan(&email, &phone, &address))
+	require.Len(t, email, 64)
+	require.Equal(t, "******0002", phone)
+	require.False(t, address.Valid)
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT email, phone, address FROM users WHERE id = 3").Scan(&email, &phone, &address))
+	require.Equal(t, "user3@example.com", email)
+	require.True(t, address.Valid)
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := anonymize.New(database.DialectSQLite3, "users", "id", nil)
+		require.Error(t, err)
+		_, err = anonymize.New(database.DialectSQLite3, "users", "id", []anonymize.Column{{Name: "id", Transform: anonymize.Nullify()}})
+		require.ErrorContains(t, err, "key column id must not be transformed")
+		_, err = anonymize.New(database.DialectSQLite3, "users", "id", []anonymize.Column{{Name: "email"}})
+		require.Error(t, err)
+		_, err = anonymize.New(database.DialectSQLite3, "users", "id", []anonymize.Column{{Name: "email", Transform: anonymize.Nullify()}}, anonymize.WithChunkSize(0))
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,86 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ArchiveDir is the name of the subdirectory, relative to the migrations directory, that [Archive]
+// moves migrations into. Files in this directory are never collected for normal runs, including
+// when collecting recursively with [WithRecursive], but remain on disk for auditing and are still
+// checked by [Provider.Verify].
+const ArchiveDir = "archive"
+
+// Archive moves SQL migrations that were applied before the given time into the [ArchiveDir]
+// subdirectory of dir, keeping the active migrations directory small.
+func Archive(db *sql.DB, dir string, before time.Time, opts ...OptionsFunc) error {
+	ctx := context.Background()
+	return ArchiveContext(ctx, db, dir, before, opts...)
+}
+
+// ArchiveContext moves SQL migrations that were applied before the given time into the
+// [ArchiveDir] subdirectory of dir.
+//
+// Only migrations whose latest record in the version table is applied are archived. Pending or
+// rolled back migrations are left in place. Go migrations are never archived, since moving a Go
+// file into another directory changes the package it belongs to.
+func ArchiveContext(ctx context.Context, db *sql.DB, dir string, before time.Time, opts ...OptionsFunc) error {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.noVersioning {
+		return errors.New("archive requires versioning: applied migrations must be tracked in the version table")
+	}
+	// always use DirFS here because it's modifying operation
+	migrations, err := collectMigrationsFS(option.scope, osFS{}, dir, minVersion, maxVersion, globalMigrationsSnapshot())
+	if err != nil {
+		if errors.Is(err, ErrNoMigrationFiles) {
+			return nil
+		}
+		return fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+		return fmt.Errorf("failed to ensure DB version: %w", err)
+	}
+	archiveDir := filepath.Join(dir, ArchiveDir)
+	var archived int
+	for _, m := range migrations {
+		if !isSQLFile(m.Source) {
+			continue
+		}
+		result, err := getStore().GetMigration(ctx, db, TableName(), m.Version)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return fmt.Errorf("failed to get migration %d: %w", m.Version, err)
+		}
+		if !result.IsApplied || !result.Timestamp.Before(before) {
+			continue
+		}
+		if archived == 0 {
+			if err := os.MkdirAll(archiveDir, 0755); err != nil {
+				return fmt.Errorf("failed to create archive directory: %w", err)
+			}
+		}
+		dest := filepath.Join(archiveDir, filepath.Base(m.Source))
+		if _, err := os.Stat(dest); !os.IsNotExist(err) {
+			return fmt.Errorf("failed to archive migration %q: %s already exists", m.Source, dest)
+		}
+		if err := os.Rename(m.Source, dest); err != nil {
+			return fmt.Errorf("failed to archive migration: %w", err)
+		}
+		log.Printf("Archived %s\n", filepath.Base(m.Source))
+		archived++
+	}
+	if archived == 0 {
+		log.Printf("goose: no migrations to archive\n")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package goose_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestArchive(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_archive.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	migrationsDir := filepath.Join(dir, "migrations")
+	require.NoError(t, os.MkdirAll(migrationsDir, 0755))
+	for _, name := range []string{"00001_a.sql", "00002_b.sql", "00003_c.sql"} {
+		data := []byte("-- +goose Up\nSELECT 1;\n-- +goose Down\nSELECT 1;\n")
+		require.NoError(t, os.WriteFile(filepath.Join(migrationsDir, name), data, 0644))
+	}
+	require.NoError(t, goose.UpTo(db, migrationsDir, 2))
+
+	// Nothing was applied before the cutoff.
+	require.NoError(t, goose.Archive(db, migrationsDir, time.Now().Add(-time.Hour)))
+	_, err = os.Stat(filepath.Join(migrationsDir, goose.ArchiveDir))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, goose.Archive(db, migrationsDir, time.Now().Add(time.Hour)))
+	for _, name := range []string{"00001_a.sql", "00002_b.sql"} {
+		_, err := os.Stat(filepath.Join(migrationsDir, goose.ArchiveDir, name))
+		require.NoError(t, err)
+		_, err = os.Stat(filepath.Join(migrationsDir, name))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	}
+	// The pending migration stays in place and can still be applied.
+	require.NoError(t, goose.Up(db, migrationsDir))
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, ver)
+}
@@ -0,0 +1,232 @@
+// Package backup defines the Backuper interface and implements backups with pg_dump and mysqldump.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Request describes a migration about to be applied, whose affected tables should be backed up.
+type Request struct {
+	// Version is the version of the migration.
+	Version int64
+	// Source is the path of the migration file.
+	Source string
+	// Tables are the tables the migration declares it affects. If empty, the whole database should
+	// be backed up.
+	Tables []string
+}
+
+// Backuper backs up a database before a destructive migration is applied.
+type Backuper interface {
+	// Backup backs up the tables of the request and returns where the backup is stored, e.g., the
+	// path of a dump file.
+	Backup(ctx context.Context, req Request) (string, error)
+}
+
+// NewPgDump returns a Backuper that dumps the affected tables of the Postgres database at dsn with
+// pg_dump, in its custom format, to a file in dir. The dump can be restored with pg_restore.
+//
+// The dsn is either a URL or a list of key=value settings. A password in it is passed to pg_dump
+// in the PGPASSWORD environment variable, so that it does not show in the process list.
+func NewPgDump(dsn, dir string, opts ...CommandOption) (Backuper, error) {
+	if dsn == "" {
+		return nil, errors.New("dsn must not be empty")
+	}
+	c, err := newCommand("pg_dump", dir, ".dump", opts)
+	if err != nil {
+		return nil, err
+	}
+	dsn, password, err := splitPassword(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if password != "" {
+		c.env = []string{"PGPASSWORD=" + password}
+	}
+	c.args = func(path string, tables []string) []string {
+		args := []string{"--dbname=" + dsn, "--format=custom", "--file=" + path}
+		for _, t := range tables {
+			args = append(args, "--table="+t)
+		}
+		return args
+	}
+	return c, nil
+}
+
+// splitPassword removes the password from a Postgres dsn, in URL or key=value form, and returns
+// it separately.
+func splitPassword(dsn string) (string, string, error) {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid dsn: %w", err)
+		}
+		password, _ := u.User.Password()
+		if u.User != nil {
+			u.User = url.User(u.User.Username())
+		}
+		query := u.Query()
+		if p := query.Get("password"); p != "" {
+			password = p
+		}
+		if query.Has("password") {
+			query.Del("password")
+			u.RawQuery = query.Encode()
+		}
+		return u.String(), password, nil
+	}
+	var password string
+	var kept []string
+	for _, field := range splitSettings(dsn) {
+		if value, ok := strings.CutPrefix(field, "password="); ok {
+			password = unquoteSetting(value)
+			continue
+		}
+		kept = append(kept, field)
+	}
+	return strings.Join(kept, " "), password, nil
+}
+
+// splitSettings splits a key=value dsn into its settings, keeping single-quoted values with
+// spaces and escaped characters intact.
+func splitSettings(dsn string) []string {
+	var fields []string
+	var b strings.Builder
+	var quoted, escaped bool
+	for _, r := range dsn {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '\'':
+			quoted = !quoted
+		case (r == ' ' || r == '\t') && !quoted:
+			if b.Len() > 0 {
+				fields = append(fields, b.String())
+				b.Reset()
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
+
+// unquoteSetting returns the value of a key=value setting without its quotes and escapes.
+func unquoteSetting(value string) string {
+	var b strings.Builder
+	var escaped bool
+	for _, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+			continue
+		case r == '\'':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NewMySQLDump returns a Backuper that dumps the affected tables of the MySQL database with
+// mysqldump to a SQL file in dir. Connection options, such as --host and --user, are passed in
+// args; the password is best set in an option file or the MYSQL_PWD environment variable.
+func NewMySQLDump(database, dir string, args []string, opts ...CommandOption) (Backuper, error) {
+	if database == "" {
+		return nil, errors.New("database must not be empty")
+	}
+	c, err := newCommand("mysqldump", dir, ".sql", opts)
+	if err != nil {
+		return nil, err
+	}
+	connArgs := append([]string(nil), args...)
+	c.args = func(path string, tables []string) []string {
+		args := append(append([]string(nil), connArgs...), "--single-transaction", "--result-file="+path, database)
+		return append(args, tables...)
+	}
+	return c, nil
+}
+
+// CommandOption is used to configure the dump command of a Backuper.
+type CommandOption interface {
+	apply(*command) error
+}
+
+// WithCommand sets the path of the dump command, if it is not found in the PATH.
+func WithCommand(path string) CommandOption {
+	return commandFunc(func(c *command) error {
+		if path == "" {
+			return errors.New("command path must not be empty")
+		}
+		c.name = path
+		return nil
+	})
+}
+
+var _ CommandOption = (commandFunc)(nil)
+
+type commandFunc func(*command) error
+
+func (f commandFunc) apply(c *command) error {
+	return f(c)
+}
+
+// command is a Backuper that runs a dump command writing to a new file per backup.
+type command struct {
+	name string
+	dir  string
+	ext  string
+	env  []string
+	args func(path string, tables []string) []string
+}
+
+func newCommand(name, dir, ext string, opts []CommandOption) (*command, error) {
+	if dir == "" {
+		return nil, errors.New("backup directory must not be empty")
+	}
+	c := &command{name: name, dir: dir, ext: ext}
+	for _, opt := range opts {
+		if err := opt.apply(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *command) Backup(ctx context.Context, req Request) (string, error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	name := fmt.Sprintf("%d_%s%s", req.Version, time.Now().UTC().Format("20060102150405"), c.ext)
+	path := filepath.Join(c.dir, name)
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.name, c.args(path, req.Tables)...)
+	if len(c.env) > 0 {
+		cmd.Env = append(os.Environ(), c.env...)
+	}
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		_ = os.Remove(path)
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s failed: %w: %s", filepath.Base(c.name), err, msg)
+		}
+		return "", fmt.Errorf("%s failed: %w", filepath.Base(c.name), err)
+	}
+	return path, nil
+}
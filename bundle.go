@@ -0,0 +1,218 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// ErrBundleChecksum is returned by [ApplyBundle] when the statements of a bundle do not match its
+// checksum, e.g., because the bundle was edited or corrupted in transit.
+var ErrBundleChecksum = errors.New("bundle checksum mismatch")
+
+// Bundle is a fully rendered, self-contained set of SQL migrations, created by [CreateBundle] and
+// applied by [ApplyBundle]. A bundle carries the Up statements of each migration, with environment
+// variables substituted, so it can be applied on hosts that cannot see the migration files, e.g., in
+// air-gapped environments.
+type Bundle struct {
+	// CreatedAt is when the bundle was created.
+	CreatedAt time.Time `json:"created_at"`
+	// Migrations are the bundled migrations, ordered by version.
+	Migrations []BundledMigration `json:"migrations"`
+	// Checksum is a checksum over the versions, transaction modes and statements of the
+	// migrations, in order, verified before the bundle is applied.
+	Checksum string `json:"checksum"`
+}
+
+// BundledMigration is a migration in a [Bundle].
+type BundledMigration struct {
+	Version int64  `json:"version"`
+	Source  string `json:"source"`
+	// Checksum is the checksum of the migration file the statements were rendered from, see
+	// [PlannedMigration.Checksum].
+	Checksum string `json:"checksum"`
+	// UseTx is false if the migration is annotated with NO TRANSACTION.
+	UseTx bool `json:"use_tx"`
+	// Tombstone is true if the migration is a tombstone, which is versioned without running any
+	// statements.
+	Tombstone bool `json:"tombstone,omitempty"`
+	// Statements are the rendered Up statements, in order.
+	Statements []string `json:"statements"`
+}
+
+// CreateBundle renders the SQL migrations in dir of fsys into a [Bundle]: their Up statements are
+// parsed, with environment variables substituted for migrations annotated with ENVSUB ON, and
+// checksummed. Go migrations cannot be bundled, since their functions are built into the binary
+// that runs them, and return an error.
+func CreateBundle(fsys fs.FS, dir string, opts ...OptionsFunc) (*Bundle, error) {
+	if fsys == nil {
+		return nil, errors.New("fsys must not be nil")
+	}
+	fsys = decompressing(fsys)
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	foundMigrations, err := collectMigrationsCached(option.collectCache, option.scope, fsys, dir, minVersion, maxVersion, globalMigrationsSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		CreatedAt:  time.Now().UTC(),
+		Migrations: make([]BundledMigration, 0, len(foundMigrations)),
+	}
+	for _, m := range foundMigrations {
+		if !isSQLFile(m.Source) {
+			return nil, fmt.Errorf("go migration %s cannot be bundled: only SQL migrations can be rendered", filepath.Base(m.Source))
+		}
+		data, err := fs.ReadFile(fsys, m.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", filepath.Base(m.Source), err)
+		}
+		bm := BundledMigration{
+			Version:    m.Version,
+			Source:     m.Source,
+			Checksum:   sqlChecksum(data),
+			UseTx:      true,
+			Statements: []string{},
+		}
+		directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration %s: %w", filepath.Base(m.Source), err)
+		}
+		if err := checkRequiresGoose(directives); err != nil {
+			return nil, fmt.Errorf("migration %s: %w", filepath.Base(m.Source), err)
+		}
+		if _, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveTombstone); ok {
+			bm.Tombstone = true
+		} else {
+			statements, useTx, err := sqlparser.ParseSQLMigration(bytes.NewReader(data), sqlparser.DirectionUp, isVerbose())
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse migration %s: %w", filepath.Base(m.Source), err)
+			}
+			bm.UseTx = useTx
+			bm.Statements = append(bm.Statements, statements...)
+		}
+		b.Migrations = append(b.Migrations, bm)
+	}
+	b.Checksum = bundleChecksum(b.Migrations)
+	return b, nil
+}
+
+// Verify checks that the migrations of the bundle match its checksum and are ordered by version,
+// without duplicates. It returns an error wrapping [ErrBundleChecksum] if the bundle was modified.
+func (b *Bundle) Verify() error {
+	if sum := bundleChecksum(b.Migrations); sum != b.Checksum {
+		return fmt.Errorf("%w: expected %s, got %s", ErrBundleChecksum, b.Checksum, sum)
+	}
+	if !sort.SliceIsSorted(b.Migrations, func(i, j int) bool { return b.Migrations[i].Version < b.Migrations[j].Version }) {
+		return errors.New("bundle migrations must be ordered by version")
+	}
+	for i := 1; i < len(b.Migrations); i++ {
+		if b.Migrations[i].Version == b.Migrations[i-1].Version {
+			return fmt.Errorf("bundle has duplicate version %d", b.Migrations[i].Version)
+		}
+	}
+	return nil
+}
+
+// ApplyBundle applies the migrations of a bundle created by [CreateBundle] that are not applied to
+// the database yet, in order, like [UpContext] would apply the migration files. The bundle is
+// verified first, see [Bundle.Verify], and nothing is run if it was modified.
+func ApplyBundle(ctx context.Context, db *sql.DB, b *Bundle, opts ...OptionsFunc) error {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.noVersioning {
+		return errors.New("bundle requires versioning: applied migrations must be tracked in the version table")
+	}
+	if err := checkRunLabels(option.runLabels); err != nil {
+		return err
+	}
+	runLabels, err := option.labelProtection.protect(ctx, option.runLabels)
+	if err != nil {
+		return err
+	}
+	if err := b.Verify(); err != nil {
+		return err
+	}
+	bundled := make(map[int64]BundledMigration, len(b.Migrations))
+	foundMigrations := make(Migrations, 0, len(b.Migrations))
+	for _, bm := range b.Migrations {
+		bundled[bm.Version] = bm
+		foundMigrations = append(foundMigrations, &Migration{Version: bm.Version, Source: bm.Source, Next: -1, Previous: -1})
+	}
+	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+		return err
+	}
+	dbMigrations, err := listAllDBVersions(ctx, db)
+	if err != nil {
+		return err
+	}
+	pending, err := pendingUpMigrations(dbMigrations, foundMigrations, maxVersion, option.allowMissing)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		log.Printf("goose: no migrations to run. current version: %d\n", dbMigrations[len(dbMigrations)-1].Version)
+		return nil
+	}
+	for _, m := range pending {
+		bm := bundled[m.Version]
+		start := time.Now()
+		if err := runSQLMigration(ctx, db, bm.Statements, bm.UseTx, bm.Version, true, false, sqlChecks{}); err != nil {
+			return fmt.Errorf("ERROR %v: failed to run SQL migration: %w", filepath.Base(bm.Source), err)
+		}
+		finish := truncateDuration(time.Since(start))
+		switch {
+		case bm.Tombstone:
+			log.Printf("TOMBSTONE %s\n", filepath.Base(bm.Source))
+		case len(bm.Statements) > 0:
+			log.Printf("OK   %s (%s)\n", filepath.Base(bm.Source), finish)
+		default:
+			log.Printf("EMPTY %s (%s)\n", filepath.Base(bm.Source), finish)
+		}
+		if len(runLabels) > 0 {
+			if err := recordRunLabels(ctx, db, bm.Version, runLabels); err != nil {
+				return fmt.Errorf("failed to record run labels for version %d: %w", bm.Version, err)
+			}
+		}
+	}
+	log.Printf("goose: successfully migrated database to version: %d\n", pending[len(pending)-1].Version)
+	return nil
+}
+
+// bundleChecksum returns a checksum over the versions, source checksums, transaction modes and
+// statements of migrations, in order. Statements are length-prefixed, so that moving text between
+// statements changes the checksum.
+func bundleChecksum(migrations []BundledMigration) string {
+	h := sha256.New()
+	for _, m := range migrations {
+		h.Write(strconv.AppendInt(nil, m.Version, 10))
+		h.Write([]byte{'\n'})
+		h.Write([]byte(m.Checksum))
+		h.Write([]byte{'\n'})
+		h.Write(strconv.AppendBool(nil, m.UseTx))
+		h.Write(strconv.AppendBool(nil, m.Tombstone))
+		h.Write([]byte{'\n'})
+		for _, stmt := range m.Statements {
+			h.Write(strconv.AppendInt(nil, int64(len(stmt)), 10))
+			h.Write([]byte{':'})
+			h.Write([]byte(stmt))
+		}
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
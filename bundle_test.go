@@ -0,0 +1,100 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestBundle(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	t.Setenv("GOOSE_BUNDLE_TABLE", "users")
+	fsys := fstest.MapFS{
+		"migrations/00001_users.sql":   newMapFile("-- +goose Up\n-- +goose ENVSUB ON\nCREATE TABLE ${GOOSE_BUNDLE_TABLE} (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n"),
+		"migrations/00002_retired.sql": newMapFile("-- +goose tombstone replaced by 3\n-- +goose Up\nCREATE TABLE retired (id INTEGER);\n"),
+		"migrations/00003_posts.sql":   newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE posts (id INTEGER);\n"),
+	}
+	b, err := goose.CreateBundle(fsys, "migrations")
+	require.NoError(t, err)
+	require.Len(t, b.Migrations, 3)
+	require.Equal(t, []string{"CREATE TABLE users (id INTEGER);"}, b.Migrations[0].Statements)
+	require.True(t, b.Migrations[0].UseTx)
+	require.NotEmpty(t, b.Migrations[0].Checksum)
+	require.True(t, b.Migrations[1].Tombstone)
+	require.Empty(t, b.Migrations[1].Statements)
+	require.False(t, b.Migrations[2].UseTx)
+	require.NoError(t, b.Verify())
+
+	// Bundles survive a JSON round trip, and are applied without the migration files.
+	data, err := json.Marshal(b)
+	require.NoError(t, err)
+	var decoded goose.Bundle
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	t.Run("run", func(t *testing.T) {
+		// Without a file, the bundle is written to the output, not logged.
+		goose.SetBaseFS(fsys)
+		t.Cleanup(func() { goose.SetBaseFS(nil) })
+		var out strings.Builder
+		goose.SetOutput(&out)
+		t.Cleanup(func() { goose.SetOutput(os.Stdout) })
+		require.NoError(t, goose.RunContext(ctx, "bundle", nil, "migrations"))
+		var written goose.Bundle
+		require.NoError(t, json.Unmarshal([]byte(out.String()), &written))
+		require.NoError(t, written.Verify())
+		require.Len(t, written.Migrations, 3)
+
+		file := filepath.Join(t.TempDir(), "bundle.json")
+		require.NoError(t, goose.RunContext(ctx, "bundle", nil, "migrations", "-o", file))
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &written))
+		require.Len(t, written.Migrations, 3)
+	})
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sql_bundle.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	t.Run("modified", func(t *testing.T) {
+		modified := decoded
+		modified.Migrations = append([]goose.BundledMigration(nil), decoded.Migrations...)
+		modified.Migrations[0].Statements = []string{"DROP TABLE accounts;"}
+		require.ErrorIs(t, goose.ApplyBundle(ctx, db, &modified), goose.ErrBundleChecksum)
+		require.False(t, tableExists(t, db, "goose_db_version"))
+	})
+	require.NoError(t, goose.ApplyBundle(ctx, db, &decoded))
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, ver)
+	require.True(t, tableExists(t, db, "users"))
+	require.False(t, tableExists(t, db, "retired"))
+	require.True(t, tableExists(t, db, "posts"))
+
+	// Applied migrations are skipped, so a newer bundle only applies the new migrations.
+	fsys["migrations/00004_tags.sql"] = newMapFile("-- +goose Up\nCREATE TABLE tags (id INTEGER);\n")
+	newer, err := goose.CreateBundle(fsys, "migrations")
+	require.NoError(t, err)
+	require.NotEqual(t, b.Checksum, newer.Checksum)
+	require.NoError(t, goose.ApplyBundle(ctx, db, newer))
+	require.NoError(t, goose.ApplyBundle(ctx, db, newer))
+	ver, err = goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 4, ver)
+
+	fsys["migrations/00005_go.go"] = newMapFile("package migrations\n")
+	_, err = goose.CreateBundle(fsys, "migrations")
+	require.ErrorContains(t, err, "cannot be bundled")
+}
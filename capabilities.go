@@ -0,0 +1,99 @@
+package goose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+type capabilitiesKey struct{}
+
+// Capabilities describes the database a Go migration runs on, so that migrations can branch on
+// the features of the target without writing their own probing queries. It is passed to Go
+// migrations through their context, see [MigrationCapabilities].
+//
+// With a [Provider], probing queries run on the transaction or connection of the migration. The
+// package-level functions, such as [UpContext], run them on the *sql.DB.
+type Capabilities struct {
+	db      database.DBTxConn
+	dialect Dialect
+
+	mu      sync.Mutex
+	version string
+}
+
+// NewCapabilities returns the capabilities of db, a database of dialect d. Providers and the
+// package-level functions pass capabilities to Go migrations automatically; use it directly to
+// test Go migrations, together with [ContextWithCapabilities].
+func NewCapabilities(db database.DBTxConn, d Dialect) *Capabilities {
+	return &Capabilities{db: db, dialect: d}
+}
+
+// ContextWithCapabilities returns a copy of ctx carrying c for Go migrations.
+func ContextWithCapabilities(ctx context.Context, c *Capabilities) context.Context {
+	return context.WithValue(ctx, capabilitiesKey{}, c)
+}
+
+// MigrationCapabilities returns the capabilities of the database from the context passed to a Go
+// migration. It reports false if none were set, e.g., for a provider with a custom store, which
+// has no dialect.
+//
+// Example:
+//
+//	func up(ctx context.Context, tx *sql.Tx) error {
+//		caps, _ := goose.MigrationCapabilities(ctx)
+//		ok, err := caps.HasExtension(ctx, "postgis")
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func MigrationCapabilities(ctx context.Context) (*Capabilities, bool) {
+	c, ok := ctx.Value(capabilitiesKey{}).(*Capabilities)
+	return c, ok
+}
+
+// Dialect returns the dialect of the database.
+func (c *Capabilities) Dialect() Dialect {
+	return c.dialect
+}
+
+// SupportsTransactionalDDL reports whether schema changes can run inside a transaction and be
+// rolled back. Dialects such as MySQL implicitly commit on DDL statements.
+func (c *Capabilities) SupportsTransactionalDDL() bool {
+	return supportsTransactionalDDL(c.dialect)
+}
+
+// ServerVersion returns the version of the server, e.g., "14.5" for PostgreSQL 14.5, as checked by
+// the "-- +goose requires-db" directive. The version is queried once and cached.
+func (c *Capabilities) ServerVersion(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.version == "" {
+		v, err := serverVersion(ctx, c.db, c.dialect)
+		if err != nil {
+			return "", err
+		}
+		c.version = strings.TrimPrefix(v, "v")
+	}
+	return c.version, nil
+}
+
+// HasExtension reports whether the extension with the given name, e.g., "postgis", is installed in
+// the database. It returns an error wrapping [errors.ErrUnsupported] for dialects without
+// extensions; only PostgreSQL is supported.
+func (c *Capabilities) HasExtension(ctx context.Context, name string) (bool, error) {
+	if c.dialect != DialectPostgres {
+		return false, fmt.Errorf("extensions are not supported by the %s dialect: %w", c.dialect, errors.ErrUnsupported)
+	}
+	var exists bool
+	q := "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1)"
+	if err := c.db.QueryRowContext(ctx, q, name).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to query extension %s: %w", name, err)
+	}
+	return exists, nil
+}
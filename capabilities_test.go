@@ -0,0 +1,51 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationCapabilities(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var transactionalDDL bool
+	var version string
+	var extensionErr error
+	up := func(ctx context.Context, tx *sql.Tx) error {
+		caps, ok := goose.MigrationCapabilities(ctx)
+		if !ok {
+			return errors.New("missing capabilities")
+		}
+		transactionalDDL = caps.SupportsTransactionalDDL()
+		var err error
+		if version, err = caps.ServerVersion(ctx); err != nil {
+			return err
+		}
+		_, extensionErr = caps.HasExtension(ctx, "postgis")
+		return nil
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), nil,
+		goose.WithGoMigrations(goose.NewGoMigration(1, &goose.GoFunc{RunTx: up}, nil)),
+	)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.True(t, transactionalDDL)
+	require.Regexp(t, `^3\.\d+`, version)
+	require.ErrorIs(t, extensionErr, errors.ErrUnsupported)
+
+	_, ok := goose.MigrationCapabilities(ctx)
+	require.False(t, ok)
+	caps := goose.NewCapabilities(newDB(t), goose.DialectMySQL)
+	require.False(t, caps.SupportsTransactionalDDL())
+	require.Equal(t, goose.DialectMySQL, caps.Dialect())
+	got, ok := goose.MigrationCapabilities(goose.ContextWithCapabilities(ctx, caps))
+	require.True(t, ok)
+	require.Same(t, caps, got)
+}
@@ -0,0 +1,199 @@
+package goose
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// Changelog is a human-readable summary of migration files for release notes, created by
+// [GenerateChangelog].
+type Changelog struct {
+	// Since is the version the changelog starts after.
+	Since int64 `json:"since"`
+	// Scopes are the migrations grouped by scope, the root scope first, then in lexical order.
+	Scopes []ChangelogScope `json:"scopes"`
+}
+
+// ChangelogScope is the migrations of a scope in a [Changelog].
+type ChangelogScope struct {
+	// Scope is the subdirectory the migrations live in, or empty for the root of the filesystem.
+	// This is the directory create places migrations of a scope in, see [WithCreateScope].
+	Scope string `json:"scope"`
+	// Entries are the migrations, ordered by version.
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// ChangelogEntry is a migration in a [Changelog].
+type ChangelogEntry struct {
+	Version int64  `json:"version"`
+	Source  string `json:"source"`
+	// Title is the title declared by a SQL migration with a "-- +goose title" directive, or else
+	// the description in the file name, e.g., "add users table" for 00002_add_users_table.sql.
+	Title string `json:"title"`
+	// Notes are the comments at the top of a SQL migration, before its first statement, without
+	// the comment markers and goose directives. Empty for Go migrations.
+	Notes string `json:"notes,omitempty"`
+	// Hints are the resource hints declared by the migration, or nil, see [Hints].
+	Hints *Hints `json:"hints,omitempty"`
+}
+
+// GenerateChangelog renders release notes from the migration files in fsys with a version greater
+// than since, from their file names, header comments and hints, grouped by scope. Migrations are
+// collected from the root of fsys and its subdirectories, except [ArchiveDir] directories. Files
+// whose version prefix does not parse, Go test files and repeatable migrations are ignored, see
+// [ReportGaps] to find them.
+func GenerateChangelog(fsys fs.FS, since int64) (*Changelog, error) {
+	if fsys == nil {
+		return nil, errors.New("fsys must not be nil")
+	}
+	var files []string
+	for _, pattern := range append(sqlFilePatterns, "*.go") {
+		matches, err := globFilesystem(fsys, pattern, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob pattern %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	byScope := make(map[string][]ChangelogEntry)
+	for _, file := range files {
+		base := path.Base(file)
+		if strings.HasSuffix(base, "_test.go") || strings.HasPrefix(base, repeatablePrefix) {
+			continue
+		}
+		version, err := NumericComponent(base)
+		if err != nil || version <= since {
+			continue
+		}
+		entry := ChangelogEntry{Version: version, Source: file, Title: changelogTitle(base)}
+		if isSQLFile(base) {
+			var title string
+			if title, entry.Notes, entry.Hints, err = readChangelogNotes(fsys, file); err != nil {
+				return nil, err
+			}
+			if title != "" {
+				entry.Title = title
+			}
+		}
+		scope, _, nested := strings.Cut(file, "/")
+		if !nested {
+			scope = ""
+		}
+		byScope[scope] = append(byScope[scope], entry)
+	}
+	scopes := make([]string, 0, len(byScope))
+	for scope := range byScope {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	changelog := &Changelog{Since: since, Scopes: make([]ChangelogScope, 0, len(scopes))}
+	for _, scope := range scopes {
+		entries := byScope[scope]
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Version < entries[j].Version })
+		changelog.Scopes = append(changelog.Scopes, ChangelogScope{Scope: scope, Entries: entries})
+	}
+	return changelog, nil
+}
+
+// changelogTitle returns the description in the base name of a migration file.
+func changelogTitle(base string) string {
+	_, name, _ := strings.Cut(base, "_")
+	for _, ext := range []string{".gz", ".zst", ".sql", ".go"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return strings.Join(strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' }), " ")
+}
+
+// readChangelogNotes returns the title, header comments and hints of the SQL migration at file.
+func readChangelogNotes(fsys fs.FS, file string) (string, string, *Hints, error) {
+	data, err := fs.ReadFile(decompressing(fsys), file)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to read migration %s: %w", path.Base(file), err)
+	}
+	directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to parse migration %s: %w", path.Base(file), err)
+	}
+	title, err := parseTitle(directives)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("migration %s: %w", path.Base(file), err)
+	}
+	hints, err := parseHints(directives)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("migration %s: %w", path.Base(file), err)
+	}
+	var notes []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			notes = append(notes, "")
+			continue
+		}
+		comment, ok := strings.CutPrefix(line, "--")
+		if !ok {
+			break
+		}
+		comment = strings.TrimSpace(comment)
+		if strings.HasPrefix(comment, "+goose") {
+			// The Up annotation ends the header, any other directive is left out.
+			if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(comment, "+goose")), "up") {
+				break
+			}
+			continue
+		}
+		notes = append(notes, comment)
+	}
+	return title, strings.TrimSpace(strings.Join(notes, "\n")), hints, nil
+}
+
+// String returns the changelog as Markdown, one list per scope, for example:
+//
+//	## Migrations since version 3
+//
+//	- 4: add users table (impact: heavy; affects: users)
+//	  Stores the accounts of the new sign up flow.
+//
+//	### billing
+//
+//	- 5: add invoices
+func (c *Changelog) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Migrations since version %d\n", c.Since)
+	if len(c.Scopes) == 0 {
+		b.WriteString("\nNo migrations.\n")
+	}
+	for _, s := range c.Scopes {
+		if s.Scope != "" {
+			fmt.Fprintf(&b, "\n### %s\n", s.Scope)
+		}
+		b.WriteString("\n")
+		for _, e := range s.Entries {
+			fmt.Fprintf(&b, "- %d: %s", e.Version, e.Title)
+			if e.Hints != nil {
+				var hints []string
+				if e.Hints.Impact != "" {
+					hints = append(hints, "impact: "+e.Hints.Impact)
+				}
+				if len(e.Hints.Affects) > 0 {
+					hints = append(hints, "affects: "+strings.Join(e.Hints.Affects, ", "))
+				}
+				fmt.Fprintf(&b, " (%s)", strings.Join(hints, "; "))
+			}
+			b.WriteString("\n")
+			for _, line := range strings.Split(e.Notes, "\n") {
+				if line != "" {
+					fmt.Fprintf(&b, "  %s\n", line)
+				}
+			}
+		}
+	}
+	return b.String()
+}
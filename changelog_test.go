@@ -0,0 +1,71 @@
+package goose_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateChangelog(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"00001_init.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"00004_add_users_table.sql": newMapFile(`-- Stores the accounts of the new sign up flow.
+--
+-- Replaces the legacy accounts table.
+-- +goose impact heavy
+-- +goose affects users
+-- +goose Up
+-- Not part of the notes.
+CREATE TABLE users (id INTEGER);
+`),
+		"00006_backfill-names.go":        newMapFile("package migrations\n"),
+		"00006_backfill-names_test.go":   newMapFile("package migrations\n"),
+		"billing/00005_add_invoices.sql": newMapFile("-- +goose title Add invoices for the billing service\n-- +goose Up\nCREATE TABLE invoices (id INTEGER);\n"),
+		"billing/archive/00002_old.sql":  newMapFile("-- +goose Up\nSELECT 1;\n"),
+		"R__views.sql":                   newMapFile("CREATE VIEW v AS SELECT 1;\n"),
+		"notes.sql":                      newMapFile("-- not a migration\n"),
+	}
+	changelog, err := goose.GenerateChangelog(fsys, 3)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, changelog.Since)
+	require.Len(t, changelog.Scopes, 2)
+	require.Equal(t, "", changelog.Scopes[0].Scope)
+	require.Equal(t, []goose.ChangelogEntry{
+		{
+			Version: 4,
+			Source:  "00004_add_users_table.sql",
+			Title:   "add users table",
+			Notes:   "Stores the accounts of the new sign up flow.\n\nReplaces the legacy accounts table.",
+			Hints:   &goose.Hints{Affects: []string{"users"}, Impact: goose.ImpactHeavy},
+		},
+		{Version: 6, Source: "00006_backfill-names.go", Title: "backfill names"},
+	}, changelog.Scopes[0].Entries)
+	require.Equal(t, "billing", changelog.Scopes[1].Scope)
+	require.Len(t, changelog.Scopes[1].Entries, 1)
+	// A declared title takes precedence over the file name.
+	require.Equal(t, "Add invoices for the billing service", changelog.Scopes[1].Entries[0].Title)
+	require.Equal(t, `## Migrations since version 3
+
+- 4: add users table (impact: heavy; affects: users)
+  Stores the accounts of the new sign up flow.
+  Replaces the legacy accounts table.
+- 6: backfill names
+
+### billing
+
+- 5: Add invoices for the billing service
+`, changelog.String())
+
+	changelog, err = goose.GenerateChangelog(fsys, 6)
+	require.NoError(t, err)
+	require.Empty(t, changelog.Scopes)
+	require.Equal(t, "## Migrations since version 6\n\nNo migrations.\n", changelog.String())
+
+	fsys["00007_bad.sql"] = newMapFile("-- +goose impact huge\n-- +goose Up\nSELECT 1;\n")
+	_, err = goose.GenerateChangelog(fsys, 0)
+	require.ErrorContains(t, err, "invalid impact directive")
+}
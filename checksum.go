@@ -0,0 +1,91 @@
+package goose
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// checksumOptionRe matches the argument of a WithChecksum registration option in Go source.
+var checksumOptionRe = regexp.MustCompile(`WithChecksum\("[^"]*"\)`)
+
+// WithChecksum sets the checksum of a Go migration, as computed by [GoMigrationChecksum] from the
+// migration's source file. Go migrations are compiled into the binary, so unlike SQL migrations
+// their source cannot be hashed at runtime; the checksum is embedded in the registration instead.
+//
+// Checksums are recorded when migrations are applied with [WithRecordChecksums] and compared by
+// [Provider.Verify]. Run "goose checksum" to update embedded checksums after editing a migration.
+func WithChecksum(sum string) MigrationOption {
+	return func(cfg *MigrationConfig) {
+		cfg.Checksum = sum
+	}
+}
+
+// GoMigrationChecksum returns the checksum of a Go migration source file. The argument of any
+// WithChecksum option in the source is ignored, so a file can embed its own checksum.
+func GoMigrationChecksum(src []byte) string {
+	return sqlChecksum(checksumOptionRe.ReplaceAll(src, []byte(`WithChecksum("")`)))
+}
+
+// ChecksumAlgorithm is the hash function checksums of migrations are computed with, see
+// [WithChecksumAlgorithm].
+type ChecksumAlgorithm struct {
+	// Name identifies the algorithm, e.g., "sha256". It is recorded alongside each checksum, so that
+	// [Provider.Verify] recomputes recorded checksums with the algorithm they were recorded with.
+	Name string
+	// New returns a new hash computing the checksum.
+	New func() hash.Hash
+}
+
+// SHA256 returns the default checksum algorithm, SHA-256 from crypto/sha256. Built with
+// GOEXPERIMENT=boringcrypto or in FIPS 140-3 mode, crypto/sha256 uses the validated module.
+func SHA256() ChecksumAlgorithm {
+	return ChecksumAlgorithm{Name: "sha256", New: sha256.New}
+}
+
+// sqlChecksum returns the hex-encoded SHA-256 of a migration's contents.
+func sqlChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// UpdateChecksums rewrites the WithChecksum option of every Go migration in dir to match the
+// current contents of the file. Files without a WithChecksum option are left unchanged, so
+// embedding a checksum is opt-in: add goose.WithChecksum("") to the registration and run this
+// function, or "goose checksum", after every edit.
+func UpdateChecksums(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if _, err := NumericComponent(file); err != nil {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if !checksumOptionRe.Match(data) {
+			continue
+		}
+		option := fmt.Sprintf("WithChecksum(%q)", GoMigrationChecksum(data))
+		updated := checksumOptionRe.ReplaceAll(data, []byte(option))
+		if string(updated) == string(data) {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, updated, info.Mode()); err != nil {
+			return err
+		}
+		log.Printf("UPDATED %s\n", filepath.Base(file))
+	}
+	return nil
+}
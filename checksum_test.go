@@ -0,0 +1,54 @@
+package goose_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoMigrationChecksum(t *testing.T) {
+	t.Parallel()
+
+	src := `package migrations
+
+func init() {
+	goose.AddMigrationContext(up, down, goose.WithChecksum(%q))
+}
+`
+	// The embedded checksum does not affect the checksum of the file.
+	a := goose.GoMigrationChecksum([]byte(strings.Replace(src, "%q", `""`, 1)))
+	b := goose.GoMigrationChecksum([]byte(strings.Replace(src, "%q", `"abc"`, 1)))
+	require.Equal(t, a, b)
+	require.Len(t, a, 64)
+	c := goose.GoMigrationChecksum([]byte(strings.Replace(src, "%q", `""`, 1) + "// edited\n"))
+	require.NotEqual(t, a, c)
+}
+
+func TestUpdateChecksums(t *testing.T) {
+	// not using t.Parallel here to avoid races with the package-level logger
+	dir := t.TempDir()
+	withChecksum := []byte("package migrations\n\nvar _ = goose.WithChecksum(\"\")\n")
+	without := []byte("package migrations\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00001_a.go"), withChecksum, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.go"), without, 0644))
+
+	require.NoError(t, goose.UpdateChecksums(dir))
+	got, err := os.ReadFile(filepath.Join(dir, "00001_a.go"))
+	require.NoError(t, err)
+	require.Contains(t, string(got), `goose.WithChecksum("`+goose.GoMigrationChecksum(withChecksum)+`")`)
+	got, err = os.ReadFile(filepath.Join(dir, "00002_b.go"))
+	require.NoError(t, err)
+	require.Equal(t, without, got)
+
+	// Running again is a no-op.
+	before, err := os.ReadFile(filepath.Join(dir, "00001_a.go"))
+	require.NoError(t, err)
+	require.NoError(t, goose.UpdateChecksums(dir))
+	after, err := os.ReadFile(filepath.Join(dir, "00001_a.go"))
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+// gooseCI runs the ci command and reports whether its checks passed.
+func gooseCI(ctx context.Context, dir string, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, fmt.Errorf("ci must be of form: goose [OPTIONS] ci check [-base REF]")
+	}
+	switch args[0] {
+	case "check":
+		return ciCheck(ctx, dir, *base)
+	}
+	return false, fmt.Errorf("unknown ci command %q, must be one of: check", args[0])
+}
+
+// ciCheck compares the migrations in dir with the migrations released at the merge base of ref and
+// HEAD, using git, and reports whether the released migrations are unchanged. Released migrations
+// must not be edited, removed or renumbered, and new migrations must be newer than every released
+// migration, unless -allow-missing is set. Moving a migration unchanged, e.g., into the archive
+// directory, and editing repeatable migrations are allowed.
+func ciCheck(ctx context.Context, dir, ref string) (bool, error) {
+	if ref == "" {
+		return false, fmt.Errorf("-base must not be empty")
+	}
+	mergeBase, err := git(ctx, dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return false, err
+	}
+	mergeBase = strings.TrimSpace(mergeBase)
+	files, err := git(ctx, dir, "ls-tree", "-r", "--name-only", mergeBase, "--", ".")
+	if err != nil {
+		return false, err
+	}
+	released := make(map[string]int64)
+	var latest int64
+	for _, file := range strings.Split(strings.TrimSpace(files), "\n") {
+		if version, ok := ciVersion(file); ok {
+			released[file] = version
+			latest = max(latest, version)
+		}
+	}
+	// The changes include uncommitted edits of tracked files, so that the check can run before
+	// committing. Renames are matched below by version and contents instead of by similarity.
+	diff, err := git(ctx, dir, "diff", "--name-status", "--no-renames", "--relative", mergeBase, "--", ".")
+	if err != nil {
+		return false, err
+	}
+	var problems, removed, added []string
+	for _, line := range strings.Split(strings.TrimSpace(diff), "\n") {
+		status, file, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		switch status {
+		case "M", "T":
+			if _, ok := released[file]; ok {
+				problems = append(problems, fmt.Sprintf("%s: released migration was edited", file))
+			}
+		case "D":
+			if _, ok := released[file]; ok {
+				removed = append(removed, file)
+			}
+		case "A":
+			if _, ok := ciVersion(file); ok {
+				added = append(added, file)
+			}
+		}
+	}
+	// A removed migration may have been moved, e.g., into the archive directory, or renumbered.
+	moved := make(map[string]bool)
+	for _, file := range removed {
+		releasedHash, err := git(ctx, dir, "rev-parse", mergeBase+":./"+file)
+		if err != nil {
+			return false, err
+		}
+		var sameVersion, sameContents string
+		for _, other := range added {
+			if moved[other] {
+				continue
+			}
+			hash, err := git(ctx, dir, "hash-object", "--", other)
+			if err != nil {
+				return false, err
+			}
+			version, _ := ciVersion(other)
+			switch {
+			case version == released[file] && hash == releasedHash:
+				sameVersion, sameContents = other, other
+			case version == released[file] && sameVersion == "":
+				sameVersion = other
+			case hash == releasedHash && sameContents == "":
+				sameContents = other
+			}
+			if sameVersion != "" && sameVersion == sameContents {
+				break
+			}
+		}
+		switch {
+		case sameVersion != "" && sameVersion == sameContents:
+			moved[sameVersion] = true
+		case sameVersion != "":
+			moved[sameVersion] = true
+			problems = append(problems, fmt.Sprintf("%s: released migration was moved to %s and edited", file, sameVersion))
+		case sameContents != "":
+			moved[sameContents] = true
+			problems = append(problems, fmt.Sprintf("%s: released migration was renumbered to %s", file, sameContents))
+		default:
+			problems = append(problems, fmt.Sprintf("%s: released migration was removed", file))
+		}
+	}
+	for _, file := range added {
+		if version, _ := ciVersion(file); !moved[file] && version <= latest && !*allowMissing {
+			problems = append(problems, fmt.Sprintf("%s: new migration is older than released version %d, reordering applied history (use -allow-missing to allow)", file, latest))
+		}
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "goose ci check: error: %s\n", p)
+	}
+	if len(problems) == 0 {
+		fmt.Printf("goose: no released migrations changed since %s\n", ref)
+	}
+	return len(problems) == 0, nil
+}
+
+// ciVersion returns the version of the migration file at file, a path relative to -dir. Repeatable
+// migrations, which are meant to be edited, Go test files and other files are not migrations.
+func ciVersion(file string) (int64, bool) {
+	base := path.Base(file)
+	if strings.HasPrefix(base, "R__") || strings.HasSuffix(base, "_test.go") {
+		return 0, false
+	}
+	switch {
+	case strings.HasSuffix(base, ".go"),
+		strings.HasSuffix(base, ".sql"),
+		strings.HasSuffix(base, ".sql.gz"),
+		strings.HasSuffix(base, ".sql.zst"):
+	default:
+		return 0, false
+	}
+	version, err := goose.NumericComponent(base)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
+
+// git runs a git command in dir and returns its output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s failed: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+)
+
+// completeCommand is the hidden command the completion scripts call with the words typed so far,
+// the last of which is the word being completed. It prints one candidate per line. It must be the
+// first argument, since the words are not parsed as flags.
+const completeCommand = "__complete"
+
+// offlineCommands are the commands that run without a database, so they are completed in place of
+// the driver.
+var offlineCommands = []string{
+	"init", "create", "fix", "renumber", "import", "export", "checksum", "bundle", "env", "validate", "gaps", "ci", "changelog", "completion",
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for goose, add to ~/.bashrc:
+#   source <(goose completion bash)
+_goose_completions() {
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$(goose ` + completeCommand + ` "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)" -- "${COMP_WORDS[COMP_CWORD]}"))
+}
+complete -o default -F _goose_completions goose
+`,
+	"zsh": `#compdef goose
+# zsh completion for goose, add to ~/.zshrc:
+#   source <(goose completion zsh)
+_goose() {
+    local -a candidates
+    candidates=("${(@f)$(goose ` + completeCommand + ` "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+compdef _goose goose
+`,
+	"fish": `# fish completion for goose, add to ~/.config/fish/config.fish:
+#   goose completion fish | source
+function __goose_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    goose ` + completeCommand + ` $tokens[2..-1] 2>/dev/null
+end
+complete -c goose -a '(__goose_complete)'
+`,
+}
+
+// printCompletion prints the completion script for the given shell.
+func printCompletion(args []string) error {
+	shells := make([]string, 0, len(completionScripts))
+	for s := range completionScripts {
+		shells = append(shells, s)
+	}
+	sort.Strings(shells)
+	if len(args) == 0 {
+		return fmt.Errorf("completion must be of form: goose completion [%s]", strings.Join(shells, "|"))
+	}
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q, must be one of: %s", args[0], strings.Join(shells, ", "))
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// complete returns the candidates for the last of words, the words typed after goose. Flags,
+// drivers, commands, migration versions and scopes are completed; the migrations directory is
+// taken from -dir, if typed, or else from the env config.
+func complete(config *envConfig, words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, words := words[len(words)-1], words[:len(words)-1]
+	if current == "=" {
+		current = ""
+	}
+	migrationsDir := config.dir
+	var positional []string
+	var flagValue string
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if !strings.HasPrefix(w, "-") || w == "-" {
+			positional = append(positional, w)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(w, "-"), "=")
+		if !hasValue && !isBoolFlag(name) && flags.Lookup(name) != nil {
+			// Bash splits -dir=x into -dir, = and x.
+			if i+1 < len(words) && words[i+1] == "=" {
+				i++
+			}
+			if i+1 < len(words) {
+				i++
+				value = words[i]
+			} else {
+				flagValue = name
+			}
+		}
+		switch name {
+		case "dir":
+			migrationsDir = value
+		case "fixtures-dir":
+			*fixturesDir = value
+		}
+	}
+	var candidates []string
+	switch {
+	case flagValue == "scope", flagValue == "require-reversible":
+		candidates = listScopes(migrationsDir)
+	case flagValue == "severity":
+		candidates = []string{"warn", "error"}
+	case flagValue != "":
+		// Values of other flags, such as -dir, are left to the shell.
+	case strings.HasPrefix(current, "-"):
+		flags.VisitAll(func(f *flag.Flag) {
+			candidates = append(candidates, "-"+f.Name)
+		})
+	default:
+		candidates = completePositional(config, migrationsDir, positional)
+	}
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, current) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+func completePositional(config *envConfig, dir string, positional []string) []string {
+	commands := listCommands()
+	// The driver and the database string come first, unless they are set in the env config or the
+	// command runs without a database.
+	skip := 0
+	if config.driver == "" {
+		skip++
+	}
+	if config.dbstring == "" {
+		skip++
+	}
+	if len(positional) == 0 {
+		switch {
+		case config.driver == "":
+			return append(append([]string{}, offlineCommands...), listDrivers()...)
+		case skip > 0:
+			return offlineCommands
+		}
+		return commands
+	}
+	if !isOfflineCommand(positional[0]) {
+		if len(positional) < skip {
+			// Database strings are left to the shell.
+			return nil
+		}
+		if positional = positional[skip:]; len(positional) == 0 {
+			return commands
+		}
+	}
+	command, args := positional[0], positional[1:]
+	switch command {
+	case "up-to", "down-to", "redo-to":
+		if len(args) == 0 {
+			return listVersions(dir, command == "down-to")
+		}
+	case "create":
+		if len(args) == 1 {
+			return []string{"sql", "go"}
+		}
+	case "schema":
+		if len(args) == 0 {
+			return []string{"emit"}
+		}
+	case "ci":
+		if len(args) == 0 {
+			return []string{"check"}
+		}
+	case "fixtures":
+		switch len(args) {
+		case 0:
+			return []string{"apply", "reset", "status"}
+		case 1:
+			return listScopes(*fixturesDir)
+		}
+	case "completion":
+		if len(args) == 0 {
+			shells := make([]string, 0, len(completionScripts))
+			for s := range completionScripts {
+				shells = append(shells, s)
+			}
+			sort.Strings(shells)
+			return shells
+		}
+	}
+	return nil
+}
+
+// listCommands returns the commands documented in the usage.
+func listCommands() []string {
+	var commands []string
+	for _, line := range strings.Split(usageCommands, "\n") {
+		if !strings.HasPrefix(line, "    ") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) > 0 {
+			commands = append(commands, fields[0])
+		}
+	}
+	return commands
+}
+
+func listDrivers() []string {
+	var drivers []string
+	for _, line := range strings.Split(usagePrefix[strings.Index(usagePrefix, "Drivers:"):], "\n")[1:] {
+		if !strings.HasPrefix(line, "    ") {
+			break
+		}
+		drivers = append(drivers, strings.TrimSpace(line))
+	}
+	return drivers
+}
+
+// listVersions returns the versions of the migration files in dir, in ascending order. If zero is
+// true, version 0 is included, e.g., to roll back all migrations.
+func listVersions(dir string, zero bool) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var versions []int64
+	if zero {
+		versions = append(versions, 0)
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if v, err := goose.NumericComponent(e.Name()); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	out := make([]string, 0, len(versions))
+	for _, v := range versions {
+		out = append(out, strconv.FormatInt(v, 10))
+	}
+	return out
+}
+
+// listScopes returns the subdirectories of dir, which is where create places migrations of a
+// scope.
+func listScopes(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var scopes []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") && e.Name() != goose.ArchiveDir {
+			scopes = append(scopes, e.Name())
+		}
+	}
+	return scopes
+}
+
+func isOfflineCommand(command string) bool {
+	for _, c := range offlineCommands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+func isBoolFlag(name string) bool {
+	f := flags.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+)
+
+// gooseFixtures runs the fixtures command, which applies, resets or reports the fixture sets in
+// -fixtures-dir.
+func gooseFixtures(ctx context.Context, driver string, db *sql.DB, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("fixtures must be of form: goose [OPTIONS] DRIVER DBSTRING fixtures [apply|reset|status] [SET[,SET...]]")
+	}
+	var sets []string
+	for _, arg := range args[1:] {
+		for _, set := range strings.Split(arg, ",") {
+			if set = strings.TrimSpace(set); set != "" {
+				sets = append(sets, set)
+			}
+		}
+	}
+	fsys := os.DirFS(*fixturesDir)
+	dialect := goose.Dialect(driver)
+	var opts []goose.ProviderOption
+	if *verbose {
+		opts = append(opts, goose.WithVerbose(true))
+	}
+	switch args[0] {
+	case "apply":
+		results, err := goose.ApplyFixtures(ctx, dialect, db, fsys, sets, opts...)
+		printFixtureResults(results)
+		return err
+	case "reset":
+		results, err := goose.ResetFixtures(ctx, dialect, db, fsys, sets, opts...)
+		printFixtureResults(results)
+		return err
+	case "status":
+		statuses, err := goose.FixtureStatuses(ctx, dialect, db, fsys, sets, opts...)
+		if err != nil {
+			return err
+		}
+		return printFixtureStatuses(statuses)
+	}
+	return fmt.Errorf("unknown fixtures command %q, must be one of: apply, reset, status", args[0])
+}
+
+func printFixtureResults(results []*goose.FixtureResult) {
+	for _, r := range results {
+		if len(r.Results) == 0 {
+			fmt.Printf("%s: no migrations to run\n", r.Set)
+			continue
+		}
+		for _, result := range r.Results {
+			fmt.Printf("%s: %s\n", r.Set, result)
+		}
+	}
+}
+
+func printFixtureStatuses(statuses []*goose.FixtureStatus) error {
+	if *jsonOutput {
+		data, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode fixture status: %w", err)
+		}
+		fmt.Printf("%s\n", data)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Set\tApplied At\tMigration\t")
+	for _, s := range statuses {
+		for _, m := range s.Statuses {
+			appliedAt := "Pending"
+			if m.State == goose.StateApplied {
+				appliedAt = m.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t\n", s.Set, appliedAt, filepath.Base(m.Source.Path))
+		}
+	}
+	return w.Flush()
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -16,11 +17,14 @@ import (
 	"strings"
 	"text/tabwriter"
 	"text/template"
+	"time"
+	"unicode"
 
 	"github.com/joho/godotenv"
 	"github.com/mfridman/xflag"
 	"github.com/pressly/goose/v3"
 	"github.com/pressly/goose/v3/internal/migrationstats"
+	"github.com/pressly/goose/v3/policy"
 )
 
 var (
@@ -41,8 +45,35 @@ var (
 	noColor      = flags.Bool("no-color", false, "disable color output (NO_COLOR env variable supported)")
 	timeout      = flags.Duration("timeout", 0, "maximum allowed duration for queries to run; e.g., 1h13m")
 	envFile      = flags.String("env", "", "load environment variables from file (default .env)")
+	templateFile = flags.String("template", "", "file path to a custom template for new migrations (used by create)")
+	author       = flags.String("author", "", "author of new migrations, available as {{.Author}} in templates (used by create)")
+	ticket       = flags.String("ticket", "", "ticket reference of new migrations, available as {{.Ticket}} in templates (used by create)")
+	before       = flags.String("before", "", "archive migrations applied before this date, e.g., 2023-01-01 (used by archive)")
+	scope        = flags.String("scope", "", "scope of Go migrations; create places new migrations in the scope's subdirectory of -dir, freeze and unfreeze only affect the scope")
+	pending      = flags.Bool("pending", false, "show only pending migrations (used by status)")
+	last         = flags.Int("last", 0, "show only the last N migrations (used by status)")
+	since        = flags.String("since", "", "show only migrations applied since this date or RFC3339 timestamp (used by status), or newer than this version (used by changelog)")
+	jsonOutput   = flags.Bool("json", false, "print output as JSON (used by status, gaps, changelog, fixtures)")
+	output       = flags.String("o", "", "file to write the plan, bundle or schema to, e.g., plan.json (used by plan, bundle, schema)")
+	compare      = flags.String("compare", "", "DBSTRING of a second database, using the same driver, to compare applied migrations with (used by status)")
+	importFrom   = flags.String("from", "", "migration tool to import from: flyway, liquibase or golang-migrate (used by import)")
+	exportTo     = flags.String("to", "", "migration tool to export to: flyway or golang-migrate (used by export)")
+	fixturesDir  = flags.String("fixtures-dir", "fixtures", "directory with fixture sets, one subdirectory per set (used by fixtures)")
+	reversible   = flags.String("require-reversible", "", "comma-separated directories relative to -dir, e.g., .,billing, whose migrations must have Down statements (used by validate)")
+	severity     = flags.String("severity", "warn", "severity of migrations breaking -require-reversible: warn or error, which exits 1 (used by validate)")
+	base         = flags.String("base", "origin/main", "git ref the migrations are compared with; migrations present at its merge base with HEAD are released (used by ci check)")
+	discover     = flags.String("discover", "", "pattern of migration directories under -dir to run on, e.g., **/db/migrations, each with its own version table (used by validate, plan)")
+	policyPath   = flags.String("policy", "", "Rego policy file or directory evaluated with opa against the migrations, e.g., policy.rego (used by validate, plan)")
+	charset      = flags.String("require-charset", "", "charset, optionally followed by /collation, every MySQL CREATE TABLE must set, e.g., utf8mb4/utf8mb4_0900_ai_ci (used by validate, plan)")
+	redactLabels = flags.String("redact-label", "", "comma-separated keys of -label values recorded as [redacted], e.g., user,host (used by up, up-by-one, up-to)")
+	ignoreFreeze = flags.Bool("ignore-freeze", false, "apply migrations even though they are frozen with freeze (used by up, up-by-one, up-to)")
+	runLabels    = labelsFlag{}
 )
 
+func init() {
+	flags.Var(runLabels, "label", "label recorded with applied migrations as key=value, may be repeated (used by up, up-by-one, up-to)")
+}
+
 var version string
 
 func main() {
@@ -50,6 +81,14 @@ func main() {
 
 	flags.Usage = usage
 
+	if len(os.Args) > 1 && os.Args[1] == completeCommand {
+		// Best effort to load default .env file
+		_ = godotenv.Load()
+		for _, c := range complete(loadEnvConfig(), os.Args[2:]) {
+			fmt.Println(c)
+		}
+		return
+	}
 	if err := xflag.ParseToEnd(flags, os.Args[1:]); err != nil {
 		log.Fatalf("failed to parse args: %v", err)
 		return
@@ -110,7 +149,7 @@ func main() {
 		}
 		return
 	case "create":
-		if err := goose.RunContext(ctx, "create", nil, *dir, args[1:]...); err != nil {
+		if err := gooseCreate(*dir, args[1:]); err != nil {
 			log.Fatalf("goose run: %v", err)
 		}
 		return
@@ -119,15 +158,116 @@ func main() {
 			log.Fatalf("goose run: %v", err)
 		}
 		return
+	case "renumber":
+		// Without a database, only the files are renamed.
+		if envConfig.driver != "" {
+			break
+		}
+		if err := goose.RunContext(ctx, "renumber", nil, *dir); err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	case "import":
+		// Without a database, only the files are converted.
+		if envConfig.driver != "" {
+			break
+		}
+		if err := goose.RunWithOptionsContext(ctx, "import", nil, *dir, args[1:], goose.WithImportFrom(goose.ImportFormat(*importFrom))); err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	case "export":
+		// Without a database, only the files are converted.
+		if envConfig.driver != "" {
+			break
+		}
+		if err := goose.RunWithOptionsContext(ctx, "export", nil, *dir, args[1:], goose.WithExportTo(goose.ExportFormat(*exportTo))); err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	case "bundle":
+		arguments := args[1:]
+		if *output != "" {
+			arguments = append([]string{*output}, arguments...)
+		}
+		if err := goose.RunWithOptionsContext(ctx, "bundle", nil, *dir, arguments); err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	case "checksum":
+		if err := goose.RunContext(ctx, "checksum", nil, *dir); err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	case "completion":
+		if err := printCompletion(args[1:]); err != nil {
+			log.Fatalf("goose completion: %v", err)
+		}
+		return
 	case "env":
 		for _, env := range envConfig.listEnvs() {
 			fmt.Printf("%s=%q\n", env.Name, env.Value)
 		}
 		return
 	case "validate":
-		if err := printValidate(*dir, *verbose); err != nil {
+		reversiblePolicy, err := newReversiblePolicy(*reversible, *severity)
+		if err != nil {
+			log.Fatalf("goose validate: %v", err)
+		}
+		p, err := newPolicy()
+		if err != nil {
 			log.Fatalf("goose validate: %v", err)
 		}
+		targets := []goose.MigrationDir{{Dir: "."}}
+		if *discover != "" {
+			if targets, err = discoverDirs(*dir, *discover); err != nil {
+				log.Fatalf("goose validate: %v", err)
+			}
+		}
+		ok := true
+		for _, t := range targets {
+			migrationsDir := filepath.Join(*dir, t.Dir)
+			if *discover != "" {
+				fmt.Printf("goose: validating %s (scope %q)\n", migrationsDir, t.Scope)
+			}
+			valid, err := printValidate(migrationsDir, *verbose, reversiblePolicy)
+			if err != nil {
+				log.Fatalf("goose validate: %v", err)
+			}
+			if p != nil && !printPolicyViolations("validate", goose.EvaluatePolicy(ctx, os.DirFS(migrationsDir), p)) {
+				valid = false
+			}
+			if !printExtensions(migrationsDir) {
+				valid = false
+			}
+			ok = ok && valid
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	case "gaps":
+		ok, err := printGaps(*dir, *jsonOutput)
+		if err != nil {
+			log.Fatalf("goose gaps: %v", err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	case "changelog":
+		if err := printChangelog(*dir, *since, *jsonOutput); err != nil {
+			log.Fatalf("goose changelog: %v", err)
+		}
+		return
+	case "ci":
+		ok, err := gooseCI(ctx, *dir, args[1:])
+		if err != nil {
+			log.Fatalf("goose ci: %v", err)
+		}
+		if !ok {
+			os.Exit(1)
+		}
 		return
 	case "beta":
 		remain := args[1:]
@@ -149,6 +289,12 @@ func main() {
 	}
 
 	driver, dbstring, command := args[0], args[1], args[2]
+	if command == "create" {
+		if err := gooseCreate(*dir, args[3:]); err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	}
 	db, err := goose.OpenDBWithDriver(driver, normalizeDBString(driver, dbstring, *certfile, *sslcert, *sslkey))
 	if err != nil {
 		log.Fatalf("-dbstring=%q: %v\n", dbstring, err)
@@ -160,6 +306,12 @@ func main() {
 	}()
 
 	arguments := []string{}
+	if command == "archive" && *before != "" {
+		arguments = append(arguments, *before)
+	}
+	if command == "plan" && *output != "" {
+		arguments = append(arguments, *output)
+	}
 	if len(args) > 3 {
 		arguments = append(arguments, args[3:]...)
 	}
@@ -173,11 +325,83 @@ func main() {
 	if *noVersioning {
 		options = append(options, goose.WithNoVersioning())
 	}
+	if *scope != "" {
+		options = append(options, goose.WithOptionScope(*scope))
+	}
+	if *ignoreFreeze {
+		options = append(options, goose.WithOptionIgnoreFreeze())
+	}
+	if len(runLabels) > 0 {
+		options = append(options, goose.WithOptionRunLabels(runLabels))
+	}
+	if *redactLabels != "" {
+		options = append(options, goose.WithOptionLabelProtection(&goose.LabelProtection{
+			Redact: strings.Split(*redactLabels, ","),
+		}))
+	}
+	if *importFrom != "" {
+		options = append(options, goose.WithImportFrom(goose.ImportFormat(*importFrom)))
+	}
+	if *exportTo != "" {
+		options = append(options, goose.WithExportTo(goose.ExportFormat(*exportTo)))
+	}
+	if command == "plan" {
+		p, err := newPolicy()
+		if err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		if p != nil {
+			options = append(options, goose.WithOptionPolicy(p))
+		}
+	}
+	if command == "status" {
+		opts, err := statusOptions()
+		if err != nil {
+			log.Fatalf("goose run: %v", err)
+		}
+		options = append(options, opts...)
+		if *compare != "" {
+			other, err := goose.OpenDBWithDriver(driver, normalizeDBString(driver, *compare, *certfile, *sslcert, *sslkey))
+			if err != nil {
+				log.Fatalf("-compare=%q: %v\n", *compare, err)
+			}
+			defer other.Close()
+			options = append(options, goose.WithStatusCompare(other))
+		}
+	}
 	if timeout != nil && *timeout != 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, *timeout)
 		defer cancel()
 	}
+	if command == "ui" {
+		if err := gooseUI(ctx, driver, db); err != nil {
+			log.Fatalf("goose ui: %v", err)
+		}
+		return
+	}
+	if command == "schema" {
+		if err := gooseSchema(ctx, driver, dbstring, db, args[3:]); err != nil {
+			log.Fatalf("goose schema: %v", err)
+		}
+		return
+	}
+	if command == "plan" && *discover != "" {
+		if err := planDirs(ctx, db, *dir, *discover, *output, options); err != nil {
+			if errors.Is(err, goose.ErrPolicyViolation) {
+				printPolicyViolations(command, err)
+				os.Exit(1)
+			}
+			log.Fatalf("goose run: %v", err)
+		}
+		return
+	}
+	if command == "fixtures" {
+		if err := gooseFixtures(ctx, driver, db, args[3:]); err != nil {
+			log.Fatalf("goose fixtures: %v", err)
+		}
+		return
+	}
 	if err := goose.RunWithOptionsContext(
 		ctx,
 		command,
@@ -186,6 +410,10 @@ func main() {
 		arguments,
 		options...,
 	); err != nil {
+		if command == "plan" && errors.Is(err, goose.ErrPolicyViolation) {
+			printPolicyViolations(command, err)
+			os.Exit(1)
+		}
 		log.Fatalf("goose run: %v", err)
 	}
 }
@@ -303,13 +531,33 @@ Commands:
     up-to VERSION        Migrate the DB to a specific VERSION
     down                 Roll back the version by 1
     down-to VERSION      Roll back to a specific VERSION
-    redo                 Re-run the latest migration
+    redo [N]             Re-run the latest migration, or the latest N migrations
+    redo-to VERSION      Roll back to a specific VERSION, then re-run the rolled back migrations
     reset                Roll back all migrations
     status               Dump the migration status for the current DB
     version              Print the current version of the database
+    archive [YYYY-MM-DD] Move SQL migrations applied before a date (or -before) to ./archive
+    plan [-o FILE]       Write the pending migrations and their checksums to a plan file, or stdout
+    apply PLAN           Apply a plan file, refusing to run if the database or migrations changed
+    bundle [-o FILE]     Write the rendered statements of all SQL migrations to a bundle file, or stdout, without a database
+    apply-bundle BUNDLE  Apply the pending migrations of a bundle file, refusing to run if it was modified
     create NAME [sql|go] Creates new migration file with the current timestamp
     fix                  Apply sequential ordering to migrations
-    validate             Check migration files without running them
+    renumber             Renumber all migrations sequentially, updating the version table if DBSTRING is set
+    import SRC_DIR       Convert flyway, liquibase or golang-migrate migrations (-from) into -dir, recording their applied history if DBSTRING is set
+    export DST_DIR       Convert the SQL migrations in -dir into flyway or golang-migrate migrations (-to), recording their applied history if DBSTRING is set
+    freeze REASON        Stop up, up-by-one, up-to and redo from applying migrations of all scopes, or the -scope scope, until unfreeze
+    unfreeze             Lift the freeze of all scopes, or of the -scope scope
+    partitions           Create future and expire old partitions of the tables declared with -- +goose partition (Postgres)
+    checksum             Update the checksums embedded in Go migrations with goose.WithChecksum
+    validate             Check migration files without running them, and their Down sections with -require-reversible
+    gaps                 Report version gaps, duplicate versions and unparsable files, exit 1 if any
+    ci check             Fail if released migrations were edited, removed or reordered since the merge base with -base, using git
+    changelog            Print release notes of the migrations newer than -since VERSION, grouped by scope
+    ui                   Browse the migration history, pending migrations and problems interactively
+    fixtures CMD [SETS]  Apply, reset or show the status of fixture sets from -fixtures-dir, e.g., apply minimal,demo
+    schema emit [FILE]   Apply all migrations to DBSTRING, e.g., :memory:, and write its schema for sqlc (or -o)
+    completion SHELL     Print the shell completion script for bash, zsh or fish
 `
 )
 
@@ -359,6 +607,77 @@ func gooseInit(dir string) error {
 	return goose.CreateWithTemplate(nil, dir, sqlMigrationTemplate, "initial", "sql")
 }
 
+// gooseCreate creates a new migration file, applying the create-related flags.
+func gooseCreate(dir string, args []string) error {
+	if len(args) == 0 {
+		return errors.New("create must be of form: goose [OPTIONS] DRIVER DBSTRING create NAME [go|sql]")
+	}
+	migrationType := "go"
+	if len(args) == 2 {
+		migrationType = args[1]
+	}
+	opts := []goose.CreateOption{
+		goose.WithCreateAuthor(*author),
+		goose.WithCreateTicket(*ticket),
+		goose.WithCreateScope(*scope),
+	}
+	if *templateFile != "" {
+		tmpl, err := template.ParseFiles(*templateFile)
+		if err != nil {
+			return fmt.Errorf("failed to parse template: %w", err)
+		}
+		opts = append(opts, goose.WithCreateTemplate(tmpl))
+	}
+	return goose.CreateWithOptions(dir, args[0], migrationType, opts...)
+}
+
+// statusOptions returns the options for the status command, applying the status-related flags.
+func statusOptions() ([]goose.OptionsFunc, error) {
+	filter := goose.StatusFilter{
+		OnlyPending: *pending,
+		Last:        *last,
+	}
+	if *since != "" {
+		t, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, *since); err != nil {
+				return nil, fmt.Errorf("-since must be a date (YYYY-MM-DD) or RFC3339 timestamp (got %q)", *since)
+			}
+		}
+		filter.Since = t
+	}
+	opts := []goose.OptionsFunc{goose.WithStatusFilter(filter)}
+	if *jsonOutput {
+		opts = append(opts, goose.WithStatusJSON())
+	}
+	return opts, nil
+}
+
+// labelsFlag collects repeated key=value flags.
+type labelsFlag map[string]string
+
+func (l labelsFlag) String() string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+l[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l labelsFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("label must be in the form key=value (got %q)", s)
+	}
+	l[k] = v
+	return nil
+}
+
 func gatherFilenames(filename string) ([]string, error) {
 	stat, err := os.Stat(filename)
 	if err != nil {
@@ -380,27 +699,235 @@ func gatherFilenames(filename string) ([]string, error) {
 	return filenames, nil
 }
 
-func printValidate(filename string, verbose bool) error {
-	filenames, err := gatherFilenames(filename)
+// discoverDirs returns the migration directories under root matching pattern, see -discover.
+func discoverDirs(root, pattern string) ([]goose.MigrationDir, error) {
+	dirs, err := goose.DiscoverMigrationDirs(os.DirFS(root), pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("no migration directories under %s match -discover %q", root, pattern)
+	}
+	return dirs, nil
+}
+
+// discoveredPlan is the plan of a migration directory found with -discover.
+type discoveredPlan struct {
+	goose.MigrationDir
+	// Table is the version table of the directory, see targetTable.
+	Table string      `json:"table"`
+	Plan  *goose.Plan `json:"plan"`
+}
+
+// planDirs creates the plan of every migration directory under root matching pattern. Each
+// directory tracks its migrations in its own version table, see targetTable. The plans are
+// printed as JSON, or written to one file per directory if output is set, see targetFile.
+func planDirs(ctx context.Context, db *sql.DB, root, pattern, output string, options []goose.OptionsFunc) error {
+	dirs, err := discoverDirs(root, pattern)
 	if err != nil {
 		return err
 	}
+	defer goose.SetTableName(*table)
+	plans := make([]discoveredPlan, 0, len(dirs))
+	for _, d := range dirs {
+		t := targetTable(*table, d.Scope)
+		goose.SetTableName(t)
+		opts := append(options[:len(options):len(options)], goose.WithOptionScope(d.Scope))
+		plan, err := goose.CreatePlan(ctx, db, filepath.Join(root, d.Dir), opts...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", d.Dir, err)
+		}
+		plans = append(plans, discoveredPlan{MigrationDir: d, Table: t, Plan: plan})
+	}
+	if output == "" {
+		data, err := json.MarshalIndent(plans, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plans: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, p := range plans {
+		data, err := json.MarshalIndent(p.Plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		file := targetFile(output, p.Scope)
+		if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+		fmt.Printf("goose: wrote plan with %d migrations of %s to %s, apply with -dir %s -table %s\n",
+			len(p.Plan.Migrations), filepath.Join(root, p.Dir), file, filepath.Join(root, p.Dir), p.Table)
+	}
+	return nil
+}
+
+// targetTable returns the version table of the migrations of scope, table suffixed with the
+// scope, e.g., goose_db_version_services_billing, or table itself for the root scope.
+func targetTable(table, scope string) string {
+	if scope == "" {
+		return table
+	}
+	return table + "_" + scopeSuffix(scope)
+}
+
+// targetFile returns the file the plan of scope is written to, output with the scope inserted
+// before its extension, e.g., plan.services_billing.json.
+func targetFile(output, scope string) string {
+	if scope == "" {
+		return output
+	}
+	ext := filepath.Ext(output)
+	return strings.TrimSuffix(output, ext) + "." + scopeSuffix(scope) + ext
+}
+
+// scopeSuffix returns scope with every character other than letters and digits replaced with an
+// underscore, to use it in table and file names.
+func scopeSuffix(scope string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, scope)
+}
+
+// newPolicy returns the policy set with -policy and -require-charset, or nil if neither is set.
+func newPolicy() (policy.Policy, error) {
+	var policies []policy.Policy
+	if *policyPath != "" {
+		p, err := policy.NewOPA(*policyPath)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	if *charset != "" {
+		cs, collation, _ := strings.Cut(*charset, "/")
+		policies = append(policies, policy.RequireTableCharset("require-charset", cs, collation))
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	return policy.All(policies...), nil
+}
+
+// printPolicyViolations prints the violations of a PolicyError as errors of command and reports
+// whether err is nil. Other errors are fatal.
+func printPolicyViolations(command string, err error) bool {
+	var policyErr *goose.PolicyError
+	if err == nil {
+		return true
+	}
+	if !errors.As(err, &policyErr) {
+		log.Fatalf("goose %s: %v", command, err)
+	}
+	for _, v := range policyErr.Violations {
+		fmt.Fprintf(os.Stderr, "goose %s: error: %s\n", command, v)
+	}
+	return false
+}
+
+// reversiblePolicy requires the migrations in some directories to have Down statements.
+type reversiblePolicy struct {
+	// dirs are the directories, relative to -dir, the policy applies to, including their
+	// subdirectories. "." is the root of -dir.
+	dirs []string
+	// fail is true if breaking the policy is an error rather than a warning.
+	fail bool
+}
+
+func newReversiblePolicy(dirs, severity string) (reversiblePolicy, error) {
+	var policy reversiblePolicy
+	switch severity {
+	case "warn":
+	case "error":
+		policy.fail = true
+	default:
+		return policy, fmt.Errorf("-severity must be warn or error: %q", severity)
+	}
+	for _, d := range strings.Split(dirs, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			policy.dirs = append(policy.dirs, filepath.Clean(d))
+		}
+	}
+	return policy, nil
+}
+
+// covers reports whether the policy applies to the migration at rel, relative to -dir.
+func (p reversiblePolicy) covers(rel string) bool {
+	dir := filepath.Dir(rel)
+	for _, d := range p.dirs {
+		if d == "." || dir == d || strings.HasPrefix(dir, d+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// printValidate checks the migration files at filename, a directory or a single file, and reports
+// whether they satisfy the reversible policy. Migrations breaking the policy are printed as
+// warnings, or as errors if the policy fails.
+func printValidate(filename string, verbose bool, policy reversiblePolicy) (bool, error) {
+	filenames, err := gatherFilenames(filename)
+	if err != nil {
+		return false, err
+	}
+	for _, d := range policy.dirs {
+		if d == "." {
+			continue
+		}
+		// Scopes live in subdirectories, which are only validated if a policy covers them.
+		more, err := gatherFilenames(filepath.Join(filename, d))
+		if err != nil {
+			return false, err
+		}
+		filenames = append(filenames, more...)
+	}
 	stats, err := migrationstats.GatherStats(
 		migrationstats.NewFileWalker(filenames...),
 		false,
 	)
 	if err != nil {
-		return err
+		return false, err
+	}
+	name := func(m *migrationstats.Stats) string {
+		if rel, err := filepath.Rel(filename, m.FileName); err == nil && rel != "." {
+			return rel
+		}
+		return filepath.Base(m.FileName)
+	}
+	ok := true
+	level := "warning"
+	if policy.fail {
+		level = "error"
+	}
+	for _, m := range stats {
+		r := m.Reversibility()
+		if r == migrationstats.Reversible || !policy.covers(name(m)) {
+			continue
+		}
+		reason := "no Down section"
+		switch {
+		case r == migrationstats.EmptyDown:
+			reason = "Down section has no statements"
+		case filepath.Ext(m.FileName) == ".go":
+			reason = "no down function"
+		}
+		fmt.Fprintf(os.Stderr, "goose validate: %s: %s (%s): %s\n", level, name(m), r, reason)
+		if policy.fail {
+			ok = false
+		}
 	}
 	// TODO(mf): we should introduce a --debug flag, which allows printing
 	// more internal debug information and leave verbose for additional information.
 	if !verbose {
-		return nil
+		return ok, nil
 	}
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', tabwriter.TabIndent)
-	fmtPattern := "%v\t%v\t%v\t%v\t%v\t\n"
-	fmt.Fprintf(w, fmtPattern, "Type", "Txn", "Up", "Down", "Name")
-	fmt.Fprintf(w, fmtPattern, "────", "───", "──", "────", "────")
+	fmtPattern := "%v\t%v\t%v\t%v\t%v\t%v\t\n"
+	fmt.Fprintf(w, fmtPattern, "Type", "Txn", "Up", "Down", "Rollback", "Name")
+	fmt.Fprintf(w, fmtPattern, "────", "───", "──", "────", "────────", "────")
 	for _, m := range stats {
 		txnStr := "✔"
 		if !m.Tx {
@@ -411,10 +938,75 @@ func printValidate(filename string, verbose bool) error {
 			txnStr,
 			m.UpCount,
 			m.DownCount,
-			filepath.Base(m.FileName),
+			m.Reversibility(),
+			name(m),
 		)
 	}
-	return w.Flush()
+	return ok, w.Flush()
+}
+
+// printExtensions prints the Postgres extensions required by the migrations in dir, and reports
+// whether their extension directives are valid.
+func printExtensions(dir string) bool {
+	extensions, err := goose.RequiredExtensions(os.DirFS(dir), ".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "goose validate: error: %v\n", err)
+		return false
+	}
+	if len(extensions) == 0 {
+		return true
+	}
+	required := make([]string, 0, len(extensions))
+	for _, e := range extensions {
+		required = append(required, fmt.Sprintf("%s (%s)", e, e.Source))
+	}
+	fmt.Printf("goose validate: requires extensions: %s\n", strings.Join(required, ", "))
+	return true
+}
+
+// printGaps prints the gap report for the migrations in dir and reports whether it found no
+// problems.
+func printGaps(dir string, jsonOutput bool) (bool, error) {
+	report, err := goose.ReportGaps(os.DirFS(dir))
+	if err != nil {
+		return false, err
+	}
+	if jsonOutput {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		fmt.Println(string(data))
+	} else if report.OK() {
+		fmt.Println("goose: no gaps, duplicates or unparsable migration files")
+	} else {
+		fmt.Print(report)
+	}
+	return report.OK(), nil
+}
+
+func printChangelog(dir, since string, jsonOutput bool) error {
+	var version int64
+	if since != "" {
+		var err error
+		if version, err = strconv.ParseInt(since, 10, 64); err != nil {
+			return fmt.Errorf("-since must be a version: %q", since)
+		}
+	}
+	changelog, err := goose.GenerateChangelog(os.DirFS(dir), version)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		data, err := json.MarshalIndent(changelog, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Print(changelog)
+	return nil
 }
 
 type envConfig struct {
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/schema"
+)
+
+// gooseSchema runs the schema command, which applies the migrations in -dir to the database and
+// writes its schema to a file, or to stdout.
+func gooseSchema(ctx context.Context, driver, dbstring string, db *sql.DB, args []string) error {
+	if len(args) == 0 || args[0] != "emit" || len(args) > 2 {
+		return fmt.Errorf("schema must be of form: goose [OPTIONS] DRIVER DBSTRING schema emit [FILE]")
+	}
+	var dumper schema.Dumper
+	switch driver {
+	case "sqlite3", "sqlite", "turso":
+		// Each connection to an in-memory database has its own database.
+		db.SetMaxOpenConns(1)
+		dumper = schema.NewSQLite()
+	case "mysql", "tidb":
+		dumper = schema.NewMySQL()
+	case "postgres", "pgx":
+		var err error
+		if dumper, err = schema.NewPgDump(dbstring); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("emitting the schema is not supported by the %s driver", driver)
+	}
+	opts := []goose.ProviderOption{goose.WithTableName(*table)}
+	if *verbose {
+		opts = append(opts, goose.WithVerbose(true))
+	}
+	p, err := goose.NewProvider(goose.Dialect(driver), db, os.DirFS(*dir), opts...)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := p.EmitSchema(ctx, &buf, dumper); err != nil {
+		return err
+	}
+	file := *output
+	if len(args) == 2 {
+		file = args[1]
+	}
+	if file == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(file, buf.Bytes(), 0644)
+}
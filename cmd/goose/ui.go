@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/internal/migrationstats"
+)
+
+const uiHelp = `Commands:
+    a       list all migrations
+    p       list pending migrations
+    d       list applied (done) migrations
+    e       list problems: out-of-order pending migrations and modified applied migrations
+    N       show details of migration N from the list
+    r       reload from the database
+    q       quit
+`
+
+// gooseUI runs the interactive status browser on the terminal, for the migrations in -dir.
+func gooseUI(ctx context.Context, driver string, db *sql.DB) error {
+	opts := []goose.ProviderOption{goose.WithTableName(*table)}
+	if *noVersioning {
+		opts = append(opts, goose.WithDisableVersioning(true))
+	}
+	p, err := goose.NewProvider(goose.Dialect(driver), db, os.DirFS(*dir), opts...)
+	if err != nil {
+		return err
+	}
+	return runUI(ctx, p, *dir, os.Stdin, os.Stdout)
+}
+
+// statusUI is an interactive browser of the migration status of a provider.
+type statusUI struct {
+	p   *goose.Provider
+	dir string
+	out io.Writer
+
+	statuses  []*goose.MigrationStatus
+	dbVersion int64
+	// listed are the migrations of the last list, numbered from 1.
+	listed []*goose.MigrationStatus
+}
+
+// runUI reads commands from in and writes views to out, until the user quits or in is closed.
+func runUI(ctx context.Context, p *goose.Provider, dir string, in io.Reader, out io.Writer) error {
+	ui := &statusUI{p: p, dir: dir, out: out}
+	if err := ui.reload(ctx); err != nil {
+		return err
+	}
+	ui.list("all")
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "goose> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		cmd := strings.TrimSpace(scanner.Text())
+		switch cmd {
+		case "":
+		case "q", "quit", "exit":
+			return nil
+		case "a":
+			ui.list("all")
+		case "p":
+			ui.list("pending")
+		case "d":
+			ui.list("applied")
+		case "e":
+			ui.problems(ctx)
+		case "r":
+			if err := ui.reload(ctx); err != nil {
+				return err
+			}
+			ui.list("all")
+		case "?", "h", "help":
+			fmt.Fprint(out, uiHelp)
+		default:
+			n, err := strconv.Atoi(cmd)
+			if err != nil || n < 1 || n > len(ui.listed) {
+				fmt.Fprintf(out, "unknown command %q, type ? for help\n", cmd)
+				continue
+			}
+			ui.details(ui.listed[n-1])
+		}
+	}
+}
+
+func (ui *statusUI) reload(ctx context.Context) error {
+	statuses, err := ui.p.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+	dbVersion, err := ui.p.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database version: %w", err)
+	}
+	ui.statuses, ui.dbVersion = statuses, dbVersion
+	return nil
+}
+
+func (ui *statusUI) list(filter string) {
+	var applied int
+	ui.listed = ui.listed[:0]
+	for _, s := range ui.statuses {
+		if s.State == goose.StateApplied {
+			applied++
+		}
+		if filter == "all" || string(s.State) == filter {
+			ui.listed = append(ui.listed, s)
+		}
+	}
+	fmt.Fprintf(ui.out, "database version %d: %d applied, %d pending\n\n",
+		ui.dbVersion, applied, len(ui.statuses)-applied)
+	if len(ui.listed) == 0 {
+		fmt.Fprintf(ui.out, "no %s migrations\n\n", filter)
+		return
+	}
+	w := tabwriter.NewWriter(ui.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tState\tApplied At\tMigration\t")
+	for i, s := range ui.listed {
+		appliedAt := ""
+		if s.State == goose.StateApplied {
+			appliedAt = s.AppliedAt.Format(time.DateTime)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t\n", i+1, s.State, appliedAt, filepath.Base(s.Source.Path))
+	}
+	w.Flush()
+	fmt.Fprintln(ui.out, "\ntype a number for details, ? for help")
+}
+
+func (ui *statusUI) details(s *goose.MigrationStatus) {
+	w := tabwriter.NewWriter(ui.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Version:\t%d\n", s.Source.Version)
+	fmt.Fprintf(w, "Source:\t%s\n", s.Source.Path)
+	fmt.Fprintf(w, "Type:\t%s\n", s.Source.Type)
+	fmt.Fprintf(w, "State:\t%s\n", s.State)
+	if s.State == goose.StateApplied {
+		age := time.Since(s.AppliedAt).Round(time.Second)
+		fmt.Fprintf(w, "Applied At:\t%s (%s ago)\n", s.AppliedAt.Format(time.RFC3339), age)
+	}
+	if len(s.Labels) > 0 {
+		keys := make([]string, 0, len(s.Labels))
+		for k := range s.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			name := ""
+			if i == 0 {
+				name = "Labels:"
+			}
+			fmt.Fprintf(w, "%s\t%s=%s\n", name, k, s.Labels[k])
+		}
+	}
+	if s.Source.Type == goose.TypeSQL {
+		stats, err := migrationstats.GatherStats(
+			migrationstats.NewFileWalker(filepath.Join(ui.dir, s.Source.Path)),
+			false,
+		)
+		if err != nil {
+			fmt.Fprintf(w, "Statements:\tfailed to parse: %v\n", err)
+		} else if len(stats) == 1 {
+			fmt.Fprintf(w, "Statements:\t%d up, %d down\n", stats[0].UpCount, stats[0].DownCount)
+			fmt.Fprintf(w, "Transaction:\t%t\n", stats[0].Tx)
+		}
+	}
+	w.Flush()
+	fmt.Fprintln(ui.out)
+}
+
+func (ui *statusUI) problems(ctx context.Context) {
+	var found bool
+	for _, s := range ui.statuses {
+		if s.State == goose.StatePending && s.Source.Version < ui.dbVersion {
+			fmt.Fprintf(ui.out, "out of order: %s is pending below database version %d\n",
+				filepath.Base(s.Source.Path), ui.dbVersion)
+			found = true
+		}
+	}
+	if err := ui.p.Verify(ctx); err != nil {
+		if !errors.Is(err, goose.ErrChecksumMismatch) {
+			err = fmt.Errorf("failed to verify migrations: %w", err)
+		}
+		fmt.Fprintf(ui.out, "%v\n", err)
+		found = true
+	}
+	if !found {
+		fmt.Fprintln(ui.out, "no problems found")
+	}
+	fmt.Fprintln(ui.out)
+}
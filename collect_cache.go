@@ -0,0 +1,116 @@
+package goose
+
+import (
+	"io/fs"
+	"path"
+	"sync"
+	"time"
+)
+
+// CollectCache caches the migration files found in directories and the annotations, such as
+// hints, parsed from them, so repeated calls, such as [Provider.Status] or [Status] on every health
+// check, do not read every migration file again. Use it with [WithCollectCache] or
+// [WithOptionCollectCache].
+//
+// Entries are invalidated by modification time: a directory is listed again once its modification
+// time changes, which happens when files are added, removed or renamed, and a file is read again
+// once its modification time or size changes. Files of filesystems without modification times,
+// such as an [embed.FS], cannot change and are read once.
+//
+// A CollectCache must only be used with a single filesystem. It is safe for concurrent use.
+type CollectCache struct {
+	mu          sync.Mutex
+	globs       map[string]cachedGlob
+	annotations map[string]cachedAnnotations
+}
+
+type cachedGlob struct {
+	modTime time.Time
+	matches []string
+}
+
+type cachedAnnotations struct {
+	modTime     time.Time
+	size        int64
+	annotations annotations
+}
+
+// NewCollectCache returns an empty CollectCache.
+func NewCollectCache() *CollectCache {
+	return &CollectCache{
+		globs:       make(map[string]cachedGlob),
+		annotations: make(map[string]cachedAnnotations),
+	}
+}
+
+// Reset forgets all cached entries.
+func (c *CollectCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.globs)
+	clear(c.annotations)
+}
+
+// glob returns the files of dir matching pattern, like [fs.Glob] of path.Join(dir, pattern). A nil
+// cache does not cache.
+func (c *CollectCache) glob(fsys fs.FS, dir, pattern string) ([]string, error) {
+	if c == nil {
+		return fs.Glob(fsys, path.Join(dir, pattern))
+	}
+	info, err := fs.Stat(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	key := path.Join(dir, pattern)
+	c.mu.Lock()
+	cached, ok := c.globs[key]
+	c.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		return append([]string(nil), cached.matches...), nil
+	}
+	matches, err := fs.Glob(fsys, key)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.globs[key] = cachedGlob{modTime: info.ModTime(), matches: matches}
+	c.mu.Unlock()
+	return append([]string(nil), matches...), nil
+}
+
+// readAnnotations returns the annotations of the migration at path, see [readAnnotations]. A nil
+// cache does not cache.
+func (c *CollectCache) readAnnotations(fsys fs.FS, path string) (annotations, error) {
+	if c == nil || !isSQLFile(path) {
+		return readAnnotations(fsys, path)
+	}
+	info, err := fs.Stat(fsys, path)
+	if err != nil {
+		// Let readAnnotations report the error.
+		return readAnnotations(fsys, path)
+	}
+	c.mu.Lock()
+	cached, ok := c.annotations[path]
+	c.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.annotations.clone(), nil
+	}
+	a, err := readAnnotations(fsys, path)
+	if err != nil {
+		return annotations{}, err
+	}
+	c.mu.Lock()
+	c.annotations[path] = cachedAnnotations{modTime: info.ModTime(), size: info.Size(), annotations: a}
+	c.mu.Unlock()
+	return a.clone(), nil
+}
+
+// cloneHints returns a copy of h, so callers cannot modify cached hints.
+func cloneHints(h *Hints) *Hints {
+	if h == nil {
+		return nil
+	}
+	clone := *h
+	clone.Affects = append([]string(nil), h.Affects...)
+	return &clone
+}
@@ -0,0 +1,142 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestCollectCache(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile := func(name, data string, modTime time.Time) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	// Modification times are set explicitly, so the test does not depend on their resolution.
+	setDirTime := func(modTime time.Time) {
+		t.Helper()
+		require.NoError(t, os.Chtimes(dir, modTime, modTime))
+	}
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeFile("00001_users.sql", "-- +goose title Users\n-- +goose affects users\n-- +goose Up\nSELECT 1;\n", t0)
+	writeFile("00002_orders.sql", "-- +goose Up\nSELECT 2;\n", t0)
+	setDirTime(t0)
+
+	cache := NewCollectCache()
+	collect := func() Migrations {
+		t.Helper()
+		migrations, err := collectMigrationsCached(cache, "", osFS{}, dir, minVersion, maxVersion, map[string]map[int64]*Migration{})
+		require.NoError(t, err)
+		return migrations
+	}
+	require.Len(t, collect(), 2)
+	// The directory is not listed again while its modification time is unchanged.
+	writeFile("00003_items.sql", "-- +goose Up\nSELECT 3;\n", t0)
+	setDirTime(t0)
+	require.Len(t, collect(), 2)
+	setDirTime(t0.Add(time.Second))
+	require.Len(t, collect(), 3)
+
+	path := filepath.Join(dir, "00001_users.sql")
+	a, err := cache.readAnnotations(osFS{}, path)
+	require.NoError(t, err)
+	require.Equal(t, "Users", a.title)
+	require.Equal(t, []string{"users"}, a.hints.Affects)
+	// Callers cannot modify cached hints.
+	a.hints.Affects[0] = "changed"
+	// A file is not read again while its modification time and size are unchanged.
+	writeFile("00001_users.sql", "-- +goose title Items\n-- +goose affects items\n-- +goose Up\nSELECT 1;\n", t0)
+	a, err = cache.readAnnotations(osFS{}, path)
+	require.NoError(t, err)
+	require.Equal(t, "Users", a.title)
+	require.Equal(t, []string{"users"}, a.hints.Affects)
+	writeFile("00001_users.sql", "-- +goose title Items\n-- +goose affects items\n-- +goose Up\nSELECT 1;\n", t0.Add(time.Second))
+	a, err = cache.readAnnotations(osFS{}, path)
+	require.NoError(t, err)
+	require.Equal(t, "Items", a.title)
+	require.Equal(t, []string{"items"}, a.hints.Affects)
+
+	cache.Reset()
+	writeFile("00004_tags.sql", "-- +goose Up\nSELECT 4;\n", t0)
+	setDirTime(t0.Add(time.Second))
+	require.Len(t, collect(), 4)
+}
+
+// writeBenchmarkMigrations writes n SQL migrations to a new directory.
+func writeBenchmarkMigrations(b *testing.B, n int) string {
+	b.Helper()
+	dir := b.TempDir()
+	for i := 1; i <= n; i++ {
+		data := fmt.Sprintf("-- +goose affects t%d\n-- +goose Up\nCREATE TABLE t%d (id INTEGER);\n-- +goose Down\nDROP TABLE t%d;\n", i, i, i)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%05d_t%d.sql", i, i)), []byte(data), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func BenchmarkCollectMigrations(b *testing.B) {
+	dir := writeBenchmarkMigrations(b, 1000)
+	for _, bc := range []struct {
+		name  string
+		cache *CollectCache
+	}{
+		{"uncached", nil},
+		{"cached", NewCollectCache()},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				migrations, err := collectMigrationsCached(bc.cache, "", osFS{}, dir, minVersion, maxVersion, map[string]map[int64]*Migration{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, m := range migrations {
+					if _, err := bc.cache.readAnnotations(osFS{}, m.Source); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkProviderStatus(b *testing.B) {
+	ctx := context.Background()
+	dir := writeBenchmarkMigrations(b, 1000)
+	db, err := sql.Open("sqlite", filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	for _, bc := range []struct {
+		name string
+		opts []ProviderOption
+	}{
+		{"uncached", nil},
+		{"cached", []ProviderOption{WithCollectCache(NewCollectCache())}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			p, err := NewProvider(DialectSQLite3, db, os.DirFS(dir), bc.opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := p.Status(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// ErrDatabasesDiffer is returned by [Status] with [WithStatusCompare] when the compared databases
+// do not have the same migrations applied.
+var ErrDatabasesDiffer = errors.New("databases differ")
+
+// WithStatusCompare makes [Status] compare the migrations applied to the database with those
+// applied to other, for example to verify that staging matches production before promotion.
+// Instead of the status table, only the migrations that differ are printed, and
+// [ErrDatabasesDiffer] is returned if there are any. Both databases must use the current dialect
+// and table name. See [Compare] for details.
+func WithStatusCompare(other *sql.DB) OptionsFunc {
+	return func(o *options) { o.compareDB = other }
+}
+
+// VersionDiff is a migration version that differs between two databases, as reported by
+// [Compare]. Left refers to the first database and Right to the second.
+type VersionDiff struct {
+	Version int64 `json:"version"`
+	// Source is the path of the migration in the migrations directory. It is empty if the version
+	// is applied to a database but the migration file is not found.
+	Source       string `json:"source,omitempty"`
+	LeftApplied  bool   `json:"left_applied"`
+	RightApplied bool   `json:"right_applied"`
+	// LeftChecksum and RightChecksum are the checksums recorded when the migration was applied,
+	// see [WithRecordChecksums]. They are empty for versions applied without recording a checksum.
+	LeftChecksum  string `json:"left_checksum,omitempty"`
+	RightChecksum string `json:"right_checksum,omitempty"`
+}
+
+// Compare returns the migration versions that differ between two databases, in ascending version
+// order. A version differs if it is applied to only one of the databases, or if it is applied to
+// both with different recorded checksums. Checksums are only compared when both databases recorded
+// one. An empty slice means both databases have the same migrations applied.
+//
+// The migrations in dir are only used to name the differing versions; dir may be empty.
+func Compare(ctx context.Context, left, right *sql.DB, dir string, opts ...OptionsFunc) ([]*VersionDiff, error) {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.noVersioning {
+		return nil, errors.New("compare requires versioning: applied migrations must be tracked in the version table")
+	}
+	sources := make(map[int64]string)
+	if dir != "" {
+		migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
+		if err != nil && !errors.Is(err, ErrNoMigrationFiles) {
+			return nil, fmt.Errorf("failed to collect migrations: %w", err)
+		}
+		for _, m := range migrations {
+			sources[m.Version] = m.Source
+		}
+	}
+	leftState, err := appliedState(ctx, left)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read left database: %w", err)
+	}
+	rightState, err := appliedState(ctx, right)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read right database: %w", err)
+	}
+	versions := make(map[int64]struct{})
+	for v := range leftState {
+		versions[v] = struct{}{}
+	}
+	for v := range rightState {
+		versions[v] = struct{}{}
+	}
+	var diffs []*VersionDiff
+	for v := range versions {
+		l, lok := leftState[v]
+		r, rok := rightState[v]
+		if lok && rok && (l == "" || r == "" || l == r) {
+			continue
+		}
+		diffs = append(diffs, &VersionDiff{
+			Version:       v,
+			Source:        sources[v],
+			LeftApplied:   lok,
+			RightApplied:  rok,
+			LeftChecksum:  l,
+			RightChecksum: r,
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Version < diffs[j].Version
+	})
+	return diffs, nil
+}
+
+// appliedState returns the versions currently applied to the database, excluding version 0, mapped
+// to their recorded checksums. A version is applied if its latest record in the version table is.
+func appliedState(ctx context.Context, db *sql.DB) (map[int64]string, error) {
+	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to ensure DB version: %w", err)
+	}
+	// ListMigrations returns records in descending order by id, so the first record of a version
+	// is its latest.
+	records, err := getStore().ListMigrations(ctx, db, TableName())
+	if err != nil {
+		return nil, err
+	}
+	state := make(map[int64]string)
+	seen := make(map[int64]bool)
+	for _, r := range records {
+		if seen[r.VersionID] {
+			continue
+		}
+		seen[r.VersionID] = true
+		if r.IsApplied && r.VersionID != 0 {
+			state[r.VersionID] = ""
+		}
+	}
+	checksums, err := listRunChecksums(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checksums: %w", err)
+	}
+	for v, sum := range checksums {
+		if _, ok := state[v]; ok {
+			state[v] = sum
+		}
+	}
+	return state, nil
+}
+
+func printCompare(ctx context.Context, db *sql.DB, dir string, option *options) error {
+	diffs, err := Compare(ctx, db, option.compareDB, dir, WithOptionScope(option.scope))
+	if err != nil {
+		return err
+	}
+	if option.statusJSON {
+		if diffs == nil {
+			diffs = []*VersionDiff{}
+		}
+		data, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode comparison: %w", err)
+		}
+		if _, err := fmt.Fprintln(output(), string(data)); err != nil {
+			return fmt.Errorf("failed to write comparison: %w", err)
+		}
+	} else if len(diffs) == 0 {
+		log.Printf("goose: databases have the same migrations applied\n")
+	} else {
+		log.Printf("    Version          Left         Right        Migration\n")
+		log.Printf("    ===================================================\n")
+		for _, d := range diffs {
+			name := "(missing file)"
+			if d.Source != "" {
+				name = filepath.Base(d.Source)
+			}
+			log.Printf("    %-16d %-12s %-12s -- %v\n",
+				d.Version, compareState(d.LeftApplied, d.LeftChecksum), compareState(d.RightApplied, d.RightChecksum), name)
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("%w: %d migrations differ", ErrDatabasesDiffer, len(diffs))
+	}
+	return nil
+}
+
+func compareState(applied bool, checksum string) string {
+	if !applied {
+		return "pending"
+	}
+	if checksum == "" {
+		return "applied"
+	}
+	if len(checksum) > 8 {
+		checksum = checksum[:8]
+	}
+	return checksum
+}
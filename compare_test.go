@@ -0,0 +1,93 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestCompare(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	dir := t.TempDir()
+	openDB := func(name string) *sql.DB {
+		db, err := sql.Open("sqlite", filepath.Join(dir, name))
+		require.NoError(t, err)
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { _ = db.Close() })
+		return db
+	}
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	t.Run("applied", func(t *testing.T) {
+		staging, production := openDB("staging.db"), openDB("production.db")
+		migrationsDir := filepath.Join(dir, "migrations")
+		require.NoError(t, os.MkdirAll(migrationsDir, 0755))
+		for _, name := range []string{"00001_a.sql", "00002_b.sql", "00003_c.sql"} {
+			data := []byte("-- +goose Up\nSELECT 1;\n-- +goose Down\nSELECT 1;\n")
+			require.NoError(t, os.WriteFile(filepath.Join(migrationsDir, name), data, 0644))
+		}
+		require.NoError(t, goose.Up(staging, migrationsDir))
+		require.NoError(t, goose.Up(production, migrationsDir))
+		diffs, err := goose.Compare(ctx, staging, production, migrationsDir)
+		require.NoError(t, err)
+		require.Empty(t, diffs)
+		require.NoError(t, goose.Status(staging, migrationsDir, goose.WithStatusCompare(production)))
+
+		// A rolled back version is no longer applied.
+		require.NoError(t, goose.Down(production, migrationsDir))
+		diffs, err = goose.Compare(ctx, staging, production, migrationsDir)
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		require.EqualValues(t, 3, diffs[0].Version)
+		require.Equal(t, filepath.Join(migrationsDir, "00003_c.sql"), diffs[0].Source)
+		require.True(t, diffs[0].LeftApplied)
+		require.False(t, diffs[0].RightApplied)
+		err = goose.Status(staging, migrationsDir, goose.WithStatusCompare(production))
+		require.ErrorIs(t, err, goose.ErrDatabasesDiffer)
+
+		// JSON is written to the output, not logged, so it can be piped.
+		var out strings.Builder
+		goose.SetOutput(&out)
+		t.Cleanup(func() { goose.SetOutput(os.Stdout) })
+		err = goose.Status(staging, migrationsDir, goose.WithStatusCompare(production), goose.WithStatusJSON())
+		require.ErrorIs(t, err, goose.ErrDatabasesDiffer)
+		var written []*goose.VersionDiff
+		require.NoError(t, json.Unmarshal([]byte(out.String()), &written))
+		require.Len(t, written, 1)
+		require.EqualValues(t, 3, written[0].Version)
+	})
+	t.Run("checksum", func(t *testing.T) {
+		staging, production := openDB("staging_checksum.db"), openDB("production_checksum.db")
+		up := func(db *sql.DB, stmt string) {
+			fsys := fstest.MapFS{
+				"00001_a.sql": {Data: []byte("-- +goose Up\n" + stmt + "\n")},
+			}
+			p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRecordChecksums(true))
+			require.NoError(t, err)
+			_, err = p.Up(ctx)
+			require.NoError(t, err)
+		}
+		up(staging, "SELECT 1;")
+		up(production, "SELECT 2;")
+		diffs, err := goose.Compare(ctx, staging, production, "")
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		require.EqualValues(t, 1, diffs[0].Version)
+		require.Empty(t, diffs[0].Source)
+		require.True(t, diffs[0].LeftApplied)
+		require.True(t, diffs[0].RightApplied)
+		require.NotEmpty(t, diffs[0].LeftChecksum)
+		require.NotEqual(t, diffs[0].LeftChecksum, diffs[0].RightChecksum)
+	})
+}
@@ -0,0 +1,102 @@
+package goose
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// sqlFilePatterns match SQL migration files, uncompressed and compressed with gzip or zstd, e.g.,
+// 00001_load_data.sql.gz.
+var sqlFilePatterns = []string{"*.sql", "*.sql.gz", "*.sql.zst"}
+
+// isSQLFile reports whether name is a SQL migration file, possibly compressed.
+func isSQLFile(name string) bool {
+	return filepath.Ext(name) == ".sql" || isCompressed(name)
+}
+
+// isCompressed reports whether name is a compressed SQL migration file.
+func isCompressed(name string) bool {
+	return strings.HasSuffix(name, ".sql.gz") || strings.HasSuffix(name, ".sql.zst")
+}
+
+// decompressFS decompresses compressed SQL migration files as they are read, so the rest of goose
+// reads them like any other SQL file. All other files and methods are passed through.
+type decompressFS struct {
+	fsys fs.FS
+}
+
+// decompressing returns fsys wrapped in a decompressFS, unless it already is one.
+func decompressing(fsys fs.FS) fs.FS {
+	if _, ok := fsys.(decompressFS); ok || fsys == nil {
+		return fsys
+	}
+	return decompressFS{fsys: fsys}
+}
+
+var (
+	_ fs.ReadDirFS  = decompressFS{}
+	_ fs.StatFS     = decompressFS{}
+	_ fs.GlobFS     = decompressFS{}
+	_ fs.ReadFileFS = decompressFS{}
+)
+
+func (d decompressFS) Open(name string) (fs.File, error) {
+	f, err := d.fsys.Open(name)
+	if err != nil || !isCompressed(name) {
+		return f, err
+	}
+	var r io.ReadCloser
+	if strings.HasSuffix(name, ".gz") {
+		r, err = gzip.NewReader(f)
+	} else {
+		var dec *zstd.Decoder
+		if dec, err = zstd.NewReader(f); err == nil {
+			r = dec.IOReadCloser()
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to decompress %s: %w", name, err)
+	}
+	return &decompressedFile{File: f, r: r}, nil
+}
+
+func (d decompressFS) ReadDir(name string) ([]fs.DirEntry, error) { return fs.ReadDir(d.fsys, name) }
+
+func (d decompressFS) Stat(name string) (fs.FileInfo, error) { return fs.Stat(d.fsys, name) }
+
+func (d decompressFS) Glob(pattern string) ([]string, error) { return fs.Glob(d.fsys, pattern) }
+
+func (d decompressFS) ReadFile(name string) ([]byte, error) {
+	if !isCompressed(name) {
+		return fs.ReadFile(d.fsys, name)
+	}
+	f, err := d.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// decompressedFile reads the decompressed contents of a file. Stat reports the compressed file.
+type decompressedFile struct {
+	fs.File
+	r io.ReadCloser
+}
+
+func (f *decompressedFile) Read(p []byte) (int, error) { return f.r.Read(p) }
+
+func (f *decompressedFile) Close() error {
+	err := f.r.Close()
+	if closeErr := f.File.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package goose_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressedMigrations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	gzipped := func(data string) *fstest.MapFile {
+		t.Helper()
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		_, err := w.Write([]byte(data))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return &fstest.MapFile{Data: buf.Bytes()}
+	}
+	zstded := func(data string) *fstest.MapFile {
+		t.Helper()
+		enc, err := zstd.NewWriter(nil)
+		require.NoError(t, err)
+		defer enc.Close()
+		return &fstest.MapFile{Data: enc.EncodeAll([]byte(data), nil)}
+	}
+	fsys := fstest.MapFS{
+		"00001_a.sql":     newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"),
+		"00002_b.sql.gz":  gzipped("-- +goose Up\nCREATE TABLE b (id INTEGER);\nINSERT INTO b VALUES (1);\n-- +goose Down\nDROP TABLE b;\n"),
+		"00003_c.sql.zst": zstded("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE c (id INTEGER);\n-- +goose Down\nDROP TABLE c;\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRecordChecksums(true))
+	require.NoError(t, err)
+	sources := p.ListSources()
+	require.Len(t, sources, 3)
+	require.Equal(t, "00003_c.sql.zst", sources[2].Path)
+	require.Equal(t, goose.TypeSQL, sources[2].Type)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM b").Scan(&n))
+	require.Equal(t, 1, n)
+	require.True(t, tableExists(t, db, "c"))
+	// The checksums recorded while applying the migrations still match.
+	require.NoError(t, p.Verify(ctx))
+	_, err = p.DownTo(ctx, 0)
+	require.NoError(t, err)
+	require.False(t, tableExists(t, db, "b"))
+	require.False(t, tableExists(t, db, "c"))
+
+	t.Run("corrupt", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql.gz": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "failed to decompress 00001_a.sql.gz")
+	})
+}
@@ -0,0 +1,87 @@
+package goose_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestPostConditionsLegacy(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_verify.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00001_a.sql"), []byte("-- +goose verify SELECT count(*) FROM a\n"+
+		"-- +goose Up\nCREATE TABLE a (id INTEGER);\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose verify SELECT count(*) FROM a WHERE id IS NULL\n"+
+		"-- +goose Up\nINSERT INTO a VALUES (1), (NULL);\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00003_c.sql"), []byte("-- +goose verify expect=1 SELECT count(*) FROM c\n"+
+		"-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE c (id INTEGER);\n"), 0644))
+
+	err = goose.Up(db, dir)
+	require.ErrorIs(t, err, goose.ErrPostConditionFailed)
+	require.ErrorContains(t, err, "SELECT count(*) FROM a WHERE id IS NULL returned 1, expected 0")
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, ver)
+	var rows int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM a").Scan(&rows))
+	require.Zero(t, rows)
+
+	// The version of a NO TRANSACTION migration is not recorded.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose verify SELECT count(*) FROM a WHERE id IS NULL\n"+
+		"-- +goose Up\nINSERT INTO a VALUES (1);\n"), 0644))
+	err = goose.Up(db, dir)
+	require.ErrorContains(t, err, "SELECT count(*) FROM c returned 0, expected 1")
+	ver, err = goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, ver)
+}
+
+func TestPreconditionsLegacy(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_precondition.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00001_a.sql"), []byte("-- +goose Up\n"+
+		"CREATE TABLE a (id INTEGER);\nINSERT INTO a VALUES (NULL);\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose precondition SELECT count(*) FROM a WHERE id IS NULL\n"+
+		"-- +goose Up\nCREATE TABLE b (id INTEGER);\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00003_c.sql"), []byte("-- +goose Up\nCREATE TABLE c (id INTEGER);\n"), 0644))
+
+	err = goose.Up(db, dir)
+	require.ErrorIs(t, err, goose.ErrPreconditionFailed)
+	require.ErrorContains(t, err, "SELECT count(*) FROM a WHERE id IS NULL returned 1, expected 0")
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, ver)
+
+	// With on-fail=skip, the run stops with ErrPreconditionSkipped instead.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose precondition on-fail=skip SELECT count(*) FROM a WHERE id IS NULL\n"+
+		"-- +goose Up\nCREATE TABLE b (id INTEGER);\n"), 0644))
+	err = goose.Up(db, dir)
+	require.ErrorIs(t, err, goose.ErrPreconditionSkipped)
+	require.NotErrorIs(t, err, goose.ErrPreconditionFailed)
+	require.ErrorContains(t, err, "skipped 00002_b.sql and the pending migrations after it")
+	ver, err = goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, ver)
+
+	_, err = db.Exec("DELETE FROM a")
+	require.NoError(t, err)
+	require.NoError(t, goose.Up(db, dir))
+	ver, err = goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, ver)
+}
@@ -13,6 +13,14 @@ import (
 type tmplVars struct {
 	Version   string
 	CamelName string
+
+	// Name is the migration name as supplied by the user, before any case conversion.
+	Name string
+	// Scope, Author and Ticket are optional and set with [CreateOption] values. They are empty
+	// unless configured.
+	Scope  string
+	Author string
+	Ticket string
 }
 
 var (
@@ -21,16 +29,76 @@ var (
 
 // SetSequential set whether to use sequential versioning instead of timestamp based versioning
 func SetSequential(s bool) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
 	sequential = s
 }
 
+func isSequential() bool {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return sequential
+}
+
+type createOptions struct {
+	tmpl   *template.Template
+	scope  string
+	author string
+	ticket string
+}
+
+// CreateOption configures [CreateWithOptions].
+type CreateOption func(o *createOptions)
+
+// WithCreateTemplate sets the template used to render the new migration file. If not set, goose
+// uses its built-in template for the migration type.
+//
+// The template is executed with the following fields: .Version, .Name, .CamelName, .Scope,
+// .Author and .Ticket.
+func WithCreateTemplate(tmpl *template.Template) CreateOption {
+	return func(o *createOptions) { o.tmpl = tmpl }
+}
+
+// WithCreateScope creates the migration in the scope's subdirectory, dir/<scope>, and sets the
+// .Scope template field. With sequential versioning, the next version is computed from the
+// migrations in that subdirectory only. The built-in Go template registers the migration with
+// [WithScope].
+func WithCreateScope(scope string) CreateOption {
+	return func(o *createOptions) { o.scope = scope }
+}
+
+// WithCreateAuthor sets the .Author template field.
+func WithCreateAuthor(author string) CreateOption {
+	return func(o *createOptions) { o.author = author }
+}
+
+// WithCreateTicket sets the .Ticket template field, e.g., an issue tracker reference.
+func WithCreateTicket(ticket string) CreateOption {
+	return func(o *createOptions) { o.ticket = ticket }
+}
+
 // Create writes a new blank migration file.
 func CreateWithTemplate(db *sql.DB, dir string, tmpl *template.Template, name, migrationType string) error {
+	return CreateWithOptions(dir, name, migrationType, WithCreateTemplate(tmpl))
+}
+
+// CreateWithOptions writes a new migration file of the given type, either "sql" or "go", to dir.
+func CreateWithOptions(dir, name, migrationType string, opts ...CreateOption) error {
+	option := &createOptions{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.scope != "" {
+		dir = filepath.Join(dir, option.scope)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create scope directory: %w", err)
+		}
+	}
 	version := time.Now().UTC().Format(timestampFormat)
 
-	if sequential {
+	if isSequential() {
 		// always use DirFS here because it's modifying operation
-		migrations, err := collectMigrationsFS("", osFS{}, dir, minVersion, maxVersion, registeredGoMigrations)
+		migrations, err := collectMigrationsFS("", osFS{}, dir, minVersion, maxVersion, globalMigrationsSnapshot())
 		if err != nil && !errors.Is(err, ErrNoMigrationFiles) {
 			return err
 		}
@@ -49,6 +117,7 @@ func CreateWithTemplate(db *sql.DB, dir string, tmpl *template.Template, name, m
 
 	filename := fmt.Sprintf("%v_%v.%v", version, snakeCase(name), migrationType)
 
+	tmpl := option.tmpl
 	if tmpl == nil {
 		if migrationType == "go" {
 			tmpl = goSQLMigrationTemplate
@@ -71,6 +140,10 @@ func CreateWithTemplate(db *sql.DB, dir string, tmpl *template.Template, name, m
 	vars := tmplVars{
 		Version:   version,
 		CamelName: camelCase(name),
+		Name:      name,
+		Scope:     option.scope,
+		Author:    option.author,
+		Ticket:    option.ticket,
 	}
 	if err := tmpl.Execute(f, vars); err != nil {
 		return fmt.Errorf("failed to execute tmpl: %w", err)
@@ -105,7 +178,7 @@ import (
 )
 
 func init() {
-	goose.AddMigrationContext(up{{.CamelName}}, down{{.CamelName}})
+	goose.AddMigrationContext(up{{.CamelName}}, down{{.CamelName}}{{if .Scope}}, goose.WithScope({{printf "%q" .Scope}}){{end}})
 }
 
 func up{{.CamelName}}(ctx context.Context, tx *sql.Tx) error {
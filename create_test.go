@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"text/template"
 	"time"
 )
 
@@ -50,3 +52,72 @@ func TestSequential(t *testing.T) {
 		}
 	}
 }
+
+func TestCreateWithOptions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tmpl := template.Must(template.New("custom").Parse(`-- {{.Name}} by {{.Author}} ({{.Ticket}}) scope={{.Scope}}
+-- +goose Up
+SELECT {{.Version}};
+`))
+	err := CreateWithOptions(dir, "add users", "sql",
+		WithCreateTemplate(tmpl),
+		WithCreateAuthor("jane"),
+		WithCreateTicket("PROJ-123"),
+		WithCreateScope("billing"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "billing", "*_add_users.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("expected 1 file, got %d", len(files))
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	version, err := NumericComponent(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("-- add users by jane (PROJ-123) scope=billing\n-- +goose Up\nSELECT %d;\n", version)
+	if string(data) != want {
+		t.Errorf("unexpected file contents:\ngot:\n%s\nwant:\n%s", data, want)
+	}
+}
+
+func TestCreateWithScope(t *testing.T) {
+	SetSequential(true)
+	t.Cleanup(func() { SetSequential(false) })
+
+	dir := t.TempDir()
+	// A migration in the root directory must not affect numbering within the scope.
+	if err := CreateWithOptions(dir, "root", "sql"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateWithOptions(dir, "root two", "sql"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"first", "second"} {
+		if err := CreateWithOptions(dir, name, "go", WithCreateScope("billing")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, filename := range []string{"00001_first.go", "00002_second.go"} {
+		data, err := os.ReadFile(filepath.Join(dir, "billing", filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), `goose.WithScope("billing")`) {
+			t.Errorf("%s: expected scope to be injected, got:\n%s", filename, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "00002_root_two.sql")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -62,7 +62,10 @@ type store struct {
 	querier   *dialectquery.QueryController
 }
 
-var _ Store = (*store)(nil)
+var (
+	_ Store         = (*store)(nil)
+	_ MetadataStore = (*store)(nil)
+)
 
 func (s *store) Tablename() string {
 	return s.tablename
@@ -170,3 +173,106 @@ func (s *store) TableExists(ctx context.Context, db DBTxConn) (bool, error) {
 	}
 	return exists, nil
 }
+
+func (s *store) UpdateVersion(ctx context.Context, db DBTxConn, oldVersion, newVersion int64) error {
+	q := s.querier.UpdateVersion(s.tablename)
+	if q == "" {
+		return errors.ErrUnsupported
+	}
+	if _, err := db.ExecContext(ctx, q, newVersion, oldVersion); err != nil {
+		return fmt.Errorf("failed to update version %d to %d: %w", oldVersion, newVersion, err)
+	}
+	return nil
+}
+
+func (s *store) CreateMetadataTable(ctx context.Context, db DBTxConn) error {
+	m := s.querier.Metadata()
+	if m == nil {
+		return errors.ErrUnsupported
+	}
+	if _, err := db.ExecContext(ctx, m.CreateMetadataTable(s.tablename)); err != nil {
+		return fmt.Errorf("failed to create metadata table: %w", err)
+	}
+	return nil
+}
+
+func (s *store) MetadataTableExists(ctx context.Context, db DBTxConn) (bool, error) {
+	m := s.querier.Metadata()
+	if m == nil {
+		return false, errors.ErrUnsupported
+	}
+	var exists bool
+	if err := db.QueryRowContext(ctx, m.MetadataTableExists(s.tablename)).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check if metadata table exists: %w", err)
+	}
+	return exists, nil
+}
+
+func (s *store) InsertMetadata(ctx context.Context, db DBTxConn, version int64, key, value string) error {
+	m := s.querier.Metadata()
+	if m == nil {
+		return errors.ErrUnsupported
+	}
+	if _, err := db.ExecContext(ctx, m.InsertMetadata(s.tablename), version, key, value); err != nil {
+		return fmt.Errorf("failed to insert metadata %q for version %d: %w", key, version, err)
+	}
+	return nil
+}
+
+func (s *store) DeleteMetadata(ctx context.Context, db DBTxConn, version int64) error {
+	m := s.querier.Metadata()
+	if m == nil {
+		return errors.ErrUnsupported
+	}
+	if _, err := db.ExecContext(ctx, m.DeleteMetadata(s.tablename), version); err != nil {
+		return fmt.Errorf("failed to delete metadata for version %d: %w", version, err)
+	}
+	return nil
+}
+
+func (s *store) DeleteMetadataKey(ctx context.Context, db DBTxConn, version int64, key string) error {
+	m := s.querier.Metadata()
+	if m == nil {
+		return errors.ErrUnsupported
+	}
+	if _, err := db.ExecContext(ctx, m.DeleteMetadataKey(s.tablename), version, key); err != nil {
+		return fmt.Errorf("failed to delete metadata %q for version %d: %w", key, version, err)
+	}
+	return nil
+}
+
+func (s *store) UpdateMetadataVersion(ctx context.Context, db DBTxConn, oldVersion, newVersion int64) error {
+	m := s.querier.Metadata()
+	if m == nil {
+		return errors.ErrUnsupported
+	}
+	if _, err := db.ExecContext(ctx, m.UpdateMetadataVersion(s.tablename), newVersion, oldVersion); err != nil {
+		return fmt.Errorf("failed to update metadata version %d to %d: %w", oldVersion, newVersion, err)
+	}
+	return nil
+}
+
+func (s *store) ListMetadata(ctx context.Context, db DBTxConn) ([]*MetadataResult, error) {
+	m := s.querier.Metadata()
+	if m == nil {
+		return nil, errors.ErrUnsupported
+	}
+	rows, err := db.QueryContext(ctx, m.ListMetadata(s.tablename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list metadata: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*MetadataResult
+	for rows.Next() {
+		var result MetadataResult
+		if err := rows.Scan(&result.Version, &result.Key, &result.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan list metadata result: %w", err)
+		}
+		results = append(results, &result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
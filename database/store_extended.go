@@ -31,3 +31,35 @@ type StoreExtender interface {
 	// table existence.
 	TableExists(ctx context.Context, db DBTxConn) (bool, error)
 }
+
+// MetadataStore is an optional extension of the Store interface for stores that keep a metadata
+// table. The metadata table records additional key/value information about applied versions, such
+// as run labels, and is created on demand by features that need it.
+//
+// Like [StoreExtender], this interface may be expanded in future versions. Methods must return
+// [errors.ErrUnsupported] if the database does not support a metadata table.
+type MetadataStore interface {
+	Store
+
+	// CreateMetadataTable creates the metadata table, if it does not already exist.
+	CreateMetadataTable(ctx context.Context, db DBTxConn) error
+	// MetadataTableExists checks if the metadata table exists in the database.
+	MetadataTableExists(ctx context.Context, db DBTxConn) (bool, error)
+	// InsertMetadata records a key and value for a version.
+	InsertMetadata(ctx context.Context, db DBTxConn, version int64, key, value string) error
+	// DeleteMetadata deletes all metadata recorded for a version.
+	DeleteMetadata(ctx context.Context, db DBTxConn, version int64) error
+	// DeleteMetadataKey deletes a single key recorded for a version.
+	DeleteMetadataKey(ctx context.Context, db DBTxConn, version int64, key string) error
+	// UpdateMetadataVersion moves all metadata recorded for a version to a new version.
+	UpdateMetadataVersion(ctx context.Context, db DBTxConn, oldVersion, newVersion int64) error
+	// ListMetadata retrieves all metadata sorted by version and key.
+	ListMetadata(ctx context.Context, db DBTxConn) ([]*MetadataResult, error)
+}
+
+// MetadataResult is a single key and value recorded for a version in the metadata table.
+type MetadataResult struct {
+	Version int64
+	Key     string
+	Value   string
+}
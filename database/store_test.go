@@ -65,6 +65,38 @@ func TestDialectStore(t *testing.T) {
 		require.EqualValues(t, 3, res[1].Version)
 		require.EqualValues(t, 1, res[2].Version)
 	})
+	t.Run("Metadata", func(t *testing.T) {
+		ctx := context.Background()
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sql_meta.db"))
+		require.NoError(t, err)
+		store, err := database.NewStore(database.DialectSQLite3, "foo")
+		require.NoError(t, err)
+		meta, ok := store.(database.MetadataStore)
+		require.True(t, ok)
+		exists, err := meta.MetadataTableExists(ctx, db)
+		require.NoError(t, err)
+		require.False(t, exists)
+		// Creating the table is idempotent.
+		require.NoError(t, meta.CreateMetadataTable(ctx, db))
+		require.NoError(t, meta.CreateMetadataTable(ctx, db))
+		exists, err = meta.MetadataTableExists(ctx, db)
+		require.NoError(t, err)
+		require.True(t, exists)
+		require.NoError(t, meta.InsertMetadata(ctx, db, 2, "b", "2"))
+		require.NoError(t, meta.InsertMetadata(ctx, db, 1, "a", "1"))
+		require.NoError(t, meta.InsertMetadata(ctx, db, 2, "a", "2"))
+		res, err := meta.ListMetadata(ctx, db)
+		require.NoError(t, err)
+		require.Equal(t, []*database.MetadataResult{
+			{Version: 1, Key: "a", Value: "1"},
+			{Version: 2, Key: "a", Value: "2"},
+			{Version: 2, Key: "b", Value: "2"},
+		}, res)
+		require.NoError(t, meta.DeleteMetadata(ctx, db, 2))
+		res, err = meta.ListMetadata(ctx, db)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+	})
 }
 
 // testStore tests various store operations.
@@ -0,0 +1,194 @@
+// Package datacopy streams rows from one database to another in batches, for Go migrations that
+// move data between databases, e.g., from an old cluster to a new one when re-platforming.
+//
+// Example:
+//
+//	func up(ctx context.Context, tx *sql.Tx) error {
+//		n, err := datacopy.Copy(ctx, legacyDB, tx, database.DialectPostgres,
+//			"SELECT id, email, created_at FROM users", "users",
+//			datacopy.WithProgress(func(copied int64) { log.Printf("copied %d users", copied) }),
+//		)
+//		...
+//	}
+package datacopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+// DefaultBatchSize is the number of rows inserted per statement, unless limited by the number of
+// bind parameters the destination database supports.
+const DefaultBatchSize = 500
+
+// Copy runs query on src and inserts the resulting rows into table on dst, whose dialect is used to
+// build the insert statements. It returns the number of rows copied.
+//
+// Rows are streamed: at most one batch is held in memory. Values are passed to dst as scanned from
+// src, except that []byte values of non-binary columns are converted to strings, since drivers
+// commonly return text as []byte. Use [WithTransform] for other type mappings.
+//
+// Copy does not use a transaction of its own. Pass a *sql.Tx as dst to insert all rows atomically.
+func Copy(
+	ctx context.Context,
+	src, dst database.DBTxConn,
+	dialect database.Dialect,
+	query, table string,
+	opts ...Option,
+) (int64, error) {
+	cfg := config{batchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		if err := opt.apply(&cfg); err != nil {
+			return 0, err
+		}
+	}
+	if src == nil || dst == nil {
+		return 0, errors.New("source and destination must not be nil")
+	}
+	if table == "" {
+		return 0, errors.New("table must not be empty")
+	}
+	rows, err := src.QueryContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query source: %w", err)
+	}
+	defer rows.Close()
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read source columns: %w", err)
+	}
+	columns := cfg.columns
+	if len(columns) == 0 {
+		for _, ct := range columnTypes {
+			columns = append(columns, ct.Name())
+		}
+	}
+	binary := make([]bool, len(columnTypes))
+	for i, ct := range columnTypes {
+		binary[i] = isBinary(ct.DatabaseTypeName())
+	}
+	batchSize := cfg.batchSize
+	if limit := maxParams(dialect) / len(columns); batchSize > limit {
+		batchSize = max(limit, 1)
+	}
+
+	var copied int64
+	batch := make([]any, 0, batchSize*len(columns))
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n := len(batch) / len(columns)
+		if _, err := dst.ExecContext(ctx, insertStatement(dialect, table, columns, n), batch...); err != nil {
+			return fmt.Errorf("failed to insert rows %d-%d: %w", copied+1, copied+int64(n), err)
+		}
+		copied += int64(n)
+		batch = batch[:0]
+		if cfg.progress != nil {
+			cfg.progress(copied)
+		}
+		return nil
+	}
+	var read int64
+	for rows.Next() {
+		read++
+		values := make([]any, len(columnTypes))
+		dest := make([]any, len(columnTypes))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return copied, fmt.Errorf("failed to scan source row: %w", err)
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok && !binary[i] {
+				values[i] = string(b)
+			}
+		}
+		if cfg.transform != nil {
+			if values, err = cfg.transform(values); err != nil {
+				return copied, fmt.Errorf("failed to transform row %d: %w", read, err)
+			}
+		}
+		if len(values) != len(columns) {
+			return copied, fmt.Errorf("row has %d values, want %d for columns %s", len(values), len(columns), strings.Join(columns, ", "))
+		}
+		batch = append(batch, values...)
+		if len(batch) == batchSize*len(columns) {
+			if err := flush(); err != nil {
+				return copied, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return copied, fmt.Errorf("failed to read source rows: %w", err)
+	}
+	if err := flush(); err != nil {
+		return copied, err
+	}
+	return copied, nil
+}
+
+func insertStatement(dialect database.Dialect, table string, columns []string, rows int) string {
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(table)
+	b.WriteString(" (")
+	b.WriteString(strings.Join(columns, ", "))
+	b.WriteString(") VALUES ")
+	param := 1
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for c := range columns {
+			if c > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(placeholder(dialect, param))
+			param++
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+func placeholder(dialect database.Dialect, n int) string {
+	switch dialect {
+	case database.DialectPostgres, database.DialectRedshift:
+		return "$" + strconv.Itoa(n)
+	case database.DialectMSSQL:
+		return "@p" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// maxParams returns the maximum number of bind parameters in a single statement.
+func maxParams(dialect database.Dialect) int {
+	switch dialect {
+	case database.DialectPostgres, database.DialectRedshift, database.DialectMySQL, database.DialectTiDB:
+		return 65535
+	case database.DialectMSSQL:
+		return 2100 - 1
+	case database.DialectSQLite3, database.DialectTurso:
+		// The default limit of SQLite versions before 3.32.0.
+		return 999
+	default:
+		return 65535
+	}
+}
+
+func isBinary(databaseType string) bool {
+	switch strings.ToUpper(databaseType) {
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BYTEA", "BINARY", "VARBINARY", "IMAGE":
+		return true
+	}
+	return false
+}
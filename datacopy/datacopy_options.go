@@ -0,0 +1,76 @@
+package datacopy
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Option is used to configure [Copy].
+type Option interface {
+	apply(*config) error
+}
+
+// WithBatchSize sets the number of rows inserted per statement. The batch size is lowered as
+// needed to stay within the number of bind parameters the destination database supports.
+//
+// If WithBatchSize is not called, the DefaultBatchSize is used.
+func WithBatchSize(n int) Option {
+	return configFunc(func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("batch size must be greater than 0: %d", n)
+		}
+		c.batchSize = n
+		return nil
+	})
+}
+
+// WithColumns sets the destination column names, in the order of the source columns. By default,
+// the source column names are used.
+func WithColumns(columns ...string) Option {
+	return configFunc(func(c *config) error {
+		if len(columns) == 0 {
+			return errors.New("columns must not be empty")
+		}
+		c.columns = columns
+		return nil
+	})
+}
+
+// WithTransform sets a function that maps each source row to the values inserted into the
+// destination, e.g., to convert types between databases. It must return one value per destination
+// column.
+func WithTransform(fn func(row []any) ([]any, error)) Option {
+	return configFunc(func(c *config) error {
+		if fn == nil {
+			return errors.New("transform func must not be nil")
+		}
+		c.transform = fn
+		return nil
+	})
+}
+
+// WithProgress sets a function called after each batch with the total number of rows copied.
+func WithProgress(fn func(copied int64)) Option {
+	return configFunc(func(c *config) error {
+		if fn == nil {
+			return errors.New("progress func must not be nil")
+		}
+		c.progress = fn
+		return nil
+	})
+}
+
+type config struct {
+	batchSize int
+	columns   []string
+	transform func(row []any) ([]any, error)
+	progress  func(copied int64)
+}
+
+var _ Option = (configFunc)(nil)
+
+type configFunc func(*config) error
+
+func (f configFunc) apply(cfg *config) error {
+	return f(cfg)
+}
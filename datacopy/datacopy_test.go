@@ -0,0 +1,69 @@
+package datacopy_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/datacopy"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func newDB(t *testing.T, name string, schema string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), name))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	_, err = db.Exec(schema)
+	require.NoError(t, err)
+	return db
+}
+
+func TestCopy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	src := newDB(t, "src.db", "CREATE TABLE users (id INTEGER, name TEXT, avatar BLOB)")
+	for i := 0; i < 7; i++ {
+		_, err := src.Exec("INSERT INTO users VALUES (?, ?, ?)", i, "user", []byte{byte(i)})
+		require.NoError(t, err)
+	}
+	dst := newDB(t, "dst.db", "CREATE TABLE people (user_id INTEGER, name TEXT, avatar BLOB)")
+
+	var progress []int64
+	n, err := datacopy.Copy(ctx, src, dst, database.DialectSQLite3, "SELECT id, name, avatar FROM users ORDER BY id", "people",
+		datacopy.WithBatchSize(3),
+		datacopy.WithColumns("user_id", "name", "avatar"),
+		datacopy.WithProgress(func(copied int64) { progress = append(progress, copied) }),
+	)
+	require.NoError(t, err)
+	require.EqualValues(t, 7, n)
+	require.Equal(t, []int64{3, 6, 7}, progress)
+	var name string
+	var avatar []byte
+	require.NoError(t, dst.QueryRow("SELECT name, avatar FROM people WHERE user_id = 6").Scan(&name, &avatar))
+	require.Equal(t, "user", name)
+	require.Equal(t, []byte{6}, avatar)
+
+	t.Run("transform", func(t *testing.T) {
+		dst := newDB(t, "dst.db", "CREATE TABLE names (name TEXT)")
+		errBad := errors.New("bad row")
+		n, err := datacopy.Copy(ctx, src, dst, database.DialectSQLite3, "SELECT id, name FROM users ORDER BY id", "names",
+			datacopy.WithBatchSize(2),
+			datacopy.WithColumns("name"),
+			datacopy.WithTransform(func(row []any) ([]any, error) {
+				if row[0].(int64) == 4 {
+					return nil, errBad
+				}
+				return []any{row[1]}, nil
+			}),
+		)
+		require.ErrorIs(t, err, errBad)
+		require.ErrorContains(t, err, "row 5")
+		require.EqualValues(t, 4, n)
+	})
+}
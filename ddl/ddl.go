@@ -0,0 +1,145 @@
+// Package ddl provides dialect-aware, idempotent schema helpers for Go migrations, so migrations
+// do not need to branch on the dialect for common operations.
+//
+// Example:
+//
+//	func up(ctx context.Context, tx *sql.Tx) error {
+//		h, err := ddl.New(database.DialectPostgres, tx)
+//		if err != nil {
+//			return err
+//		}
+//		if err := h.CreateTableIfNotExists(ctx, "users", "id BIGINT PRIMARY KEY, name TEXT"); err != nil {
+//			return err
+//		}
+//		return h.AddColumnIfNotExists(ctx, "users", "email", "TEXT")
+//	}
+//
+// Table, column and index names are used as-is and are not quoted, so they may be schema
+// qualified. They must not come from untrusted input.
+package ddl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+// ErrUnsupported is returned by [New] for dialects without idempotent schema helpers.
+var ErrUnsupported = errors.New("dialect not supported")
+
+// Helper runs idempotent schema changes on a database.
+type Helper struct {
+	dialect database.Dialect
+	db      database.DBTxConn
+}
+
+// New returns a Helper that runs statements for the given dialect on db, typically the *sql.Tx or
+// *sql.DB passed to a Go migration.
+//
+// Supported dialects are Postgres, MySQL, TiDB, SQLite, Turso and SQL Server.
+func New(dialect database.Dialect, db database.DBTxConn) (*Helper, error) {
+	switch dialect {
+	case database.DialectPostgres,
+		database.DialectMySQL,
+		database.DialectTiDB,
+		database.DialectSQLite3,
+		database.DialectTurso,
+		database.DialectMSSQL:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupported, dialect)
+	}
+	if db == nil {
+		return nil, errors.New("db must not be nil")
+	}
+	return &Helper{dialect: dialect, db: db}, nil
+}
+
+// TableExists reports whether the table exists.
+func (h *Helper) TableExists(ctx context.Context, table string) (bool, error) {
+	var query string
+	switch h.dialect {
+	case database.DialectPostgres:
+		query = `SELECT to_regclass($1) IS NOT NULL`
+	case database.DialectMySQL, database.DialectTiDB:
+		query = `SELECT COUNT(*) > 0 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+	case database.DialectSQLite3, database.DialectTurso:
+		query = `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`
+	case database.DialectMSSQL:
+		query = `SELECT CASE WHEN OBJECT_ID(@p1, N'U') IS NULL THEN 0 ELSE 1 END`
+	}
+	return h.exists(ctx, query, table)
+}
+
+// ColumnExists reports whether the table has the column.
+func (h *Helper) ColumnExists(ctx context.Context, table, column string) (bool, error) {
+	var query string
+	switch h.dialect {
+	case database.DialectPostgres:
+		query = `SELECT COUNT(*) > 0 FROM pg_attribute WHERE attrelid = to_regclass($1) AND attname = $2 AND NOT attisdropped`
+	case database.DialectMySQL, database.DialectTiDB:
+		query = `SELECT COUNT(*) > 0 FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`
+	case database.DialectSQLite3, database.DialectTurso:
+		query = `SELECT COUNT(*) > 0 FROM pragma_table_info(?) WHERE name = ?`
+	case database.DialectMSSQL:
+		query = `SELECT CASE WHEN COL_LENGTH(@p1, @p2) IS NULL THEN 0 ELSE 1 END`
+	}
+	return h.exists(ctx, query, table, column)
+}
+
+// CreateTableIfNotExists creates the table with the given column and constraint definitions,
+// e.g., "id BIGINT PRIMARY KEY, name TEXT", unless it already exists.
+func (h *Helper) CreateTableIfNotExists(ctx context.Context, table, definitions string) error {
+	if h.dialect == database.DialectMSSQL {
+		exists, err := h.TableExists(ctx, table)
+		if err != nil || exists {
+			return err
+		}
+		return h.exec(ctx, fmt.Sprintf("CREATE TABLE %s (%s)", table, definitions))
+	}
+	return h.exec(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", table, definitions))
+}
+
+// AddColumnIfNotExists adds the column with the given definition, e.g., "TEXT NOT NULL", unless
+// the table already has it.
+func (h *Helper) AddColumnIfNotExists(ctx context.Context, table, column, definition string) error {
+	switch h.dialect {
+	case database.DialectPostgres:
+		return h.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s", table, column, definition))
+	}
+	exists, err := h.ColumnExists(ctx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	if h.dialect == database.DialectMSSQL {
+		return h.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD %s %s", table, column, definition))
+	}
+	return h.exec(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+}
+
+// DropColumnIfExists drops the column, if the table has it.
+func (h *Helper) DropColumnIfExists(ctx context.Context, table, column string) error {
+	switch h.dialect {
+	case database.DialectPostgres, database.DialectMSSQL:
+		return h.exec(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s", table, column))
+	}
+	exists, err := h.ColumnExists(ctx, table, column)
+	if err != nil || !exists {
+		return err
+	}
+	return h.exec(ctx, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", table, column))
+}
+
+func (h *Helper) exists(ctx context.Context, query string, args ...any) (bool, error) {
+	var exists bool
+	if err := h.db.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (h *Helper) exec(ctx context.Context, query string) error {
+	_, err := h.db.ExecContext(ctx, query)
+	return err
+}
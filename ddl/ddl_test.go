@@ -0,0 +1,50 @@
+package ddl_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/ddl"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestHelper(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	h, err := ddl.New(database.DialectSQLite3, db)
+	require.NoError(t, err)
+	exists, err := h.TableExists(ctx, "users")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// Each helper may be called repeatedly.
+	for i := 0; i < 2; i++ {
+		require.NoError(t, h.CreateTableIfNotExists(ctx, "users", "id INTEGER PRIMARY KEY"))
+		require.NoError(t, h.AddColumnIfNotExists(ctx, "users", "email", "TEXT NOT NULL DEFAULT ''"))
+	}
+	exists, err = h.TableExists(ctx, "users")
+	require.NoError(t, err)
+	require.True(t, exists)
+	exists, err = h.ColumnExists(ctx, "users", "email")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, h.DropColumnIfExists(ctx, "users", "email"))
+	}
+	exists, err = h.ColumnExists(ctx, "users", "email")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = ddl.New(database.DialectVertica, db)
+	require.ErrorIs(t, err, ddl.ErrUnsupported)
+}
@@ -0,0 +1,85 @@
+// Package ddlguard installs a Postgres event trigger that rejects DDL not run through goose, to
+// enforce that all schema changes go through migrations.
+//
+// The trigger allows DDL on sessions tagged by a provider created with [goose.WithSessionTag],
+// and rejects it everywhere else. It is typically installed by a migration of its own:
+//
+//	func up(ctx context.Context, tx *sql.Tx) error {
+//		return ddlguard.Install(ctx, tx)
+//	}
+//
+//	func down(ctx context.Context, tx *sql.Tx) error {
+//		return ddlguard.Uninstall(ctx, tx)
+//	}
+//
+// Alternatively, the statements returned by [InstallStatements] can be copied into a SQL migration.
+//
+// Installing an event trigger requires a superuser. Go migrations registered with a *sql.DB do
+// not run on the tagged session, and are rejected unless they set the tag themselves. In an
+// emergency, a DBA can bypass the guard for a session with:
+//
+//	SET goose.migration TO 'on';
+package ddlguard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+)
+
+// Name is the name of the event trigger and of its function.
+const Name = "goose_ddl_guard"
+
+// InstallStatements returns the statements that create the event trigger, replacing its function
+// if it exists.
+func InstallStatements() []string {
+	return []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s() RETURNS event_trigger LANGUAGE plpgsql AS $$
+BEGIN
+	IF coalesce(current_setting('%s', true), '') <> 'on' THEN
+		RAISE EXCEPTION '%% must be run through a goose migration', tg_tag
+			USING HINT = 'Add a migration, or run SET %s TO ''on'' to bypass the guard.';
+	END IF;
+END;
+$$`, Name, goose.SessionTag, goose.SessionTag),
+		fmt.Sprintf("DROP EVENT TRIGGER IF EXISTS %s", Name),
+		// EXECUTE PROCEDURE is used over EXECUTE FUNCTION, which requires Postgres 11.
+		fmt.Sprintf("CREATE EVENT TRIGGER %s ON ddl_command_start EXECUTE PROCEDURE %s()", Name, Name),
+	}
+}
+
+// UninstallStatements returns the statements that drop the event trigger and its function.
+func UninstallStatements() []string {
+	return []string{
+		fmt.Sprintf("DROP EVENT TRIGGER IF EXISTS %s", Name),
+		fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", Name),
+	}
+}
+
+// Install creates the event trigger, see [InstallStatements]. It must run on a session tagged
+// with [goose.WithSessionTag] if the guard is already installed, e.g., in a migration.
+func Install(ctx context.Context, db database.DBTxConn) error {
+	if err := exec(ctx, db, InstallStatements()); err != nil {
+		return fmt.Errorf("failed to install ddl guard: %w", err)
+	}
+	return nil
+}
+
+// Uninstall drops the event trigger, see [UninstallStatements].
+func Uninstall(ctx context.Context, db database.DBTxConn) error {
+	if err := exec(ctx, db, UninstallStatements()); err != nil {
+		return fmt.Errorf("failed to uninstall ddl guard: %w", err)
+	}
+	return nil
+}
+
+func exec(ctx context.Context, db database.DBTxConn, statements []string) error {
+	for _, stmt := range statements {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
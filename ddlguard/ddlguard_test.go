@@ -0,0 +1,42 @@
+package ddlguard_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3/ddlguard"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstall(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, rec := goosetest.NewDB()
+	require.NoError(t, ddlguard.Install(ctx, db))
+	queries := rec.Queries()
+	require.Len(t, queries, 3)
+	require.Contains(t, queries[0], "CREATE OR REPLACE FUNCTION goose_ddl_guard() RETURNS event_trigger")
+	require.Contains(t, queries[0], "current_setting('goose.migration', true)")
+	require.Contains(t, queries[0], "RAISE EXCEPTION '% must be run through a goose migration', tg_tag")
+	require.Equal(t, "CREATE EVENT TRIGGER goose_ddl_guard ON ddl_command_start EXECUTE PROCEDURE goose_ddl_guard()", queries[2])
+
+	rec.Reset()
+	require.NoError(t, ddlguard.Uninstall(ctx, db))
+	require.Equal(t, ddlguard.UninstallStatements(), rec.Queries())
+
+	failure := errors.New("must be superuser")
+	db, _ = goosetest.NewDB(goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+		if strings.HasPrefix(query, "CREATE EVENT TRIGGER") {
+			return nil, failure
+		}
+		return nil, nil
+	}))
+	err := ddlguard.Install(ctx, db)
+	require.ErrorIs(t, err, failure)
+	require.ErrorContains(t, err, "failed to install ddl guard")
+}
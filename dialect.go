@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/controller"
 	"github.com/pressly/goose/v3/internal/dialect"
 )
 
@@ -25,11 +26,23 @@ const (
 
 func init() {
 	store, _ = dialect.NewStore(dialect.Postgres)
+	storeDialect = dialect.Postgres
 }
 
-var store dialect.Store
+var (
+	store        dialect.Store
+	storeDialect dialect.Dialect
+)
+
+func getStore() dialect.Store {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return store
+}
 
 // SetDialect sets the dialect to use for the goose package.
+//
+// Deprecated: Use [NewProvider] and pass the dialect directly.
 func SetDialect(s string) error {
 	var d dialect.Dialect
 	switch s {
@@ -58,7 +71,34 @@ func SetDialect(s string) error {
 	default:
 		return fmt.Errorf("%q: unknown dialect", s)
 	}
-	var err error
-	store, err = dialect.NewStore(d)
-	return err
+	newStore, err := dialect.NewStore(d)
+	if err != nil {
+		return err
+	}
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	store = newStore
+	storeDialect = d
+	return nil
+}
+
+// getDialect returns the package-level dialect, set with [SetDialect].
+func getDialect() Dialect {
+	globalMu.RLock()
+	d := Dialect(storeDialect)
+	globalMu.RUnlock()
+	if d == Dialect(dialect.Sqlserver) {
+		return DialectMSSQL
+	}
+	return d
+}
+
+// getMetadataStore returns a store for the metadata table that belongs to the package-level
+// dialect and version table.
+func getMetadataStore() (*controller.StoreController, error) {
+	s, err := database.NewStore(getDialect(), TableName())
+	if err != nil {
+		return nil, err
+	}
+	return controller.NewStoreController(s), nil
 }
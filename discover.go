@@ -0,0 +1,92 @@
+package goose
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// MigrationDir is a migrations directory found by [DiscoverMigrationDirs].
+type MigrationDir struct {
+	// Dir is the path of the directory, relative to the root of the repository.
+	Dir string `json:"dir"`
+	// Scope is the part of Dir matched by the wildcards of the pattern, e.g., "services/billing"
+	// for services/billing/db/migrations and the pattern **/db/migrations, or empty if the pattern
+	// matched the literal path.
+	Scope string `json:"scope"`
+}
+
+// DiscoverMigrationDirs walks fsys, the root of a repository, and returns the directories matching
+// pattern, in lexical order, so that the migrations of every module of a monorepo can be validated
+// or planned in a single command. The pattern is a slash-separated path, whose elements are
+// matched with [path.Match], and "**" matches any number of directories, e.g.,
+// "**/db/migrations" or "services/*/migrations". Hidden directories, such as .git, are skipped.
+//
+// Each directory is mapped to a scope, the part of its path before the literal elements at the
+// end of the pattern, see [MigrationDir].
+func DiscoverMigrationDirs(fsys fs.FS, pattern string) ([]MigrationDir, error) {
+	if fsys == nil {
+		return nil, errors.New("fsys must not be nil")
+	}
+	elems := strings.Split(strings.Trim(path.Clean(pattern), "/"), "/")
+	if pattern == "" || elems[0] == "." {
+		return nil, errors.New("pattern must not be empty")
+	}
+	for _, elem := range elems {
+		// Validate the pattern upfront, it would otherwise fail to match silently.
+		if _, err := path.Match(elem, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	// The literal elements at the end of the pattern are the same for every directory.
+	var literal int
+	for i := len(elems) - 1; i >= 0 && !strings.ContainsAny(elems[i], `*?[\`); i-- {
+		literal++
+	}
+	var dirs []MigrationDir
+	err := fs.WalkDir(fsys, ".", func(fullpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() || fullpath == "." {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		parts := strings.Split(fullpath, "/")
+		if matchElems(elems, parts) {
+			dirs = append(dirs, MigrationDir{Dir: fullpath, Scope: strings.Join(parts[:len(parts)-literal], "/")})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+// matchElems reports whether the elements of a path match the elements of a pattern, where "**"
+// matches any number of elements.
+func matchElems(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchElems(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
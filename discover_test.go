@@ -0,0 +1,52 @@
+package goose_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverMigrationDirs(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"db/migrations/00001_a.sql":                          newMapFile("-- +goose Up\n"),
+		"services/billing/db/migrations/00001_a.sql":         newMapFile("-- +goose Up\n"),
+		"services/billing/db/migrations/archive/00000_a.sql": newMapFile("-- +goose Up\n"),
+		"services/users/db/migrations/00001_a.sql":           newMapFile("-- +goose Up\n"),
+		"services/users/db/seeds/00001_a.sql":                newMapFile("-- +goose Up\n"),
+		".git/db/migrations/HEAD":                            newMapFile(""),
+	}
+	dirs, err := goose.DiscoverMigrationDirs(fsys, "**/db/migrations")
+	require.NoError(t, err)
+	require.Equal(t, []goose.MigrationDir{
+		{Dir: "db/migrations", Scope: ""},
+		{Dir: "services/billing/db/migrations", Scope: "services/billing"},
+		{Dir: "services/users/db/migrations", Scope: "services/users"},
+	}, dirs)
+
+	dirs, err = goose.DiscoverMigrationDirs(fsys, "services/*/db/migrations")
+	require.NoError(t, err)
+	require.Equal(t, []goose.MigrationDir{
+		{Dir: "services/billing/db/migrations", Scope: "services/billing"},
+		{Dir: "services/users/db/migrations", Scope: "services/users"},
+	}, dirs)
+
+	dirs, err = goose.DiscoverMigrationDirs(fsys, "services/users/db/*")
+	require.NoError(t, err)
+	require.Equal(t, []goose.MigrationDir{
+		{Dir: "services/users/db/migrations", Scope: "services/users/db/migrations"},
+		{Dir: "services/users/db/seeds", Scope: "services/users/db/seeds"},
+	}, dirs)
+
+	dirs, err = goose.DiscoverMigrationDirs(fsys, "db/migrations")
+	require.NoError(t, err)
+	require.Equal(t, []goose.MigrationDir{{Dir: "db/migrations", Scope: ""}}, dirs)
+
+	_, err = goose.DiscoverMigrationDirs(fsys, "")
+	require.Error(t, err)
+	_, err = goose.DiscoverMigrationDirs(fsys, "**/[")
+	require.Error(t, err)
+}
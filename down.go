@@ -18,7 +18,7 @@ func DownContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFun
 	for _, f := range opts {
 		f(option)
 	}
-	migrations, err := CollectMigrations(option.scope, dir, minVersion, maxVersion)
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
@@ -53,7 +53,7 @@ func DownToContext(ctx context.Context, db *sql.DB, dir string, version int64, o
 	for _, f := range opts {
 		f(option)
 	}
-	migrations, err := CollectMigrations(option.scope, dir, minVersion, maxVersion)
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
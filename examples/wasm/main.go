@@ -0,0 +1,69 @@
+//go:build js && wasm
+
+// Command wasm exposes the goose planner to JavaScript, so web-based review tools can parse and
+// plan migrations client-side.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"syscall/js"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+)
+
+func main() {
+	js.Global().Set("goosePlan", js.FuncOf(plan))
+	js.Global().Set("gooseParse", js.FuncOf(parse))
+	select {}
+}
+
+// plan takes an object of migration file contents by name and an array of applied versions, and
+// returns the plan as JSON, or an object with an error.
+func plan(_ js.Value, args []js.Value) any {
+	if len(args) != 2 {
+		return errorValue("goosePlan(files, applied) takes 2 arguments")
+	}
+	fsys := fstest.MapFS{}
+	keys := js.Global().Get("Object").Call("keys", args[0])
+	for i := 0; i < keys.Length(); i++ {
+		name := keys.Index(i).String()
+		fsys[name] = &fstest.MapFile{Data: []byte(args[0].Get(name).String())}
+	}
+	applied := make([]int64, 0, args[1].Length())
+	for i := 0; i < args[1].Length(); i++ {
+		applied = append(applied, int64(args[1].Index(i).Int()))
+	}
+	p, err := goose.PlanFS(context.Background(), fsys, ".", applied)
+	if err != nil {
+		return errorValue(err.Error())
+	}
+	return jsonValue(p)
+}
+
+// parse takes the contents of a SQL migration and returns it parsed as JSON, or an object with an
+// error.
+func parse(_ js.Value, args []js.Value) any {
+	if len(args) != 1 {
+		return errorValue("gooseParse(sql) takes 1 argument")
+	}
+	parsed, err := goose.ParseSQL(strings.NewReader(args[0].String()))
+	if err != nil {
+		return errorValue(err.Error())
+	}
+	return jsonValue(parsed)
+}
+
+func jsonValue(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errorValue(err.Error())
+	}
+	return string(data)
+}
+
+func errorValue(msg string) any {
+	return map[string]any{"error": msg}
+}
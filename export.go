@@ -0,0 +1,390 @@
+package goose
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"go.uber.org/multierr"
+)
+
+// ExportFormat is a migration tool whose migrations [Export] writes.
+type ExportFormat string
+
+const (
+	// ExportFlyway writes Flyway SQL migrations, V1__name.sql with U1__name.sql undo migrations
+	// for Down sections and R__name.sql for repeatable migrations, and the flyway_schema_history
+	// table.
+	ExportFlyway ExportFormat = "flyway"
+	// ExportGolangMigrate writes golang-migrate migrations, 1_name.up.sql and 1_name.down.sql, and
+	// the schema_migrations table.
+	ExportGolangMigrate ExportFormat = "golang-migrate"
+)
+
+// WithExportTo sets the tool whose migrations the export command writes, see [Export].
+func WithExportTo(format ExportFormat) OptionsFunc {
+	return func(o *options) { o.exportTo = format }
+}
+
+// ExportedMigration is a migration written by [Export].
+type ExportedMigration struct {
+	// Version is the goose version of the migration, or 0 for repeatable migrations.
+	Version int64 `json:"version"`
+	// Source is the path of the goose migration file.
+	Source string `json:"source"`
+	// Paths are the paths of the files written, the up migration first.
+	Paths []string `json:"paths"`
+	// Applied reports whether the goose version table records the migration as applied, so it was
+	// recorded in the other tool's history table.
+	Applied bool `json:"applied"`
+}
+
+// Export writes the SQL migrations in dir as migrations of the migration tool of the given format
+// to dstDir, keeping their versions, so a database managed by goose can be handed over to a team
+// using the other tool. Go migrations cannot be exported. Flyway runs each migration in a
+// transaction, so a Flyway script configuration file disabling it is written for migrations
+// annotated with -- +goose NO TRANSACTION. The Up and Down sections are copied with their
+// comments but without goose annotations, so environment variables of -- +goose ENVSUB sections
+// are not substituted.
+//
+// If db is not nil, the migrations the goose version table records as applied are recorded in
+// the other tool's history table, flyway_schema_history or schema_migrations, which is created if
+// it does not exist and must otherwise be empty. Since golang-migrate only records the current
+// version, exporting to it fails if a migration before the current version is pending. The history
+// is written in a single transaction that is only committed once all files are written.
+func Export(ctx context.Context, db *sql.DB, format ExportFormat, dir, dstDir string, opts ...OptionsFunc) ([]*ExportedMigration, error) {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if db != nil && option.noVersioning {
+		return nil, errors.New("export requires versioning: applied migrations must be tracked in the version table")
+	}
+	if format != ExportFlyway && format != ExportGolangMigrate {
+		return nil, fmt.Errorf("unsupported export format %q, must be one of: %s, %s",
+			format, ExportFlyway, ExportGolangMigrate)
+	}
+	migrations, err := readExportMigrations(dir, format)
+	if err != nil {
+		return nil, err
+	}
+	exported := make([]*ExportedMigration, 0, len(migrations))
+	for _, m := range migrations {
+		exported = append(exported, &ExportedMigration{
+			Version: m.version,
+			Source:  m.source,
+			Paths:   m.paths(format, dstDir),
+		})
+	}
+	var tx *sql.Tx
+	if db != nil {
+		records, err := getStore().ListMigrations(ctx, db, TableName())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list migrations: %w", err)
+		}
+		applied := make(map[int64]bool, len(records))
+		for _, r := range records {
+			applied[r.VersionID] = true
+		}
+		for _, m := range exported {
+			m.Applied = m.Version > 0 && applied[m.Version]
+		}
+		if tx, err = exportHistory(ctx, db, format, migrations, exported); err != nil {
+			return nil, fmt.Errorf("failed to write %s history: %w", format, err)
+		}
+	}
+	var written []string
+	restore := func() error {
+		var errs error
+		for _, path := range written {
+			errs = multierr.Append(errs, os.Remove(path))
+		}
+		if tx != nil {
+			errs = multierr.Append(errs, tx.Rollback())
+		}
+		return errs
+	}
+	if err := os.MkdirAll(dstDir, 0755); err != nil {
+		return nil, multierr.Append(fmt.Errorf("failed to create export directory: %w", err), restore())
+	}
+	for i, m := range migrations {
+		for j, data := range m.files(format) {
+			path := exported[i].Paths[j]
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				return nil, multierr.Append(fmt.Errorf("failed to export %s: %s already exists", m.source, path), restore())
+			}
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				return nil, multierr.Append(fmt.Errorf("failed to export %s: %w", m.source, err), restore())
+			}
+			written = append(written, path)
+		}
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			tx = nil
+			return nil, multierr.Append(fmt.Errorf("failed to commit history: %w", err), restore())
+		}
+	}
+	for _, m := range exported {
+		log.Printf("EXPORTED %s => %s\n", filepath.Base(m.Source), filepath.Base(m.Paths[0]))
+	}
+	return exported, nil
+}
+
+// exportMigration is a goose SQL migration to write for another tool.
+type exportMigration struct {
+	// version is the goose version, or 0 for a repeatable migration.
+	version int64
+	// name is the name of the migration without version and extension, e.g., create_users.
+	name     string
+	source   string
+	up, down string
+	useTx    bool
+}
+
+// paths returns the paths of the files written for m, in the order of [exportMigration.files].
+func (m *exportMigration) paths(format ExportFormat, dstDir string) []string {
+	var names []string
+	switch {
+	case m.version == 0:
+		names = []string{repeatablePrefix + m.name + ".sql"}
+	case format == ExportGolangMigrate:
+		names = []string{fmt.Sprintf("%d_%s.up.sql", m.version, m.name)}
+		if m.down != "" {
+			names = append(names, fmt.Sprintf("%d_%s.down.sql", m.version, m.name))
+		}
+	default:
+		names = []string{fmt.Sprintf("V%d__%s.sql", m.version, m.name)}
+		if m.down != "" {
+			names = append(names, fmt.Sprintf("U%d__%s.sql", m.version, m.name))
+		}
+		if !m.useTx {
+			names = append(names, names[0]+".conf")
+		}
+	}
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		paths = append(paths, filepath.Join(dstDir, name))
+	}
+	return paths
+}
+
+// files returns the contents of the files written for m.
+func (m *exportMigration) files(format ExportFormat) []string {
+	files := []string{m.up}
+	if m.version > 0 && m.down != "" {
+		files = append(files, m.down)
+	}
+	if format == ExportFlyway && m.version > 0 && !m.useTx {
+		files = append(files, "executeInTransaction=false\n")
+	}
+	return files
+}
+
+// readExportMigrations reads the SQL migrations in dir, in version order followed by repeatable
+// migrations.
+func readExportMigrations(dir string, format ExportFormat) ([]*exportMigration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	var migrations, repeatables []*exportMigration
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() {
+			continue
+		}
+		if filepath.Ext(name) == ".go" && !strings.HasSuffix(name, "_test.go") {
+			if _, err := NumericComponent(name); err == nil {
+				return nil, fmt.Errorf("go migration %s cannot be exported", name)
+			}
+			continue
+		}
+		if filepath.Ext(name) != ".sql" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration: %w", err)
+		}
+		if desc, ok := strings.CutPrefix(name, repeatablePrefix); ok {
+			if format == ExportGolangMigrate {
+				return nil, fmt.Errorf("repeatable migration %s cannot be exported to %s", name, format)
+			}
+			// Repeatable migrations have no annotations and are copied as is.
+			repeatables = append(repeatables, &exportMigration{
+				name:   strings.TrimSuffix(desc, ".sql"),
+				source: filepath.Join(dir, name),
+				up:     strings.TrimSpace(string(data)) + "\n",
+			})
+			continue
+		}
+		version, err := NumericComponent(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse SQL migration file %q: %w", name, err)
+		}
+		m := &exportMigration{version: version, source: filepath.Join(dir, name)}
+		_, m.name, _ = strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		if m.name == "" {
+			m.name = "migration"
+		}
+		// The file is parsed to validate it and read its transaction mode, but sections are copied
+		// with their comments.
+		if _, m.useTx, err = sqlparser.ParseSQLMigration(bytes.NewReader(data), sqlparser.DirectionUp, false); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		if _, _, err := sqlparser.ParseSQLMigration(bytes.NewReader(data), sqlparser.DirectionDown, false); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+		m.up, m.down = exportSections(string(data))
+		migrations = append(migrations, m)
+	}
+	if len(migrations) == 0 && len(repeatables) == 0 {
+		return nil, ErrNoMigrationFiles
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("duplicate version %d: %s and %s",
+				migrations[i].version, migrations[i-1].source, migrations[i].source)
+		}
+	}
+	sort.Slice(repeatables, func(i, j int) bool { return repeatables[i].name < repeatables[j].name })
+	return append(migrations, repeatables...), nil
+}
+
+// exportSections returns the Up and Down sections of a SQL migration, without goose annotations.
+func exportSections(data string) (up, down string) {
+	var b [2]strings.Builder
+	section := -1
+	for _, line := range strings.Split(data, "\n") {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "--"); ok && strings.HasPrefix(strings.TrimSpace(rest), "+goose") {
+			switch strings.ToLower(strings.Join(strings.Fields(rest)[1:], " ")) {
+			case "up":
+				section = 0
+			case "down":
+				section = 1
+			}
+			continue
+		}
+		if section >= 0 {
+			b[section].WriteString(line + "\n")
+		}
+	}
+	for i, s := range b {
+		if s := strings.TrimSpace(s.String()); s != "" {
+			if i == 0 {
+				up = s + "\n"
+			} else {
+				down = s + "\n"
+			}
+		}
+	}
+	return up, down
+}
+
+// exportHistory records the applied exported migrations in the history table of the other tool,
+// in a transaction that is returned uncommitted.
+func exportHistory(ctx context.Context, db *sql.DB, format ExportFormat, migrations []*exportMigration, exported []*ExportedMigration) (*sql.Tx, error) {
+	var table, create string
+	var queries []string
+	switch format {
+	case ExportGolangMigrate:
+		table = "schema_migrations"
+		create = "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)"
+		var current, pending int64
+		for _, m := range exported {
+			switch {
+			case !m.Applied:
+				if pending == 0 {
+					pending = m.Version
+				}
+			case pending > 0:
+				return nil, fmt.Errorf("version %d is pending before applied version %d, apply it before exporting",
+					pending, m.Version)
+			default:
+				current = m.Version
+			}
+		}
+		if current > 0 {
+			queries = append(queries, fmt.Sprintf("INSERT INTO schema_migrations (version, dirty) VALUES (%d, false)", current))
+		}
+	case ExportFlyway:
+		table = "flyway_schema_history"
+		create = `CREATE TABLE IF NOT EXISTS flyway_schema_history (
+	installed_rank integer NOT NULL PRIMARY KEY,
+	version varchar(50),
+	description varchar(200) NOT NULL,
+	type varchar(20) NOT NULL,
+	script varchar(1000) NOT NULL,
+	checksum integer,
+	installed_by varchar(100) NOT NULL,
+	installed_on timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	execution_time integer NOT NULL,
+	success boolean NOT NULL
+)`
+		for i, m := range exported {
+			// Repeatable migrations are recorded as applied, since goose applies them after every
+			// migration run.
+			version := "NULL"
+			if m.Version > 0 {
+				if !m.Applied {
+					continue
+				}
+				version = exportQuote(strconv.FormatInt(m.Version, 10))
+			}
+			queries = append(queries, fmt.Sprintf(`INSERT INTO flyway_schema_history
+	(installed_rank, version, description, type, script, checksum, installed_by, execution_time, success)
+	VALUES (%d, %s, %s, 'SQL', %s, %d, 'goose', 0, true)`,
+				len(queries)+1, version, exportQuote(strings.ReplaceAll(migrations[i].name, "_", " ")),
+				exportQuote(filepath.Base(m.Paths[0])), flywayChecksum(migrations[i].up)))
+		}
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, create); err != nil {
+		return nil, multierr.Append(err, tx.Rollback())
+	}
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+		return nil, multierr.Append(err, tx.Rollback())
+	}
+	if count > 0 {
+		return nil, multierr.Append(fmt.Errorf("%s is not empty", table), tx.Rollback())
+	}
+	for _, q := range queries {
+		if _, err := tx.ExecContext(ctx, q); err != nil {
+			return nil, multierr.Append(err, tx.Rollback())
+		}
+	}
+	return tx, nil
+}
+
+// exportQuote returns s as a SQL string literal.
+func exportQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// flywayChecksum returns the checksum Flyway validates migrations with: the CRC32 of the lines of
+// the script, without line endings and a byte order mark.
+func flywayChecksum(script string) int32 {
+	h := crc32.NewIEEE()
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(script, "\ufeff")))
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		h.Write(scanner.Bytes())
+	}
+	return int32(h.Sum32())
+}
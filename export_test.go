@@ -0,0 +1,183 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestExport(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	newDir := func(t *testing.T) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, data := range map[string]string{
+			"00001_create_users.sql": `-- +goose Up
+CREATE TABLE users (id INTEGER);
+-- important index
+CREATE INDEX users_id ON users (id);
+
+-- +goose Down
+DROP TABLE users;
+`,
+			"00002_add_email.sql": `-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TABLE users ADD COLUMN email TEXT;
+`,
+			"00003_add_name.sql": `-- +goose Up
+ALTER TABLE users ADD COLUMN name TEXT;
+-- +goose Down
+ALTER TABLE users DROP COLUMN name;
+`,
+		} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+		}
+		return dir
+	}
+	newDB := func(t *testing.T) *sql.DB {
+		t.Helper()
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "export.db"))
+		require.NoError(t, err)
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { _ = db.Close() })
+		return db
+	}
+	readFile := func(t *testing.T, path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+	t.Run("golang_migrate", func(t *testing.T) {
+		dir := newDir(t)
+		db := newDB(t)
+		require.NoError(t, goose.UpTo(db, dir, 2))
+		dst := filepath.Join(t.TempDir(), "migrate")
+		exported, err := goose.Export(ctx, db, goose.ExportGolangMigrate, dir, dst)
+		require.NoError(t, err)
+		require.Len(t, exported, 3)
+		require.Equal(t, &goose.ExportedMigration{
+			Version: 1,
+			Source:  filepath.Join(dir, "00001_create_users.sql"),
+			Paths: []string{
+				filepath.Join(dst, "1_create_users.up.sql"),
+				filepath.Join(dst, "1_create_users.down.sql"),
+			},
+			Applied: true,
+		}, exported[0])
+		require.Equal(t, []string{filepath.Join(dst, "2_add_email.up.sql")}, exported[1].Paths)
+		require.True(t, exported[1].Applied)
+		require.False(t, exported[2].Applied)
+		require.Equal(t, "CREATE TABLE users (id INTEGER);\n-- important index\nCREATE INDEX users_id ON users (id);\n",
+			readFile(t, exported[0].Paths[0]))
+		require.Equal(t, "DROP TABLE users;\n", readFile(t, exported[0].Paths[1]))
+
+		var version int64
+		var dirty bool
+		require.NoError(t, db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty))
+		require.EqualValues(t, 2, version)
+		require.False(t, dirty)
+
+		// The history table must be empty.
+		_, err = goose.Export(ctx, db, goose.ExportGolangMigrate, dir, t.TempDir())
+		require.ErrorContains(t, err, "schema_migrations is not empty")
+		// Existing files are not overwritten.
+		_, err = goose.Export(ctx, nil, goose.ExportGolangMigrate, dir, dst)
+		require.ErrorContains(t, err, "already exists")
+	})
+	t.Run("golang_migrate_pending", func(t *testing.T) {
+		dir := newDir(t)
+		db := newDB(t)
+		require.NoError(t, goose.Up(db, dir))
+		require.NoError(t, goose.DownTo(db, dir, 1))
+		_, err := db.ExecContext(ctx, "INSERT INTO goose_db_version (version_id, is_applied) VALUES (3, true)")
+		require.NoError(t, err)
+		dst := filepath.Join(t.TempDir(), "migrate")
+		_, err = goose.Export(ctx, db, goose.ExportGolangMigrate, dir, dst)
+		require.ErrorContains(t, err, "version 2 is pending before applied version 3")
+		_, err = os.Stat(dst)
+		require.True(t, os.IsNotExist(err))
+		// The history is rolled back.
+		var exists int
+		require.NoError(t, db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'").Scan(&exists))
+		require.Zero(t, exists)
+	})
+	t.Run("flyway", func(t *testing.T) {
+		dir := newDir(t)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "R__users_view.sql"),
+			[]byte("DROP VIEW IF EXISTS names;\nCREATE VIEW names AS SELECT name FROM users;\n"), 0644))
+		dst := t.TempDir()
+		exported, err := goose.Export(ctx, nil, goose.ExportFlyway, dir, dst)
+		require.NoError(t, err)
+		var names []string
+		for _, m := range exported {
+			for _, path := range m.Paths {
+				names = append(names, filepath.Base(path))
+			}
+		}
+		require.Equal(t, []string{
+			"V1__create_users.sql",
+			"U1__create_users.sql",
+			"V2__add_email.sql",
+			"V2__add_email.sql.conf",
+			"V3__add_name.sql",
+			"U3__add_name.sql",
+			"R__users_view.sql",
+		}, names)
+		require.Equal(t, "executeInTransaction=false\n", readFile(t, filepath.Join(dst, "V2__add_email.sql.conf")))
+		require.Equal(t, "DROP VIEW IF EXISTS names;\nCREATE VIEW names AS SELECT name FROM users;\n",
+			readFile(t, filepath.Join(dst, "R__users_view.sql")))
+
+		// Repeatable migrations cannot be exported to golang-migrate.
+		_, err = goose.Export(ctx, nil, goose.ExportGolangMigrate, dir, t.TempDir())
+		require.ErrorContains(t, err, "repeatable migration R__users_view.sql cannot be exported")
+	})
+	t.Run("flyway_history", func(t *testing.T) {
+		dir := newDir(t)
+		db := newDB(t)
+		require.NoError(t, goose.UpTo(db, dir, 2))
+		_, err := goose.Export(ctx, db, goose.ExportFlyway, dir, t.TempDir())
+		require.NoError(t, err)
+		rows, err := db.QueryContext(ctx,
+			"SELECT installed_rank, version, description, script, checksum, success FROM flyway_schema_history ORDER BY installed_rank")
+		require.NoError(t, err)
+		defer rows.Close()
+		type history struct {
+			rank                         int
+			version, description, script string
+			checksum                     int32
+			success                      bool
+		}
+		var got []history
+		for rows.Next() {
+			var h history
+			require.NoError(t, rows.Scan(&h.rank, &h.version, &h.description, &h.script, &h.checksum, &h.success))
+			got = append(got, h)
+		}
+		require.NoError(t, rows.Err())
+		// Checksums are the CRC32 of the lines of the scripts, as computed by Flyway.
+		require.Equal(t, []history{
+			{1, "1", "create users", "V1__create_users.sql", 1504255176, true},
+			{2, "2", "add email", "V2__add_email.sql", 273465196, true},
+		}, got)
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		dir := newDir(t)
+		_, err := goose.Export(ctx, nil, "liquibase", dir, t.TempDir())
+		require.Error(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "00004_backfill.go"), []byte("package migrations\n"), 0644))
+		_, err = goose.Export(ctx, nil, goose.ExportFlyway, dir, t.TempDir())
+		require.ErrorContains(t, err, "go migration 00004_backfill.go cannot be exported")
+	})
+}
@@ -0,0 +1,130 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/gooseutil"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// ExtensionRequirement is a Postgres extension a SQL migration declares with a "-- +goose
+// extension" directive, e.g., "postgis >= 3.0". The extension is created before the Up statements
+// of the migration run, if it does not exist, and its version checked against the constraint.
+type ExtensionRequirement struct {
+	// Name is the name of the extension, e.g., "postgis".
+	Name string `json:"name"`
+	// Constraint is the version constraint as written, e.g., ">= 3.0", or empty if any version
+	// is accepted.
+	Constraint string `json:"constraint,omitempty"`
+	// Source is the path of the migration declaring the extension.
+	Source string `json:"source"`
+
+	// constraint is Constraint normalized for [gooseutil.CheckVersionConstraint].
+	constraint string
+}
+
+func (e ExtensionRequirement) String() string {
+	if e.Constraint == "" {
+		return e.Name
+	}
+	return e.Name + " " + e.Constraint
+}
+
+// extensionNameRe matches the name of an extension, e.g., "postgis" or "uuid-ossp".
+var extensionNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// parseExtensions returns the extensions declared by extension directives, in order.
+func parseExtensions(directives []sqlparser.Directive) ([]ExtensionRequirement, error) {
+	var extensions []ExtensionRequirement
+	for _, d := range directives {
+		if d.Name != sqlparser.DirectiveExtension {
+			continue
+		}
+		name, constraint, _ := strings.Cut(d.Value, " ")
+		if !extensionNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid %s directive %q: must be an extension name, optionally followed by a version constraint, e.g., postgis >= 3.0",
+				sqlparser.DirectiveExtension, d.Value)
+		}
+		e := ExtensionRequirement{Name: name, Constraint: strings.TrimSpace(constraint)}
+		if e.Constraint != "" {
+			match := dbConstraintRe.FindStringSubmatch(e.Constraint)
+			if match == nil {
+				return nil, fmt.Errorf("invalid %s directive %q: invalid version constraint %q, e.g., >= 3.0",
+					sqlparser.DirectiveExtension, d.Value, e.Constraint)
+			}
+			op := match[1]
+			if op == "" {
+				op = ">="
+			}
+			e.constraint = op + " v" + match[2]
+		}
+		extensions = append(extensions, e)
+	}
+	return extensions, nil
+}
+
+// RequiredExtensions returns the Postgres extensions declared by the SQL migrations in dir of
+// fsys, in version order, so that tooling such as "goose validate" can report what a database
+// needs before migrations are applied. It returns an error if a directive is invalid.
+func RequiredExtensions(fsys fs.FS, dir string) ([]ExtensionRequirement, error) {
+	fsys = decompressing(fsys)
+	foundMigrations, err := collectMigrationsCached(nil, "", fsys, dir, minVersion, maxVersion, globalMigrationsSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	var extensions []ExtensionRequirement
+	for _, m := range foundMigrations {
+		if !isSQLFile(m.Source) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, m.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", m.Source, err)
+		}
+		directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration %s: %w", m.Source, err)
+		}
+		found, err := parseExtensions(directives)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", m.Source, err)
+		}
+		for _, e := range found {
+			e.Source = m.Source
+			extensions = append(extensions, e)
+		}
+	}
+	return extensions, nil
+}
+
+// ensureExtensions creates the extensions that do not exist yet and returns an error wrapping
+// [ErrDBRequirement] if the installed version of an extension does not satisfy its constraint.
+func ensureExtensions(ctx context.Context, db database.DBTxConn, extensions []ExtensionRequirement) error {
+	for _, e := range extensions {
+		if _, err := db.ExecContext(ctx, "CREATE EXTENSION IF NOT EXISTS "+quoteIdentifiers([]string{e.Name})); err != nil {
+			return fmt.Errorf("failed to create extension %s: %w", e.Name, err)
+		}
+		if e.constraint == "" {
+			continue
+		}
+		var installed string
+		q := "SELECT extversion FROM pg_extension WHERE extname = $1"
+		if err := db.QueryRowContext(ctx, q, e.Name).Scan(&installed); err != nil {
+			return fmt.Errorf("failed to query version of extension %s: %w", e.Name, err)
+		}
+		v := serverVersionRe.FindString(installed)
+		if v == "" {
+			return fmt.Errorf("failed to parse version %q of extension %s", installed, e.Name)
+		}
+		if err := gooseutil.CheckVersionConstraint(e.constraint, "v"+v); err != nil {
+			return fmt.Errorf("%w: requires extension %s, but version %s is installed", ErrDBRequirement, e, installed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiredExtensions(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"migrations/00002_geo.sql": newMapFile("-- +goose extension postgis >= 3.0\n-- +goose extension uuid-ossp\n" +
+			"-- +goose Up\nCREATE TABLE places (id uuid, location geometry);\n"),
+		"migrations/00003_trgm.sql": newMapFile("-- +goose extension pg_trgm 1.6\n-- +goose Up\nSELECT 1;\n"),
+	}
+	extensions, err := goose.RequiredExtensions(fsys, "migrations")
+	require.NoError(t, err)
+	require.Len(t, extensions, 3)
+	require.Equal(t, "postgis >= 3.0", extensions[0].String())
+	require.Equal(t, "migrations/00002_geo.sql", extensions[0].Source)
+	require.Equal(t, "uuid-ossp", extensions[1].String())
+	require.Equal(t, "pg_trgm", extensions[2].Name)
+	require.Equal(t, "1.6", extensions[2].Constraint)
+
+	fsys["migrations/00004_bad.sql"] = newMapFile("-- +goose extension postgis newest\n-- +goose Up\nSELECT 1;\n")
+	_, err = goose.RequiredExtensions(fsys, "migrations")
+	require.ErrorContains(t, err, `migration migrations/00004_bad.sql: invalid extension directive "postgis newest"`)
+
+	// Extensions are specific to Postgres.
+	ctx := context.Background()
+	delete(fsys, "migrations/00004_bad.sql")
+	db := newDB(t)
+	sub, err := fsys.Sub("migrations")
+	require.NoError(t, err)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, sub)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorContains(t, err, "extension directive requires the postgres dialect")
+	require.False(t, tableExists(t, db, "a"))
+}
@@ -11,7 +11,7 @@ const seqVersionTemplate = "%05v"
 
 func Fix(dir string) error {
 	// always use osFS here because it's modifying operation
-	migrations, err := collectMigrationsFS("", osFS{}, dir, minVersion, maxVersion, registeredGoMigrations)
+	migrations, err := collectMigrationsFS("", osFS{}, dir, minVersion, maxVersion, globalMigrationsSnapshot())
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,158 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FixtureTablePrefix is the prefix of the version tables that track fixture sets, see
+// [FixtureTableName].
+const FixtureTablePrefix = "goose_fixture_"
+
+var fixtureSetName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// FixtureTableName returns the version table that tracks the fixture set, e.g.,
+// "goose_fixture_load_test" for the set "load-test".
+func FixtureTableName(set string) string {
+	return FixtureTablePrefix + strings.ReplaceAll(set, "-", "_")
+}
+
+// FixtureResult is the result of applying or resetting a fixture set.
+type FixtureResult struct {
+	Set     string
+	Results []*MigrationResult
+}
+
+// FixtureStatus is the status of the migrations of a fixture set.
+type FixtureStatus struct {
+	Set      string             `json:"set"`
+	Statuses []*MigrationStatus `json:"statuses"`
+}
+
+// ListFixtureSets returns the names of the fixture sets in fsys, which are its subdirectories, in
+// sorted order.
+func ListFixtureSets(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fixture sets: %w", err)
+	}
+	var sets []string
+	for _, e := range entries {
+		if e.IsDir() && fixtureSetName.MatchString(e.Name()) {
+			sets = append(sets, e.Name())
+		}
+	}
+	sort.Strings(sets)
+	return sets, nil
+}
+
+// ApplyFixtures applies the named fixture sets of fsys to the database, in the given order, e.g.,
+// "minimal" then "demo", so sets can be composed to provision an environment with the data it
+// needs.
+//
+// A fixture set is a subdirectory of fsys holding migrations, typically SQL files inserting data.
+// Each set is tracked in its own version table, named by [FixtureTableName], independently of the
+// schema migrations and of other sets, so a set is only applied once and can be reset on its own.
+// The provider options are applied to the provider of every set; the table name is always set,
+// and Go migrations registered globally are not included unless enabled with
+// [WithDisableGlobalRegistry].
+func ApplyFixtures(ctx context.Context, dialect Dialect, db *sql.DB, fsys fs.FS, sets []string, opts ...ProviderOption) ([]*FixtureResult, error) {
+	providers, err := newFixtureProviders(dialect, db, fsys, sets, opts)
+	if err != nil {
+		return nil, err
+	}
+	var results []*FixtureResult
+	for i, p := range providers {
+		applied, err := p.Up(ctx)
+		if len(applied) > 0 || err == nil {
+			results = append(results, &FixtureResult{Set: sets[i], Results: applied})
+		}
+		if err != nil {
+			return results, fmt.Errorf("failed to apply fixture set %s: %w", sets[i], err)
+		}
+	}
+	return results, nil
+}
+
+// ResetFixtures rolls back all migrations of the named fixture sets, in the reverse of the given
+// order, undoing [ApplyFixtures] with the same sets.
+func ResetFixtures(ctx context.Context, dialect Dialect, db *sql.DB, fsys fs.FS, sets []string, opts ...ProviderOption) ([]*FixtureResult, error) {
+	providers, err := newFixtureProviders(dialect, db, fsys, sets, opts)
+	if err != nil {
+		return nil, err
+	}
+	var results []*FixtureResult
+	for i := len(providers) - 1; i >= 0; i-- {
+		rolledBack, err := providers[i].DownTo(ctx, 0)
+		if len(rolledBack) > 0 || err == nil {
+			results = append(results, &FixtureResult{Set: sets[i], Results: rolledBack})
+		}
+		if err != nil {
+			return results, fmt.Errorf("failed to reset fixture set %s: %w", sets[i], err)
+		}
+	}
+	return results, nil
+}
+
+// FixtureStatuses returns the status of the named fixture sets, or of all sets in fsys if none
+// are named.
+func FixtureStatuses(ctx context.Context, dialect Dialect, db *sql.DB, fsys fs.FS, sets []string, opts ...ProviderOption) ([]*FixtureStatus, error) {
+	if len(sets) == 0 {
+		var err error
+		if sets, err = ListFixtureSets(fsys); err != nil {
+			return nil, err
+		}
+	}
+	providers, err := newFixtureProviders(dialect, db, fsys, sets, opts)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*FixtureStatus, 0, len(providers))
+	for i, p := range providers {
+		s, err := p.Status(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status of fixture set %s: %w", sets[i], err)
+		}
+		statuses = append(statuses, &FixtureStatus{Set: sets[i], Statuses: s})
+	}
+	return statuses, nil
+}
+
+func newFixtureProviders(dialect Dialect, db *sql.DB, fsys fs.FS, sets []string, opts []ProviderOption) ([]*Provider, error) {
+	if len(sets) == 0 {
+		return nil, errors.New("no fixture sets selected")
+	}
+	seen := make(map[string]bool)
+	providers := make([]*Provider, 0, len(sets))
+	for _, set := range sets {
+		if !fixtureSetName.MatchString(set) {
+			return nil, fmt.Errorf("invalid fixture set name %q: must only contain letters, digits, underscores and hyphens", set)
+		}
+		table := FixtureTableName(set)
+		if seen[table] {
+			return nil, fmt.Errorf("fixture set %s selected more than once", set)
+		}
+		seen[table] = true
+		sub, err := fs.Sub(fsys, set)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fs.Stat(sub, "."); err != nil {
+			return nil, fmt.Errorf("fixture set %s not found: %w", set, err)
+		}
+		// Go migrations registered globally belong to the schema migrations, not to fixture sets.
+		setOpts := append([]ProviderOption{WithDisableGlobalRegistry(true)}, opts...)
+		p, err := NewProvider(dialect, db, sub, append(setOpts, WithTableName(table))...)
+		if err != nil {
+			return nil, fmt.Errorf("fixture set %s: %w", set, err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
@@ -0,0 +1,76 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFixtures(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"minimal/00001_users.sql":   newMapFile("-- +goose Up\nINSERT INTO users VALUES (1, 'admin');\n-- +goose Down\nDELETE FROM users WHERE id = 1;\n"),
+		"demo/00001_users.sql":      newMapFile("-- +goose Up\nINSERT INTO users VALUES (2, 'demo');\n-- +goose Down\nDELETE FROM users WHERE id = 2;\n"),
+		"demo/00002_more.sql":       newMapFile("-- +goose Up\nINSERT INTO users VALUES (3, 'guest');\n-- +goose Down\nDELETE FROM users WHERE id = 3;\n"),
+		"load-test/00001_users.sql": newMapFile("-- +goose Up\nINSERT INTO users SELECT 100 + value, 'load' FROM generate_series(1, 50);\n"),
+	}
+	db := newDB(t)
+	_, err := db.ExecContext(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT)")
+	require.NoError(t, err)
+	countUsers := func() int {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n))
+		return n
+	}
+
+	sets, err := goose.ListFixtureSets(fsys)
+	require.NoError(t, err)
+	require.Equal(t, []string{"demo", "load-test", "minimal"}, sets)
+	require.Equal(t, "goose_fixture_load_test", goose.FixtureTableName("load-test"))
+
+	results, err := goose.ApplyFixtures(ctx, goose.DialectSQLite3, db, fsys, []string{"minimal", "demo"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, "minimal", results[0].Set)
+	require.Len(t, results[0].Results, 1)
+	require.Len(t, results[1].Results, 2)
+	require.Equal(t, 3, countUsers())
+	require.True(t, tableExists(t, db, "goose_fixture_minimal"))
+	require.True(t, tableExists(t, db, "goose_fixture_demo"))
+	require.False(t, tableExists(t, db, "goose_db_version"))
+
+	// Sets are tracked independently, so applying a set again only applies what is new.
+	results, err = goose.ApplyFixtures(ctx, goose.DialectSQLite3, db, fsys, []string{"minimal"})
+	require.NoError(t, err)
+	require.Empty(t, results[0].Results)
+
+	statuses, err := goose.FixtureStatuses(ctx, goose.DialectSQLite3, db, fsys, nil)
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	require.Equal(t, "demo", statuses[0].Set)
+	require.Equal(t, goose.StateApplied, statuses[0].Statuses[1].State)
+	require.Equal(t, "load-test", statuses[1].Set)
+	require.Equal(t, goose.StatePending, statuses[1].Statuses[0].State)
+
+	results, err = goose.ResetFixtures(ctx, goose.DialectSQLite3, db, fsys, []string{"demo"})
+	require.NoError(t, err)
+	require.Len(t, results[0].Results, 2)
+	require.Equal(t, 1, countUsers())
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := goose.ApplyFixtures(ctx, goose.DialectSQLite3, db, fsys, nil)
+		require.ErrorContains(t, err, "no fixture sets selected")
+		_, err = goose.ApplyFixtures(ctx, goose.DialectSQLite3, db, fsys, []string{"demo; DROP TABLE users"})
+		require.ErrorContains(t, err, "invalid fixture set name")
+		_, err = goose.ApplyFixtures(ctx, goose.DialectSQLite3, db, fsys, []string{"minimal", "minimal"})
+		require.ErrorContains(t, err, "selected more than once")
+		_, err = goose.ApplyFixtures(ctx, goose.DialectSQLite3, db, fsys, []string{"missing"})
+		require.ErrorContains(t, err, "fixture set missing not found")
+		require.Equal(t, 1, countUsers())
+	})
+}
@@ -0,0 +1,51 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+
+	"go.uber.org/multierr"
+)
+
+// Freeze stops [Up], [UpByOne] and [UpTo] from applying migrations until [Unfreeze] is called. All
+// scopes are frozen, unless a scope is set with [WithOptionScope]. See [Provider.Freeze].
+func Freeze(ctx context.Context, db *sql.DB, reason string, opts ...OptionsFunc) (retErr error) {
+	option := applyOptions(opts)
+	store, err := getMetadataStore()
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, conn.Close())
+	}()
+	if err := freeze(ctx, store, conn, option.scope, reason); err != nil {
+		return err
+	}
+	log.Printf("goose: froze migrations of %s: %s\n", freezeScope(option.scope), reason)
+	return nil
+}
+
+// Unfreeze lifts the freeze set with [Freeze], of all scopes or of the scope set with
+// [WithOptionScope]. See [Provider.Unfreeze].
+func Unfreeze(ctx context.Context, db *sql.DB, opts ...OptionsFunc) error {
+	option := applyOptions(opts)
+	store, err := getMetadataStore()
+	if err != nil {
+		return err
+	}
+	if err := unfreeze(ctx, store, db, option.scope); err != nil {
+		return err
+	}
+	log.Printf("goose: unfroze migrations of %s\n", freezeScope(option.scope))
+	return nil
+}
+
+// WithOptionIgnoreFreeze lets [Up], [UpByOne] and [UpTo] apply migrations even though they are
+// frozen with [Freeze].
+func WithOptionIgnoreFreeze() OptionsFunc {
+	return func(o *options) { o.ignoreFreeze = true }
+}
@@ -0,0 +1,46 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestFreezeLegacy(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_freeze.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00001_a.sql"), []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"), 0644))
+	_, err = goose.EnsureDBVersion(db)
+	require.NoError(t, err)
+
+	require.NoError(t, goose.RunContext(ctx, "freeze", db, dir, "incident", "42"))
+	err = goose.UpByOne(db, dir)
+	require.ErrorIs(t, err, goose.ErrFrozen)
+	require.ErrorContains(t, err, "all scopes since ")
+	require.ErrorContains(t, err, ": incident 42")
+	require.NoError(t, goose.UpByOne(db, dir, goose.WithOptionIgnoreFreeze()))
+	require.ErrorIs(t, goose.Redo(db, dir), goose.ErrFrozen)
+	require.ErrorIs(t, goose.RedoLast(db, dir, 1), goose.ErrFrozen)
+	require.NoError(t, goose.Redo(db, dir, goose.WithOptionIgnoreFreeze()))
+
+	// A freeze of another scope does not apply.
+	require.NoError(t, goose.RunContext(ctx, "unfreeze", db, dir))
+	require.NoError(t, goose.Freeze(ctx, db, "billing incident", goose.WithOptionScope("billing")))
+	require.NoError(t, goose.Up(db, dir))
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, ver)
+}
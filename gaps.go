@@ -0,0 +1,141 @@
+package goose
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GapReport describes problems with migration file numbering that goose tolerates or rejects only
+// when migrations are collected. It is meant to run in CI, before migrations reach a database.
+type GapReport struct {
+	// Gaps are missing versions between consecutive sequential migrations, e.g., 3 followed by 5.
+	// Timestamped migrations are expected to have gaps and are never reported.
+	Gaps []VersionGap `json:"gaps"`
+	// Duplicates are versions used by more than one migration file.
+	Duplicates []DuplicateVersion `json:"duplicates"`
+	// Unparsable are .sql files whose version prefix cannot be parsed. Go files without a version
+	// prefix are not migrations, e.g., the main package of a custom binary, and are ignored.
+	Unparsable []UnparsableFile `json:"unparsable"`
+}
+
+// VersionGap is a range of missing sequential versions. Missing versions are those greater than
+// After and less than Before.
+type VersionGap struct {
+	After  int64 `json:"after"`
+	Before int64 `json:"before"`
+}
+
+// DuplicateVersion is a version shared by multiple migration files.
+type DuplicateVersion struct {
+	Version int64    `json:"version"`
+	Paths   []string `json:"paths"`
+}
+
+// UnparsableFile is a migration file whose version prefix could not be parsed.
+type UnparsableFile struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// OK reports whether the report found no problems.
+func (r *GapReport) OK() bool {
+	return len(r.Gaps) == 0 && len(r.Duplicates) == 0 && len(r.Unparsable) == 0
+}
+
+// ReportGaps checks the migration files in the root of fsys and reports numbering gaps, duplicate
+// versions and SQL files whose version prefix does not parse. Go test files, and Go files without a
+// version prefix, are ignored, as when migrations are collected. Migrations in
+// the [ArchiveDir] subdirectory are included, since they are still part of the history.
+//
+// The returned error is only non-nil if the filesystem cannot be read; problems with the files are
+// reported in the [GapReport].
+func ReportGaps(fsys fs.FS) (*GapReport, error) {
+	if fsys == nil {
+		return nil, errors.New("fsys must not be nil")
+	}
+	var files []string
+	for _, dir := range []string{".", ArchiveDir} {
+		for _, pattern := range append(sqlFilePatterns, "*.go") {
+			pattern = path.Join(dir, pattern)
+			matches, err := fs.Glob(fsys, pattern)
+			if err != nil {
+				return nil, fmt.Errorf("failed to glob pattern %q: %w", pattern, err)
+			}
+			files = append(files, matches...)
+		}
+	}
+	sort.Strings(files)
+
+	report := &GapReport{
+		Gaps:       []VersionGap{},
+		Duplicates: []DuplicateVersion{},
+		Unparsable: []UnparsableFile{},
+	}
+	paths := make(map[int64][]string)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") || strings.HasPrefix(path.Base(file), repeatablePrefix) {
+			continue
+		}
+		version, err := NumericComponent(file)
+		if err != nil && path.Ext(file) == ".go" {
+			continue
+		}
+		if err != nil {
+			report.Unparsable = append(report.Unparsable, UnparsableFile{Path: file, Error: err.Error()})
+			continue
+		}
+		paths[version] = append(paths[version], file)
+	}
+
+	versions := make([]int64, 0, len(paths))
+	for v := range paths {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	var prev int64
+	for _, v := range versions {
+		if len(paths[v]) > 1 {
+			sort.Strings(paths[v])
+			report.Duplicates = append(report.Duplicates, DuplicateVersion{Version: v, Paths: paths[v]})
+		}
+		if isTimestampVersion(v) {
+			continue
+		}
+		if v > prev+1 {
+			report.Gaps = append(report.Gaps, VersionGap{After: prev, Before: v})
+		}
+		prev = v
+	}
+	return report, nil
+}
+
+// String returns a human-readable summary of the report, one problem per line.
+func (r *GapReport) String() string {
+	var b strings.Builder
+	for _, g := range r.Gaps {
+		if g.Before-g.After == 2 {
+			fmt.Fprintf(&b, "gap: version %d is missing\n", g.After+1)
+		} else {
+			fmt.Fprintf(&b, "gap: versions %d-%d are missing\n", g.After+1, g.Before-1)
+		}
+	}
+	for _, d := range r.Duplicates {
+		fmt.Fprintf(&b, "duplicate: version %d is used by %s\n", d.Version, strings.Join(d.Paths, ", "))
+	}
+	for _, u := range r.Unparsable {
+		fmt.Fprintf(&b, "unparsable: %s: %s\n", u.Path, u.Error)
+	}
+	return b.String()
+}
+
+// isTimestampVersion reports whether the version is a timestamp, using the same rule as [Fix].
+func isTimestampVersion(version int64) bool {
+	t, err := time.Parse(timestampFormat, fmt.Sprintf("%d", version))
+	return err == nil && t.After(time.Unix(0, 0))
+}
@@ -0,0 +1,61 @@
+package goose_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportGaps(t *testing.T) {
+	t.Parallel()
+
+	t.Run("ok", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"archive/00001_a.sql":        {},
+			"00002_b.sql":                {},
+			"00003_c.go":                 {},
+			"00003_c_test.go":            {},
+			"main.go":                    {},
+			"v4_helpers.go":              {},
+			"20230101120000_d.sql":       {},
+			"20240101120000_e.sql":       {},
+			"README.md":                  {},
+			"nested/00004_not_collected": {},
+		}
+		report, err := goose.ReportGaps(fsys)
+		require.NoError(t, err)
+		require.True(t, report.OK(), report.String())
+	})
+	t.Run("problems", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql":          {},
+			"00003_b.sql":          {},
+			"00007_c.sql":          {},
+			"00007_d.go":           {},
+			"20230101120000_e.sql": {},
+			"20230101120000_f.sql": {},
+			"v8_bad.sql":           {},
+			"nounderscore.sql":     {},
+		}
+		report, err := goose.ReportGaps(fsys)
+		require.NoError(t, err)
+		require.False(t, report.OK())
+		require.Equal(t, []goose.VersionGap{{After: 1, Before: 3}, {After: 3, Before: 7}}, report.Gaps)
+		require.Equal(t, []goose.DuplicateVersion{
+			{Version: 7, Paths: []string{"00007_c.sql", "00007_d.go"}},
+			{Version: 20230101120000, Paths: []string{"20230101120000_e.sql", "20230101120000_f.sql"}},
+		}, report.Duplicates)
+		require.Len(t, report.Unparsable, 2)
+		require.Equal(t, "nounderscore.sql", report.Unparsable[0].Path)
+		require.Equal(t, "v8_bad.sql", report.Unparsable[1].Path)
+		require.Equal(t, `gap: version 2 is missing
+gap: versions 4-6 are missing
+duplicate: version 7 is used by 00007_c.sql, 00007_d.go
+duplicate: version 20230101120000 is used by 20230101120000_e.sql, 20230101120000_f.sql
+unparsable: nounderscore.sql: no filename separator '_' found
+unparsable: v8_bad.sql: failed to parse version from migration file: v8_bad.sql: strconv.ParseInt: parsing "v8": invalid syntax
+`, report.String())
+	})
+}
@@ -4,25 +4,104 @@ import (
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"sync"
 )
 
 var (
+	// registryMu guards registeredGoMigrations. The registry is written by the goose.Add*
+	// functions, which typically run from init functions, and read when collecting migrations.
+	registryMu             sync.RWMutex
 	registeredGoMigrations = make(map[string]map[int64]*Migration)
 )
 
 // ResetGlobalMigrations resets the global Go migrations registry.
-//
-// Not safe for concurrent use.
 func ResetGlobalMigrations() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
 	registeredGoMigrations = make(map[string]map[int64]*Migration)
 }
 
+// ResetGlobalMigrationsScope removes all Go migrations registered globally under the given scope,
+// leaving other scopes untouched.
+func ResetGlobalMigrationsScope(scope string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(registeredGoMigrations, scope)
+}
+
+// RegisteredGoMigration describes a Go migration in the global registry.
+type RegisteredGoMigration struct {
+	Scope   string
+	Version int64
+	// Source is the file the migration was registered from. It may be empty for migrations
+	// registered with [SetGlobalMigrations] without a source.
+	Source   string
+	UpMode   TransactionMode
+	DownMode TransactionMode
+}
+
+// GlobalMigrationScopes returns the scopes that have at least one Go migration registered
+// globally, sorted in ascending order. The default scope is the empty string.
+func GlobalMigrationScopes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	scopes := make([]string, 0, len(registeredGoMigrations))
+	for scope, versionMap := range registeredGoMigrations {
+		if len(versionMap) > 0 {
+			scopes = append(scopes, scope)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// ListGlobalMigrations returns the Go migrations registered globally under the given scope, sorted
+// in ascending order by version. If nothing is registered for the scope, an empty slice is
+// returned.
+func ListGlobalMigrations(scope string) []*RegisteredGoMigration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	versionMap := registeredGoMigrations[scope]
+	out := make([]*RegisteredGoMigration, 0, len(versionMap))
+	for _, m := range versionMap {
+		out = append(out, &RegisteredGoMigration{
+			Scope:    scope,
+			Version:  m.Version,
+			Source:   m.Source,
+			UpMode:   m.goUp.Mode,
+			DownMode: m.goDown.Mode,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Version < out[j].Version
+	})
+	return out
+}
+
+// SnapshotGlobalMigrations captures the current state of the global Go migrations registry and
+// returns a function that restores it. This is useful in tests that register migrations and need to
+// leave the registry as they found it:
+//
+//	t.Cleanup(goose.SnapshotGlobalMigrations())
+func SnapshotGlobalMigrations() (restore func()) {
+	registryMu.RLock()
+	snapshot := copyRegistry(registeredGoMigrations)
+	registryMu.RUnlock()
+	return func() {
+		registryMu.Lock()
+		defer registryMu.Unlock()
+		registeredGoMigrations = copyRegistry(snapshot)
+	}
+}
+
 // SetGlobalMigrations registers Go migrations globally. It returns an error if a migration with the
 // same version has already been registered. Go migrations must be constructed using the
 // [NewGoMigration] function.
-//
-// Not safe for concurrent use.
 func SetGlobalMigrations(scope string, migrations ...*Migration) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
 	if _, ok := registeredGoMigrations[scope]; !ok {
 		registeredGoMigrations[scope] = make(map[int64]*Migration)
 	}
@@ -34,11 +113,33 @@ func SetGlobalMigrations(scope string, migrations ...*Migration) error {
 		if err := checkGoMigration(m); err != nil {
 			return fmt.Errorf("invalid go migration: %w", err)
 		}
+		m.Source = normalizePath(m.Source)
+		m.scope = scope
 		registeredGoMigrations[scope][m.Version] = m
 	}
 	return nil
 }
 
+// globalMigrationsSnapshot returns a copy of the global registry that callers may read and modify
+// without holding registryMu. The migrations themselves are shared, not copied.
+func globalMigrationsSnapshot() map[string]map[int64]*Migration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return copyRegistry(registeredGoMigrations)
+}
+
+func copyRegistry(in map[string]map[int64]*Migration) map[string]map[int64]*Migration {
+	out := make(map[string]map[int64]*Migration, len(in))
+	for scope, versionMap := range in {
+		copied := make(map[int64]*Migration, len(versionMap))
+		for version, m := range versionMap {
+			copied[version] = m
+		}
+		out[scope] = copied
+	}
+	return out
+}
+
 func checkGoMigration(m *Migration) error {
 	if !m.construct {
 		return errors.New("must use NewGoMigration to construct migrations")
@@ -91,6 +192,9 @@ func checkGoFunc(f *GoFunc) error {
 	if f.RunTx != nil && f.RunDB != nil {
 		return errors.New("must specify exactly one of RunTx or RunDB")
 	}
+	if f.RunConn != nil && (f.RunTx != nil || f.RunDB != nil) {
+		return errors.New("must specify exactly one of RunTx, RunDB or RunConn")
+	}
 	switch f.Mode {
 	case TransactionEnabled, TransactionDisabled:
 		// No functions, but mode is set. This is not an error. It means the user wants to
@@ -101,6 +205,9 @@ func checkGoFunc(f *GoFunc) error {
 	if f.RunDB != nil && f.Mode != TransactionDisabled {
 		return fmt.Errorf("transaction mode must be disabled or unspecified when RunDB is set")
 	}
+	if f.RunConn != nil && f.Mode != TransactionDisabled {
+		return fmt.Errorf("transaction mode must be disabled or unspecified when RunConn is set")
+	}
 	if f.RunTx != nil && f.Mode != TransactionEnabled {
 		return fmt.Errorf("transaction mode must be enabled or unspecified when RunTx is set")
 	}
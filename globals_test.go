@@ -3,6 +3,7 @@ package goose
 import (
 	"context"
 	"database/sql"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -185,6 +186,22 @@ func TestLegacyFunctions(t *testing.T) {
 		require.NotNil(t, m.UpFnNoTx)
 		require.NotNil(t, m.DownFnNoTx)
 	})
+	t.Run("all_conn", func(t *testing.T) {
+		t.Cleanup(ResetGlobalMigrations)
+		runConn := func(context.Context, *sql.Conn) error { return nil }
+		err := SetGlobalMigrations("",
+			NewGoMigration(3, &GoFunc{RunConn: runConn}, &GoFunc{RunConn: runConn}),
+		)
+		require.NoError(t, err)
+		m := registeredGoMigrations[""][3]
+		assertMigration(t, m, 3)
+		require.False(t, m.UseTx)
+		require.Equal(t, TransactionDisabled, m.goUp.Mode)
+		require.NotNil(t, m.UpFnConnContext)
+		require.NotNil(t, m.DownFnConnContext)
+		require.Nil(t, m.UpFnContext)
+		require.Nil(t, m.UpFnNoTxContext)
+	})
 }
 
 func TestGlobalRegister(t *testing.T) {
@@ -283,3 +300,166 @@ func TestCheckMigration(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "must specify exactly one of DownFn or DownFnNoTx")
 }
+
+func TestGlobalSettersConcurrent(t *testing.T) {
+	t.Cleanup(func() {
+		SetVerbose(false)
+		SetBaseFS(nil)
+		SetTableName(DefaultTablename)
+		SetLogger(&stdLogger{})
+		require.NoError(t, SetDialect("postgres"))
+	})
+	// Exercise the package-level setters and getters concurrently. Run with -race to detect
+	// unsynchronized access.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			SetVerbose(true)
+			SetBaseFS(nil)
+			SetTableName("goose_concurrent")
+			SetLogger(NopLogger())
+			_ = SetDialect("sqlite3")
+		}()
+		go func() {
+			defer wg.Done()
+			_ = isVerbose()
+			_ = getBaseFS()
+			_ = TableName()
+			_ = getStore()
+			log.Printf("")
+		}()
+	}
+	wg.Wait()
+	require.True(t, isVerbose())
+	require.Equal(t, "goose_concurrent", TableName())
+}
+
+func TestGlobalRegistryConcurrent(t *testing.T) {
+	t.Cleanup(SnapshotGlobalMigrations())
+
+	// Register and collect migrations from many goroutines at once. Run with -race to detect
+	// unsynchronized access to the registry.
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(2)
+		go func(version int64) {
+			defer wg.Done()
+			scope := "even"
+			if version%2 == 1 {
+				scope = "odd"
+			}
+			err := SetGlobalMigrations(scope, NewGoMigration(version, nil, nil))
+			require.NoError(t, err)
+		}(int64(i))
+		go func() {
+			defer wg.Done()
+			_ = globalMigrationsSnapshot()
+		}()
+	}
+	wg.Wait()
+	snapshot := globalMigrationsSnapshot()
+	require.Len(t, snapshot["even"], 10)
+	require.Len(t, snapshot["odd"], 10)
+}
+
+func TestSnapshotGlobalMigrations(t *testing.T) {
+	t.Cleanup(ResetGlobalMigrations)
+
+	require.NoError(t, SetGlobalMigrations("", NewGoMigration(1, nil, nil)))
+	restore := SnapshotGlobalMigrations()
+	require.NoError(t, SetGlobalMigrations("", NewGoMigration(2, nil, nil)))
+	require.NoError(t, SetGlobalMigrations("other", NewGoMigration(3, nil, nil)))
+	require.Len(t, registeredGoMigrations, 2)
+
+	restore()
+	require.Len(t, registeredGoMigrations, 1)
+	require.Len(t, registeredGoMigrations[""], 1)
+	require.Contains(t, registeredGoMigrations[""], int64(1))
+	// The snapshot is independent of later registrations.
+	require.NoError(t, SetGlobalMigrations("", NewGoMigration(2, nil, nil)))
+	restore()
+	require.Len(t, registeredGoMigrations[""], 1)
+}
+
+func TestGlobalMigrationsScope(t *testing.T) {
+	t.Cleanup(ResetGlobalMigrations)
+
+	runDB := func(context.Context, *sql.DB) error { return nil }
+	runTx := func(context.Context, *sql.Tx) error { return nil }
+
+	require.Empty(t, GlobalMigrationScopes())
+	require.Empty(t, ListGlobalMigrations(""))
+
+	m := NewGoMigration(2, &GoFunc{RunTx: runTx}, &GoFunc{RunDB: runDB})
+	m.Source = "00002_add_users.go"
+	require.NoError(t, SetGlobalMigrations("", m, NewGoMigration(1, nil, nil)))
+	require.NoError(t, SetGlobalMigrations("billing", NewGoMigration(1, &GoFunc{RunDB: runDB}, nil)))
+	require.Equal(t, []string{"", "billing"}, GlobalMigrationScopes())
+
+	list := ListGlobalMigrations("")
+	require.Len(t, list, 2)
+	require.Equal(t, &RegisteredGoMigration{
+		Version:  1,
+		UpMode:   TransactionEnabled,
+		DownMode: TransactionEnabled,
+	}, list[0])
+	require.Equal(t, &RegisteredGoMigration{
+		Version:  2,
+		Source:   "00002_add_users.go",
+		UpMode:   TransactionEnabled,
+		DownMode: TransactionDisabled,
+	}, list[1])
+	list = ListGlobalMigrations("billing")
+	require.Len(t, list, 1)
+	require.Equal(t, "billing", list[0].Scope)
+	require.Equal(t, TransactionDisabled, list[0].UpMode)
+
+	// Resetting one scope leaves the others untouched.
+	ResetGlobalMigrationsScope("billing")
+	require.Equal(t, []string{""}, GlobalMigrationScopes())
+	require.Empty(t, ListGlobalMigrations("billing"))
+	require.Len(t, ListGlobalMigrations(""), 2)
+	// The scope can be registered again after a reset.
+	require.NoError(t, SetGlobalMigrations("billing", NewGoMigration(1, nil, nil)))
+}
+
+func TestGlobalRegisterWindowsPath(t *testing.T) {
+	t.Cleanup(ResetGlobalMigrations)
+
+	// Paths from runtime.Caller on Windows use backslashes, whatever the OS running the tests.
+	version, err := NumericComponent(`C:\app\migrations\00042_add_users.go`)
+	require.NoError(t, err)
+	require.EqualValues(t, 42, version)
+
+	AddNamedMigrationContext(`C:\app\migrations\00042_add_users.go`, nil, nil, WithScope("windows"))
+	m := NewGoMigration(43, nil, nil)
+	m.Source = `C:\app\migrations\00043_add_posts.go`
+	require.NoError(t, SetGlobalMigrations("windows", m))
+	list := ListGlobalMigrations("windows")
+	require.Len(t, list, 2)
+	require.EqualValues(t, 42, list[0].Version)
+	require.Equal(t, "C:/app/migrations/00042_add_users.go", list[0].Source)
+	require.Equal(t, "C:/app/migrations/00043_add_posts.go", list[1].Source)
+
+	// The registered migrations match the files of the filesystem.
+	sources := &fileSources{goSources: []Source{
+		{Type: TypeGo, Path: "00042_add_users.go", Version: 42},
+		{Type: TypeGo, Path: "00043_add_posts.go", Version: 43},
+	}}
+	migrations, err := merge(sources, globalMigrationsSnapshot()["windows"])
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+}
+
+func TestGlobalRegisterDescription(t *testing.T) {
+	t.Cleanup(ResetGlobalMigrations)
+
+	AddNamedMigrationContext("00044_backfill_names.go", nil, nil, WithScope("description"),
+		WithDescription("Backfill user names"), WithOwner("identity-team"))
+	m := globalMigrationsSnapshot()["description"][44]
+	require.NotNil(t, m)
+	require.Equal(t, "Backfill user names", m.Description)
+	require.Equal(t, "identity-team", m.Owner)
+}
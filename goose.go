@@ -3,9 +3,14 @@ package goose
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io/fs"
+	"os"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Deprecated: VERSION will no longer be supported in the next major release.
@@ -15,29 +20,68 @@ var (
 	minVersion      = int64(0)
 	maxVersion      = int64((1 << 63) - 1)
 	timestampFormat = "20060102150405"
-	verbose         = false
-	noColor         = false
+)
+
+// The package-level state below configures the legacy (non-Provider) API. Access is guarded by
+// globalMu so the setters are safe to call while other goroutines run commands, but the state is
+// still shared by every caller in the process. Use [NewProvider] for isolated configurations.
+var (
+	globalMu sync.RWMutex
+
+	verbose = false
+	noColor = false
 
 	// base fs to lookup migrations
 	baseFS fs.FS = osFS{}
 )
 
 // SetVerbose set the goose verbosity mode
+//
+// Deprecated: Use [NewProvider] with [WithVerbose].
 func SetVerbose(v bool) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
 	verbose = v
 }
 
 // SetBaseFS sets a base FS to discover migrations. It can be used with 'embed' package.
 // Calling with 'nil' argument leads to default behaviour: discovering migrations from os filesystem.
 // Note that modifying operations like Create will use os filesystem anyway.
+//
+// Deprecated: Use [NewProvider] and pass the filesystem directly.
 func SetBaseFS(fsys fs.FS) {
 	if fsys == nil {
 		fsys = osFS{}
 	}
-
+	globalMu.Lock()
+	defer globalMu.Unlock()
 	baseFS = fsys
 }
 
+func isVerbose() bool {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return verbose
+}
+
+func isNoColor() bool {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return noColor
+}
+
+func setNoColor(b bool) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	noColor = b
+}
+
+func getBaseFS() fs.FS {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return decompressing(baseFS)
+}
+
 // Run runs a goose command.
 //
 // Deprecated: Use RunContext.
@@ -114,12 +158,159 @@ func run(ctx context.Context, command string, db *sql.DB, dir string, args []str
 		if err := DownToContext(ctx, db, dir, version, options...); err != nil {
 			return err
 		}
+	case "archive":
+		if len(args) == 0 {
+			return fmt.Errorf("archive must be of form: goose [OPTIONS] DRIVER DBSTRING archive YYYY-MM-DD")
+		}
+		before, err := time.Parse(time.DateOnly, args[0])
+		if err != nil {
+			return fmt.Errorf("date must be of form YYYY-MM-DD (got '%s')", args[0])
+		}
+		if err := ArchiveContext(ctx, db, dir, before, options...); err != nil {
+			return err
+		}
+	case "plan":
+		plan, err := CreatePlan(ctx, db, dir, options...)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode plan: %w", err)
+		}
+		file := outputFile(args)
+		if file == "" {
+			if _, err := fmt.Fprintln(output(), string(data)); err != nil {
+				return fmt.Errorf("failed to write plan: %w", err)
+			}
+			break
+		}
+		if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write plan: %w", err)
+		}
+		log.Printf("goose: wrote plan with %d migrations to %s\n", len(plan.Migrations), file)
+	case "apply":
+		if len(args) == 0 {
+			return fmt.Errorf("apply must be of form: goose [OPTIONS] DRIVER DBSTRING apply PLAN")
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read plan: %w", err)
+		}
+		var plan Plan
+		if err := json.Unmarshal(data, &plan); err != nil {
+			return fmt.Errorf("failed to decode plan: %w", err)
+		}
+		if err := ApplyPlan(ctx, db, dir, &plan, options...); err != nil {
+			return err
+		}
+	case "bundle":
+		b, err := CreateBundle(getBaseFS(), dir, options...)
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(b, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode bundle: %w", err)
+		}
+		file := outputFile(args)
+		if file == "" {
+			if _, err := fmt.Fprintln(output(), string(data)); err != nil {
+				return fmt.Errorf("failed to write bundle: %w", err)
+			}
+			break
+		}
+		if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+		log.Printf("goose: wrote bundle with %d migrations to %s\n", len(b.Migrations), file)
+	case "apply-bundle":
+		if len(args) == 0 {
+			return fmt.Errorf("apply-bundle must be of form: goose [OPTIONS] DRIVER DBSTRING apply-bundle BUNDLE")
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+		var b Bundle
+		if err := json.Unmarshal(data, &b); err != nil {
+			return fmt.Errorf("failed to decode bundle: %w", err)
+		}
+		if err := ApplyBundle(ctx, db, &b, options...); err != nil {
+			return err
+		}
+	case "import":
+		if len(args) == 0 {
+			return fmt.Errorf("import must be of form: goose [OPTIONS] [DRIVER DBSTRING] import SRC_DIR")
+		}
+		option := applyOptions(options)
+		if option.importFrom == "" {
+			return fmt.Errorf("import requires the format of the migrations to import, e.g., -from %s", ImportFlyway)
+		}
+		if _, err := Import(ctx, db, option.importFrom, args[0], dir, options...); err != nil {
+			return err
+		}
+	case "export":
+		if len(args) == 0 {
+			return fmt.Errorf("export must be of form: goose [OPTIONS] [DRIVER DBSTRING] export DST_DIR")
+		}
+		option := applyOptions(options)
+		if option.exportTo == "" {
+			return fmt.Errorf("export requires the format of the migrations to export, e.g., -to %s", ExportFlyway)
+		}
+		if _, err := Export(ctx, db, option.exportTo, dir, args[0], options...); err != nil {
+			return err
+		}
 	case "fix":
 		if err := Fix(dir); err != nil {
 			return err
 		}
+	case "renumber":
+		if err := Renumber(ctx, db, dir, options...); err != nil {
+			return err
+		}
+	case "partitions":
+		if _, err := MaintainPartitions(ctx, db, dir, options...); err != nil {
+			return err
+		}
+	case "freeze":
+		if len(args) == 0 {
+			return fmt.Errorf("freeze must be of form: goose [OPTIONS] DRIVER DBSTRING freeze REASON")
+		}
+		if err := Freeze(ctx, db, strings.Join(args, " "), options...); err != nil {
+			return err
+		}
+	case "unfreeze":
+		if err := Unfreeze(ctx, db, options...); err != nil {
+			return err
+		}
+	case "checksum":
+		if err := UpdateChecksums(dir); err != nil {
+			return err
+		}
 	case "redo":
-		if err := RedoContext(ctx, db, dir, options...); err != nil {
+		if len(args) == 0 {
+			if err := RedoContext(ctx, db, dir, options...); err != nil {
+				return err
+			}
+			break
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("count must be a number (got '%s')", args[0])
+		}
+		if err := RedoLastContext(ctx, db, dir, n, options...); err != nil {
+			return err
+		}
+	case "redo-to":
+		if len(args) == 0 {
+			return fmt.Errorf("redo-to must be of form: goose [OPTIONS] DRIVER DBSTRING redo-to VERSION")
+		}
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("version must be a number (got '%s')", args[0])
+		}
+		if err := RedoToContext(ctx, db, dir, version, options...); err != nil {
 			return err
 		}
 	case "reset":
@@ -139,3 +330,29 @@ func run(ctx context.Context, command string, db *sql.DB, dir string, args []str
 	}
 	return nil
 }
+
+// outputFile returns the file a command writes its output to, given as its first argument or with
+// -o FILE, or empty if it is written to the output, see [SetOutput].
+func outputFile(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if args[0] == "-o" || args[0] == "--o" {
+		if len(args) < 2 {
+			return ""
+		}
+		return args[1]
+	}
+	if file, ok := strings.CutPrefix(args[0], "-o="); ok {
+		return file
+	}
+	return args[0]
+}
+
+func applyOptions(opts []OptionsFunc) *options {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	return option
+}
@@ -6,6 +6,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -104,6 +105,78 @@ func TestLiteBinary(t *testing.T) {
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "goose run: no migration files found")
 	})
+	t.Run("completion", func(t *testing.T) {
+		t.Parallel()
+		out, err := cli.run("completion", "bash")
+		require.NoError(t, err)
+		require.Contains(t, out, "complete -o default -F _goose_completions goose")
+		_, err = cli.run("completion", "tcsh")
+		require.Error(t, err)
+
+		out, err = cli.run("__complete", "sqlite3", "./foo.db", "st")
+		require.NoError(t, err)
+		require.Equal(t, "status\n", out)
+		out, err = cli.run("__complete", "sq")
+		require.NoError(t, err)
+		require.Equal(t, "sqlite3\n", out)
+		out, err = cli.run("__complete", "-dir=testdata/migrations", "sqlite3", "./foo.db", "up-to", "")
+		require.NoError(t, err)
+		require.Equal(t, "1\n2\n3\n4\n5\n", out)
+		out, err = cli.run("__complete", "-no-col")
+		require.NoError(t, err)
+		require.Equal(t, "-no-color\n", out)
+	})
+	t.Run("ui", func(t *testing.T) {
+		t.Parallel()
+		dbPath := filepath.Join(t.TempDir(), "sql.db")
+		_, err := cli.run("-dir=testdata/migrations", "sqlite3", dbPath, "up-to", "2")
+		require.NoError(t, err)
+		out, err := cli.runWithInput("p\n1\ne\nq\n", "-dir=testdata/migrations", "sqlite3", dbPath, "ui")
+		require.NoError(t, err)
+		require.Contains(t, out, "database version 2: 2 applied")
+		require.Contains(t, out, "Source:       00003_")
+		require.Contains(t, out, "no problems found")
+	})
+	t.Run("fixtures", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		dbPath := filepath.Join(dir, "sql.db")
+		fixturesDir := filepath.Join(dir, "fixtures")
+		for set, email := range map[string]string{"minimal": "admin@example.com", "demo": "demo@example.com"} {
+			require.NoError(t, os.MkdirAll(filepath.Join(fixturesDir, set), 0755))
+			data := "-- +goose Up\nINSERT INTO users (username, email) VALUES ('" + set + "', '" + email + "');\n"
+			require.NoError(t, os.WriteFile(filepath.Join(fixturesDir, set, "00001_users.sql"), []byte(data), 0644))
+		}
+		_, err := cli.run("-dir=testdata/migrations", "sqlite3", dbPath, "up-to", "1")
+		require.NoError(t, err)
+		out, err := cli.run("-fixtures-dir="+fixturesDir, "sqlite3", dbPath, "fixtures", "apply", "minimal,demo")
+		require.NoError(t, err)
+		require.Contains(t, out, "minimal: OK")
+		require.Contains(t, out, "demo: OK")
+		out, err = cli.run("-fixtures-dir="+fixturesDir, "sqlite3", dbPath, "fixtures", "status")
+		require.NoError(t, err)
+		require.Contains(t, out, "demo ")
+		require.NotContains(t, out, "Pending")
+		_, err = cli.run("-fixtures-dir="+fixturesDir, "sqlite3", dbPath, "fixtures", "seed")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), `unknown fixtures command "seed"`)
+	})
+	t.Run("schema", func(t *testing.T) {
+		t.Parallel()
+		out, err := cli.run("-dir=testdata/migrations", "sqlite3", ":memory:", "schema", "emit")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(out, "-- Code generated by goose schema emit. DO NOT EDIT.\n"))
+		require.Contains(t, out, "CREATE TABLE users")
+		require.NotContains(t, out, "goose_db_version")
+		file := filepath.Join(t.TempDir(), "schema.sql")
+		_, err = cli.run("-dir=testdata/migrations", "-o="+file, "sqlite3", ":memory:", "schema", "emit")
+		require.NoError(t, err)
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.Equal(t, out, string(data))
+		_, err = cli.run("-dir=testdata/migrations", "sqlite3", ":memory:", "schema", "dump")
+		require.Error(t, err)
+	})
 	t.Run("create_and_fix", func(t *testing.T) {
 		t.Parallel()
 		dir := t.TempDir()
@@ -172,6 +245,16 @@ func (g gooseBinary) run(params ...string) (string, error) {
 	return string(out), nil
 }
 
+func (g gooseBinary) runWithInput(input string, params ...string) (string, error) {
+	cmd := exec.Command(g.binaryPath, params...)
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to run goose command: %v\nout: %v", err, string(out))
+	}
+	return string(out), nil
+}
+
 // buildGooseCLI builds goose test binary, which is used for testing goose CLI. It is built with all
 // drivers enabled, unless lite is true, in which case all drivers are disabled except sqlite3
 func buildGooseCLI(t *testing.T, lite bool) gooseBinary {
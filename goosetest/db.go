@@ -0,0 +1,265 @@
+package goosetest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+)
+
+// Statements recorded for transactions, in addition to the executed statements.
+const (
+	Begin    = "BEGIN"
+	Commit   = "COMMIT"
+	Rollback = "ROLLBACK"
+)
+
+// Statement is a statement executed on a database returned by [NewDB].
+type Statement struct {
+	Query string
+	Args  []any
+	// InTx reports whether the statement was executed in a transaction.
+	InTx bool
+}
+
+// ExecFunc is called for each statement executed on a database returned by [NewDB]. A non-nil
+// error fails the statement. If the result is nil, a result with no affected rows is returned.
+type ExecFunc func(ctx context.Context, query string, args []any) (sql.Result, error)
+
+// QueryFunc is called for each query run on a database returned by [NewDB]. It returns the rows of
+// the result, each row a slice of column values of a type supported by database/sql/driver, e.g.,
+// int64, string or bool.
+type QueryFunc func(ctx context.Context, query string, args []any) ([][]any, error)
+
+// DBOption is used to configure a database returned by [NewDB].
+type DBOption interface {
+	apply(*Recorder)
+}
+
+type dbOptionFunc func(*Recorder)
+
+func (f dbOptionFunc) apply(r *Recorder) { f(r) }
+
+// WithExec sets the function called for each executed statement, e.g., to fail a statement or to
+// return the number of affected rows.
+func WithExec(fn ExecFunc) DBOption {
+	return dbOptionFunc(func(r *Recorder) { r.exec = fn })
+}
+
+// WithQueryError sets the error returned by queries, which otherwise return no rows.
+func WithQueryError(err error) DBOption {
+	return dbOptionFunc(func(r *Recorder) { r.queryErr = err })
+}
+
+// WithQuery sets the function called for each query, e.g., to return rows. It is not called if an
+// error is set with [WithQueryError].
+func WithQuery(fn QueryFunc) DBOption {
+	return dbOptionFunc(func(r *Recorder) { r.query = fn })
+}
+
+// NewDB returns a fake database that records the statements executed on it, without running them,
+// and the Recorder to inspect them. Queries return no rows. The database is meant to be used
+// together with a [Store], since it does not track versions itself.
+func NewDB(opts ...DBOption) (*sql.DB, *Recorder) {
+	r := &Recorder{}
+	for _, opt := range opts {
+		opt.apply(r)
+	}
+	return sql.OpenDB(connector{r}), r
+}
+
+// Recorder records the statements executed on a database returned by [NewDB]. It is safe for
+// concurrent use.
+type Recorder struct {
+	exec     ExecFunc
+	query    QueryFunc
+	queryErr error
+
+	mu         sync.Mutex
+	statements []Statement
+}
+
+// Statements returns the recorded statements, in the order they were executed.
+func (r *Recorder) Statements() []Statement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Statement(nil), r.statements...)
+}
+
+// Queries returns the queries of the recorded statements, including [Begin], [Commit] and
+// [Rollback] for transactions.
+func (r *Recorder) Queries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queries := make([]string, 0, len(r.statements))
+	for _, s := range r.statements {
+		queries = append(queries, s.Query)
+	}
+	return queries
+}
+
+// Reset forgets the recorded statements.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = nil
+}
+
+// record records a statement and returns its argument values.
+func (r *Recorder) record(query string, args []driver.NamedValue, inTx bool) []any {
+	values := make([]any, 0, len(args))
+	for _, a := range args {
+		values = append(values, a.Value)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, Statement{Query: query, Args: values, InTx: inTx})
+	return values
+}
+
+type connector struct{ r *Recorder }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return &conn{r: c.r}, nil }
+func (c connector) Driver() driver.Driver                        { return fakeDriver{c.r} }
+
+type fakeDriver struct{ r *Recorder }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return &conn{r: d.r}, nil }
+
+var (
+	_ driver.ExecerContext      = (*conn)(nil)
+	_ driver.QueryerContext     = (*conn)(nil)
+	_ driver.ConnBeginTx        = (*conn)(nil)
+	_ driver.NamedValueChecker  = (*conn)(nil)
+	_ driver.SessionResetter    = (*conn)(nil)
+	_ driver.ConnPrepareContext = (*conn)(nil)
+)
+
+type conn struct {
+	r    *Recorder
+	inTx bool
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(_ context.Context, query string) (driver.Stmt, error) {
+	return &stmt{c: c, query: query}, nil
+}
+
+func (c *conn) Close() error { return nil }
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if c.inTx {
+		return nil, errors.New("transaction already in progress")
+	}
+	c.r.record(Begin, nil, false)
+	c.inTx = true
+	return tx{c}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	values := c.r.record(query, args, c.inTx)
+	if c.r.exec == nil {
+		return driver.RowsAffected(0), nil
+	}
+	result, err := c.r.exec(ctx, query, values)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return driver.RowsAffected(0), nil
+	}
+	return result, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	values := c.r.record(query, args, c.inTx)
+	if c.r.queryErr != nil {
+		return nil, c.r.queryErr
+	}
+	if c.r.query == nil {
+		return &rows{}, nil
+	}
+	result, err := c.r.query(ctx, query, values)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{values: result}, nil
+}
+
+// CheckNamedValue accepts arguments of any type, since they are only recorded.
+func (c *conn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *conn) ResetSession(context.Context) error { return nil }
+
+type tx struct{ c *conn }
+
+func (t tx) Commit() error {
+	t.c.inTx = false
+	t.c.r.record(Commit, nil, false)
+	return nil
+}
+
+func (t tx) Rollback() error {
+	t.c.inTx = false
+	t.c.r.record(Rollback, nil, false)
+	return nil
+}
+
+type stmt struct {
+	c     *conn
+	query string
+}
+
+func (s *stmt) Close() error  { return nil }
+func (s *stmt) NumInput() int { return -1 }
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.c.ExecContext(context.Background(), s.query, namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.c.QueryContext(context.Background(), s.query, namedValues(args))
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, 0, len(args))
+	for i, v := range args {
+		named = append(named, driver.NamedValue{Ordinal: i + 1, Value: v})
+	}
+	return named
+}
+
+// rows are the rows returned by a query, see [WithQuery]. Columns are unnamed.
+type rows struct {
+	values [][]any
+	next   int
+}
+
+func (r *rows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return make([]string, len(r.values[0]))
+}
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	for i, v := range r.values[r.next] {
+		dest[i] = v
+	}
+	r.next++
+	return nil
+}
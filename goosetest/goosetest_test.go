@@ -0,0 +1,154 @@
+package goosetest_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func newFsys() fstest.MapFS {
+	return fstest.MapFS{
+		"00001_users.sql": {Data: []byte(`-- +goose Up
+CREATE TABLE users (id INTEGER);
+-- +goose Down
+DROP TABLE users;
+`)},
+		"00002_email.sql": {Data: []byte(`-- +goose NO TRANSACTION
+-- +goose Up
+ALTER TABLE users ADD COLUMN email TEXT;
+-- +goose Down
+ALTER TABLE users DROP COLUMN email;
+`)},
+	}
+}
+
+func TestProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := goosetest.NewStore("goose_db_version")
+	db, rec := goosetest.NewDB()
+	p, err := goose.NewProvider("", db, newFsys(), goose.WithStore(store))
+	require.NoError(t, err)
+	results, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Equal(t, []int64{0, 1, 2}, store.Versions())
+	require.Equal(t, []string{
+		goosetest.Begin, goosetest.Commit, // version table
+		goosetest.Begin, "CREATE TABLE users (id INTEGER);", goosetest.Commit,
+		"ALTER TABLE users ADD COLUMN email TEXT;",
+	}, rec.Queries())
+	require.True(t, rec.Statements()[3].InTx)
+	require.False(t, rec.Statements()[5].InTx)
+
+	rec.Reset()
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{0, 1}, store.Versions())
+	require.Equal(t, []string{"ALTER TABLE users DROP COLUMN email;"}, rec.Queries())
+	current, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, current)
+}
+
+func TestWithExec(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := goosetest.NewStore("goose_db_version")
+	store.SetVersions(1)
+	failure := errors.New("column exists")
+	db, rec := goosetest.NewDB(goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+		if strings.Contains(query, "email") {
+			return nil, failure
+		}
+		return nil, nil
+	}))
+	p, err := goose.NewProvider("", db, newFsys(), goose.WithStore(store))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, failure)
+	require.Equal(t, []int64{0, 1}, store.Versions())
+	require.Equal(t, []string{"ALTER TABLE users ADD COLUMN email TEXT;"}, rec.Queries())
+}
+
+func TestWithQuery(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, rec := goosetest.NewDB(goosetest.WithQuery(func(ctx context.Context, query string, args []any) ([][]any, error) {
+		return [][]any{{args[0], int64(1)}, {"b", int64(2)}}, nil
+	}))
+	rows, err := db.QueryContext(ctx, "SELECT name, n FROM t WHERE name = $1", "a")
+	require.NoError(t, err)
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var name string
+		var n int64
+		require.NoError(t, rows.Scan(&name, &n))
+		got = append(got, fmt.Sprintf("%s=%d", name, n))
+	}
+	require.NoError(t, rows.Err())
+	require.Equal(t, []string{"a=1", "b=2"}, got)
+	require.Equal(t, []string{"SELECT name, n FROM t WHERE name = $1"}, rec.Queries())
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := goosetest.NewStore("goose_db_version")
+	require.Equal(t, "goose_db_version", store.Tablename())
+	_, err := store.ListMigrations(ctx, nil)
+	require.Error(t, err)
+	exists, err := store.TableExists(ctx, nil)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, store.CreateVersionTable(ctx, nil))
+	require.Error(t, store.CreateVersionTable(ctx, nil))
+	_, err = store.GetLatestVersion(ctx, nil)
+	require.ErrorIs(t, err, database.ErrVersionNotFound)
+	for _, v := range []int64{0, 3, 1} {
+		require.NoError(t, store.Insert(ctx, nil, database.InsertRequest{Version: v}))
+	}
+	latest, err := store.GetLatestVersion(ctx, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, latest)
+	// Migrations are listed the last recorded first.
+	list, err := store.ListMigrations(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*database.ListMigrationsResult{
+		{Version: 1, IsApplied: true},
+		{Version: 3, IsApplied: true},
+		{Version: 0, IsApplied: true},
+	}, list)
+	require.NoError(t, store.Delete(ctx, nil, 3))
+	_, err = store.GetMigration(ctx, nil, 3)
+	require.ErrorIs(t, err, database.ErrVersionNotFound)
+	res, err := store.GetMigration(ctx, nil, 1)
+	require.NoError(t, err)
+	require.True(t, res.IsApplied)
+
+	require.Error(t, store.InsertMetadata(ctx, nil, 1, "k", "v"))
+	require.NoError(t, store.CreateMetadataTable(ctx, nil))
+	require.NoError(t, store.InsertMetadata(ctx, nil, 1, "k", "v"))
+	require.Error(t, store.InsertMetadata(ctx, nil, 1, "k", "v"))
+	require.NoError(t, store.UpdateVersion(ctx, nil, 1, 2))
+	require.NoError(t, store.UpdateMetadataVersion(ctx, nil, 1, 2))
+	require.Equal(t, []int64{0, 2}, store.Versions())
+	metadata, err := store.ListMetadata(ctx, nil)
+	require.NoError(t, err)
+	require.Equal(t, []*database.MetadataResult{{Version: 2, Key: "k", Value: "v"}}, metadata)
+}
@@ -0,0 +1,298 @@
+// Package goosetest provides an in-memory [database.Store] and a fake database, so tools built on
+// top of the goose library can be unit tested without a real database.
+//
+// A [Store] tracks versions in memory and a database returned by [NewDB] records the statements
+// executed on it, without running them:
+//
+//	store := goosetest.NewStore("goose_db_version")
+//	db, rec := goosetest.NewDB()
+//	p, err := goose.NewProvider("", db, fsys, goose.WithStore(store))
+//	...
+//	results, err := p.Up(ctx)
+//	// store.Versions() returns the applied versions and rec.Queries() the executed statements.
+//
+// The store is not transactional: versions recorded in a transaction that is rolled back are kept.
+package goosetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+var (
+	_ database.StoreExtender = (*Store)(nil)
+	_ database.MetadataStore = (*Store)(nil)
+)
+
+// Store is an in-memory implementation of [database.Store], [database.StoreExtender] and
+// [database.MetadataStore]. The db arguments of its methods are ignored. It is safe for concurrent
+// use.
+type Store struct {
+	tablename string
+	now       func() time.Time
+
+	mu       sync.Mutex
+	created  bool
+	records  []record
+	metadata map[int64]map[string]string
+}
+
+type record struct {
+	version   int64
+	timestamp time.Time
+}
+
+// NewStore returns an empty Store whose version table is named tablename. The version table does
+// not exist until it is created by goose or with [Store.SetVersions].
+func NewStore(tablename string) *Store {
+	return &Store{tablename: tablename, now: time.Now}
+}
+
+// Versions returns the applied versions, in ascending order, including the initial version 0.
+func (s *Store) Versions() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	versions := make([]int64, 0, len(s.records))
+	for _, r := range s.records {
+		versions = append(versions, r.version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
+
+// SetVersions creates the version table, if it does not exist, and replaces its content with the
+// initial version 0 followed by versions, as if they were applied in the given order.
+func (s *Store) SetVersions(versions ...int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.created = true
+	now := s.now()
+	s.records = []record{{version: 0, timestamp: now}}
+	for _, v := range versions {
+		s.records = append(s.records, record{version: v, timestamp: now})
+	}
+}
+
+// Tablename returns the name of the version table.
+func (s *Store) Tablename() string { return s.tablename }
+
+// CreateVersionTable creates the version table. Like a database, it fails if the table already
+// exists.
+func (s *Store) CreateVersionTable(ctx context.Context, _ database.DBTxConn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.created {
+		return fmt.Errorf("table %s already exists", s.tablename)
+	}
+	s.created = true
+	return nil
+}
+
+// Insert records a version as applied.
+func (s *Store) Insert(ctx context.Context, _ database.DBTxConn, req database.InsertRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTable(); err != nil {
+		return err
+	}
+	s.records = append(s.records, record{version: req.Version, timestamp: s.now()})
+	return nil
+}
+
+// Delete removes all records of a version.
+func (s *Store) Delete(ctx context.Context, _ database.DBTxConn, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTable(); err != nil {
+		return err
+	}
+	records := s.records[:0]
+	for _, r := range s.records {
+		if r.version != version {
+			records = append(records, r)
+		}
+	}
+	s.records = records
+	return nil
+}
+
+// GetMigration returns the last record of a version, or [database.ErrVersionNotFound].
+func (s *Store) GetMigration(ctx context.Context, _ database.DBTxConn, version int64) (*database.GetMigrationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTable(); err != nil {
+		return nil, err
+	}
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if r := s.records[i]; r.version == version {
+			return &database.GetMigrationResult{Timestamp: r.timestamp, IsApplied: true}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %d", database.ErrVersionNotFound, version)
+}
+
+// GetLatestVersion returns the highest applied version, or [database.ErrVersionNotFound].
+func (s *Store) GetLatestVersion(ctx context.Context, _ database.DBTxConn) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTable(); err != nil {
+		return -1, err
+	}
+	if len(s.records) == 0 {
+		return -1, fmt.Errorf("latest %w", database.ErrVersionNotFound)
+	}
+	latest := s.records[0].version
+	for _, r := range s.records[1:] {
+		latest = max(latest, r.version)
+	}
+	return latest, nil
+}
+
+// ListMigrations returns all records, the last recorded first.
+func (s *Store) ListMigrations(ctx context.Context, _ database.DBTxConn) ([]*database.ListMigrationsResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTable(); err != nil {
+		return nil, err
+	}
+	results := make([]*database.ListMigrationsResult, 0, len(s.records))
+	for i := len(s.records) - 1; i >= 0; i-- {
+		results = append(results, &database.ListMigrationsResult{Version: s.records[i].version, IsApplied: true})
+	}
+	return results, nil
+}
+
+// TableExists reports whether the version table was created.
+func (s *Store) TableExists(ctx context.Context, _ database.DBTxConn) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.created, nil
+}
+
+// UpdateVersion moves the records of a version to a new version.
+func (s *Store) UpdateVersion(ctx context.Context, _ database.DBTxConn, oldVersion, newVersion int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkTable(); err != nil {
+		return err
+	}
+	for i := range s.records {
+		if s.records[i].version == oldVersion {
+			s.records[i].version = newVersion
+		}
+	}
+	return nil
+}
+
+// CreateMetadataTable creates the metadata table, if it does not already exist.
+func (s *Store) CreateMetadataTable(ctx context.Context, _ database.DBTxConn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.metadata == nil {
+		s.metadata = make(map[int64]map[string]string)
+	}
+	return nil
+}
+
+// MetadataTableExists reports whether the metadata table was created.
+func (s *Store) MetadataTableExists(ctx context.Context, _ database.DBTxConn) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.metadata != nil, nil
+}
+
+// InsertMetadata records a key and value for a version. Like a database, it fails if the key is
+// already recorded for the version.
+func (s *Store) InsertMetadata(ctx context.Context, _ database.DBTxConn, version int64, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkMetadataTable(); err != nil {
+		return err
+	}
+	if _, ok := s.metadata[version][key]; ok {
+		return fmt.Errorf("metadata key %q already exists for version %d", key, version)
+	}
+	if s.metadata[version] == nil {
+		s.metadata[version] = make(map[string]string)
+	}
+	s.metadata[version][key] = value
+	return nil
+}
+
+// DeleteMetadata deletes all metadata recorded for a version.
+func (s *Store) DeleteMetadata(ctx context.Context, _ database.DBTxConn, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkMetadataTable(); err != nil {
+		return err
+	}
+	delete(s.metadata, version)
+	return nil
+}
+
+// DeleteMetadataKey deletes a single key recorded for a version.
+func (s *Store) DeleteMetadataKey(ctx context.Context, _ database.DBTxConn, version int64, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkMetadataTable(); err != nil {
+		return err
+	}
+	delete(s.metadata[version], key)
+	return nil
+}
+
+// UpdateMetadataVersion moves all metadata recorded for a version to a new version.
+func (s *Store) UpdateMetadataVersion(ctx context.Context, _ database.DBTxConn, oldVersion, newVersion int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkMetadataTable(); err != nil {
+		return err
+	}
+	if m, ok := s.metadata[oldVersion]; ok {
+		delete(s.metadata, oldVersion)
+		s.metadata[newVersion] = m
+	}
+	return nil
+}
+
+// ListMetadata returns all metadata sorted by version and key.
+func (s *Store) ListMetadata(ctx context.Context, _ database.DBTxConn) ([]*database.MetadataResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.checkMetadataTable(); err != nil {
+		return nil, err
+	}
+	var results []*database.MetadataResult
+	for version, m := range s.metadata {
+		for key, value := range m {
+			results = append(results, &database.MetadataResult{Version: version, Key: key, Value: value})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Version != results[j].Version {
+			return results[i].Version < results[j].Version
+		}
+		return results[i].Key < results[j].Key
+	})
+	return results, nil
+}
+
+func (s *Store) checkTable() error {
+	if !s.created {
+		return fmt.Errorf("table %s does not exist", s.tablename)
+	}
+	return nil
+}
+
+func (s *Store) checkMetadataTable() error {
+	if s.metadata == nil {
+		return errors.New("metadata table does not exist")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package goose
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// Impact levels of a migration, declared with a "-- +goose impact LEVEL" directive.
+const (
+	ImpactLight  = "light"
+	ImpactMedium = "medium"
+	ImpactHeavy  = "heavy"
+)
+
+// Hints are resource hints declared by a SQL migration for review and deployment tooling, e.g., to
+// route heavy migrations to a maintenance window:
+//
+//	-- +goose affects users, orders
+//	-- +goose impact heavy
+//
+// Hints are informational; goose does not act on them. They are reported in the JSON output of
+// status and plan.
+type Hints struct {
+	// Affects are the tables the migration declares it affects, in the order declared.
+	Affects []string `json:"affects,omitempty"`
+	// Impact is the estimated impact: [ImpactLight], [ImpactMedium] or [ImpactHeavy]. It is empty
+	// if not declared.
+	Impact string `json:"impact,omitempty"`
+}
+
+// parseHints returns the hints declared by the directives, or nil if there are none.
+func parseHints(directives []sqlparser.Directive) (*Hints, error) {
+	var hints Hints
+	for _, d := range directives {
+		switch d.Name {
+		case sqlparser.DirectiveAffects:
+			tables := strings.FieldsFunc(d.Value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+			if len(tables) == 0 {
+				return nil, fmt.Errorf("invalid %s directive: must list at least one table", sqlparser.DirectiveAffects)
+			}
+			hints.Affects = append(hints.Affects, tables...)
+		case sqlparser.DirectiveImpact:
+			switch impact := strings.ToLower(d.Value); impact {
+			case ImpactLight, ImpactMedium, ImpactHeavy:
+				hints.Impact = impact
+			default:
+				return nil, fmt.Errorf("invalid %s directive %q: must be one of %s, %s or %s",
+					sqlparser.DirectiveImpact, d.Value, ImpactLight, ImpactMedium, ImpactHeavy)
+			}
+		}
+	}
+	if len(hints.Affects) == 0 && hints.Impact == "" {
+		return nil, nil
+	}
+	return &hints, nil
+}
+
+// parseTitle returns the title declared by the directives, or an empty string if there is none.
+func parseTitle(directives []sqlparser.Directive) (string, error) {
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveTitle)
+	if !ok {
+		return "", nil
+	}
+	if d.Value == "" {
+		return "", fmt.Errorf("invalid %s directive: must not be empty", sqlparser.DirectiveTitle)
+	}
+	return d.Value, nil
+}
+
+// parseOwner returns the owner declared by the directives, or an empty string if there is none.
+func parseOwner(directives []sqlparser.Directive) (string, error) {
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveOwner)
+	if !ok {
+		return "", nil
+	}
+	if d.Value == "" || strings.ContainsAny(d.Value, " \t") {
+		return "", fmt.Errorf("invalid %s directive %q: must be a single team name, e.g., payments-team",
+			sqlparser.DirectiveOwner, d.Value)
+	}
+	return d.Value, nil
+}
+
+// annotations are the informational directives of a SQL migration, reported by status and plan.
+type annotations struct {
+	title string
+	owner string
+	hints *Hints
+}
+
+// resolve returns the annotations of m. Go migrations have no annotations in their file, their
+// title and owner are set with [WithDescription] and [WithOwner] instead.
+func (a annotations) resolve(m *Migration) annotations {
+	if a.title == "" {
+		a.title = m.Description
+	}
+	if a.owner == "" {
+		a.owner = m.Owner
+	}
+	return a
+}
+
+// clone returns a copy of a, so callers cannot modify cached hints.
+func (a annotations) clone() annotations {
+	a.hints = cloneHints(a.hints)
+	return a
+}
+
+// readAnnotations returns the title, owner and hints declared by the SQL migration at path in
+// fsys. Go migrations have no annotations, see [annotations.resolve].
+func readAnnotations(fsys fs.FS, path string) (annotations, error) {
+	if !isSQLFile(path) {
+		return annotations{}, nil
+	}
+	f, err := fsys.Open(path)
+	if err != nil {
+		return annotations{}, fmt.Errorf("failed to read migration %s: %w", filepath.Base(path), err)
+	}
+	defer f.Close()
+	directives, err := sqlparser.ParseDirectives(f)
+	if err != nil {
+		return annotations{}, fmt.Errorf("failed to parse migration %s: %w", filepath.Base(path), err)
+	}
+	var a annotations
+	if a.title, err = parseTitle(directives); err != nil {
+		return annotations{}, fmt.Errorf("migration %s: %w", filepath.Base(path), err)
+	}
+	if a.owner, err = parseOwner(directives); err != nil {
+		return annotations{}, fmt.Errorf("migration %s: %w", filepath.Base(path), err)
+	}
+	if a.hints, err = parseHints(directives); err != nil {
+		return annotations{}, fmt.Errorf("migration %s: %w", filepath.Base(path), err)
+	}
+	return a, nil
+}
+
+// migrationTitle returns the title of a prepared migration m, see [MigrationStatus.Title].
+func migrationTitle(m *Migration) string {
+	if m.Type == TypeSQL {
+		return m.sql.Title
+	}
+	return m.Description
+}
+
+// migrationOwner returns the owner of a prepared migration m, see [MigrationStatus.Owner].
+func migrationOwner(m *Migration) string {
+	if m.Type == TypeSQL {
+		return m.sql.Owner
+	}
+	return m.Owner
+}
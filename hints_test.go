@@ -0,0 +1,145 @@
+package goose_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/notify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHints(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose affects users, orders\n-- +goose impact Heavy\n-- +goose Up\nSELECT 1;\n"),
+		"00002_b.sql": newMapFile("-- +goose affects audit_log\n-- +goose Up\nSELECT 1;\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nSELECT 1;\n"),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+	require.NoError(t, err)
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	require.Equal(t, &goose.Hints{Affects: []string{"users", "orders"}, Impact: goose.ImpactHeavy}, status[0].Hints)
+	require.Equal(t, &goose.Hints{Affects: []string{"audit_log"}}, status[1].Hints)
+	require.Nil(t, status[2].Hints)
+
+	data, err := json.Marshal(status[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"hints":{"affects":["users","orders"],"impact":"heavy"}`)
+	data, err = json.Marshal(status[2])
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "hints")
+
+	fsys["00003_c.sql"] = newMapFile("-- +goose impact enormous\n-- +goose Up\nSELECT 1;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+	require.NoError(t, err)
+	_, err = p.Status(ctx)
+	require.ErrorContains(t, err, `invalid impact directive "enormous"`)
+}
+
+func TestTitle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose title Add users table\n-- +goose Up\nSELECT 1;\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nSELECT 1;\n"),
+	}
+	goMigration := goose.NewGoMigration(3, &goose.GoFunc{Mode: goose.TransactionEnabled}, nil)
+	goMigration.Description = "Backfill user names"
+	var summaries []notify.Summary
+	p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys,
+		goose.WithGoMigrations(goMigration),
+		goose.WithNotifier(notifierFunc(func(_ context.Context, s notify.Summary) error {
+			summaries = append(summaries, s)
+			return nil
+		})),
+	)
+	require.NoError(t, err)
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	require.Equal(t, "Add users table", status[0].Title)
+	require.Empty(t, status[1].Title)
+	require.Equal(t, "Backfill user names", status[2].Title)
+	data, err := json.Marshal(status[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"title":"Add users table"`)
+	data, err = json.Marshal(status[1])
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "title")
+
+	results, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, "Add users table", results[0].Title)
+	require.Equal(t, "Backfill user names", results[2].Title)
+	require.Len(t, summaries, 1)
+	require.Equal(t, "Add users table", summaries[0].Migrations[0].Title)
+	require.Contains(t, notify.Text(summaries[0]), "OK     up 00001_a.sql: Add users table (")
+
+	fsys["00002_b.sql"] = newMapFile("-- +goose title\n-- +goose Up\nSELECT 1;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+	require.NoError(t, err)
+	_, err = p.Status(ctx)
+	require.ErrorContains(t, err, "invalid title directive: must not be empty")
+}
+
+func TestOwner(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose owner identity-team\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"00002_b.sql": newMapFile("-- +goose owner payments-team\n-- +goose Up\nSELECT * FROM missing;\n"),
+	}
+	goMigration := goose.NewGoMigration(3, &goose.GoFunc{Mode: goose.TransactionEnabled}, nil)
+	goMigration.Owner = "growth-team"
+	var payments, fallback []notify.Summary
+	router, err := notify.NewOwnerRouter(map[string]notify.Notifier{
+		"payments-team": notifierFunc(func(_ context.Context, s notify.Summary) error {
+			payments = append(payments, s)
+			return nil
+		}),
+	}, notifierFunc(func(_ context.Context, s notify.Summary) error {
+		fallback = append(fallback, s)
+		return nil
+	}))
+	require.NoError(t, err)
+	p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys,
+		goose.WithGoMigrations(goMigration),
+		goose.WithFailureNotifier(router),
+	)
+	require.NoError(t, err)
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	require.Equal(t, "identity-team", status[0].Owner)
+	require.Equal(t, "payments-team", status[1].Owner)
+	require.Equal(t, "growth-team", status[2].Owner)
+	data, err := json.Marshal(status[0])
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"owner":"identity-team"`)
+
+	// The failure is routed to the team owning the failed migration.
+	_, err = p.Up(ctx)
+	var partialErr *goose.PartialError
+	require.ErrorAs(t, err, &partialErr)
+	require.Equal(t, "payments-team", partialErr.Failed.Owner)
+	require.Equal(t, "identity-team", partialErr.Applied[0].Owner)
+	require.Empty(t, fallback)
+	require.Len(t, payments, 1)
+	require.Equal(t, "payments-team", payments[0].Migrations[1].Owner)
+
+	fsys["00002_b.sql"] = newMapFile("-- +goose owner payments team\n-- +goose Up\nSELECT 1;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+	require.NoError(t, err)
+	_, err = p.Status(ctx)
+	require.ErrorContains(t, err, `invalid owner directive "payments team"`)
+}
@@ -0,0 +1,577 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// ImportFormat is a migration tool whose migrations [Import] converts.
+type ImportFormat string
+
+const (
+	// ImportFlyway converts Flyway SQL migrations, V1__name.sql with optional U1__name.sql undo
+	// migrations and R__name.sql repeatable migrations, and the flyway_schema_history table.
+	ImportFlyway ImportFormat = "flyway"
+	// ImportLiquibase converts the changesets of Liquibase formatted SQL changelogs and the
+	// DATABASECHANGELOG table.
+	ImportLiquibase ImportFormat = "liquibase"
+	// ImportGolangMigrate converts golang-migrate migrations, 1_name.up.sql with optional
+	// 1_name.down.sql, and the schema_migrations table.
+	ImportGolangMigrate ImportFormat = "golang-migrate"
+)
+
+// WithImportFrom sets the tool whose migrations the import command converts, see [Import].
+func WithImportFrom(format ImportFormat) OptionsFunc {
+	return func(o *options) { o.importFrom = format }
+}
+
+// ImportedMigration is a migration converted by [Import].
+type ImportedMigration struct {
+	// Version is the goose version of the migration, or 0 for repeatable migrations.
+	Version int64 `json:"version"`
+	// Source identifies the migration in the other tool: the file name for Flyway and
+	// golang-migrate, and the author:id of the changeset for Liquibase.
+	Source string `json:"source"`
+	// Path is the path of the goose migration file written.
+	Path string `json:"path"`
+	// Applied reports whether the other tool's history table records the migration as applied, so
+	// its version was recorded in the goose version table.
+	Applied bool `json:"applied"`
+}
+
+// Import converts the migrations in srcDir, written for the migration tool of the given format,
+// into goose SQL migrations in dir. Versions are kept if they are integers, as with golang-migrate
+// and most Flyway projects, and are otherwise numbered sequentially in the other tool's order,
+// e.g., for Flyway versions such as 1.1 and for Liquibase changesets. Flyway repeatable migrations
+// become goose repeatable migrations, see [WithRepeatable].
+//
+// If db is not nil, the migrations the other tool's history table records as applied are recorded
+// in the goose version table, in a single transaction that is only committed once all files are
+// written, so the applied history is kept. Import fails if a migration is recorded as applied
+// without a migration file, or if the history is in a failed state, such as a dirty golang-migrate
+// version.
+//
+// Statements are copied as is. Statements containing semicolons, such as function bodies, must be
+// annotated with -- +goose StatementBegin and -- +goose StatementEnd afterwards.
+func Import(ctx context.Context, db *sql.DB, format ImportFormat, srcDir, dir string, opts ...OptionsFunc) ([]*ImportedMigration, error) {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if db != nil && option.noVersioning {
+		return nil, errors.New("import requires versioning: applied migrations must be tracked in the version table")
+	}
+	var (
+		migrations []*importMigration
+		err        error
+	)
+	switch format {
+	case ImportFlyway:
+		migrations, err = readFlyway(srcDir)
+	case ImportLiquibase:
+		migrations, err = readLiquibase(srcDir)
+	case ImportGolangMigrate:
+		migrations, err = readGolangMigrate(srcDir)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q, must be one of: %s, %s, %s",
+			format, ImportFlyway, ImportLiquibase, ImportGolangMigrate)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("no %s migrations found in %s", format, srcDir)
+	}
+	existing, err := importExistingVersions(dir)
+	if err != nil {
+		return nil, err
+	}
+	var imported []*ImportedMigration
+	for _, m := range migrations {
+		name := m.name + ".sql"
+		if m.version > 0 {
+			if existing[m.version] {
+				return nil, fmt.Errorf("version %d of %s already exists in %s", m.version, m.source, dir)
+			}
+			name = fmt.Sprintf(seqVersionTemplate, m.version) + "_" + name
+		}
+		imported = append(imported, &ImportedMigration{
+			Version: m.version,
+			Source:  m.source,
+			Path:    filepath.Join(dir, name),
+		})
+	}
+	var tx *sql.Tx
+	if db != nil {
+		applied, err := readImportHistory(ctx, db, format, migrations)
+		if err != nil {
+			return nil, err
+		}
+		for i, m := range migrations {
+			imported[i].Applied = m.version > 0 && applied[m.key]
+		}
+		if tx, err = importVersions(ctx, db, imported); err != nil {
+			return nil, err
+		}
+	}
+	var written []string
+	restore := func() error {
+		var errs error
+		for _, path := range written {
+			errs = multierr.Append(errs, os.Remove(path))
+		}
+		if tx != nil {
+			errs = multierr.Append(errs, tx.Rollback())
+		}
+		return errs
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, multierr.Append(fmt.Errorf("failed to create migrations directory: %w", err), restore())
+	}
+	for i, m := range migrations {
+		path := imported[i].Path
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			return nil, multierr.Append(fmt.Errorf("failed to import %s: %s already exists", m.source, path), restore())
+		}
+		if err := os.WriteFile(path, []byte(m.content(format)), 0644); err != nil {
+			return nil, multierr.Append(fmt.Errorf("failed to import %s: %w", m.source, err), restore())
+		}
+		written = append(written, path)
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			tx = nil
+			return nil, multierr.Append(fmt.Errorf("failed to commit version changes: %w", err), restore())
+		}
+	}
+	for _, m := range imported {
+		log.Printf("IMPORTED %s => %s\n", m.Source, filepath.Base(m.Path))
+	}
+	return imported, nil
+}
+
+// importMigration is a migration read from another tool.
+type importMigration struct {
+	// version is the goose version, or 0 for a repeatable migration.
+	version int64
+	// key identifies the migration in the other tool's history table.
+	key string
+	// name is the file name of the goose migration without version and extension.
+	name     string
+	source   string
+	up, down string
+}
+
+func (m *importMigration) content(format ImportFormat) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Imported from %s %s\n", format, m.source)
+	b.WriteString("-- +goose Up\n")
+	b.WriteString(strings.TrimSpace(m.up) + "\n")
+	if down := strings.TrimSpace(m.down); down != "" {
+		b.WriteString("\n-- +goose Down\n")
+		b.WriteString(down + "\n")
+	}
+	return b.String()
+}
+
+var importNameReplacer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// importName returns description as a lower-case snake case file name.
+func importName(description string) string {
+	name := strings.Trim(importNameReplacer.ReplaceAllString(strings.ToLower(description), "_"), "_")
+	if name == "" {
+		return "imported"
+	}
+	return name
+}
+
+var (
+	golangMigrateFile = regexp.MustCompile(`^(\d+)_(.*)\.(up|down)\.sql$`)
+	flywayFile        = regexp.MustCompile(`^([VU])(\d+(?:[._]\d+)*)__(.+)\.sql$`)
+)
+
+func readGolangMigrate(srcDir string) ([]*importMigration, error) {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	byVersion := make(map[int64]*importMigration)
+	for _, e := range entries {
+		match := golangMigrateFile.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid version of %s", e.Name())
+		}
+		data, err := os.ReadFile(filepath.Join(srcDir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration: %w", err)
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &importMigration{version: version, key: strconv.FormatInt(version, 10), name: importName(match[2])}
+			byVersion[version] = m
+		}
+		if match[3] == "up" {
+			m.up, m.source = string(data), e.Name()
+		} else {
+			m.down = string(data)
+		}
+	}
+	migrations := make([]*importMigration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.source == "" {
+			return nil, fmt.Errorf("version %d has a down migration without an up migration", m.version)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
+
+func readFlyway(srcDir string) ([]*importMigration, error) {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	byVersion := make(map[string]*importMigration)
+	var repeatables []*importMigration
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(srcDir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration: %w", err)
+		}
+		if desc, ok := strings.CutPrefix(e.Name(), repeatablePrefix); ok && strings.HasSuffix(desc, ".sql") {
+			repeatables = append(repeatables, &importMigration{
+				name:   repeatablePrefix + importName(strings.TrimSuffix(desc, ".sql")),
+				source: e.Name(),
+				up:     string(data),
+			})
+			continue
+		}
+		match := flywayFile.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		key := normalizeFlywayVersion(match[2])
+		m, ok := byVersion[key]
+		if !ok {
+			m = &importMigration{key: key, name: importName(match[3])}
+			byVersion[key] = m
+		}
+		if match[1] == "V" {
+			m.up, m.source = string(data), e.Name()
+		} else {
+			m.down = string(data)
+		}
+	}
+	migrations := make([]*importMigration, 0, len(byVersion))
+	for key, m := range byVersion {
+		if m.source == "" {
+			return nil, fmt.Errorf("version %s has an undo migration without a versioned migration", key)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return compareFlywayVersions(migrations[i].key, migrations[j].key) < 0
+	})
+	sequential := false
+	for _, m := range migrations {
+		version, err := strconv.ParseInt(m.key, 10, 64)
+		if err != nil || version < 1 {
+			sequential = true
+			break
+		}
+		m.version = version
+	}
+	if sequential {
+		for i, m := range migrations {
+			m.version = int64(i + 1)
+		}
+	}
+	sort.Slice(repeatables, func(i, j int) bool { return repeatables[i].name < repeatables[j].name })
+	return append(migrations, repeatables...), nil
+}
+
+// normalizeFlywayVersion returns version with dots between its parts, whether they were written
+// with dots or underscores, and without leading zeros, e.g., 1.1 for 001_1.
+func normalizeFlywayVersion(version string) string {
+	parts := strings.FieldsFunc(version, func(r rune) bool { return r == '.' || r == '_' })
+	for i, part := range parts {
+		if part = strings.TrimLeft(part, "0"); part == "" {
+			part = "0"
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ".")
+}
+
+// compareFlywayVersions compares dotted versions, such as 1.10 and 1.9, part by part.
+func compareFlywayVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int64
+		if i < len(as) {
+			x, _ = strconv.ParseInt(as[i], 10, 64)
+		}
+		if i < len(bs) {
+			y, _ = strconv.ParseInt(bs[i], 10, 64)
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+const liquibaseHeader = "--liquibase formatted sql"
+
+// readLiquibase reads the changesets of the formatted SQL changelogs in srcDir, in file name
+// order. Other changelogs, such as XML or YAML, are not supported.
+func readLiquibase(srcDir string) ([]*importMigration, error) {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changelogs: %w", err)
+	}
+	var migrations []*importMigration
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(srcDir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read changelog: %w", err)
+		}
+		lines := strings.Split(string(data), "\n")
+		if !strings.HasPrefix(strings.TrimSpace(lines[0]), liquibaseHeader) {
+			continue
+		}
+		var m *importMigration
+		var up, down strings.Builder
+		flush := func() {
+			if m != nil {
+				m.up, m.down = up.String(), down.String()
+				migrations = append(migrations, m)
+			}
+			up.Reset()
+			down.Reset()
+		}
+		for i, line := range lines[1:] {
+			trimmed := strings.TrimSpace(line)
+			if rest, ok := strings.CutPrefix(trimmed, "--changeset "); ok {
+				flush()
+				fields := strings.Fields(rest)
+				author, id, ok := strings.Cut(fields[0], ":")
+				if !ok || author == "" || id == "" {
+					return nil, fmt.Errorf("%s:%d: changeset must be of form author:id", e.Name(), i+2)
+				}
+				key := fields[0]
+				if seen[key] {
+					return nil, fmt.Errorf("%s:%d: duplicate changeset %s", e.Name(), i+2, key)
+				}
+				seen[key] = true
+				m = &importMigration{key: key, name: importName(id), source: key}
+				continue
+			}
+			if m == nil {
+				continue
+			}
+			if rest, ok := strings.CutPrefix(trimmed, "--rollback"); ok {
+				down.WriteString(strings.TrimSpace(rest) + "\n")
+				continue
+			}
+			if strings.HasPrefix(trimmed, "--comment:") || strings.HasPrefix(trimmed, "--preconditions") ||
+				strings.HasPrefix(trimmed, "--precondition-") {
+				continue
+			}
+			up.WriteString(line + "\n")
+		}
+		flush()
+	}
+	for i, m := range migrations {
+		m.version = int64(i + 1)
+	}
+	return migrations, nil
+}
+
+// readImportHistory returns the keys of the migrations recorded as applied in the history table
+// of the other tool.
+func readImportHistory(ctx context.Context, db *sql.DB, format ImportFormat, migrations []*importMigration) (map[string]bool, error) {
+	var (
+		applied map[string]bool
+		err     error
+	)
+	switch format {
+	case ImportFlyway:
+		applied, err = readFlywayHistory(ctx, db, migrations)
+	case ImportLiquibase:
+		applied, err = readLiquibaseHistory(ctx, db)
+	case ImportGolangMigrate:
+		applied, err = readGolangMigrateHistory(ctx, db, migrations)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s history: %w", format, err)
+	}
+	known := make(map[string]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.key] = true
+	}
+	for key := range applied {
+		if !known[key] {
+			return nil, fmt.Errorf("%s migration %s is recorded as applied without a migration file", format, key)
+		}
+	}
+	return applied, nil
+}
+
+func readGolangMigrateHistory(ctx context.Context, db *sql.DB, migrations []*importMigration) (map[string]bool, error) {
+	var version int64
+	var dirty bool
+	err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if dirty {
+		return nil, fmt.Errorf("version %d is dirty, fix it with golang-migrate force before importing", version)
+	}
+	applied := make(map[string]bool)
+	for _, m := range migrations {
+		if m.version <= version {
+			applied[m.key] = true
+		}
+	}
+	return applied, nil
+}
+
+func readFlywayHistory(ctx context.Context, db *sql.DB, migrations []*importMigration) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT version, type, success FROM flyway_schema_history WHERE version IS NOT NULL ORDER BY installed_rank")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[string]bool)
+	var baseline string
+	for rows.Next() {
+		var version, typ string
+		var success bool
+		if err := rows.Scan(&version, &typ, &success); err != nil {
+			return nil, err
+		}
+		version = normalizeFlywayVersion(version)
+		switch {
+		case typ == "BASELINE":
+			baseline = version
+		case !success:
+			return nil, fmt.Errorf("version %s failed, fix it with flyway repair before importing", version)
+		case typ == "DELETE":
+		case strings.HasPrefix(typ, "UNDO_"):
+			delete(applied, version)
+		default:
+			applied[version] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Versions up to the baseline are applied, without history rows of their own.
+	if baseline != "" {
+		for _, m := range migrations {
+			if m.version > 0 && compareFlywayVersions(m.key, baseline) <= 0 {
+				applied[m.key] = true
+			}
+		}
+	}
+	return applied, nil
+}
+
+func readLiquibaseHistory(ctx context.Context, db *sql.DB) (map[string]bool, error) {
+	rows, err := db.QueryContext(ctx, "SELECT ID, AUTHOR, EXECTYPE FROM DATABASECHANGELOG ORDER BY ORDEREXECUTED")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[string]bool)
+	for rows.Next() {
+		var id, author, execType string
+		if err := rows.Scan(&id, &author, &execType); err != nil {
+			return nil, err
+		}
+		switch execType {
+		case "EXECUTED", "MARK_RAN", "RERAN":
+			applied[author+":"+id] = true
+		case "FAILED":
+			return nil, fmt.Errorf("changeset %s:%s failed, fix it before importing", author, id)
+		}
+	}
+	return applied, rows.Err()
+}
+
+// importExistingVersions returns the versions of the goose migrations already in dir.
+func importExistingVersions(dir string) (map[int64]bool, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+	versions := make(map[int64]bool)
+	for _, e := range entries {
+		if v, err := NumericComponent(e.Name()); err == nil && !e.IsDir() {
+			versions[v] = true
+		}
+	}
+	return versions, nil
+}
+
+// importVersions records the applied imported migrations in the goose version table, in a
+// transaction that is returned uncommitted.
+func importVersions(ctx context.Context, db *sql.DB, imported []*ImportedMigration) (*sql.Tx, error) {
+	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to ensure DB version: %w", err)
+	}
+	records, err := getStore().ListMigrations(ctx, db, TableName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	recorded := make(map[int64]bool, len(records))
+	for _, r := range records {
+		recorded[r.VersionID] = true
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range imported {
+		if !m.Applied || recorded[m.Version] {
+			continue
+		}
+		if err := getStore().InsertVersion(ctx, tx, TableName(), m.Version); err != nil {
+			return nil, multierr.Append(fmt.Errorf("failed to insert version %d: %w", m.Version, err), tx.Rollback())
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,224 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestImport(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	writeFiles := func(t *testing.T, files map[string]string) string {
+		t.Helper()
+		dir := t.TempDir()
+		for name, data := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+		}
+		return dir
+	}
+	newDB := func(t *testing.T, queries ...string) *sql.DB {
+		t.Helper()
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "import.db"))
+		require.NoError(t, err)
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { _ = db.Close() })
+		for _, q := range queries {
+			_, err := db.ExecContext(ctx, q)
+			require.NoError(t, err)
+		}
+		return db
+	}
+	readFile := func(t *testing.T, path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+	t.Run("golang_migrate", func(t *testing.T) {
+		src := writeFiles(t, map[string]string{
+			"1_create_users.up.sql":   "CREATE TABLE users (id INTEGER);\n",
+			"1_create_users.down.sql": "DROP TABLE users;\n",
+			"2_add_email.up.sql":      "ALTER TABLE users ADD COLUMN email TEXT;\n",
+			"3_add_index.up.sql":      "CREATE INDEX users_email ON users (email);\n",
+			"README.md":               "not a migration",
+		})
+		db := newDB(t,
+			"CREATE TABLE users (id INTEGER, email TEXT)",
+			"CREATE TABLE schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)",
+			"INSERT INTO schema_migrations VALUES (2, false)",
+		)
+		dir := filepath.Join(t.TempDir(), "migrations")
+		imported, err := goose.Import(ctx, db, goose.ImportGolangMigrate, src, dir)
+		require.NoError(t, err)
+		require.Len(t, imported, 3)
+		require.Equal(t, &goose.ImportedMigration{
+			Version: 1,
+			Source:  "1_create_users.up.sql",
+			Path:    filepath.Join(dir, "00001_create_users.sql"),
+			Applied: true,
+		}, imported[0])
+		require.True(t, imported[1].Applied)
+		require.False(t, imported[2].Applied)
+		require.Equal(t, `-- Imported from golang-migrate 1_create_users.up.sql
+-- +goose Up
+CREATE TABLE users (id INTEGER);
+
+-- +goose Down
+DROP TABLE users;
+`, readFile(t, imported[0].Path))
+
+		// The applied history is kept, so only the last migration is pending.
+		version, err := goose.GetDBVersion(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, version)
+		require.NoError(t, goose.Up(db, dir))
+		version, err = goose.GetDBVersion(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, version)
+
+		// Versions that already exist are not overwritten.
+		_, err = goose.Import(ctx, nil, goose.ImportGolangMigrate, src, dir)
+		require.ErrorContains(t, err, "already exists")
+	})
+	t.Run("golang_migrate_dirty", func(t *testing.T) {
+		src := writeFiles(t, map[string]string{"1_a.up.sql": "SELECT 1;\n"})
+		db := newDB(t,
+			"CREATE TABLE schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)",
+			"INSERT INTO schema_migrations VALUES (1, true)",
+		)
+		dir := filepath.Join(t.TempDir(), "migrations")
+		_, err := goose.Import(ctx, db, goose.ImportGolangMigrate, src, dir)
+		require.ErrorContains(t, err, "dirty")
+		_, err = os.Stat(dir)
+		require.True(t, os.IsNotExist(err))
+	})
+	t.Run("flyway", func(t *testing.T) {
+		src := writeFiles(t, map[string]string{
+			"V1__Create_users.sql":  "CREATE TABLE users (id INTEGER);\n",
+			"U1__Create_users.sql":  "DROP TABLE users;\n",
+			"V1_1__Add_email.sql":   "ALTER TABLE users ADD COLUMN email TEXT;\n",
+			"V1.10__Add_index.sql":  "CREATE INDEX users_email ON users (email);\n",
+			"V1.2__Add_name.sql":    "ALTER TABLE users ADD COLUMN name TEXT;\n",
+			"R__Users_view.sql":     "DROP VIEW IF EXISTS names;\nCREATE VIEW names AS SELECT name FROM users;\n",
+			"afterMigrate.sql":      "SELECT 1;\n",
+			"V2__Not_applied.sql":   "SELECT 1;\n",
+			"V3__Failed_before.sql": "SELECT 1;\n",
+		})
+		db := newDB(t,
+			`CREATE TABLE flyway_schema_history (installed_rank INTEGER, version TEXT, description TEXT,
+				type TEXT, script TEXT, success BOOLEAN)`,
+			`INSERT INTO flyway_schema_history VALUES
+				(1, '1', 'Create users', 'SQL', 'V1__Create_users.sql', true),
+				(2, '1.1', 'Add email', 'SQL', 'V1_1__Add_email.sql', true),
+				(3, '1.2', 'Add name', 'SQL', 'V1.2__Add_name.sql', true),
+				(4, NULL, 'Users view', 'SQL', 'R__Users_view.sql', true),
+				(5, '1.10', 'Add index', 'SQL', 'V1.10__Add_index.sql', true)`,
+		)
+		dir := filepath.Join(t.TempDir(), "migrations")
+		imported, err := goose.Import(ctx, db, goose.ImportFlyway, src, dir)
+		require.NoError(t, err)
+		var names []string
+		var applied []int64
+		for _, m := range imported {
+			names = append(names, filepath.Base(m.Path))
+			if m.Applied {
+				applied = append(applied, m.Version)
+			}
+		}
+		// Versions such as 1.1 are numbered sequentially, in version order.
+		require.Equal(t, []string{
+			"00001_create_users.sql",
+			"00002_add_email.sql",
+			"00003_add_name.sql",
+			"00004_add_index.sql",
+			"00005_not_applied.sql",
+			"00006_failed_before.sql",
+			"R__users_view.sql",
+		}, names)
+		require.Equal(t, []int64{1, 2, 3, 4}, applied)
+		require.Contains(t, readFile(t, filepath.Join(dir, "00001_create_users.sql")), "-- +goose Down\nDROP TABLE users;\n")
+	})
+	t.Run("flyway_baseline", func(t *testing.T) {
+		src := writeFiles(t, map[string]string{
+			"V1__a.sql": "SELECT 1;\n",
+			"V2__b.sql": "SELECT 1;\n",
+			"V3__c.sql": "SELECT 1;\n",
+		})
+		db := newDB(t,
+			"CREATE TABLE flyway_schema_history (installed_rank INTEGER, version TEXT, type TEXT, success BOOLEAN)",
+			"INSERT INTO flyway_schema_history VALUES (1, '2', 'BASELINE', true)",
+		)
+		imported, err := goose.Import(ctx, db, goose.ImportFlyway, src, filepath.Join(t.TempDir(), "migrations"))
+		require.NoError(t, err)
+		require.Len(t, imported, 3)
+		// Integer versions are kept.
+		require.EqualValues(t, 3, imported[2].Version)
+		require.True(t, imported[0].Applied)
+		require.True(t, imported[1].Applied)
+		require.False(t, imported[2].Applied)
+	})
+	t.Run("flyway_missing_file", func(t *testing.T) {
+		src := writeFiles(t, map[string]string{"V1__a.sql": "SELECT 1;\n"})
+		db := newDB(t,
+			"CREATE TABLE flyway_schema_history (installed_rank INTEGER, version TEXT, type TEXT, success BOOLEAN)",
+			"INSERT INTO flyway_schema_history VALUES (1, '1', 'SQL', true), (2, '2', 'SQL', true)",
+		)
+		_, err := goose.Import(ctx, db, goose.ImportFlyway, src, filepath.Join(t.TempDir(), "migrations"))
+		require.ErrorContains(t, err, "recorded as applied without a migration file")
+	})
+	t.Run("liquibase", func(t *testing.T) {
+		src := writeFiles(t, map[string]string{
+			"changelog.sql": `--liquibase formatted sql
+
+--changeset alice:create-users
+--comment: the users table
+CREATE TABLE users (id INTEGER);
+--rollback DROP TABLE users;
+
+--changeset bob:add-email splitStatements:true
+ALTER TABLE users ADD COLUMN email TEXT;
+UPDATE users SET email = '';
+`,
+			"other.sql": "SELECT 1;\n",
+		})
+		db := newDB(t,
+			"CREATE TABLE DATABASECHANGELOG (ID TEXT, AUTHOR TEXT, FILENAME TEXT, ORDEREXECUTED INTEGER, EXECTYPE TEXT)",
+			"INSERT INTO DATABASECHANGELOG VALUES ('create-users', 'alice', 'changelog.sql', 1, 'EXECUTED')",
+		)
+		dir := filepath.Join(t.TempDir(), "migrations")
+		imported, err := goose.Import(ctx, db, goose.ImportLiquibase, src, dir)
+		require.NoError(t, err)
+		require.Len(t, imported, 2)
+		require.Equal(t, "alice:create-users", imported[0].Source)
+		require.True(t, imported[0].Applied)
+		require.False(t, imported[1].Applied)
+		require.Equal(t, `-- Imported from liquibase alice:create-users
+-- +goose Up
+CREATE TABLE users (id INTEGER);
+
+-- +goose Down
+DROP TABLE users;
+`, readFile(t, filepath.Join(dir, "00001_create_users.sql")))
+		require.Equal(t, `-- Imported from liquibase bob:add-email
+-- +goose Up
+ALTER TABLE users ADD COLUMN email TEXT;
+UPDATE users SET email = '';
+`, readFile(t, filepath.Join(dir, "00002_add_email.sql")))
+	})
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := goose.Import(ctx, nil, "sqitch", t.TempDir(), t.TempDir())
+		require.Error(t, err)
+		_, err = goose.Import(ctx, nil, goose.ImportFlyway, t.TempDir(), t.TempDir())
+		require.ErrorContains(t, err, "no flyway migrations found")
+	})
+}
@@ -12,7 +12,10 @@ import (
 // that are not part of the core Store interface.
 type StoreController struct{ database.Store }
 
-var _ database.StoreExtender = (*StoreController)(nil)
+var (
+	_ database.StoreExtender = (*StoreController)(nil)
+	_ database.MetadataStore = (*StoreController)(nil)
+)
 
 // NewStoreController returns a new StoreController that wraps the given Store.
 //
@@ -20,6 +23,9 @@ var _ database.StoreExtender = (*StoreController)(nil)
 // appropriate:
 //
 //   - TableExists(context.Context, DBTxConn) (bool, error)
+//   - UpdateVersion(context.Context, DBTxConn, int64, int64) error
+//   - CreateMetadataTable, MetadataTableExists, InsertMetadata, DeleteMetadata,
+//     DeleteMetadataKey, UpdateMetadataVersion and ListMetadata
 //
 // If the Store does not implement a method, it will either return a [errors.ErrUnsupported] error
 // or fall back to the default behavior.
@@ -35,3 +41,75 @@ func (c *StoreController) TableExists(ctx context.Context, db database.DBTxConn)
 	}
 	return false, errors.ErrUnsupported
 }
+
+func (c *StoreController) UpdateVersion(ctx context.Context, db database.DBTxConn, oldVersion, newVersion int64) error {
+	if t, ok := c.Store.(interface {
+		UpdateVersion(ctx context.Context, db database.DBTxConn, oldVersion, newVersion int64) error
+	}); ok {
+		return t.UpdateVersion(ctx, db, oldVersion, newVersion)
+	}
+	return errors.ErrUnsupported
+}
+
+func (c *StoreController) CreateMetadataTable(ctx context.Context, db database.DBTxConn) error {
+	if t, ok := c.Store.(interface {
+		CreateMetadataTable(ctx context.Context, db database.DBTxConn) error
+	}); ok {
+		return t.CreateMetadataTable(ctx, db)
+	}
+	return errors.ErrUnsupported
+}
+
+func (c *StoreController) MetadataTableExists(ctx context.Context, db database.DBTxConn) (bool, error) {
+	if t, ok := c.Store.(interface {
+		MetadataTableExists(ctx context.Context, db database.DBTxConn) (bool, error)
+	}); ok {
+		return t.MetadataTableExists(ctx, db)
+	}
+	return false, errors.ErrUnsupported
+}
+
+func (c *StoreController) InsertMetadata(ctx context.Context, db database.DBTxConn, version int64, key, value string) error {
+	if t, ok := c.Store.(interface {
+		InsertMetadata(ctx context.Context, db database.DBTxConn, version int64, key, value string) error
+	}); ok {
+		return t.InsertMetadata(ctx, db, version, key, value)
+	}
+	return errors.ErrUnsupported
+}
+
+func (c *StoreController) DeleteMetadata(ctx context.Context, db database.DBTxConn, version int64) error {
+	if t, ok := c.Store.(interface {
+		DeleteMetadata(ctx context.Context, db database.DBTxConn, version int64) error
+	}); ok {
+		return t.DeleteMetadata(ctx, db, version)
+	}
+	return errors.ErrUnsupported
+}
+
+func (c *StoreController) DeleteMetadataKey(ctx context.Context, db database.DBTxConn, version int64, key string) error {
+	if t, ok := c.Store.(interface {
+		DeleteMetadataKey(ctx context.Context, db database.DBTxConn, version int64, key string) error
+	}); ok {
+		return t.DeleteMetadataKey(ctx, db, version, key)
+	}
+	return errors.ErrUnsupported
+}
+
+func (c *StoreController) UpdateMetadataVersion(ctx context.Context, db database.DBTxConn, oldVersion, newVersion int64) error {
+	if t, ok := c.Store.(interface {
+		UpdateMetadataVersion(ctx context.Context, db database.DBTxConn, oldVersion, newVersion int64) error
+	}); ok {
+		return t.UpdateMetadataVersion(ctx, db, oldVersion, newVersion)
+	}
+	return errors.ErrUnsupported
+}
+
+func (c *StoreController) ListMetadata(ctx context.Context, db database.DBTxConn) ([]*database.MetadataResult, error) {
+	if t, ok := c.Store.(interface {
+		ListMetadata(ctx context.Context, db database.DBTxConn) ([]*database.MetadataResult, error)
+	}); ok {
+		return t.ListMetadata(ctx, db)
+	}
+	return nil, errors.ErrUnsupported
+}
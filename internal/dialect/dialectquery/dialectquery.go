@@ -47,3 +47,54 @@ func (c *QueryController) TableExists(tableName string) string {
 	}
 	return ""
 }
+
+// UpdateVersion returns the SQL query string to change the version_id of all records of a version
+// in the version table. The query takes the new and the old version_id, in that order. If the
+// Querier does not implement this method, it will return an empty string.
+func (c *QueryController) UpdateVersion(tableName string) string {
+	if t, ok := c.Querier.(interface{ UpdateVersion(string) string }); ok {
+		return t.UpdateVersion(tableName)
+	}
+	return ""
+}
+
+// MetadataQuerier is implemented by dialects that support the metadata table, which records
+// additional key/value information, such as run labels, alongside applied versions. All methods
+// take the name of the version table; the metadata table name is derived from it with
+// [MetadataTableName].
+type MetadataQuerier interface {
+	// CreateMetadataTable returns the SQL query string to create the metadata table if it does not
+	// already exist.
+	CreateMetadataTable(tableName string) string
+	// MetadataTableExists returns the SQL query string to check if the metadata table exists.
+	//
+	// Returns a boolean value.
+	MetadataTableExists(tableName string) string
+	// InsertMetadata returns the SQL query string to insert a version_id, key and value.
+	InsertMetadata(tableName string) string
+	// DeleteMetadata returns the SQL query string to delete all metadata for a version_id.
+	DeleteMetadata(tableName string) string
+	// DeleteMetadataKey returns the SQL query string to delete a single key for a version_id.
+	DeleteMetadataKey(tableName string) string
+	// UpdateMetadataVersion returns the SQL query string to change the version_id of all metadata
+	// for a version_id. The query takes the new and the old version_id, in that order.
+	UpdateMetadataVersion(tableName string) string
+	// ListMetadata returns the SQL query string to list all metadata ordered by version_id and key.
+	//
+	// The query should return the version_id, meta_key and meta_value columns.
+	ListMetadata(tableName string) string
+}
+
+// MetadataTableName returns the name of the metadata table for the given version table.
+func MetadataTableName(tableName string) string {
+	return tableName + "_meta"
+}
+
+// Metadata returns the MetadataQuerier for the wrapped Querier, or nil if it does not support the
+// metadata table.
+func (c *QueryController) Metadata() MetadataQuerier {
+	if m, ok := c.Querier.(MetadataQuerier); ok {
+		return m
+	}
+	return nil
+}
@@ -27,6 +27,11 @@ func (m *Mysql) DeleteVersion(tableName string) string {
 	return fmt.Sprintf(q, tableName)
 }
 
+func (m *Mysql) UpdateVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=? WHERE version_id=?`
+	return fmt.Sprintf(q, tableName)
+}
+
 func (m *Mysql) GetMigrationByVersion(tableName string) string {
 	q := `SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1`
 	return fmt.Sprintf(q, tableName)
@@ -41,3 +46,43 @@ func (m *Mysql) GetLatestVersion(tableName string) string {
 	q := `SELECT MAX(version_id) FROM %s`
 	return fmt.Sprintf(q, tableName)
 }
+
+func (m *Mysql) CreateMetadataTable(tableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		version_id bigint NOT NULL,
+		meta_key varchar(255) NOT NULL,
+		meta_value text NOT NULL,
+		PRIMARY KEY(version_id, meta_key)
+	)`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (m *Mysql) MetadataTableExists(tableName string) string {
+	q := `SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = '%s')`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (m *Mysql) InsertMetadata(tableName string) string {
+	q := `INSERT INTO %s (version_id, meta_key, meta_value) VALUES (?, ?, ?)`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (m *Mysql) DeleteMetadata(tableName string) string {
+	q := `DELETE FROM %s WHERE version_id=?`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (m *Mysql) DeleteMetadataKey(tableName string) string {
+	q := `DELETE FROM %s WHERE version_id=? AND meta_key=?`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (m *Mysql) UpdateMetadataVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=? WHERE version_id=?`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (m *Mysql) ListMetadata(tableName string) string {
+	q := `SELECT version_id, meta_key, meta_value FROM %s ORDER BY version_id, meta_key`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
@@ -29,6 +29,11 @@ func (p *Postgres) DeleteVersion(tableName string) string {
 	return fmt.Sprintf(q, tableName)
 }
 
+func (p *Postgres) UpdateVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=$1 WHERE version_id=$2`
+	return fmt.Sprintf(q, tableName)
+}
+
 func (p *Postgres) GetMigrationByVersion(tableName string) string {
 	q := `SELECT tstamp, is_applied FROM %s WHERE version_id=$1 ORDER BY tstamp DESC LIMIT 1`
 	return fmt.Sprintf(q, tableName)
@@ -61,3 +66,42 @@ func parseTableIdentifier(name string) (schema, table string) {
 	}
 	return schema, table
 }
+
+func (p *Postgres) CreateMetadataTable(tableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		version_id bigint NOT NULL,
+		meta_key varchar(255) NOT NULL,
+		meta_value text NOT NULL,
+		PRIMARY KEY (version_id, meta_key)
+	)`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (p *Postgres) MetadataTableExists(tableName string) string {
+	return p.TableExists(MetadataTableName(tableName))
+}
+
+func (p *Postgres) InsertMetadata(tableName string) string {
+	q := `INSERT INTO %s (version_id, meta_key, meta_value) VALUES ($1, $2, $3)`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (p *Postgres) DeleteMetadata(tableName string) string {
+	q := `DELETE FROM %s WHERE version_id=$1`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (p *Postgres) DeleteMetadataKey(tableName string) string {
+	q := `DELETE FROM %s WHERE version_id=$1 AND meta_key=$2`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (p *Postgres) UpdateMetadataVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=$1 WHERE version_id=$2`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (p *Postgres) ListMetadata(tableName string) string {
+	q := `SELECT version_id, meta_key, meta_value FROM %s ORDER BY version_id, meta_key`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
@@ -27,6 +27,11 @@ func (r *Redshift) DeleteVersion(tableName string) string {
 	return fmt.Sprintf(q, tableName)
 }
 
+func (r *Redshift) UpdateVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=$1 WHERE version_id=$2`
+	return fmt.Sprintf(q, tableName)
+}
+
 func (r *Redshift) GetMigrationByVersion(tableName string) string {
 	q := `SELECT tstamp, is_applied FROM %s WHERE version_id=$1 ORDER BY tstamp DESC LIMIT 1`
 	return fmt.Sprintf(q, tableName)
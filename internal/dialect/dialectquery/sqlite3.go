@@ -26,6 +26,11 @@ func (s *Sqlite3) DeleteVersion(tableName string) string {
 	return fmt.Sprintf(q, tableName)
 }
 
+func (s *Sqlite3) UpdateVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=? WHERE version_id=?`
+	return fmt.Sprintf(q, tableName)
+}
+
 func (s *Sqlite3) GetMigrationByVersion(tableName string) string {
 	q := `SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1`
 	return fmt.Sprintf(q, tableName)
@@ -40,3 +45,43 @@ func (s *Sqlite3) GetLatestVersion(tableName string) string {
 	q := `SELECT MAX(version_id) FROM %s`
 	return fmt.Sprintf(q, tableName)
 }
+
+func (s *Sqlite3) CreateMetadataTable(tableName string) string {
+	q := `CREATE TABLE IF NOT EXISTS %s (
+		version_id INTEGER NOT NULL,
+		meta_key TEXT NOT NULL,
+		meta_value TEXT NOT NULL,
+		PRIMARY KEY (version_id, meta_key)
+	)`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (s *Sqlite3) MetadataTableExists(tableName string) string {
+	q := `SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type='table' AND name='%s')`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (s *Sqlite3) InsertMetadata(tableName string) string {
+	q := `INSERT INTO %s (version_id, meta_key, meta_value) VALUES (?, ?, ?)`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (s *Sqlite3) DeleteMetadata(tableName string) string {
+	q := `DELETE FROM %s WHERE version_id=?`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (s *Sqlite3) DeleteMetadataKey(tableName string) string {
+	q := `DELETE FROM %s WHERE version_id=? AND meta_key=?`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (s *Sqlite3) UpdateMetadataVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=? WHERE version_id=?`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
+
+func (s *Sqlite3) ListMetadata(tableName string) string {
+	q := `SELECT version_id, meta_key, meta_value FROM %s ORDER BY version_id, meta_key`
+	return fmt.Sprintf(q, MetadataTableName(tableName))
+}
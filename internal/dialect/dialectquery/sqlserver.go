@@ -26,6 +26,11 @@ func (s *Sqlserver) DeleteVersion(tableName string) string {
 	return fmt.Sprintf(q, tableName)
 }
 
+func (s *Sqlserver) UpdateVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=@p1 WHERE version_id=@p2`
+	return fmt.Sprintf(q, tableName)
+}
+
 func (s *Sqlserver) GetMigrationByVersion(tableName string) string {
 	q := `SELECT TOP 1 tstamp, is_applied FROM %s WHERE version_id=@p1 ORDER BY tstamp DESC`
 	return fmt.Sprintf(q, tableName)
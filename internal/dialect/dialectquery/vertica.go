@@ -27,6 +27,11 @@ func (v *Vertica) DeleteVersion(tableName string) string {
 	return fmt.Sprintf(q, tableName)
 }
 
+func (v *Vertica) UpdateVersion(tableName string) string {
+	q := `UPDATE %s SET version_id=? WHERE version_id=?`
+	return fmt.Sprintf(q, tableName)
+}
+
 func (v *Vertica) GetMigrationByVersion(tableName string) string {
 	q := `SELECT tstamp, is_applied FROM %s WHERE version_id=? ORDER BY tstamp DESC LIMIT 1`
 	return fmt.Sprintf(q, tableName)
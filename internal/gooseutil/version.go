@@ -0,0 +1,88 @@
+package gooseutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CheckVersionConstraint returns an error if version does not satisfy the constraint. A constraint
+// is an operator followed by a version, e.g., ">= v3.20". Supported operators are >=, >, <=, < and
+// =; a bare version is treated as >=.
+//
+// Versions are of the form vMAJOR[.MINOR[.PATCH]], with missing parts treated as zero. Pre-release
+// and build suffixes are ignored.
+func CheckVersionConstraint(constraint, version string) error {
+	op, want := splitConstraint(constraint)
+	wantParts, err := parseVersion(want)
+	if err != nil {
+		return fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	gotParts, err := parseVersion(version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", version, err)
+	}
+	cmp := compareVersions(gotParts, wantParts)
+	var ok bool
+	switch op {
+	case ">=":
+		ok = cmp >= 0
+	case ">":
+		ok = cmp > 0
+	case "<=":
+		ok = cmp <= 0
+	case "<":
+		ok = cmp < 0
+	case "=":
+		ok = cmp == 0
+	}
+	if !ok {
+		return fmt.Errorf("version %s does not satisfy %s %s", version, op, want)
+	}
+	return nil
+}
+
+func splitConstraint(constraint string) (op, version string) {
+	constraint = strings.TrimSpace(constraint)
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if v, ok := strings.CutPrefix(constraint, op); ok {
+			return op, strings.TrimSpace(v)
+		}
+	}
+	return ">=", constraint
+}
+
+func parseVersion(s string) ([3]int, error) {
+	var parts [3]int
+	v, ok := strings.CutPrefix(s, "v")
+	if !ok {
+		return parts, fmt.Errorf("must start with v")
+	}
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) > 3 {
+		return parts, fmt.Errorf("too many version parts")
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version part %q", f)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
+
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
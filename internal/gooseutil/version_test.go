@@ -0,0 +1,40 @@
+package gooseutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		constraint, version string
+		ok                  bool
+	}{
+		{">= v3.20", "v3.20.0", true},
+		{">= v3.20", "v3.24.1", true},
+		{">= v3.20", "v3.19.9", false},
+		{"v3.20", "v3.21.0", true},
+		{"v3.20", "v3.2.0", false},
+		{">v3.20.1", "v3.20.1", false},
+		{"< v4", "v3.99.0", true},
+		{"<= v3.20", "v3.20.1", false},
+		{"= v3.20.0", "v3.20.0-rc1", true},
+		{">= v3.20", "v4.0.0", true},
+	}
+	for _, tt := range tests {
+		err := CheckVersionConstraint(tt.constraint, tt.version)
+		if tt.ok {
+			require.NoError(t, err, "%s %s", tt.constraint, tt.version)
+		} else {
+			require.Error(t, err, "%s %s", tt.constraint, tt.version)
+		}
+	}
+	for _, constraint := range []string{">= 3.20", ">= v3.x", "", ">= v1.2.3.4"} {
+		err := CheckVersionConstraint(constraint, "v3.20.0")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid version constraint")
+	}
+}
@@ -0,0 +1,86 @@
+package gooseutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily time window, e.g., from 02:00 to 04:00 UTC.
+type Window struct {
+	// start and end are offsets from midnight. If end is before start, the window spans midnight.
+	start, end time.Duration
+	loc        *time.Location
+	spec       string
+}
+
+// ParseWindow parses a window of the form "HH:MM-HH:MM [ZONE]", e.g., "02:00-04:00 UTC". The zone
+// is an IANA time zone name and defaults to UTC. The start is inclusive and the end exclusive. A
+// window whose end is before its start spans midnight, e.g., "22:00-02:00".
+func ParseWindow(spec string) (Window, error) {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || len(fields) > 2 {
+		return Window{}, fmt.Errorf("invalid window %q: must be of the form HH:MM-HH:MM [ZONE]", spec)
+	}
+	from, to, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return Window{}, fmt.Errorf("invalid window %q: must be of the form HH:MM-HH:MM [ZONE]", spec)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", spec, err)
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid window %q: %w", spec, err)
+	}
+	if start == end {
+		return Window{}, fmt.Errorf("invalid window %q: start and end must differ", spec)
+	}
+	loc := time.UTC
+	if len(fields) == 2 {
+		if loc, err = time.LoadLocation(fields[1]); err != nil {
+			return Window{}, fmt.Errorf("invalid window %q: %w", spec, err)
+		}
+	}
+	return Window{start: start, end: end, loc: loc, spec: spec}, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: must be HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains reports whether t is within the window.
+func (w Window) Contains(t time.Time) bool {
+	t = t.In(w.loc)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end
+	}
+	return offset >= w.start || offset < w.end
+}
+
+// Next returns the earliest time at or after t that is within the window.
+func (w Window) Next(t time.Time) time.Time {
+	if w.Contains(t) {
+		return t
+	}
+	t = t.In(w.loc)
+	// The start is computed on the wall clock, so that it is correct on days with a DST change.
+	minutes := int(w.start / time.Minute)
+	next := time.Date(t.Year(), t.Month(), t.Day(), 0, minutes, 0, 0, w.loc)
+	if !next.After(t) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, 0, minutes, 0, 0, w.loc)
+	}
+	return next
+}
+
+// String returns the window as given to ParseWindow.
+func (w Window) String() string {
+	return w.spec
+}
@@ -0,0 +1,48 @@
+package gooseutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 5, 1, hour, minute, 0, 0, time.UTC)
+	}
+	t.Run("same_day", func(t *testing.T) {
+		w, err := ParseWindow("02:00-04:00 UTC")
+		require.NoError(t, err)
+		require.True(t, w.Contains(at(2, 0)))
+		require.True(t, w.Contains(at(3, 59)))
+		require.False(t, w.Contains(at(4, 0)))
+		require.False(t, w.Contains(at(1, 59)))
+		require.Equal(t, at(3, 0), w.Next(at(3, 0)))
+		require.Equal(t, at(2, 0), w.Next(at(1, 0)))
+		require.Equal(t, at(2, 0).AddDate(0, 0, 1), w.Next(at(5, 0)))
+	})
+	t.Run("spans_midnight", func(t *testing.T) {
+		w, err := ParseWindow("22:00-02:00")
+		require.NoError(t, err)
+		require.True(t, w.Contains(at(23, 0)))
+		require.True(t, w.Contains(at(1, 0)))
+		require.False(t, w.Contains(at(12, 0)))
+		require.Equal(t, at(22, 0), w.Next(at(12, 0)))
+	})
+	t.Run("zone", func(t *testing.T) {
+		w, err := ParseWindow("02:00-04:00 America/New_York")
+		require.NoError(t, err)
+		// 02:30 in New York is 06:30 UTC during daylight saving time.
+		require.True(t, w.Contains(at(6, 30)))
+		require.False(t, w.Contains(at(2, 30)))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, spec := range []string{"", "02:00", "2am-4am", "02:00-02:00", "02:00-04:00 Nowhere/City", "02:00-04:00 UTC extra"} {
+			_, err := ParseWindow(spec)
+			require.Error(t, err, spec)
+		}
+	})
+}
@@ -15,6 +15,7 @@ const (
 	registerGoFuncNameNoTx        = "AddMigrationNoTx"
 	registerGoFuncNameContext     = "AddMigrationContext"
 	registerGoFuncNameNoTxContext = "AddMigrationNoTxContext"
+	registerGoFuncNameConnContext = "AddMigrationConnContext"
 )
 
 type goMigration struct {
@@ -78,7 +79,7 @@ func parseInitFunc(fd *ast.FuncDecl) (*goMigration, error) {
 		case registerGoFuncName, registerGoFuncNameContext:
 			b = true
 			gf.useTx = &b
-		case registerGoFuncNameNoTx, registerGoFuncNameNoTxContext:
+		case registerGoFuncNameNoTx, registerGoFuncNameNoTxContext, registerGoFuncNameConnContext:
 			gf.useTx = &b
 		default:
 			continue
@@ -11,6 +11,8 @@ import (
 type sqlMigration struct {
 	useTx              bool
 	upCount, downCount int
+	hasDown            bool
+	tombstone          bool
 }
 
 func parseSQLFile(r io.Reader, debug bool) (*sqlMigration, error) {
@@ -18,6 +20,14 @@ func parseSQLFile(r io.Reader, debug bool) (*sqlMigration, error) {
 	if err != nil {
 		return nil, err
 	}
+	directives, err := sqlparser.ParseDirectives(bytes.NewReader(by))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveTombstone); ok {
+		// Tombstones have no statements and need not have Up or Down sections.
+		return &sqlMigration{useTx: true, tombstone: true}, nil
+	}
 	upStatements, txUp, err := sqlparser.ParseSQLMigration(
 		bytes.NewReader(by),
 		sqlparser.DirectionUp,
@@ -38,9 +48,14 @@ func parseSQLFile(r io.Reader, debug bool) (*sqlMigration, error) {
 	if txUp != txDown {
 		return nil, fmt.Errorf("up and down statements must have the same transaction mode")
 	}
+	hasDown, err := sqlparser.HasDown(bytes.NewReader(by))
+	if err != nil {
+		return nil, err
+	}
 	return &sqlMigration{
 		useTx:     txUp,
 		upCount:   len(upStatements),
 		downCount: len(downStatements),
+		hasDown:   hasDown,
 	}, nil
 }
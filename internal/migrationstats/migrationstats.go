@@ -27,6 +27,36 @@ type Stats struct {
 	UpCount int
 	// DownCount is the number of statements in the Down migration.
 	DownCount int
+	// HasDown is true if the .sql migration file has a +goose Down annotation, even without
+	// statements, or the .go migration file registers a down function.
+	HasDown bool
+	// Tombstone is true if the .sql migration file has a +goose tombstone directive.
+	Tombstone bool
+}
+
+// Reversibility classifies how a migration is rolled back.
+type Reversibility string
+
+const (
+	// Reversible migrations have Down statements or a down function. Tombstones are reversible,
+	// since they never run in either direction.
+	Reversible Reversibility = "reversible"
+	// EmptyDown migrations have a Down annotation without statements, so rolling them back only
+	// removes their version.
+	EmptyDown Reversibility = "empty-down"
+	// Irreversible migrations have no Down section or down function.
+	Irreversible Reversibility = "irreversible"
+)
+
+// Reversibility returns how the migration is rolled back.
+func (s *Stats) Reversibility() Reversibility {
+	switch {
+	case s.Tombstone, s.DownCount > 0:
+		return Reversible
+	case s.HasDown:
+		return EmptyDown
+	}
+	return Irreversible
 }
 
 // GatherStats returns the migration file stats.
@@ -38,7 +68,7 @@ func GatherStats(fw FileWalker, debug bool) ([]*Stats, error) {
 			return fmt.Errorf("failed to get version from file %q: %w", filename, err)
 		}
 		var up, down int
-		var tx bool
+		var tx, hasDown, tombstone bool
 		switch filepath.Ext(filename) {
 		case ".sql":
 			m, err := parseSQLFile(r, debug)
@@ -47,6 +77,7 @@ func GatherStats(fw FileWalker, debug bool) ([]*Stats, error) {
 			}
 			up, down = m.upCount, m.downCount
 			tx = m.useTx
+			hasDown, tombstone = m.hasDown, m.tombstone
 		case ".go":
 			m, err := parseGoFile(r)
 			if err != nil {
@@ -54,6 +85,7 @@ func GatherStats(fw FileWalker, debug bool) ([]*Stats, error) {
 			}
 			up, down = nilAsNumber(m.upFuncName), nilAsNumber(m.downFuncName)
 			tx = *m.useTx
+			hasDown = down > 0
 		}
 		stats = append(stats, &Stats{
 			FileName:  filename,
@@ -61,6 +93,8 @@ func GatherStats(fw FileWalker, debug bool) ([]*Stats, error) {
 			Tx:        tx,
 			UpCount:   up,
 			DownCount: down,
+			HasDown:   hasDown,
+			Tombstone: tombstone,
 		})
 		return nil
 	})
@@ -3,6 +3,7 @@ package migrationstats
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 
@@ -81,6 +82,37 @@ func checkGoStats(t *testing.T, stats *Stats, filename string, version int64, up
 	require.Equal(t, stats.Tx, tx)
 }
 
+func TestReversibility(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"001_reversible.sql":   "-- +goose Up\nCREATE TABLE a (id int);\n-- +goose Down\nDROP TABLE a;\n",
+		"002_empty_down.sql":   "-- +goose Up\nCREATE TABLE b (id int);\n-- +goose Down\n",
+		"003_irreversible.sql": "-- +goose Up\nCREATE TABLE c (id int);\n",
+		"004_tombstone.sql":    "-- +goose tombstone replaced by 005\n",
+		"005_up_only.go":       upOnly,
+		"006_up_and_down.go":   upAndDown,
+	}
+	var filenames []string
+	for name, data := range files {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(filename, []byte(data), 0644))
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	stats, err := GatherStats(NewFileWalker(filenames...), false)
+	require.NoError(t, err)
+	require.Len(t, stats, 6)
+	want := []Reversibility{Reversible, EmptyDown, Irreversible, Reversible, Irreversible, Reversible}
+	for i, s := range stats {
+		require.Equal(t, want[i], s.Reversibility(), filepath.Base(s.FileName))
+	}
+	require.True(t, stats[1].HasDown)
+	require.False(t, stats[2].HasDown)
+	require.True(t, stats[3].Tombstone)
+}
+
 func TestParsingGoMigrationsError(t *testing.T) {
 	t.Parallel()
 	_, err := parseGoFile(strings.NewReader(emptyInit))
@@ -0,0 +1,129 @@
+package sqlparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// TableCharset is the character set and collation of a MySQL CREATE TABLE statement, see
+// [ParseTableCharset].
+type TableCharset struct {
+	// Table is the name of the table, as written.
+	Table string
+	// Charset and Collation are the defaults of the table, set in its table options, or empty.
+	Charset   string
+	Collation string
+	// ColumnCharsets and ColumnCollations are the character sets and collations set on columns,
+	// in order.
+	ColumnCharsets   []string
+	ColumnCollations []string
+
+	// end is the offset of the closing parenthesis of the column definitions.
+	end int
+}
+
+var (
+	createTableRe = regexp.MustCompile(`(?is)^CREATE\s+(?:TEMPORARY\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)\s*\(`)
+	charsetRe     = regexp.MustCompile("(?i)\\b(?:CHARACTER\\s+SET|CHARSET)\\s*=?\\s*['\"`]?([A-Za-z0-9_]+)")
+	collateRe     = regexp.MustCompile("(?i)\\bCOLLATE\\s*=?\\s*['\"`]?([A-Za-z0-9_]+)")
+)
+
+// ParseTableCharset returns the character sets and collations of stmt, and reports whether stmt is
+// a CREATE TABLE statement with column definitions. Statements such as CREATE TABLE ... LIKE, which
+// copy the definition of another table, report false. Leading comments are ignored.
+func ParseTableCharset(stmt string) (TableCharset, bool) {
+	offset := len(stmt) - len(skipLeadingComments(stmt))
+	loc := createTableRe.FindStringSubmatchIndex(stmt[offset:])
+	if loc == nil {
+		return TableCharset{}, false
+	}
+	open := offset + loc[1] - 1
+	end, body := matchParen(stmt, open)
+	if end < 0 {
+		return TableCharset{}, false
+	}
+	tc := TableCharset{Table: stmt[offset+loc[2] : offset+loc[3]], end: end}
+	for _, m := range charsetRe.FindAllStringSubmatch(body, -1) {
+		tc.ColumnCharsets = append(tc.ColumnCharsets, m[1])
+	}
+	for _, m := range collateRe.FindAllStringSubmatch(body, -1) {
+		tc.ColumnCollations = append(tc.ColumnCollations, m[1])
+	}
+	options := stmt[end+1:]
+	if m := charsetRe.FindStringSubmatch(options); m != nil {
+		tc.Charset = m[1]
+	}
+	if m := collateRe.FindStringSubmatch(options); m != nil {
+		tc.Collation = m[1]
+	}
+	return tc, true
+}
+
+// InjectTableCharset returns stmt with the charset and collation added to the table options of a
+// CREATE TABLE statement that does not set them. Other statements, and empty values, are left
+// unchanged.
+func InjectTableCharset(stmt, charset, collation string) string {
+	tc, ok := ParseTableCharset(stmt)
+	if !ok {
+		return stmt
+	}
+	var options string
+	if charset != "" && tc.Charset == "" {
+		options += " DEFAULT CHARSET=" + charset
+	}
+	if collation != "" && tc.Collation == "" {
+		options += " COLLATE=" + collation
+	}
+	if options == "" {
+		return stmt
+	}
+	return stmt[:tc.end+1] + options + stmt[tc.end+1:]
+}
+
+// matchParen returns the offset of the parenthesis closing the one at open, and the text between
+// them with string literals and quoted identifiers blanked out. It returns -1 if it is not closed.
+func matchParen(s string, open int) (int, string) {
+	var b strings.Builder
+	depth := 0
+	var quote byte
+	for i := open; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			c = ' '
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			c = ' '
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i, b.String()
+			}
+		}
+		if i > open {
+			b.WriteByte(c)
+		}
+	}
+	return -1, ""
+}
+
+// skipLeadingComments returns stmt without the whitespace, line and block comments before its
+// first keyword.
+func skipLeadingComments(stmt string) string {
+	for {
+		stmt = strings.TrimLeft(stmt, " \t\r\n")
+		switch {
+		case strings.HasPrefix(stmt, "--"):
+			_, stmt, _ = strings.Cut(stmt, "\n")
+		case strings.HasPrefix(stmt, "/*"):
+			_, stmt, _ = strings.Cut(stmt, "*/")
+		default:
+			return stmt
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package sqlparser_test
+
+import (
+	"testing"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTableCharset(t *testing.T) {
+	t.Parallel()
+
+	tc, ok := sqlparser.ParseTableCharset("-- users\nCREATE TABLE IF NOT EXISTS `users` (\n" +
+		"  id BIGINT PRIMARY KEY,\n" +
+		"  name VARCHAR(255) CHARACTER SET utf8mb3 COLLATE utf8mb3_general_ci,\n" +
+		"  note TEXT DEFAULT 'charset latin1'\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;")
+	require.True(t, ok)
+	require.Equal(t, "`users`", tc.Table)
+	require.Equal(t, "utf8mb4", tc.Charset)
+	require.Equal(t, "utf8mb4_0900_ai_ci", tc.Collation)
+	// String literals are not mistaken for column options.
+	require.Equal(t, []string{"utf8mb3"}, tc.ColumnCharsets)
+	require.Equal(t, []string{"utf8mb3_general_ci"}, tc.ColumnCollations)
+
+	tc, ok = sqlparser.ParseTableCharset("create table t (id int) character set = 'latin1';")
+	require.True(t, ok)
+	require.Equal(t, "latin1", tc.Charset)
+	require.Empty(t, tc.Collation)
+
+	for _, stmt := range []string{
+		"CREATE TABLE t2 LIKE t;",
+		"CREATE INDEX idx ON t (id);",
+		"ALTER TABLE t CONVERT TO CHARACTER SET utf8mb4;",
+		"CREATE TABLE t (id int",
+	} {
+		_, ok := sqlparser.ParseTableCharset(stmt)
+		require.False(t, ok, stmt)
+	}
+}
+
+func TestInjectTableCharset(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t,
+		"CREATE TABLE t (id INT) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci ENGINE=InnoDB;",
+		sqlparser.InjectTableCharset("CREATE TABLE t (id INT) ENGINE=InnoDB;", "utf8mb4", "utf8mb4_0900_ai_ci"),
+	)
+	// Only missing options are added.
+	require.Equal(t,
+		"CREATE TABLE t (id INT) COLLATE=utf8mb4_bin CHARSET=utf8mb4;",
+		sqlparser.InjectTableCharset("CREATE TABLE t (id INT) CHARSET=utf8mb4;", "utf8mb4", "utf8mb4_bin"),
+	)
+	for _, stmt := range []string{
+		"CREATE TABLE t (id INT) CHARSET=latin1 COLLATE=latin1_swedish_ci;",
+		"CREATE TABLE t2 LIKE t;",
+		"INSERT INTO t VALUES (1);",
+	} {
+		require.Equal(t, stmt, sqlparser.InjectTableCharset(stmt, "utf8mb4", "utf8mb4_bin"))
+	}
+}
@@ -0,0 +1,99 @@
+package sqlparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	addConstraintRe = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(\S+)\s+ADD\s+CONSTRAINT\s+(\S+)\s+(?:CHECK|FOREIGN\s+KEY)\b`)
+	setNotNullRe    = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(\S+)\s+ALTER\s+(?:COLUMN\s+)?(\S+)\s+SET\s+NOT\s+NULL\s*;?\s*$`)
+	notValidRe      = regexp.MustCompile(`(?i)\bNOT\s+VALID\b`)
+)
+
+// SplitNotValid rewrites a Postgres statement adding a constraint so that existing rows are not
+// checked while the table is locked, and returns the statements that check them afterwards:
+//
+//   - ALTER TABLE t ADD CONSTRAINT c CHECK (...) or FOREIGN KEY (...) is added NOT VALID, and
+//     validated with ALTER TABLE t VALIDATE CONSTRAINT c.
+//   - ALTER TABLE t ALTER COLUMN x SET NOT NULL is replaced with a NOT VALID check constraint, which
+//     is validated, after which SET NOT NULL skips the table scan, and then dropped.
+//
+// It reports false and returns stmt unchanged for other statements, including statements with
+// several actions, unnamed constraints and constraints already added NOT VALID. Leading comments
+// are kept.
+func SplitNotValid(stmt string) (string, []string, bool) {
+	body := skipLeadingComments(stmt)
+	prefix := stmt[:len(stmt)-len(body)]
+	body = strings.TrimSuffix(strings.TrimRight(body, " \t\r\n"), ";")
+	if hasTopLevelComma(body) {
+		return stmt, nil, false
+	}
+	if m := addConstraintRe.FindStringSubmatch(body); m != nil {
+		if notValidRe.MatchString(blankQuoted(body)) {
+			return stmt, nil, false
+		}
+		table, name := m[1], m[2]
+		return prefix + body + " NOT VALID;", []string{
+			"ALTER TABLE " + table + " VALIDATE CONSTRAINT " + name + ";",
+		}, true
+	}
+	if m := setNotNullRe.FindStringSubmatch(body); m != nil {
+		table, column := m[1], m[2]
+		base := table
+		if i := strings.LastIndexByte(base, '.'); i >= 0 {
+			base = base[i+1:]
+		}
+		name := `"` + strings.Trim(base, `"`) + "_" + strings.Trim(column, `"`) + `_not_null"`
+		return prefix + "ALTER TABLE " + table + " ADD CONSTRAINT " + name + " CHECK (" + column + " IS NOT NULL) NOT VALID;", []string{
+			"ALTER TABLE " + table + " VALIDATE CONSTRAINT " + name + ";",
+			"ALTER TABLE " + table + " ALTER COLUMN " + column + " SET NOT NULL;",
+			"ALTER TABLE " + table + " DROP CONSTRAINT " + name + ";",
+		}, true
+	}
+	return stmt, nil, false
+}
+
+// hasTopLevelComma reports whether s has a comma outside parentheses, string literals and quoted
+// identifiers, i.e., whether an ALTER TABLE statement has several actions.
+func hasTopLevelComma(s string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			return true
+		}
+	}
+	return false
+}
+
+// blankQuoted returns s with string literals and quoted identifiers blanked out.
+func blankQuoted(s string) string {
+	b := []byte(s)
+	var quote byte
+	for i, c := range b {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+			b[i] = ' '
+		case c == '\'' || c == '"':
+			quote = c
+			b[i] = ' '
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,57 @@
+package sqlparser_test
+
+import (
+	"testing"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitNotValid(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		stmt     string
+		want     string
+		validate []string
+	}{
+		{
+			"-- orders must reference users\nALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id);\n",
+			"-- orders must reference users\nALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;",
+			[]string{"ALTER TABLE orders VALIDATE CONSTRAINT orders_user_fk;"},
+		},
+		{
+			"alter table if exists only app.orders add constraint total_positive check (total > 0, 'a,b' <> '')",
+			"alter table if exists only app.orders add constraint total_positive check (total > 0, 'a,b' <> '') NOT VALID;",
+			[]string{"ALTER TABLE app.orders VALIDATE CONSTRAINT total_positive;"},
+		},
+		{
+			`ALTER TABLE app.users ALTER COLUMN "Email" SET NOT NULL;`,
+			`ALTER TABLE app.users ADD CONSTRAINT "users_Email_not_null" CHECK ("Email" IS NOT NULL) NOT VALID;`,
+			[]string{
+				`ALTER TABLE app.users VALIDATE CONSTRAINT "users_Email_not_null";`,
+				`ALTER TABLE app.users ALTER COLUMN "Email" SET NOT NULL;`,
+				`ALTER TABLE app.users DROP CONSTRAINT "users_Email_not_null";`,
+			},
+		},
+	} {
+		got, validate, ok := sqlparser.SplitNotValid(tc.stmt)
+		require.True(t, ok, tc.stmt)
+		require.Equal(t, tc.want, got)
+		require.Equal(t, tc.validate, validate)
+	}
+	for _, stmt := range []string{
+		"ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;",
+		"ALTER TABLE orders ADD CHECK (total > 0);",
+		"ALTER TABLE orders ADD CONSTRAINT orders_pk PRIMARY KEY (id);",
+		"ALTER TABLE orders ADD CONSTRAINT a CHECK (total > 0), ADD CONSTRAINT b CHECK (total < 10);",
+		"ALTER TABLE users ALTER COLUMN email SET NOT NULL, ALTER COLUMN name SET NOT NULL;",
+		"ALTER TABLE users ALTER COLUMN email DROP NOT NULL;",
+		"CREATE TABLE users (id INTEGER NOT NULL);",
+	} {
+		got, validate, ok := sqlparser.SplitNotValid(stmt)
+		require.False(t, ok, stmt)
+		require.Equal(t, stmt, got)
+		require.Empty(t, validate)
+	}
+}
@@ -0,0 +1,169 @@
+package sqlparser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Directive is a migration-level annotation of the form "-- +goose NAME [VALUE]".
+//
+// Unlike annotations such as Up or StatementBegin, directives describe the migration as a whole.
+// They may appear anywhere in the file and are never part of the parsed statements.
+type Directive struct {
+	// Name is the lower-case name of the directive, e.g., "tombstone".
+	Name string
+	// Value is the remainder of the line after the name, with surrounding whitespace removed. It
+	// may be empty.
+	Value string
+}
+
+const (
+	// DirectiveTombstone marks a migration as intentionally retired. The migration is still
+	// versioned, but its statements are never run. The optional value is a free-form reason.
+	DirectiveTombstone = "tombstone"
+	// DirectiveRequiresGoose declares the goose versions able to run the migration, e.g.,
+	// ">= v3.20". The value is a version constraint.
+	DirectiveRequiresGoose = "requires-goose"
+	// DirectiveRequiresDB declares the database server versions able to run the migration, e.g.,
+	// "postgres >= 14". The value is a database engine followed by a version constraint.
+	DirectiveRequiresDB = "requires-db"
+	// DirectiveExtension declares a Postgres extension the migration needs, e.g., "postgis >= 3.0".
+	// The value is an extension name, optionally followed by a version constraint. It may be
+	// repeated.
+	DirectiveExtension = "extension"
+	// DirectiveAfter declares the repeatable migrations that must run before this one, e.g.,
+	// "R__users_view.sql". The value is a comma or space separated list of file names.
+	DirectiveAfter = "after"
+	// DirectivePartition declares a time-partitioning policy for a Postgres table partitioned by
+	// range, e.g., "events interval=month premake=3 retain=12". It may be repeated.
+	DirectivePartition = "partition"
+	// DirectiveNotValid opts a Postgres migration into adding CHECK, FOREIGN KEY and NOT NULL
+	// constraints NOT VALID, and validating them once the migration's statements committed.
+	DirectiveNotValid = "not-valid"
+	// DirectiveRefreshMaterializedView declares a materialized view to refresh after the migration
+	// is applied, e.g., "daily_totals concurrently". The value is the view name, optionally
+	// followed by "concurrently".
+	DirectiveRefreshMaterializedView = "refresh-materialized-view"
+	// DirectiveExpectDuration declares how long the migration is expected to run, e.g., "30s".
+	// The value is a Go duration.
+	DirectiveExpectDuration = "expect-duration"
+	// DirectiveAffects declares the tables the migration affects, e.g., "users, orders". The value
+	// is a comma or space separated list of table names.
+	DirectiveAffects = "affects"
+	// DirectiveImpact declares the estimated impact of the migration, e.g., "heavy".
+	DirectiveImpact = "impact"
+	// DirectiveMaxAffected declares the most rows a single statement of the migration may affect,
+	// e.g., "10000". The value is a positive integer.
+	DirectiveMaxAffected = "max-affected"
+	// DirectiveDestructive marks a migration that may destroy data, so its affected tables are
+	// backed up before it is applied. The value is ignored.
+	DirectiveDestructive = "destructive"
+	// DirectiveSnapshot declares small tables whose contents are recorded before the migration is
+	// applied and restored after it is rolled back, e.g., "roles, permissions". The value is a
+	// comma or space separated list of table names.
+	DirectiveSnapshot = "snapshot"
+	// DirectiveLoad declares a CSV or JSON file to load into a table after the Up statements, e.g.,
+	// "table=users file=data/users.csv". The value is a list of key=value pairs.
+	DirectiveLoad = "load"
+	// DirectiveRole declares the database role the migration runs as, e.g., "dba_migrator". The
+	// value is a role name.
+	DirectiveRole = "role"
+	// DirectiveTitle declares a human-readable title of the migration, e.g., "Add users table".
+	// The value is free-form text, reported by status and plan.
+	DirectiveTitle = "title"
+	// DirectiveOwner declares the team owning the migration, e.g., "payments-team". The value is a
+	// team name, reported by status and plan and used to route failure notifications.
+	DirectiveOwner = "owner"
+	// DirectivePrecondition declares a condition checked before the Up statements run, e.g.,
+	// "SELECT count(*) FROM users WHERE email IS NULL". The value is a query returning a single
+	// value, optionally preceded by "expect=VALUE", the value it must return, "0" by default, and
+	// "on-fail=skip" or "on-fail=abort", the default. Skipping a migration also holds back the
+	// migrations after it, and the run fails with goose.ErrPreconditionSkipped. It may be repeated.
+	DirectivePrecondition = "precondition"
+	// DirectiveVerify declares a post-condition checked after the Up statements ran, e.g.,
+	// "SELECT count(*) FROM users WHERE email IS NULL". The value is a query returning a single
+	// value, optionally preceded by "expect=VALUE", the value it must return, "0" by default. It
+	// may be repeated.
+	DirectiveVerify = "verify"
+)
+
+var supportedDirectives = map[string]struct{}{
+	DirectiveTombstone:               {},
+	DirectiveRequiresGoose:           {},
+	DirectiveRequiresDB:              {},
+	DirectiveExtension:               {},
+	DirectiveAfter:                   {},
+	DirectivePartition:               {},
+	DirectiveNotValid:                {},
+	DirectiveRefreshMaterializedView: {},
+	DirectiveExpectDuration:          {},
+	DirectiveAffects:                 {},
+	DirectiveImpact:                  {},
+	DirectiveMaxAffected:             {},
+	DirectiveDestructive:             {},
+	DirectiveSnapshot:                {},
+	DirectiveLoad:                    {},
+	DirectiveRole:                    {},
+	DirectiveTitle:                   {},
+	DirectiveOwner:                   {},
+	DirectivePrecondition:            {},
+	DirectiveVerify:                  {},
+}
+
+// ParseDirectives returns all directives in the migration, in the order they appear.
+func ParseDirectives(r io.Reader) ([]Directive, error) {
+	scanBufPtr := bufferPool.Get().(*[]byte)
+	scanBuf := *scanBufPtr
+	defer bufferPool.Put(scanBufPtr)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(scanBuf, scanBufSize)
+
+	var directives []Directive
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "--") || !strings.Contains(line, "+goose") {
+			continue
+		}
+		if d, ok := extractDirective(line); ok {
+			directives = append(directives, d)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan migration: %w", err)
+	}
+	return directives, nil
+}
+
+// LookupDirective returns the first directive with the given name.
+func LookupDirective(directives []Directive, name string) (Directive, bool) {
+	for _, d := range directives {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Directive{}, false
+}
+
+// extractDirective returns the directive on the given annotation line, if the line holds a
+// supported directive. Directive names are matched case-insensitively.
+func extractDirective(line string) (Directive, bool) {
+	cmd := strings.TrimSpace(strings.TrimPrefix(line, "--"))
+	cmd, ok := strings.CutPrefix(cmd, "+goose")
+	if !ok {
+		return Directive{}, false
+	}
+	cmd = strings.TrimSpace(cmd)
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 {
+		return Directive{}, false
+	}
+	name := strings.ToLower(fields[0])
+	value := strings.TrimPrefix(cmd, fields[0])
+	if _, ok := supportedDirectives[name]; !ok {
+		return Directive{}, false
+	}
+	return Directive{Name: name, Value: strings.TrimSpace(value)}, true
+}
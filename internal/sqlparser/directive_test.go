@@ -0,0 +1,79 @@
+package sqlparser_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDirectives(t *testing.T) {
+	t.Parallel()
+
+	directives, err := sqlparser.ParseDirectives(strings.NewReader(`-- +goose TOMBSTONE  replaced by 00012_users.sql
+-- +goose Up
+-- +goose unknown value
+-- +goose requires-goose >= v3.20
+-- +goose after R__a.sql, R__b.sql
+-- +goose refresh-materialized-view daily_totals concurrently
+-- +goose expect-duration 30s
+-- +goose affects users, orders
+-- +goose impact heavy
+-- +goose max-affected 10000
+-- +goose destructive
+-- +goose snapshot roles
+-- +goose load table=users file=users.csv
+-- +goose role dba_migrator
+SELECT 1;
+`))
+	require.NoError(t, err)
+	require.Equal(t, []sqlparser.Directive{
+		{Name: sqlparser.DirectiveTombstone, Value: "replaced by 00012_users.sql"},
+		{Name: sqlparser.DirectiveRequiresGoose, Value: ">= v3.20"},
+		{Name: sqlparser.DirectiveAfter, Value: "R__a.sql, R__b.sql"},
+		{Name: sqlparser.DirectiveRefreshMaterializedView, Value: "daily_totals concurrently"},
+		{Name: sqlparser.DirectiveExpectDuration, Value: "30s"},
+		{Name: sqlparser.DirectiveAffects, Value: "users, orders"},
+		{Name: sqlparser.DirectiveImpact, Value: "heavy"},
+		{Name: sqlparser.DirectiveMaxAffected, Value: "10000"},
+		{Name: sqlparser.DirectiveDestructive, Value: ""},
+		{Name: sqlparser.DirectiveSnapshot, Value: "roles"},
+		{Name: sqlparser.DirectiveLoad, Value: "table=users file=users.csv"},
+		{Name: sqlparser.DirectiveRole, Value: "dba_migrator"},
+	}, directives)
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveTombstone)
+	require.True(t, ok)
+	require.Equal(t, "replaced by 00012_users.sql", d.Value)
+	_, ok = sqlparser.LookupDirective(directives, "other")
+	require.False(t, ok)
+}
+
+func TestParseTombstone(t *testing.T) {
+	t.Parallel()
+
+	mapFS := fstest.MapFS{
+		// Tombstones may drop the original statements and the Up annotation entirely.
+		"001_tombstone.sql": {Data: []byte("-- +goose tombstone\n")},
+		"002_tombstone_with_sql.sql": {Data: []byte(`-- +goose Up
+-- +goose tombstone retired
+CREATE TABLE foo (id int);
+`)},
+	}
+	for _, name := range []string{"001_tombstone.sql", "002_tombstone_with_sql.sql"} {
+		parsed, err := sqlparser.ParseAllFromFS(mapFS, name, false)
+		require.NoError(t, err)
+		require.True(t, parsed.Tombstone)
+		require.True(t, parsed.UseTx)
+		require.Empty(t, parsed.Up)
+		require.Empty(t, parsed.Down)
+	}
+	// Directives are skipped when parsing statements.
+	stmts, _, err := sqlparser.ParseSQLMigration(strings.NewReader(`-- +goose Up
+-- +goose tombstone
+SELECT 1;
+`), sqlparser.DirectionUp, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"SELECT 1;"}, stmts)
+}
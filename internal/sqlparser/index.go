@@ -0,0 +1,33 @@
+package sqlparser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var createIndexRe = regexp.MustCompile(`(?is)^(CREATE\s+(?:UNIQUE\s+)?INDEX\s+)(CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(\S+)\s+ON\s+(ONLY\s+)?(\S+)`)
+
+// ConcurrentIndex returns a Postgres CREATE INDEX statement rewritten to build the index
+// CONCURRENTLY, unless it already does, and the name of the index, qualified by the schema of its
+// table, if any. It reports false for other statements, unnamed indexes and indexes ON ONLY a
+// table, which cannot be built concurrently. Leading comments are kept.
+func ConcurrentIndex(stmt string) (string, string, bool) {
+	offset := len(stmt) - len(skipLeadingComments(stmt))
+	loc := createIndexRe.FindStringSubmatchIndex(stmt[offset:])
+	if loc == nil || loc[8] >= 0 {
+		return stmt, "", false
+	}
+	name := stmt[offset+loc[6] : offset+loc[7]]
+	if strings.EqualFold(name, "ON") {
+		return stmt, "", false
+	}
+	table := stmt[offset+loc[10] : offset+loc[11]]
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		name = table[:i] + "." + name
+	}
+	if loc[4] >= 0 {
+		return stmt, name, true
+	}
+	at := offset + loc[3]
+	return stmt[:at] + "CONCURRENTLY " + stmt[at:], name, true
+}
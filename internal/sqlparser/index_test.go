@@ -0,0 +1,49 @@
+package sqlparser_test
+
+import (
+	"testing"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentIndex(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		stmt string
+		want string
+		name string
+	}{
+		{
+			"-- lookups by email\nCREATE UNIQUE INDEX users_email ON users (email);",
+			"-- lookups by email\nCREATE UNIQUE INDEX CONCURRENTLY users_email ON users (email);",
+			"users_email",
+		},
+		{
+			"create index if not exists orders_total on app.orders using btree (total);",
+			"create index CONCURRENTLY if not exists orders_total on app.orders using btree (total);",
+			"app.orders_total",
+		},
+		{
+			"CREATE INDEX CONCURRENTLY users_name ON users (name);",
+			"CREATE INDEX CONCURRENTLY users_name ON users (name);",
+			"users_name",
+		},
+	} {
+		got, name, ok := sqlparser.ConcurrentIndex(tc.stmt)
+		require.True(t, ok, tc.stmt)
+		require.Equal(t, tc.want, got)
+		require.Equal(t, tc.name, name)
+	}
+	for _, stmt := range []string{
+		"CREATE INDEX ON users (name);",
+		"CREATE INDEX events_created_at ON ONLY events (created_at);",
+		"CREATE TABLE users (id INTEGER);",
+		"DROP INDEX users_name;",
+	} {
+		got, _, ok := sqlparser.ConcurrentIndex(stmt)
+		require.False(t, ok, stmt)
+		require.Equal(t, stmt, got)
+	}
+}
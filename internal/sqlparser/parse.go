@@ -1,8 +1,11 @@
 package sqlparser
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"io/fs"
+	"strings"
 
 	"go.uber.org/multierr"
 	"golang.org/x/sync/errgroup"
@@ -11,10 +14,33 @@ import (
 type ParsedSQL struct {
 	UseTx    bool
 	Up, Down []string
+
+	// Directives are the migration-level directives found in the file, see [Directive].
+	Directives []Directive
+	// Tombstone is true if the migration has a tombstone directive. Tombstones are not required
+	// to have Up or Down sections, and Up and Down are always empty.
+	Tombstone bool
+
+	// UpCount and DownCount are the number of Up and Down statements. They are only set by
+	// [ScanAllFromFS], which leaves Up and Down empty.
+	UpCount, DownCount int
+	// HasDown is true if the migration has a Down annotation, even if the Down section has no
+	// statements. It is only set by [ParseAllFromFS].
+	HasDown bool
 }
 
 func ParseAllFromFS(fsys fs.FS, filename string, debug bool) (*ParsedSQL, error) {
 	parsedSQL := new(ParsedSQL)
+	directives, err := parseDirectives(fsys, filename)
+	if err != nil {
+		return nil, err
+	}
+	parsedSQL.Directives = directives
+	if _, ok := LookupDirective(directives, DirectiveTombstone); ok {
+		parsedSQL.Tombstone = true
+		parsedSQL.UseTx = true
+		return parsedSQL, nil
+	}
 	// TODO(mf): parse is called twice, once for up and once for down. This is inefficient. It
 	// should be possible to parse both directions in one pass. Also, UseTx is set once (but
 	// returned twice), which is unnecessary and potentially error-prone if the two calls to
@@ -37,12 +63,87 @@ func ParseAllFromFS(fsys fs.FS, filename string, debug bool) (*ParsedSQL, error)
 		parsedSQL.Down = down
 		return nil
 	})
+	g.Go(func() error {
+		hasDown, err := hasDownAnnotation(fsys, filename)
+		if err != nil {
+			return err
+		}
+		parsedSQL.HasDown = hasDown
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return parsedSQL, nil
+}
+
+// ScanAllFromFS validates the migration like [ParseAllFromFS] and returns its directives, UseTx and
+// statement counts, without keeping its statements in memory. Use [StreamFromFS] to read the
+// statements of a direction when they are needed, e.g., for migrations too large to load.
+func ScanAllFromFS(fsys fs.FS, filename string, debug bool) (*ParsedSQL, error) {
+	parsedSQL := new(ParsedSQL)
+	directives, err := parseDirectives(fsys, filename)
+	if err != nil {
+		return nil, err
+	}
+	parsedSQL.Directives = directives
+	if _, ok := LookupDirective(directives, DirectiveTombstone); ok {
+		parsedSQL.Tombstone = true
+		parsedSQL.UseTx = true
+		return parsedSQL, nil
+	}
+	var g errgroup.Group
+	g.Go(func() error {
+		useTx, err := stream(fsys, filename, DirectionUp, debug, func(string) error {
+			parsedSQL.UpCount++
+			return nil
+		})
+		parsedSQL.UseTx = useTx
+		return err
+	})
+	g.Go(func() error {
+		_, err := stream(fsys, filename, DirectionDown, debug, func(string) error {
+			parsedSQL.DownCount++
+			return nil
+		})
+		return err
+	})
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
 	return parsedSQL, nil
 }
 
+// StreamFromFS calls fn with each statement of the migration in the given direction, as it is
+// read from fsys, see [StreamSQLMigration]. The migration should be validated first, e.g., with
+// [ScanAllFromFS].
+func StreamFromFS(fsys fs.FS, filename string, direction Direction, debug bool, fn func(stmt string) error) error {
+	_, err := stream(fsys, filename, direction, debug, fn)
+	return err
+}
+
+func stream(fsys fs.FS, filename string, direction Direction, debug bool, fn func(stmt string) error) (_ bool, retErr error) {
+	r, err := fsys.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, r.Close())
+	}()
+	var fnErr error
+	useTx, err := StreamSQLMigration(r, direction, debug, func(stmt string) error {
+		fnErr = fn(stmt)
+		return fnErr
+	})
+	if err != nil {
+		if fnErr != nil {
+			return false, fnErr
+		}
+		return false, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return useTx, nil
+}
+
 func parse(fsys fs.FS, filename string, direction Direction, debug bool) (_ []string, _ bool, retErr error) {
 	r, err := fsys.Open(filename)
 	if err != nil {
@@ -57,3 +158,58 @@ func parse(fsys fs.FS, filename string, direction Direction, debug bool) (_ []st
 	}
 	return stmts, useTx, nil
 }
+
+func parseDirectives(fsys fs.FS, filename string) (_ []Directive, retErr error) {
+	r, err := fsys.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, r.Close())
+	}()
+	directives, err := ParseDirectives(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return directives, nil
+}
+
+// hasDownAnnotation reports whether the migration has a Down annotation, see [HasDown].
+func hasDownAnnotation(fsys fs.FS, filename string) (_ bool, retErr error) {
+	r, err := fsys.Open(filename)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, r.Close())
+	}()
+	hasDown, err := HasDown(r)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+	return hasDown, nil
+}
+
+// HasDown reports whether the migration has a Down annotation, even if the Down section has no
+// statements. Invalid annotations are ignored, they are reported when the migration is parsed.
+func HasDown(r io.Reader) (bool, error) {
+	scanBufPtr := bufferPool.Get().(*[]byte)
+	scanBuf := *scanBufPtr
+	defer bufferPool.Put(scanBufPtr)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(scanBuf, scanBufSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "--") || !strings.Contains(line, "+goose") {
+			continue
+		}
+		if a, err := extractAnnotation(line); err == nil && a == annotationDown {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to scan migration: %w", err)
+	}
+	return false, nil
+}
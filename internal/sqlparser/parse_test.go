@@ -1,6 +1,7 @@
 package sqlparser_test
 
 import (
+	"errors"
 	"os"
 	"testing"
 	"testing/fstest"
@@ -54,18 +55,68 @@ DROP TABLE foo;
 		parsedSQL, err := sqlparser.ParseAllFromFS(mapFS, "001_foo.sql", false)
 		require.NoError(t, err)
 		assertParsedSQL(t, parsedSQL, true, 0, 0)
+		require.False(t, parsedSQL.HasDown)
 		parsedSQL, err = sqlparser.ParseAllFromFS(mapFS, "002_bar.sql", false)
 		require.NoError(t, err)
 		assertParsedSQL(t, parsedSQL, true, 0, 0)
+		require.True(t, parsedSQL.HasDown)
 		parsedSQL, err = sqlparser.ParseAllFromFS(mapFS, "003_baz.sql", false)
 		require.NoError(t, err)
 		assertParsedSQL(t, parsedSQL, true, 2, 1)
+		require.True(t, parsedSQL.HasDown)
 		parsedSQL, err = sqlparser.ParseAllFromFS(mapFS, "004_qux.sql", false)
 		require.NoError(t, err)
 		assertParsedSQL(t, parsedSQL, false, 1, 1)
+		require.True(t, parsedSQL.HasDown)
 	})
 }
 
+func TestScanAllFromFS(t *testing.T) {
+	t.Parallel()
+
+	mapFS := fstest.MapFS{
+		"001_foo.sql": newFile(`
+-- +goose NO TRANSACTION
+-- +goose Up
+CREATE TABLE foo (id int);
+CREATE TABLE bar (id int);
+-- +goose Down
+DROP TABLE bar;
+`),
+		"002_bar.sql": newFile(`
+-- +goose Up
+CREATE TABLE foo (id int);
+-- +goose StatementBegin
+CREATE FUNCTION f() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;
+`),
+	}
+	parsedSQL, err := sqlparser.ScanAllFromFS(mapFS, "001_foo.sql", false)
+	require.NoError(t, err)
+	assertParsedSQL(t, parsedSQL, false, 0, 0)
+	require.Equal(t, 2, parsedSQL.UpCount)
+	require.Equal(t, 1, parsedSQL.DownCount)
+	// Errors at the end of the file are found before any statement is streamed for execution.
+	_, err = sqlparser.ScanAllFromFS(mapFS, "002_bar.sql", false)
+	require.ErrorContains(t, err, "missing '-- +goose StatementEnd' annotation")
+
+	var got []string
+	err = sqlparser.StreamFromFS(mapFS, "001_foo.sql", sqlparser.DirectionUp, false, func(stmt string) error {
+		got = append(got, stmt)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"CREATE TABLE foo (id int);", "CREATE TABLE bar (id int);"}, got)
+	// An error of fn stops streaming and is returned as is.
+	stop := errors.New("stop")
+	var calls int
+	err = sqlparser.StreamFromFS(mapFS, "001_foo.sql", sqlparser.DirectionUp, false, func(string) error {
+		calls++
+		return stop
+	})
+	require.Equal(t, stop, err)
+	require.Equal(t, 1, calls)
+}
+
 func assertParsedSQL(t *testing.T, got *sqlparser.ParsedSQL, useTx bool, up, down int) {
 	t.Helper()
 	require.NotNil(t, got)
@@ -101,6 +101,27 @@ var bufferPool = sync.Pool{
 // 'StatementBegin' and 'StatementEnd' to allow the script to
 // tell us to ignore semicolons.
 func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []string, useTx bool, err error) {
+	useTx, err = StreamSQLMigration(r, direction, debug, func(stmt string) error {
+		stmts = append(stmts, stmt)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return stmts, useTx, nil
+}
+
+// StreamSQLMigration parses the statements of r like [ParseSQLMigration], but calls fn with each
+// statement as soon as it is parsed instead of returning them all, so memory use is bounded by the
+// largest statement rather than the size of the migration. An error returned by fn stops parsing
+// and is returned as is.
+//
+// Since r is read once, errors later in the migration, such as a missing StatementEnd annotation,
+// are only returned after fn was called for the preceding statements, and useTx, which a NO
+// TRANSACTION annotation anywhere in the migration clears, is only known once r is read. Callers
+// that execute statements should read the migration twice: once with a no-op fn to validate it,
+// then again to execute its statements.
+func StreamSQLMigration(r io.Reader, direction Direction, debug bool, fn func(stmt string) error) (useTx bool, err error) {
 	scanBufPtr := bufferPool.Get().(*[]byte)
 	scanBuf := *scanBufPtr
 	defer bufferPool.Put(scanBufPtr)
@@ -129,7 +150,7 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 
 			cmd, err = extractAnnotation(line)
 			if err != nil {
-				return nil, false, fmt.Errorf("failed to parse annotation line %q: %w", line, err)
+				return false, fmt.Errorf("failed to parse annotation line %q: %w", line, err)
 			}
 
 			switch cmd {
@@ -138,7 +159,7 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 				case start:
 					stateMachine.set(gooseUp)
 				default:
-					return nil, false, fmt.Errorf("duplicate '-- +goose Up' annotations; stateMachine=%d, see https://github.com/pressly/goose#sql-migrations", stateMachine.state)
+					return false, fmt.Errorf("duplicate '-- +goose Up' annotations; stateMachine=%d, see https://github.com/pressly/goose#sql-migrations", stateMachine.state)
 				}
 				continue
 
@@ -149,11 +170,11 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 					// previous up annotation. This is an error, because we expect the SQL query to be terminated by a semicolon
 					// and the buffer to have been reset.
 					if bufferRemaining := strings.TrimSpace(buf.String()); len(bufferRemaining) > 0 {
-						return nil, false, missingSemicolonError(stateMachine.state, direction, bufferRemaining)
+						return false, missingSemicolonError(stateMachine.state, direction, bufferRemaining)
 					}
 					stateMachine.set(gooseDown)
 				default:
-					return nil, false, fmt.Errorf("must start with '-- +goose Up' annotation, stateMachine=%d, see https://github.com/pressly/goose#sql-migrations", stateMachine.state)
+					return false, fmt.Errorf("must start with '-- +goose Up' annotation, stateMachine=%d, see https://github.com/pressly/goose#sql-migrations", stateMachine.state)
 				}
 				continue
 
@@ -164,7 +185,7 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 				case gooseDown, gooseStatementEndDown:
 					stateMachine.set(gooseStatementBeginDown)
 				default:
-					return nil, false, fmt.Errorf("'-- +goose StatementBegin' must be defined after '-- +goose Up' or '-- +goose Down' annotation, stateMachine=%d, see https://github.com/pressly/goose#sql-migrations", stateMachine.state)
+					return false, fmt.Errorf("'-- +goose StatementBegin' must be defined after '-- +goose Up' or '-- +goose Down' annotation, stateMachine=%d, see https://github.com/pressly/goose#sql-migrations", stateMachine.state)
 				}
 				continue
 
@@ -175,7 +196,7 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 				case gooseStatementBeginDown:
 					stateMachine.set(gooseStatementEndDown)
 				default:
-					return nil, false, errors.New("'-- +goose StatementEnd' must be defined after '-- +goose StatementBegin', see https://github.com/pressly/goose#sql-migrations")
+					return false, errors.New("'-- +goose StatementEnd' must be defined after '-- +goose StatementBegin', see https://github.com/pressly/goose#sql-migrations")
 				}
 
 			case annotationNoTransaction:
@@ -190,8 +211,11 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 				useEnvsub = false
 				continue
 
+			case annotationDirective:
+				continue
+
 			default:
-				return nil, false, fmt.Errorf("unknown annotation: %q", cmd)
+				return false, fmt.Errorf("unknown annotation: %q", cmd)
 			}
 		}
 		// Once we've started parsing a statement the buffer is no longer empty,
@@ -211,13 +235,13 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 			if useEnvsub {
 				expanded, err := interpolate.Interpolate(&envWrapper{}, line)
 				if err != nil {
-					return nil, false, fmt.Errorf("variable substitution failed: %w:\n%s", err, line)
+					return false, fmt.Errorf("variable substitution failed: %w:\n%s", err, line)
 				}
 				line = expanded
 			}
 			// Write SQL line to a buffer.
 			if _, err := buf.WriteString(line + "\n"); err != nil {
-				return nil, false, fmt.Errorf("failed to write to buf: %w", err)
+				return false, fmt.Errorf("failed to write to buf: %w", err)
 			}
 		}
 		// Read SQL body one by line, if we're in the right direction.
@@ -239,51 +263,59 @@ func ParseSQLMigration(r io.Reader, direction Direction, debug bool) (stmts []st
 				continue
 			}
 		default:
-			return nil, false, fmt.Errorf("failed to parse migration: unexpected state %d on line %q, see https://github.com/pressly/goose#sql-migrations", stateMachine.state, line)
+			return false, fmt.Errorf("failed to parse migration: unexpected state %d on line %q, see https://github.com/pressly/goose#sql-migrations", stateMachine.state, line)
 		}
 
 		switch stateMachine.get() {
 		case gooseUp:
 			if endsWithSemicolon(line) {
-				stmts = append(stmts, cleanupStatement(buf.String()))
+				if err := fn(cleanupStatement(buf.String())); err != nil {
+					return false, err
+				}
 				buf.Reset()
 				stateMachine.print("store simple Up query")
 			}
 		case gooseDown:
 			if endsWithSemicolon(line) {
-				stmts = append(stmts, cleanupStatement(buf.String()))
+				if err := fn(cleanupStatement(buf.String())); err != nil {
+					return false, err
+				}
 				buf.Reset()
 				stateMachine.print("store simple Down query")
 			}
 		case gooseStatementEndUp:
-			stmts = append(stmts, cleanupStatement(buf.String()))
+			if err := fn(cleanupStatement(buf.String())); err != nil {
+				return false, err
+			}
 			buf.Reset()
 			stateMachine.print("store Up statement")
 			stateMachine.set(gooseUp)
 		case gooseStatementEndDown:
-			stmts = append(stmts, cleanupStatement(buf.String()))
+			if err := fn(cleanupStatement(buf.String())); err != nil {
+				return false, err
+			}
 			buf.Reset()
 			stateMachine.print("store Down statement")
 			stateMachine.set(gooseDown)
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return nil, false, fmt.Errorf("failed to scan migration: %w", err)
+		return false, fmt.Errorf("failed to scan migration: %w", err)
 	}
 	// EOF
 
 	switch stateMachine.get() {
 	case start:
-		return nil, false, errors.New("failed to parse migration: must start with '-- +goose Up' annotation, see https://github.com/pressly/goose#sql-migrations")
+		return false, errors.New("failed to parse migration: must start with '-- +goose Up' annotation, see https://github.com/pressly/goose#sql-migrations")
 	case gooseStatementBeginUp, gooseStatementBeginDown:
-		return nil, false, errors.New("failed to parse migration: missing '-- +goose StatementEnd' annotation")
+		return false, errors.New("failed to parse migration: missing '-- +goose StatementEnd' annotation")
 	}
 
 	if bufferRemaining := strings.TrimSpace(buf.String()); len(bufferRemaining) > 0 {
-		return nil, false, missingSemicolonError(stateMachine.state, direction, bufferRemaining)
+		return false, missingSemicolonError(stateMachine.state, direction, bufferRemaining)
 	}
 
-	return stmts, useTx, nil
+	return useTx, nil
 }
 
 type annotation string
@@ -296,6 +328,10 @@ const (
 	annotationNoTransaction  annotation = "NO TRANSACTION"
 	annotationEnvsubOn       annotation = "ENVSUB ON"
 	annotationEnvsubOff      annotation = "ENVSUB OFF"
+
+	// annotationDirective is returned for any supported directive, see [Directive]. Directives are
+	// skipped when parsing statements.
+	annotationDirective annotation = "directive"
 )
 
 var supportedAnnotations = map[annotation]struct{}{
@@ -346,6 +382,9 @@ func extractAnnotation(line string) (annotation, error) {
 			return s, nil
 		}
 	}
+	if _, ok := extractDirective(line); ok {
+		return annotationDirective, nil
+	}
 
 	return "", fmt.Errorf("%q not supported: %w", cmd, errInvalidAnnotation)
 }
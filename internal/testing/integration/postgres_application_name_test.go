@@ -0,0 +1,41 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/internal/testing/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresApplicationName(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	db, cleanup, err := testdb.NewPostgres()
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	// A single idle connection makes sure the reset connection is the one reused below.
+	db.SetMaxIdleConns(1)
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE app_names (name TEXT);\nINSERT INTO app_names SELECT current_setting('application_name');\n")},
+	}
+	p, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithApplicationName("goose migrations"))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	var name string
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT name FROM app_names").Scan(&name))
+	require.Equal(t, "goose migrations", name)
+	// The application name is reset before the connection is returned to the pool.
+	var current sql.NullString
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT current_setting('application_name')").Scan(&current))
+	require.NotEqual(t, "goose migrations", current.String)
+}
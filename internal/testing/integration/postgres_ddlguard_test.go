@@ -0,0 +1,52 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/ddlguard"
+	"github.com/pressly/goose/v3/internal/testing/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresDDLGuard(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	db, cleanup, err := testdb.NewPostgres()
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	guard := goose.NewGoMigration(1,
+		&goose.GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error { return ddlguard.Install(ctx, tx) }},
+		&goose.GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error { return ddlguard.Uninstall(ctx, tx) }},
+	)
+	fsys := fstest.MapFS{
+		"00002_users.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n")},
+	}
+	p, err := goose.NewProvider(goose.DialectPostgres, db, fsys,
+		goose.WithGoMigrations(guard),
+		goose.WithSessionTag(true),
+	)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+
+	// DDL outside of goose is rejected, including on the connection goose used, once it is reset.
+	_, err = db.ExecContext(ctx, "CREATE TABLE orders (id INTEGER)")
+	require.ErrorContains(t, err, "CREATE TABLE must be run through a goose migration")
+	_, err = db.ExecContext(ctx, "DROP TABLE users")
+	require.Error(t, err)
+
+	// The guard can be rolled back through goose.
+	_, err = p.DownTo(ctx, 0)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, "CREATE TABLE orders (id INTEGER)")
+	require.NoError(t, err)
+}
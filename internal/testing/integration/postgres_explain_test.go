@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/internal/testing/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresExplainDML(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	db, cleanup, err := testdb.NewPostgres()
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": {Data: []byte(`-- +goose Up
+CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT, active BOOLEAN);
+INSERT INTO users SELECT i, 'user' || i, true FROM generate_series(1, 10000) AS i;
+ANALYZE users;
+`)},
+		"00002_b.sql": {Data: []byte(`-- +goose Up
+UPDATE users SET active = false WHERE id = 1;
+`)},
+		"00003_c.sql": {Data: []byte(`-- +goose Up
+UPDATE users SET active = false WHERE email = 'user2';
+`)},
+	}
+	p, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithExplainDML(1000))
+	require.NoError(t, err)
+	// An update by primary key uses the index.
+	_, err = p.UpTo(ctx, 2)
+	require.NoError(t, err)
+	// An update on an unindexed column scans the table, and is not run.
+	_, err = p.Up(ctx)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, goose.ErrFullTableScan))
+	var active bool
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT active FROM users WHERE id = 2").Scan(&active))
+	require.True(t, active)
+
+	p, err = goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithExplainDML(1000), goose.WithExplainWarnOnly(true))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT active FROM users WHERE id = 2").Scan(&active))
+	require.False(t, active)
+}
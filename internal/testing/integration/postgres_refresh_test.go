@@ -0,0 +1,51 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/internal/testing/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresRefreshMaterializedView(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	db, cleanup, err := testdb.NewPostgres()
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": {Data: []byte(`-- +goose Up
+CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER);
+CREATE MATERIALIZED VIEW order_totals AS SELECT COUNT(*) AS n, SUM(total) AS total FROM orders;
+CREATE UNIQUE INDEX ON order_totals (n);
+`)},
+		"00002_b.sql": {Data: []byte(`-- +goose refresh-materialized-view order_totals concurrently
+-- +goose Up
+INSERT INTO orders VALUES (1, 10), (2, 20);
+`)},
+		"00003_c.sql": {Data: []byte(`-- +goose refresh-materialized-view order_totals
+-- +goose Up
+INSERT INTO orders VALUES (3, 30);
+`)},
+	}
+	p, err := goose.NewProvider(goose.DialectPostgres, db, fsys)
+	require.NoError(t, err)
+	_, err = p.UpTo(ctx, 2)
+	require.NoError(t, err)
+	var total int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT total FROM order_totals").Scan(&total))
+	require.Equal(t, 30, total)
+
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT total FROM order_totals").Scan(&total))
+	require.Equal(t, 60, total)
+}
@@ -0,0 +1,46 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/internal/testing/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresSearchPath(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	db, cleanup, err := testdb.NewPostgres()
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER);\n")},
+		"00002_b.sql": {Data: []byte("-- +goose NO TRANSACTION\n-- +goose Up\nINSERT INTO users VALUES (1);\n")},
+	}
+	for _, tenant := range []string{"tenant_a", "tenant_b"} {
+		_, err := db.ExecContext(ctx, "CREATE SCHEMA "+tenant)
+		require.NoError(t, err)
+		p, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSearchPath(tenant))
+		require.NoError(t, err)
+		res, err := p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+tenant+".users").Scan(&n))
+		require.Equal(t, 1, n)
+		require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+tenant+".goose_db_version").Scan(&n))
+		require.Equal(t, 3, n)
+	}
+	// Nothing leaked into the default schema.
+	var exists bool
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT to_regclass('public.goose_db_version') IS NOT NULL").Scan(&exists))
+	require.False(t, exists)
+}
@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/internal/testing/testdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresShadowAuto(t *testing.T) {
+	t.Parallel()
+	if testing.Short() {
+		t.Skip("skipping test in short mode.")
+	}
+
+	const port = 15437
+	db, cleanup, err := testdb.NewPostgres(testdb.WithBindPort(port))
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	server := fmt.Sprintf("host=localhost port=%d user=%s password=%s dbname=%s sslmode=disable",
+		port, testdb.POSTGRES_USER, testdb.POSTGRES_PASSWORD, testdb.POSTGRES_DB)
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": {Data: []byte("-- +goose Up\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n")},
+		"00002_b.sql": {Data: []byte("-- +goose Up\nINSERT INTO missing VALUES (1);\n")},
+	}
+	countShadows := func() int {
+		var n int
+		require.NoError(t, db.QueryRowContext(ctx,
+			"SELECT COUNT(*) FROM pg_database WHERE datname LIKE 'goose_shadow_%'").Scan(&n))
+		return n
+	}
+	p, err := goose.NewProvider(goose.DialectPostgres, db, fsys,
+		goose.WithShadow(goose.ShadowAuto), goose.WithShadowServer(server))
+	require.NoError(t, err)
+	// The failing migration is caught on the shadow database, which is dropped afterwards.
+	_, err = p.Up(ctx)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, goose.ErrShadowFailed))
+	require.Zero(t, countShadows())
+	var exists bool
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT to_regclass('users') IS NOT NULL").Scan(&exists))
+	require.False(t, exists)
+
+	_, err = p.UpTo(ctx, 1)
+	require.NoError(t, err)
+	require.Zero(t, countShadows())
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT to_regclass('users') IS NOT NULL").Scan(&exists))
+	require.True(t, exists)
+}
@@ -0,0 +1,61 @@
+// Package kms defines the KMS interface goose uses to encrypt sensitive values before persisting
+// them, such as run labels with usernames or hostnames, and implements it with a local AES-GCM key.
+//
+// To use a cloud key management service, implement [KMS] with its encrypt and decrypt calls.
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// KMS encrypts and decrypts values. Implementations must be safe for concurrent use.
+type KMS interface {
+	// Encrypt returns the ciphertext of plaintext.
+	Encrypt(ctx context.Context, plaintext []byte) ([]byte, error)
+	// Decrypt returns the plaintext of a ciphertext returned by Encrypt.
+	Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error)
+}
+
+// NewAESGCM returns a KMS that encrypts with AES-GCM and a local key of 16, 24 or 32 bytes, for
+// AES-128, AES-192 or AES-256. Each ciphertext starts with a random nonce.
+func NewAESGCM(key []byte) (KMS, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &aesGCM{aead: aead}, nil
+}
+
+type aesGCM struct {
+	aead cipher.AEAD
+}
+
+func (k *aesGCM) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize(), k.aead.NonceSize()+len(plaintext)+k.aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (k *aesGCM) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < k.aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:k.aead.NonceSize()], ciphertext[k.aead.NonceSize():]
+	plaintext, err := k.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
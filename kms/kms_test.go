@@ -0,0 +1,43 @@
+package kms_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/pressly/goose/v3/kms"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAESGCM(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	k, err := kms.NewAESGCM(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	ciphertext, err := k.Encrypt(ctx, []byte("alice@build-01"))
+	require.NoError(t, err)
+	require.NotContains(t, string(ciphertext), "alice")
+	plaintext, err := k.Decrypt(ctx, ciphertext)
+	require.NoError(t, err)
+	require.Equal(t, "alice@build-01", string(plaintext))
+
+	// Nonces are random, so the same value encrypts differently.
+	again, err := k.Encrypt(ctx, []byte("alice@build-01"))
+	require.NoError(t, err)
+	require.NotEqual(t, ciphertext, again)
+
+	// Another key, or a modified ciphertext, fails to decrypt.
+	other, err := kms.NewAESGCM(bytes.Repeat([]byte{2}, 32))
+	require.NoError(t, err)
+	_, err = other.Decrypt(ctx, ciphertext)
+	require.Error(t, err)
+	ciphertext[len(ciphertext)-1] ^= 1
+	_, err = k.Decrypt(ctx, ciphertext)
+	require.Error(t, err)
+	_, err = k.Decrypt(ctx, []byte("short"))
+	require.Error(t, err)
+
+	_, err = kms.NewAESGCM([]byte("too short"))
+	require.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package goose
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pressly/goose/v3/kms"
+)
+
+const (
+	// redactedLabel replaces the value of a redacted run label.
+	redactedLabel = "[redacted]"
+	// encryptedLabelPrefix is the prefix of the base64-encoded ciphertext of an encrypted run
+	// label.
+	encryptedLabelPrefix = "enc:"
+)
+
+// LabelProtection protects run labels with sensitive values, such as usernames or hostnames,
+// before they are persisted, to satisfy data-handling policies. See [WithLabelProtection].
+type LabelProtection struct {
+	// Redact are the keys of labels whose value is replaced with "[redacted]". The key is kept, so
+	// it is still visible that the label was set.
+	Redact []string
+	// Encrypt are the keys of labels whose value is encrypted with KMS and stored as "enc:"
+	// followed by the base64-encoded ciphertext.
+	Encrypt []string
+	// KMS encrypts the values of the labels in Encrypt and decrypts them when they are reported.
+	// It is required if Encrypt is not empty, see the kms package.
+	KMS kms.KMS
+}
+
+func (lp *LabelProtection) check() error {
+	if lp == nil {
+		return nil
+	}
+	redact := make(map[string]bool, len(lp.Redact))
+	for _, k := range lp.Redact {
+		if k == "" {
+			return errors.New("redacted label key must not be empty")
+		}
+		redact[k] = true
+	}
+	for _, k := range lp.Encrypt {
+		if k == "" {
+			return errors.New("encrypted label key must not be empty")
+		}
+		if redact[k] {
+			return fmt.Errorf("label %q cannot be both redacted and encrypted", k)
+		}
+	}
+	if len(lp.Encrypt) > 0 && lp.KMS == nil {
+		return errors.New("encrypted labels require a KMS")
+	}
+	return nil
+}
+
+// protect returns a copy of labels with the configured values redacted or encrypted. It returns
+// labels as is if lp is nil.
+func (lp *LabelProtection) protect(ctx context.Context, labels map[string]string) (map[string]string, error) {
+	if lp == nil || len(labels) == 0 {
+		return labels, nil
+	}
+	if err := lp.check(); err != nil {
+		return nil, err
+	}
+	protected := make(map[string]string, len(labels))
+	for k, v := range labels {
+		protected[k] = v
+	}
+	for _, k := range lp.Redact {
+		if _, ok := protected[k]; ok {
+			protected[k] = redactedLabel
+		}
+	}
+	for _, k := range lp.Encrypt {
+		v, ok := protected[k]
+		if !ok {
+			continue
+		}
+		ciphertext, err := lp.KMS.Encrypt(ctx, []byte(v))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt run label %q: %w", k, err)
+		}
+		protected[k] = encryptedLabelPrefix + base64.StdEncoding.EncodeToString(ciphertext)
+	}
+	return protected, nil
+}
+
+// reveal decrypts, in place, the encrypted values of the labels in Encrypt. Values that cannot be
+// decrypted, e.g., because they were encrypted with another key, are left as stored, so that
+// status can still be reported. It is a no-op if lp is nil or has no KMS.
+func (lp *LabelProtection) reveal(ctx context.Context, labels map[int64]map[string]string) {
+	if lp == nil || lp.KMS == nil {
+		return
+	}
+	for _, versionLabels := range labels {
+		for _, k := range lp.Encrypt {
+			encoded, ok := strings.CutPrefix(versionLabels[k], encryptedLabelPrefix)
+			if !ok {
+				continue
+			}
+			ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				continue
+			}
+			plaintext, err := lp.KMS.Decrypt(ctx, ciphertext)
+			if err != nil {
+				continue
+			}
+			versionLabels[k] = string(plaintext)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package goose
 
 import (
+	"io"
 	std "log"
+	"os"
 )
 
-var log Logger = &stdLogger{}
+var log Logger = &globalLogger{}
 
 // Logger is standard logger interface
 type Logger interface {
@@ -12,11 +14,48 @@ type Logger interface {
 	Printf(format string, v ...interface{})
 }
 
+var pkgLogger Logger = &stdLogger{}
+
 // SetLogger sets the logger for package output
+//
+// Deprecated: Use [NewProvider] with [WithLogger].
 func SetLogger(l Logger) {
-	log = l
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	pkgLogger = l
+}
+
+var pkgOutput io.Writer = os.Stdout
+
+// SetOutput sets the writer the machine-readable output of commands, such as the JSON output of
+// status, is written to. It defaults to os.Stdout, so that it can be piped to other tools apart
+// from log output.
+func SetOutput(w io.Writer) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	pkgOutput = w
+}
+
+// output returns the writer configured with [SetOutput].
+func output() io.Writer {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return pkgOutput
 }
 
+// globalLogger forwards to the logger configured with [SetLogger], so the package-level logger can
+// be swapped while other goroutines are logging.
+type globalLogger struct{}
+
+func (*globalLogger) current() Logger {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+	return pkgLogger
+}
+
+func (l *globalLogger) Fatalf(format string, v ...interface{}) { l.current().Fatalf(format, v...) }
+func (l *globalLogger) Printf(format string, v ...interface{}) { l.current().Printf(format, v...) }
+
 // stdLogger is a default logger that outputs to a stdlib's log.std logger.
 type stdLogger struct{}
 
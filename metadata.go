@@ -0,0 +1,116 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// recordRunLabels records labels for a version applied with the package-level functions, replacing
+// any labels from an earlier run.
+func recordRunLabels(ctx context.Context, db *sql.DB, version int64, labels map[string]string) error {
+	store, err := getMetadataStore()
+	if err != nil {
+		return err
+	}
+	if err := store.CreateMetadataTable(ctx, db); err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return fmt.Errorf("run labels are not supported by the current dialect: %w", err)
+		}
+		return err
+	}
+	if err := store.DeleteMetadata(ctx, db, version); err != nil {
+		return err
+	}
+	return insertLabels(ctx, store, db, version, labels)
+}
+
+// deleteMetadata removes the metadata recorded for a version that was rolled back with the
+// package-level functions. It is a no-op if there is no metadata table.
+func deleteMetadata(ctx context.Context, db *sql.DB, version int64) error {
+	store, err := getMetadataStore()
+	if err != nil {
+		return err
+	}
+	exists, err := store.MetadataTableExists(ctx, db)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	return store.DeleteMetadata(ctx, db, version)
+}
+
+// listRunLabels returns the run labels recorded for each version, see [listLabels].
+func listRunLabels(ctx context.Context, db *sql.DB) (map[int64]map[string]string, error) {
+	store, err := getMetadataStore()
+	if err != nil {
+		return nil, err
+	}
+	return listLabels(ctx, store, db)
+}
+
+// listRunChecksums returns the checksum recorded for each applied version. Versions applied
+// without recording a checksum are absent.
+func listRunChecksums(ctx context.Context, db *sql.DB) (map[int64]string, error) {
+	store, err := getMetadataStore()
+	if err != nil {
+		return nil, err
+	}
+	exists, err := store.MetadataTableExists(ctx, db)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	results, err := store.ListMetadata(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	checksums := make(map[int64]string)
+	for _, r := range results {
+		if r.Key == checksumKey {
+			checksums[r.Version] = r.Value
+		}
+	}
+	return checksums, nil
+}
+
+// hasRunCheckpoints reports whether a checkpoint is recorded for any version, i.e., a migration run
+// outside a transaction failed part way and was not completed since. See [WithCheckpoints].
+func hasRunCheckpoints(ctx context.Context, db *sql.DB) (bool, error) {
+	store, err := getMetadataStore()
+	if err != nil {
+		return false, err
+	}
+	exists, err := store.MetadataTableExists(ctx, db)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return false, nil
+		}
+		return false, err
+	}
+	if !exists {
+		return false, nil
+	}
+	results, err := store.ListMetadata(ctx, db)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range results {
+		if strings.HasPrefix(r.Key, checkpointKeyPrefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"io/fs"
 	"math"
-	"path"
 	"sort"
 	"strings"
 	"time"
@@ -37,6 +36,26 @@ func (ms Migrations) Less(i, j int) bool {
 	return ms[i].Version < ms[j].Version
 }
 
+// CompareMigrations orders migrations by version. Ties between migrations that share a version,
+// e.g., registered under different scopes or found in different directories, are broken by the
+// scope they were registered under, then by source. Strings are compared byte-wise, not by the
+// collation of a locale, so the order is the same on every machine.
+//
+// It returns a negative number if a comes before b, a positive number if a comes after b, and zero
+// if neither comes first.
+func CompareMigrations(a, b *Migration) int {
+	switch {
+	case a.Version < b.Version:
+		return -1
+	case a.Version > b.Version:
+		return 1
+	}
+	if c := strings.Compare(a.scope, b.scope); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Source, b.Source)
+}
+
 // Current gets the current migration.
 func (ms Migrations) Current(current int64) (*Migration, error) {
 	for i, migration := range ms {
@@ -130,6 +149,25 @@ func collectMigrationsFS(
 	dirpath string,
 	current, target int64,
 	registered map[string]map[int64]*Migration,
+) (Migrations, error) {
+	return collectMigrationsCached(nil, scope, fsys, dirpath, current, target, registered)
+}
+
+// collectMigrations collects the migrations in dir of the base filesystem, using the collect cache
+// of the options, if any.
+func collectMigrations(option *options, dir string, current, target int64) (Migrations, error) {
+	return collectMigrationsCached(option.collectCache, option.scope, getBaseFS(), dir, current, target, globalMigrationsSnapshot())
+}
+
+// collectMigrationsCached is collectMigrationsFS, listing directories with cache. A nil cache does
+// not cache.
+func collectMigrationsCached(
+	cache *CollectCache,
+	scope string,
+	fsys fs.FS,
+	dirpath string,
+	current, target int64,
+	registered map[string]map[int64]*Migration,
 ) (Migrations, error) {
 	if _, err := fs.Stat(fsys, dirpath); err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
@@ -139,9 +177,13 @@ func collectMigrationsFS(
 	}
 	var migrations Migrations
 	// SQL migration files.
-	sqlMigrationFiles, err := fs.Glob(fsys, path.Join(dirpath, "*.sql"))
-	if err != nil {
-		return nil, err
+	var sqlMigrationFiles []string
+	for _, pattern := range sqlFilePatterns {
+		files, err := cache.glob(fsys, dirpath, pattern)
+		if err != nil {
+			return nil, err
+		}
+		sqlMigrationFiles = append(sqlMigrationFiles, files...)
 	}
 	for _, file := range sqlMigrationFiles {
 		v, err := NumericComponent(file)
@@ -158,7 +200,7 @@ func collectMigrationsFS(
 		}
 	}
 	// Go migration files.
-	goMigrations, err := collectGoMigrations(scope, fsys, dirpath, registered, current, target)
+	goMigrations, err := collectGoMigrations(cache, scope, fsys, dirpath, registered, current, target)
 	if err != nil {
 		return nil, err
 	}
@@ -172,11 +214,21 @@ func collectMigrationsFS(
 // CollectMigrations returns all the valid looking migration scripts in the
 // migrations folder and go func registry, and key them by version.
 func CollectMigrations(scope, dirpath string, current, target int64) (Migrations, error) {
-	return collectMigrationsFS(scope, baseFS, dirpath, current, target, registeredGoMigrations)
+	return collectMigrationsFS(scope, getBaseFS(), dirpath, current, target, globalMigrationsSnapshot())
 }
 
 func sortAndConnectMigrations(migrations Migrations) Migrations {
-	sort.Sort(migrations)
+	// Sort with a total order first, so the duplicate reported does not depend on the order the
+	// migrations were collected in.
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return CompareMigrations(migrations[i], migrations[j]) < 0
+	})
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i-1].Version == migrations[i].Version {
+			panic(fmt.Sprintf("goose: duplicate version %v detected:\n%v\n%v",
+				migrations[i].Version, migrations[i-1].Source, migrations[i].Source))
+		}
+	}
 
 	// now that we're sorted in the appropriate direction,
 	// populate next and previous for each migration
@@ -212,7 +264,7 @@ func EnsureDBVersion(db *sql.DB) (int64, error) {
 // EnsureDBVersionContext retrieves the current version for this DB.
 // Create and initialize the DB version table if it doesn't exist.
 func EnsureDBVersionContext(ctx context.Context, db *sql.DB) (int64, error) {
-	dbMigrations, err := store.ListMigrations(ctx, db, TableName())
+	dbMigrations, err := getStore().ListMigrations(ctx, db, TableName())
 	if err != nil {
 		return 0, createVersionTable(ctx, db)
 	}
@@ -252,11 +304,11 @@ func createVersionTable(ctx context.Context, db *sql.DB) error {
 	if err != nil {
 		return err
 	}
-	if err := store.CreateVersionTable(ctx, txn, TableName()); err != nil {
+	if err := getStore().CreateVersionTable(ctx, txn, TableName()); err != nil {
 		_ = txn.Rollback()
 		return err
 	}
-	if err := store.InsertVersion(ctx, txn, TableName(), 0); err != nil {
+	if err := getStore().InsertVersion(ctx, txn, TableName(), 0); err != nil {
 		_ = txn.Rollback()
 		return err
 	}
@@ -292,6 +344,7 @@ func GetDBVersionContext(ctx context.Context, db *sql.DB) (int64, error) {
 // error. This is to prevent users from accidentally adding valid looking Go files to the migrations
 // folder without registering them.
 func collectGoMigrations(
+	cache *CollectCache,
 	scope string,
 	fsys fs.FS,
 	dirpath string,
@@ -306,7 +359,7 @@ func collectGoMigrations(
 			}
 		}
 	}
-	goFiles, err := fs.Glob(fsys, path.Join(dirpath, "*.go"))
+	goFiles, err := cache.glob(fsys, dirpath, "*.go")
 	if err != nil {
 		return nil, err
 	}
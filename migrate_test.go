@@ -28,6 +28,25 @@ func TestMigrationSort(t *testing.T) {
 	validateMigrationSort(t, ms, sorted)
 }
 
+func TestCompareMigrations(t *testing.T) {
+	t.Parallel()
+
+	a := &Migration{Version: 1, Source: "b/00001_a.sql"}
+	b := &Migration{Version: 1, Source: "a/00001_a.sql"}
+	c := &Migration{Version: 1, Source: "a/00001_a.go", scope: "billing"}
+	d := &Migration{Version: 2, Source: "00002_a.sql"}
+	require.Negative(t, CompareMigrations(b, a))
+	require.Negative(t, CompareMigrations(a, c))
+	require.Negative(t, CompareMigrations(c, d))
+	require.Positive(t, CompareMigrations(d, a))
+	require.Zero(t, CompareMigrations(a, &Migration{Version: 1, Source: "b/00001_a.sql"}))
+
+	// The duplicate reported does not depend on the order the migrations were collected in.
+	want := "goose: duplicate version 1 detected:\na/00001_a.sql\nb/00001_a.sql"
+	require.PanicsWithValue(t, want, func() { sortAndConnectMigrations(Migrations{d, a, b}) })
+	require.PanicsWithValue(t, want, func() { sortAndConnectMigrations(Migrations{b, d, a}) })
+}
+
 func newMigration(v int64, src string) *Migration {
 	return &Migration{Version: v, Previous: -1, Next: -1, Source: src}
 }
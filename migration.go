@@ -1,11 +1,15 @@
 package goose
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -31,15 +35,15 @@ func NewGoMigration(version int64, up, down *GoFunc) *Migration {
 	updateMode := func(f *GoFunc) *GoFunc {
 		// infer mode from function
 		if f.Mode == 0 {
-			if f.RunTx != nil && f.RunDB == nil {
+			if f.RunTx != nil && f.RunDB == nil && f.RunConn == nil {
 				f.Mode = TransactionEnabled
 			}
-			if f.RunTx == nil && f.RunDB != nil {
+			if f.RunTx == nil && (f.RunDB != nil || f.RunConn != nil) {
 				f.Mode = TransactionDisabled
 			}
-			// Always default to TransactionEnabled if both functions are nil. This is the most
+			// Always default to TransactionEnabled if all functions are nil. This is the most
 			// common use case.
-			if f.RunDB == nil && f.RunTx == nil {
+			if f.RunDB == nil && f.RunTx == nil && f.RunConn == nil {
 				f.Mode = TransactionEnabled
 			}
 		}
@@ -62,6 +66,9 @@ func NewGoMigration(version int64, up, down *GoFunc) *Migration {
 			m.UpFnContext = up.RunTx          // func(context.Context, *sql.Tx) error
 			m.UpFn = withoutContext(up.RunTx) // func(*sql.Tx) error
 		}
+		if up.RunConn != nil {
+			m.UpFnConnContext = up.RunConn // func(context.Context, *sql.Conn) error
+		}
 	}
 	if down != nil {
 		m.goDown = updateMode(down)
@@ -75,6 +82,9 @@ func NewGoMigration(version int64, up, down *GoFunc) *Migration {
 			m.DownFnContext = down.RunTx          // func(context.Context, *sql.Tx) error
 			m.DownFn = withoutContext(down.RunTx) // func(*sql.Tx) error
 		}
+		if down.RunConn != nil {
+			m.DownFnConnContext = down.RunConn // func(context.Context, *sql.Conn) error
+		}
 	}
 	return m
 }
@@ -91,6 +101,30 @@ type Migration struct {
 
 	UpFnContext, DownFnContext         GoMigrationContext
 	UpFnNoTxContext, DownFnNoTxContext GoMigrationNoTxContext
+	UpFnConnContext, DownFnConnContext GoMigrationConnContext
+
+	// Checksum is the checksum of a Go migration's source file, set with [WithChecksum]. It is
+	// empty if unknown. The checksum of SQL migrations is computed from the file instead.
+	Checksum string
+
+	// ExpectDuration is how long a Go migration is expected to run, set with [WithExpectDuration].
+	// Zero means no expectation. The expected duration of SQL migrations is declared with a
+	// "-- +goose expect-duration" directive in the file instead. See [WithSlowMigration].
+	ExpectDuration time.Duration
+
+	// Role is the database role a Go migration runs as, set with [WithRole]. Empty means the role
+	// of the connection. The role of SQL migrations is declared with a "-- +goose role" directive
+	// in the file instead. Only used by the Provider, on Postgres.
+	Role string
+
+	// Description is a human-readable description of a Go migration, set with [WithDescription].
+	// The title of SQL migrations is declared with a "-- +goose title" directive in the file
+	// instead.
+	Description string
+
+	// Owner is the team owning a Go migration, set with [WithOwner]. The owner of SQL migrations
+	// is declared with a "-- +goose owner" directive in the file instead.
+	Owner string
 
 	// These fields will be removed in a future major version. They are here for backwards
 	// compatibility and are an implementation detail.
@@ -109,6 +143,8 @@ type Migration struct {
 	DownFnNoTx GoMigrationNoTx // Deprecated: use DownFnNoTxContext instead.
 
 	noVersioning bool
+	// scope is the scope a Go migration was registered under, see [WithScope].
+	scope string
 
 	// These fields are used internally by goose and users are not expected to set them. Instead,
 	// use [NewGoMigration] to create a new go migration.
@@ -129,24 +165,89 @@ type sqlMigration struct {
 	UseTx bool
 	Up    []string
 	Down  []string
+	// Stream is true if Up and Down are not loaded and the statements are read from the file as
+	// they are executed, and UpCount and DownCount are the number of statements. Only used by the
+	// Provider, see [WithStreamSQL].
+	Stream             bool
+	UpCount, DownCount int
+	// Tombstone is true if the migration was retired with a "-- +goose tombstone" directive. Up
+	// and Down are always empty for tombstones.
+	Tombstone bool
+	// Refresh are the materialized views to refresh after the migration is applied. Only used by
+	// the Provider.
+	Refresh []materializedView
+	// ExpectDuration is the expected duration declared with a "-- +goose expect-duration"
+	// directive, or zero. Only used by the Provider.
+	ExpectDuration time.Duration
+	// Role is the database role declared with a "-- +goose role" directive, or empty. Only used by
+	// the Provider.
+	Role string
+	// RequiresDB is the database version requirement declared with a "-- +goose requires-db"
+	// directive, or nil. Only used by the Provider.
+	RequiresDB *dbRequirement
+	// Extensions are the Postgres extensions declared with "-- +goose extension" directives,
+	// created before the Up statements run. Only used by the Provider.
+	Extensions []ExtensionRequirement
+	// Validate are the statements validating the constraints that were rewritten to be added NOT
+	// VALID, set for migrations with a "-- +goose not-valid" directive. They run after the Up
+	// statements are committed. Only used by the Provider.
+	Validate []string
+	// Indexes are the CREATE INDEX statements moved out of the transaction of the migration to be
+	// built CONCURRENTLY, see [WithConcurrentIndexes]. Only used by the Provider.
+	Indexes []concurrentIndex
+	// Partitions are the partitioning policies declared with "-- +goose partition" directives,
+	// maintained after the migration is applied. Only used by the Provider.
+	Partitions []partitionPolicy
+	// Preconditions are the queries declared with "-- +goose precondition" directives, checked
+	// before the Up statements run, and PostConditions the queries declared with "-- +goose verify"
+	// directives, checked after they ran. Only used by the Provider.
+	Preconditions  []condition
+	PostConditions []condition
+	// Title is the title declared with a "-- +goose title" directive, or empty.
+	Title string
+	// Owner is the team declared with a "-- +goose owner" directive, or empty.
+	Owner string
+	// MaxAffected is the most rows a statement may affect, declared with a "-- +goose
+	// max-affected" directive, or zero. Only used by the Provider.
+	MaxAffected int64
+	// Destructive is true if the migration is flagged with a "-- +goose destructive" directive,
+	// and Affects are the tables it declares it affects. Only used by the Provider.
+	Destructive bool
+	Affects     []string
+	// Snapshot are the tables declared with a "-- +goose snapshot" directive, whose contents are
+	// restored after the migration is rolled back. Only used by the Provider.
+	Snapshot []string
+	// Loads are the data files declared with "-- +goose load" directives, loaded after the Up
+	// statements. Only used by the Provider.
+	Loads []dataLoad
+	// Irreversible are the Up statements no Down statement could be derived from, when the Down
+	// section is derived with [WithAutoDown]. Only used by the Provider.
+	Irreversible []string
 }
 
 // GoFunc represents a Go migration function.
 type GoFunc struct {
-	// Exactly one of these must be set, or both must be nil.
+	// Exactly one of these must be set, or all must be nil.
 	RunTx func(ctx context.Context, tx *sql.Tx) error
 	// -- OR --
 	RunDB func(ctx context.Context, db *sql.DB) error
+	// -- OR --
+	//
+	// RunConn runs outside a transaction, like RunDB, but on a single connection. All statements
+	// share one database session, which is required for temporary tables, session variables and
+	// session-level advisory locks. With a Provider, this is the same connection that holds the
+	// session lock, if any.
+	RunConn func(ctx context.Context, conn *sql.Conn) error
 
 	// Mode is the transaction mode for the migration. When one of the run functions is set, the
 	// mode will be inferred from the function and the field is ignored. Users do not need to set
 	// this field when supplying a run function.
 	//
-	// If both run functions are nil, the mode defaults to TransactionEnabled. The use case for nil
+	// If all run functions are nil, the mode defaults to TransactionEnabled. The use case for nil
 	// functions is to record a version in the version table without invoking a Go migration
 	// function.
 	//
-	// The only time this field is required is if BOTH run functions are nil AND you want to
+	// The only time this field is required is if ALL run functions are nil AND you want to
 	// override the default transaction mode.
 	Mode TransactionMode
 }
@@ -208,27 +309,106 @@ func (m *Migration) DownContext(ctx context.Context, db *sql.DB) error {
 	if err := m.run(ctx, db, false); err != nil {
 		return err
 	}
+	if !m.noVersioning {
+		if err := deleteMetadata(ctx, db, m.Version); err != nil {
+			return fmt.Errorf("ERROR %v: failed to delete metadata: %w", filepath.Base(m.Source), err)
+		}
+	}
 	return nil
 }
 
 func (m *Migration) run(ctx context.Context, db *sql.DB, direction bool) error {
-	switch filepath.Ext(m.Source) {
-	case ".sql":
-		f, err := baseFS.Open(m.Source)
+	switch {
+	case isSQLFile(m.Source):
+		data, err := fs.ReadFile(getBaseFS(), m.Source)
 		if err != nil {
 			return fmt.Errorf("ERROR %v: failed to open SQL migration file: %w", filepath.Base(m.Source), err)
 		}
-		defer f.Close()
+		directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("ERROR %v: failed to parse SQL migration file: %w", filepath.Base(m.Source), err)
+		}
+		if err := checkRequiresGoose(directives); err != nil {
+			return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+		}
+		requiresDB, err := parseRequiresDB(directives)
+		if err != nil {
+			return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+		}
+		extensions, err := parseExtensions(directives)
+		if err != nil {
+			return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+		}
+		if direction {
+			checker := &dbVersionChecker{db: db, dialect: getDialect()}
+			if err := checker.check(ctx, requiresDB); err != nil {
+				return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+			}
+			if _, tombstone := sqlparser.LookupDirective(directives, sqlparser.DirectiveTombstone); len(extensions) > 0 && !tombstone {
+				if getDialect() != DialectPostgres {
+					return fmt.Errorf("ERROR %v: %s directive requires the %s dialect", filepath.Base(m.Source), sqlparser.DirectiveExtension, DialectPostgres)
+				}
+				if err := ensureExtensions(ctx, db, extensions); err != nil {
+					return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+				}
+			}
+		}
+		if _, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveTombstone); ok {
+			// Tombstones are versioned, but never run any statements.
+			if err := runSQLMigration(ctx, db, nil, true, m.Version, direction, m.noVersioning, sqlChecks{}); err != nil {
+				return fmt.Errorf("ERROR %v: failed to run SQL migration: %w", filepath.Base(m.Source), err)
+			}
+			log.Printf("TOMBSTONE %s\n", filepath.Base(m.Source))
+			return nil
+		}
 
-		statements, useTx, err := sqlparser.ParseSQLMigration(f, sqlparser.FromBool(direction), verbose)
+		statements, useTx, err := sqlparser.ParseSQLMigration(bytes.NewReader(data), sqlparser.FromBool(direction), isVerbose())
 		if err != nil {
 			return fmt.Errorf("ERROR %v: failed to parse SQL migration file: %w", filepath.Base(m.Source), err)
 		}
 
+		var validate []string
+		if _, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveNotValid); ok && direction {
+			for i, stmt := range statements {
+				if rewritten, v, ok := sqlparser.SplitNotValid(stmt); ok {
+					statements[i] = rewritten
+					validate = append(validate, v...)
+				}
+			}
+			if len(validate) > 0 && getDialect() != DialectPostgres {
+				return fmt.Errorf("ERROR %v: %s directive requires the %s dialect", filepath.Base(m.Source), sqlparser.DirectiveNotValid, DialectPostgres)
+			}
+		}
+
+		var checks sqlChecks
+		if checks.maxAffected, err = parseMaxAffected(directives); err != nil {
+			return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+		}
+		if direction {
+			if checks.preconditions, err = parseConditions(directives, sqlparser.DirectivePrecondition); err != nil {
+				return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+			}
+			if checks.postConditions, err = parseConditions(directives, sqlparser.DirectiveVerify); err != nil {
+				return fmt.Errorf("ERROR %v: %w", filepath.Base(m.Source), err)
+			}
+		}
+		if checks.maxAffected > 0 && !useTx {
+			return fmt.Errorf("ERROR %v: %s requires a transaction to roll back: migration must not use NO TRANSACTION",
+				filepath.Base(m.Source), sqlparser.DirectiveMaxAffected)
+		}
+
 		start := time.Now()
-		if err := runSQLMigration(ctx, db, statements, useTx, m.Version, direction, m.noVersioning); err != nil {
+		if err := runSQLMigration(ctx, db, statements, useTx, m.Version, direction, m.noVersioning, checks); err != nil {
 			return fmt.Errorf("ERROR %v: failed to run SQL migration: %w", filepath.Base(m.Source), err)
 		}
+		// Constraints added NOT VALID are validated once the migration committed, as by the
+		// Provider.
+		for i, stmt := range validate {
+			if _, err := db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("ERROR %v: applied with constraints NOT VALID, but failed to validate them; "+
+					"fix the data and run the remaining statements: %q: %w", filepath.Base(m.Source), validate[i:], err)
+			}
+		}
 		finish := truncateDuration(time.Since(start))
 
 		if len(statements) > 0 {
@@ -237,10 +417,11 @@ func (m *Migration) run(ctx context.Context, db *sql.DB, direction bool) error {
 			log.Printf("EMPTY %s (%s)\n", filepath.Base(m.Source), finish)
 		}
 
-	case ".go":
+	case filepath.Ext(m.Source) == ".go":
 		if !m.Registered {
 			return fmt.Errorf("ERROR %v: failed to run Go migration: Go functions must be registered and built into a custom binary (see https://github.com/pressly/goose/tree/master/examples/go-migrations)", m.Source)
 		}
+		ctx = ContextWithCapabilities(ctx, NewCapabilities(db, getDialect()))
 		start := time.Now()
 		var empty bool
 		if m.UseTx {
@@ -260,6 +441,23 @@ func (m *Migration) run(ctx context.Context, db *sql.DB, direction bool) error {
 			); err != nil {
 				return fmt.Errorf("ERROR go migration: %q: %w", filepath.Base(m.Source), err)
 			}
+		} else if m.UpFnConnContext != nil || m.DownFnConnContext != nil {
+			// Run go-based migration outside a tx, on a single connection.
+			fn := m.DownFnConnContext
+			if direction {
+				fn = m.UpFnConnContext
+			}
+			empty = (fn == nil)
+			if err := runGoMigrationConn(
+				ctx,
+				db,
+				fn,
+				m.Version,
+				direction,
+				!m.noVersioning,
+			); err != nil {
+				return fmt.Errorf("ERROR go migration conn: %q: %w", filepath.Base(m.Source), err)
+			}
 		} else {
 			// Run go-based migration outside a tx.
 			fn := m.DownFnNoTxContext
@@ -308,6 +506,34 @@ func runGoMigrationNoTx(
 	return nil
 }
 
+func runGoMigrationConn(
+	ctx context.Context,
+	db *sql.DB,
+	fn GoMigrationConnContext,
+	version int64,
+	direction bool,
+	recordVersion bool,
+) error {
+	if fn != nil {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get connection: %w", err)
+		}
+		// Run go migration function.
+		err = fn(ctx, conn)
+		if closeErr := conn.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to close connection: %w", closeErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to run go migration: %w", err)
+		}
+	}
+	if recordVersion {
+		return insertOrDeleteVersionNoTx(ctx, db, version, direction)
+	}
+	return nil
+}
+
 func runGoMigration(
 	ctx context.Context,
 	db *sql.DB,
@@ -344,25 +570,25 @@ func runGoMigration(
 
 func insertOrDeleteVersion(ctx context.Context, tx *sql.Tx, version int64, direction bool) error {
 	if direction {
-		return store.InsertVersion(ctx, tx, TableName(), version)
+		return getStore().InsertVersion(ctx, tx, TableName(), version)
 	}
-	return store.DeleteVersion(ctx, tx, TableName(), version)
+	return getStore().DeleteVersion(ctx, tx, TableName(), version)
 }
 
 func insertOrDeleteVersionNoTx(ctx context.Context, db *sql.DB, version int64, direction bool) error {
 	if direction {
-		return store.InsertVersionNoTx(ctx, db, TableName(), version)
+		return getStore().InsertVersionNoTx(ctx, db, TableName(), version)
 	}
-	return store.DeleteVersionNoTx(ctx, db, TableName(), version)
+	return getStore().DeleteVersionNoTx(ctx, db, TableName(), version)
 }
 
 // NumericComponent parses the version from the migration file name.
 //
 // XXX_descriptivename.ext where XXX specifies the version number and ext specifies the type of
-// migration, either .sql or .go.
+// migration, either .sql or .go. Compressed SQL migrations end with .sql.gz or .sql.zst.
 func NumericComponent(filename string) (int64, error) {
-	base := filepath.Base(filename)
-	if ext := filepath.Ext(base); ext != ".go" && ext != ".sql" {
+	base := path.Base(normalizePath(filename))
+	if filepath.Ext(base) != ".go" && !isSQLFile(base) {
 		return 0, errors.New("migration file does not have .sql or .go file extension")
 	}
 	idx := strings.Index(base, "_")
@@ -379,6 +605,41 @@ func NumericComponent(filename string) (int64, error) {
 	return n, nil
 }
 
+// FilenameParser parses the version from the base name of a migration file, see
+// [WithFilenameParser]. It returns an error for files that are not migrations, which are ignored.
+// The default is [NumericComponent].
+type FilenameParser func(filename string) (int64, error)
+
+// RegexpFilenameParser returns a [FilenameParser] that parses the version from the first
+// submatch of re, in files with a .sql or .go extension. For example, for Flyway style names such
+// as V001__create_users.sql:
+//
+//	goose.RegexpFilenameParser(regexp.MustCompile(`^V(\d+)__`))
+//
+// Or for names prefixed with a ticket, such as JIRA-1234_create_users.sql:
+//
+//	goose.RegexpFilenameParser(regexp.MustCompile(`^[A-Z]+-(\d+)_`))
+func RegexpFilenameParser(re *regexp.Regexp) FilenameParser {
+	return func(filename string) (int64, error) {
+		base := path.Base(normalizePath(filename))
+		if filepath.Ext(base) != ".go" && !isSQLFile(base) {
+			return 0, errors.New("migration file does not have .sql or .go file extension")
+		}
+		match := re.FindStringSubmatch(base)
+		if len(match) < 2 {
+			return 0, fmt.Errorf("migration file name %s does not match %s", base, re)
+		}
+		n, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse version from migration file: %s: %w", base, err)
+		}
+		if n < 1 {
+			return 0, errors.New("migration version must be greater than zero")
+		}
+		return n, nil
+	}
+}
+
 func truncateDuration(d time.Duration) time.Duration {
 	for _, v := range []time.Duration{
 		time.Second,
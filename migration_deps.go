@@ -0,0 +1,29 @@
+package goose
+
+import "context"
+
+type migrationDepsKey struct{}
+
+// ContextWithMigrationDeps returns a copy of ctx carrying deps for Go migrations. Providers do this
+// automatically when configured with [WithMigrationDeps]; use it directly to pass dependencies to
+// Go migrations run through the package-level functions, such as [UpContext].
+func ContextWithMigrationDeps(ctx context.Context, deps any) context.Context {
+	return context.WithValue(ctx, migrationDepsKey{}, deps)
+}
+
+// MigrationDeps returns the dependencies of type T from the context passed to a Go migration. It
+// reports false if no dependencies were set or they are not of type T.
+//
+// Example:
+//
+//	func up(ctx context.Context, tx *sql.Tx) error {
+//		deps, ok := goose.MigrationDeps[*AppDeps](ctx)
+//		if !ok {
+//			return errors.New("missing migration deps")
+//		}
+//		...
+//	}
+func MigrationDeps[T any](ctx context.Context) (T, bool) {
+	deps, ok := ctx.Value(migrationDepsKey{}).(T)
+	return deps, ok
+}
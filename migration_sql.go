@@ -7,6 +7,17 @@ import (
 	"regexp"
 )
 
+// sqlChecks are the checks declared by the directives of a SQL migration, run on the transaction
+// or database its statements run on, as by the Provider.
+type sqlChecks struct {
+	// maxAffected is the most rows a statement may affect, or zero, see [checkAffected].
+	maxAffected int64
+	// preconditions are checked before the statements run, see [checkPreconditions].
+	preconditions []condition
+	// postConditions are checked after the statements ran, see [checkPostConditions].
+	postConditions []condition
+}
+
 // Run a migration specified in raw SQL.
 //
 // Sections of the script can be annotated with a special comment,
@@ -23,6 +34,7 @@ func runSQLMigration(
 	v int64,
 	direction bool,
 	noVersioning bool,
+	checks sqlChecks,
 ) error {
 	if useTx {
 		// TRANSACTION.
@@ -33,25 +45,41 @@ func runSQLMigration(
 		if err != nil {
 			return fmt.Errorf("failed to begin transaction: %w", err)
 		}
+		if err := checkPreconditions(ctx, tx, checks.preconditions); err != nil {
+			verboseInfo("Rollback transaction")
+			_ = tx.Rollback()
+			return err
+		}
 
 		for _, query := range statements {
 			verboseInfo("Executing statement: %s\n", clearStatement(query))
-			if _, err := tx.ExecContext(ctx, query); err != nil {
+			result, err := tx.ExecContext(ctx, query)
+			if err != nil {
 				verboseInfo("Rollback transaction")
 				_ = tx.Rollback()
 				return fmt.Errorf("failed to execute SQL query %q: %w", clearStatement(query), err)
 			}
+			if err := checkAffected(checks.maxAffected, query, result); err != nil {
+				verboseInfo("Rollback transaction")
+				_ = tx.Rollback()
+				return err
+			}
+		}
+		if err := checkPostConditions(ctx, tx, checks.postConditions); err != nil {
+			verboseInfo("Rollback transaction")
+			_ = tx.Rollback()
+			return err
 		}
 
 		if !noVersioning {
 			if direction {
-				if err := store.InsertVersion(ctx, tx, TableName(), v); err != nil {
+				if err := getStore().InsertVersion(ctx, tx, TableName(), v); err != nil {
 					verboseInfo("Rollback transaction")
 					_ = tx.Rollback()
 					return fmt.Errorf("failed to insert new goose version: %w", err)
 				}
 			} else {
-				if err := store.DeleteVersion(ctx, tx, TableName(), v); err != nil {
+				if err := getStore().DeleteVersion(ctx, tx, TableName(), v); err != nil {
 					verboseInfo("Rollback transaction")
 					_ = tx.Rollback()
 					return fmt.Errorf("failed to delete goose version: %w", err)
@@ -68,19 +96,25 @@ func runSQLMigration(
 	}
 
 	// NO TRANSACTION.
+	if err := checkPreconditions(ctx, db, checks.preconditions); err != nil {
+		return err
+	}
 	for _, query := range statements {
 		verboseInfo("Executing statement: %s", clearStatement(query))
 		if _, err := db.ExecContext(ctx, query); err != nil {
 			return fmt.Errorf("failed to execute SQL query %q: %w", clearStatement(query), err)
 		}
 	}
+	if err := checkPostConditions(ctx, db, checks.postConditions); err != nil {
+		return err
+	}
 	if !noVersioning {
 		if direction {
-			if err := store.InsertVersionNoTx(ctx, db, TableName(), v); err != nil {
+			if err := getStore().InsertVersionNoTx(ctx, db, TableName(), v); err != nil {
 				return fmt.Errorf("failed to insert new goose version: %w", err)
 			}
 		} else {
-			if err := store.DeleteVersionNoTx(ctx, db, TableName(), v); err != nil {
+			if err := getStore().DeleteVersionNoTx(ctx, db, TableName(), v); err != nil {
 				return fmt.Errorf("failed to delete goose version: %w", err)
 			}
 		}
@@ -95,8 +129,8 @@ const (
 )
 
 func verboseInfo(s string, args ...interface{}) {
-	if verbose {
-		if noColor {
+	if isVerbose() {
+		if isNoColor() {
 			log.Printf(s, args...)
 		} else {
 			log.Printf(grayColor+s+resetColor, args...)
@@ -0,0 +1,58 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"go.uber.org/multierr"
+)
+
+// RunAsMigrator opens a dedicated connection to dsn with the registered database/sql driver
+// driverName, creates a [Provider] on it and calls fn, then closes the connection. It is meant for
+// a privileged "migrator" user that owns the schema, while the application keeps using its own
+// *sql.DB with a restricted user that cannot alter the schema:
+//
+//	err := goose.RunAsMigrator(ctx, goose.DialectPostgres, "pgx", os.Getenv("MIGRATOR_DSN"), fsys,
+//		func(ctx context.Context, p *goose.Provider) error {
+//			_, err := p.Up(ctx)
+//			return err
+//		},
+//	)
+//
+// The connection is verified before fn is called, so bad credentials fail early. The privileged
+// connection is never shared with the application and is closed when RunAsMigrator returns,
+// whether fn succeeds or not.
+func RunAsMigrator(
+	ctx context.Context,
+	dialect Dialect,
+	driverName string,
+	dsn string,
+	fsys fs.FS,
+	fn func(context.Context, *Provider) error,
+	opts ...ProviderOption,
+) (retErr error) {
+	if fn == nil {
+		return errors.New("migrator func must not be nil")
+	}
+	if dsn == "" {
+		return errors.New("migrator dsn must not be empty")
+	}
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open migrator connection: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, db.Close())
+	}()
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect as migrator: %w", err)
+	}
+	p, err := NewProvider(dialect, db, fsys, opts...)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, p)
+}
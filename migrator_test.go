@@ -0,0 +1,59 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAsMigrator(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dsn := filepath.Join(t.TempDir(), "app.db")
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+	}
+	var provider *goose.Provider
+	err := goose.RunAsMigrator(ctx, goose.DialectSQLite3, "sqlite", dsn, fsys, func(ctx context.Context, p *goose.Provider) error {
+		provider = p
+		_, err := p.Up(ctx)
+		return err
+	})
+	require.NoError(t, err)
+	// The migrator connection is closed once the run completes.
+	require.ErrorContains(t, provider.Ping(ctx), "database is closed")
+
+	// The application sees the migrated schema on its own connection.
+	db, err := sql.Open("sqlite", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.True(t, tableExists(t, db, "a"))
+
+	t.Run("errors", func(t *testing.T) {
+		failure := errors.New("failure")
+		err := goose.RunAsMigrator(ctx, goose.DialectSQLite3, "sqlite", dsn, fsys, func(context.Context, *goose.Provider) error {
+			return failure
+		})
+		require.ErrorIs(t, err, failure)
+		err = goose.RunAsMigrator(ctx, goose.DialectSQLite3, "unknown", dsn, fsys, func(context.Context, *goose.Provider) error {
+			return nil
+		})
+		require.ErrorContains(t, err, "failed to open migrator connection")
+		err = goose.RunAsMigrator(ctx, goose.DialectSQLite3, "sqlite", "", fsys, func(context.Context, *goose.Provider) error {
+			return nil
+		})
+		require.ErrorContains(t, err, "migrator dsn must not be empty")
+		err = goose.RunAsMigrator(ctx, goose.DialectSQLite3, "sqlite", filepath.Join(t.TempDir(), "missing", "app.db"), fsys,
+			func(context.Context, *goose.Provider) error { return nil })
+		require.ErrorContains(t, err, "failed to connect as migrator")
+		err = goose.RunAsMigrator(ctx, goose.DialectSQLite3, "sqlite", dsn, fsys, nil)
+		require.ErrorContains(t, err, "migrator func must not be nil")
+	})
+}
@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"text/template"
+)
+
+const (
+	// DefaultPagerDutyURL is the PagerDuty Events API v2 endpoint alerts are sent to.
+	DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	// DefaultOpsgenieURL is the Opsgenie Alert API endpoint alerts are sent to. Accounts in the EU
+	// service region use https://api.eu.opsgenie.com/v2/alerts instead, see [WithURL].
+	DefaultOpsgenieURL = "https://api.opsgenie.com/v2/alerts"
+	// DefaultAlertRetries is how many times alerts are retried, unless set with [WithRetries].
+	DefaultAlertRetries = 3
+)
+
+// pagerDutyTemplate triggers a critical PagerDuty incident. The summary is limited to 1024
+// characters by the Events API.
+const pagerDutyTemplate = `{
+	"routing_key": {{ routingKey | json }},
+	"event_action": "trigger",
+	"payload": {
+		"summary": {{ printf "goose: migration failed: %s" .Error | truncate 1024 | json }},
+		"source": "goose",
+		"severity": "critical",
+		"custom_details": {"text": {{ json .Text }}, "migrations": {{ json .Migrations }}}
+	}
+}`
+
+// opsgenieTemplate creates a P1 Opsgenie alert. The message is limited to 130 characters and the
+// description to 15000 characters by the Alert API.
+const opsgenieTemplate = `{
+	"message": {{ printf "goose: migration failed: %s" .Error | truncate 130 | json }},
+	"description": {{ .Text | truncate 15000 | json }},
+	"source": "goose",
+	"tags": ["goose"],
+	"priority": "P1"
+}`
+
+// NewPagerDuty returns a Notifier that triggers a critical PagerDuty incident for each run, with
+// the routing key of an Events API v2 integration. It is meant for failed runs, see the
+// WithFailureNotifier option of the goose Provider.
+//
+// Alerts are retried [DefaultAlertRetries] times, unless set with [WithRetries]. The payload can be
+// customized with [WithTemplate], where the template may use "routingKey" to insert the routing
+// key.
+func NewPagerDuty(routingKey string, opts ...Option) (Notifier, error) {
+	if routingKey == "" {
+		return nil, errors.New("routing key must not be empty")
+	}
+	funcs := template.FuncMap{"routingKey": func() string { return routingKey }}
+	return newAlerter(DefaultPagerDutyURL, pagerDutyTemplate, funcs, opts)
+}
+
+// NewOpsgenie returns a Notifier that creates a P1 Opsgenie alert for each run, with the key of an
+// API integration. It is meant for failed runs, see the WithFailureNotifier option of the goose
+// Provider.
+//
+// Alerts are retried [DefaultAlertRetries] times, unless set with [WithRetries]. The payload can be
+// customized with [WithTemplate].
+func NewOpsgenie(apiKey string, opts ...Option) (Notifier, error) {
+	if apiKey == "" {
+		return nil, errors.New("api key must not be empty")
+	}
+	opts = append([]Option{WithHeader("Authorization", "GenieKey "+apiKey)}, opts...)
+	return newAlerter(DefaultOpsgenieURL, opsgenieTemplate, nil, opts)
+}
+
+func newAlerter(rawURL, tmpl string, funcs template.FuncMap, opts []Option) (Notifier, error) {
+	defaults := []Option{
+		WithRetries(DefaultAlertRetries, DefaultRetryBackoff),
+		WithTemplate(tmpl),
+	}
+	p, err := newTemplatePoster(rawURL, nil, funcs, append(defaults, opts...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alerter: %w", err)
+	}
+	return p, nil
+}
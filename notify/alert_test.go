@@ -0,0 +1,137 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3/notify"
+	"github.com/stretchr/testify/require"
+)
+
+var failedSummary = notify.Summary{
+	Migrations: []notify.Migration{
+		{Version: 3, Source: "00003_c.sql", Direction: "up", Duration: 12 * time.Millisecond, Error: "no such table: users"},
+	},
+	Duration: 20 * time.Millisecond,
+	Error:    "partial migration error (type:sql,version:3): no such table: users",
+}
+
+// flakyServer fails the first failures requests with status, then records the body of each
+// request.
+func flakyServer(t *testing.T, failures, status int) (*httptest.Server, *[][]byte, *http.Header) {
+	t.Helper()
+	var bodies [][]byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		if failures > 0 {
+			failures--
+			http.Error(w, "unavailable", status)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &bodies, &header
+}
+
+func TestPagerDuty(t *testing.T) {
+	t.Parallel()
+
+	srv, bodies, _ := flakyServer(t, 2, http.StatusInternalServerError)
+	n, err := notify.NewPagerDuty("R0UT1NG", notify.WithURL(srv.URL), notify.WithRetries(2, time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), failedSummary))
+	require.Len(t, *bodies, 1)
+	var event struct {
+		RoutingKey  string `json:"routing_key"`
+		EventAction string `json:"event_action"`
+		Payload     struct {
+			Summary       string `json:"summary"`
+			Severity      string `json:"severity"`
+			CustomDetails struct {
+				Migrations []notify.Migration `json:"migrations"`
+			} `json:"custom_details"`
+		} `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal((*bodies)[0], &event))
+	require.Equal(t, "R0UT1NG", event.RoutingKey)
+	require.Equal(t, "trigger", event.EventAction)
+	require.Equal(t, "critical", event.Payload.Severity)
+	require.Equal(t, "goose: migration failed: "+failedSummary.Error, event.Payload.Summary)
+	require.Equal(t, failedSummary.Migrations, event.Payload.CustomDetails.Migrations)
+
+	_, err = notify.NewPagerDuty("")
+	require.Error(t, err)
+}
+
+func TestOpsgenie(t *testing.T) {
+	t.Parallel()
+
+	srv, bodies, header := flakyServer(t, 0, 0)
+	n, err := notify.NewOpsgenie("k3y", notify.WithURL(srv.URL))
+	require.NoError(t, err)
+	summary := failedSummary
+	summary.Error = strings.Repeat("x", 200)
+	require.NoError(t, n.Notify(context.Background(), summary))
+	require.Equal(t, "GenieKey k3y", header.Get("Authorization"))
+	var alert map[string]any
+	require.NoError(t, json.Unmarshal((*bodies)[0], &alert))
+	require.Len(t, alert["message"], 130)
+	require.True(t, strings.HasSuffix(alert["message"].(string), "..."))
+	require.Equal(t, "P1", alert["priority"])
+
+	_, err = notify.NewOpsgenie("")
+	require.Error(t, err)
+}
+
+func TestRetries(t *testing.T) {
+	t.Parallel()
+
+	t.Run("exhausted", func(t *testing.T) {
+		srv, _, _ := flakyServer(t, 3, http.StatusTooManyRequests)
+		n, err := notify.NewWebhook(srv.URL, notify.WithRetries(2, time.Millisecond))
+		require.NoError(t, err)
+		err = n.Notify(context.Background(), failedSummary)
+		require.ErrorContains(t, err, "429 Too Many Requests")
+	})
+	t.Run("client_error", func(t *testing.T) {
+		// Errors that would fail again are not retried.
+		srv, bodies, _ := flakyServer(t, 1, http.StatusBadRequest)
+		n, err := notify.NewWebhook(srv.URL, notify.WithRetries(2, time.Millisecond))
+		require.NoError(t, err)
+		require.ErrorContains(t, n.Notify(context.Background(), failedSummary), "400 Bad Request")
+		require.Empty(t, *bodies)
+	})
+	_, err := notify.NewWebhook("http://localhost", notify.WithRetries(-1, time.Second))
+	require.Error(t, err)
+	_, err = notify.NewWebhook("http://localhost", notify.WithRetries(1, 0))
+	require.Error(t, err)
+}
+
+func TestTemplate(t *testing.T) {
+	t.Parallel()
+
+	srv, bodies, _ := flakyServer(t, 0, 0)
+	n, err := notify.NewWebhook(srv.URL, notify.WithTemplate(
+		`{"title": {{ .Error | truncate 10 | json }}, "count": {{ len .Migrations }}, "text": {{ json .Text }}}`,
+	))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), failedSummary))
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal((*bodies)[0], &payload))
+	require.Equal(t, "partial...", payload["title"])
+	require.EqualValues(t, 1, payload["count"])
+	require.Equal(t, notify.Text(failedSummary), payload["text"])
+
+	_, err = notify.NewWebhook(srv.URL, notify.WithTemplate("{{ .Error"))
+	require.ErrorContains(t, err, "invalid payload template")
+}
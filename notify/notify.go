@@ -0,0 +1,321 @@
+// Package notify defines the Notifier interface and implements notifications with webhooks and
+// Slack.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Summary describes a completed run of migrations, whether it succeeded or failed.
+type Summary struct {
+	// Migrations are the migrations of the run, in the order they were run. If the run failed
+	// while running a migration, it is the last one and its Error is set. May be empty if the run
+	// failed before any migration was run.
+	Migrations []Migration `json:"migrations"`
+	// Duration is how long the run took, in nanoseconds when encoded as JSON.
+	Duration time.Duration `json:"duration"`
+	// Error is the error of a failed run, or empty if the run succeeded.
+	Error string `json:"error,omitempty"`
+}
+
+// Migration is a migration run as part of a [Summary].
+type Migration struct {
+	Version int64 `json:"version"`
+	// Source is the path of the migration file. It may be empty for Go migrations registered
+	// without a file.
+	Source string `json:"source,omitempty"`
+	// Title is the title of the migration, declared with a "-- +goose title" directive or set with
+	// the WithDescription option of a Go migration. It may be empty.
+	Title string `json:"title,omitempty"`
+	// Owner is the team owning the migration, declared with a "-- +goose owner" directive or set
+	// with the WithOwner option of a Go migration. It may be empty, see [NewOwnerRouter].
+	Owner string `json:"owner,omitempty"`
+	// Direction is either "up" or "down".
+	Direction string `json:"direction"`
+	// Duration is how long the migration took, in nanoseconds when encoded as JSON.
+	Duration time.Duration `json:"duration"`
+	// Error is the error of the migration, or empty if it was applied.
+	Error string `json:"error,omitempty"`
+}
+
+// Notifier sends the summary of a run, e.g., to a chat channel watched by on-call engineers.
+type Notifier interface {
+	// Notify sends the summary of a completed run.
+	Notify(ctx context.Context, s Summary) error
+}
+
+// DefaultTimeout is how long a notification request may take, unless a client is set with
+// [WithHTTPClient].
+const DefaultTimeout = 10 * time.Second
+
+// DefaultRetryBackoff is the delay before the first retry of a notification, see [WithRetries].
+const DefaultRetryBackoff = time.Second
+
+// NewWebhook returns a Notifier that posts the summary of each run to rawURL, encoded as JSON, or
+// rendered with the template set with [WithTemplate].
+func NewWebhook(rawURL string, opts ...Option) (Notifier, error) {
+	return newPoster(rawURL, func(s Summary) ([]byte, error) { return json.Marshal(s) }, opts)
+}
+
+// NewSlack returns a Notifier that posts the summary of each run as a message to a Slack incoming
+// webhook at rawURL.
+func NewSlack(rawURL string, opts ...Option) (Notifier, error) {
+	return newPoster(rawURL, func(s Summary) ([]byte, error) {
+		return json.Marshal(map[string]string{"text": Text(s)})
+	}, opts)
+}
+
+// TemplateData is the data payload templates are executed with, see [WithTemplate].
+type TemplateData struct {
+	Summary
+	// Text is the human-readable summary, see [Text].
+	Text string
+}
+
+// Text returns a human-readable summary, one line per migration, for example:
+//
+//	goose: migration failed after 1.2s: partial migration error (type:sql,version:3): no such table: users
+//	OK     up 00002_b.sql: Add users table (1.188s)
+//	FAILED up 00003_c.sql @payments-team (12ms): no such table: users
+func Text(s Summary) string {
+	var b strings.Builder
+	if s.Error != "" {
+		fmt.Fprintf(&b, "goose: migration failed after %s: %s", s.Duration.Round(time.Millisecond), s.Error)
+	} else {
+		n := "migrations"
+		if len(s.Migrations) == 1 {
+			n = "migration"
+		}
+		fmt.Fprintf(&b, "goose: ran %d %s in %s", len(s.Migrations), n, s.Duration.Round(time.Millisecond))
+	}
+	for _, m := range s.Migrations {
+		name := path.Base(m.Source)
+		if m.Source == "" {
+			name = fmt.Sprintf("version %d", m.Version)
+		}
+		if m.Title != "" {
+			name += ": " + m.Title
+		}
+		if m.Owner != "" {
+			name += " @" + m.Owner
+		}
+		if m.Error != "" {
+			fmt.Fprintf(&b, "\nFAILED %s %s (%s): %s", m.Direction, name, m.Duration.Round(time.Millisecond), m.Error)
+			continue
+		}
+		fmt.Fprintf(&b, "\nOK     %s %s (%s)", m.Direction, name, m.Duration.Round(time.Millisecond))
+	}
+	return b.String()
+}
+
+// Option is used to configure a Notifier.
+type Option interface {
+	apply(*poster) error
+}
+
+// WithHTTPClient sets the client used to post notifications. The default client times out after
+// [DefaultTimeout].
+func WithHTTPClient(client *http.Client) Option {
+	return posterFunc(func(p *poster) error {
+		if client == nil {
+			return errors.New("http client must not be nil")
+		}
+		p.client = client
+		return nil
+	})
+}
+
+// WithTemplate renders the payload of notifications with the [text/template] tmpl, executed with
+// a [TemplateData], instead of the default payload of the Notifier. Besides the builtin functions,
+// templates may use "json" to encode a value as JSON and "truncate" to shorten a string to at most
+// n characters, e.g., for the size limits of alerting services:
+//
+//	{"title": {{ .Error | truncate 100 | json }}, "migrations": {{ json .Migrations }}}
+func WithTemplate(tmpl string) Option {
+	return posterFunc(func(p *poster) error {
+		t, err := template.New("payload").Funcs(p.funcs).Parse(tmpl)
+		if err != nil {
+			return fmt.Errorf("invalid payload template: %w", err)
+		}
+		p.encode = executeTemplate(t)
+		return nil
+	})
+}
+
+// WithRetries retries a notification up to n times when it fails with a network error, a server
+// error, or because of rate limiting. The delay before the first retry is backoff, and doubles for
+// every retry after. Zero retries, the default, sends each notification once.
+func WithRetries(n int, backoff time.Duration) Option {
+	return posterFunc(func(p *poster) error {
+		if n < 0 {
+			return fmt.Errorf("retries must not be negative: %d", n)
+		}
+		if backoff <= 0 {
+			return fmt.Errorf("retry backoff must be greater than 0: %s", backoff)
+		}
+		p.retries, p.backoff = n, backoff
+		return nil
+	})
+}
+
+// WithURL sets the URL notifications are posted to, e.g., for the EU service region of an alerting
+// service.
+func WithURL(rawURL string) Option {
+	return posterFunc(func(p *poster) error {
+		p.url = rawURL
+		return nil
+	})
+}
+
+// WithHeader sets a header on every notification request, e.g., for authentication.
+func WithHeader(key, value string) Option {
+	return posterFunc(func(p *poster) error {
+		if key == "" {
+			return errors.New("header key must not be empty")
+		}
+		p.header.Set(key, value)
+		return nil
+	})
+}
+
+var _ Option = (posterFunc)(nil)
+
+type posterFunc func(*poster) error
+
+func (f posterFunc) apply(p *poster) error {
+	return f(p)
+}
+
+// poster is a Notifier that posts an encoded summary to a URL.
+type poster struct {
+	url     string
+	encode  func(Summary) ([]byte, error)
+	funcs   template.FuncMap
+	client  *http.Client
+	header  http.Header
+	retries int
+	backoff time.Duration
+}
+
+func newPoster(rawURL string, encode func(Summary) ([]byte, error), opts []Option) (*poster, error) {
+	return newTemplatePoster(rawURL, encode, nil, opts)
+}
+
+// newTemplatePoster returns a poster whose payload templates may use funcs, in addition to the
+// functions available to all templates.
+func newTemplatePoster(
+	rawURL string,
+	encode func(Summary) ([]byte, error),
+	funcs template.FuncMap,
+	opts []Option,
+) (*poster, error) {
+	p := &poster{
+		url:    rawURL,
+		encode: encode,
+		funcs: template.FuncMap{
+			"json":     toJSON,
+			"truncate": truncate,
+		},
+		client:  &http.Client{Timeout: DefaultTimeout},
+		header:  make(http.Header),
+		backoff: DefaultRetryBackoff,
+	}
+	for name, fn := range funcs {
+		p.funcs[name] = fn
+	}
+	for _, opt := range opts {
+		if err := opt.apply(p); err != nil {
+			return nil, err
+		}
+	}
+	u, err := url.Parse(p.url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("notification url must be http or https: %q", p.url)
+	}
+	return p, nil
+}
+
+func (p *poster) Notify(ctx context.Context, s Summary) error {
+	body, err := p.encode(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+	backoff := p.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := p.post(ctx, body)
+		if err == nil || !retry || attempt == p.retries {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %w", err, ctx.Err())
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// post posts body once, and reports whether a failed post may succeed if retried.
+func (p *poster) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	for key, values := range p.header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("failed to post notification: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return false, nil
+}
+
+func executeTemplate(t *template.Template) func(Summary) ([]byte, error) {
+	return func(s Summary) ([]byte, error) {
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, TemplateData{Summary: s, Text: Text(s)}); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 3 {
+		return string(r[:n])
+	}
+	return string(r[:n-3]) + "..."
+}
@@ -0,0 +1,76 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3/notify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+
+	summary := notify.Summary{
+		Migrations: []notify.Migration{
+			{Version: 2, Source: "migrations/00002_b.sql", Direction: "up", Duration: 1188 * time.Millisecond},
+			{Version: 3, Source: "migrations/00003_c.sql", Direction: "up", Duration: 12 * time.Millisecond, Error: "no such table: users"},
+		},
+		Duration: 1200 * time.Millisecond,
+		Error:    "partial migration error (type:sql,version:3): no such table: users",
+	}
+	var got []byte
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		got, _ = io.ReadAll(r.Body)
+	}))
+	t.Cleanup(srv.Close)
+
+	n, err := notify.NewWebhook(srv.URL, notify.WithHeader("Authorization", "Bearer token"))
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), summary))
+	require.Equal(t, "Bearer token", header.Get("Authorization"))
+	require.Equal(t, "application/json", header.Get("Content-Type"))
+	var decoded notify.Summary
+	require.NoError(t, json.Unmarshal(got, &decoded))
+	require.Equal(t, summary, decoded)
+
+	n, err = notify.NewSlack(srv.URL)
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), summary))
+	var message map[string]string
+	require.NoError(t, json.Unmarshal(got, &message))
+	require.Equal(t, "goose: migration failed after 1.2s: partial migration error (type:sql,version:3): no such table: users\n"+
+		"OK     up 00002_b.sql (1.188s)\n"+
+		"FAILED up 00003_c.sql (12ms): no such table: users", message["text"])
+	require.Equal(t, "goose: ran 1 migration in 5ms\nOK     up version 4 (5ms)", notify.Text(notify.Summary{
+		Migrations: []notify.Migration{{Version: 4, Direction: "up", Duration: 5 * time.Millisecond}},
+		Duration:   5 * time.Millisecond,
+	}))
+}
+
+func TestWebhookErrors(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+	n, err := notify.NewSlack(srv.URL)
+	require.NoError(t, err)
+	err = n.Notify(context.Background(), notify.Summary{})
+	require.EqualError(t, err, "failed to post notification: 403 Forbidden: invalid_token")
+
+	_, err = notify.NewWebhook("hooks.slack.com/services/T000")
+	require.ErrorContains(t, err, "must be http or https")
+	_, err = notify.NewWebhook(srv.URL, notify.WithHTTPClient(nil))
+	require.Error(t, err)
+	_, err = notify.NewWebhook(srv.URL, notify.WithHeader("", "value"))
+	require.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+)
+
+// NewOwnerRouter returns a Notifier that sends the summary of a failed run to the notifier of the
+// team owning the failed migration, e.g., the Slack channel of the team, much like a CODEOWNERS
+// file routes reviews. owners maps a team, as declared with a "-- +goose owner" directive, to its
+// notifier.
+//
+// Summaries of successful runs, of runs that failed before any migration was run, and of failed
+// migrations whose owner is not in owners are sent to fallback. fallback may be nil to drop them.
+func NewOwnerRouter(owners map[string]Notifier, fallback Notifier) (Notifier, error) {
+	routes := make(map[string]Notifier, len(owners))
+	for owner, n := range owners {
+		if n == nil {
+			return nil, fmt.Errorf("notifier of owner %q must not be nil", owner)
+		}
+		routes[owner] = n
+	}
+	return &ownerRouter{routes: routes, fallback: fallback}, nil
+}
+
+type ownerRouter struct {
+	routes   map[string]Notifier
+	fallback Notifier
+}
+
+func (r *ownerRouter) Notify(ctx context.Context, s Summary) error {
+	n := r.fallback
+	if owner := failedOwner(s); owner != "" {
+		if route, ok := r.routes[owner]; ok {
+			n = route
+		}
+	}
+	if n == nil {
+		return nil
+	}
+	return n.Notify(ctx, s)
+}
+
+// failedOwner returns the owner of the failed migration of s, or an empty string if s did not fail
+// while running a migration.
+func failedOwner(s Summary) string {
+	if s.Error == "" || len(s.Migrations) == 0 {
+		return ""
+	}
+	if last := s.Migrations[len(s.Migrations)-1]; last.Error != "" {
+		return last.Owner
+	}
+	return ""
+}
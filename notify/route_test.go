@@ -0,0 +1,73 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pressly/goose/v3/notify"
+	"github.com/stretchr/testify/require"
+)
+
+type recorder struct {
+	summaries []notify.Summary
+}
+
+func (r *recorder) Notify(_ context.Context, s notify.Summary) error {
+	r.summaries = append(r.summaries, s)
+	return nil
+}
+
+func TestOwnerRouter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	payments, fallback := &recorder{}, &recorder{}
+	router, err := notify.NewOwnerRouter(map[string]notify.Notifier{"payments-team": payments}, fallback)
+	require.NoError(t, err)
+
+	failed := notify.Summary{
+		Migrations: []notify.Migration{
+			{Version: 2, Direction: "up", Owner: "identity-team"},
+			{Version: 3, Direction: "up", Owner: "payments-team", Error: "no such table: invoices"},
+		},
+		Error: "partial migration error (type:sql,version:3): no such table: invoices",
+	}
+	require.NoError(t, router.Notify(ctx, failed))
+	require.Equal(t, []notify.Summary{failed}, payments.summaries)
+	require.Empty(t, fallback.summaries)
+
+	// Failures of migrations without a routed owner, runs that failed before any migration and
+	// successful runs go to the fallback.
+	unrouted := notify.Summary{
+		Migrations: []notify.Migration{{Version: 2, Direction: "up", Owner: "identity-team", Error: "boom"}},
+		Error:      "boom",
+	}
+	early := notify.Summary{Error: "failed to initialize: boom"}
+	succeeded := notify.Summary{Migrations: []notify.Migration{{Version: 3, Direction: "up", Owner: "payments-team"}}}
+	for _, s := range []notify.Summary{unrouted, early, succeeded} {
+		require.NoError(t, router.Notify(ctx, s))
+	}
+	require.Len(t, payments.summaries, 1)
+	require.Equal(t, []notify.Summary{unrouted, early, succeeded}, fallback.summaries)
+
+	// Without a fallback, unrouted summaries are dropped.
+	router, err = notify.NewOwnerRouter(map[string]notify.Notifier{"payments-team": payments}, nil)
+	require.NoError(t, err)
+	require.NoError(t, router.Notify(ctx, succeeded))
+	require.Len(t, payments.summaries, 1)
+
+	_, err = notify.NewOwnerRouter(map[string]notify.Notifier{"payments-team": nil}, fallback)
+	require.ErrorContains(t, err, `notifier of owner "payments-team" must not be nil`)
+}
+
+func TestTextOwner(t *testing.T) {
+	t.Parallel()
+
+	text := notify.Text(notify.Summary{
+		Migrations: []notify.Migration{
+			{Version: 3, Source: "00003_c.sql", Title: "Add invoices", Owner: "payments-team", Direction: "up", Error: "boom"},
+		},
+		Error: "boom",
+	})
+	require.Contains(t, text, "\nFAILED up 00003_c.sql: Add invoices @payments-team (0s): boom")
+}
@@ -1,7 +1,14 @@
 package goose
 
+import "time"
+
 type MigrationConfig struct {
-	Scope string
+	Scope          string
+	Checksum       string
+	ExpectDuration time.Duration
+	Role           string
+	Description    string
+	Owner          string
 }
 
 type MigrationOption func(cfg *MigrationConfig)
@@ -11,3 +18,36 @@ func WithScope(scope string) MigrationOption {
 		cfg.Scope = scope
 	}
 }
+
+// WithExpectDuration sets how long a Go migration is expected to run. A provider warns when the
+// migration runs longer, see [WithSlowMigration].
+func WithExpectDuration(d time.Duration) MigrationOption {
+	return func(cfg *MigrationConfig) {
+		cfg.ExpectDuration = d
+	}
+}
+
+// WithRole sets the database role a Go migration runs as. A provider switches to the role with SET
+// ROLE before the migration and back with RESET ROLE after it, see [Migration.Role].
+func WithRole(role string) MigrationOption {
+	return func(cfg *MigrationConfig) {
+		cfg.Role = role
+	}
+}
+
+// WithDescription sets a human-readable description of a Go migration, e.g., "Add users table".
+// It is reported as the title of the migration by status, plan and notifications, see
+// [Migration.Description].
+func WithDescription(desc string) MigrationOption {
+	return func(cfg *MigrationConfig) {
+		cfg.Description = desc
+	}
+}
+
+// WithOwner sets the team owning a Go migration, e.g., "payments-team". It is reported by status,
+// plan and notifications, which can route failures to the owning team, see [Migration.Owner].
+func WithOwner(team string) MigrationOption {
+	return func(cfg *MigrationConfig) {
+		cfg.Owner = team
+	}
+}
@@ -0,0 +1,60 @@
+package goose
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// ParsedSQL is a SQL migration file parsed by [ParseSQL].
+type ParsedSQL struct {
+	// Up and Down are the statements of the Up and Down sections, in order. Down is empty if the
+	// migration has no Down section, or an empty one.
+	Up   []string `json:"up"`
+	Down []string `json:"down"`
+	// UseTx is false if the migration is annotated with NO TRANSACTION.
+	UseTx bool `json:"use_tx"`
+	// HasDown is true if the migration has a Down annotation, even without statements.
+	HasDown bool `json:"has_down"`
+	// Directives are the goose directives of the migration, by name, e.g., "impact": "heavy". A
+	// directive without a value maps to an empty string.
+	Directives map[string]string `json:"directives"`
+}
+
+// ParseSQL parses the SQL migration read from r, like goose does before running it, but without a
+// database. Like [PlanFS], it compiles for GOOS=js, e.g., for web-based review tools.
+func ParseSQL(r io.Reader) (*ParsedSQL, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration: %w", err)
+	}
+	up, useTx, err := sqlparser.ParseSQLMigration(bytes.NewReader(data), sqlparser.DirectionUp, false)
+	if err != nil {
+		return nil, err
+	}
+	down, _, err := sqlparser.ParseSQLMigration(bytes.NewReader(data), sqlparser.DirectionDown, false)
+	if err != nil {
+		return nil, err
+	}
+	hasDown, err := sqlparser.HasDown(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	parsed := &ParsedSQL{
+		Up:         append([]string{}, up...),
+		Down:       append([]string{}, down...),
+		UseTx:      useTx,
+		HasDown:    hasDown,
+		Directives: make(map[string]string, len(directives)),
+	}
+	for _, d := range directives {
+		parsed.Directives[d.Name] = d.Value
+	}
+	return parsed, nil
+}
@@ -0,0 +1,39 @@
+package goose_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSQL(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := goose.ParseSQL(strings.NewReader(`-- +goose impact heavy
+-- +goose NO TRANSACTION
+-- +goose Up
+CREATE TABLE users (id INTEGER);
+CREATE INDEX idx_users_id ON users (id);
+-- +goose Down
+DROP TABLE users;
+`))
+	require.NoError(t, err)
+	require.Equal(t, &goose.ParsedSQL{
+		Up:         []string{"CREATE TABLE users (id INTEGER);", "CREATE INDEX idx_users_id ON users (id);"},
+		Down:       []string{"DROP TABLE users;"},
+		UseTx:      false,
+		HasDown:    true,
+		Directives: map[string]string{"impact": "heavy"},
+	}, parsed)
+
+	parsed, err = goose.ParseSQL(strings.NewReader("-- +goose Up\nSELECT 1;\n"))
+	require.NoError(t, err)
+	require.True(t, parsed.UseTx)
+	require.False(t, parsed.HasDown)
+	require.Empty(t, parsed.Down)
+
+	_, err = goose.ParseSQL(strings.NewReader("SELECT 1;\n"))
+	require.Error(t, err)
+}
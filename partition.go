@@ -0,0 +1,62 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// MaintainPartitions runs partition maintenance for the partitioning policies declared by the
+// applied SQL migrations in dir, on the package-level dialect, which must be postgres. See
+// [Provider.MaintainPartitions].
+func MaintainPartitions(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFunc) (_ []*PartitionChange, retErr error) {
+	option := applyOptions(opts)
+	if d := getDialect(); d != DialectPostgres {
+		return nil, fmt.Errorf("partition maintenance requires the %s dialect, got %s", DialectPostgres, d)
+	}
+	if option.noVersioning {
+		return nil, errors.New("partition maintenance requires versioning: the applied migrations declare the partitioning policies")
+	}
+	foundMigrations, err := collectMigrations(option, dir, minVersion, maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	dbMigrations, err := listAllDBVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Version] = true
+	}
+	var migrations []*Migration
+	for _, m := range foundMigrations {
+		if applied[m.Version] {
+			migrations = append(migrations, m)
+		}
+	}
+	policies, err := collectPartitions(getBaseFS(), migrations)
+	if err != nil {
+		return nil, err
+	}
+	store, err := getMetadataStore()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, conn.Close())
+	}()
+	changes, err := maintainPartitions(ctx, conn, store, policies, time.Now())
+	for _, c := range changes {
+		log.Printf("goose: %s\n", c)
+	}
+	return changes, err
+}
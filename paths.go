@@ -0,0 +1,32 @@
+package goose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// normalizePath converts the separators of a file path to forward slashes, the separator of
+// [fs.FS] paths. Paths from runtime.Caller and user input on Windows use backslashes, e.g.,
+// C:\app\migrations\00001_init.go, and would otherwise not match the paths of the filesystem, or
+// parse differently depending on the OS.
+func normalizePath(name string) string {
+	return strings.ReplaceAll(name, `\`, "/")
+}
+
+// caseConflicts detects migration files whose paths differ only in case. Such files cannot exist
+// side by side on a case-insensitive filesystem, the default on Windows and macOS, so a checkout
+// there silently keeps only one of them.
+type caseConflicts map[string]string // map[folded path]fullpath
+
+// add returns an error if fullpath differs only in case from a path added earlier.
+func (c caseConflicts) add(fullpath string) error {
+	folded := strings.ToLower(fullpath)
+	if existing, ok := c[folded]; ok && existing != fullpath {
+		return fmt.Errorf("found migration files that differ only in case:\n\texisting:%v\n\tcurrent:%v",
+			existing,
+			fullpath,
+		)
+	}
+	c[folded] = fullpath
+	return nil
+}
@@ -0,0 +1,322 @@
+package goose
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pressly/goose/v3/policy"
+)
+
+// ErrPlanStale is returned by [ApplyPlan] when the database or the planned migration files changed
+// since the plan was created.
+var ErrPlanStale = errors.New("plan is stale")
+
+// Plan is a reviewable record of the migrations an up would apply, created by [CreatePlan] and
+// applied by [ApplyPlan]. A plan is meant to be JSON encoded, reviewed and applied as-is, so that
+// exactly the reviewed changes are made to the database.
+type Plan struct {
+	// CreatedAt is when the plan was created.
+	CreatedAt time.Time `json:"created_at"`
+	// DatabaseVersion is the version of the database when the plan was created.
+	DatabaseVersion int64 `json:"database_version"`
+	// State is a fingerprint of the versions applied to the database when the plan was created.
+	State string `json:"state"`
+	// Migrations are the migrations to apply, in order. May be empty.
+	Migrations []PlannedMigration `json:"migrations"`
+}
+
+// PlannedMigration is a migration in a [Plan].
+type PlannedMigration struct {
+	Version int64  `json:"version"`
+	Source  string `json:"source"`
+	// Title is the title of the migration, or empty, see [MigrationStatus.Title].
+	Title string `json:"title,omitempty"`
+	// Owner is the team owning the migration, or empty, see [MigrationStatus.Owner].
+	Owner string `json:"owner,omitempty"`
+	// Checksum is the checksum of the migration when the plan was created. It is empty for Go
+	// migrations without an embedded checksum, see [WithChecksum].
+	Checksum string `json:"checksum,omitempty"`
+	// Hints are the resource hints declared by the migration, or nil, see [Hints].
+	Hints *Hints `json:"hints,omitempty"`
+}
+
+// CreatePlan resolves the migrations in dir that [UpContext] would apply, without applying them.
+// If a policy is set with [WithOptionPolicy], it is evaluated against the pending migrations.
+func CreatePlan(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFunc) (*Plan, error) {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.noVersioning {
+		return nil, errors.New("plan requires versioning: applied migrations must be tracked in the version table")
+	}
+	foundMigrations, err := collectMigrations(option, dir, minVersion, maxVersion)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+		return nil, err
+	}
+	dbMigrations, err := listAllDBVersions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := createPlan(ctx, option, getBaseFS(), foundMigrations, dbMigrations)
+	if err != nil {
+		return nil, err
+	}
+	// Fail when planning, rather than part way through applying the plan, if a migration uses
+	// features the connected server does not support.
+	checker := &dbVersionChecker{db: db, dialect: getDialect()}
+	for _, m := range plan.Migrations {
+		if err := checker.checkFile(ctx, getBaseFS(), m.Source); err != nil {
+			return nil, err
+		}
+	}
+	return plan, nil
+}
+
+// PlanFS resolves the migrations in dir of fsys that [UpContext] would apply to a database with the
+// applied versions, without a database. The plan is the plan [CreatePlan] would create, and can be
+// applied with [ApplyPlan] as long as the applied versions are those of the database.
+//
+// PlanFS does not use database/sql drivers, so it compiles for GOOS=js and lets web-based review
+// tools plan migrations client-side, see [ParseSQL] to inspect their statements.
+func PlanFS(ctx context.Context, fsys fs.FS, dir string, applied []int64, opts ...OptionsFunc) (*Plan, error) {
+	if fsys == nil {
+		return nil, errors.New("fsys must not be nil")
+	}
+	fsys = decompressing(fsys)
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	foundMigrations, err := collectMigrationsCached(option.collectCache, option.scope, fsys, dir, minVersion, maxVersion, globalMigrationsSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	return createPlan(ctx, option, fsys, foundMigrations, appliedMigrations(applied))
+}
+
+// Pending returns the migrations in dir of fsys that [UpContext] would apply to a database with the
+// applied versions, in order, without connecting to a database. The applied versions are supplied
+// externally, e.g., exported from the version table, so offline tooling and air-gapped review
+// processes can compute what a deploy would run. Like [UpContext], it returns an error if a
+// migration older than the latest applied version is not applied, unless [WithAllowMissing] is
+// set.
+func Pending(applied []int64, fsys fs.FS, dir string, opts ...OptionsFunc) (Migrations, error) {
+	if fsys == nil {
+		return nil, errors.New("fsys must not be nil")
+	}
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	foundMigrations, err := collectMigrationsCached(option.collectCache, option.scope, decompressing(fsys), dir, minVersion, maxVersion, globalMigrationsSnapshot())
+	if err != nil {
+		return nil, err
+	}
+	return pendingUpMigrations(appliedMigrations(applied), foundMigrations, maxVersion, option.allowMissing)
+}
+
+// appliedMigrations returns the applied versions as the migrations of a version table, in
+// ascending order, see listAllDBVersions. The version table always has the initial version 0, see
+// EnsureDBVersionContext.
+func appliedMigrations(applied []int64) Migrations {
+	dbMigrations := Migrations{{Version: 0}}
+	seen := map[int64]bool{0: true}
+	for _, v := range applied {
+		if !seen[v] {
+			seen[v] = true
+			dbMigrations = append(dbMigrations, &Migration{Version: v})
+		}
+	}
+	sort.SliceStable(dbMigrations, func(i, j int) bool { return dbMigrations[i].Version < dbMigrations[j].Version })
+	return dbMigrations
+}
+
+// createPlan returns the plan of the found migrations in fsys that are not applied, dbMigrations
+// being the applied versions in ascending order.
+func createPlan(ctx context.Context, option *options, fsys fs.FS, foundMigrations, dbMigrations Migrations) (*Plan, error) {
+	pending, err := pendingUpMigrations(dbMigrations, foundMigrations, maxVersion, option.allowMissing)
+	if err != nil {
+		return nil, err
+	}
+	if option.policy != nil {
+		migrations := make([]policy.Migration, 0, len(pending))
+		for _, m := range pending {
+			pm, err := policyMigration(fsys, m, option.scope)
+			if err != nil {
+				return nil, err
+			}
+			migrations = append(migrations, pm)
+		}
+		if err := evaluatePolicy(ctx, option.policy, migrations); err != nil {
+			return nil, err
+		}
+	}
+	plan := &Plan{
+		CreatedAt:       time.Now().UTC(),
+		DatabaseVersion: dbMigrations[len(dbMigrations)-1].Version,
+		State:           planState(dbMigrations),
+		Migrations:      make([]PlannedMigration, 0, len(pending)),
+	}
+	for _, m := range pending {
+		sum, err := fileChecksum(fsys, m)
+		if err != nil {
+			return nil, err
+		}
+		a, err := option.collectCache.readAnnotations(fsys, m.Source)
+		if err != nil {
+			return nil, err
+		}
+		a = a.resolve(m)
+		plan.Migrations = append(plan.Migrations, PlannedMigration{
+			Version:  m.Version,
+			Source:   m.Source,
+			Title:    a.title,
+			Owner:    a.owner,
+			Checksum: sum,
+			Hints:    a.hints,
+		})
+	}
+	return plan, nil
+}
+
+// PlanHash returns a hash of the migrations in dir that [UpContext] would apply, see [Plan.Hash].
+// Deploy tooling can compare the hash computed in staging with the hash computed in production to
+// assert that exactly the tested migrations are about to run.
+func PlanHash(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFunc) (string, error) {
+	plan, err := CreatePlan(ctx, db, dir, opts...)
+	if err != nil {
+		return "", err
+	}
+	return plan.Hash(), nil
+}
+
+// Hash returns a stable hash over the versions and checksums of the planned migrations, in order.
+// It does not depend on when or where the plan was created, nor on the paths of the migration
+// files, so the same pending migrations hash the same on every machine. Go migrations without an
+// embedded checksum only contribute their version, see [WithChecksum].
+func (p *Plan) Hash() string {
+	return planHash(p.Migrations)
+}
+
+// ApplyPlan applies the migrations of a plan created by [CreatePlan], in order.
+//
+// Before any migration is applied, the plan is checked against the database and dir. If other
+// migrations were applied or rolled back since the plan was created, or a planned migration is
+// missing or was modified, ApplyPlan returns an error wrapping [ErrPlanStale] and nothing is run.
+func ApplyPlan(ctx context.Context, db *sql.DB, dir string, plan *Plan, opts ...OptionsFunc) error {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.noVersioning {
+		return errors.New("plan requires versioning: applied migrations must be tracked in the version table")
+	}
+	if err := checkRunLabels(option.runLabels); err != nil {
+		return err
+	}
+	runLabels, err := option.labelProtection.protect(ctx, option.runLabels)
+	if err != nil {
+		return err
+	}
+	foundMigrations, err := collectMigrations(option, dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+		return err
+	}
+	dbMigrations, err := listAllDBVersions(ctx, db)
+	if err != nil {
+		return err
+	}
+	if state := planState(dbMigrations); state != plan.State {
+		return fmt.Errorf("%w: database changed since the plan was created at version %d, current version %d",
+			ErrPlanStale, plan.DatabaseVersion, dbMigrations[len(dbMigrations)-1].Version)
+	}
+	apply := make(Migrations, 0, len(plan.Migrations))
+	for _, planned := range plan.Migrations {
+		m, err := foundMigrations.Current(planned.Version)
+		if err != nil {
+			return fmt.Errorf("%w: planned migration %d (%s) not found", ErrPlanStale, planned.Version, planned.Source)
+		}
+		sum, err := legacyChecksum(m)
+		if err != nil {
+			return err
+		}
+		if filepath.Base(m.Source) != filepath.Base(planned.Source) || sum != planned.Checksum {
+			return fmt.Errorf("%w: planned migration %d (%s) was modified", ErrPlanStale, planned.Version, planned.Source)
+		}
+		apply = append(apply, m)
+	}
+	if len(apply) == 0 {
+		log.Printf("goose: no migrations to run. current version: %d\n", plan.DatabaseVersion)
+		return nil
+	}
+	for _, m := range apply {
+		if err := m.UpContext(ctx, db); err != nil {
+			if errors.Is(err, ErrPreconditionSkipped) {
+				return preconditionSkipError(m, err)
+			}
+			return err
+		}
+		if len(runLabels) > 0 {
+			if err := recordRunLabels(ctx, db, m.Version, runLabels); err != nil {
+				return fmt.Errorf("failed to record run labels for version %d: %w", m.Version, err)
+			}
+		}
+	}
+	log.Printf("goose: successfully migrated database to version: %d\n", apply[len(apply)-1].Version)
+	return nil
+}
+
+// planState returns a fingerprint of the applied versions, which must be sorted.
+func planState(dbMigrations Migrations) string {
+	h := sha256.New()
+	for _, m := range dbMigrations {
+		h.Write(strconv.AppendInt(nil, m.Version, 10))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// planHash returns a hash of the versions and checksums of migrations, in order.
+func planHash(migrations []PlannedMigration) string {
+	h := sha256.New()
+	for _, m := range migrations {
+		h.Write(strconv.AppendInt(nil, m.Version, 10))
+		h.Write([]byte{'\n'})
+		h.Write([]byte(m.Checksum))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// legacyChecksum returns the checksum of a migration collected from the base filesystem.
+func legacyChecksum(m *Migration) (string, error) {
+	return fileChecksum(getBaseFS(), m)
+}
+
+// fileChecksum returns the checksum of a migration collected from fsys.
+func fileChecksum(fsys fs.FS, m *Migration) (string, error) {
+	if !isSQLFile(m.Source) {
+		return m.Checksum, nil
+	}
+	data, err := fs.ReadFile(decompressing(fsys), m.Source)
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration %s: %w", filepath.Base(m.Source), err)
+	}
+	return sqlChecksum(data), nil
+}
@@ -0,0 +1,180 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestPlan(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_plan.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	migrationsDir := filepath.Join(dir, "migrations")
+	require.NoError(t, os.MkdirAll(migrationsDir, 0755))
+	writeMigration := func(name, table string) {
+		data := []byte("-- +goose Up\nCREATE TABLE " + table + " (id INTEGER);\n-- +goose Down\nDROP TABLE " + table + ";\n")
+		require.NoError(t, os.WriteFile(filepath.Join(migrationsDir, name), data, 0644))
+	}
+	writeMigration("00001_a.sql", "a")
+	writeMigration("00002_b.sql", "b")
+	writeMigration("00003_c.sql", "c")
+	require.NoError(t, os.WriteFile(filepath.Join(migrationsDir, "00002_b.sql"),
+		[]byte("-- +goose title Add b\n-- +goose owner b-team\n-- +goose affects b\n-- +goose impact light\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n"), 0644))
+	require.NoError(t, goose.UpTo(db, migrationsDir, 1))
+
+	plan, err := goose.CreatePlan(ctx, db, migrationsDir)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, plan.DatabaseVersion)
+	require.Len(t, plan.Migrations, 2)
+	require.EqualValues(t, 2, plan.Migrations[0].Version)
+	require.EqualValues(t, 3, plan.Migrations[1].Version)
+	require.NotEmpty(t, plan.Migrations[0].Checksum)
+	require.Equal(t, &goose.Hints{Affects: []string{"b"}, Impact: goose.ImpactLight}, plan.Migrations[0].Hints)
+	require.Nil(t, plan.Migrations[1].Hints)
+	require.Equal(t, "Add b", plan.Migrations[0].Title)
+	require.Equal(t, "b-team", plan.Migrations[0].Owner)
+	require.Empty(t, plan.Migrations[1].Title)
+
+	// Plans survive a JSON round trip.
+	data, err := json.Marshal(plan)
+	require.NoError(t, err)
+	var decoded goose.Plan
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	t.Run("requires_db", func(t *testing.T) {
+		data := []byte("-- +goose requires-db sqlite >= 99\n-- +goose Up\nSELECT 1;\n")
+		path := filepath.Join(migrationsDir, "00004_d.sql")
+		require.NoError(t, os.WriteFile(path, data, 0644))
+		defer os.Remove(path)
+		_, err := goose.CreatePlan(ctx, db, migrationsDir)
+		require.ErrorIs(t, err, goose.ErrDBRequirement)
+		require.ErrorContains(t, err, "migration 00004_d.sql")
+	})
+	t.Run("hash", func(t *testing.T) {
+		hash, err := goose.PlanHash(ctx, db, migrationsDir)
+		require.NoError(t, err)
+		require.Equal(t, plan.Hash(), hash)
+		require.Equal(t, plan.Hash(), decoded.Hash())
+		// The provider hashes the same pending migrations the same.
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, os.DirFS(migrationsDir))
+		require.NoError(t, err)
+		providerHash, err := p.PlanHash(ctx)
+		require.NoError(t, err)
+		require.Equal(t, hash, providerHash)
+		// Any change to a pending migration changes the hash.
+		writeMigration("00003_c.sql", "c2")
+		t.Cleanup(func() { writeMigration("00003_c.sql", "c") })
+		modified, err := goose.PlanHash(ctx, db, migrationsDir)
+		require.NoError(t, err)
+		require.NotEqual(t, hash, modified)
+	})
+	t.Run("offline", func(t *testing.T) {
+		// Planning from the applied versions creates the same plan as planning against the database.
+		offline, err := goose.PlanFS(ctx, os.DirFS(migrationsDir), ".", []int64{1})
+		require.NoError(t, err)
+		require.Equal(t, plan.State, offline.State)
+		require.Equal(t, plan.DatabaseVersion, offline.DatabaseVersion)
+		require.Equal(t, plan.Hash(), offline.Hash())
+		require.Equal(t, "00002_b.sql", offline.Migrations[0].Source)
+		require.Equal(t, "Add b", offline.Migrations[0].Title)
+
+		offline, err = goose.PlanFS(ctx, os.DirFS(migrationsDir), ".", []int64{3, 1, 2})
+		require.NoError(t, err)
+		require.EqualValues(t, 3, offline.DatabaseVersion)
+		require.Empty(t, offline.Migrations)
+		_, err = goose.PlanFS(ctx, os.DirFS(migrationsDir), ".", []int64{1, 3})
+		require.ErrorContains(t, err, "missing migrations")
+	})
+	t.Run("run", func(t *testing.T) {
+		var out strings.Builder
+		goose.SetOutput(&out)
+		t.Cleanup(func() { goose.SetOutput(os.Stdout) })
+		require.NoError(t, goose.RunContext(ctx, "plan", db, migrationsDir))
+		var written goose.Plan
+		require.NoError(t, json.Unmarshal([]byte(out.String()), &written))
+		require.Equal(t, plan.Hash(), written.Hash())
+
+		for _, args := range [][]string{{"-o", "plan.json"}, {"-o=plan.json"}, {"plan.json"}} {
+			file := filepath.Join(t.TempDir(), "plan.json")
+			args[len(args)-1] = strings.Replace(args[len(args)-1], "plan.json", file, 1)
+			require.NoError(t, goose.RunContext(ctx, "plan", db, migrationsDir, args...))
+			data, err := os.ReadFile(file)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(data, &written))
+			require.Equal(t, plan.Hash(), written.Hash())
+		}
+	})
+	t.Run("modified_migration", func(t *testing.T) {
+		writeMigration("00003_c.sql", "c2")
+		t.Cleanup(func() { writeMigration("00003_c.sql", "c") })
+		require.ErrorIs(t, goose.ApplyPlan(ctx, db, migrationsDir, &decoded), goose.ErrPlanStale)
+		ver, err := goose.GetDBVersion(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, ver)
+	})
+
+	// A migration added after planning is not part of the plan.
+	writeMigration("00004_d.sql", "d")
+	require.NoError(t, goose.ApplyPlan(ctx, db, migrationsDir, &decoded))
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, ver)
+
+	// Applying the plan changed the database, so it cannot be applied again.
+	require.ErrorIs(t, goose.ApplyPlan(ctx, db, migrationsDir, &decoded), goose.ErrPlanStale)
+}
+
+func TestPendingApplied(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"migrations/00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"migrations/00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+		"migrations/00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n"),
+		"migrations/00004_d.sql": newMapFile("-- +goose Up\nCREATE TABLE d (id INTEGER);\n"),
+	}
+	versions := func(migrations goose.Migrations) []int64 {
+		var out []int64
+		for _, m := range migrations {
+			out = append(out, m.Version)
+		}
+		return out
+	}
+	pending, err := goose.Pending(nil, fsys, "migrations")
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 3, 4}, versions(pending))
+	pending, err = goose.Pending([]int64{2, 1}, fsys, "migrations")
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 4}, versions(pending))
+	require.Equal(t, "migrations/00003_c.sql", pending[0].Source)
+	pending, err = goose.Pending([]int64{1, 2, 3, 4}, fsys, "migrations")
+	require.NoError(t, err)
+	require.Empty(t, pending)
+
+	// Versions missing before the latest applied version are an error, or pending with
+	// WithAllowMissing.
+	_, err = goose.Pending([]int64{1, 3}, fsys, "migrations")
+	require.ErrorContains(t, err, "found 1 missing migrations")
+	pending, err = goose.Pending([]int64{1, 3}, fsys, "migrations", goose.WithAllowMissing())
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 4}, versions(pending))
+
+	_, err = goose.Pending(nil, fsys, "missing")
+	require.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"github.com/pressly/goose/v3/policy"
+)
+
+// ErrPolicyViolation is returned when migrations break the rules of a policy, see [EvaluatePolicy]
+// and [WithOptionPolicy]. The returned error is a [PolicyError].
+var ErrPolicyViolation = errors.New("policy violation")
+
+// PolicyError is returned when migrations break the rules of a policy, see [ErrPolicyViolation].
+type PolicyError struct {
+	// Violations are the broken rules, at least one.
+	Violations []policy.Violation
+}
+
+func (e *PolicyError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, v.String())
+	}
+	return fmt.Sprintf("%v: %s", ErrPolicyViolation, strings.Join(violations, "; "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// WithOptionPolicy evaluates p against the migrations [CreatePlan] would apply. If a rule is
+// broken, no plan is created and a [PolicyError] is returned.
+func WithOptionPolicy(p policy.Policy) OptionsFunc {
+	return func(o *options) { o.policy = p }
+}
+
+// EvaluatePolicy evaluates p against the migration files in fsys, without a database, e.g., to
+// enforce organizational rules in CI before migrations are merged. Migrations are collected from
+// the root of fsys and its subdirectories, except [ArchiveDir] directories, and the scope of a
+// migration is the subdirectory it lives in, like [GenerateChangelog]. Go migrations are evaluated
+// with their transaction mode if they are registered globally in their scope, and run in a
+// transaction otherwise.
+//
+// If a rule is broken, a [PolicyError] is returned.
+func EvaluatePolicy(ctx context.Context, fsys fs.FS, p policy.Policy) error {
+	if fsys == nil {
+		return errors.New("fsys must not be nil")
+	}
+	if p == nil {
+		return errors.New("policy must not be nil")
+	}
+	var files []string
+	for _, pattern := range append(sqlFilePatterns, "*.go") {
+		matches, err := globFilesystem(fsys, pattern, true)
+		if err != nil {
+			return fmt.Errorf("failed to glob pattern %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	var migrations []policy.Migration
+	for _, file := range files {
+		base := path.Base(file)
+		if strings.HasSuffix(base, "_test.go") || strings.HasPrefix(base, repeatablePrefix) {
+			continue
+		}
+		version, err := NumericComponent(base)
+		if err != nil {
+			continue
+		}
+		scope, _, nested := strings.Cut(file, "/")
+		if !nested {
+			scope = ""
+		}
+		m := &Migration{Version: version, Source: file, UseTx: true}
+		if !isSQLFile(base) {
+			registryMu.RLock()
+			if registered, ok := registeredGoMigrations[scope][version]; ok {
+				m.UseTx = registered.UseTx
+			}
+			registryMu.RUnlock()
+		}
+		pm, err := policyMigration(fsys, m, scope)
+		if err != nil {
+			return err
+		}
+		migrations = append(migrations, pm)
+	}
+	sort.SliceStable(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return evaluatePolicy(ctx, p, migrations)
+}
+
+// evaluatePolicy returns a PolicyError if the migrations break a rule of p.
+func evaluatePolicy(ctx context.Context, p policy.Policy, migrations []policy.Migration) error {
+	violations, err := p.Evaluate(ctx, migrations)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// policyMigration describes m, whose source is read from fsys if it is a SQL migration, to a
+// policy.
+func policyMigration(fsys fs.FS, m *Migration, scope string) (policy.Migration, error) {
+	pm := policy.Migration{
+		Version:    m.Version,
+		Source:     m.Source,
+		Scope:      scope,
+		Type:       string(TypeGo),
+		UseTx:      m.UseTx,
+		Statements: []string{},
+		Directives: map[string]string{},
+	}
+	if !isSQLFile(m.Source) {
+		return pm, nil
+	}
+	pm.Type = string(TypeSQL)
+	data, err := fs.ReadFile(decompressing(fsys), m.Source)
+	if err != nil {
+		return pm, fmt.Errorf("failed to read migration %s: %w", filepath.Base(m.Source), err)
+	}
+	statements, useTx, err := sqlparser.ParseSQLMigration(bytes.NewReader(data), sqlparser.DirectionUp, false)
+	if err != nil {
+		return pm, fmt.Errorf("failed to parse migration %s: %w", filepath.Base(m.Source), err)
+	}
+	directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+	if err != nil {
+		return pm, fmt.Errorf("failed to parse migration %s: %w", filepath.Base(m.Source), err)
+	}
+	pm.UseTx = useTx
+	pm.Statements = append(pm.Statements, statements...)
+	for _, d := range directives {
+		pm.Directives[d.Name] = d.Value
+	}
+	return pm, nil
+}
@@ -0,0 +1,150 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultOPAQuery is the query evaluated by the OPA Policy, unless set with [WithQuery].
+const DefaultOPAQuery = "data.goose.deny"
+
+// NewOPA returns a Policy that evaluates the Rego policies at path, a file or a directory, with
+// the opa command of Open Policy Agent. The input document is {"migrations": [...]}, a list of
+// [Migration], and the query, [DefaultOPAQuery] by default, must evaluate to a set of violations:
+// strings, which become the message of a violation, or objects with the fields of [Violation]. For
+// example:
+//
+//	package goose
+//
+//	import rego.v1
+//
+//	deny contains v if {
+//		some m in input.migrations
+//		m.scope == "prod"
+//		some stmt in m.statements
+//		startswith(upper(trim_space(stmt)), "DROP")
+//		v := {"rule": "no-drop-in-prod", "message": stmt, "version": m.version, "source": m.source}
+//	}
+//
+// An undefined query result means no violations.
+func NewOPA(path string, opts ...OPAOption) (Policy, error) {
+	if path == "" {
+		return nil, errors.New("policy path must not be empty")
+	}
+	o := &opa{name: "opa", path: path, query: DefaultOPAQuery}
+	for _, opt := range opts {
+		if err := opt.apply(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// OPAOption is used to configure the OPA Policy.
+type OPAOption interface {
+	apply(*opa) error
+}
+
+// WithQuery sets the query evaluated by the OPA Policy, e.g., "data.platform.migrations.deny".
+func WithQuery(query string) OPAOption {
+	return opaFunc(func(o *opa) error {
+		if query == "" {
+			return errors.New("query must not be empty")
+		}
+		o.query = query
+		return nil
+	})
+}
+
+// WithCommand sets the path of the opa command, if it is not found in the PATH.
+func WithCommand(path string) OPAOption {
+	return opaFunc(func(o *opa) error {
+		if path == "" {
+			return errors.New("command path must not be empty")
+		}
+		o.name = path
+		return nil
+	})
+}
+
+var _ OPAOption = (opaFunc)(nil)
+
+type opaFunc func(*opa) error
+
+func (f opaFunc) apply(o *opa) error {
+	return f(o)
+}
+
+// opa is a Policy that runs opa eval.
+type opa struct {
+	name  string
+	path  string
+	query string
+}
+
+func (o *opa) Evaluate(ctx context.Context, migrations []Migration) ([]Violation, error) {
+	if migrations == nil {
+		migrations = []Migration{}
+	}
+	input, err := json.Marshal(map[string]any{"migrations": migrations})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, o.name, "eval", "--format=json", "--stdin-input", "--data", o.path, o.query)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String() + stdout.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", filepath.Base(o.name), err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", filepath.Base(o.name), err)
+	}
+	return decodeOPAResult(stdout.Bytes(), o.query)
+}
+
+// decodeOPAResult returns the violations in the output of opa eval. Violations given as strings
+// are named after the query.
+func decodeOPAResult(data []byte, query string) ([]Violation, error) {
+	var out struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode opa result: %w", err)
+	}
+	var violations []Violation
+	for _, r := range out.Result {
+		for _, e := range r.Expressions {
+			var values []json.RawMessage
+			if err := json.Unmarshal(e.Value, &values); err != nil {
+				return nil, fmt.Errorf("query %s must evaluate to a set of violations: %w", query, err)
+			}
+			for _, value := range values {
+				var msg string
+				if err := json.Unmarshal(value, &msg); err == nil {
+					violations = append(violations, Violation{Rule: query, Message: msg})
+					continue
+				}
+				var v Violation
+				if err := json.Unmarshal(value, &v); err != nil {
+					return nil, fmt.Errorf("invalid violation %s: %w", value, err)
+				}
+				if v.Rule == "" {
+					v.Rule = query
+				}
+				violations = append(violations, v)
+			}
+		}
+	}
+	return violations, nil
+}
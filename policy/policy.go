@@ -0,0 +1,225 @@
+// Package policy defines the Policy interface for organizational migration rules, evaluated when
+// migrations are planned or validated, and implements policies with Go functions and Open Policy
+// Agent.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// Migration describes a migration evaluated by a [Policy].
+type Migration struct {
+	Version int64 `json:"version"`
+	// Source is the path of the migration file.
+	Source string `json:"source"`
+	// Scope is the scope of the migration, or empty for the root scope.
+	Scope string `json:"scope"`
+	// Type is either "sql" or "go".
+	Type string `json:"type"`
+	// UseTx is false if the migration runs without a transaction, e.g., a SQL migration annotated
+	// with NO TRANSACTION.
+	UseTx bool `json:"use_tx"`
+	// Statements are the Up statements of a SQL migration, in order. Empty for Go migrations.
+	Statements []string `json:"statements"`
+	// Directives are the goose directives of a SQL migration, by name, e.g., "impact": "heavy". A
+	// directive without a value maps to an empty string.
+	Directives map[string]string `json:"directives"`
+}
+
+// Violation is a broken rule.
+type Violation struct {
+	// Rule is the name of the broken rule, e.g., "no-drop-in-prod".
+	Rule string `json:"rule"`
+	// Message describes the violation.
+	Message string `json:"message"`
+	// Version and Source identify the migration breaking the rule. They may be empty for rules
+	// about the migrations as a whole.
+	Version int64  `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// String returns the violation as "SOURCE: RULE: MESSAGE", for example:
+//
+//	prod/00003_drop_users.sql: no-drop-in-prod: DROP TABLE users;
+func (v Violation) String() string {
+	var b strings.Builder
+	if v.Source != "" {
+		b.WriteString(v.Source + ": ")
+	}
+	if v.Rule != "" {
+		b.WriteString(v.Rule + ": ")
+	}
+	b.WriteString(v.Message)
+	return b.String()
+}
+
+// Policy evaluates organizational rules against migrations, e.g., to forbid DROP statements in the
+// prod scope.
+type Policy interface {
+	// Evaluate returns the violations of the migrations, which are ordered by version. An error
+	// means the policy itself failed, not that a rule was broken.
+	Evaluate(ctx context.Context, migrations []Migration) ([]Violation, error)
+}
+
+// Func adapts a function to a [Policy].
+type Func func(ctx context.Context, migrations []Migration) ([]Violation, error)
+
+// Evaluate calls f.
+func (f Func) Evaluate(ctx context.Context, migrations []Migration) ([]Violation, error) {
+	return f(ctx, migrations)
+}
+
+// Rule returns a Policy named name that checks each migration with check. check returns a message
+// describing the violation, or an empty string if the migration follows the rule. For example, to
+// require backfills to run without a transaction:
+//
+//	policy.Rule("backfill-no-tx", func(m policy.Migration) string {
+//		if m.UseTx && m.Directives["impact"] == "heavy" {
+//			return "heavy migrations must run with NO TRANSACTION"
+//		}
+//		return ""
+//	})
+func Rule(name string, check func(m Migration) string) Policy {
+	return Func(func(_ context.Context, migrations []Migration) ([]Violation, error) {
+		var violations []Violation
+		for _, m := range migrations {
+			if msg := check(m); msg != "" {
+				violations = append(violations, Violation{Rule: name, Message: msg, Version: m.Version, Source: m.Source})
+			}
+		}
+		return violations, nil
+	})
+}
+
+// DenyStatements returns a Policy named name that rejects every statement matching re, in the
+// given scopes, or in all scopes if none are given. Use "" for the root scope. For example:
+//
+//	policy.DenyStatements("no-drop-in-prod", regexp.MustCompile(`(?i)^\s*DROP\b`), "prod")
+//
+// Statements are matched without leading comments.
+func DenyStatements(name string, re *regexp.Regexp, scopes ...string) Policy {
+	return Func(func(_ context.Context, migrations []Migration) ([]Violation, error) {
+		var violations []Violation
+		for _, m := range migrations {
+			if !inScopes(m.Scope, scopes) {
+				continue
+			}
+			for _, stmt := range m.Statements {
+				stmt = trimLeadingComments(stmt)
+				if re.MatchString(stmt) {
+					violations = append(violations, Violation{
+						Rule:    name,
+						Message: fmt.Sprintf("statement not allowed: %s", firstLine(stmt)),
+						Version: m.Version,
+						Source:  m.Source,
+					})
+				}
+			}
+		}
+		return violations, nil
+	})
+}
+
+// RequireTableCharset returns a Policy named name that requires every MySQL CREATE TABLE statement
+// to set charset and collation in its table options, in the given scopes, or in all scopes if none
+// are given. Character sets and collations set on columns must match as well. An empty charset or
+// collation is not checked. For example, to keep utf8mb3 tables from slipping in:
+//
+//	policy.RequireTableCharset("utf8mb4-only", "utf8mb4", "utf8mb4_0900_ai_ci")
+//
+// CREATE TABLE ... LIKE statements, which copy another table, are not checked.
+func RequireTableCharset(name, charset, collation string, scopes ...string) Policy {
+	return Func(func(_ context.Context, migrations []Migration) ([]Violation, error) {
+		var violations []Violation
+		for _, m := range migrations {
+			if !inScopes(m.Scope, scopes) {
+				continue
+			}
+			for _, stmt := range m.Statements {
+				tc, ok := sqlparser.ParseTableCharset(stmt)
+				if !ok {
+					continue
+				}
+				for _, msg := range charsetViolations(tc, charset, collation) {
+					violations = append(violations, Violation{Rule: name, Message: msg, Version: m.Version, Source: m.Source})
+				}
+			}
+		}
+		return violations, nil
+	})
+}
+
+func charsetViolations(tc sqlparser.TableCharset, charset, collation string) []string {
+	var msgs []string
+	check := func(kind, want, table string, columns []string) {
+		if want == "" {
+			return
+		}
+		switch {
+		case table == "":
+			msgs = append(msgs, fmt.Sprintf("CREATE TABLE %s must set %s %s", tc.Table, kind, want))
+		case !strings.EqualFold(table, want):
+			msgs = append(msgs, fmt.Sprintf("CREATE TABLE %s sets %s %s, expected %s", tc.Table, kind, table, want))
+		}
+		for _, c := range columns {
+			if !strings.EqualFold(c, want) {
+				msgs = append(msgs, fmt.Sprintf("CREATE TABLE %s sets column %s %s, expected %s", tc.Table, kind, c, want))
+			}
+		}
+	}
+	check("CHARSET", charset, tc.Charset, tc.ColumnCharsets)
+	check("COLLATE", collation, tc.Collation, tc.ColumnCollations)
+	return msgs
+}
+
+// All returns a Policy that evaluates every policy and returns all their violations.
+func All(policies ...Policy) Policy {
+	return Func(func(ctx context.Context, migrations []Migration) ([]Violation, error) {
+		var violations []Violation
+		for _, p := range policies {
+			v, err := p.Evaluate(ctx, migrations)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, v...)
+		}
+		return violations, nil
+	})
+}
+
+func inScopes(scope string, scopes []string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// trimLeadingComments returns stmt without the line and block comments before its first keyword.
+func trimLeadingComments(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		switch {
+		case strings.HasPrefix(stmt, "--"):
+			_, stmt, _ = strings.Cut(stmt, "\n")
+		case strings.HasPrefix(stmt, "/*"):
+			_, stmt, _ = strings.Cut(stmt, "*/")
+		default:
+			return stmt
+		}
+	}
+}
+
+func firstLine(stmt string) string {
+	line, _, _ := strings.Cut(stmt, "\n")
+	return line
+}
@@ -0,0 +1,181 @@
+package policy_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/pressly/goose/v3/policy"
+	"github.com/stretchr/testify/require"
+)
+
+var migrations = []policy.Migration{
+	{
+		Version:    1,
+		Source:     "00001_users.sql",
+		Type:       "sql",
+		UseTx:      true,
+		Statements: []string{"CREATE TABLE users (id INTEGER);", "-- legacy\nDROP TABLE accounts;"},
+	},
+	{
+		Version:    2,
+		Source:     "prod/00002_backfill.sql",
+		Scope:      "prod",
+		Type:       "sql",
+		UseTx:      true,
+		Statements: []string{"UPDATE users SET active = true;", "DROP TABLE sessions;"},
+		Directives: map[string]string{"impact": "heavy"},
+	},
+}
+
+func TestDenyStatements(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	drop := regexp.MustCompile(`(?i)^DROP\b`)
+	violations, err := policy.DenyStatements("no-drop-in-prod", drop, "prod").Evaluate(ctx, migrations)
+	require.NoError(t, err)
+	require.Equal(t, []policy.Violation{{
+		Rule:    "no-drop-in-prod",
+		Message: "statement not allowed: DROP TABLE sessions;",
+		Version: 2,
+		Source:  "prod/00002_backfill.sql",
+	}}, violations)
+	require.Equal(t, "prod/00002_backfill.sql: no-drop-in-prod: statement not allowed: DROP TABLE sessions;", violations[0].String())
+
+	// Without scopes, every scope is checked, and leading comments are ignored.
+	violations, err = policy.DenyStatements("no-drop", drop).Evaluate(ctx, migrations)
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+	require.Equal(t, "statement not allowed: DROP TABLE accounts;", violations[0].Message)
+}
+
+func TestRequireTableCharset(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	migrations := []policy.Migration{{
+		Version: 1,
+		Source:  "00001_tables.sql",
+		Statements: []string{
+			"CREATE TABLE ok (id INT) DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci;",
+			"CREATE TABLE missing (id INT) ENGINE=InnoDB;",
+			"CREATE TABLE legacy (name VARCHAR(10) CHARACTER SET utf8mb3) CHARSET=UTF8MB4 COLLATE utf8mb3_general_ci;",
+			"CREATE TABLE copy LIKE ok;",
+		},
+	}}
+	violations, err := policy.RequireTableCharset("utf8mb4-only", "utf8mb4", "utf8mb4_0900_ai_ci").Evaluate(ctx, migrations)
+	require.NoError(t, err)
+	var messages []string
+	for _, v := range violations {
+		require.Equal(t, "utf8mb4-only", v.Rule)
+		require.EqualValues(t, 1, v.Version)
+		messages = append(messages, v.Message)
+	}
+	require.Equal(t, []string{
+		"CREATE TABLE missing must set CHARSET utf8mb4",
+		"CREATE TABLE missing must set COLLATE utf8mb4_0900_ai_ci",
+		"CREATE TABLE legacy sets column CHARSET utf8mb3, expected utf8mb4",
+		"CREATE TABLE legacy sets COLLATE utf8mb3_general_ci, expected utf8mb4_0900_ai_ci",
+	}, messages)
+
+	// An empty collation is not checked.
+	violations, err = policy.RequireTableCharset("utf8mb4-only", "utf8mb4", "").Evaluate(ctx, migrations)
+	require.NoError(t, err)
+	require.Len(t, violations, 2)
+}
+
+func TestRule(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	noTx := policy.Rule("heavy-no-tx", func(m policy.Migration) string {
+		if m.Directives["impact"] == "heavy" && m.UseTx {
+			return "heavy migrations must run with NO TRANSACTION"
+		}
+		return ""
+	})
+	violations, err := noTx.Evaluate(ctx, migrations)
+	require.NoError(t, err)
+	require.Equal(t, []policy.Violation{{
+		Rule:    "heavy-no-tx",
+		Message: "heavy migrations must run with NO TRANSACTION",
+		Version: 2,
+		Source:  "prod/00002_backfill.sql",
+	}}, violations)
+
+	violations, err = policy.All(noTx, policy.DenyStatements("no-drop", regexp.MustCompile(`^DROP`))).Evaluate(ctx, migrations)
+	require.NoError(t, err)
+	require.Len(t, violations, 3)
+	require.Equal(t, "heavy-no-tx", violations[0].Rule)
+	require.Equal(t, "no-drop", violations[2].Rule)
+}
+
+// fakeOPA writes a script that checks its arguments like opa eval, saves its input next to it and
+// prints output.
+func fakeOPA(t *testing.T, output string, status int) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "opa")
+	script := `#!/bin/sh
+[ "$1" = eval ] || exit 2
+cat > "` + filepath.Join(dir, "input.json") + `"
+echo "$@" > "` + filepath.Join(dir, "args") + `"
+echo '` + output + `'
+exit ` + strconv.Itoa(status) + `
+`
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	return path
+}
+
+func TestOPA(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	output := `{"result": [{"expressions": [{"value": ["no backfills on fridays", {"rule": "no-drop-in-prod", "message": "DROP TABLE sessions;", "version": 2, "source": "prod/00002_backfill.sql"}]}]}]}`
+	command := fakeOPA(t, output, 0)
+	p, err := policy.NewOPA("policy.rego", policy.WithCommand(command))
+	require.NoError(t, err)
+	violations, err := p.Evaluate(ctx, migrations)
+	require.NoError(t, err)
+	require.Equal(t, []policy.Violation{
+		{Rule: policy.DefaultOPAQuery, Message: "no backfills on fridays"},
+		{Rule: "no-drop-in-prod", Message: "DROP TABLE sessions;", Version: 2, Source: "prod/00002_backfill.sql"},
+	}, violations)
+	args, err := os.ReadFile(filepath.Join(filepath.Dir(command), "args"))
+	require.NoError(t, err)
+	require.Equal(t, "eval --format=json --stdin-input --data policy.rego data.goose.deny\n", string(args))
+	input, err := os.ReadFile(filepath.Join(filepath.Dir(command), "input.json"))
+	require.NoError(t, err)
+	require.Contains(t, string(input), `"source":"prod/00002_backfill.sql"`)
+	require.Contains(t, string(input), `"directives":{"impact":"heavy"}`)
+
+	t.Run("undefined", func(t *testing.T) {
+		p, err := policy.NewOPA("policy.rego", policy.WithCommand(fakeOPA(t, `{}`, 0)), policy.WithQuery("data.platform.deny"))
+		require.NoError(t, err)
+		violations, err := p.Evaluate(ctx, migrations)
+		require.NoError(t, err)
+		require.Empty(t, violations)
+	})
+	t.Run("failure", func(t *testing.T) {
+		p, err := policy.NewOPA("policy.rego", policy.WithCommand(fakeOPA(t, "rego_parse_error", 1)))
+		require.NoError(t, err)
+		_, err = p.Evaluate(ctx, migrations)
+		require.ErrorContains(t, err, "opa failed")
+		require.ErrorContains(t, err, "rego_parse_error")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		p, err := policy.NewOPA("policy.rego", policy.WithCommand(fakeOPA(t, `{"result": [{"expressions": [{"value": true}]}]}`, 0)))
+		require.NoError(t, err)
+		_, err = p.Evaluate(ctx, migrations)
+		require.ErrorContains(t, err, "must evaluate to a set of violations")
+	})
+
+	_, err = policy.NewOPA("")
+	require.Error(t, err)
+	_, err = policy.NewOPA("policy.rego", policy.WithQuery(""))
+	require.Error(t, err)
+}
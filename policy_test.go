@@ -0,0 +1,106 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/policy"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluatePolicy(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_users.sql":             newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER);\nDROP TABLE accounts;\n"),
+		"prod/00002_drop.sql":         newMapFile("-- +goose Up\nDROP TABLE sessions;\n"),
+		"prod/00003_backfill.sql":     newMapFile("-- +goose impact heavy\n-- +goose Up\nUPDATE users SET active = true;\n"),
+		"prod/00004_backfill.sql":     newMapFile("-- +goose impact heavy\n-- +goose NO TRANSACTION\n-- +goose Up\nUPDATE users SET name = '';\n"),
+		"prod/00005_anything.go":      newMapFile("package migrations\n"),
+		"prod/00005_anything_test.go": newMapFile("package migrations\n"),
+	}
+	var got []policy.Migration
+	capture := policy.Func(func(_ context.Context, migrations []policy.Migration) ([]policy.Violation, error) {
+		got = migrations
+		return nil, nil
+	})
+	require.NoError(t, goose.EvaluatePolicy(ctx, fsys, capture))
+	require.Len(t, got, 5)
+	require.Equal(t, policy.Migration{
+		Version:    1,
+		Source:     "00001_users.sql",
+		Type:       "sql",
+		UseTx:      true,
+		Statements: []string{"CREATE TABLE users (id INTEGER);", "DROP TABLE accounts;"},
+		Directives: map[string]string{},
+	}, got[0])
+	require.Equal(t, "prod", got[1].Scope)
+	require.Equal(t, map[string]string{"impact": "heavy"}, got[2].Directives)
+	require.False(t, got[3].UseTx)
+	require.Equal(t, "go", got[4].Type)
+	require.True(t, got[4].UseTx)
+
+	p := policy.All(
+		policy.DenyStatements("no-drop-in-prod", regexp.MustCompile(`(?i)^DROP\b`), "prod"),
+		policy.Rule("heavy-no-tx", func(m policy.Migration) string {
+			if m.Directives["impact"] == "heavy" && m.UseTx {
+				return "heavy migrations must run with NO TRANSACTION"
+			}
+			return ""
+		}),
+	)
+	err := goose.EvaluatePolicy(ctx, fsys, p)
+	require.ErrorIs(t, err, goose.ErrPolicyViolation)
+	var policyErr *goose.PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Len(t, policyErr.Violations, 2)
+	require.Equal(t, "prod/00002_drop.sql", policyErr.Violations[0].Source)
+	require.Equal(t, "prod/00003_backfill.sql", policyErr.Violations[1].Source)
+	require.EqualError(t, err, "policy violation: prod/00002_drop.sql: no-drop-in-prod: statement not allowed: DROP TABLE sessions;; "+
+		"prod/00003_backfill.sql: heavy-no-tx: heavy migrations must run with NO TRANSACTION")
+
+	failing := policy.Func(func(context.Context, []policy.Migration) ([]policy.Violation, error) {
+		return nil, os.ErrNotExist
+	})
+	err = goose.EvaluatePolicy(ctx, fsys, failing)
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.NotErrorIs(t, err, goose.ErrPolicyViolation)
+}
+
+func TestPlanPolicy(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_plan_policy.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	migrationsDir := filepath.Join(dir, "migrations")
+	require.NoError(t, os.MkdirAll(migrationsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(migrationsDir, "00001_a.sql"),
+		[]byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(migrationsDir, "00002_drop.sql"),
+		[]byte("-- +goose Up\nDROP TABLE a;\n"), 0644))
+	noDrop := policy.DenyStatements("no-drop", regexp.MustCompile(`(?i)^DROP\b`))
+
+	_, err = goose.CreatePlan(ctx, db, migrationsDir, goose.WithOptionPolicy(noDrop))
+	var policyErr *goose.PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	require.Len(t, policyErr.Violations, 1)
+	require.EqualValues(t, 2, policyErr.Violations[0].Version)
+
+	// Applied migrations are not evaluated.
+	require.NoError(t, goose.UpTo(db, migrationsDir, 2))
+	plan, err := goose.CreatePlan(ctx, db, migrationsDir, goose.WithOptionPolicy(noDrop))
+	require.NoError(t, err)
+	require.Empty(t, plan.Migrations)
+}
@@ -10,6 +10,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pressly/goose/v3/database"
 	"github.com/pressly/goose/v3/internal/controller"
@@ -25,8 +26,15 @@ type Provider struct {
 	mu sync.Mutex
 
 	db               *sql.DB
+	dialect          Dialect // empty when using a custom store implementation
 	store            *controller.StoreController
 	versionTableOnce sync.Once
+	metadata         bool // whether the metadata table is kept in sync, guarded by mu
+	// backups are the backups taken in the current run by version, guarded by mu.
+	backups map[int64]string
+	// snapshots are the table snapshots taken in the current run by version and table, guarded
+	// by mu.
+	snapshots map[int64]map[string]string
 
 	fsys fs.FS
 	cfg  config
@@ -34,6 +42,12 @@ type Provider struct {
 	// migrations are ordered by version in ascending order. This list will never be empty and
 	// contains all migrations known to the provider.
 	migrations []*Migration
+	// repeatables are unversioned migrations that run whenever their contents change, ordered by
+	// path. This list is empty unless WithRepeatable or WithRoutinesDir is set.
+	repeatables []*Migration
+	// excluded are the migration files left out with WithExcludeNames or WithExcludeVersions,
+	// ordered by version.
+	excluded []*excludedSource
 }
 
 // NewProvider returns a new goose provider.
@@ -41,8 +55,9 @@ type Provider struct {
 // The caller is responsible for matching the database dialect with the database/sql driver. For
 // example, if the database dialect is "postgres", the database/sql driver could be
 // github.com/lib/pq or github.com/jackc/pgx. Each dialect has a corresponding [database.Dialect]
-// constant backed by a default [database.Store] implementation. For more advanced use cases, such
-// as using a custom table name or supplying a custom store implementation, see [WithStore].
+// constant backed by a default [database.Store] implementation. To use a custom table name, see
+// [WithTableName]. For more advanced use cases, such as supplying a custom store implementation,
+// see [WithStore].
 //
 // fsys is the filesystem used to read migration files, but may be nil. Most users will want to use
 // [os.DirFS], os.DirFS("path/to/migrations"), to read migrations from the local filesystem.
@@ -78,10 +93,77 @@ func NewProvider(dialect Dialect, db *sql.DB, fsys fs.FS, opts ...ProviderOption
 	if dialect != "" && cfg.store != nil {
 		return nil, errors.New("dialect must be empty when using a custom store implementation")
 	}
+	if (cfg.repeatable || cfg.routinesDir != "") && cfg.disableVersioning {
+		return nil, errors.New("repeatable migrations require versioning")
+	}
+	if len(cfg.searchPath) > 0 && dialect != DialectPostgres && dialect != DialectRedshift {
+		return nil, fmt.Errorf("search path requires the %s or %s dialect", DialectPostgres, DialectRedshift)
+	}
+	if cfg.applicationName != "" && dialect != DialectPostgres && dialect != DialectRedshift {
+		return nil, fmt.Errorf("application name requires the %s or %s dialect", DialectPostgres, DialectRedshift)
+	}
+	if cfg.sessionTag && dialect != DialectPostgres && dialect != DialectRedshift {
+		return nil, fmt.Errorf("session tag requires the %s or %s dialect", DialectPostgres, DialectRedshift)
+	}
+	if cfg.explainDML && dialect != DialectPostgres && dialect != DialectMySQL {
+		return nil, fmt.Errorf("explaining statements requires the %s or %s dialect", DialectPostgres, DialectMySQL)
+	}
+	if (cfg.tableCharset != "" || cfg.tableCollation != "") && dialect != DialectMySQL && dialect != DialectTiDB {
+		return nil, fmt.Errorf("table charset requires the %s or %s dialect", DialectMySQL, DialectTiDB)
+	}
+	if cfg.concurrentIndexes && dialect != "" && dialect != DialectPostgres {
+		return nil, fmt.Errorf("concurrent indexes require the %s dialect", DialectPostgres)
+	}
+	if cfg.lockDiagnostics > 0 && dialect != "" && dialect != DialectPostgres && dialect != DialectMySQL {
+		return nil, fmt.Errorf("lock diagnostics require the %s or %s dialect", DialectPostgres, DialectMySQL)
+	}
+	if cfg.lockTimeout > 0 && dialect != "" && dialect != DialectPostgres {
+		return nil, fmt.Errorf("lock retry requires the %s dialect", DialectPostgres)
+	}
+	if cfg.explainWarnOnly && !cfg.explainDML {
+		return nil, errors.New("warning about full table scans requires WithExplainDML")
+	}
+	if cfg.waitForWindow && cfg.window == nil {
+		return nil, errors.New("waiting for a maintenance window requires WithWindow")
+	}
+	if cfg.strictOrdering && cfg.allowMissing {
+		return nil, errors.New("strict ordering and allow out-of-order are mutually exclusive")
+	}
+	if cfg.strictOrdering && (cfg.includeVersions != nil || cfg.skipVersions != nil) {
+		return nil, errors.New("strict ordering and include or skip versions are mutually exclusive")
+	}
+	for version := range cfg.includeVersions {
+		if cfg.skipVersions[version] {
+			return nil, fmt.Errorf("version %d cannot be both included and skipped", version)
+		}
+	}
+	if cfg.shadowDB == db {
+		return nil, errors.New("shadow database must not be the target database")
+	}
+	if cfg.shadowDB != nil && cfg.shadowDSN != "" {
+		return nil, errors.New("shadow database and shadow connection string are mutually exclusive")
+	}
+	if _, auto := parseShadowAuto(cfg.shadowDSN); auto {
+		if dialect != DialectPostgres {
+			return nil, fmt.Errorf("creating the shadow database requires the %s dialect", DialectPostgres)
+		}
+		if cfg.shadowServer == "" {
+			return nil, errors.New("creating the shadow database requires WithShadowServer")
+		}
+	} else if cfg.shadowServer != "" {
+		return nil, fmt.Errorf("shadow server requires WithShadow(%q)", ShadowAuto)
+	}
+	if cfg.store != nil && cfg.tableName != "" {
+		return nil, errors.New("table name must be set on the custom store implementation")
+	}
 	var store database.Store
 	if dialect != "" {
+		tablename := DefaultTablename
+		if cfg.tableName != "" {
+			tablename = cfg.tableName
+		}
 		var err error
-		store, err = database.NewStore(dialect, DefaultTablename)
+		store, err = database.NewStore(dialect, tablename)
 		if err != nil {
 			return nil, err
 		}
@@ -91,7 +173,12 @@ func NewProvider(dialect Dialect, db *sql.DB, fsys fs.FS, opts ...ProviderOption
 	if store.Tablename() == "" {
 		return nil, errors.New("invalid store implementation: table name must not be empty")
 	}
-	return newProvider(db, store, fsys, cfg, registeredGoMigrations /* global */)
+	p, err := newProvider(db, store, fsys, cfg, globalMigrationsSnapshot() /* global */)
+	if err != nil {
+		return nil, err
+	}
+	p.dialect = dialect
+	return p, nil
 }
 
 func newProvider(
@@ -108,7 +195,8 @@ func newProvider(
 	// feat(mf): we could add a flag to parse SQL migrations eagerly. This would allow us to return
 	// an error if there are any SQL parsing errors. This adds a bit overhead to startup though, so
 	// we should make it optional.
-	filesystemSources, err := collectFilesystemSources(fsys, false, cfg.excludePaths, cfg.excludeVersions)
+	fsys = decompressing(fsys)
+	filesystemSources, err := collectFilesystemSources(fsys, false, cfg.recursive, cfg.excludePaths, cfg.excludeVersions, cfg.filenameParser)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +210,7 @@ func newProvider(
 		// TODO(mf): let's add a warn-level log here to inform users if len(global) > 0. Would like
 		// to add this once we're on go1.21 and leverage the new slog package.
 	} else {
-		if versionMap, ok := global[""]; ok {
+		if versionMap, ok := global[cfg.registryScope]; ok {
 			for version, m := range versionMap {
 				if _, ok := versionToGoMigration[version]; ok {
 					return nil, fmt.Errorf("global go migration conflicts with provider-registered go migration with version %d", version)
@@ -140,12 +228,21 @@ func newProvider(
 	if len(migrations) == 0 {
 		return nil, ErrNoMigrations
 	}
+	var repeatables []*Migration
+	if (cfg.repeatable || cfg.routinesDir != "") && fsys != nil {
+		repeatables, err = collectRepeatables(fsys, cfg.repeatable, cfg.routinesDir, cfg.recursive, cfg.excludePaths)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &Provider{
-		db:         db,
-		fsys:       fsys,
-		cfg:        cfg,
-		store:      controller.NewStoreController(store),
-		migrations: migrations,
+		db:          db,
+		fsys:        fsys,
+		cfg:         cfg,
+		store:       controller.NewStoreController(store),
+		migrations:  migrations,
+		repeatables: repeatables,
+		excluded:    filesystemSources.excluded,
 	}, nil
 }
 
@@ -164,6 +261,63 @@ func (p *Provider) HasPending(ctx context.Context) (bool, error) {
 	return p.hasPending(ctx)
 }
 
+// CheckUpToDate returns a [PendingMigrationsError] listing the pending versions if there are
+// migrations left to apply, otherwise, it returns nil. It is meant for readiness probes of
+// processes that must not serve traffic until another process finished migrating the database.
+//
+// Note, this method will not use a SessionLocker if one is configured, see [Provider.HasPending].
+func (p *Provider) CheckUpToDate(ctx context.Context) error {
+	if p.cfg.disableVersioning {
+		return errors.New("checking for pending migrations not supported when versioning is disabled")
+	}
+	versions, err := p.pendingVersions(ctx)
+	if err != nil {
+		return err
+	}
+	if len(versions) > 0 {
+		return &PendingMigrationsError{Versions: versions}
+	}
+	return nil
+}
+
+// WaitForNoPending blocks until all migrations are applied, checking every pollInterval with
+// [Provider.CheckUpToDate]. It is meant for replicas that start alongside a leader applying the
+// migrations, see [WaitForNoPending].
+func (p *Provider) WaitForNoPending(ctx context.Context, pollInterval time.Duration) error {
+	return waitForNoPending(ctx, pollInterval, p.CheckUpToDate)
+}
+
+// PlanHash returns a stable hash over the versions and checksums of the migrations an up would
+// apply, in order, see [Plan.Hash]. Versions left out with [WithSkipVersions] or
+// [WithIncludeVersions] are not part of the hash.
+//
+// Note, this method will not use a SessionLocker if one is configured, see [Provider.HasPending].
+func (p *Provider) PlanHash(ctx context.Context) (string, error) {
+	if p.cfg.disableVersioning {
+		return "", errors.New("plan hash not supported when versioning is disabled")
+	}
+	versions, err := p.pendingVersions(ctx)
+	if err != nil {
+		return "", err
+	}
+	planned := make([]PlannedMigration, 0, len(versions))
+	for _, v := range versions {
+		if !p.selected(v) {
+			continue
+		}
+		m, err := p.getMigration(v)
+		if err != nil {
+			return "", err
+		}
+		sum, err := p.checksum(m)
+		if err != nil {
+			return "", err
+		}
+		planned = append(planned, PlannedMigration{Version: v, Source: m.Source, Checksum: sum})
+	}
+	return planHash(planned), nil
+}
+
 // GetVersions returns the max database version and the target version to migrate to.
 //
 // Note, this method will not use a SessionLocker if one is configured. This allows callers to check
@@ -239,7 +393,8 @@ func (p *Provider) Up(ctx context.Context) ([]*MigrationResult, error) {
 	if err != nil {
 		return nil, err
 	}
-	if !hasPending {
+	// Indexes left pending by a failed build are resumed even if no migrations are pending.
+	if !hasPending && len(p.repeatables) == 0 && !p.cfg.concurrentIndexes {
 		return nil, nil
 	}
 	return p.up(ctx, false, math.MaxInt64)
@@ -286,7 +441,8 @@ func (p *Provider) UpTo(ctx context.Context, version int64) ([]*MigrationResult,
 	if err != nil {
 		return nil, err
 	}
-	if !hasPending {
+	// Indexes left pending by a failed build are resumed even if no migrations are pending.
+	if !hasPending && len(p.repeatables) == 0 && !p.cfg.concurrentIndexes {
 		return nil, nil
 	}
 	return p.up(ctx, false, version)
@@ -335,6 +491,52 @@ func (p *Provider) DownTo(ctx context.Context, version int64) ([]*MigrationResul
 	return p.down(ctx, false, version)
 }
 
+// RedoTo rolls back all migrations down to, but not including, the specified version, and then
+// applies the same migrations again. The results contain the rolled back migrations followed by
+// the re-applied migrations.
+//
+// On dialects with transactional DDL, such as Postgres and SQLite, the whole redo runs in a single
+// transaction if every migration involved allows it. A failure then leaves the database as it was
+// before the redo. Otherwise, each migration runs on its own.
+func (p *Provider) RedoTo(ctx context.Context, version int64) ([]*MigrationResult, error) {
+	if version < 0 {
+		return nil, fmt.Errorf("invalid version: must be a valid number or zero: %d", version)
+	}
+	return p.redo(ctx, 0, version)
+}
+
+// RedoLast rolls back the n most recently applied migrations, and then applies them again. See
+// [Provider.RedoTo] for details.
+func (p *Provider) RedoLast(ctx context.Context, n int) ([]*MigrationResult, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid count: must be greater than zero: %d", n)
+	}
+	return p.redo(ctx, n, 0)
+}
+
+// Verify checks that applied migrations were not modified after they were applied, by comparing
+// their current checksums with the checksums recorded by [WithRecordChecksums]. If any migration
+// was modified, the returned error wraps [ErrChecksumMismatch] and lists the modified migrations.
+//
+// Migrations in the [ArchiveDir] subdirectory are verified as well, see [Archive]. Migrations
+// without a recorded checksum, Go migrations without an embedded checksum (see [WithChecksum]),
+// tombstones and applied migrations no longer known to the provider are skipped.
+func (p *Provider) Verify(ctx context.Context) error {
+	report, err := p.verify(ctx)
+	if err != nil {
+		return err
+	}
+	return report.Err()
+}
+
+// VerifyReport checks the checksums of applied migrations like [Provider.Verify], but returns the
+// outcome for every migration instead of failing on the first problem. Migrations are hashed
+// concurrently, see [WithVerifyConcurrency]. The returned error is only non-nil if the database
+// cannot be read; use [VerifyReport.Err] to fail on modified or unreadable migrations.
+func (p *Provider) VerifyReport(ctx context.Context) (*VerifyReport, error) {
+	return p.verify(ctx)
+}
+
 // *** Internal methods ***
 
 func (p *Provider) up(
@@ -345,6 +547,9 @@ func (p *Provider) up(
 	if version < 1 {
 		return nil, errInvalidVersion
 	}
+	if err := p.waitForWindow(ctx); err != nil {
+		return nil, err
+	}
 	conn, cleanup, err := p.initialize(ctx, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize: %w", err)
@@ -352,6 +557,14 @@ func (p *Provider) up(
 	defer func() {
 		retErr = multierr.Append(retErr, cleanup())
 	}()
+	if err := p.checkFreeze(ctx, conn); err != nil {
+		return nil, err
+	}
+	if p.cfg.concurrentIndexes && !p.cfg.disableVersioning {
+		if err := p.resumeIndexes(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
 
 	if len(p.migrations) == 0 {
 		return nil, nil
@@ -382,24 +595,83 @@ func (p *Provider) up(
 		if len(dbMigrations) == 0 {
 			return nil, errMissingZeroVersion
 		}
+		if err := p.checkStrictOrdering(dbMigrations); err != nil {
+			return nil, err
+		}
+		fsysVersions := getVersionsFromMigrations(p.migrations)
+		dbVersions := getVersionsFromListMigrations(dbMigrations)
 		versions, err := gooseutil.UpVersions(
-			getVersionsFromMigrations(p.migrations),     // fsys versions
-			getVersionsFromListMigrations(dbMigrations), // db versions
+			fsysVersions,
+			dbVersions,
 			version,
-			p.cfg.allowMissing,
+			p.allowMissing(fsysVersions, dbVersions, version),
 		)
 		if err != nil {
 			return nil, err
 		}
+		skipped := make(map[int64]string)
+		for _, e := range p.excludedPending(dbMigrations, version) {
+			skipped[e.Version] = e.reason
+		}
 		for _, v := range versions {
+			if !p.selected(v) {
+				if p.cfg.skipVersions[v] {
+					skipped[v] = p.cfg.skipReason
+				}
+				continue
+			}
 			m, err := p.getMigration(v)
 			if err != nil {
 				return nil, err
 			}
 			apply = append(apply, m)
 		}
+		if err := p.recordSkipped(ctx, conn, skipped, apply); err != nil {
+			return nil, err
+		}
+	}
+	verify := apply
+	if byOne && len(apply) > 1 {
+		verify = apply[:1]
+	}
+	if err := p.verifyOnShadow(ctx, verify); err != nil {
+		return nil, err
+	}
+	var repeatables []*Migration
+	var sums map[string]string
+	if !byOne {
+		if repeatables, sums, err = p.pendingRepeatables(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.approve(ctx, append(verify[:len(verify):len(verify)], repeatables...)); err != nil {
+		return nil, err
+	}
+	results, err := p.runMigrations(ctx, conn, apply, sqlparser.DirectionUp, byOne)
+	if err != nil {
+		return nil, p.rollbackFailed(ctx, conn, nil, err)
+	}
+	if !byOne {
+		repeatResults, err := p.runRepeatables(ctx, conn, repeatables, sums)
+		if err != nil {
+			var partialErr *PartialError
+			if errors.As(err, &partialErr) {
+				partialErr.Applied = append(results, partialErr.Applied...)
+			}
+			return nil, p.rollbackFailed(ctx, conn, results, err)
+		}
+		results = append(results, repeatResults...)
+	}
+	if err := p.refreshMaterializedViews(ctx, conn, results); err != nil {
+		return nil, p.rollbackFailed(ctx, conn, results, err)
 	}
-	return p.runMigrations(ctx, conn, apply, sqlparser.DirectionUp, byOne)
+	if err := p.maintainPartitionsAfter(ctx, conn, results); err != nil {
+		return nil, p.rollbackFailed(ctx, conn, results, err)
+	}
+	if err := p.smokeTest(ctx, conn, results); err != nil {
+		return nil, p.rollbackFailed(ctx, conn, results, err)
+	}
+	return results, nil
 }
 
 func (p *Provider) down(
@@ -407,6 +679,9 @@ func (p *Provider) down(
 	byOne bool,
 	version int64,
 ) (_ []*MigrationResult, retErr error) {
+	if err := p.waitForWindow(ctx); err != nil {
+		return nil, err
+	}
 	conn, cleanup, err := p.initialize(ctx, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize: %w", err)
@@ -426,6 +701,9 @@ func (p *Provider) down(
 		} else {
 			downMigrations = p.migrations
 		}
+		if err := p.approve(ctx, downMigrations); err != nil {
+			return nil, err
+		}
 		return p.runMigrations(ctx, conn, downMigrations, sqlparser.DirectionDown, byOne)
 	}
 	dbMigrations, err := p.store.ListMigrations(ctx, conn)
@@ -435,6 +713,9 @@ func (p *Provider) down(
 	if len(dbMigrations) == 0 {
 		return nil, errMissingZeroVersion
 	}
+	if err := p.checkStrictOrdering(dbMigrations); err != nil {
+		return nil, err
+	}
 	// We never migrate the zero version down.
 	if dbMigrations[0].Version == 0 {
 		p.printf("no migrations to run, current version: 0")
@@ -451,9 +732,88 @@ func (p *Provider) down(
 		}
 		apply = append(apply, m)
 	}
+	approve := apply
+	if byOne && len(apply) > 1 {
+		approve = apply[:1]
+	}
+	if err := p.approve(ctx, approve); err != nil {
+		return nil, err
+	}
 	return p.runMigrations(ctx, conn, apply, sqlparser.DirectionDown, byOne)
 }
 
+// redo rolls back and re-applies either the count most recently applied migrations, or, if count is
+// zero, all migrations applied after version.
+func (p *Provider) redo(
+	ctx context.Context,
+	count int,
+	version int64,
+) (_ []*MigrationResult, retErr error) {
+	if p.cfg.disableVersioning {
+		return nil, errors.New("redo not supported when versioning is disabled")
+	}
+	if err := p.waitForWindow(ctx); err != nil {
+		return nil, err
+	}
+	conn, cleanup, err := p.initialize(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, cleanup())
+	}()
+	if err := p.checkFreeze(ctx, conn); err != nil {
+		return nil, err
+	}
+
+	dbMigrations, err := p.store.ListMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbMigrations) == 0 {
+		return nil, errMissingZeroVersion
+	}
+	if err := p.checkStrictOrdering(dbMigrations); err != nil {
+		return nil, err
+	}
+	var redo []*Migration
+	for _, dbMigration := range dbMigrations {
+		// We never migrate the zero version down.
+		if dbMigration.Version == 0 || dbMigration.Version <= version {
+			break
+		}
+		if count > 0 && len(redo) == count {
+			break
+		}
+		m, err := p.getMigration(dbMigration.Version)
+		if err != nil {
+			return nil, err
+		}
+		redo = append(redo, m)
+	}
+	if len(redo) == 0 {
+		p.printf("no migrations to redo, current version: %d", dbMigrations[0].Version)
+		return nil, nil
+	}
+	// Roll back in the order migrations were applied, most recent first, then re-apply them in the
+	// reverse order.
+	steps := make([]migrationStep, 0, 2*len(redo))
+	for _, m := range redo {
+		steps = append(steps, migrationStep{m: m, direction: false})
+	}
+	for i := len(redo) - 1; i >= 0; i-- {
+		steps = append(steps, migrationStep{m: redo[i], direction: true})
+	}
+	pending := make([]*Migration, 0, len(steps))
+	for _, step := range steps {
+		pending = append(pending, step.m)
+	}
+	if err := p.approve(ctx, pending); err != nil {
+		return nil, err
+	}
+	return p.runSteps(ctx, conn, steps, atomicIfSupported)
+}
+
 func (p *Provider) apply(
 	ctx context.Context,
 	version int64,
@@ -466,6 +826,9 @@ func (p *Provider) apply(
 	if err != nil {
 		return nil, err
 	}
+	if err := p.waitForWindow(ctx); err != nil {
+		return nil, err
+	}
 	conn, cleanup, err := p.initialize(ctx, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize: %w", err)
@@ -473,7 +836,21 @@ func (p *Provider) apply(
 	defer func() {
 		retErr = multierr.Append(retErr, cleanup())
 	}()
+	if direction {
+		if err := p.checkFreeze(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
 
+	if p.cfg.strictOrdering && !p.cfg.disableVersioning {
+		dbMigrations, err := p.store.ListMigrations(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.checkStrictOrdering(dbMigrations); err != nil {
+			return nil, err
+		}
+	}
 	result, err := p.store.GetMigration(ctx, conn, version)
 	if err != nil && !errors.Is(err, database.ErrVersionNotFound) {
 		return nil, err
@@ -495,6 +872,9 @@ func (p *Provider) apply(
 	if direction {
 		d = sqlparser.DirectionUp
 	}
+	if err := p.approve(ctx, []*Migration{m}); err != nil {
+		return nil, err
+	}
 	return p.runMigrations(ctx, conn, []*Migration{m}, d, true)
 }
 
@@ -525,10 +905,19 @@ func (p *Provider) getVersions(ctx context.Context) (current, target int64, retE
 	return current, target, nil
 }
 
-func (p *Provider) hasPending(ctx context.Context) (_ bool, retErr error) {
+func (p *Provider) hasPending(ctx context.Context) (bool, error) {
+	versions, err := p.pendingVersions(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(versions) > 0, nil
+}
+
+// pendingVersions returns the versions left to apply, in ascending order.
+func (p *Provider) pendingVersions(ctx context.Context) (_ []int64, retErr error) {
 	conn, cleanup, err := p.initialize(ctx, false)
 	if err != nil {
-		return false, fmt.Errorf("failed to initialize: %w", err)
+		return nil, fmt.Errorf("failed to initialize: %w", err)
 	}
 	defer func() {
 		retErr = multierr.Append(retErr, cleanup())
@@ -536,7 +925,7 @@ func (p *Provider) hasPending(ctx context.Context) (_ bool, retErr error) {
 
 	// If versioning is disabled, we always have pending migrations.
 	if p.cfg.disableVersioning {
-		return true, nil
+		return getVersionsFromMigrations(p.migrations), nil
 	}
 
 	// List all migrations from the database. Careful, optimizations here can lead to subtle bugs.
@@ -557,18 +946,35 @@ func (p *Provider) hasPending(ctx context.Context) (_ bool, retErr error) {
 
 	dbMigrations, err := p.store.ListMigrations(ctx, conn)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	apply, err := gooseutil.UpVersions(
-		getVersionsFromMigrations(p.migrations),     // fsys versions
-		getVersionsFromListMigrations(dbMigrations), // db versions
+	fsysVersions := getVersionsFromMigrations(p.migrations)
+	dbVersions := getVersionsFromListMigrations(dbMigrations)
+	return gooseutil.UpVersions(
+		fsysVersions,
+		dbVersions,
 		math.MaxInt64,
-		p.cfg.allowMissing,
+		p.allowMissing(fsysVersions, dbVersions, math.MaxInt64),
 	)
-	if err != nil {
-		return false, err
+}
+
+// checkStrictOrdering returns an error if strict ordering is enabled and any known migration is
+// missing, i.e., it has a version lower than the highest applied version but was never applied.
+func (p *Provider) checkStrictOrdering(dbMigrations []*database.ListMigrationsResult) error {
+	if !p.cfg.strictOrdering {
+		return nil
+	}
+	// Resolving with the highest possible target surfaces every missing migration, regardless of
+	// the target of the current operation.
+	if _, err := gooseutil.UpVersions(
+		getVersionsFromMigrations(p.migrations),
+		getVersionsFromListMigrations(dbMigrations),
+		math.MaxInt64,
+		false,
+	); err != nil {
+		return fmt.Errorf("strict ordering: %w", err)
 	}
-	return len(apply) > 0, nil
+	return nil
 }
 
 func getVersionsFromMigrations(in []*Migration) []int64 {
@@ -598,6 +1004,17 @@ func (p *Provider) status(ctx context.Context) (_ []*MigrationStatus, retErr err
 		retErr = multierr.Append(retErr, cleanup())
 	}()
 
+	var labels map[int64]map[string]string
+	var skipRecords map[int64]*SkipRecord
+	if !p.cfg.disableVersioning {
+		if labels, err = listLabels(ctx, p.store, conn); err != nil {
+			return nil, err
+		}
+		p.cfg.labelProtection.reveal(ctx, labels)
+		if skipRecords, err = p.listSkipRecords(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
 	status := make([]*MigrationStatus, 0, len(p.migrations))
 	for _, m := range p.migrations {
 		migrationStatus := &MigrationStatus{
@@ -608,6 +1025,12 @@ func (p *Provider) status(ctx context.Context) (_ []*MigrationStatus, retErr err
 			},
 			State: StatePending,
 		}
+		a, err := p.cfg.collectCache.readAnnotations(p.fsys, m.Source)
+		if err != nil {
+			return nil, err
+		}
+		a = a.resolve(m)
+		migrationStatus.Title, migrationStatus.Owner, migrationStatus.Hints = a.title, a.owner, a.hints
 		// If versioning is disabled, we can't check the database for applied migrations, so we
 		// assume all migrations are pending.
 		if !p.cfg.disableVersioning {
@@ -618,6 +1041,9 @@ func (p *Provider) status(ctx context.Context) (_ []*MigrationStatus, retErr err
 			if dbResult != nil {
 				migrationStatus.State = StateApplied
 				migrationStatus.AppliedAt = dbResult.Timestamp
+				migrationStatus.Labels = labels[m.Version]
+			} else {
+				migrationStatus.Skipped = skipRecords[m.Version]
 			}
 		}
 		status = append(status, migrationStatus)
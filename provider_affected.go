@@ -0,0 +1,42 @@
+package goose
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// parseMaxAffected returns the bound declared by a max-affected directive, or zero if there is
+// none.
+func parseMaxAffected(directives []sqlparser.Directive) (int64, error) {
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveMaxAffected)
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(d.Value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s directive %q: must be a positive number of rows",
+			sqlparser.DirectiveMaxAffected, d.Value)
+	}
+	return n, nil
+}
+
+// checkAffected returns an error wrapping [ErrTooManyRowsAffected] if stmt affected more than max
+// rows, unless max is zero. Since such migrations always run in a transaction, the error rolls the
+// statement back. A nil result, from a middleware that skipped the statement, affected no rows.
+func checkAffected(max int64, stmt string, result sql.Result) error {
+	if max <= 0 || result == nil {
+		return nil
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected for %s: %w", sqlparser.DirectiveMaxAffected, err)
+	}
+	if n > max {
+		return fmt.Errorf("%w: statement affected %d rows, more than the %d allowed: %s",
+			ErrTooManyRowsAffected, n, max, firstLine(stmt))
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package goose_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxAffected(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER, active BOOLEAN);\nINSERT INTO a VALUES (1, true), (2, true), (3, true), (4, true), (5, true);\n"),
+		"00002_b.sql": newMapFile("-- +goose max-affected 2\n-- +goose Up\nUPDATE a SET active = false WHERE id = 1;\nDELETE FROM a WHERE id = 2;\n"),
+		"00003_c.sql": newMapFile("-- +goose max-affected 2\n-- +goose Up\nUPDATE a SET active = false WHERE id = 3;\nUPDATE a SET active = false;\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.UpTo(ctx, 2)
+	require.NoError(t, err)
+	// The second statement affects all rows, so the whole migration is rolled back.
+	_, err = p.Up(ctx)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, goose.ErrTooManyRowsAffected))
+	require.Contains(t, err.Error(), "statement affected 4 rows, more than the 2 allowed")
+	var active int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM a WHERE active").Scan(&active))
+	require.Equal(t, 3, active)
+	version, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, version)
+
+	t.Run("no_transaction", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose max-affected 10\n-- +goose NO TRANSACTION\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "max-affected requires a transaction")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose max-affected lots\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `invalid max-affected directive "lots"`)
+	})
+}
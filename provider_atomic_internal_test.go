@@ -0,0 +1,76 @@
+package goose
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+// newAtomicProvider returns a provider on a fake database, treated as Postgres so that migrations
+// can run in a single transaction.
+func newAtomicProvider(t *testing.T, fsys fstest.MapFS, opts ...ProviderOption) (*Provider, *goosetest.Recorder, *goosetest.Store) {
+	t.Helper()
+	store := goosetest.NewStore("goose_db_version")
+	db, rec := goosetest.NewDB()
+	p, err := NewProvider("", db, fsys, append(opts, WithStore(store))...)
+	require.NoError(t, err)
+	p.dialect = DialectPostgres
+	return p, rec, store
+}
+
+func TestAtomicConcurrentIndexes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n" +
+			"-- +goose Down\nDROP TABLE a;\n")},
+		"00002_b.sql": &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE INDEX a_id ON a (id);\n" +
+			"-- +goose Down\nDROP INDEX a_id;\n")},
+	}
+	p, rec, store := newAtomicProvider(t, fsys, WithConcurrentIndexes(0), WithAtomicUp(true))
+	_, err := p.Up(ctx)
+	require.ErrorIs(t, err, errNotAtomic)
+	require.ErrorContains(t, err, "migration (type:sql,version:2) builds indexes concurrently")
+	require.NotContains(t, rec.Queries(), "CREATE TABLE a (id INTEGER);")
+	require.Equal(t, []int64{0}, store.Versions())
+
+	// Redo falls back to running the migration on its own, building the index.
+	p, rec, store = newAtomicProvider(t, fsys, WithConcurrentIndexes(0))
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	rec.Reset()
+	_, err = p.redo(ctx, 1, 0)
+	require.NoError(t, err)
+	require.Contains(t, rec.Queries(), "CREATE INDEX CONCURRENTLY a_id ON a (id);")
+	require.Equal(t, []int64{0, 1, 2}, store.Versions())
+}
+
+func TestAtomicNotValid(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE TABLE orders (id INTEGER, total INTEGER);\n")},
+		"00002_b.sql": &fstest.MapFile{Data: []byte("-- +goose not-valid\n-- +goose Up\n" +
+			"ALTER TABLE orders ALTER COLUMN total SET NOT NULL;\n")},
+	}
+	p, rec, store := newAtomicProvider(t, fsys, WithAtomicUp(true))
+	_, err := p.Up(ctx)
+	require.NoError(t, err)
+	// Constraints are validated after the run committed.
+	require.Equal(t, []string{
+		goosetest.Begin, goosetest.Commit, // version table
+		goosetest.Begin,
+		"CREATE TABLE orders (id INTEGER, total INTEGER);",
+		`ALTER TABLE orders ADD CONSTRAINT "orders_total_not_null" CHECK (total IS NOT NULL) NOT VALID;`,
+		goosetest.Commit,
+		`ALTER TABLE orders VALIDATE CONSTRAINT "orders_total_not_null";`,
+		"ALTER TABLE orders ALTER COLUMN total SET NOT NULL;",
+		`ALTER TABLE orders DROP CONSTRAINT "orders_total_not_null";`,
+	}, rec.Queries())
+	require.Equal(t, []int64{0, 1, 2}, store.Versions())
+}
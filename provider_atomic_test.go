@@ -0,0 +1,57 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAtomicUp(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	t.Run("rollback_all", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+			"00003_c.sql": newMapFile("-- +goose Up\nINSERT INTO missing VALUES (1);\n"),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithAtomicUp(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.Error(t, err)
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Empty(t, partialErr.Applied)
+		require.EqualValues(t, 3, partialErr.Failed.Source.Version)
+		currentVersion, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, currentVersion)
+		require.False(t, tableExists(t, db, "a"))
+		require.False(t, tableExists(t, db, "b"))
+		// UpTo is atomic too.
+		res, err := p.UpTo(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+	})
+	t.Run("no_transaction", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithAtomicUp(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "cannot be run in a single transaction")
+		require.Contains(t, err.Error(), "version:2")
+		currentVersion, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, currentVersion)
+	})
+}
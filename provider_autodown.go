@@ -0,0 +1,157 @@
+package goose
+
+import "strings"
+
+// reverseStatements derives the Down statements of a SQL migration without a Down section from its
+// Up statements, see [WithAutoDown]. The Down statements undo the Up statements in reverse order.
+// Statements that cannot be reversed are returned as irreversible, in order.
+func reverseStatements(dialect Dialect, up []string) (down, irreversible []string) {
+	for i := len(up) - 1; i >= 0; i-- {
+		stmt, ok := reverseStatement(dialect, up[i])
+		if !ok {
+			irreversible = append(irreversible, up[i])
+			continue
+		}
+		down = append(down, stmt)
+	}
+	for i, j := 0, len(irreversible)-1; i < j; i, j = i+1, j-1 {
+		irreversible[i], irreversible[j] = irreversible[j], irreversible[i]
+	}
+	return down, irreversible
+}
+
+// reverseStatement returns the statement that undoes stmt, if stmt is a simple CREATE TABLE,
+// CREATE INDEX or ALTER TABLE ... ADD COLUMN statement.
+func reverseStatement(dialect Dialect, stmt string) (string, bool) {
+	text := strings.TrimSuffix(strings.TrimSpace(trimLeadingComments(stmt)), ";")
+	if strings.Contains(text, ";") {
+		// A StatementBegin block of several statements.
+		return "", false
+	}
+	w := sqlWords(strings.Fields(strings.ReplaceAll(text, "(", " ( ")))
+	switch {
+	case w.match("CREATE", "TABLE"):
+		ifExists := w.match("IF", "NOT", "EXISTS")
+		name, ok := w.name()
+		if !ok {
+			return "", false
+		}
+		return "DROP TABLE " + ifExistsClause(ifExists) + name + ";", true
+	case w.match("CREATE", "INDEX"), w.match("CREATE", "UNIQUE", "INDEX"):
+		concurrently := w.match("CONCURRENTLY")
+		ifExists := w.match("IF", "NOT", "EXISTS")
+		name, ok := w.name()
+		if !ok || !w.match("ON") {
+			// Unnamed indexes cannot be dropped by name.
+			return "", false
+		}
+		w.match("ONLY")
+		table, ok := w.name()
+		if !ok {
+			return "", false
+		}
+		if dialect == DialectMySQL {
+			return "DROP INDEX " + name + " ON " + table + ";", true
+		}
+		// The index lives in the schema of its table.
+		if i := strings.LastIndex(table, "."); i > 0 && !strings.Contains(name, ".") {
+			name = table[:i+1] + name
+		}
+		var b strings.Builder
+		b.WriteString("DROP INDEX ")
+		if concurrently {
+			b.WriteString("CONCURRENTLY ")
+		}
+		b.WriteString(ifExistsClause(ifExists) + name + ";")
+		return b.String(), true
+	case w.match("ALTER", "TABLE"):
+		w.match("IF", "EXISTS")
+		w.match("ONLY")
+		table, ok := w.name()
+		if !ok || !w.match("ADD") {
+			return "", false
+		}
+		w.match("COLUMN")
+		ifExists := w.match("IF", "NOT", "EXISTS")
+		column, ok := w.name()
+		if !ok || isConstraintKeyword(column) || hasTopLevelComma(text) {
+			// Constraints and statements of several actions are not reversed.
+			return "", false
+		}
+		return "ALTER TABLE " + table + " DROP COLUMN " + ifExistsClause(ifExists) + column + ";", true
+	}
+	return "", false
+}
+
+// sqlWords are the remaining words of a statement, consumed from the front.
+type sqlWords []string
+
+// match consumes the keywords if the next words are the keywords, compared case-insensitively.
+func (w *sqlWords) match(keywords ...string) bool {
+	if len(*w) < len(keywords) {
+		return false
+	}
+	for i, keyword := range keywords {
+		if !strings.EqualFold((*w)[i], keyword) {
+			return false
+		}
+	}
+	*w = (*w)[len(keywords):]
+	return true
+}
+
+// name consumes the next word, if it is an identifier rather than an opening parenthesis.
+func (w *sqlWords) name() (string, bool) {
+	if len(*w) == 0 || (*w)[0] == "(" {
+		return "", false
+	}
+	name := (*w)[0]
+	*w = (*w)[1:]
+	return name, true
+}
+
+func ifExistsClause(b bool) string {
+	if b {
+		return "IF EXISTS "
+	}
+	return ""
+}
+
+func isConstraintKeyword(word string) bool {
+	switch strings.ToUpper(word) {
+	case "CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK", "INDEX", "KEY", "EXCLUDE":
+		return true
+	}
+	return false
+}
+
+// hasTopLevelComma reports whether stmt has a comma outside parentheses and string literals, e.g.,
+// an ALTER TABLE statement of several actions.
+func hasTopLevelComma(stmt string) bool {
+	var depth int
+	var quoted bool
+	for _, r := range stmt {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			return true
+		}
+	}
+	return false
+}
+
+// irreversibleStatements returns the first lines of the irreversible statements of m, to flag
+// them in warnings and errors.
+func irreversibleStatements(m *Migration) string {
+	lines := make([]string, 0, len(m.sql.Irreversible))
+	for _, stmt := range m.sql.Irreversible {
+		lines = append(lines, firstLine(trimLeadingComments(stmt)))
+	}
+	return strings.Join(lines, "; ")
+}
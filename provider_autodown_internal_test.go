@@ -0,0 +1,51 @@
+package goose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseStatement(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		dialect Dialect
+		up      string
+		down    string
+	}{
+		{DialectPostgres, "CREATE TABLE users (id INTEGER);", "DROP TABLE users;"},
+		{DialectPostgres, "-- accounts\ncreate table if not exists app.users(id integer);", "DROP TABLE IF EXISTS app.users;"},
+		{DialectPostgres, "CREATE UNIQUE INDEX CONCURRENTLY idx_users_email ON users (email);", "DROP INDEX CONCURRENTLY idx_users_email;"},
+		{DialectPostgres, "CREATE INDEX IF NOT EXISTS idx_users_name ON ONLY app.users (name);", "DROP INDEX IF EXISTS app.idx_users_name;"},
+		{DialectMySQL, "CREATE INDEX idx_users_name ON users (name);", "DROP INDEX idx_users_name ON users;"},
+		{DialectSQLite3, "ALTER TABLE users ADD COLUMN name TEXT NOT NULL DEFAULT 'a,b';", "ALTER TABLE users DROP COLUMN name;"},
+		{DialectPostgres, "ALTER TABLE users ADD IF NOT EXISTS total NUMERIC(10,2);", "ALTER TABLE users DROP COLUMN IF EXISTS total;"},
+	} {
+		down, ok := reverseStatement(tc.dialect, tc.up)
+		require.True(t, ok, tc.up)
+		require.Equal(t, tc.down, down)
+	}
+	for _, up := range []string{
+		"INSERT INTO users VALUES (1);",
+		"DROP TABLE users;",
+		"CREATE VIEW v AS SELECT 1;",
+		"CREATE INDEX ON users (name);",
+		"ALTER TABLE users ADD CONSTRAINT users_pk PRIMARY KEY (id);",
+		"ALTER TABLE users ADD COLUMN a TEXT, ADD COLUMN b TEXT;",
+		"ALTER TABLE users RENAME COLUMN a TO b;",
+		"CREATE TABLE a (id INTEGER); CREATE TABLE b (id INTEGER);",
+	} {
+		_, ok := reverseStatement(DialectPostgres, up)
+		require.False(t, ok, up)
+	}
+
+	down, irreversible := reverseStatements(DialectPostgres, []string{
+		"CREATE TABLE users (id INTEGER);",
+		"INSERT INTO users VALUES (1);",
+		"CREATE INDEX idx_users_id ON users (id);",
+		"UPDATE users SET id = 2;",
+	})
+	require.Equal(t, []string{"DROP INDEX idx_users_id;", "DROP TABLE users;"}, down)
+	require.Equal(t, []string{"INSERT INTO users VALUES (1);", "UPDATE users SET id = 2;"}, irreversible)
+}
@@ -0,0 +1,56 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoDown(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile(`-- +goose Up
+CREATE TABLE a (id INTEGER);
+ALTER TABLE a ADD COLUMN name TEXT;
+CREATE INDEX idx_a_name ON a (name);
+`),
+		// An empty Down section is kept as written.
+		"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nINSERT INTO a (id) VALUES (1);\n"),
+	}
+	logger := &bufferLogger{}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+		goose.WithAutoDown(true),
+		goose.WithLogger(logger),
+	)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Contains(t, logger.String(),
+		"goose: warning: migration (type:sql,version:3) cannot be rolled back: INSERT INTO a (id) VALUES (1);")
+
+	_, err = p.Down(ctx)
+	require.ErrorIs(t, err, goose.ErrIrreversible)
+	require.ErrorContains(t, err, "cannot derive the down migration of (type:sql,version:3): INSERT INTO a (id) VALUES (1);")
+	current, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, current)
+
+	// Without the irreversible migration, the derived Down section drops the index, the column and
+	// the table, in reverse order.
+	delete(fsys, "00003_c.sql")
+	_, err = db.ExecContext(ctx, "DELETE FROM goose_db_version WHERE version_id = 3")
+	require.NoError(t, err)
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithAutoDown(true))
+	require.NoError(t, err)
+	_, err = p.DownTo(ctx, 0)
+	require.NoError(t, err)
+	require.False(t, tableExists(t, db, "a"))
+	require.True(t, tableExists(t, db, "b"))
+}
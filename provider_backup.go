@@ -0,0 +1,31 @@
+package goose
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pressly/goose/v3/backup"
+)
+
+// backup backs up the affected tables of m with the backuper set with [WithBackup], if m is a
+// destructive SQL migration about to be applied. It returns where the backup is stored, which is
+// also kept to be recorded with the version once it is applied.
+func (p *Provider) backup(ctx context.Context, m *Migration, direction bool) (string, error) {
+	if p.cfg.backuper == nil || !direction || m.Type != TypeSQL || !m.sql.Destructive || m.Version <= 0 {
+		return "", nil
+	}
+	path, err := p.cfg.backuper.Backup(ctx, backup.Request{
+		Version: m.Version,
+		Source:  m.Source,
+		Tables:  m.sql.Affects,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to back up before destructive migration %s: %w", m.ref(), err)
+	}
+	if p.backups == nil {
+		p.backups = make(map[int64]string)
+	}
+	p.backups[m.Version] = path
+	p.printf("backed up migration %s to %s", m.ref(), path)
+	return path, nil
+}
@@ -0,0 +1,74 @@
+package goose_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/backup"
+	"github.com/stretchr/testify/require"
+)
+
+type backupFunc func(ctx context.Context, req backup.Request) (string, error)
+
+func (f backupFunc) Backup(ctx context.Context, req backup.Request) (string, error) {
+	return f(ctx, req)
+}
+
+func TestBackup(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n"),
+		"00002_b.sql": newMapFile("-- +goose destructive\n-- +goose affects users\n-- +goose Up\nDELETE FROM users;\n-- +goose Down\nSELECT 1;\n"),
+		"00003_c.sql": newMapFile("-- +goose destructive\n-- +goose Up\nDROP TABLE users;\n"),
+	}
+	var requests []backup.Request
+	backuper := backupFunc(func(_ context.Context, req backup.Request) (string, error) {
+		requests = append(requests, req)
+		return fmt.Sprintf("/backups/%d.dump", req.Version), nil
+	})
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithBackup(backuper))
+	require.NoError(t, err)
+	results, err := p.UpTo(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.Empty(t, results[0].Backup)
+	require.Equal(t, "/backups/2.dump", results[1].Backup)
+	require.Equal(t, []backup.Request{{Version: 2, Source: "00002_b.sql", Tables: []string{"users"}}}, requests)
+	var recorded string
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT meta_value FROM goose_db_version_meta WHERE version_id = 2 AND meta_key = 'backup'").Scan(&recorded))
+	require.Equal(t, "/backups/2.dump", recorded)
+
+	// Rollbacks are not backed up.
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+
+	t.Run("failed", func(t *testing.T) {
+		backuper := backupFunc(func(context.Context, backup.Request) (string, error) {
+			return "", errors.New("pg_dump: connection refused")
+		})
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithBackup(backuper))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "failed to back up before destructive migration")
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.EqualValues(t, 2, partialErr.Failed.Source.Version)
+		require.True(t, tableExists(t, db, "users"))
+	})
+	t.Run("whole_database", func(t *testing.T) {
+		_, err := p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, requests, 3)
+		require.Empty(t, requests[2].Tables)
+	})
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithBackup(nil))
+	require.Error(t, err)
+}
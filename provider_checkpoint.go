@@ -0,0 +1,149 @@
+package goose
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// checkpointKeyPrefix is the prefix of the metadata key that records how many statements of a
+// migration run outside a transaction completed, followed by the direction, e.g., "checkpoint:up".
+const checkpointKeyPrefix = "checkpoint:"
+
+func checkpointKey(direction bool) string {
+	if direction {
+		return checkpointKeyPrefix + "up"
+	}
+	return checkpointKeyPrefix + "down"
+}
+
+// checkpointValue returns the value recorded after the given statements completed: their count and
+// a hash of their contents, e.g., "2:9f86d0...".
+func checkpointValue(completed []string) string {
+	h := newCheckpointHash()
+	for _, stmt := range completed {
+		h.add(stmt)
+	}
+	return h.value()
+}
+
+// checkpointHash computes checkpoint values incrementally, as statements complete, see
+// [checkpointValue].
+type checkpointHash struct {
+	h hash.Hash
+	n int
+}
+
+func newCheckpointHash() *checkpointHash {
+	return &checkpointHash{h: sha256.New()}
+}
+
+func (c *checkpointHash) add(stmt string) {
+	c.h.Write([]byte(stmt))
+	c.h.Write([]byte{0})
+	c.n++
+}
+
+// value returns the checkpoint value of the statements added so far.
+func (c *checkpointHash) value() string {
+	return strconv.Itoa(c.n) + ":" + hex.EncodeToString(c.h.Sum(nil))
+}
+
+// usesCheckpoints reports whether completed statements of m are recorded, so a run after a
+// failure resumes at the failed statement.
+func (p *Provider) usesCheckpoints(m *Migration, inTx bool) bool {
+	return p.cfg.checkpoints && p.metadata && !inTx && m.Version > 0
+}
+
+// loadCheckpoint returns the number of leading statements that completed in a previous run that
+// failed. total is the number of statements of the migration and valueAt returns the checkpoint
+// value of its first n statements. If the completed statements were modified since, the checkpoint
+// is ignored and 0 is returned.
+func (p *Provider) loadCheckpoint(
+	ctx context.Context,
+	db database.DBTxConn,
+	m *Migration,
+	direction bool,
+	total int,
+	valueAt func(n int) (string, error),
+) (int, error) {
+	metadata, err := p.store.ListMetadata(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	key := checkpointKey(direction)
+	for _, r := range metadata {
+		if r.Version != m.Version || r.Key != key {
+			continue
+		}
+		count, _, _ := strings.Cut(r.Value, ":")
+		n, err := strconv.Atoi(count)
+		valid := err == nil && n >= 0 && n <= total
+		if valid {
+			value, err := valueAt(n)
+			if err != nil {
+				return 0, err
+			}
+			valid = value == r.Value
+		}
+		if !valid {
+			p.printf("ignoring checkpoint of %s: completed statements were modified, running all statements", m.ref())
+			return 0, nil
+		}
+		if n > 0 {
+			p.printf("resuming %s at statement %d of %d", m.ref(), n+1, total)
+		}
+		return n, nil
+	}
+	return 0, nil
+}
+
+// saveCheckpoint records the checkpoint value of the leading statements of m that completed.
+func (p *Provider) saveCheckpoint(
+	ctx context.Context,
+	db database.DBTxConn,
+	m *Migration,
+	direction bool,
+	value string,
+) error {
+	key := checkpointKey(direction)
+	if err := p.store.DeleteMetadataKey(ctx, db, m.Version, key); err != nil {
+		return fmt.Errorf("failed to record checkpoint: %w", err)
+	}
+	if err := p.store.InsertMetadata(ctx, db, m.Version, key, value); err != nil {
+		return fmt.Errorf("failed to record checkpoint: %w", err)
+	}
+	return nil
+}
+
+// errCheckpointPrefix stops reading the statements of a streamed migration once the prefix of a
+// checkpoint was hashed.
+var errCheckpointPrefix = errors.New("checkpoint prefix read")
+
+// streamedCheckpointValue returns the checkpoint value of the first n statements of a streamed
+// migration, reading only as much of the file as needed.
+func (p *Provider) streamedCheckpointValue(m *Migration, direction bool, n int) (string, error) {
+	h := newCheckpointHash()
+	if n == 0 {
+		return h.value(), nil
+	}
+	err := sqlparser.StreamFromFS(p.fsys, m.Source, sqlparser.FromBool(direction), false, func(stmt string) error {
+		h.add(stmt)
+		if h.n == n {
+			return errCheckpointPrefix
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errCheckpointPrefix) {
+		return "", err
+	}
+	return h.value(), nil
+}
@@ -0,0 +1,52 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpoints(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE a (id INTEGER);\nINSERT INTO b VALUES (1);\nCREATE TABLE c (id INTEGER);\n"),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithCheckpoints(true))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorContains(t, err, "no such table: b")
+
+	// The first statement is not re-executed, it would fail as table a already exists.
+	_, err = db.ExecContext(ctx, "CREATE TABLE b (id INTEGER)")
+	require.NoError(t, err)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.True(t, tableExists(t, db, "c"))
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM goose_db_version_meta WHERE meta_key LIKE 'checkpoint:%'").Scan(&n))
+	require.Zero(t, n)
+
+	t.Run("modified", func(t *testing.T) {
+		db := newDB(t)
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE a (id INTEGER);\nINSERT INTO b VALUES (1);\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithCheckpoints(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.Error(t, err)
+		// The completed statement changed, so all statements run again.
+		fsys["00001_a.sql"] = newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE b (id INTEGER);\nINSERT INTO b VALUES (1);\n")
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithCheckpoints(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -13,33 +14,52 @@ import (
 type fileSources struct {
 	sqlSources []Source
 	goSources  []Source
+	// excluded are the versioned migration files left out with [WithExcludeNames] or
+	// [WithExcludeVersions].
+	excluded []*excludedSource
+}
+
+// excludedSource is a migration file that was excluded, and why.
+type excludedSource struct {
+	Source
+	reason string
 }
 
 // collectFilesystemSources scans the file system for migration files that have a numeric prefix
-// (greater than one) followed by an underscore and a file extension of either .go or .sql. fsys may
-// be nil, in which case an empty fileSources is returned.
+// (greater than one) followed by an underscore and a file extension of either .go or .sql, or
+// .sql.gz or .sql.zst for compressed SQL migrations. fsys may be nil, in which case an empty
+// fileSources is returned.
 //
-// If strict is true, then any error parsing the numeric component of the filename will result in an
-// error. The file is skipped otherwise.
+// Versions are parsed from file names with parse, or [NumericComponent] if nil. If strict is true,
+// then any error parsing the numeric component of the filename will result in an error. The file
+// is skipped otherwise.
+//
+// If recursive is true, migration files are also collected from all subdirectories of fsys, except
+// [ArchiveDir] directories.
+// Versions must be unique across all directories, and sources are ordered by version regardless of
+// which directory they live in.
 //
 // This function DOES NOT parse SQL migrations or merge registered Go migrations. It only collects
 // migration sources from the filesystem.
 func collectFilesystemSources(
 	fsys fs.FS,
 	strict bool,
+	recursive bool,
 	excludePaths map[string]bool,
 	excludeVersions map[int64]bool,
+	parse FilenameParser,
 ) (*fileSources, error) {
 	if fsys == nil {
 		return new(fileSources), nil
 	}
+	if parse == nil {
+		parse = NumericComponent
+	}
 	sources := new(fileSources)
-	versionToBaseLookup := make(map[int64]string) // map[version]filepath.Base(fullpath)
-	for _, pattern := range []string{
-		"*.sql",
-		"*.go",
-	} {
-		files, err := fs.Glob(fsys, pattern)
+	versionToPathLookup := make(map[int64]string) // map[version]fullpath
+	conflicts := make(caseConflicts)
+	for _, pattern := range append(sqlFilePatterns, "*.go") {
+		files, err := globFilesystem(fsys, pattern, recursive)
 		if err != nil {
 			return nil, fmt.Errorf("failed to glob pattern %q: %w", pattern, err)
 		}
@@ -48,8 +68,13 @@ func collectFilesystemSources(
 			if strings.HasSuffix(base, "_test.go") {
 				continue
 			}
-			if excludePaths[base] {
-				// TODO(mf): log this?
+			if err := conflicts.add(fullpath); err != nil {
+				return nil, err
+			}
+			if excludePaths[base] || excludePaths[fullpath] {
+				if version, err := parse(base); err == nil && version > 0 {
+					sources.exclude(fullpath, version, "excluded by name")
+				}
 				continue
 			}
 			// If the filename has a valid looking version of the form: NUMBER_.{sql,go}, then use
@@ -57,33 +82,36 @@ func collectFilesystemSources(
 			// filenames, but still have versioned migrations within the same directory. For
 			// example, a user could have a helpers.go file which contains unexported helper
 			// functions for migrations.
-			version, err := NumericComponent(base)
+			version, err := parse(base)
 			if err != nil {
 				if strict {
 					return nil, fmt.Errorf("failed to parse numeric component from %q: %w", base, err)
 				}
 				continue
 			}
+			if version < 1 {
+				return nil, fmt.Errorf("invalid version %d parsed from %q: %w", version, base, errInvalidVersion)
+			}
 			if excludeVersions[version] {
-				// TODO: log this?
+				sources.exclude(fullpath, version, "excluded by version")
 				continue
 			}
 			// Ensure there are no duplicate versions.
-			if existing, ok := versionToBaseLookup[version]; ok {
+			if existing, ok := versionToPathLookup[version]; ok {
 				return nil, fmt.Errorf("found duplicate migration version %d:\n\texisting:%v\n\tcurrent:%v",
 					version,
 					existing,
-					base,
+					fullpath,
 				)
 			}
-			switch filepath.Ext(base) {
-			case ".sql":
+			switch {
+			case isSQLFile(base):
 				sources.sqlSources = append(sources.sqlSources, Source{
 					Type:    TypeSQL,
 					Path:    fullpath,
 					Version: version,
 				})
-			case ".go":
+			case filepath.Ext(base) == ".go":
 				sources.goSources = append(sources.goSources, Source{
 					Type:    TypeGo,
 					Path:    fullpath,
@@ -94,12 +122,65 @@ func collectFilesystemSources(
 				return nil, fmt.Errorf("invalid file extension: %q", base)
 			}
 			// Add the version to the lookup map.
-			versionToBaseLookup[version] = base
+			versionToPathLookup[version] = fullpath
 		}
 	}
+	if recursive {
+		// Files from different directories are not globbed in version order.
+		for _, list := range [][]Source{sources.sqlSources, sources.goSources} {
+			sort.SliceStable(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+		}
+	}
+	sort.SliceStable(sources.excluded, func(i, j int) bool {
+		return sources.excluded[i].Version < sources.excluded[j].Version
+	})
 	return sources, nil
 }
 
+// exclude notes a migration file that was excluded, so a run can record it as skipped.
+func (s *fileSources) exclude(fullpath string, version int64, reason string) {
+	typ := TypeSQL
+	if filepath.Ext(fullpath) == ".go" {
+		typ = TypeGo
+	}
+	s.excluded = append(s.excluded, &excludedSource{
+		Source: Source{Type: typ, Path: fullpath, Version: version},
+		reason: reason,
+	})
+}
+
+// globFilesystem returns the names of all files matching pattern in the root of fsys. If recursive
+// is true, files matching pattern in all subdirectories are returned as well, in lexical order.
+func globFilesystem(fsys fs.FS, pattern string, recursive bool) ([]string, error) {
+	if !recursive {
+		return fs.Glob(fsys, pattern)
+	}
+	// Validate the pattern upfront, fs.WalkDir would otherwise swallow a bad pattern per file.
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(fullpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ArchiveDir && fullpath != "." {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if ok, _ := path.Match(pattern, d.Name()); ok {
+			files = append(files, fullpath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
 func newSQLMigration(source Source) *Migration {
 	return &Migration{
 		Type:      source.Type,
@@ -151,7 +232,14 @@ func merge(sources *fileSources, registered map[int64]*Migration) ([]*Migration,
 	// migrations may not have a corresponding file on disk. Which is fine! We include them
 	// wholesale as part of migrations. This allows users to build a custom binary that only embeds
 	// the SQL migration files.
-	for version, r := range registered {
+	versions := make([]int64, 0, len(registered))
+	for version := range registered {
+		versions = append(versions, version)
+	}
+	// Iterate in version order, so the duplicate reported is the same on every run.
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	for _, version := range versions {
+		r := registered[version]
 		// Ensure there are no duplicate versions.
 		if existing, ok := migrationLookup[version]; ok {
 			fullpath := r.Source
@@ -169,7 +257,7 @@ func merge(sources *fileSources, registered map[int64]*Migration) ([]*Migration,
 	}
 	// Sort migrations by version in ascending order.
 	sort.Slice(migrations, func(i, j int) bool {
-		return migrations[i].Version < migrations[j].Version
+		return CompareMigrations(migrations[i], migrations[j]) < 0
 	})
 	return migrations, nil
 }
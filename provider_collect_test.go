@@ -11,21 +11,21 @@ import (
 func TestCollectFileSources(t *testing.T) {
 	t.Parallel()
 	t.Run("nil_fsys", func(t *testing.T) {
-		sources, err := collectFilesystemSources(nil, false, nil, nil)
+		sources, err := collectFilesystemSources(nil, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.NotNil(t, sources)
 		require.Empty(t, sources.goSources)
 		require.Empty(t, sources.sqlSources)
 	})
 	t.Run("noop_fsys", func(t *testing.T) {
-		sources, err := collectFilesystemSources(noopFS{}, false, nil, nil)
+		sources, err := collectFilesystemSources(noopFS{}, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.NotNil(t, sources)
 		require.Empty(t, sources.goSources)
 		require.Empty(t, sources.sqlSources)
 	})
 	t.Run("empty_fsys", func(t *testing.T) {
-		sources, err := collectFilesystemSources(fstest.MapFS{}, false, nil, nil)
+		sources, err := collectFilesystemSources(fstest.MapFS{}, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.Empty(t, sources.goSources)
 		require.Empty(t, sources.sqlSources)
@@ -36,19 +36,19 @@ func TestCollectFileSources(t *testing.T) {
 			"00000_foo.sql": sqlMapFile,
 		}
 		// strict disable - should not error
-		sources, err := collectFilesystemSources(mapFS, false, nil, nil)
+		sources, err := collectFilesystemSources(mapFS, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.Empty(t, sources.goSources)
 		require.Empty(t, sources.sqlSources)
 		// strict enabled - should error
-		_, err = collectFilesystemSources(mapFS, true, nil, nil)
+		_, err = collectFilesystemSources(mapFS, true, false, nil, nil, nil)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "migration version must be greater than zero")
 	})
 	t.Run("collect", func(t *testing.T) {
 		fsys, err := fs.Sub(newSQLOnlyFS(), "migrations")
 		require.NoError(t, err)
-		sources, err := collectFilesystemSources(fsys, false, nil, nil)
+		sources, err := collectFilesystemSources(fsys, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.Len(t, sources.sqlSources, 4)
 		require.Empty(t, sources.goSources)
@@ -64,18 +64,60 @@ func TestCollectFileSources(t *testing.T) {
 			require.Equal(t, sources.sqlSources[i], expected.sqlSources[i])
 		}
 	})
+	t.Run("recursive", func(t *testing.T) {
+		mapFS := fstest.MapFS{
+			"00001_foo.sql":              sqlMapFile,
+			"2023/00003_baz.sql":         sqlMapFile,
+			"2022/00002_bar.sql":         sqlMapFile,
+			"2022/billing/00004_qux.go":  {Data: []byte(`package billing`)},
+			"2022/billing/helpers.go":    {Data: []byte(`package billing`)},
+			"archive/00000_old.sql":      sqlMapFile,
+			"2022/archive/00005_old.sql": sqlMapFile,
+		}
+		sources, err := collectFilesystemSources(mapFS, false, false, nil, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, sources.sqlSources, 1)
+		require.Len(t, sources.goSources, 0)
+
+		sources, err = collectFilesystemSources(mapFS, false, true, map[string]bool{"2023/00003_baz.sql": true}, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, sources.sqlSources, []Source{
+			newSource(TypeSQL, "00001_foo.sql", 1),
+			newSource(TypeSQL, "2022/00002_bar.sql", 2),
+		})
+		require.Equal(t, sources.goSources, []Source{
+			newSource(TypeGo, "2022/billing/00004_qux.go", 4),
+		})
+
+		sources, err = collectFilesystemSources(mapFS, false, true, nil, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, sources.sqlSources, []Source{
+			newSource(TypeSQL, "00001_foo.sql", 1),
+			newSource(TypeSQL, "2022/00002_bar.sql", 2),
+			newSource(TypeSQL, "2023/00003_baz.sql", 3),
+		})
+
+		mapFS["2023/00002_bar.sql"] = sqlMapFile
+		_, err = collectFilesystemSources(mapFS, false, true, nil, nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "found duplicate migration version 2")
+		require.Contains(t, err.Error(), "2022/00002_bar.sql")
+		require.Contains(t, err.Error(), "2023/00002_bar.sql")
+	})
 	t.Run("excludes", func(t *testing.T) {
 		fsys, err := fs.Sub(newSQLOnlyFS(), "migrations")
 		require.NoError(t, err)
 		sources, err := collectFilesystemSources(
 			fsys,
 			false,
+			false,
 			// exclude 2 files explicitly
 			map[string]bool{
 				"00002_bar.sql": true,
 				"00110_qux.sql": true,
 			},
 			nil,
+			nil,
 		)
 		require.NoError(t, err)
 		require.Len(t, sources.sqlSources, 2)
@@ -96,7 +138,7 @@ func TestCollectFileSources(t *testing.T) {
 		mapFS["migrations/not_valid.sql"] = &fstest.MapFile{Data: []byte("invalid")}
 		fsys, err := fs.Sub(mapFS, "migrations")
 		require.NoError(t, err)
-		_, err = collectFilesystemSources(fsys, true, nil, nil)
+		_, err = collectFilesystemSources(fsys, true, false, nil, nil, nil)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), `failed to parse numeric component from "not_valid.sql"`)
 	})
@@ -108,7 +150,7 @@ func TestCollectFileSources(t *testing.T) {
 			"4_qux.sql":     sqlMapFile,
 			"5_foo_test.go": {Data: []byte(`package goose_test`)},
 		}
-		sources, err := collectFilesystemSources(mapFS, false, nil, nil)
+		sources, err := collectFilesystemSources(mapFS, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.Len(t, sources.sqlSources, 4)
 		require.Empty(t, sources.goSources)
@@ -123,7 +165,7 @@ func TestCollectFileSources(t *testing.T) {
 			"no_a_real_migration.sql":  {Data: []byte(`SELECT 1;`)},
 			"some/other/dir/2_foo.sql": {Data: []byte(`SELECT 1;`)},
 		}
-		sources, err := collectFilesystemSources(mapFS, false, nil, nil)
+		sources, err := collectFilesystemSources(mapFS, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.Len(t, sources.sqlSources, 2)
 		require.Len(t, sources.goSources, 1)
@@ -142,10 +184,42 @@ func TestCollectFileSources(t *testing.T) {
 			"001_foo.sql": sqlMapFile,
 			"01_bar.sql":  sqlMapFile,
 		}
-		_, err := collectFilesystemSources(mapFS, false, nil, nil)
+		_, err := collectFilesystemSources(mapFS, false, false, nil, nil, nil)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "found duplicate migration version 1")
 	})
+	t.Run("case_conflict", func(t *testing.T) {
+		mapFS := fstest.MapFS{
+			"00001_foo.sql":     sqlMapFile,
+			"00001_Foo.sql":     sqlMapFile,
+			"app/00002_bar.sql": sqlMapFile,
+			"App/00002_bar.sql": sqlMapFile,
+		}
+		_, err := collectFilesystemSources(mapFS, false, false, nil, nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "found migration files that differ only in case")
+		require.Contains(t, err.Error(), "00001_Foo.sql")
+		sub, err := fs.Sub(mapFS, "app")
+		require.NoError(t, err)
+		_, err = collectFilesystemSources(sub, false, false, nil, nil, nil)
+		require.NoError(t, err)
+		delete(mapFS, "00001_Foo.sql")
+		_, err = collectFilesystemSources(mapFS, false, true, nil, nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "App/00002_bar.sql")
+	})
+	t.Run("windows_paths", func(t *testing.T) {
+		// WithExcludeNames normalizes backslashes, as typed on Windows.
+		mapFS := fstest.MapFS{
+			"app/00001_foo.sql": sqlMapFile,
+			"app/00002_bar.sql": sqlMapFile,
+		}
+		cfg := config{excludePaths: make(map[string]bool)}
+		require.NoError(t, WithExcludeNames([]string{`app\00002_bar.sql`}).apply(&cfg))
+		sources, err := collectFilesystemSources(mapFS, false, true, cfg.excludePaths, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, []Source{newSource(TypeSQL, "app/00001_foo.sql", 1)}, sources.sqlSources)
+	})
 	t.Run("dirpath", func(t *testing.T) {
 		mapFS := fstest.MapFS{
 			"dir1/101_a.sql": sqlMapFile,
@@ -158,7 +232,7 @@ func TestCollectFileSources(t *testing.T) {
 			t.Helper()
 			f, err := fs.Sub(mapFS, dirpath)
 			require.NoError(t, err)
-			got, err := collectFilesystemSources(f, false, nil, nil)
+			got, err := collectFilesystemSources(f, false, false, nil, nil, nil)
 			require.NoError(t, err)
 			require.Equal(t, len(got.sqlSources), len(sqlSources))
 			require.Empty(t, got.goSources)
@@ -194,7 +268,7 @@ func TestMerge(t *testing.T) {
 		}
 		fsys, err := fs.Sub(mapFS, "migrations")
 		require.NoError(t, err)
-		sources, err := collectFilesystemSources(fsys, false, nil, nil)
+		sources, err := collectFilesystemSources(fsys, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		require.Len(t, sources.sqlSources, 1)
 		require.Len(t, sources.goSources, 2)
@@ -242,7 +316,7 @@ func TestMerge(t *testing.T) {
 		}
 		fsys, err := fs.Sub(mapFS, "migrations")
 		require.NoError(t, err)
-		sources, err := collectFilesystemSources(fsys, false, nil, nil)
+		sources, err := collectFilesystemSources(fsys, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		t.Run("unregistered_all", func(t *testing.T) {
 			migrations, err := merge(sources, map[int64]*Migration{
@@ -266,7 +340,7 @@ func TestMerge(t *testing.T) {
 		}
 		fsys, err := fs.Sub(mapFS, "migrations")
 		require.NoError(t, err)
-		sources, err := collectFilesystemSources(fsys, false, nil, nil)
+		sources, err := collectFilesystemSources(fsys, false, false, nil, nil, nil)
 		require.NoError(t, err)
 		t.Run("unregistered_all", func(t *testing.T) {
 			migrations, err := merge(sources, map[int64]*Migration{
@@ -0,0 +1,174 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// condition is a query declared with a precondition or verify directive, and the value it must
+// return.
+type condition struct {
+	query  string
+	expect string
+	// skip is set for preconditions that skip the migration instead of failing it.
+	skip bool
+}
+
+// parseConditions returns the conditions declared by the directives with the given name, in order.
+// Only preconditions may set on-fail.
+func parseConditions(directives []sqlparser.Directive, name string) ([]condition, error) {
+	var conditions []condition
+	for _, d := range directives {
+		if d.Name != name {
+			continue
+		}
+		c := condition{query: d.Value, expect: "0"}
+		for {
+			field, rest, _ := strings.Cut(c.query, " ")
+			if v, ok := strings.CutPrefix(field, "expect="); ok {
+				c.expect = v
+			} else if v, ok := strings.CutPrefix(field, "on-fail="); ok && name == sqlparser.DirectivePrecondition {
+				switch v {
+				case "skip":
+					c.skip = true
+				case "abort":
+					c.skip = false
+				default:
+					return nil, fmt.Errorf("invalid %s directive %q: on-fail must be skip or abort", name, d.Value)
+				}
+			} else {
+				break
+			}
+			c.query = strings.TrimSpace(rest)
+		}
+		if c.query == "" || c.expect == "" {
+			usage := "[expect=VALUE] QUERY"
+			if name == sqlparser.DirectivePrecondition {
+				usage = "[expect=VALUE] [on-fail=skip|abort] QUERY"
+			}
+			return nil, fmt.Errorf("invalid %s directive %q: must be %s", name, d.Value, usage)
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions, nil
+}
+
+// checkPreconditions runs the preconditions of a migration on db, the transaction or connection its
+// Up statements are about to run on. The first query that does not return its expected value fails
+// the migration with an error wrapping [ErrPreconditionFailed], or, with on-fail=skip,
+// [ErrPreconditionSkipped].
+func checkPreconditions(ctx context.Context, db database.DBTxConn, conditions []condition) error {
+	for _, c := range conditions {
+		mismatch, err := checkCondition(ctx, db, c)
+		if err != nil {
+			return fmt.Errorf("failed to check precondition %s: %w", c.query, err)
+		}
+		if mismatch != "" && c.skip {
+			return fmt.Errorf("%w: %s", ErrPreconditionSkipped, mismatch)
+		}
+		if mismatch != "" {
+			return fmt.Errorf("%w: %s", ErrPreconditionFailed, mismatch)
+		}
+	}
+	return nil
+}
+
+// skipRemaining records that the run stopped at steps[skipped], whose precondition with
+// on-fail=skip failed with err, leaving it and the remaining steps after it pending, and returns
+// the [PreconditionSkipError] of the run. The migrations of the run rolled back with it, if it ran
+// in a single transaction, are logged too.
+func (p *Provider) skipRemaining(
+	ctx context.Context,
+	conn *sql.Conn,
+	steps []migrationStep,
+	skipped int,
+	applied []*MigrationResult,
+	rolledBack int,
+	err error,
+) error {
+	m := steps[skipped].m
+	remaining := len(steps) - skipped - 1
+	if rolledBack > 0 {
+		p.cfg.logger.Printf("goose: warning: rolled back the %d migrations applied before migration %s, "+
+			"skipped it and the %d pending after it: %v", rolledBack, m.ref(), remaining, err)
+	} else {
+		p.cfg.logger.Printf("goose: warning: skipped migration %s and the %d pending after it: %v", m.ref(), remaining, err)
+	}
+	if p.metadata {
+		records, listErr := p.listSkipRecords(ctx, conn)
+		if listErr != nil {
+			return listErr
+		}
+		if recordErr := p.recordSkip(ctx, conn, records, m.Version, err.Error()); recordErr != nil {
+			return recordErr
+		}
+	}
+	skipErr := &PreconditionSkipError{
+		Applied: applied,
+		Skipped: newMigrationResult(steps[skipped]).Source,
+		Err:     err,
+	}
+	for _, step := range steps[skipped+1:] {
+		skipErr.Pending = append(skipErr.Pending, newMigrationResult(step).Source)
+	}
+	return skipErr
+}
+
+// hasSkipPrecondition reports whether a step applies a migration with a precondition that skips it,
+// whose skip must be recorded in the metadata table.
+func hasSkipPrecondition(steps []migrationStep) bool {
+	for _, step := range steps {
+		if !step.direction || step.m.Type != TypeSQL {
+			continue
+		}
+		for _, c := range step.m.sql.Preconditions {
+			if c.skip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkPostConditions runs the post-conditions of a migration on db, the transaction or connection
+// its Up statements ran on, so they see its writes. The first query that does not return its
+// expected value fails the migration with an error wrapping [ErrPostConditionFailed]; a migration
+// run in a transaction is rolled back.
+func checkPostConditions(ctx context.Context, db database.DBTxConn, conditions []condition) error {
+	for _, c := range conditions {
+		mismatch, err := checkCondition(ctx, db, c)
+		if err != nil {
+			return fmt.Errorf("failed to check post-condition %s: %w", c.query, err)
+		}
+		if mismatch != "" {
+			return fmt.Errorf("%w: %s", ErrPostConditionFailed, mismatch)
+		}
+	}
+	return nil
+}
+
+// checkCondition runs the query of c and compares the value it returns with the expected value, as
+// text, NULL as "NULL". It returns a description of the mismatch, or empty if the condition holds.
+func checkCondition(ctx context.Context, db database.DBTxConn, c condition) (string, error) {
+	var value sql.NullString
+	if err := db.QueryRowContext(ctx, c.query).Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Sprintf("%s returned no rows, expected %s", c.query, c.expect), nil
+		}
+		return "", err
+	}
+	got := "NULL"
+	if value.Valid {
+		got = value.String
+	}
+	if got != c.expect {
+		return fmt.Sprintf("%s returned %s, expected %s", c.query, got, c.expect), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,155 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostConditions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose verify expect=2 SELECT count(*) FROM users\n-- +goose Up\nCREATE TABLE users (id INTEGER, email TEXT);\nINSERT INTO users VALUES (1, 'a@example.com'), (2, 'b@example.com');\n"),
+		"00002_b.sql": newMapFile("-- +goose verify SELECT count(*) FROM users WHERE email IS NULL\n-- +goose Up\nALTER TABLE users ADD COLUMN name TEXT;\nINSERT INTO users VALUES (3, NULL, 'c');\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.UpTo(ctx, 1)
+	require.NoError(t, err)
+	// The post-condition sees the writes of the migration, which is rolled back.
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, goose.ErrPostConditionFailed)
+	require.ErrorContains(t, err, "SELECT count(*) FROM users WHERE email IS NULL returned 1, expected 0")
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT count(*) FROM users").Scan(&n))
+	require.Equal(t, 2, n)
+	version, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, version)
+
+	t.Run("no_transaction", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose verify expect=NULL SELECT max(id) FROM a\n-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose verify SELECT id FROM b\n-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		// The statements are applied, but the version is not recorded.
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, goose.ErrPostConditionFailed)
+		require.ErrorContains(t, err, "SELECT id FROM b returned no rows, expected 0")
+		require.True(t, tableExists(t, db, "b"))
+		version, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, version)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose verify expect=1\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `invalid verify directive "expect=1"`)
+	})
+}
+
+func TestPreconditions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER, email TEXT);\nINSERT INTO users VALUES (1, 'a@example.com'), (2, NULL);\n"),
+		"00002_b.sql": newMapFile("-- +goose precondition expect=1 SELECT count(*) FROM users WHERE id = 1\n-- +goose precondition SELECT count(*) FROM users WHERE email IS NULL\n-- +goose Up\nCREATE UNIQUE INDEX users_email ON users (email);\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, goose.ErrPreconditionFailed)
+	require.ErrorContains(t, err, "SELECT count(*) FROM users WHERE email IS NULL returned 1, expected 0")
+	version, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, version)
+
+	// Once the data was cleaned up, the migration is applied.
+	_, err = db.ExecContext(ctx, "DELETE FROM users WHERE email IS NULL")
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	version, err = p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, version)
+
+	t.Run("skip", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose precondition on-fail=skip expect=1 SELECT count(*) FROM a\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+			"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n"),
+		}
+		const reason = "precondition not met: SELECT count(*) FROM a returned 0, expected 1"
+		for _, atomic := range []bool{false, true} {
+			logger := &bufferLogger{}
+			db := newDB(t)
+			p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithLogger(logger), goose.WithAtomicUp(atomic))
+			require.NoError(t, err)
+			results, err := p.Up(ctx)
+			require.Empty(t, results)
+			require.ErrorIs(t, err, goose.ErrPreconditionSkipped)
+			var skipErr *goose.PreconditionSkipError
+			require.ErrorAs(t, err, &skipErr)
+			require.EqualValues(t, 2, skipErr.Skipped.Version)
+			require.Len(t, skipErr.Pending, 1)
+			require.EqualValues(t, 3, skipErr.Pending[0].Version)
+			version, err := p.GetDBVersion(ctx)
+			require.NoError(t, err)
+			if atomic {
+				// The migration before it is rolled back with the transaction.
+				require.Empty(t, skipErr.Applied)
+				require.Contains(t, logger.String(), "rolled back the 1 migrations applied before migration (type:sql,version:2), "+
+					"skipped it and the 1 pending after it: "+reason)
+				require.EqualValues(t, 0, version)
+				require.False(t, tableExists(t, db, "a"))
+			} else {
+				require.Len(t, skipErr.Applied, 1)
+				require.Contains(t, logger.String(), "skipped migration (type:sql,version:2) and the 1 pending after it: "+reason)
+				require.EqualValues(t, 1, version)
+			}
+			// The skip is recorded, and cleared once the migration is applied.
+			status, err := p.Status(ctx)
+			require.NoError(t, err)
+			require.NotNil(t, status[1].Skipped)
+			require.Equal(t, reason, status[1].Skipped.Reason)
+			require.Nil(t, status[2].Skipped)
+			if atomic {
+				_, err = p.UpTo(ctx, 1)
+				require.NoError(t, err)
+			}
+			_, err = db.ExecContext(ctx, "INSERT INTO a VALUES (1)")
+			require.NoError(t, err)
+			_, err = p.Up(ctx)
+			require.NoError(t, err)
+			status, err = p.Status(ctx)
+			require.NoError(t, err)
+			require.Equal(t, goose.StateApplied, status[1].State)
+			require.Nil(t, status[1].Skipped)
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose precondition on-fail=ignore SELECT 1\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `invalid precondition directive "on-fail=ignore SELECT 1": on-fail must be skip or abort`)
+	})
+}
@@ -0,0 +1,31 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// validateConstraints runs the statements validating the constraints a migration with a "-- +goose
+// not-valid" directive added NOT VALID. They run after the migration is recorded as applied, each
+// on its own, so that the scan of existing rows only takes a SHARE UPDATE EXCLUSIVE lock, which
+// does not block reads or writes, instead of holding the lock taken by ALTER TABLE.
+//
+// If a validation fails, e.g., because existing rows violate the constraint, the migration stays
+// applied with the constraint NOT VALID: new rows are checked, and the remaining statements must
+// be run by hand once the data is fixed.
+func (p *Provider) validateConstraints(ctx context.Context, conn *sql.Conn, m *Migration) error {
+	if m.Type != TypeSQL {
+		return nil
+	}
+	for i, stmt := range m.sql.Validate {
+		start := time.Now()
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migration %s was applied with constraints NOT VALID, but failed to validate them; "+
+				"fix the data and run the remaining statements: %q: %w", m.ref(), m.sql.Validate[i:], err)
+		}
+		p.printf("validated constraint of migration %s: %s (%s)", m.ref(), stmt, truncateDuration(time.Since(start)))
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotValid(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose not-valid\n-- +goose Up\n" +
+			"ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id);\n" +
+			"ALTER TABLE orders ALTER COLUMN total SET NOT NULL;\n" +
+			"CREATE INDEX orders_total ON orders (total);\n" +
+			"-- +goose Down\nALTER TABLE orders DROP CONSTRAINT orders_user_fk;\n"),
+	}
+	store := goosetest.NewStore("goose_db_version")
+	db, rec := goosetest.NewDB()
+	p, err := goose.NewProvider("", db, fsys, goose.WithStore(store))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	// Constraints are validated after the migration committed.
+	require.Equal(t, []string{
+		goosetest.Begin, goosetest.Commit, // version table
+		goosetest.Begin,
+		"ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;",
+		`ALTER TABLE orders ADD CONSTRAINT "orders_total_not_null" CHECK (total IS NOT NULL) NOT VALID;`,
+		"CREATE INDEX orders_total ON orders (total);",
+		goosetest.Commit,
+		"ALTER TABLE orders VALIDATE CONSTRAINT orders_user_fk;",
+		`ALTER TABLE orders VALIDATE CONSTRAINT "orders_total_not_null";`,
+		"ALTER TABLE orders ALTER COLUMN total SET NOT NULL;",
+		`ALTER TABLE orders DROP CONSTRAINT "orders_total_not_null";`,
+	}, rec.Queries())
+
+	// Down statements are not rewritten.
+	rec.Reset()
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		goosetest.Begin, "ALTER TABLE orders DROP CONSTRAINT orders_user_fk;", goosetest.Commit,
+	}, rec.Queries())
+
+	t.Run("validation_failed", func(t *testing.T) {
+		violation := errors.New("insert or update on table orders violates foreign key constraint")
+		db, _ := goosetest.NewDB(goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+			if strings.Contains(query, "VALIDATE CONSTRAINT orders_user_fk") {
+				return nil, violation
+			}
+			return nil, nil
+		}))
+		store := goosetest.NewStore("goose_db_version")
+		p, err := goose.NewProvider("", db, fsys, goose.WithStore(store))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, violation)
+		require.ErrorContains(t, err, "applied with constraints NOT VALID")
+		// The migration stays applied, with the constraints left to validate.
+		require.Equal(t, []int64{0, 1}, store.Versions())
+	})
+	t.Run("dialect", func(t *testing.T) {
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "not-valid directive requires the postgres dialect")
+	})
+}
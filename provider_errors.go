@@ -3,6 +3,9 @@ package goose
 import (
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -21,6 +24,65 @@ var (
 	// yet been applied. This error is returned by [Provider.Apply].
 	ErrNotApplied = errors.New("migration not applied")
 
+	// ErrChecksumMismatch is returned by [Provider.Verify] when the checksum of an applied
+	// migration no longer matches the checksum recorded when it was applied.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrOutsideWindow is returned when migrations would run outside the maintenance window set
+	// with [WithWindow].
+	ErrOutsideWindow = errors.New("outside maintenance window")
+
+	// ErrDurationExceeded wraps the error of a migration cancelled for running longer than its
+	// expected duration, see [WithCancelSlowMigrations].
+	ErrDurationExceeded = errors.New("migration exceeded expected duration")
+
+	// ErrShadowFailed is returned when pending migrations fail on the shadow database set with
+	// [WithShadowDB]. The target database is left untouched.
+	ErrShadowFailed = errors.New("shadow database verification failed")
+
+	// ErrFullTableScan is returned when the plan of an UPDATE or DELETE statement scans a whole
+	// table above the threshold set with [WithExplainDML].
+	ErrFullTableScan = errors.New("full table scan")
+
+	// ErrTooManyRowsAffected is returned when a statement affects more rows than declared with a
+	// "-- +goose max-affected" directive. The migration is rolled back.
+	ErrTooManyRowsAffected = errors.New("too many rows affected")
+
+	// ErrPendingMigrations is returned by [CheckUpToDate] and [Provider.CheckUpToDate] when the
+	// database has migrations left to apply. The returned error is a [PendingMigrationsError].
+	ErrPendingMigrations = errors.New("pending migrations")
+
+	// ErrIrreversible is returned when rolling back a SQL migration whose Down section could not be
+	// derived from its Up statements, see [WithAutoDown].
+	ErrIrreversible = errors.New("irreversible migration")
+
+	// ErrDBRequirement is returned before any migrations run when the connected database does not
+	// satisfy the "-- +goose requires-db" directive of a pending migration.
+	ErrDBRequirement = errors.New("database requirement not met")
+
+	// ErrPreconditionFailed is returned when a query declared with a "-- +goose precondition"
+	// directive does not return the expected value before the Up statements of a migration run.
+	// The migration is not applied.
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrPreconditionSkipped is returned when a query declared with a "-- +goose precondition
+	// on-fail=skip" directive does not return the expected value. The run stops, and the migration
+	// and the ones after it are left pending. The error returned by a [Provider] is a
+	// [PreconditionSkipError].
+	ErrPreconditionSkipped = errors.New("precondition not met")
+
+	// ErrPostConditionFailed is returned when a query declared with a "-- +goose verify"
+	// directive does not return the expected value after the Up statements of a migration ran.
+	ErrPostConditionFailed = errors.New("post-condition failed")
+
+	// ErrSmokeTestFailed is returned when a smoke test set with [WithSmokeTest] fails after a run
+	// applied migrations. The returned error is a [SmokeTestError].
+	ErrSmokeTestFailed = errors.New("smoke test failed")
+
+	// ErrFrozen is returned by Up, UpByOne and UpTo when migrations are frozen, see
+	// [Provider.Freeze]. The returned error is a [FrozenError].
+	ErrFrozen = errors.New("migrations are frozen")
+
 	// errInvalidVersion is returned when a migration version is invalid.
 	errInvalidVersion = errors.New("version must be greater than 0")
 )
@@ -46,3 +108,103 @@ func (e *PartialError) Error() string {
 func (e *PartialError) Unwrap() error {
 	return e.Err
 }
+
+// PendingMigrationsError is returned when the database has migrations left to apply, see
+// [ErrPendingMigrations].
+type PendingMigrationsError struct {
+	// Versions are the pending versions, in ascending order.
+	Versions []int64
+}
+
+func (e *PendingMigrationsError) Error() string {
+	versions := make([]string, 0, len(e.Versions))
+	for _, v := range e.Versions {
+		versions = append(versions, strconv.FormatInt(v, 10))
+	}
+	return fmt.Sprintf("%v: %s", ErrPendingMigrations, strings.Join(versions, ", "))
+}
+
+func (e *PendingMigrationsError) Unwrap() error {
+	return ErrPendingMigrations
+}
+
+// FrozenError is returned when a run is refused because of a freeze of all scopes or of the scope
+// of the provider, see [Provider.Freeze].
+type FrozenError struct {
+	// Freezes are the freezes that apply to the provider.
+	Freezes []*FreezeRecord
+}
+
+func (e *FrozenError) Error() string {
+	freezes := make([]string, 0, len(e.Freezes))
+	for _, f := range e.Freezes {
+		freezes = append(freezes, fmt.Sprintf("%s since %s: %s", freezeScope(f.Scope), f.FrozenAt.Format(time.RFC3339), f.Reason))
+	}
+	return fmt.Sprintf("%v: %s", ErrFrozen, strings.Join(freezes, "; "))
+}
+
+func (e *FrozenError) Unwrap() error {
+	return ErrFrozen
+}
+
+// SmokeTestError is returned when a smoke test set with [WithSmokeTest] vetoes a run, see
+// [ErrSmokeTestFailed].
+type SmokeTestError struct {
+	// Applied are the migrations applied by the run.
+	Applied []*MigrationResult
+	// Err is the error of the smoke test.
+	Err error
+}
+
+func (e *SmokeTestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SmokeTestError) Unwrap() error {
+	return e.Err
+}
+
+// PreconditionSkipError is returned when a precondition with on-fail=skip stops a run, see
+// [ErrPreconditionSkipped].
+type PreconditionSkipError struct {
+	// Applied are the migrations applied by the run before the skipped one. It is empty if the run
+	// was applied in a single transaction with [WithAtomicUp], which is rolled back.
+	Applied []*MigrationResult
+	// Skipped is the migration whose precondition did not hold.
+	Skipped *Source
+	// Pending are the migrations of the run after the skipped one, which were held back.
+	Pending []*Source
+	// Err is the error of the precondition, wrapping [ErrPreconditionSkipped].
+	Err error
+}
+
+func (e *PreconditionSkipError) Error() string {
+	return fmt.Sprintf("skipped migration (type:%s,version:%d) and the %d pending after it: %v",
+		e.Skipped.Type, e.Skipped.Version, len(e.Pending), e.Err)
+}
+
+func (e *PreconditionSkipError) Unwrap() error {
+	return e.Err
+}
+
+// RollbackError is returned when a run failed and the migrations it applied were rolled back, see
+// [WithAutoRollbackOnFailure] and [WithSmokeTestRollback].
+type RollbackError struct {
+	// Err is the error that failed the run, e.g., a [PartialError] or a [SmokeTestError].
+	Err error
+	// Report describes what was rolled back. Cannot be nil.
+	Report *RollbackReport
+}
+
+func (e *RollbackError) Error() string {
+	msg := fmt.Sprintf("%v; rolled back %d migrations from version %d to %d",
+		e.Err, len(e.Report.RolledBack), e.Report.FromVersion, e.Report.ToVersion)
+	if e.Report.Err != nil {
+		msg += fmt.Sprintf(", then failed to roll back: %v", e.Report.Err)
+	}
+	return msg
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
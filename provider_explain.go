@@ -0,0 +1,163 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+// tableScan is a full table scan found in the plan of a statement.
+type tableScan struct {
+	table string
+	rows  int64
+}
+
+// explainDML runs EXPLAIN on stmt, if it is an UPDATE or DELETE, and fails with ErrFullTableScan,
+// or only warns with [WithExplainWarnOnly], when the plan scans a whole table estimated to hold
+// more than the threshold set with [WithExplainDML]. EXPLAIN does not run the statement.
+func (p *Provider) explainDML(ctx context.Context, db database.DBTxConn, m *Migration, stmt string) error {
+	if !p.cfg.explainDML || !isUpdateOrDelete(stmt) {
+		return nil
+	}
+	var scans []tableScan
+	var err error
+	switch p.dialect {
+	case DialectPostgres:
+		scans, err = explainPostgres(ctx, db, stmt)
+	case DialectMySQL:
+		scans, err = explainMySQL(ctx, db, stmt)
+	default:
+		return fmt.Errorf("explaining statements is not supported by the %s dialect", p.dialect)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to explain statement: %w", err)
+	}
+	for _, scan := range scans {
+		if scan.rows <= p.cfg.explainMaxRows {
+			continue
+		}
+		err := fmt.Errorf("%w of %s (estimated %d rows) in migration %s: %s",
+			ErrFullTableScan, scan.table, scan.rows, m.ref(), firstLine(stmt))
+		if !p.cfg.explainWarnOnly {
+			return err
+		}
+		p.cfg.logger.Printf("goose: warning: %v", err)
+	}
+	return nil
+}
+
+// explainPostgres returns the sequential scans in the plan of stmt.
+func explainPostgres(ctx context.Context, db database.DBTxConn, stmt string) ([]tableScan, error) {
+	var data []byte
+	if err := db.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+stmt).Scan(&data); err != nil {
+		return nil, err
+	}
+	var plans []struct {
+		Plan postgresPlan `json:"Plan"`
+	}
+	if err := json.Unmarshal(data, &plans); err != nil {
+		return nil, fmt.Errorf("failed to decode plan: %w", err)
+	}
+	var scans []tableScan
+	for _, plan := range plans {
+		scans = plan.Plan.appendScans(scans)
+	}
+	return scans, nil
+}
+
+// postgresPlan is a node of a Postgres JSON plan.
+type postgresPlan struct {
+	NodeType     string         `json:"Node Type"`
+	RelationName string         `json:"Relation Name"`
+	PlanRows     float64        `json:"Plan Rows"`
+	Plans        []postgresPlan `json:"Plans"`
+}
+
+func (n postgresPlan) appendScans(scans []tableScan) []tableScan {
+	if n.NodeType == "Seq Scan" {
+		scans = append(scans, tableScan{table: n.RelationName, rows: int64(n.PlanRows)})
+	}
+	for _, child := range n.Plans {
+		scans = child.appendScans(scans)
+	}
+	return scans
+}
+
+// explainMySQL returns the full table scans, of access type ALL, in the plan of stmt.
+func explainMySQL(ctx context.Context, db database.DBTxConn, stmt string) (_ []tableScan, retErr error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN "+stmt)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	// The columns of EXPLAIN differ between versions, so they are looked up by name.
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var scans []tableScan
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]string, len(columns))
+		for i, c := range columns {
+			row[strings.ToLower(c)] = values[i].String
+		}
+		if row["type"] != "ALL" {
+			continue
+		}
+		n, err := strconv.ParseInt(row["rows"], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid row estimate %q: %w", row["rows"], err)
+		}
+		scans = append(scans, tableScan{table: row["table"], rows: n})
+	}
+	return scans, rows.Err()
+}
+
+// isUpdateOrDelete reports whether stmt is an UPDATE or DELETE statement, ignoring leading
+// comments.
+func isUpdateOrDelete(stmt string) bool {
+	fields := strings.Fields(trimLeadingComments(stmt))
+	if len(fields) == 0 {
+		return false
+	}
+	keyword := strings.ToUpper(fields[0])
+	return keyword == "UPDATE" || keyword == "DELETE"
+}
+
+// trimLeadingComments returns stmt without the line and block comments before its first keyword.
+func trimLeadingComments(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+		switch {
+		case strings.HasPrefix(stmt, "--"):
+			_, stmt, _ = strings.Cut(stmt, "\n")
+		case strings.HasPrefix(stmt, "/*"):
+			_, stmt, _ = strings.Cut(stmt, "*/")
+		default:
+			return stmt
+		}
+	}
+}
+
+// firstLine returns the first line of stmt, to identify it in errors.
+func firstLine(stmt string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(stmt), "\n")
+	return line
+}
@@ -0,0 +1,37 @@
+package goose
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsUpdateOrDelete(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, isUpdateOrDelete("UPDATE users SET name = 'a';"))
+	require.True(t, isUpdateOrDelete("  delete from users;"))
+	require.True(t, isUpdateOrDelete("-- remove stale rows\n/* see #12 */ DELETE FROM users;"))
+	require.False(t, isUpdateOrDelete("INSERT INTO users VALUES (1);"))
+	require.False(t, isUpdateOrDelete("CREATE TABLE updates (id INTEGER);"))
+	require.False(t, isUpdateOrDelete("-- UPDATE users\nSELECT 1;"))
+	require.False(t, isUpdateOrDelete(""))
+}
+
+func TestPostgresPlanScans(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`[{"Plan": {"Node Type": "ModifyTable", "Relation Name": "users", "Plan Rows": 0, "Plans": [
+		{"Node Type": "Hash Join", "Plan Rows": 10, "Plans": [
+			{"Node Type": "Seq Scan", "Relation Name": "users", "Plan Rows": 250000},
+			{"Node Type": "Index Scan", "Relation Name": "orders", "Plan Rows": 10}
+		]}
+	]}}]`)
+	var plans []struct {
+		Plan postgresPlan `json:"Plan"`
+	}
+	require.NoError(t, json.Unmarshal(data, &plans))
+	require.Len(t, plans, 1)
+	require.Equal(t, []tableScan{{table: "users", rows: 250000}}, plans[0].Plan.appendScans(nil))
+}
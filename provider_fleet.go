@@ -0,0 +1,119 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// FleetTimeouts bound the steps of [Provider.RunOncePerFleet].
+type FleetTimeouts struct {
+	// Lock is how long a process tries to acquire the session lock to become the leader. It should
+	// be short: a process that does not get the lock waits for the leader instead. Zero defaults to
+	// 5 seconds.
+	Lock time.Duration
+	// Wait is how long a process that did not get the lock waits for the leader to apply the
+	// migrations. Zero waits until ctx is done.
+	Wait time.Duration
+	// PollInterval is how often a waiting process checks for pending migrations. Zero defaults to
+	// 1 second.
+	PollInterval time.Duration
+}
+
+// FleetResult is the result of [Provider.RunOncePerFleet].
+type FleetResult struct {
+	// Leader indicates this process acquired the session lock and applied the migrations. If
+	// false, another process applied them, or there was nothing to apply.
+	Leader bool
+	// Results are the migrations applied by this process. Empty if Leader is false.
+	Results []*MigrationResult
+	// Duration is how long RunOncePerFleet took, including waiting for the lock or the leader.
+	Duration time.Duration
+}
+
+// RunOncePerFleet applies pending migrations from exactly one process of a fleet, such as the
+// replicas of a Kubernetes deployment starting at the same time. It requires a session locker, see
+// [WithSessionLocker].
+//
+// If no migrations are pending, it returns immediately. Otherwise, the process that acquires the
+// session lock within timeouts.Lock becomes the leader and applies the migrations, like
+// [Provider.Up]. Every other process waits for the leader with [Provider.WaitForNoPending], then
+// verifies the checksums of the applied migrations with [Provider.Verify], so replicas running a
+// different set of migration files fail to start.
+func (p *Provider) RunOncePerFleet(ctx context.Context, timeouts FleetTimeouts) (*FleetResult, error) {
+	if p.cfg.sessionLocker == nil || !p.cfg.lockEnabled {
+		return nil, errors.New("running once per fleet requires a session locker, see WithSessionLocker")
+	}
+	if timeouts.Lock <= 0 {
+		timeouts.Lock = 5 * time.Second
+	}
+	if timeouts.PollInterval <= 0 {
+		timeouts.PollInterval = time.Second
+	}
+	start := time.Now()
+	res := &FleetResult{}
+	err := p.CheckUpToDate(ctx)
+	if err == nil {
+		res.Duration = time.Since(start)
+		return res, nil
+	}
+	if !errors.Is(err, ErrPendingMigrations) {
+		return nil, err
+	}
+	results, err := p.Up(withLockTimeout(ctx, timeouts.Lock))
+	if err == nil {
+		// Another leader may have applied the migrations between the check and the lock.
+		res.Leader = len(results) > 0
+		res.Results = results
+		res.Duration = time.Since(start)
+		return res, nil
+	}
+	if !errors.Is(err, errLockTimeout) {
+		return nil, err
+	}
+	p.printf("session lock held by another process, waiting for its migrations")
+	waitCtx := ctx
+	if timeouts.Wait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeouts.Wait)
+		defer cancel()
+	}
+	if err := p.WaitForNoPending(waitCtx, timeouts.PollInterval); err != nil {
+		return nil, fmt.Errorf("failed to wait for the leader: %w", err)
+	}
+	if err := p.Verify(ctx); err != nil {
+		return nil, fmt.Errorf("failed to verify migrations applied by the leader: %w", err)
+	}
+	res.Duration = time.Since(start)
+	return res, nil
+}
+
+// errLockTimeout is returned when the session lock was not acquired within the timeout set with
+// [withLockTimeout].
+var errLockTimeout = errors.New("session lock not acquired before timeout")
+
+type lockTimeoutKey struct{}
+
+// withLockTimeout returns a copy of ctx that bounds the session lock acquisition of a run, but not
+// the run itself.
+func withLockTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, lockTimeoutKey{}, timeout)
+}
+
+// sessionLock acquires the session lock of the provider on conn, within the timeout set with
+// [withLockTimeout], if any.
+func (p *Provider) sessionLock(ctx context.Context, conn *sql.Conn) error {
+	timeout, ok := ctx.Value(lockTimeoutKey{}).(time.Duration)
+	if !ok {
+		return p.cfg.sessionLocker.SessionLock(ctx, conn)
+	}
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := p.cfg.sessionLocker.SessionLock(lockCtx, conn)
+	if err != nil && ctx.Err() == nil && lockCtx.Err() != nil {
+		return fmt.Errorf("%w: %w", errLockTimeout, err)
+	}
+	return err
+}
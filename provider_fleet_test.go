@@ -0,0 +1,108 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunOncePerFleet(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	newFsys := func() fstest.MapFS {
+		return fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+		}
+	}
+	timeouts := goose.FleetTimeouts{Lock: 20 * time.Millisecond, PollInterval: 5 * time.Millisecond}
+
+	t.Run("leader", func(t *testing.T) {
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, newFsys(), goose.WithSessionLocker(newChanLocker()))
+		require.NoError(t, err)
+		res, err := p.RunOncePerFleet(ctx, timeouts)
+		require.NoError(t, err)
+		require.True(t, res.Leader)
+		require.Len(t, res.Results, 2)
+		// Nothing is left to apply, the next process returns immediately.
+		res, err = p.RunOncePerFleet(ctx, timeouts)
+		require.NoError(t, err)
+		require.False(t, res.Leader)
+		require.Empty(t, res.Results)
+	})
+	t.Run("follower", func(t *testing.T) {
+		// The follower polls while the leader writes, wait for the write lock of the file.
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "fleet.db")+"?_pragma=busy_timeout(5000)")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		locker := newChanLocker()
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, newFsys(), goose.WithSessionLocker(locker))
+		require.NoError(t, err)
+		// Another process holds the lock while it applies the migrations.
+		locker.ch <- struct{}{}
+		done := make(chan error, 1)
+		var res *goose.FleetResult
+		go func() {
+			var err error
+			res, err = p.RunOncePerFleet(ctx, timeouts)
+			done <- err
+		}()
+		leader, err := goose.NewProvider(goose.DialectSQLite3, db, newFsys())
+		require.NoError(t, err)
+		time.Sleep(2 * timeouts.Lock)
+		_, err = leader.Up(ctx)
+		require.NoError(t, err)
+		<-locker.ch
+		require.NoError(t, <-done)
+		require.False(t, res.Leader)
+		require.Empty(t, res.Results)
+	})
+	t.Run("wait_timeout", func(t *testing.T) {
+		locker := newChanLocker()
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithSessionLocker(locker))
+		require.NoError(t, err)
+		locker.ch <- struct{}{}
+		timeouts := timeouts
+		timeouts.Wait = 20 * time.Millisecond
+		_, err = p.RunOncePerFleet(ctx, timeouts)
+		require.ErrorIs(t, err, goose.ErrPendingMigrations)
+		require.ErrorContains(t, err, "failed to wait for the leader")
+	})
+	t.Run("no_locker", func(t *testing.T) {
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys())
+		require.NoError(t, err)
+		_, err = p.RunOncePerFleet(ctx, timeouts)
+		require.ErrorContains(t, err, "requires a session locker")
+	})
+}
+
+// chanLocker is a session locker held by whoever sent to its channel.
+type chanLocker struct {
+	ch chan struct{}
+}
+
+func newChanLocker() *chanLocker {
+	return &chanLocker{ch: make(chan struct{}, 1)}
+}
+
+func (l *chanLocker) SessionLock(ctx context.Context, _ *sql.Conn) error {
+	select {
+	case l.ch <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *chanLocker) SessionUnlock(context.Context, *sql.Conn) error {
+	<-l.ch
+	return nil
+}
@@ -0,0 +1,173 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3/database"
+	"go.uber.org/multierr"
+)
+
+// freezeKeyPrefix is the prefix of the metadata keys of freezes, followed by the frozen scope.
+// Freezes are recorded for version 0, like the other state not tied to a migration.
+const freezeKeyPrefix = "freeze:"
+
+// Freeze stops Up, UpByOne, UpTo, ApplyVersion in the up direction and the Redo methods from
+// applying migrations of scope, on every provider sharing the version table, until
+// [Provider.Unfreeze] is called, e.g., to put an emergency brake on deployments during an
+// incident. An empty scope freezes all scopes; otherwise only providers with the same
+// [WithRegistryScope] are stopped. Runs fail with a [FrozenError], unless the provider is
+// configured with [WithIgnoreFreeze]. Freezing a frozen scope replaces its reason.
+//
+// The freeze is kept in the metadata table, which requires a store with metadata support.
+func (p *Provider) Freeze(ctx context.Context, scope, reason string) (retErr error) {
+	conn, cleanup, err := p.initialize(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, cleanup())
+	}()
+	return freeze(ctx, p.store, conn, scope, reason)
+}
+
+// Unfreeze lifts the freeze of scope set with [Provider.Freeze]. The freeze of all scopes is lifted
+// with an empty scope; it does not lift the freezes of individual scopes. Unfreezing a scope that
+// is not frozen is not an error.
+func (p *Provider) Unfreeze(ctx context.Context, scope string) (retErr error) {
+	conn, cleanup, err := p.initialize(ctx, false)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, cleanup())
+	}()
+	return unfreeze(ctx, p.store, conn, scope)
+}
+
+// Freezes returns the freezes set with [Provider.Freeze], sorted by scope, with the freeze of all
+// scopes first.
+func (p *Provider) Freezes(ctx context.Context) (_ []*FreezeRecord, retErr error) {
+	conn, cleanup, err := p.initialize(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, cleanup())
+	}()
+	return listFreezes(ctx, p.store, conn)
+}
+
+// checkFreeze returns a [FrozenError] if all scopes or the scope of the provider are frozen.
+func (p *Provider) checkFreeze(ctx context.Context, db database.DBTxConn) error {
+	if p.cfg.ignoreFreeze {
+		return nil
+	}
+	return checkFreeze(ctx, p.store, db, p.cfg.registryScope)
+}
+
+func freeze(ctx context.Context, store database.MetadataStore, conn *sql.Conn, scope, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("freeze reason must not be empty")
+	}
+	if err := store.CreateMetadataTable(ctx, conn); err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return fmt.Errorf("freezing migrations requires a store with metadata support: %w", err)
+		}
+		return err
+	}
+	data, err := json.Marshal(&FreezeRecord{Scope: scope, Reason: reason, FrozenAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	return beginTx(ctx, conn, func(tx *sql.Tx) error {
+		if err := store.DeleteMetadataKey(ctx, tx, 0, freezeKeyPrefix+scope); err != nil {
+			return err
+		}
+		return store.InsertMetadata(ctx, tx, 0, freezeKeyPrefix+scope, string(data))
+	})
+}
+
+func unfreeze(ctx context.Context, store database.MetadataStore, db database.DBTxConn, scope string) error {
+	exists, err := store.MetadataTableExists(ctx, db)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	return store.DeleteMetadataKey(ctx, db, 0, freezeKeyPrefix+scope)
+}
+
+// listFreezes returns the recorded freezes, see [Provider.Freezes]. It returns nil if the store
+// has no metadata table.
+func listFreezes(ctx context.Context, store database.MetadataStore, db database.DBTxConn) ([]*FreezeRecord, error) {
+	exists, err := store.MetadataTableExists(ctx, db)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	results, err := store.ListMetadata(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	var freezes []*FreezeRecord
+	for _, r := range results {
+		scope, ok := strings.CutPrefix(r.Key, freezeKeyPrefix)
+		if !ok || r.Version != 0 {
+			continue
+		}
+		f := new(FreezeRecord)
+		if err := json.Unmarshal([]byte(r.Value), f); err != nil {
+			// A freeze that cannot be decoded, e.g., written by a newer version of goose, still
+			// applies.
+			f = &FreezeRecord{Reason: r.Value}
+		}
+		f.Scope = scope
+		freezes = append(freezes, f)
+	}
+	sort.Slice(freezes, func(i, j int) bool {
+		return freezes[i].Scope < freezes[j].Scope
+	})
+	return freezes, nil
+}
+
+// checkFreeze returns a [FrozenError] if all scopes or the given scope are frozen.
+func checkFreeze(ctx context.Context, store database.MetadataStore, db database.DBTxConn, scope string) error {
+	freezes, err := listFreezes(ctx, store, db)
+	if err != nil {
+		return fmt.Errorf("failed to check for freezes: %w", err)
+	}
+	var applies []*FreezeRecord
+	for _, f := range freezes {
+		if f.Scope == "" || f.Scope == scope {
+			applies = append(applies, f)
+		}
+	}
+	if len(applies) > 0 {
+		return &FrozenError{Freezes: applies}
+	}
+	return nil
+}
+
+// freezeScope describes the scope of a freeze in messages.
+func freezeScope(scope string) string {
+	if scope == "" {
+		return "all scopes"
+	}
+	return fmt.Sprintf("scope %q", scope)
+}
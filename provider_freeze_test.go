@@ -0,0 +1,84 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreeze(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	billing, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRegistryScope("billing"))
+	require.NoError(t, err)
+	freezes, err := p.Freezes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, freezes)
+	require.NoError(t, p.Unfreeze(ctx, ""))
+
+	require.NoError(t, p.Freeze(ctx, "billing", "incident 42"))
+	_, err = billing.UpByOne(ctx)
+	require.ErrorIs(t, err, goose.ErrFrozen)
+	require.ErrorContains(t, err, `migrations are frozen: scope "billing" since `)
+	require.ErrorContains(t, err, ": incident 42")
+	// Other scopes are not frozen.
+	_, err = p.UpByOne(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Freeze(ctx, "", "release freeze"))
+	freezes, err = p.Freezes(ctx)
+	require.NoError(t, err)
+	require.Len(t, freezes, 2)
+	require.Equal(t, "", freezes[0].Scope)
+	require.Equal(t, "release freeze", freezes[0].Reason)
+	require.False(t, freezes[0].FrozenAt.IsZero())
+	require.Equal(t, "billing", freezes[1].Scope)
+	_, err = p.Up(ctx)
+	var frozenErr *goose.FrozenError
+	require.ErrorAs(t, err, &frozenErr)
+	require.Len(t, frozenErr.Freezes, 1)
+	_, err = billing.Up(ctx)
+	require.ErrorAs(t, err, &frozenErr)
+	require.Len(t, frozenErr.Freezes, 2)
+	require.False(t, tableExists(t, db, "b"))
+	// Applying a single version or redoing applied ones is stopped too, rolling back is not.
+	_, err = p.ApplyVersion(ctx, 2, true)
+	require.ErrorIs(t, err, goose.ErrFrozen)
+	_, err = p.RedoLast(ctx, 1)
+	require.ErrorIs(t, err, goose.ErrFrozen)
+	_, err = p.ApplyVersion(ctx, 1, false)
+	require.NoError(t, err)
+	_, err = p.ApplyVersion(ctx, 1, true)
+	require.ErrorIs(t, err, goose.ErrFrozen)
+	current, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, current)
+
+	// The freeze can be overridden, e.g., to ship a fix.
+	override, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithIgnoreFreeze(true))
+	require.NoError(t, err)
+	_, err = override.UpTo(ctx, 2)
+	require.NoError(t, err)
+	require.True(t, tableExists(t, db, "b"))
+
+	require.NoError(t, p.Unfreeze(ctx, ""))
+	require.NoError(t, p.Unfreeze(ctx, "billing"))
+	freezes, err = p.Freezes(ctx)
+	require.NoError(t, err)
+	require.Empty(t, freezes)
+	_, err = billing.Up(ctx)
+	require.NoError(t, err)
+
+	require.ErrorContains(t, p.Freeze(ctx, "", " "), "freeze reason must not be empty")
+}
@@ -0,0 +1,190 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"go.uber.org/multierr"
+)
+
+// concurrentIndex is a CREATE INDEX CONCURRENTLY statement and the name of the index it builds.
+type concurrentIndex struct {
+	stmt string
+	name string
+}
+
+// concurrentIndexes rewrites the named CREATE INDEX statements to build their index CONCURRENTLY,
+// see [WithConcurrentIndexes]. The statements of a migration that runs in a transaction are
+// removed and returned separately, to be run after it committed; otherwise they are rewritten in
+// place.
+func concurrentIndexes(statements []string, useTx bool) ([]string, []concurrentIndex) {
+	var indexes []concurrentIndex
+	kept := statements[:0:0]
+	for _, stmt := range statements {
+		rewritten, name, ok := sqlparser.ConcurrentIndex(stmt)
+		switch {
+		case !ok:
+			kept = append(kept, stmt)
+		case useTx:
+			indexes = append(indexes, concurrentIndex{stmt: rewritten, name: name})
+		default:
+			kept = append(kept, rewritten)
+		}
+	}
+	return kept, indexes
+}
+
+// pendingIndexesKey is the metadata key of the names of the indexes moved out of the transaction
+// of an applied version that are not built yet, separated by spaces.
+const pendingIndexesKey = "pending_indexes"
+
+// createIndexes builds the given indexes moved out of the transaction of the migration, in order,
+// on conn. If track is true, the indexes still to be built are recorded as pending after each
+// build, so that the next Up resumes with the index that failed.
+func (p *Provider) createIndexes(
+	ctx context.Context,
+	conn *sql.Conn,
+	m *Migration,
+	indexes []concurrentIndex,
+	track bool,
+) error {
+	for i, idx := range indexes {
+		start := time.Now()
+		run := func() error {
+			_, err := conn.ExecContext(ctx, idx.stmt)
+			return err
+		}
+		if err := p.retryIndex(ctx, conn, idx.name, run(), run); err != nil {
+			if track {
+				return fmt.Errorf("failed to create index %s of migration %s, which is applied without it "+
+					"until the next up builds it: %w", idx.name, m.ref(), err)
+			}
+			return fmt.Errorf("failed to create index %s of migration %s: %w", idx.name, m.ref(), err)
+		}
+		p.printf("created index %s concurrently (%s)", idx.name, truncateDuration(time.Since(start)))
+		if track {
+			if err := p.savePendingIndexes(ctx, conn, m, indexes[i+1:]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// savePendingIndexes records the given indexes of m as pending, replacing the ones recorded
+// before. If there are none, the record is removed.
+func (p *Provider) savePendingIndexes(
+	ctx context.Context,
+	db database.DBTxConn,
+	m *Migration,
+	indexes []concurrentIndex,
+) error {
+	if err := p.store.DeleteMetadataKey(ctx, db, m.Version, pendingIndexesKey); err != nil {
+		return fmt.Errorf("failed to record pending indexes: %w", err)
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(indexes))
+	for _, idx := range indexes {
+		names = append(names, idx.name)
+	}
+	if err := p.store.InsertMetadata(ctx, db, m.Version, pendingIndexesKey, strings.Join(names, " ")); err != nil {
+		return fmt.Errorf("failed to record pending indexes: %w", err)
+	}
+	return nil
+}
+
+// resumeIndexes builds the indexes that a previous run left pending after it applied the
+// migration that declares them, see [WithConcurrentIndexes].
+func (p *Provider) resumeIndexes(ctx context.Context, conn *sql.Conn) error {
+	exists, err := p.store.MetadataTableExists(ctx, conn)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return err
+	}
+	if !exists {
+		return nil
+	}
+	metadata, err := p.store.ListMetadata(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for _, r := range metadata {
+		if r.Key != pendingIndexesKey {
+			continue
+		}
+		m, err := p.getMigration(r.Version)
+		if err != nil {
+			p.cfg.logger.Printf("goose: warning: not resuming pending indexes %s of missing version %d", r.Value, r.Version)
+			continue
+		}
+		if err := p.prepareMigration(p.fsys, m, true); err != nil {
+			return fmt.Errorf("failed to prepare migration %s: %w", m.ref(), err)
+		}
+		var pending []concurrentIndex
+		for _, name := range strings.Fields(r.Value) {
+			for _, idx := range m.sql.Indexes {
+				if idx.name == name {
+					pending = append(pending, idx)
+				}
+			}
+		}
+		p.printf("resuming %d pending indexes of %s", len(pending), m.ref())
+		if err := p.createIndexes(ctx, conn, m, pending, true); err != nil {
+			return err
+		}
+		// Indexes no longer declared by the migration are not built, so the record is dropped
+		// even if none were left to build.
+		if err := p.savePendingIndexes(ctx, conn, m, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryIndex handles err, the error of a concurrent build of the named index. If the failed build
+// left an INVALID index behind, the index is dropped, and run retried up to the configured number
+// of retries. An index that is still invalid after the last attempt is dropped too, so that a
+// later run does not skip it with IF NOT EXISTS.
+func (p *Provider) retryIndex(ctx context.Context, db database.DBTxConn, name string, err error, run func() error) error {
+	for attempt := 0; err != nil; attempt++ {
+		invalid, checkErr := invalidIndex(ctx, db, name)
+		if checkErr != nil {
+			return multierr.Append(err, checkErr)
+		}
+		if !invalid {
+			return err
+		}
+		if _, dropErr := db.ExecContext(ctx, "DROP INDEX CONCURRENTLY IF EXISTS "+name); dropErr != nil {
+			return multierr.Append(err, fmt.Errorf("failed to drop invalid index %s: %w", name, dropErr))
+		}
+		if attempt >= p.cfg.indexRetries {
+			return fmt.Errorf("index %s was invalid after %d attempts and was dropped: %w", name, attempt+1, err)
+		}
+		p.printf("dropped invalid index %s, retrying: %v", name, err)
+		err = run()
+	}
+	return nil
+}
+
+// invalidIndex reports whether the named index exists and is marked INVALID.
+func invalidIndex(ctx context.Context, db database.DBTxConn, name string) (bool, error) {
+	var invalid bool
+	q := "SELECT NOT indisvalid FROM pg_catalog.pg_index WHERE indexrelid = to_regclass($1)"
+	if err := db.QueryRowContext(ctx, q, name).Scan(&invalid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check index %s: %w", name, err)
+	}
+	return invalid, nil
+}
@@ -0,0 +1,126 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentIndexes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\nCREATE INDEX a_id ON a (id);\nCREATE INDEX ON a (id);\nINSERT INTO a VALUES (1);\n"),
+		"00002_b.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE UNIQUE INDEX b_id ON app.b (id);\nSELECT 1;\n"),
+	}
+	store := goosetest.NewStore("goose_db_version")
+	db, rec := goosetest.NewDB()
+	p, err := goose.NewProvider("", db, fsys, goose.WithStore(store), goose.WithConcurrentIndexes(1))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	// Named indexes are built after the transaction that recorded the version committed.
+	require.Equal(t, []string{
+		goosetest.Begin, goosetest.Commit, // version table
+		goosetest.Begin, "CREATE TABLE a (id INTEGER);", "CREATE INDEX ON a (id);", "INSERT INTO a VALUES (1);", goosetest.Commit,
+		"CREATE INDEX CONCURRENTLY a_id ON a (id);",
+		"CREATE UNIQUE INDEX CONCURRENTLY b_id ON app.b (id);", "SELECT 1;",
+	}, rec.Queries())
+	require.Equal(t, []int64{0, 1, 2}, store.Versions())
+
+	t.Run("retry", func(t *testing.T) {
+		for _, tc := range []struct {
+			failures int
+			err      string
+		}{
+			{failures: 1},
+			{failures: 2, err: "index app.b_id was invalid after 2 attempts and was dropped: deadlock detected"},
+		} {
+			failures := tc.failures
+			db, rec := goosetest.NewDB(
+				goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+					if strings.HasPrefix(query, "CREATE UNIQUE INDEX CONCURRENTLY") && failures > 0 {
+						failures--
+						return nil, errors.New("deadlock detected")
+					}
+					return nil, nil
+				}),
+				// The failed build left an invalid index behind.
+				goosetest.WithQuery(func(ctx context.Context, query string, args []any) ([][]any, error) {
+					if strings.Contains(query, "pg_index") {
+						return [][]any{{true}}, nil
+					}
+					return nil, nil
+				}),
+			)
+			store := goosetest.NewStore("goose_db_version")
+			store.SetVersions(1)
+			p, err := goose.NewProvider("", db, fsys, goose.WithStore(store), goose.WithConcurrentIndexes(1))
+			require.NoError(t, err)
+			_, err = p.Up(ctx)
+			var drops int
+			for _, q := range rec.Queries() {
+				if q == "DROP INDEX CONCURRENTLY IF EXISTS app.b_id" {
+					drops++
+				}
+			}
+			require.Equal(t, tc.failures, drops)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				require.Equal(t, []int64{0, 1}, store.Versions())
+				continue
+			}
+			require.NoError(t, err)
+			require.Equal(t, []int64{0, 1, 2}, store.Versions())
+		}
+	})
+	t.Run("resume", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\nCREATE INDEX a_id ON a (id);\nCREATE INDEX a_id2 ON a (id);\n"),
+		}
+		fail := true
+		db, rec := goosetest.NewDB(
+			goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+				if strings.HasPrefix(query, "CREATE INDEX CONCURRENTLY a_id2") && fail {
+					return nil, errors.New("could not create unique index")
+				}
+				return nil, nil
+			}),
+		)
+		store := goosetest.NewStore("goose_db_version")
+		p, err := goose.NewProvider("", db, fsys, goose.WithStore(store), goose.WithConcurrentIndexes(0))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "failed to create index a_id2 of migration (type:sql,version:1), "+
+			"which is applied without it until the next up builds it: could not create unique index")
+		// The version was recorded with the table, so the next up does not create it again.
+		require.Equal(t, []int64{0, 1}, store.Versions())
+		metadata, err := store.ListMetadata(ctx, nil)
+		require.NoError(t, err)
+		require.Equal(t, []*database.MetadataResult{{Version: 1, Key: "pending_indexes", Value: "a_id2"}}, metadata)
+
+		fail = false
+		rec.Reset()
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []string{"CREATE INDEX CONCURRENTLY a_id2 ON a (id);"}, rec.Queries())
+		metadata, err = store.ListMetadata(ctx, nil)
+		require.NoError(t, err)
+		require.Empty(t, metadata)
+	})
+	t.Run("dialect", func(t *testing.T) {
+		_, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithConcurrentIndexes(1))
+		require.ErrorContains(t, err, "concurrent indexes require the postgres dialect")
+		_, err = goose.NewProvider(goose.DialectPostgres, newDB(t), fsys, goose.WithConcurrentIndexes(-1))
+		require.ErrorContains(t, err, "index retries must not be negative")
+	})
+}
@@ -0,0 +1,344 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+const (
+	// defaultLoadBatch is how many rows a load directive inserts per statement by default.
+	defaultLoadBatch = 500
+	// maxLoadParams bounds the parameters of a single insert statement, below the limits of the
+	// supported databases, e.g., 2100 for SQL Server.
+	maxLoadParams = 2000
+)
+
+// dataLoad is a data file to load into a table after the Up statements of a migration, declared
+// with a "-- +goose load table=NAME file=PATH [format=csv|json] [batch=N]" directive.
+type dataLoad struct {
+	table  string
+	file   string
+	format string
+	batch  int
+}
+
+// parseLoads returns the data loads declared by the directives, in the order they appear. File
+// paths are relative to the directory of the migration at source, and must exist in fsys.
+func parseLoads(fsys fs.FS, source string, directives []sqlparser.Directive) ([]dataLoad, error) {
+	var loads []dataLoad
+	for _, d := range directives {
+		if d.Name != sqlparser.DirectiveLoad {
+			continue
+		}
+		load := dataLoad{batch: defaultLoadBatch}
+		for _, field := range strings.Fields(d.Value) {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("invalid %s directive %q: %q must be of form key=value", sqlparser.DirectiveLoad, d.Value, field)
+			}
+			switch key {
+			case "table":
+				load.table = value
+			case "file":
+				load.file = value
+			case "format":
+				load.format = strings.ToLower(value)
+			case "batch":
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 {
+					return nil, fmt.Errorf("invalid %s directive %q: batch must be a positive number of rows", sqlparser.DirectiveLoad, d.Value)
+				}
+				load.batch = n
+			default:
+				return nil, fmt.Errorf("invalid %s directive %q: unknown key %q", sqlparser.DirectiveLoad, d.Value, key)
+			}
+		}
+		if load.table == "" || load.file == "" {
+			return nil, fmt.Errorf("invalid %s directive %q: table and file are required", sqlparser.DirectiveLoad, d.Value)
+		}
+		if strings.Contains(load.table, ";") {
+			return nil, fmt.Errorf("invalid load table name: %q", load.table)
+		}
+		if load.format == "" {
+			load.format = strings.TrimPrefix(strings.ToLower(path.Ext(load.file)), ".")
+		}
+		if load.format != "csv" && load.format != "json" {
+			return nil, fmt.Errorf("invalid %s directive %q: format must be csv or json", sqlparser.DirectiveLoad, d.Value)
+		}
+		load.file = path.Join(path.Dir(source), load.file)
+		if !fs.ValidPath(load.file) {
+			return nil, fmt.Errorf("invalid load file: %q", load.file)
+		}
+		if _, err := fs.Stat(fsys, load.file); err != nil {
+			return nil, fmt.Errorf("failed to find load file: %w", err)
+		}
+		loads = append(loads, load)
+	}
+	return loads, nil
+}
+
+// loadData loads the data files declared by m into their tables, in batches of multi-row inserts.
+// Inserts work with every driver and run in the migration's transaction, so a failed load rolls
+// the migration back.
+func (p *Provider) loadData(ctx context.Context, db database.DBTxConn, m *Migration) error {
+	for _, load := range m.sql.Loads {
+		n, err := p.load(ctx, db, load)
+		if err != nil {
+			return fmt.Errorf("failed to load %s into %s: %w", load.file, load.table, err)
+		}
+		p.printf("loaded %d rows into %s from %s", n, load.table, load.file)
+	}
+	return nil
+}
+
+func (p *Provider) load(ctx context.Context, db database.DBTxConn, load dataLoad) (_ int, retErr error) {
+	f, err := p.fsys.Open(load.file)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err := f.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	var r rowReader
+	if load.format == "csv" {
+		r, err = newCSVReader(f)
+	} else {
+		r, err = newJSONReader(f)
+	}
+	if err != nil {
+		return 0, err
+	}
+	columns := r.columns()
+	batch := load.batch
+	if max := maxLoadParams / len(columns); batch > max {
+		batch = max
+	}
+	var total int
+	var rows [][]any
+	flush := func() error {
+		if len(rows) == 0 {
+			return nil
+		}
+		q, args := p.insertRows(load.table, columns, rows)
+		if _, err := db.ExecContext(ctx, q, args...); err != nil {
+			return fmt.Errorf("failed to insert rows %d-%d: %w", total+1, total+len(rows), err)
+		}
+		total += len(rows)
+		rows = rows[:0]
+		return nil
+	}
+	for {
+		row, err := r.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return total, fmt.Errorf("row %d: %w", total+len(rows)+1, err)
+		}
+		if rows = append(rows, row); len(rows) == batch {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, flush()
+}
+
+// insertRows returns a single insert statement for rows, with the placeholders of the dialect.
+func (p *Provider) insertRows(table string, columns []string, rows [][]any) (string, []any) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", "))
+	args := make([]any, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j, v := range row {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, v)
+			sb.WriteString(p.placeholder(len(args)))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String(), args
+}
+
+// placeholder returns the n-th query parameter placeholder of the dialect, starting at 1.
+func (p *Provider) placeholder(n int) string {
+	switch p.dialect {
+	case DialectPostgres, DialectRedshift:
+		return "$" + strconv.Itoa(n)
+	case DialectMSSQL:
+		return "@p" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// rowReader reads the rows of a data file, with values in the order of its columns.
+type rowReader interface {
+	columns() []string
+	next() ([]any, error)
+}
+
+// csvReader reads CSV files whose first record is the header of column names. Empty fields are
+// loaded as NULL.
+type csvReader struct {
+	r      *csv.Reader
+	header []string
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("missing header of column names")
+		}
+		return nil, err
+	}
+	return &csvReader{r: cr, header: append([]string(nil), header...)}, nil
+}
+
+func (r *csvReader) columns() []string { return r.header }
+
+func (r *csvReader) next() ([]any, error) {
+	record, err := r.r.Read()
+	if err != nil {
+		return nil, err
+	}
+	row := make([]any, len(record))
+	for i, field := range record {
+		if field != "" {
+			row[i] = field
+		}
+	}
+	return row, nil
+}
+
+// jsonReader reads JSON files holding an array of objects, one per row, without reading the whole
+// array at once. The columns are the keys of the first object, in sorted order; keys missing from
+// later objects are loaded as NULL.
+type jsonReader struct {
+	dec   *json.Decoder
+	keys  []string
+	first map[string]json.RawMessage
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	dec := json.NewDecoder(r)
+	jr := &jsonReader{dec: dec}
+	tok, err := dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("no objects to load")
+		}
+		return nil, err
+	}
+	if tok != json.Delim('[') {
+		return nil, fmt.Errorf("unexpected %v, must be an array of objects", tok)
+	}
+	first, err := jr.decode()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("no objects to load")
+		}
+		return nil, err
+	}
+	jr.first = first
+	for k := range jr.first {
+		jr.keys = append(jr.keys, k)
+	}
+	sort.Strings(jr.keys)
+	return jr, nil
+}
+
+func (r *jsonReader) decode() (map[string]json.RawMessage, error) {
+	if !r.dec.More() {
+		return nil, io.EOF
+	}
+	var obj map[string]json.RawMessage
+	if err := r.dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, errors.New("must be an object")
+	}
+	return obj, nil
+}
+
+func (r *jsonReader) columns() []string { return r.keys }
+
+func (r *jsonReader) next() ([]any, error) {
+	obj := r.first
+	r.first = nil
+	if obj == nil {
+		var err error
+		if obj, err = r.decode(); err != nil {
+			return nil, err
+		}
+	}
+	row := make([]any, len(r.keys))
+	for i, k := range r.keys {
+		raw, ok := obj[k]
+		if !ok {
+			continue
+		}
+		v, err := jsonValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", k, err)
+		}
+		row[i] = v
+		delete(obj, k)
+	}
+	for k := range obj {
+		return nil, fmt.Errorf("unknown column %q, not in the first object", k)
+	}
+	return row, nil
+}
+
+// jsonValue converts a JSON value to a query argument: strings and numbers as strings, booleans as
+// bools, null as NULL, and arrays and objects as their JSON text.
+func jsonValue(raw json.RawMessage) (any, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, errors.New("empty value")
+	}
+	switch raw[0] {
+	case 'n':
+		return nil, nil
+	case 't', 'f':
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	case '"':
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case '[', '{':
+		return string(raw), nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return nil, err
+	}
+	return n.String(), nil
+}
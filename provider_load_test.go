@@ -0,0 +1,76 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql":         newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER, name TEXT, email TEXT);\nCREATE TABLE countries (code TEXT, name TEXT, eu BOOLEAN);\n"),
+		"00002_b.sql":         newMapFile("-- +goose load table=users file=data/users.csv batch=2\n-- +goose load table=countries file=data/countries.json\n-- +goose Up\n"),
+		"data/users.csv":      newMapFile("id,name,email\n1,alice,alice@example.com\n2,\"bob, jr\",\n3,carol,carol@example.com\n"),
+		"data/countries.json": newMapFile(`[{"code": "DE", "name": "Germany", "eu": true}, {"code": "NO", "name": "Norway", "eu": false}, {"code": "XX"}]`),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	results, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.False(t, results[1].Empty)
+
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n))
+	require.Equal(t, 3, n)
+	var name string
+	var email sql.NullString
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT name, email FROM users WHERE id = 2").Scan(&name, &email))
+	require.Equal(t, "bob, jr", name)
+	require.False(t, email.Valid)
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM countries WHERE eu").Scan(&n))
+	require.Equal(t, 1, n)
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM countries WHERE name IS NULL").Scan(&n))
+	require.Equal(t, 1, n)
+
+	t.Run("rolled_back", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose load table=users file=users.csv\n-- +goose Up\nCREATE TABLE users (id INTEGER NOT NULL);\n"),
+			// The empty id is loaded as NULL and violates the constraint.
+			"users.csv": newMapFile("id\n1\n\"\"\n"),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "failed to load users.csv into users")
+		require.False(t, tableExists(t, db, "users"))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for value, want := range map[string]string{
+			"table=users":                        "table and file are required",
+			"table=users file=missing.csv":       "failed to find load file",
+			"table=users file=users.txt":         "format must be csv or json",
+			"table=users file=users.csv batch=0": "batch must be a positive number of rows",
+			"table=users file=users.csv on=1":    `unknown key "on"`,
+			"table=users file=../users.csv":      "invalid load file",
+		} {
+			fsys := fstest.MapFS{
+				"00001_a.sql": newMapFile("-- +goose load " + value + "\n-- +goose Up\nSELECT 1;\n"),
+				"users.csv":   newMapFile("id\n1\n"),
+				"users.txt":   newMapFile("id\n1\n"),
+			}
+			p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+			require.NoError(t, err)
+			_, err = p.Up(ctx)
+			require.ErrorContains(t, err, want, value)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+// lockBlocker is a session holding a lock a statement of a migration waits on.
+type lockBlocker struct {
+	session int64
+	user    string
+	state   string
+	// Age of the transaction of the session, or the time spent in its current command on MySQL.
+	age   time.Duration
+	query string
+}
+
+// sessionID returns the id of the database session of db, the transaction or connection a
+// migration runs on, so that the sessions blocking it can be looked up, see
+// [WithLockDiagnostics].
+func sessionID(ctx context.Context, db database.DBTxConn, dialect Dialect) (int64, error) {
+	q := "SELECT pg_backend_pid()"
+	if dialect == DialectMySQL {
+		q = "SELECT CONNECTION_ID()"
+	}
+	var id int64
+	if err := db.QueryRowContext(ctx, q).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get session id: %w", err)
+	}
+	return id, nil
+}
+
+// watchLocks reports the sessions blocking the statement stmt of m, run by the session with the
+// given id, every lock diagnostics interval until the returned function is called. Blockers are
+// looked up on a separate connection of the pool, and failures to look them up are logged; neither
+// interrupts the statement.
+func (p *Provider) watchLocks(ctx context.Context, m *Migration, stmt string, session int64) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		start := time.Now()
+		ticker := time.NewTicker(p.cfg.lockDiagnostics)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			blockers, err := p.lockBlockers(ctx, session)
+			if err != nil {
+				if ctx.Err() == nil {
+					p.cfg.logger.Printf("goose: warning: failed to look up lock blockers of migration %s: %v", m.ref(), err)
+				}
+				continue
+			}
+			if len(blockers) == 0 {
+				continue
+			}
+			p.cfg.logger.Printf("goose: migration %s has been waiting on a lock for %s: %s",
+				m.ref(), truncateDuration(time.Since(start)), oneLine(stmt))
+			for _, b := range blockers {
+				p.cfg.logger.Printf("goose:   blocked by session %d (user %s, %s for %s): %s",
+					b.session, b.user, b.state, truncateDuration(b.age), oneLine(b.query))
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// lockBlockers returns the sessions holding the locks the session with the given id waits on.
+func (p *Provider) lockBlockers(ctx context.Context, session int64) ([]lockBlocker, error) {
+	q := `SELECT a.pid, COALESCE(a.usename, ''), COALESCE(a.state, ''),
+	COALESCE(EXTRACT(EPOCH FROM now() - a.xact_start), 0), COALESCE(a.query, '')
+FROM pg_catalog.pg_stat_activity a
+WHERE a.pid = ANY (pg_catalog.pg_blocking_pids($1))
+ORDER BY a.pid`
+	args := []any{session}
+	if p.dialect == DialectMySQL {
+		q = `SELECT w.blocking_pid, COALESCE(l.user, ''), COALESCE(l.command, ''),
+	COALESCE(l.time, 0), COALESCE(l.info, '')
+FROM (
+	SELECT blocking_pid FROM sys.innodb_lock_waits WHERE waiting_pid = ?
+	UNION
+	SELECT blocking_pid FROM sys.schema_table_lock_waits WHERE waiting_pid = ?
+) w
+LEFT JOIN information_schema.processlist l ON l.id = w.blocking_pid
+ORDER BY w.blocking_pid`
+		args = []any{session, session}
+	}
+	rows, err := p.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var blockers []lockBlocker
+	for rows.Next() {
+		var b lockBlocker
+		var seconds float64
+		if err := rows.Scan(&b.session, &b.user, &b.state, &seconds, &b.query); err != nil {
+			return nil, err
+		}
+		b.age = time.Duration(seconds * float64(time.Second))
+		blockers = append(blockers, b)
+	}
+	return blockers, rows.Err()
+}
+
+// oneLine returns s with runs of whitespace collapsed, so that a statement fits on a log line.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// setLockTimeout sets the lock timeout of [WithLockRetry] on db, the transaction or connection a
+// migration runs on. The returned function resets the timeout of a connection, which outlives the
+// migration.
+func (p *Provider) setLockTimeout(ctx context.Context, db database.DBTxConn) (func() error, error) {
+	timeout := fmt.Sprintf("'%dms'", p.cfg.lockTimeout.Milliseconds())
+	if _, inTx := db.(*sql.Tx); inTx {
+		if _, err := db.ExecContext(ctx, "SET LOCAL lock_timeout = "+timeout); err != nil {
+			return nil, fmt.Errorf("failed to set lock timeout: %w", err)
+		}
+		return func() error { return nil }, nil
+	}
+	if _, err := db.ExecContext(ctx, "SET lock_timeout = "+timeout); err != nil {
+		return nil, fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	return func() error {
+		if _, err := db.ExecContext(ctx, "RESET lock_timeout"); err != nil {
+			return fmt.Errorf("failed to reset lock timeout: %w", err)
+		}
+		return nil
+	}, nil
+}
+
+// retryLocked runs fn, and runs it again after the configured wait each time it fails because a
+// statement of migration m timed out waiting on a lock, up to the configured number of retries,
+// see [WithLockRetry]. fn must be safe to run again, i.e., run a single statement or a whole
+// transaction.
+func (p *Provider) retryLocked(ctx context.Context, m *Migration, fn func() error) error {
+	err := fn()
+	if p.cfg.lockTimeout <= 0 {
+		return err
+	}
+	for attempt := 1; err != nil && isLockTimeout(err); attempt++ {
+		if attempt > p.cfg.lockRetries {
+			return fmt.Errorf("migration %s timed out waiting on a lock after %d attempts: %w", m.ref(), attempt, err)
+		}
+		p.cfg.logger.Printf("goose: warning: migration %s timed out waiting on a lock, retrying in %s (%d/%d)",
+			m.ref(), p.cfg.lockRetryWait, attempt, p.cfg.lockRetries)
+		timer := time.NewTimer(p.cfg.lockRetryWait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("waiting to retry migration %s: %w", m.ref(), ctx.Err())
+		case <-timer.C:
+		}
+		err = fn()
+	}
+	return err
+}
+
+// isLockTimeout reports whether err is the Postgres lock_not_available error raised when a
+// statement exceeds its lock timeout. The SQLSTATE is checked for drivers that expose it, e.g.,
+// pgx and lib/pq, and the message otherwise.
+func isLockTimeout(err error) bool {
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		return state.SQLState() == "55P03"
+	}
+	return strings.Contains(err.Error(), "due to lock timeout")
+}
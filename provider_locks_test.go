@@ -0,0 +1,120 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nALTER TABLE users ADD COLUMN email TEXT;\nSELECT 1;\n"),
+	}
+	db, _ := goosetest.NewDB(
+		// The ALTER TABLE waits on the lock held by session 7.
+		goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+			if strings.HasPrefix(query, "ALTER TABLE") {
+				time.Sleep(100 * time.Millisecond)
+			}
+			return nil, nil
+		}),
+		goosetest.WithQuery(func(ctx context.Context, query string, args []any) ([][]any, error) {
+			switch {
+			case strings.Contains(query, "pg_backend_pid"):
+				return [][]any{{int64(42)}}, nil
+			case strings.Contains(query, "pg_blocking_pids") && args[0] == int64(42):
+				return [][]any{{int64(7), "alice", "idle in transaction", float64(300), "UPDATE users\n  SET name = 'a'"}}, nil
+			}
+			return nil, nil
+		}),
+	)
+	logger := &bufferLogger{}
+	store := goosetest.NewStore("goose_db_version")
+	p, err := goose.NewProvider("", db, fsys, goose.WithStore(store), goose.WithLogger(logger),
+		goose.WithLockDiagnostics(20*time.Millisecond))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	out := logger.String()
+	require.Contains(t, out, "migration (type:sql,version:1) has been waiting on a lock for")
+	require.Contains(t, out, "blocked by session 7 (user alice, idle in transaction for 5m0s): UPDATE users SET name = 'a'")
+	require.NotContains(t, out, "SELECT 1")
+
+	t.Run("dialect", func(t *testing.T) {
+		_, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithLockDiagnostics(time.Second))
+		require.ErrorContains(t, err, "lock diagnostics require the postgres or mysql dialect")
+		_, err = goose.NewProvider(goose.DialectPostgres, newDB(t), fsys, goose.WithLockDiagnostics(0))
+		require.ErrorContains(t, err, "lock diagnostics interval must be positive")
+	})
+}
+
+func TestLockRetry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\nALTER TABLE users ADD COLUMN a_id INTEGER;\n"),
+		"00002_b.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE b (id INTEGER);\nALTER TABLE users ADD COLUMN b_id INTEGER;\n"),
+	}
+	newProvider := func(failures map[string]int) (*goose.Provider, *goosetest.Recorder, *goosetest.Store) {
+		db, rec := goosetest.NewDB(
+			// The ALTER TABLE statements time out waiting on a lock the first times they run.
+			goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+				if failures[query] > 0 {
+					failures[query]--
+					return nil, errors.New("canceling statement due to lock timeout")
+				}
+				return nil, nil
+			}),
+		)
+		store := goosetest.NewStore("goose_db_version")
+		p, err := goose.NewProvider("", db, fsys, goose.WithStore(store), goose.WithLogger(&bufferLogger{}),
+			goose.WithLockRetry(time.Second, time.Millisecond, 2))
+		require.NoError(t, err)
+		return p, rec, store
+	}
+
+	p, rec, store := newProvider(map[string]int{
+		"ALTER TABLE users ADD COLUMN a_id INTEGER;": 2,
+		"ALTER TABLE users ADD COLUMN b_id INTEGER;": 1,
+	})
+	_, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []int64{0, 1, 2}, store.Versions())
+	// The transaction is retried as a whole, and the statement of the NO TRANSACTION migration
+	// alone.
+	require.Equal(t, []string{
+		goosetest.Begin, goosetest.Commit, // version table
+		goosetest.Begin, "SET LOCAL lock_timeout = '1000ms'", "CREATE TABLE a (id INTEGER);", "ALTER TABLE users ADD COLUMN a_id INTEGER;", goosetest.Rollback,
+		goosetest.Begin, "SET LOCAL lock_timeout = '1000ms'", "CREATE TABLE a (id INTEGER);", "ALTER TABLE users ADD COLUMN a_id INTEGER;", goosetest.Rollback,
+		goosetest.Begin, "SET LOCAL lock_timeout = '1000ms'", "CREATE TABLE a (id INTEGER);", "ALTER TABLE users ADD COLUMN a_id INTEGER;", goosetest.Commit,
+		"SET lock_timeout = '1000ms'", "CREATE TABLE b (id INTEGER);",
+		"ALTER TABLE users ADD COLUMN b_id INTEGER;", "ALTER TABLE users ADD COLUMN b_id INTEGER;",
+		"RESET lock_timeout",
+	}, rec.Queries())
+
+	p, _, store = newProvider(map[string]int{"ALTER TABLE users ADD COLUMN a_id INTEGER;": 3})
+	_, err = p.Up(ctx)
+	require.ErrorContains(t, err, "timed out waiting on a lock after 3 attempts: canceling statement due to lock timeout")
+	require.Equal(t, []int64{0}, store.Versions())
+
+	t.Run("dialect", func(t *testing.T) {
+		_, err := goose.NewProvider(goose.DialectMySQL, newDB(t), fsys, goose.WithLockRetry(time.Second, time.Second, 1))
+		require.ErrorContains(t, err, "lock retry requires the postgres dialect")
+		_, err = goose.NewProvider(goose.DialectPostgres, newDB(t), fsys, goose.WithLockRetry(0, time.Second, 1))
+		require.ErrorContains(t, err, "lock timeout must be at least 1ms")
+		_, err = goose.NewProvider(goose.DialectPostgres, newDB(t), fsys, goose.WithLockRetry(time.Second, time.Second, -1))
+		require.ErrorContains(t, err, "lock retries must not be negative")
+	})
+}
@@ -0,0 +1,404 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"go.uber.org/multierr"
+)
+
+const (
+	// labelKeyPrefix is the prefix of run label keys in the metadata table, which keeps labels
+	// apart from other metadata recorded for a version.
+	labelKeyPrefix = "label:"
+	// checksumKey is the metadata key of the checksum recorded for an applied version.
+	checksumKey = "checksum"
+	// checksumAlgorithmKey is the metadata key of the name of the algorithm the checksum of a
+	// version was computed with. Checksums recorded without it were computed with SHA-256.
+	checksumAlgorithmKey = "checksum_algorithm"
+	// backupKey is the metadata key of where the affected tables were backed up before a version
+	// was applied.
+	backupKey = "backup"
+	// includedKey and skippedKey are the metadata keys of the reasons recorded for versions
+	// selected with [WithIncludeVersions] and [WithSkipVersions].
+	includedKey = "included"
+	skippedKey  = "skipped"
+)
+
+// prepareMetadata must be called before running migrations. If the provider records metadata,
+// such as run labels, or required is true, the metadata table is created. Otherwise, it notes
+// whether a metadata table from earlier runs exists, so that its rows are kept in sync with the
+// version table.
+func (p *Provider) prepareMetadata(ctx context.Context, conn *sql.Conn, required bool) error {
+	p.metadata = false
+	if p.cfg.disableVersioning {
+		return nil
+	}
+	if len(p.cfg.runLabels) > 0 || p.cfg.recordChecksums || len(p.repeatables) > 0 || p.cfg.checkpoints || p.cfg.backuper != nil ||
+		p.cfg.includeVersions != nil || p.cfg.skipVersions != nil || required {
+		if err := p.store.CreateMetadataTable(ctx, conn); err != nil {
+			if errors.Is(err, errors.ErrUnsupported) {
+				return fmt.Errorf("run labels, checksums, repeatable migrations, checkpoints, backups, snapshots, concurrent indexes, selected versions and skipping preconditions require a store with metadata support: %w", err)
+			}
+			return err
+		}
+		p.metadata = true
+		return nil
+	}
+	exists, err := p.store.MetadataTableExists(ctx, conn)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return err
+	}
+	p.metadata = exists
+	return nil
+}
+
+// syncMetadata records the metadata for a version that was just applied, replacing anything left
+// over from an earlier run, or removes it when the version was rolled back.
+func (p *Provider) syncMetadata(ctx context.Context, db database.DBTxConn, m *Migration, direction bool) error {
+	if !p.metadata {
+		return nil
+	}
+	if err := p.store.DeleteMetadata(ctx, db, m.Version); err != nil {
+		return err
+	}
+	if !direction {
+		return nil
+	}
+	if p.cfg.recordChecksums {
+		sum, err := p.checksum(m)
+		if err != nil {
+			return err
+		}
+		if sum != "" {
+			if err := p.store.InsertMetadata(ctx, db, m.Version, checksumKey, sum); err != nil {
+				return err
+			}
+			if err := p.store.InsertMetadata(ctx, db, m.Version, checksumAlgorithmKey, p.checksumAlgorithm(m).Name); err != nil {
+				return err
+			}
+		}
+	}
+	for table, snapshot := range p.snapshots[m.Version] {
+		if err := p.store.InsertMetadata(ctx, db, m.Version, snapshotKeyPrefix+table, snapshot); err != nil {
+			return err
+		}
+	}
+	if backup, ok := p.backups[m.Version]; ok {
+		if err := p.store.InsertMetadata(ctx, db, m.Version, backupKey, backup); err != nil {
+			return err
+		}
+	}
+	if p.cfg.includeVersions[m.Version] {
+		if err := p.store.InsertMetadata(ctx, db, m.Version, includedKey, p.cfg.includeReason); err != nil {
+			return err
+		}
+	}
+	labels, err := p.cfg.labelProtection.protect(ctx, p.cfg.runLabels)
+	if err != nil {
+		return err
+	}
+	return insertLabels(ctx, p.store, db, m.Version, labels)
+}
+
+// checksum returns the current checksum of the migration. SQL migrations are hashed from their
+// file, while Go migrations use the checksum set at registration, if any. It returns an empty
+// string if the checksum is unknown.
+func (p *Provider) checksum(m *Migration) (string, error) {
+	return p.checksumWith(m, p.checksumAlgorithm(m))
+}
+
+// checksumAlgorithm returns the algorithm the checksum of the migration is computed with. Checksums
+// of Go migrations are embedded at registration, as computed by [GoMigrationChecksum].
+func (p *Provider) checksumAlgorithm(m *Migration) ChecksumAlgorithm {
+	if m.Type == TypeSQL && p.cfg.checksumAlgorithm.New != nil {
+		return p.cfg.checksumAlgorithm
+	}
+	return SHA256()
+}
+
+func (p *Provider) checksumWith(m *Migration, alg ChecksumAlgorithm) (string, error) {
+	switch m.Type {
+	case TypeGo:
+		return m.Checksum, nil
+	case TypeSQL:
+		// The file is hashed as it is read, since it may be too large to load, see
+		// [WithStreamSQL].
+		f, err := p.fsys.Open(m.Source)
+		if err != nil {
+			return "", fmt.Errorf("failed to read migration %s: %w", m.ref(), err)
+		}
+		defer f.Close()
+		h := alg.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", fmt.Errorf("failed to read migration %s: %w", m.ref(), err)
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+	return "", fmt.Errorf("invalid migration type: %q", m.Type)
+}
+
+// listLabels returns the run labels recorded for each version. It returns nil if the store has no
+// metadata table.
+func listLabels(ctx context.Context, store database.MetadataStore, db database.DBTxConn) (map[int64]map[string]string, error) {
+	exists, err := store.MetadataTableExists(ctx, db)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	results, err := store.ListMetadata(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	labels := make(map[int64]map[string]string)
+	for _, r := range results {
+		key, ok := strings.CutPrefix(r.Key, labelKeyPrefix)
+		if !ok {
+			continue
+		}
+		if labels[r.Version] == nil {
+			labels[r.Version] = make(map[string]string)
+		}
+		labels[r.Version][key] = r.Value
+	}
+	return labels, nil
+}
+
+func insertLabels(
+	ctx context.Context,
+	store database.MetadataStore,
+	db database.DBTxConn,
+	version int64,
+	labels map[string]string,
+) error {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := store.InsertMetadata(ctx, db, version, labelKeyPrefix+k, labels[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkRunLabels(labels map[string]string) error {
+	for k := range labels {
+		if k == "" || strings.TrimSpace(k) != k {
+			return fmt.Errorf("invalid run label key %q: must be non-empty without surrounding whitespace", k)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) verify(ctx context.Context) (_ *VerifyReport, retErr error) {
+	if p.cfg.disableVersioning {
+		return nil, errors.New("verify not supported when versioning is disabled")
+	}
+	conn, cleanup, err := p.initialize(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, cleanup())
+	}()
+
+	report := new(VerifyReport)
+	exists, err := p.store.MetadataTableExists(ctx, conn)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return report, nil
+		}
+		return nil, err
+	}
+	if !exists {
+		return report, nil
+	}
+	results, err := p.store.ListMetadata(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	type job struct {
+		m         *Migration
+		recorded  string
+		algorithm string
+		state     verifyState
+		err       error
+	}
+	algorithms := make(map[int64]string)
+	for _, r := range results {
+		if r.Key == checksumAlgorithmKey {
+			algorithms[r.Version] = r.Value
+		}
+	}
+	archived, err := p.collectArchived()
+	if err != nil {
+		return nil, err
+	}
+	var jobs []*job
+	for _, r := range results {
+		if r.Key != checksumKey {
+			continue
+		}
+		m, err := p.getMigration(r.Version)
+		if err != nil {
+			if m = archived[r.Version]; m == nil {
+				continue
+			}
+		}
+		jobs = append(jobs, &job{m: m, recorded: r.Value, algorithm: algorithms[r.Version]})
+	}
+	// Migrations are hashed by a pool of workers, since reading and hashing thousands of files one
+	// at a time is slow. Results are kept in the order of the recorded checksums.
+	next := make(chan *job)
+	var wg sync.WaitGroup
+	for i := 0; i < min(p.verifyConcurrency(), len(jobs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range next {
+				j.state, j.err = p.verifyMigration(j.m, j.recorded, j.algorithm)
+			}
+		}()
+	}
+	for _, j := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- j
+	}
+	close(next)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		if r.Key != skippedKey {
+			continue
+		}
+		record := decodeSkipRecord(r.Value)
+		source := &Source{Version: r.Version}
+		if m, err := p.getMigration(r.Version); err == nil {
+			source = &Source{Type: m.Type, Path: m.Source, Version: m.Version}
+		} else if e := p.lookupExcluded(r.Version); e != nil {
+			source = &Source{Type: e.Type, Path: e.Path, Version: e.Version}
+		}
+		report.Withheld = append(report.Withheld, &SkippedMigration{Source: source, SkipRecord: *record})
+	}
+	for _, j := range jobs {
+		source := &Source{Type: j.m.Type, Path: j.m.Source, Version: j.m.Version}
+		switch {
+		case j.err != nil:
+			report.Failed = append(report.Failed, &VerifyFailure{Source: source, Err: j.err})
+		case j.state == verifyModified:
+			report.Modified = append(report.Modified, source)
+		case j.state == verifySkipped:
+			report.Skipped = append(report.Skipped, source)
+		default:
+			report.Verified = append(report.Verified, source)
+		}
+	}
+	return report, nil
+}
+
+// collectArchived returns the SQL migrations in the [ArchiveDir] subdirectory of the migrations
+// filesystem, by version. They are not part of normal runs, but are still verified.
+func (p *Provider) collectArchived() (map[int64]*Migration, error) {
+	archived := make(map[int64]*Migration)
+	for _, pattern := range sqlFilePatterns {
+		pattern = path.Join(ArchiveDir, pattern)
+		files, err := fs.Glob(p.fsys, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob pattern %q: %w", pattern, err)
+		}
+		for _, file := range files {
+			version, err := NumericComponent(file)
+			if err != nil {
+				continue
+			}
+			archived[version] = newSQLMigration(Source{Type: TypeSQL, Path: file, Version: version})
+		}
+	}
+	return archived, nil
+}
+
+type verifyState int
+
+const (
+	verifyMatched verifyState = iota
+	verifyModified
+	verifySkipped
+)
+
+// verifyMigration compares the current checksum of m with the checksum recorded when it was
+// applied, computed with the recorded algorithm, SHA-256 if empty. It is safe to call
+// concurrently.
+func (p *Provider) verifyMigration(m *Migration, recorded, algorithm string) (verifyState, error) {
+	if m.Type == TypeSQL {
+		// Parse the file as it is now; the cached parse may predate the change.
+		f, err := p.fsys.Open(m.Source)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migration %s: %w", m.ref(), err)
+		}
+		directives, err := sqlparser.ParseDirectives(f)
+		f.Close()
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse migration %s: %w", m.ref(), err)
+		}
+		if _, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveTombstone); ok {
+			return verifySkipped, nil
+		}
+	}
+	alg := p.checksumAlgorithm(m)
+	if algorithm == "" {
+		algorithm = SHA256().Name
+	}
+	if algorithm != alg.Name {
+		if algorithm != SHA256().Name || m.Type != TypeSQL {
+			return 0, fmt.Errorf("checksum of migration %s was recorded with algorithm %s, which is not configured", m.ref(), algorithm)
+		}
+		alg = SHA256()
+	}
+	sum, err := p.checksumWith(m, alg)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case sum == "":
+		return verifySkipped, nil
+	case sum != recorded:
+		return verifyModified, nil
+	}
+	return verifyMatched, nil
+}
+
+// verifyConcurrency returns the number of migrations [Provider.Verify] hashes concurrently, see
+// [WithVerifyConcurrency].
+func (p *Provider) verifyConcurrency() int {
+	if p.cfg.verifyConcurrency > 0 {
+		return p.cfg.verifyConcurrency
+	}
+	return runtime.GOMAXPROCS(0)
+}
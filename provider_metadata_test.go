@@ -0,0 +1,260 @@
+package goose_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/kms"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	t.Run("sql", func(t *testing.T) {
+		db := newDB(t)
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+			"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRecordChecksums(true))
+		require.NoError(t, err)
+		_, err = p.UpTo(ctx, 2)
+		require.NoError(t, err)
+		require.NoError(t, p.Verify(ctx))
+
+		// Retiring a migration is an intentional change, and pending migrations may change freely.
+		fsys["00001_a.sql"] = newMapFile("-- +goose tombstone\n")
+		fsys["00003_c.sql"] = newMapFile("-- +goose Up\nCREATE TABLE c2 (id INTEGER);\n")
+		require.NoError(t, p.Verify(ctx))
+
+		fsys["00002_b.sql"] = newMapFile("-- +goose Up\nCREATE TABLE b2 (id INTEGER);\n")
+		err = p.Verify(ctx)
+		require.ErrorIs(t, err, goose.ErrChecksumMismatch)
+		require.Contains(t, err.Error(), "(type:sql,version:2)")
+	})
+	t.Run("archived", func(t *testing.T) {
+		db := newDB(t)
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRecordChecksums(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+
+		fsys["archive/00001_a.sql"] = fsys["00001_a.sql"]
+		delete(fsys, "00001_a.sql")
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRecordChecksums(true))
+		require.NoError(t, err)
+		report, err := p.VerifyReport(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Verified, 2)
+		require.Equal(t, "archive/00001_a.sql", report.Verified[0].Path)
+
+		fsys["archive/00001_a.sql"] = newMapFile("-- +goose Up\nCREATE TABLE a2 (id INTEGER);\n")
+		err = p.Verify(ctx)
+		require.ErrorIs(t, err, goose.ErrChecksumMismatch)
+		require.Contains(t, err.Error(), "(type:sql,version:1)")
+	})
+	t.Run("go", func(t *testing.T) {
+		db := newDB(t)
+		newMigration := func(checksum string) *goose.Migration {
+			m := goose.NewGoMigration(1, &goose.GoFunc{RunTx: newTxFn("SELECT 1")}, nil)
+			m.Checksum = checksum
+			return m
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, nil,
+			goose.WithGoMigrations(newMigration("abc")),
+			goose.WithRecordChecksums(true),
+		)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.NoError(t, p.Verify(ctx))
+
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, nil, goose.WithGoMigrations(newMigration("def")))
+		require.NoError(t, err)
+		require.ErrorIs(t, p.Verify(ctx), goose.ErrChecksumMismatch)
+		// Without an embedded checksum, Go migrations cannot be verified.
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, nil, goose.WithGoMigrations(newMigration("")))
+		require.NoError(t, err)
+		require.NoError(t, p.Verify(ctx))
+	})
+	t.Run("report", func(t *testing.T) {
+		db := newDB(t)
+		fsys := fstest.MapFS{}
+		for i := 1; i <= 20; i++ {
+			fsys[fmt.Sprintf("%05d_t%d.sql", i, i)] = newMapFile(fmt.Sprintf("-- +goose Up\nCREATE TABLE t%d (id INTEGER);\n", i))
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithRecordChecksums(true),
+			goose.WithVerifyConcurrency(4),
+		)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+
+		fsys["00003_t3.sql"] = newMapFile("-- +goose tombstone\n")
+		fsys["00005_t5.sql"] = newMapFile("-- +goose Up\nCREATE TABLE t5b (id INTEGER);\n")
+		fsys["00012_t12.sql"] = newMapFile("-- +goose Up\nCREATE TABLE t12b (id INTEGER);\n")
+		delete(fsys, "00007_t7.sql")
+		report, err := p.VerifyReport(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Verified, 16)
+		require.Equal(t, []*goose.Source{
+			{Type: goose.TypeSQL, Path: "00005_t5.sql", Version: 5},
+			{Type: goose.TypeSQL, Path: "00012_t12.sql", Version: 12},
+		}, report.Modified)
+		require.Equal(t, []*goose.Source{{Type: goose.TypeSQL, Path: "00003_t3.sql", Version: 3}}, report.Skipped)
+		require.Len(t, report.Failed, 1)
+		require.EqualValues(t, 7, report.Failed[0].Source.Version)
+		// All problems are reported together.
+		err = report.Err()
+		require.ErrorIs(t, err, goose.ErrChecksumMismatch)
+		require.ErrorIs(t, err, fs.ErrNotExist)
+		require.Contains(t, err.Error(), "(type:sql,version:5), (type:sql,version:12)")
+		require.Equal(t, err.Error(), p.Verify(ctx).Error())
+
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithVerifyConcurrency(0))
+		require.ErrorContains(t, err, "verify concurrency must be at least 1")
+	})
+	t.Run("algorithm", func(t *testing.T) {
+		db := newDB(t)
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRecordChecksums(true))
+		require.NoError(t, err)
+		_, err = p.UpTo(ctx, 1)
+		require.NoError(t, err)
+		alg := goose.ChecksumAlgorithm{Name: "sha512", New: sha512.New}
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithRecordChecksums(true),
+			goose.WithChecksumAlgorithm(alg),
+		)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+
+		var sum, algorithm string
+		err = db.QueryRow("SELECT meta_value FROM goose_db_version_meta WHERE version_id = 2 AND meta_key = 'checksum'").Scan(&sum)
+		require.NoError(t, err)
+		require.Len(t, sum, 128)
+		err = db.QueryRow("SELECT meta_value FROM goose_db_version_meta WHERE version_id = 2 AND meta_key = 'checksum_algorithm'").Scan(&algorithm)
+		require.NoError(t, err)
+		require.Equal(t, "sha512", algorithm)
+		// Checksums recorded with SHA-256 still verify.
+		require.NoError(t, p.Verify(ctx))
+		fsys["00001_a.sql"] = newMapFile("-- +goose Up\nCREATE TABLE a2 (id INTEGER);\n")
+		require.ErrorIs(t, p.Verify(ctx), goose.ErrChecksumMismatch)
+
+		// Without the algorithm, the checksum of version 2 cannot be verified.
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		report, err := p.VerifyReport(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Failed, 1)
+		require.EqualValues(t, 2, report.Failed[0].Source.Version)
+		require.ErrorContains(t, report.Failed[0].Err, "recorded with algorithm sha512, which is not configured")
+
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithChecksumAlgorithm(goose.ChecksumAlgorithm{Name: "md5"}))
+		require.ErrorContains(t, err, "must have a New function")
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithChecksumAlgorithm(goose.ChecksumAlgorithm{New: sha512.New}))
+		require.ErrorContains(t, err, "invalid checksum algorithm name")
+	})
+}
+
+func TestRunLabels(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := newFsys()
+	labels := map[string]string{"git_sha": "abc123", "deployer": "ci"}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRunLabels(labels))
+	require.NoError(t, err)
+	_, err = p.UpTo(ctx, 2)
+	require.NoError(t, err)
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, labels, status[0].Labels)
+	require.Equal(t, labels, status[1].Labels)
+	require.Nil(t, status[2].Labels)
+
+	// Rolling back removes the labels, even with a provider that does not record any.
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	_, err = p.UpByOne(ctx)
+	require.NoError(t, err)
+	status, err = p.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, labels, status[0].Labels)
+	require.Nil(t, status[1].Labels)
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRunLabels(map[string]string{"": "x"}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid run label key")
+}
+
+func TestLabelProtection(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := newFsys()
+	key, err := kms.NewAESGCM(bytes.Repeat([]byte{1}, 32))
+	require.NoError(t, err)
+	lp := &goose.LabelProtection{Redact: []string{"host"}, Encrypt: []string{"deployer"}, KMS: key}
+	labels := map[string]string{"git_sha": "abc123", "deployer": "alice", "host": "build-01"}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRunLabels(labels), goose.WithLabelProtection(lp))
+	require.NoError(t, err)
+	_, err = p.UpTo(ctx, 1)
+	require.NoError(t, err)
+
+	stored := make(map[string]string)
+	rows, err := db.Query("SELECT meta_key, meta_value FROM goose_db_version_meta WHERE version_id = 1")
+	require.NoError(t, err)
+	for rows.Next() {
+		var k, v string
+		require.NoError(t, rows.Scan(&k, &v))
+		stored[k] = v
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Equal(t, "abc123", stored["label:git_sha"])
+	require.Equal(t, "[redacted]", stored["label:host"])
+	require.True(t, strings.HasPrefix(stored["label:deployer"], "enc:"))
+	require.NotContains(t, stored["label:deployer"], "alice")
+
+	// Status decrypts with the KMS, redacted values cannot be recovered.
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"git_sha": "abc123", "deployer": "alice", "host": "[redacted]"}, status[0].Labels)
+	// Without the KMS, the ciphertext is reported as stored.
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	status, err = p.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, stored["label:deployer"], status[0].Labels["deployer"])
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithLabelProtection(&goose.LabelProtection{Encrypt: []string{"deployer"}}))
+	require.ErrorContains(t, err, "require a KMS")
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithLabelProtection(&goose.LabelProtection{
+		Redact: []string{"host"}, Encrypt: []string{"host"}, KMS: key,
+	}))
+	require.ErrorContains(t, err, "both redacted and encrypted")
+}
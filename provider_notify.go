@@ -0,0 +1,49 @@
+package goose
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/pressly/goose/v3/notify"
+)
+
+// newSummary returns the summary of a completed run, for notifiers.
+func newSummary(results []*MigrationResult, err error, elapsed time.Duration) notify.Summary {
+	summary := notify.Summary{Duration: elapsed}
+	if err != nil {
+		summary.Error = err.Error()
+		var partialErr *PartialError
+		var skipErr *PreconditionSkipError
+		if errors.As(err, &partialErr) {
+			results = append(append([]*MigrationResult(nil), partialErr.Applied...), partialErr.Failed)
+		} else if errors.As(err, &skipErr) {
+			results = skipErr.Applied
+		}
+	}
+	summary.Migrations = make([]notify.Migration, 0, len(results))
+	for _, r := range results {
+		m := notify.Migration{
+			Version:   r.Source.Version,
+			Source:    r.Source.Path,
+			Title:     r.Title,
+			Owner:     r.Owner,
+			Direction: r.Direction,
+			Duration:  r.Duration,
+		}
+		if r.Error != nil {
+			m.Error = r.Error.Error()
+		}
+		summary.Migrations = append(summary.Migrations, m)
+	}
+	return summary
+}
+
+// notify sends the summary of a completed run to n. A failed notification is logged as a warning,
+// it never fails the run.
+func (p *Provider) notify(ctx context.Context, n notify.Notifier, summary notify.Summary) {
+	// The run may have failed because ctx is done, the summary is sent regardless.
+	if err := n.Notify(context.WithoutCancel(ctx), summary); err != nil {
+		p.cfg.logger.Printf("goose: warning: failed to send notification: %v", err)
+	}
+}
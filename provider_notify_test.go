@@ -0,0 +1,94 @@
+package goose_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/notify"
+	"github.com/stretchr/testify/require"
+)
+
+type notifierFunc func(ctx context.Context, s notify.Summary) error
+
+func (f notifierFunc) Notify(ctx context.Context, s notify.Summary) error {
+	return f(ctx, s)
+}
+
+func TestNotifier(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+	}
+	var summaries []notify.Summary
+	notifier := notifierFunc(func(_ context.Context, s notify.Summary) error {
+		summaries = append(summaries, s)
+		return nil
+	})
+	var alerts []notify.Summary
+	alerter := notifierFunc(func(_ context.Context, s notify.Summary) error {
+		alerts = append(alerts, s)
+		return nil
+	})
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+		goose.WithNotifier(notifier),
+		goose.WithFailureNotifier(alerter),
+	)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, alerts)
+	require.Len(t, summaries, 1)
+	require.Empty(t, summaries[0].Error)
+	require.Len(t, summaries[0].Migrations, 2)
+	require.Equal(t, "00002_b.sql", summaries[0].Migrations[1].Source)
+	require.Equal(t, "up", summaries[0].Migrations[1].Direction)
+	require.Positive(t, summaries[0].Duration)
+	// Runs with nothing to apply are not notified.
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, summaries, 1)
+
+	t.Run("failed", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nINSERT INTO missing VALUES (1);\n"),
+		}
+		var summaries []notify.Summary
+		// A failed notification does not fail the run.
+		notifier := notifierFunc(func(_ context.Context, s notify.Summary) error {
+			summaries = append(summaries, s)
+			return errors.New("webhook unreachable")
+		})
+		var alerts []notify.Summary
+		alerter := notifierFunc(func(_ context.Context, s notify.Summary) error {
+			alerts = append(alerts, s)
+			return nil
+		})
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys,
+			goose.WithNotifier(notifier),
+			goose.WithFailureNotifier(alerter),
+		)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Len(t, summaries, 1)
+		require.Equal(t, summaries, alerts)
+		require.Equal(t, err.Error(), summaries[0].Error)
+		require.Len(t, summaries[0].Migrations, 2)
+		require.Empty(t, summaries[0].Migrations[0].Error)
+		require.EqualValues(t, 2, summaries[0].Migrations[1].Version)
+		require.Contains(t, summaries[0].Migrations[1].Error, "no such table: missing")
+	})
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithNotifier(nil))
+	require.Error(t, err)
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithFailureNotifier(nil))
+	require.Error(t, err)
+}
@@ -1,11 +1,22 @@
 package goose
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/pressly/goose/v3/backup"
 	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/gooseutil"
 	"github.com/pressly/goose/v3/lock"
+	"github.com/pressly/goose/v3/notify"
+	"github.com/pressly/goose/v3/throttle"
 )
 
 const (
@@ -53,6 +64,25 @@ func WithStore(store database.Store) ProviderOption {
 	})
 }
 
+// WithTableName sets the name of the version table used by the default store implementation
+// backed by the dialect passed to [NewProvider]. The name may be schema-qualified, e.g.,
+// "myschema.goose_db_version". If WithTableName is not called, [DefaultTablename] is used.
+//
+// This option cannot be combined with [WithStore], since custom store implementations own their
+// table name.
+func WithTableName(name string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if c.tableName != "" {
+			return fmt.Errorf("table name already set: %q", c.tableName)
+		}
+		if name == "" {
+			return errors.New("table name must not be empty")
+		}
+		c.tableName = name
+		return nil
+	})
+}
+
 // WithVerbose enables verbose logging.
 func WithVerbose(b bool) ProviderOption {
 	return configFunc(func(c *config) error {
@@ -83,9 +113,13 @@ func WithSessionLocker(locker lock.SessionLocker) ProviderOption {
 
 // WithExcludeNames excludes the given file name from the list of migrations. If called multiple
 // times, the list of excludes is merged.
+//
+// If the provider keeps a metadata table, e.g., for [WithRecordChecksums], runs record a
+// [SkipRecord] for excluded files that are not applied.
 func WithExcludeNames(excludes []string) ProviderOption {
 	return configFunc(func(c *config) error {
 		for _, name := range excludes {
+			name = normalizePath(name)
 			if _, ok := c.excludePaths[name]; ok {
 				return fmt.Errorf("duplicate exclude file name: %s", name)
 			}
@@ -97,6 +131,9 @@ func WithExcludeNames(excludes []string) ProviderOption {
 
 // WithExcludeVersions excludes the given versions from the list of migrations. If called multiple
 // times, the list of excludes is merged.
+//
+// If the provider keeps a metadata table, runs record a [SkipRecord] for excluded files that are
+// not applied, as for [WithExcludeNames].
 func WithExcludeVersions(versions []int64) ProviderOption {
 	return configFunc(func(c *config) error {
 		for _, version := range versions {
@@ -112,6 +149,109 @@ func WithExcludeVersions(versions []int64) ProviderOption {
 	})
 }
 
+// WithIncludeVersions restricts runs of Up, UpByOne and UpTo to the given pending versions, e.g., to
+// apply a single hotfix migration ahead of others. Other pending migrations are left pending, even
+// those with lower versions. The reason is recorded in the metadata table of each included version
+// that is applied, see [WithRunLabels].
+//
+// Versions left pending below an applied version are missing migrations afterwards, which later
+// runs only apply with [WithAllowOutofOrder] or by including them. Selecting versions does not
+// relax the missing check for other versions: a run that would apply a missing version that is
+// neither included nor skipped still fails, unless [WithAllowOutofOrder] is set.
+func WithIncludeVersions(versions []int64, reason string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if err := addSelectedVersions(&c.includeVersions, versions, reason); err != nil {
+			return fmt.Errorf("invalid include versions: %w", err)
+		}
+		c.includeReason = reason
+		return nil
+	})
+}
+
+// WithSkipVersions skips the given pending versions in runs of Up, UpByOne and UpTo, e.g., to hold
+// back a broken migration while the ones after it are applied. Unlike [WithExcludeVersions], the
+// skipped migrations are still known to the provider and reported as pending by Status. The reason
+// is recorded in the metadata table of each skipped version, and cleared once it is applied, see
+// [SkipRecord].
+//
+// Skipped versions below an applied version are missing migrations afterwards, see
+// [WithIncludeVersions].
+func WithSkipVersions(versions []int64, reason string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if err := addSelectedVersions(&c.skipVersions, versions, reason); err != nil {
+			return fmt.Errorf("invalid skip versions: %w", err)
+		}
+		c.skipReason = reason
+		return nil
+	})
+}
+
+func addSelectedVersions(selected *map[int64]bool, versions []int64, reason string) error {
+	if *selected != nil {
+		return errors.New("versions already set")
+	}
+	if len(versions) == 0 {
+		return errors.New("at least one version is required")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return errors.New("reason must not be empty")
+	}
+	*selected = make(map[int64]bool, len(versions))
+	for _, version := range versions {
+		if version < 1 {
+			return errInvalidVersion
+		}
+		if (*selected)[version] {
+			return fmt.Errorf("duplicate version: %d", version)
+		}
+		(*selected)[version] = true
+	}
+	return nil
+}
+
+// WithFilenameParser parses the versions of migration files with parse instead of
+// [NumericComponent], to use migrations named by another convention without renaming them, e.g.,
+// with [RegexpFilenameParser]. Only the files collected from the filesystem are parsed: Go
+// migrations registered globally still take their version from [NumericComponent], so Go
+// migrations named differently should be registered with [WithGoMigrations].
+func WithFilenameParser(parse FilenameParser) ProviderOption {
+	return configFunc(func(c *config) error {
+		if parse == nil {
+			return errors.New("filename parser must not be nil")
+		}
+		c.filenameParser = parse
+		return nil
+	})
+}
+
+// WithRecursive collects migration files from all subdirectories of the filesystem passed to
+// [NewProvider], not just its root. This allows organizing migrations in nested folders, e.g., by
+// year or by domain. Migrations are still applied in global version order, and a version that
+// appears in more than one folder results in an error.
+//
+// File names passed to [WithExcludeNames] match either the base name or the full path relative to
+// the filesystem root.
+func WithRecursive(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.recursive = b
+		return nil
+	})
+}
+
+// WithCollectCache caches the hints parsed from migration files, which [Provider.Status] otherwise
+// reads from every file on each call, e.g., for services that report the status on every health
+// check. The cache may be shared by providers using the same filesystem. See [CollectCache] for how
+// entries are invalidated.
+func WithCollectCache(cache *CollectCache) ProviderOption {
+	return configFunc(func(c *config) error {
+		if cache == nil {
+			return errors.New("collect cache must not be nil")
+		}
+		c.collectCache = cache
+		return nil
+	})
+}
+
 // WithGoMigrations registers Go migrations with the provider. If a Go migration with the same
 // version has already been registered, an error will be returned.
 //
@@ -140,6 +280,18 @@ func WithDisableGlobalRegistry(b bool) ProviderOption {
 	})
 }
 
+// WithRegistryScope selects which scope of the global registry the provider merges Go migrations
+// from. Go migrations are registered under a scope with the [WithScope] migration option. By
+// default, the provider uses the default (empty) scope.
+//
+// This option has no effect when combined with [WithDisableGlobalRegistry].
+func WithRegistryScope(scope string) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.registryScope = scope
+		return nil
+	})
+}
+
 // WithAllowOutofOrder allows the provider to apply missing (out-of-order) migrations. By default,
 // goose will raise an error if it encounters a missing migration.
 //
@@ -154,6 +306,791 @@ func WithAllowOutofOrder(b bool) ProviderOption {
 	})
 }
 
+// WithAtomicUp runs all migrations applied by [Provider.Up] and [Provider.UpTo] in a single
+// transaction, so a failure in any migration leaves the database exactly as it was before the run.
+// By default, each migration is applied in its own transaction.
+//
+// This requires a dialect with transactional DDL, such as Postgres or SQLite, and that none of the
+// pending migrations are marked to run without a transaction or build indexes concurrently, see
+// [WithConcurrentIndexes]. Otherwise, the run fails before any migration is applied.
+func WithAtomicUp(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.atomicUp = b
+		return nil
+	})
+}
+
+// WithMigrationDeps sets dependencies, such as application config or service clients, that are
+// made available to Go migrations run by the provider. Go migration functions retrieve them from
+// their context with [MigrationDeps].
+//
+// This avoids package-level singletons for migrations that need more than a database handle, e.g.,
+// to backfill data from an external API.
+func WithMigrationDeps(deps any) ProviderOption {
+	return configFunc(func(c *config) error {
+		if deps == nil {
+			return errors.New("migration deps must not be nil")
+		}
+		c.deps = deps
+		return nil
+	})
+}
+
+// ApprovalFunc decides whether the provider may run the given migrations. A non-nil error vetoes the
+// run. See [WithApproval].
+type ApprovalFunc func(ctx context.Context, pending []*Migration) error
+
+// WithApproval sets a change-approval check, e.g., that a ticket exists or that a maintenance
+// window is open, invoked before any migration is executed.
+//
+// The function receives the migrations about to run, in the order they will run, in the direction
+// of the provider method: migrations to apply for Up and UpTo, and migrations to roll back for Down
+// and DownTo. Repeatable migrations that will run, see [WithRepeatable], follow the versioned
+// migrations, so that a run is approved with a single call. It is called with the session lock
+// held, and not at all when there is nothing to run. If it returns an error, none of the
+// migrations are run and the error is returned wrapped.
+func WithApproval(fn ApprovalFunc) ProviderOption {
+	return configFunc(func(c *config) error {
+		if fn == nil {
+			return errors.New("approval func must not be nil")
+		}
+		c.approval = fn
+		return nil
+	})
+}
+
+// WithIgnoreFreeze lets Up, UpByOne, UpTo, ApplyVersion and the Redo methods apply migrations even
+// though they are frozen with [Provider.Freeze], e.g., to ship the fix for the incident that
+// caused the freeze.
+func WithIgnoreFreeze(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.ignoreFreeze = b
+		return nil
+	})
+}
+
+// WithWindow constrains runs to a daily maintenance window of the form "HH:MM-HH:MM [ZONE]", e.g.,
+// "02:00-04:00 UTC". The zone is an IANA time zone name and defaults to UTC. A window whose end is
+// before its start spans midnight.
+//
+// The window is checked before each migration is executed. Outside the window, the provider returns
+// an error wrapping [ErrOutsideWindow], with the migrations that already ran in a [PartialError] if
+// the window closed during the run. Migrations already running when the window closes are not
+// interrupted, nor are transactions of runs with [WithAtomicUp].
+func WithWindow(window string) ProviderOption {
+	return configFunc(func(c *config) error {
+		w, err := gooseutil.ParseWindow(window)
+		if err != nil {
+			return err
+		}
+		c.window = &w
+		return nil
+	})
+}
+
+// WithWaitForWindow makes the provider wait until the maintenance window set with [WithWindow]
+// opens before a run starts, instead of returning an error. The wait happens before the session
+// lock is taken and is cancelled with the context. If the window closes during the run, the next
+// migration still fails with [ErrOutsideWindow].
+func WithWaitForWindow(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.waitForWindow = b
+		return nil
+	})
+}
+
+// PacingFunc is called between consecutive migrations with the result of the migration that just
+// ran. It may block to delay the next migration, and a non-nil error aborts the run. See
+// [WithPacing].
+type PacingFunc func(ctx context.Context, prev *MigrationResult) error
+
+// WithPause sleeps for d between consecutive migrations, so large batches of backfills do not
+// saturate the database. The pause is cancelled with the context. See [WithPacing] for details.
+func WithPause(d time.Duration) ProviderOption {
+	return configFunc(func(c *config) error {
+		if d < 0 {
+			return fmt.Errorf("pause must not be negative: %s", d)
+		}
+		c.pause = d
+		return nil
+	})
+}
+
+// WithPacing sets a callback invoked between consecutive migrations, e.g., to wait for the
+// database to catch up or to stop a run that is taking too long. It is called with the session lock
+// held, after the pause set with [WithPause] and the throttling set with [WithThrottler], if any.
+//
+// When the callback returns an error, the remaining migrations are not run and the provider returns
+// a [PartialError] whose Failed result is the migration that would have run next. Migrations run in
+// a single transaction with [WithAtomicUp] are never paced.
+func WithPacing(fn PacingFunc) ProviderOption {
+	return configFunc(func(c *config) error {
+		if fn == nil {
+			return errors.New("pacing func must not be nil")
+		}
+		c.pacing = fn
+		return nil
+	})
+}
+
+// SmokeTestFunc runs application-critical queries against the schema a run migrated, on the
+// provider's *sql.DB, and returns an error to veto the run. It is called with the migrations the run
+// applied. See [WithSmokeTest].
+type SmokeTestFunc func(ctx context.Context, db *sql.DB, applied []*MigrationResult) error
+
+// WithSmokeTest adds a smoke test run once Up, UpByOne or UpTo applied migrations, with the
+// session lock held, e.g., to check that the queries of the application still work. Smoke tests
+// run in the order they were added, and are not run when no migrations were applied. When one
+// fails, the run fails with a [SmokeTestError], and the migrations are rolled back if
+// [WithSmokeTestRollback] is set.
+//
+// Smoke tests query the database on their own connection, so the pool of the provider's *sql.DB
+// must allow more than one open connection.
+func WithSmokeTest(fn SmokeTestFunc) ProviderOption {
+	return configFunc(func(c *config) error {
+		if fn == nil {
+			return errors.New("smoke test func must not be nil")
+		}
+		c.smokeTests = append(c.smokeTests, fn)
+		return nil
+	})
+}
+
+// WithSmokeTestRollback rolls back the migrations applied by a run when a smoke test set with
+// [WithSmokeTest] fails, see [WithAutoRollbackOnFailure] for details.
+func WithSmokeTestRollback(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.smokeTestRollback = b
+		return nil
+	})
+}
+
+// WithAutoRollbackOnFailure rolls back the migrations applied by Up or UpTo when a later step of
+// the run fails: a migration, a repeatable migration, the refresh of a materialized view, partition
+// maintenance or a smoke test set with [WithSmokeTest]. The versioned migrations the run applied
+// are rolled back newest first, with the session lock held, restoring the version from before the
+// run. The run fails with a [RollbackError] whose report lists what was reverted and what was kept.
+// The rollback also runs if the run failed because its context was cancelled, and it is neither
+// gated by [WithWindow] or [WithApproval] nor reported to notifiers on its own.
+//
+// Only migrations with a Down section are rolled back: the rollback stops at the first migration
+// without one, which is kept with the migrations before it. Repeatable migrations are always kept,
+// and nothing is rolled back with versioning disabled. A failed migration that ran outside a
+// transaction may have been partially applied; it is not rolled back, since its version was not
+// recorded.
+func WithAutoRollbackOnFailure(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.autoRollback = b
+		return nil
+	})
+}
+
+// SlowMigrationFunc is called when a migration runs longer than its expected duration. See
+// [WithSlowMigration].
+type SlowMigrationFunc func(ctx context.Context, m *Migration, expected time.Duration)
+
+// WithSlowMigration sets a callback invoked when a migration is still running after its expected
+// duration, e.g., to emit a metric or page someone before an accidental table scan holds locks for
+// hours. The expected duration of SQL migrations is declared with a directive:
+//
+//	-- +goose expect-duration 30s
+//
+// and that of Go migrations with [WithExpectDuration]. Migrations without an expected duration are
+// never reported. The callback is called at most once per migration run, from a separate
+// goroutine, while the migration keeps running, unless [WithCancelSlowMigrations] is set.
+func WithSlowMigration(fn SlowMigrationFunc) ProviderOption {
+	return configFunc(func(c *config) error {
+		if fn == nil {
+			return errors.New("slow migration func must not be nil")
+		}
+		c.slowMigration = fn
+		return nil
+	})
+}
+
+// WithCancelSlowMigrations cancels migrations that run longer than their expected duration, see
+// [WithSlowMigration]. The context of the migration is cancelled, so the running statement is
+// interrupted if the driver supports it, and the migration fails with an error wrapping
+// [ErrDurationExceeded]. Migrations run in a transaction are rolled back.
+func WithCancelSlowMigrations(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.cancelSlowMigrations = b
+		return nil
+	})
+}
+
+// WithExplainDML runs EXPLAIN before each UPDATE and DELETE statement of SQL migrations, and fails
+// the migration with [ErrFullTableScan] when the plan shows a full table scan of a table estimated
+// to hold more than maxRows rows, e.g., an accidental mass update on an unindexed column. The
+// statement is not run. Estimates come from the planner's statistics, so they may be stale.
+//
+// Explaining statements is supported by the postgres and mysql dialects. Statements of Go
+// migrations are never explained.
+func WithExplainDML(maxRows int64) ProviderOption {
+	return configFunc(func(c *config) error {
+		if maxRows < 0 {
+			return fmt.Errorf("explain row threshold must not be negative: %d", maxRows)
+		}
+		c.explainDML = true
+		c.explainMaxRows = maxRows
+		return nil
+	})
+}
+
+// WithTableCharset adds the character set charset and the collation collation to the table
+// options of CREATE TABLE statements in SQL migrations that do not set them, so that tables do not
+// silently get the server defaults, e.g., utf8mb3 on older servers. Either may be empty to leave it
+// unset. To reject such statements when migrations are validated or planned instead, see
+// policy.RequireTableCharset.
+//
+// Adding table defaults is supported by the mysql and tidb dialects.
+func WithTableCharset(charset, collation string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if charset == "" && collation == "" {
+			return errors.New("table charset and collation must not both be empty")
+		}
+		for _, name := range []string{charset, collation} {
+			if name != "" && !charsetNameRe.MatchString(name) {
+				return fmt.Errorf("invalid charset or collation name %q", name)
+			}
+		}
+		c.tableCharset, c.tableCollation = charset, collation
+		return nil
+	})
+}
+
+// WithConcurrentIndexes builds the named indexes of CREATE INDEX statements in SQL migrations with
+// CREATE INDEX CONCURRENTLY, which does not block writes to the table while the index is built.
+// Since indexes cannot be built concurrently in a transaction, the indexes of a migration that runs
+// in a transaction are built after its other statements committed. The version is recorded in that
+// transaction, together with the indexes as pending in the metadata table until they are built.
+// Statements of NO TRANSACTION migrations are rewritten in place. Migrations with such indexes
+// cannot be applied in a single transaction with [WithAtomicUp].
+//
+// A concurrent build that fails, e.g., because of a deadlock or a unique violation, leaves an
+// INVALID index behind. It is dropped and the build retried up to retries times; if the last
+// attempt fails, the index is dropped and the run fails, with the migration applied but its
+// remaining indexes not built. The next Up or UpTo builds them before applying other migrations,
+// so the migration is not run again. Unnamed indexes, indexes ON ONLY a table and streamed
+// migrations are left unchanged.
+//
+// Building indexes concurrently is supported by the postgres dialect, or a custom store on
+// Postgres.
+func WithConcurrentIndexes(retries int) ProviderOption {
+	return configFunc(func(c *config) error {
+		if retries < 0 {
+			return fmt.Errorf("index retries must not be negative: %d", retries)
+		}
+		c.concurrentIndexes = true
+		c.indexRetries = retries
+		return nil
+	})
+}
+
+// WithLockDiagnostics logs the sessions blocking a statement of an SQL migration that has been
+// waiting on a lock for longer than after, and again every after while it keeps waiting: their
+// id, user, state, transaction age and current query. This lets operators decide whether to
+// terminate the blocker or abort the migration. The statement itself is not interrupted; combine
+// with a lock timeout to bound the wait.
+//
+// Blockers are looked up on a separate connection of the pool, in pg_locks through
+// pg_blocking_pids on postgres, and in the sys schema on mysql, which requires performance_schema.
+// Lock diagnostics are supported by the postgres and mysql dialects, or a custom store on
+// Postgres.
+func WithLockDiagnostics(after time.Duration) ProviderOption {
+	return configFunc(func(c *config) error {
+		if after <= 0 {
+			return fmt.Errorf("lock diagnostics interval must be positive: %s", after)
+		}
+		c.lockDiagnostics = after
+		return nil
+	})
+}
+
+// WithLockRetry sets the Postgres lock_timeout of SQL migrations to timeout, so that a statement
+// waiting longer than that on a lock, e.g., an ALTER TABLE queued behind a long-running
+// transaction, is cancelled instead of blocking every later query on the table. The migration then
+// waits for wait and tries again, up to retries times, before it fails.
+//
+// A migration that runs in a transaction is rolled back and retried as a whole; a NO TRANSACTION
+// migration retries the statement that timed out. Statements of Go migrations are not bounded.
+// Retrying on lock timeouts is supported by the postgres dialect, or a custom store on Postgres.
+func WithLockRetry(timeout, wait time.Duration, retries int) ProviderOption {
+	return configFunc(func(c *config) error {
+		if timeout < time.Millisecond {
+			return fmt.Errorf("lock timeout must be at least 1ms: %s", timeout)
+		}
+		if wait < 0 {
+			return fmt.Errorf("lock retry wait must not be negative: %s", wait)
+		}
+		if retries < 0 {
+			return fmt.Errorf("lock retries must not be negative: %d", retries)
+		}
+		c.lockTimeout, c.lockRetryWait, c.lockRetries = timeout, wait, retries
+		return nil
+	})
+}
+
+// charsetNameRe matches the name of a character set or collation, e.g., "utf8mb4_0900_ai_ci".
+var charsetNameRe = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// WithExplainWarnOnly logs full table scans found with [WithExplainDML] as warnings, and runs the
+// statements anyway, instead of failing the migration.
+func WithExplainWarnOnly(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.explainWarnOnly = b
+		return nil
+	})
+}
+
+// WithBackup backs up the affected tables with b before applying each SQL migration flagged as
+// destructive, and records where the backup is stored with the applied version, in the metadata
+// table. The tables are those declared with an affects directive, or the whole database if none
+// are declared:
+//
+//	-- +goose destructive
+//	-- +goose affects users, orders
+//
+// If the backup fails, the migration is not applied. Rollbacks and Go migrations are never backed
+// up. See the [backup] package for built-in backups with pg_dump and mysqldump.
+func WithBackup(b backup.Backuper) ProviderOption {
+	return configFunc(func(c *config) error {
+		if b == nil {
+			return errors.New("backuper must not be nil")
+		}
+		c.backuper = b
+		return nil
+	})
+}
+
+// WithNotifier sends a summary of each run to n when it completes, with the migrations run, their
+// durations, and the error if the run failed. Runs with nothing to apply are not notified. See the
+// [notify] package for built-in webhook and Slack notifiers.
+//
+// A failed notification does not fail the run, it is logged as a warning instead.
+func WithNotifier(n notify.Notifier) ProviderOption {
+	return configFunc(func(c *config) error {
+		if n == nil {
+			return errors.New("notifier must not be nil")
+		}
+		c.notifier = n
+		return nil
+	})
+}
+
+// WithFailureNotifier sends a summary of each failed run to n, in addition to any notifier set with
+// [WithNotifier]. It is meant for alerting services that page on-call engineers, such as the
+// PagerDuty and Opsgenie notifiers of the [notify] package, which retry alerts on transient errors.
+//
+// A failed notification does not change the error of the run, it is logged as a warning instead.
+func WithFailureNotifier(n notify.Notifier) ProviderOption {
+	return configFunc(func(c *config) error {
+		if n == nil {
+			return errors.New("failure notifier must not be nil")
+		}
+		c.failureNotifier = n
+		return nil
+	})
+}
+
+// ExecFunc executes a statement of the SQL migration m. See [WithMiddleware].
+type ExecFunc func(ctx context.Context, m *Migration, query string) (sql.Result, error)
+
+// Middleware wraps the execution of statements, e.g., to log them, rewrite them, or record
+// per-statement metrics. A middleware calls next to execute the statement, possibly with a
+// rewritten query, and may return an error instead to fail the migration.
+type Middleware func(next ExecFunc) ExecFunc
+
+// WithMiddleware adds middleware around the execution of each statement of SQL migrations, for
+// example to tag statements with their migration for tracing:
+//
+//	goose.WithMiddleware(func(next goose.ExecFunc) goose.ExecFunc {
+//		return func(ctx context.Context, m *goose.Migration, query string) (sql.Result, error) {
+//			return next(ctx, m, fmt.Sprintf("/* migration:%d */ %s", m.Version, query))
+//		}
+//	})
+//
+// Middleware run in the order given, the first being the outermost, and may be set more than
+// once. Statements of Go migrations, and queries goose runs on its own, such as version table
+// updates, are not passed through middleware.
+func WithMiddleware(mw ...Middleware) ProviderOption {
+	return configFunc(func(c *config) error {
+		for _, m := range mw {
+			if m == nil {
+				return errors.New("middleware must not be nil")
+			}
+		}
+		c.middleware = append(c.middleware, mw...)
+		return nil
+	})
+}
+
+// WithStatementTags prepends a comment identifying the migration to every statement of SQL
+// migrations, e.g., "/* goose:version=5 source=00005_add_index.sql */", so statements can be
+// attributed to their migration in tools such as pg_stat_statements or the MySQL slow query log.
+//
+// The comment is added before any middleware run, so middleware see the statements as they are
+// executed.
+func WithStatementTags(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.statementTags = b
+		return nil
+	})
+}
+
+// WithStatementLog writes every statement of SQL migrations the Provider executes to w, with its
+// start time, duration and outcome, as an audit record of exactly what ran. Statements are logged
+// as sent to the database, after any middleware and [WithStatementTags]. To keep an append-only log
+// file, open it with os.O_APPEND.
+//
+// Entries are SQL comments followed by the statement, and failed statements are commented out, so
+// the log can be replayed, e.g., with psql -f. Statements that succeeded in a transaction that was
+// later rolled back are still logged as ok. Failing to write an entry fails the migration.
+func WithStatementLog(w io.Writer) ProviderOption {
+	return configFunc(func(c *config) error {
+		if w == nil {
+			return errors.New("statement log must not be nil")
+		}
+		c.statementLog = w
+		return nil
+	})
+}
+
+// WithShadowDB verifies pending migrations on a shadow database before applying them. Up, UpByOne
+// and UpTo first apply the migrations, up to the highest pending version, to shadow, which must use
+// the same dialect, and only run them on the target database once all of them are applied there.
+// Otherwise [ErrShadowFailed] is returned and the target database is left untouched.
+//
+// The shadow database should be empty, or a recent copy of the target. Migrations it has already
+// applied are not run again. Locking, backups, approval, pacing and statement middleware only apply
+// to the target database.
+func WithShadowDB(shadow *sql.DB) ProviderOption {
+	return configFunc(func(c *config) error {
+		if shadow == nil {
+			return errors.New("shadow database must not be nil")
+		}
+		c.shadowDB = shadow
+		return nil
+	})
+}
+
+// WithShadow is like [WithShadowDB], but opens the shadow database for each run and closes it
+// afterwards. dsnOrAuto is either the connection string of the shadow database, opened with the
+// driver of the target database, or [ShadowAuto].
+//
+// With [ShadowAuto], which requires the postgres dialect, a scratch database is created on the
+// target's server with CREATE DATABASE ... TEMPLATE template0, verified against, and dropped. Use
+// "auto:NAME" to copy the template database NAME instead, e.g., one holding a recent schema. The
+// connection string of the server must be set with [WithShadowServer].
+func WithShadow(dsnOrAuto string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if dsnOrAuto == "" {
+			return errors.New("shadow connection string must not be empty")
+		}
+		c.shadowDSN = dsnOrAuto
+		return nil
+	})
+}
+
+// WithShadowServer sets the Postgres connection string used to connect to the shadow database
+// created by [WithShadow] with [ShadowAuto], usually that of the target database. Its database
+// name is replaced by the name of the shadow database.
+func WithShadowServer(dsn string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if dsn == "" {
+			return errors.New("shadow server connection string must not be empty")
+		}
+		c.shadowServer = dsn
+		return nil
+	})
+}
+
+// WithThrottler pauses the run while the replication lag reported by t exceeds its threshold. The
+// lag is checked between consecutive migrations and, for SQL migrations that run outside a
+// transaction, between statements. See the [throttle] package for built-in lag checkers.
+//
+// Lag checkers query the database on their own connection. With a Postgres lag checker on the
+// provider's *sql.DB, the pool must allow more than one open connection.
+func WithThrottler(t *throttle.Throttler) ProviderOption {
+	return configFunc(func(c *config) error {
+		if t == nil {
+			return errors.New("throttler must not be nil")
+		}
+		c.throttler = t
+		return nil
+	})
+}
+
+// WithRunLabels attaches labels, such as a git SHA, deployer or ticket, to a run. The labels are
+// recorded with each version applied by the provider and reported by [Provider.Status], which
+// helps trace a schema change back to the deployment that made it.
+//
+// Labels are kept in a metadata table next to the version table, named after it with a "_meta"
+// suffix. The table is created on first use and requires a store with metadata support; the
+// default stores for Postgres, MySQL and SQLite support it.
+func WithRunLabels(labels map[string]string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if err := checkRunLabels(labels); err != nil {
+			return err
+		}
+		if c.runLabels == nil {
+			c.runLabels = make(map[string]string, len(labels))
+		}
+		for k, v := range labels {
+			c.runLabels[k] = v
+		}
+		return nil
+	})
+}
+
+// WithLabelProtection redacts or encrypts the values of the configured run labels, see
+// [WithRunLabels], before they are recorded, e.g., when labels contain usernames or hostnames that
+// data-handling policies forbid storing in clear text. Encrypted values are decrypted with the KMS
+// of lp when reported by [Provider.Status]; redacted values cannot be recovered.
+func WithLabelProtection(lp *LabelProtection) ProviderOption {
+	return configFunc(func(c *config) error {
+		if lp == nil {
+			return errors.New("label protection must not be nil")
+		}
+		if err := lp.check(); err != nil {
+			return err
+		}
+		c.labelProtection = lp
+		return nil
+	})
+}
+
+// WithStrictOrdering enforces a linear migration history. When enabled, every provider operation
+// that changes the database, including down, redo and applying a single version, fails if any
+// migration has a version lower than the highest applied version but has not been applied.
+//
+// By default, such missing (out-of-order) migrations are only reported by operations that apply
+// them, and only up to the target version. This option is the opposite of [WithAllowOutofOrder]
+// and the two cannot be combined.
+func WithStrictOrdering(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.strictOrdering = b
+		return nil
+	})
+}
+
+// WithRecordChecksums records the checksum of each migration as it is applied, so that
+// [Provider.Verify] can later detect applied migrations that were modified. SQL migrations are
+// hashed from their file contents and Go migrations use the checksum embedded with
+// [WithChecksum].
+//
+// Like run labels, checksums are kept in the metadata table, see [WithRunLabels].
+func WithRecordChecksums(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.recordChecksums = b
+		return nil
+	})
+}
+
+// WithChecksumAlgorithm sets the hash function SQL migrations are checksummed with, for
+// [WithRecordChecksums], [Provider.Verify] and [Provider.PlanHash], e.g., to use an implementation
+// approved by a compliance program. The default is [SHA256]. Go migrations keep the checksum
+// embedded with [WithChecksum], computed with SHA-256 by [GoMigrationChecksum].
+//
+// The name of the algorithm is recorded alongside each checksum. Checksums recorded with SHA-256,
+// including those recorded before algorithm names were, still verify after switching algorithms;
+// checksums recorded with another algorithm fail to verify until it is configured again.
+func WithChecksumAlgorithm(alg ChecksumAlgorithm) ProviderOption {
+	return configFunc(func(c *config) error {
+		if alg.Name == "" || strings.TrimSpace(alg.Name) != alg.Name {
+			return fmt.Errorf("invalid checksum algorithm name %q: must be non-empty without surrounding whitespace", alg.Name)
+		}
+		if alg.New == nil {
+			return fmt.Errorf("checksum algorithm %s must have a New function", alg.Name)
+		}
+		c.checksumAlgorithm = alg
+		return nil
+	})
+}
+
+// WithVerifyConcurrency sets the number of migrations whose checksums [Provider.Verify] and
+// [Provider.VerifyReport] compute concurrently. The default is [runtime.GOMAXPROCS].
+func WithVerifyConcurrency(n int) ProviderOption {
+	return configFunc(func(c *config) error {
+		if n < 1 {
+			return fmt.Errorf("verify concurrency must be at least 1: %d", n)
+		}
+		c.verifyConcurrency = n
+		return nil
+	})
+}
+
+// WithCheckpoints records the statements completed by SQL migrations that run outside a
+// transaction, e.g., with "-- +goose NO TRANSACTION". When such a migration fails, running it again
+// resumes at the failed statement instead of re-executing the statements that already completed.
+// Migrations run in a transaction do not need checkpoints, a failure rolls back all statements.
+//
+// The checkpoint is kept in the metadata table, see [WithRunLabels], and cleared once the migration
+// completes. If the completed statements were modified before running the migration again, the
+// checkpoint is ignored and all statements are run.
+func WithCheckpoints(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.checkpoints = b
+		return nil
+	})
+}
+
+// WithStreamSQL streams the statements of SQL migrations of at least threshold bytes from the
+// filesystem as they are executed, instead of loading the whole file into memory, so very large
+// migrations, such as multi-gigabyte data loads, run with flat memory usage.
+//
+// A streamed migration is read once to validate it before any statement runs, so a malformed file
+// still fails without side effects, and again to run each statement. The file must not change
+// while it is applied.
+func WithStreamSQL(threshold int64) ProviderOption {
+	return configFunc(func(c *config) error {
+		if threshold <= 0 {
+			return fmt.Errorf("stream threshold must be positive: %d", threshold)
+		}
+		c.streamSize = threshold
+		return nil
+	})
+}
+
+// WithAutoDown derives the Down section of SQL migrations that omit it from their Up statements.
+// Simple statements are reversed: CREATE TABLE is undone with DROP TABLE, CREATE INDEX with DROP
+// INDEX, and ALTER TABLE ... ADD COLUMN with ALTER TABLE ... DROP COLUMN, in reverse order.
+//
+// Migrations with any other statement are irreversible: applying them logs a warning listing the
+// statements, and rolling them back fails with an error wrapping [ErrIrreversible]. Migrations with
+// a Down annotation, even one without statements, and streamed migrations, see [WithStreamSQL],
+// are never changed.
+func WithAutoDown(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.autoDown = b
+		return nil
+	})
+}
+
+// WithRepeatable enables repeatable SQL migrations. Repeatable migrations are files named with an
+// "R__" prefix instead of a version, e.g., R__views.sql. They are ideal for views, functions and
+// stored procedures that are edited in place.
+//
+// Repeatable migrations are not versioned. Instead, after [Provider.Up] and [Provider.UpTo] apply
+// pending migrations, every repeatable migration whose contents changed since it last ran is run
+// again, in filename order. Only the Up section is used, and repeatable migrations are never rolled
+// back. Their checksums are tracked in the metadata table, see [WithRunLabels].
+func WithRepeatable(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.repeatable = b
+		return nil
+	})
+}
+
+// WithRoutinesDir treats every SQL file in dir, a path relative to the root of the provider's
+// filesystem, as a repeatable migration, see [WithRepeatable]. Each file typically defines one
+// view, function or stored procedure and need not be named with an "R__" prefix.
+//
+// Repeatable migrations, including routines, are run in dependency order, declared with
+// "-- +goose after NAME [NAME...]" directives that name the files a migration depends on. When a
+// migration runs, the migrations that depend on it run again too, so database objects built on top
+// of a recreated object are recreated as well.
+func WithRoutinesDir(dir string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if dir == "" || dir == "." || !fs.ValidPath(dir) {
+			return fmt.Errorf("invalid routines directory: %q", dir)
+		}
+		c.routinesDir = dir
+		return nil
+	})
+}
+
+// WithSearchPath sets the Postgres search_path of the session migrations run on, e.g., to migrate
+// one tenant schema in a schema-per-tenant database. The version table and unqualified names in
+// migrations resolve against the given schemas, in order. The schemas must exist.
+//
+// The search_path is set on the provider's connection and reset when the run completes. SQL
+// migrations and Go migrations registered with a *sql.Tx or *sql.Conn run on that connection. Go
+// migrations registered with a *sql.DB do not, and must schema-qualify their names.
+func WithSearchPath(schemas ...string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if len(schemas) == 0 {
+			return errors.New("search path must not be empty")
+		}
+		for _, schema := range schemas {
+			if schema == "" {
+				return errors.New("search path schema must not be empty")
+			}
+		}
+		c.searchPath = schemas
+		return nil
+	})
+}
+
+// PoolLimits are connection pool limits set on the *sql.DB of a provider for the duration of each
+// run, see [WithPoolLimits].
+type PoolLimits struct {
+	// MaxOpenConns and MaxIdleConns are set with [sql.DB.SetMaxOpenConns] and
+	// [sql.DB.SetMaxIdleConns] when a run starts. Zero leaves a limit unchanged.
+	MaxOpenConns int
+	MaxIdleConns int
+	// RestoreMaxIdleConns is the idle connection limit set when the run completes, since the
+	// current limit cannot be read from a *sql.DB. Zero restores the database/sql default of 2 if
+	// MaxIdleConns is set, and otherwise leaves the idle limit alone. Note that database/sql lowers
+	// the idle limit to MaxOpenConns if it was higher.
+	RestoreMaxIdleConns int
+}
+
+// WithPoolLimits caps the connections a migration run may take from the provider's *sql.DB, so
+// migrations do not starve an application sharing the pool. The limits are set when a run starts
+// and the previous maximum of open connections is restored when it completes, see [PoolLimits] for
+// idle connections. Providers sharing a *sql.DB should not run concurrently with pool limits.
+//
+// MaxOpenConns must be at least 2: the run holds one connection, and Go migrations registered
+// with a *sql.DB use another.
+func WithPoolLimits(limits PoolLimits) ProviderOption {
+	return configFunc(func(c *config) error {
+		if limits.MaxOpenConns < 0 || limits.MaxIdleConns < 0 || limits.RestoreMaxIdleConns < 0 {
+			return errors.New("pool limits must not be negative")
+		}
+		if limits.MaxOpenConns == 0 && limits.MaxIdleConns == 0 {
+			return errors.New("pool limits must set MaxOpenConns or MaxIdleConns")
+		}
+		if limits.MaxOpenConns == 1 {
+			return errors.New("pool limits must allow at least 2 open connections")
+		}
+		c.poolLimits = &limits
+		return nil
+	})
+}
+
+// WithApplicationName sets the Postgres application_name of the session migrations run on, so
+// migrations can be told apart from the application in pg_stat_activity and the server logs. Like
+// [WithSearchPath], it is set on the provider's connection and reset when the run completes, and Go
+// migrations registered with a *sql.DB do not run on that connection.
+func WithApplicationName(name string) ProviderOption {
+	return configFunc(func(c *config) error {
+		if name == "" {
+			return errors.New("application name must not be empty")
+		}
+		c.applicationName = name
+		return nil
+	})
+}
+
+// SessionTag is the custom Postgres setting set to "on" on the session migrations run on, with
+// [WithSessionTag]. The [ddlguard] package uses it to tell migrations apart from out-of-band DDL.
+//
+// [ddlguard]: https://pkg.go.dev/github.com/pressly/goose/v3/ddlguard
+const SessionTag = "goose.migration"
+
+// WithSessionTag sets the custom Postgres setting [SessionTag] to "on" on the session migrations
+// run on, so database-side checks, such as the event trigger of the ddlguard package, can tell
+// that a statement is run by goose. Like [WithSearchPath], it is set on the provider's connection
+// and reset when the run completes, and Go migrations registered with a *sql.DB do not run on
+// that connection.
+func WithSessionTag(b bool) ProviderOption {
+	return configFunc(func(c *config) error {
+		c.sessionTag = b
+		return nil
+	})
+}
+
 // WithDisableVersioning disables versioning. Disabling versioning allows applying migrations
 // without tracking the versions in the database schema table. Useful for tests, seeding a database
 // or running ad-hoc queries. By default, goose will track all versions in the database schema
@@ -174,11 +1111,25 @@ func WithLogger(l Logger) ProviderOption {
 }
 
 type config struct {
-	store database.Store
+	store      database.Store
+	tableName  string
+	searchPath []string
+	// Session settings and pool limits applied around each run.
+	applicationName string
+	sessionTag      bool
+	poolLimits      *PoolLimits
 
 	verbose         bool
 	excludePaths    map[string]bool
 	excludeVersions map[int64]bool
+	// Versions selected for runs, for hotfixes, and the reasons recorded with them.
+	includeVersions map[int64]bool
+	includeReason   string
+	skipVersions    map[int64]bool
+	skipReason      string
+	recursive       bool
+	filenameParser  FilenameParser
+	collectCache    *CollectCache
 
 	// Go migrations registered by the user. These will be merged/resolved against the globally
 	// registered migrations.
@@ -191,7 +1142,74 @@ type config struct {
 	// Feature
 	disableVersioning     bool
 	allowMissing          bool
+	strictOrdering        bool
+	atomicUp              bool
 	disableGlobalRegistry bool
+	registryScope         string
+
+	// Dependencies delivered to Go migrations via the context.
+	deps any
+	// Change-approval check invoked before running migrations, and whether freezes are ignored.
+	approval     ApprovalFunc
+	ignoreFreeze bool
+	// Maintenance window migrations may run in.
+	window        *gooseutil.Window
+	waitForWindow bool
+	// Pacing between consecutive migrations.
+	pause     time.Duration
+	pacing    PacingFunc
+	throttler *throttle.Throttler
+	// Checks of the migrated schema that veto a run, and whether a failed run is rolled back.
+	smokeTests        []SmokeTestFunc
+	smokeTestRollback bool
+	autoRollback      bool
+	// Watchdog for migrations that exceed their expected duration.
+	slowMigration        SlowMigrationFunc
+	cancelSlowMigrations bool
+	// Safety check of the plans of UPDATE and DELETE statements.
+	explainDML      bool
+	explainMaxRows  int64
+	explainWarnOnly bool
+	// Defaults added to CREATE TABLE statements that do not set them.
+	tableCharset   string
+	tableCollation string
+	// Indexes built CONCURRENTLY, and how often an invalid index is rebuilt.
+	concurrentIndexes bool
+	indexRetries      int
+	// Interval after which the sessions blocking a waiting statement are logged.
+	lockDiagnostics time.Duration
+	// Lock timeout of SQL migrations, and how often and after how long a timed out one is retried.
+	lockTimeout   time.Duration
+	lockRetryWait time.Duration
+	lockRetries   int
+	// Backups taken before destructive migrations.
+	backuper backup.Backuper
+	// Summaries of completed runs, and of failed runs only.
+	notifier        notify.Notifier
+	failureNotifier notify.Notifier
+	// Middleware around the execution of SQL statements.
+	middleware    []Middleware
+	statementTags bool
+	statementLog  io.Writer
+	// Size from which SQL migrations are streamed instead of loaded.
+	streamSize int64
+	// Down sections derived from the Up statements of SQL migrations that omit them.
+	autoDown bool
+	// Scratch database migrations are verified on before the target.
+	shadowDB     *sql.DB
+	shadowDSN    string
+	shadowServer string
+	// Labels recorded with each applied version.
+	runLabels       map[string]string
+	labelProtection *LabelProtection
+	recordChecksums bool
+	// Algorithm SQL migrations are hashed with; SHA-256 if New is nil.
+	checksumAlgorithm ChecksumAlgorithm
+	checkpoints       bool
+	repeatable        bool
+	routinesDir       string
+	// Number of migrations hashed concurrently by Verify.
+	verifyConcurrency int
 
 	logger Logger
 }
@@ -1,6 +1,7 @@
 package goose_test
 
 import (
+	"context"
 	"database/sql"
 	"path/filepath"
 	"testing"
@@ -46,6 +47,67 @@ func TestNewProvider(t *testing.T) {
 			goose.WithStore(store),
 		)
 		require.Error(t, err)
+		// Search path is only supported by postgres
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithSearchPath("tenant"))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithSearchPath())
+		require.Error(t, err)
+		// Application name is only supported by postgres
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithApplicationName("goose"))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithApplicationName(""))
+		require.Error(t, err)
+		// Session tag is only supported by postgres
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithSessionTag(true))
+		require.Error(t, err)
+		// Pool limits must leave room for the run's connection
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithPoolLimits(goose.PoolLimits{}))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithPoolLimits(goose.PoolLimits{MaxOpenConns: 1}))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithPoolLimits(goose.PoolLimits{MaxIdleConns: -1}))
+		require.Error(t, err)
+		// Selected versions need a reason and must not overlap
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithIncludeVersions([]int64{1}, ""))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithSkipVersions(nil, "reason"))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithIncludeVersions([]int64{1}, "reason"),
+			goose.WithSkipVersions([]int64{1}, "reason"),
+		)
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithSkipVersions([]int64{1}, "reason"),
+			goose.WithStrictOrdering(true),
+		)
+		require.Error(t, err)
+		// Explaining statements is only supported by postgres and mysql
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithExplainDML(1000))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithExplainDML(-1))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithExplainWarnOnly(true))
+		require.Error(t, err)
+		// Table charsets are only supported by mysql and tidb, and must be valid names
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithTableCharset("utf8mb4", ""))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectMySQL, db, fsys, goose.WithTableCharset("", ""))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectMySQL, db, fsys, goose.WithTableCharset("utf8mb4; DROP", ""))
+		require.Error(t, err)
+		// Creating the shadow database is only supported by postgres, and needs the server
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithShadow(goose.ShadowAuto), goose.WithShadowServer("file.db"))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithShadow(goose.ShadowAuto))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectPostgres, db, fsys,
+			goose.WithShadow("postgres://localhost/shadow"), goose.WithShadowServer("postgres://localhost/app"))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithShadow("shadow.db"), goose.WithShadowDB(db))
+		require.Error(t, err)
 	})
 	t.Run("valid", func(t *testing.T) {
 		// Valid dialect, db, and fsys allowed
@@ -62,4 +124,29 @@ func TestNewProvider(t *testing.T) {
 		_, err = goose.NewProvider("", db, nil, goose.WithStore(store))
 		require.Error(t, err)
 	})
+	t.Run("table_name", func(t *testing.T) {
+		// Empty table name not allowed
+		_, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithTableName(""))
+		require.Error(t, err)
+		// Multiple table names not allowed
+		_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithTableName("foo"),
+			goose.WithTableName("bar"),
+		)
+		require.Error(t, err)
+		// Cannot set table name with a custom store
+		store, err := database.NewStore(goose.DialectSQLite3, "custom_table")
+		require.NoError(t, err)
+		_, err = goose.NewProvider("", db, fsys, goose.WithStore(store), goose.WithTableName("foo"))
+		require.Error(t, err)
+		require.Equal(t, "table name must be set on the custom store implementation", err.Error())
+		// Valid table name
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithTableName("my_versions"))
+		require.NoError(t, err)
+		_, err = p.Up(context.Background())
+		require.NoError(t, err)
+		version, err := getMaxVersionID(db, "my_versions")
+		require.NoError(t, err)
+		require.EqualValues(t, 4, version)
+	})
 }
@@ -0,0 +1,468 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/controller"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"go.uber.org/multierr"
+)
+
+const (
+	// partitionKeyPrefix is the prefix of the metadata keys that track the partitions managed by
+	// partition maintenance. Partitions are not versioned, so, like repeatable migrations, they are
+	// tracked under version 0, apart from the version history.
+	partitionKeyPrefix = "partition:"
+	// defaultPartitionPremake is the number of future partitions created ahead of the current one
+	// when a partition directive does not set premake.
+	defaultPartitionPremake = 3
+	// partitionAttached and partitionDetached are the recorded states of a managed partition.
+	partitionAttached = "attached"
+	partitionDetached = "detached"
+)
+
+// PartitionAction is what partition maintenance did to a partition, see [PartitionChange].
+type PartitionAction string
+
+const (
+	// PartitionCreated is a partition created ahead of the rows it holds.
+	PartitionCreated PartitionAction = "created"
+	// PartitionDetached is an expired partition detached from its table. The detached table and
+	// its rows are kept.
+	PartitionDetached PartitionAction = "detached"
+	// PartitionDropped is an expired partition that was dropped, together with its rows.
+	PartitionDropped PartitionAction = "dropped"
+)
+
+// PartitionChange is a partition created, detached or dropped by partition maintenance, see
+// [Provider.MaintainPartitions].
+type PartitionChange struct {
+	// Table is the partitioned table, as declared by the partition directive.
+	Table string `json:"table"`
+	// Partition is the name of the partition, e.g., "events_p202610".
+	Partition string `json:"partition"`
+	// Action is what was done to the partition.
+	Action PartitionAction `json:"action"`
+	// From and To are the bounds of the partition, which holds the rows from From, inclusive, to
+	// To, exclusive. Both are midnight UTC.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+func (c *PartitionChange) String() string {
+	return fmt.Sprintf("%s partition %s of %s [%s, %s)",
+		c.Action, c.Partition, c.Table, c.From.Format(time.DateOnly), c.To.Format(time.DateOnly))
+}
+
+// partitionLayouts are the time layouts of the partition name suffixes, by interval. Weekly
+// partitions are named after the Monday they start on.
+var partitionLayouts = map[string]string{
+	"day":   "20060102",
+	"week":  "20060102",
+	"month": "200601",
+}
+
+// partitionTableRe matches the name of a partitioned table, optionally qualified by its schema.
+var partitionTableRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// partitionPolicy is a time-partitioning policy for a table partitioned by range, declared with a
+// "-- +goose partition TABLE interval=day|week|month [premake=N] [retain=N] [expire=detach|drop]"
+// directive.
+type partitionPolicy struct {
+	table    string
+	interval string
+	// premake is the number of partitions created ahead of the current one.
+	premake int
+	// retain is the number of partitions kept before the current one, or zero if partitions never
+	// expire.
+	retain int
+	// drop is true if expired partitions are dropped rather than only detached.
+	drop   bool
+	source string
+}
+
+// parsePartitions returns the partitioning policies declared by partition directives, in the order
+// they appear.
+func parsePartitions(directives []sqlparser.Directive) ([]partitionPolicy, error) {
+	var policies []partitionPolicy
+	for _, d := range directives {
+		if d.Name != sqlparser.DirectivePartition {
+			continue
+		}
+		fields := strings.Fields(d.Value)
+		if len(fields) == 0 || strings.Contains(fields[0], "=") {
+			return nil, fmt.Errorf("invalid %s directive %q: must be a table name followed by key=value options, e.g., events interval=month",
+				sqlparser.DirectivePartition, d.Value)
+		}
+		if !partitionTableRe.MatchString(fields[0]) {
+			return nil, fmt.Errorf("invalid partitioned table name: %q", fields[0])
+		}
+		policy := partitionPolicy{table: fields[0], premake: defaultPartitionPremake}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("invalid %s directive %q: %q must be of form key=value", sqlparser.DirectivePartition, d.Value, field)
+			}
+			switch key {
+			case "interval":
+				policy.interval = strings.ToLower(value)
+			case "premake", "retain":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid %s directive %q: %s must be a number of partitions", sqlparser.DirectivePartition, d.Value, key)
+				}
+				if key == "premake" {
+					policy.premake = n
+				} else {
+					policy.retain = n
+				}
+			case "expire":
+				switch strings.ToLower(value) {
+				case "detach":
+				case "drop":
+					policy.drop = true
+				default:
+					return nil, fmt.Errorf("invalid %s directive %q: expire must be detach or drop", sqlparser.DirectivePartition, d.Value)
+				}
+			default:
+				return nil, fmt.Errorf("invalid %s directive %q: unknown key %q", sqlparser.DirectivePartition, d.Value, key)
+			}
+		}
+		if _, ok := partitionLayouts[policy.interval]; !ok {
+			return nil, fmt.Errorf("invalid %s directive %q: interval must be day, week or month", sqlparser.DirectivePartition, d.Value)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// start returns the start of the partition holding t.
+func (pp partitionPolicy) start(t time.Time) time.Time {
+	t = t.UTC()
+	switch pp.interval {
+	case "week":
+		// Weeks start on Monday.
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// add returns the start of the partition n partitions after the one starting at start.
+func (pp partitionPolicy) add(start time.Time, n int) time.Time {
+	switch pp.interval {
+	case "week":
+		return start.AddDate(0, 0, 7*n)
+	case "month":
+		return start.AddDate(0, n, 0)
+	}
+	return start.AddDate(0, 0, n)
+}
+
+// name returns the name of the partition starting at start, e.g., "events_p202610". It is
+// qualified by the schema of the table, if any.
+func (pp partitionPolicy) name(start time.Time) string {
+	return pp.table + "_p" + start.Format(partitionLayouts[pp.interval])
+}
+
+// parseName returns the start of the partition with the given name, unqualified or qualified by
+// the schema of the table, and reports whether the partition is named by the policy. Partitions
+// named otherwise, such as a default partition, are not managed by goose.
+func (pp partitionPolicy) parseName(name string) (time.Time, bool) {
+	prefix := pp.table + "_p"
+	if i := strings.LastIndexByte(pp.table, '.'); i >= 0 && !strings.Contains(name, ".") {
+		prefix = pp.table[i+1:] + "_p"
+	}
+	suffix, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	start, err := time.Parse(partitionLayouts[pp.interval], suffix)
+	if err != nil || !pp.start(start).Equal(start) {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// collectPartitions returns the partitioning policies declared by the SQL migrations in fsys.
+func collectPartitions(fsys fs.FS, migrations []*Migration) ([]partitionPolicy, error) {
+	var policies []partitionPolicy
+	for _, m := range migrations {
+		if !isSQLFile(m.Source) {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, m.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", m.Source, err)
+		}
+		directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse migration %s: %w", m.Source, err)
+		}
+		found, err := parsePartitions(directives)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", m.Source, err)
+		}
+		for _, policy := range found {
+			policy.source = m.Source
+			policies = append(policies, policy)
+		}
+	}
+	return policies, nil
+}
+
+// MaintainPartitions runs partition maintenance for the partitioning policies declared by the
+// applied SQL migrations with "-- +goose partition" directives. Maintenance is repeatable, and is
+// meant to run on a schedule, e.g., daily: each run creates the partitions from the current one up
+// to premake partitions ahead, if they do not exist, and detaches, or with expire=drop drops, the
+// partitions that start more than retain partitions before the current one.
+//
+// Partitions are tracked in the metadata table under version 0, apart from the version history, so
+// maintenance never adds or removes versions. Only partitions named by goose, e.g.,
+// events_p202610, are expired. Partition maintenance requires the Postgres dialect, or a custom
+// store on Postgres.
+func (p *Provider) MaintainPartitions(ctx context.Context) (_ []*PartitionChange, retErr error) {
+	if p.dialect != "" && p.dialect != DialectPostgres {
+		return nil, fmt.Errorf("partition maintenance requires the %s dialect", DialectPostgres)
+	}
+	if p.cfg.disableVersioning {
+		return nil, errors.New("partition maintenance requires versioning: the applied migrations declare the partitioning policies")
+	}
+	conn, cleanup, err := p.initialize(ctx, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize: %w", err)
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, cleanup())
+	}()
+	dbMigrations, err := p.store.ListMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Version] = true
+	}
+	var migrations []*Migration
+	for _, m := range p.migrations {
+		if applied[m.Version] {
+			migrations = append(migrations, m)
+		}
+	}
+	policies, err := collectPartitions(p.fsys, migrations)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := maintainPartitions(ctx, conn, p.store, policies, time.Now())
+	for _, c := range changes {
+		p.printf("%s", c)
+	}
+	return changes, err
+}
+
+// maintainPartitionsAfter runs partition maintenance for the policies declared by the migrations
+// that were just applied, so that their tables have partitions before the next scheduled run.
+func (p *Provider) maintainPartitionsAfter(ctx context.Context, conn *sql.Conn, results []*MigrationResult) error {
+	var policies []partitionPolicy
+	for _, result := range results {
+		if result.Repeatable || result.Source.Type != TypeSQL {
+			continue
+		}
+		m, err := p.getMigration(result.Source.Version)
+		if err != nil {
+			return err
+		}
+		for _, policy := range m.sql.Partitions {
+			policy.source = m.Source
+			policies = append(policies, policy)
+		}
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	changes, err := maintainPartitions(ctx, conn, p.store, policies, time.Now())
+	for _, c := range changes {
+		p.printf("%s", c)
+	}
+	return err
+}
+
+// maintainPartitions creates and expires the partitions of each policy, as of now, see
+// [Provider.MaintainPartitions]. The changes to the partitions of a table and their tracking
+// metadata are made in a single transaction. The changes made before an error are returned with
+// it.
+func maintainPartitions(
+	ctx context.Context,
+	conn *sql.Conn,
+	store *controller.StoreController,
+	policies []partitionPolicy,
+	now time.Time,
+) ([]*PartitionChange, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	if err := store.CreateMetadataTable(ctx, conn); err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, fmt.Errorf("partition maintenance requires a store with metadata support: %w", err)
+		}
+		return nil, err
+	}
+	metadata, err := store.ListMetadata(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	recorded := make(map[string]string)
+	for _, r := range metadata {
+		if name, ok := strings.CutPrefix(r.Key, partitionKeyPrefix); ok && r.Version == 0 {
+			recorded[name] = r.Value
+		}
+	}
+	var changes []*PartitionChange
+	for _, policy := range policies {
+		var made []*PartitionChange
+		err := beginTx(ctx, conn, func(tx *sql.Tx) error {
+			var err error
+			made, err = maintainPartition(ctx, tx, store, policy, recorded, now)
+			return err
+		})
+		if err != nil {
+			return changes, fmt.Errorf("failed to maintain partitions of %s (%s): %w", policy.table, policy.source, err)
+		}
+		changes = append(changes, made...)
+	}
+	return changes, nil
+}
+
+// maintainPartition creates and expires the partitions of a single policy, and updates recorded
+// with the new state of its partitions.
+func maintainPartition(
+	ctx context.Context,
+	db database.DBTxConn,
+	store *controller.StoreController,
+	policy partitionPolicy,
+	recorded map[string]string,
+	now time.Time,
+) ([]*PartitionChange, error) {
+	attached, err := listPartitions(ctx, db, policy)
+	if err != nil {
+		return nil, err
+	}
+	record := func(name, state string) error {
+		key := partitionKeyPrefix + name
+		if err := store.DeleteMetadataKey(ctx, db, 0, key); err != nil {
+			return err
+		}
+		if state == "" {
+			delete(recorded, name)
+			return nil
+		}
+		recorded[name] = state
+		return store.InsertMetadata(ctx, db, 0, key, state)
+	}
+	var changes []*PartitionChange
+	current := policy.start(now)
+	for i := 0; i <= policy.premake; i++ {
+		from := policy.add(current, i)
+		if attached[from] {
+			continue
+		}
+		to := policy.add(from, 1)
+		name := policy.name(from)
+		q := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM ('%s') TO ('%s')",
+			name, policy.table, from.Format(time.DateOnly), to.Format(time.DateOnly))
+		if _, err := db.ExecContext(ctx, q); err != nil {
+			return nil, fmt.Errorf("failed to create partition %s: %w", name, err)
+		}
+		if err := record(name, partitionAttached); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &PartitionChange{Table: policy.table, Partition: name, Action: PartitionCreated, From: from, To: to})
+	}
+	if policy.retain == 0 {
+		return changes, nil
+	}
+	cutoff := policy.add(current, -policy.retain)
+	var expired []time.Time
+	for from := range attached {
+		if from.Before(cutoff) {
+			expired = append(expired, from)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].Before(expired[j]) })
+	for _, from := range expired {
+		name := policy.name(from)
+		if _, err := db.ExecContext(ctx, "ALTER TABLE "+policy.table+" DETACH PARTITION "+name); err != nil {
+			return nil, fmt.Errorf("failed to detach partition %s: %w", name, err)
+		}
+		action, state := PartitionDetached, partitionDetached
+		if policy.drop {
+			if _, err := db.ExecContext(ctx, "DROP TABLE "+name); err != nil {
+				return nil, fmt.Errorf("failed to drop partition %s: %w", name, err)
+			}
+			action, state = PartitionDropped, ""
+		}
+		if err := record(name, state); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &PartitionChange{Table: policy.table, Partition: name, Action: action, From: from, To: policy.add(from, 1)})
+	}
+	if !policy.drop {
+		return changes, nil
+	}
+	// Partitions detached by earlier runs, e.g., before expire=drop was set, are dropped too.
+	var names []string
+	for name, state := range recorded {
+		if from, ok := policy.parseName(name); ok && state == partitionDetached && from.Before(cutoff) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		from, _ := policy.parseName(name)
+		if _, err := db.ExecContext(ctx, "DROP TABLE IF EXISTS "+name); err != nil {
+			return nil, fmt.Errorf("failed to drop partition %s: %w", name, err)
+		}
+		if err := record(name, ""); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &PartitionChange{Table: policy.table, Partition: name, Action: PartitionDropped, From: from, To: policy.add(from, 1)})
+	}
+	return changes, nil
+}
+
+// listPartitions returns the starts of the partitions of the policy's table that are attached and
+// named by goose.
+func listPartitions(ctx context.Context, db database.DBTxConn, policy partitionPolicy) (map[time.Time]bool, error) {
+	q := `SELECT c.relname FROM pg_catalog.pg_inherits i JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid WHERE i.inhparent = $1::regclass`
+	rows, err := db.QueryContext(ctx, q, policy.table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", policy.table, err)
+	}
+	defer rows.Close()
+	attached := make(map[time.Time]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to list partitions of %s: %w", policy.table, err)
+		}
+		if from, ok := policy.parseName(name); ok {
+			attached[from] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list partitions of %s: %w", policy.table, err)
+	}
+	return attached, nil
+}
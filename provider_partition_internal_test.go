@@ -0,0 +1,123 @@
+package goose
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/pressly/goose/v3/internal/controller"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePartitions(t *testing.T) {
+	t.Parallel()
+
+	policies, err := parsePartitions([]sqlparser.Directive{
+		{Name: sqlparser.DirectivePartition, Value: "events interval=month premake=2 retain=12 expire=drop"},
+		{Name: sqlparser.DirectiveTitle, Value: "Add events"},
+		{Name: sqlparser.DirectivePartition, Value: "app.logs interval=DAY"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []partitionPolicy{
+		{table: "events", interval: "month", premake: 2, retain: 12, drop: true},
+		{table: "app.logs", interval: "day", premake: defaultPartitionPremake},
+	}, policies)
+
+	for value, msg := range map[string]string{
+		"":                                  "must be a table name",
+		"interval=month":                    "must be a table name",
+		"events;drop interval=month":        "invalid partitioned table name",
+		"events":                            "interval must be day, week or month",
+		"events interval=year":              "interval must be day, week or month",
+		"events interval=month retain=-1":   "retain must be a number of partitions",
+		"events interval=month expire=keep": "expire must be detach or drop",
+		"events interval=month size=10":     `unknown key "size"`,
+		"events interval":                   "must be of form key=value",
+	} {
+		_, err := parsePartitions([]sqlparser.Directive{{Name: sqlparser.DirectivePartition, Value: value}})
+		require.ErrorContains(t, err, msg, value)
+	}
+}
+
+func TestPartitionPolicy(t *testing.T) {
+	t.Parallel()
+
+	// Wednesday.
+	now := time.Date(2026, 10, 14, 15, 4, 5, 0, time.UTC)
+	for _, tc := range []struct {
+		interval string
+		start    string
+		next     string
+		name     string
+	}{
+		{"day", "2026-10-14", "2026-10-15", "events_p20261014"},
+		{"week", "2026-10-12", "2026-10-19", "events_p20261012"},
+		{"month", "2026-10-01", "2026-11-01", "events_p202610"},
+	} {
+		pp := partitionPolicy{table: "events", interval: tc.interval}
+		start := pp.start(now)
+		require.Equal(t, tc.start, start.Format(time.DateOnly))
+		require.Equal(t, tc.next, pp.add(start, 1).Format(time.DateOnly))
+		require.Equal(t, tc.name, pp.name(start))
+		parsed, ok := pp.parseName(tc.name)
+		require.True(t, ok)
+		require.Equal(t, start, parsed)
+	}
+	pp := partitionPolicy{table: "app.events", interval: "week"}
+	_, ok := pp.parseName("events_p20261012")
+	require.True(t, ok)
+	_, ok = pp.parseName("app.events_p20261012")
+	require.True(t, ok)
+	// Not a Monday, nor a partition named by goose.
+	_, ok = pp.parseName("events_p20261014")
+	require.False(t, ok)
+	_, ok = pp.parseName("events_default")
+	require.False(t, ok)
+}
+
+func TestMaintainPartitions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC)
+	db, rec := goosetest.NewDB()
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+	store := controller.NewStoreController(goosetest.NewStore("goose_db_version"))
+	require.NoError(t, store.CreateMetadataTable(ctx, conn))
+	// Detached by an earlier run, before expire=drop was set.
+	require.NoError(t, store.InsertMetadata(ctx, conn, 0, partitionKeyPrefix+"events_p202508", partitionDetached))
+	require.NoError(t, store.InsertMetadata(ctx, conn, 0, partitionKeyPrefix+"events_p202510", partitionDetached))
+
+	policy := partitionPolicy{table: "events", interval: "month", premake: 1, retain: 12, drop: true}
+	changes, err := maintainPartitions(ctx, conn, store, []partitionPolicy{policy}, now)
+	require.NoError(t, err)
+	var actions []string
+	for _, c := range changes {
+		actions = append(actions, c.String())
+	}
+	require.Equal(t, []string{
+		"created partition events_p202610 of events [2026-10-01, 2026-11-01)",
+		"created partition events_p202611 of events [2026-11-01, 2026-12-01)",
+		"dropped partition events_p202508 of events [2025-08-01, 2025-09-01)",
+	}, actions)
+	require.Contains(t, rec.Queries(),
+		"CREATE TABLE IF NOT EXISTS events_p202610 PARTITION OF events FOR VALUES FROM ('2026-10-01') TO ('2026-11-01')")
+	require.Contains(t, rec.Queries(), "DROP TABLE IF EXISTS events_p202508")
+
+	metadata, err := store.ListMetadata(ctx, conn)
+	require.NoError(t, err)
+	recorded := make(map[string]string)
+	for _, r := range metadata {
+		require.Zero(t, r.Version)
+		recorded[r.Key] = r.Value
+	}
+	require.Equal(t, map[string]string{
+		partitionKeyPrefix + "events_p202510": partitionDetached,
+		partitionKeyPrefix + "events_p202610": partitionAttached,
+		partitionKeyPrefix + "events_p202611": partitionAttached,
+	}, recorded)
+}
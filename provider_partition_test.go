@@ -0,0 +1,78 @@
+package goose_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionMaintenance(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose partition events interval=day premake=1\n-- +goose Up\n" +
+			"CREATE TABLE events (id INTEGER, created_at DATE NOT NULL) PARTITION BY RANGE (created_at);\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nSELECT 1;\n"),
+	}
+	countPartitions := func(queries []string) int {
+		var n int
+		for _, q := range queries {
+			if strings.HasPrefix(q, "CREATE TABLE IF NOT EXISTS events_p") && strings.Contains(q, " PARTITION OF events ") {
+				n++
+			}
+		}
+		return n
+	}
+	db, rec := goosetest.NewDB()
+	p, err := goose.NewProvider("", db, fsys, goose.WithStore(goosetest.NewStore("goose_db_version")))
+	require.NoError(t, err)
+	// Nothing to maintain before the migration declaring the policy is applied.
+	changes, err := p.MaintainPartitions(ctx)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+	_, err = p.UpTo(ctx, 1)
+	require.NoError(t, err)
+	// The current and next partitions are created right after the table.
+	require.Equal(t, 2, countPartitions(rec.Queries()))
+
+	rec.Reset()
+	changes, err = p.MaintainPartitions(ctx)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, goose.PartitionCreated, changes[0].Action)
+	require.Equal(t, "events", changes[0].Table)
+	require.Equal(t, changes[0].To, changes[1].From)
+	require.Equal(t, 2, countPartitions(rec.Queries()))
+
+	// Policies are only maintained after the migration declaring them.
+	rec.Reset()
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Zero(t, countPartitions(rec.Queries()))
+
+	t.Run("dialect", func(t *testing.T) {
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "partition directive requires the postgres dialect")
+		require.False(t, tableExists(t, db, "events"))
+		_, err = p.MaintainPartitions(ctx)
+		require.ErrorContains(t, err, "partition maintenance requires the postgres dialect")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose partition events interval=year\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider("", db, fsys, goose.WithStore(goosetest.NewStore("goose_db_version")))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `invalid partition directive "events interval=year"`)
+	})
+}
@@ -0,0 +1,89 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// materializedView is a materialized view to refresh after a migration is applied, declared with a
+// "-- +goose refresh-materialized-view NAME [concurrently]" directive.
+type materializedView struct {
+	name         string
+	concurrently bool
+}
+
+// parseMaterializedViews returns the materialized views declared by the directives, in the order
+// they appear.
+func parseMaterializedViews(directives []sqlparser.Directive) ([]materializedView, error) {
+	var views []materializedView
+	for _, d := range directives {
+		if d.Name != sqlparser.DirectiveRefreshMaterializedView {
+			continue
+		}
+		fields := strings.Fields(d.Value)
+		switch {
+		case len(fields) == 1:
+			views = append(views, materializedView{name: fields[0]})
+		case len(fields) == 2 && strings.EqualFold(fields[1], "concurrently"):
+			views = append(views, materializedView{name: fields[0], concurrently: true})
+		default:
+			return nil, fmt.Errorf("invalid %s directive %q: must be a view name, optionally followed by concurrently",
+				sqlparser.DirectiveRefreshMaterializedView, d.Value)
+		}
+		if strings.Contains(fields[0], ";") {
+			return nil, fmt.Errorf("invalid materialized view name: %q", fields[0])
+		}
+	}
+	return views, nil
+}
+
+// refreshMaterializedViews refreshes the materialized views declared by the applied migrations.
+// Each view is refreshed once, in the order it was first declared, after all migrations ran.
+//
+// A concurrent refresh does not block reads of the view, but requires the view to be populated and
+// to have a unique index. When a concurrent refresh fails, the view is refreshed without
+// CONCURRENTLY instead.
+func (p *Provider) refreshMaterializedViews(ctx context.Context, conn *sql.Conn, results []*MigrationResult) error {
+	var views []materializedView
+	seen := make(map[string]int)
+	for _, result := range results {
+		if result.Repeatable || result.Source.Type != TypeSQL {
+			continue
+		}
+		m, err := p.getMigration(result.Source.Version)
+		if err != nil {
+			return err
+		}
+		for _, view := range m.sql.Refresh {
+			if i, ok := seen[view.name]; ok {
+				views[i].concurrently = views[i].concurrently || view.concurrently
+				continue
+			}
+			seen[view.name] = len(views)
+			views = append(views, view)
+		}
+	}
+	for _, view := range views {
+		start := time.Now()
+		var err error
+		if view.concurrently {
+			_, err = conn.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view.name)
+			if err != nil {
+				p.printf("concurrent refresh of materialized view %s failed, refreshing without concurrently: %v", view.name, err)
+			}
+		}
+		if !view.concurrently || err != nil {
+			_, err = conn.ExecContext(ctx, "REFRESH MATERIALIZED VIEW "+view.name)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to refresh materialized view %s: %w", view.name, err)
+		}
+		p.printf("refreshed materialized view %s (%s)", view.name, truncateDuration(time.Since(start)))
+	}
+	return nil
+}
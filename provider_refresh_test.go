@@ -0,0 +1,33 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRefreshMaterializedView(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// Materialized views are refreshed by the postgres integration tests, here we only check that
+	// unsupported configurations are rejected before any migration runs.
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose refresh-materialized-view totals\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorContains(t, err, "refreshing materialized views requires the postgres dialect")
+	require.False(t, tableExists(t, db, "a"))
+
+	fsys["00001_a.sql"] = newMapFile("-- +goose refresh-materialized-view totals eventually\n-- +goose Up\nSELECT 1;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorContains(t, err, "invalid refresh-materialized-view directive")
+}
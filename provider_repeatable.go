@@ -0,0 +1,281 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+const (
+	// repeatablePrefix is the filename prefix of repeatable SQL migrations, e.g., R__views.sql.
+	repeatablePrefix = "R__"
+	// repeatableKeyPrefix is the prefix of the metadata keys that record the checksum of each
+	// repeatable migration. Repeatable migrations are not versioned, so their metadata is stored
+	// under version 0, which is never rolled back.
+	repeatableKeyPrefix = "repeatable:"
+)
+
+// collectRepeatables returns the repeatable SQL migrations in fsys, ordered by path. If
+// routinesDir is not empty, every SQL file in that directory is a repeatable migration as well.
+func collectRepeatables(
+	fsys fs.FS,
+	repeatable bool,
+	routinesDir string,
+	recursive bool,
+	excludePaths map[string]bool,
+) ([]*Migration, error) {
+	var files []string
+	if repeatable {
+		matches, err := globFilesystem(fsys, repeatablePrefix+"*.sql", recursive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob repeatable migrations: %w", err)
+		}
+		files = append(files, matches...)
+	}
+	if routinesDir != "" {
+		matches, err := fs.Glob(fsys, path.Join(routinesDir, "*.sql"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to glob routines: %w", err)
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	var repeatables []*Migration
+	conflicts := make(caseConflicts)
+	for i, fullpath := range files {
+		// A recursive glob may also find R__ files in the routines directory.
+		if i > 0 && files[i-1] == fullpath {
+			continue
+		}
+		if err := conflicts.add(fullpath); err != nil {
+			return nil, err
+		}
+		if excludePaths[path.Base(fullpath)] || excludePaths[fullpath] {
+			continue
+		}
+		repeatables = append(repeatables, newSQLMigration(Source{Type: TypeSQL, Path: fullpath}))
+	}
+	return repeatables, nil
+}
+
+// orderRepeatables returns the repeatable migrations ordered so that every migration comes after
+// the migrations it depends on. Migrations without a dependency between them keep their path
+// order. The dependencies of a migration are given by path, and an unknown dependency or a
+// dependency cycle is an error.
+func orderRepeatables(repeatables []*Migration, after map[string][]string) ([]*Migration, error) {
+	bySource := make(map[string]*Migration, len(repeatables))
+	for _, m := range repeatables {
+		bySource[m.Source] = m
+	}
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(repeatables))
+	ordered := make([]*Migration, 0, len(repeatables))
+	var visit func(m *Migration, chain []string) error
+	visit = func(m *Migration, chain []string) error {
+		switch state[m.Source] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(chain, m.Source), " -> "))
+		}
+		state[m.Source] = visiting
+		for _, dep := range after[m.Source] {
+			d, ok := bySource[dep]
+			if !ok {
+				return fmt.Errorf("%s: unknown dependency %q", m.Source, dep)
+			}
+			if err := visit(d, append(chain, m.Source)); err != nil {
+				return err
+			}
+		}
+		state[m.Source] = visited
+		ordered = append(ordered, m)
+		return nil
+	}
+	for _, m := range repeatables {
+		if err := visit(m, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// resolveAfter returns the paths of the repeatable migrations named by an "after" directive. Names
+// are either paths relative to the root of the filesystem or, if unambiguous, base names.
+func resolveAfter(repeatables []*Migration, source string, directives []sqlparser.Directive) ([]string, error) {
+	var deps []string
+	for _, d := range directives {
+		if d.Name != sqlparser.DirectiveAfter {
+			continue
+		}
+		for _, name := range strings.FieldsFunc(d.Value, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			name = normalizePath(name)
+			var matches []string
+			for _, m := range repeatables {
+				if m.Source == name {
+					matches = []string{m.Source}
+					break
+				}
+				if path.Base(m.Source) == name {
+					matches = append(matches, m.Source)
+				}
+			}
+			switch len(matches) {
+			case 0:
+				return nil, fmt.Errorf("%s: unknown dependency %q", source, name)
+			case 1:
+				deps = append(deps, matches[0])
+			default:
+				return nil, fmt.Errorf("%s: ambiguous dependency %q matches %s", source, name, strings.Join(matches, ", "))
+			}
+		}
+	}
+	return deps, nil
+}
+
+// pendingRepeatables returns every repeatable migration whose checksum differs from the checksum
+// recorded when it last ran, including repeatable migrations that never ran, and the current
+// checksums of the files. Migrations that depend on a migration that runs are run again too, in
+// dependency order.
+func (p *Provider) pendingRepeatables(ctx context.Context, conn *sql.Conn) ([]*Migration, map[string]string, error) {
+	if len(p.repeatables) == 0 {
+		return nil, nil, nil
+	}
+	if err := p.prepareMetadata(ctx, conn, false); err != nil {
+		return nil, nil, fmt.Errorf("failed to prepare metadata table: %w", err)
+	}
+	metadata, err := p.store.ListMetadata(ctx, conn)
+	if err != nil {
+		return nil, nil, err
+	}
+	recorded := make(map[string]string)
+	for _, r := range metadata {
+		if name, ok := strings.CutPrefix(r.Key, repeatableKeyPrefix); ok && r.Version == 0 {
+			recorded[name] = r.Value
+		}
+	}
+	// The files are expected to change between runs, so checksums and dependencies are always
+	// computed from their current contents.
+	sums := make(map[string]string, len(p.repeatables))
+	after := make(map[string][]string)
+	for _, m := range p.repeatables {
+		data, err := fs.ReadFile(p.fsys, m.Source)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read repeatable migration %s: %w", m.Source, err)
+		}
+		directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse repeatable migration %s: %w", m.Source, err)
+		}
+		if after[m.Source], err = resolveAfter(p.repeatables, m.Source, directives); err != nil {
+			return nil, nil, fmt.Errorf("failed to order repeatable migrations: %w", err)
+		}
+		sums[m.Source] = sqlChecksum(data)
+	}
+	ordered, err := orderRepeatables(p.repeatables, after)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to order repeatable migrations: %w", err)
+	}
+	var run []*Migration
+	rerun := make(map[string]bool)
+	for _, m := range ordered {
+		rerun[m.Source] = recorded[m.Source] != sums[m.Source]
+		for _, dep := range after[m.Source] {
+			rerun[m.Source] = rerun[m.Source] || rerun[dep]
+		}
+		if !rerun[m.Source] {
+			continue
+		}
+		parsed, err := sqlparser.ParseAllFromFS(p.fsys, m.Source, false)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to prepare repeatable migration %s: %w", m.Source, err)
+		}
+		if err := checkRequiresGoose(parsed.Directives); err != nil {
+			return nil, nil, fmt.Errorf("failed to prepare repeatable migration %s: %w", m.Source, err)
+		}
+		m.sql = sqlMigration{Parsed: true, UseTx: parsed.UseTx, Up: parsed.Up}
+		run = append(run, m)
+	}
+	return run, sums, nil
+}
+
+// runRepeatables runs the pending repeatable migrations returned by [Provider.pendingRepeatables]
+// and records their checksums. The maintenance window is checked before each of them.
+func (p *Provider) runRepeatables(ctx context.Context, conn *sql.Conn, run []*Migration, sums map[string]string) ([]*MigrationResult, error) {
+	var results []*MigrationResult
+	for i, m := range run {
+		result := &MigrationResult{
+			Source:     &Source{Type: TypeSQL, Path: m.Source},
+			Direction:  sqlparser.DirectionUp.String(),
+			Empty:      len(m.sql.Up) == 0,
+			Repeatable: true,
+		}
+		if i > 0 {
+			if err := p.pace(ctx, results[i-1]); err != nil {
+				result.Error = err
+				return nil, &PartialError{
+					Applied: results,
+					Failed:  result,
+					Err:     err,
+				}
+			}
+		}
+		if err := p.checkWindow(); err != nil {
+			result.Error = err
+			return nil, &PartialError{
+				Applied: results,
+				Failed:  result,
+				Err:     err,
+			}
+		}
+		start := time.Now()
+		if err := p.runRepeatable(ctx, conn, m, sums[m.Source]); err != nil {
+			result.Error = err
+			result.Duration = time.Since(start)
+			return nil, &PartialError{
+				Applied: results,
+				Failed:  result,
+				Err:     err,
+			}
+		}
+		result.Duration = time.Since(start)
+		results = append(results, result)
+		p.printf("%s", result)
+	}
+	return results, nil
+}
+
+func (p *Provider) runRepeatable(ctx context.Context, conn *sql.Conn, m *Migration, sum string) error {
+	record := func(db database.DBTxConn) error {
+		key := repeatableKeyPrefix + m.Source
+		if err := p.store.DeleteMetadataKey(ctx, db, 0, key); err != nil {
+			return err
+		}
+		return p.store.InsertMetadata(ctx, db, 0, key, sum)
+	}
+	if m.sql.UseTx {
+		return beginTx(ctx, conn, func(tx *sql.Tx) error {
+			if err := p.runSQL(ctx, tx, m, true); err != nil {
+				return err
+			}
+			return record(tx)
+		})
+	}
+	if err := p.runSQL(ctx, conn, m, true); err != nil {
+		return err
+	}
+	return record(conn)
+}
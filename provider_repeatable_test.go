@@ -0,0 +1,125 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepeatable(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"00001_a.sql":  newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"R__views.sql": newMapFile("-- +goose Up\nDROP VIEW IF EXISTS v;\nCREATE VIEW v AS SELECT id FROM a;\n"),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRepeatable(true))
+	require.NoError(t, err)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.False(t, res[0].Repeatable)
+	require.True(t, res[1].Repeatable)
+	require.Equal(t, "R__views.sql", res[1].Source.Path)
+	require.EqualValues(t, 0, res[1].Source.Version)
+
+	// Unchanged repeatable migrations are not run again.
+	res, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Empty(t, res)
+
+	// A changed repeatable migration runs even when no versioned migrations are pending.
+	fsys["R__views.sql"] = newMapFile("-- +goose Up\nDROP VIEW IF EXISTS v;\nCREATE VIEW v AS SELECT id, id AS id2 FROM a;\n")
+	res, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.True(t, res[0].Repeatable)
+	_, err = db.ExecContext(ctx, "SELECT id2 FROM v")
+	require.NoError(t, err)
+	current, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, current)
+
+	// A failed repeatable migration is not recorded and runs again on the next up.
+	fsys["R__views.sql"] = newMapFile("-- +goose Up\nCREATE VIEW v AS SELECT id FROM a;\n")
+	_, err = p.Up(ctx)
+	var partialErr *goose.PartialError
+	require.ErrorAs(t, err, &partialErr)
+	require.True(t, partialErr.Failed.Repeatable)
+	_, err = p.Up(ctx)
+	require.Error(t, err)
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+		goose.WithRepeatable(true),
+		goose.WithDisableVersioning(true),
+	)
+	require.Error(t, err)
+}
+
+func TestRoutinesDir(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER, active BOOLEAN);\n"),
+		"routines/active_users.sql": newMapFile(
+			"-- +goose after users_view.sql\n-- +goose Up\nDROP VIEW IF EXISTS active_users;\nCREATE VIEW active_users AS SELECT id FROM users_view WHERE active;\n",
+		),
+		"routines/users_view.sql": newMapFile(
+			"-- +goose Up\nDROP VIEW IF EXISTS active_users;\nDROP VIEW IF EXISTS users_view;\nCREATE VIEW users_view AS SELECT * FROM users;\n",
+		),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRoutinesDir("routines"))
+	require.NoError(t, err)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	require.Equal(t, "routines/users_view.sql", res[1].Source.Path)
+	require.Equal(t, "routines/active_users.sql", res[2].Source.Path)
+
+	// Recreating a routine recreates the routines that depend on it.
+	fsys["routines/users_view.sql"] = newMapFile(
+		"-- +goose Up\nDROP VIEW IF EXISTS active_users;\nDROP VIEW IF EXISTS users_view;\nCREATE VIEW users_view AS SELECT id, active FROM users;\n",
+	)
+	res, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.True(t, tableExists(t, db, "users") && viewExists(t, db, "active_users"))
+
+	// But not the other way around.
+	fsys["routines/active_users.sql"] = newMapFile(
+		"-- +goose after users_view.sql\n-- +goose Up\nDROP VIEW IF EXISTS active_users;\nCREATE VIEW active_users AS SELECT id, 1 AS one FROM users_view WHERE active;\n",
+	)
+	res, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, "routines/active_users.sql", res[0].Source.Path)
+
+	t.Run("cycle", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql":    newMapFile("-- +goose Up\nSELECT 1;\n"),
+			"routines/a.sql": newMapFile("-- +goose after b.sql\n-- +goose Up\nSELECT 1;\n"),
+			"routines/b.sql": newMapFile("-- +goose after a.sql\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithRoutinesDir("routines"))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "dependency cycle: routines/a.sql -> routines/b.sql -> routines/a.sql")
+	})
+	t.Run("unknown_dependency", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql":    newMapFile("-- +goose Up\nSELECT 1;\n"),
+			"routines/a.sql": newMapFile("-- +goose after missing.sql\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithRoutinesDir("routines"))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `unknown dependency "missing.sql"`)
+	})
+}
@@ -0,0 +1,63 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+	"go.uber.org/multierr"
+)
+
+// parseRole returns the role declared by a role directive, or an empty string if there is none.
+func parseRole(directives []sqlparser.Directive) (string, error) {
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveRole)
+	if !ok {
+		return "", nil
+	}
+	if d.Value == "" || strings.ContainsAny(d.Value, " \t") {
+		return "", fmt.Errorf("invalid %s directive %q: must be a single role name, e.g., dba_migrator",
+			sqlparser.DirectiveRole, d.Value)
+	}
+	return d.Value, nil
+}
+
+// migrationRole returns the role m runs as, or an empty string to keep the role of the connection.
+func migrationRole(m *Migration) string {
+	if m.Type == TypeSQL {
+		return m.sql.Role
+	}
+	return m.Role
+}
+
+// setRole switches the session to the role of m, if any, and returns a function that must be
+// called with the result of the migration to switch back.
+//
+// In a transaction, the role is set with SET LOCAL ROLE, so a failed migration is rolled back to
+// the previous role, and reset after the migration succeeds, so the version is recorded with the
+// provider's own role. On a connection, the role is always reset.
+func (p *Provider) setRole(ctx context.Context, db database.DBTxConn, m *Migration) (func(error) error, error) {
+	role := migrationRole(m)
+	if role == "" {
+		return func(err error) error { return err }, nil
+	}
+	_, inTx := db.(*sql.Tx)
+	query := "SET ROLE "
+	if inTx {
+		query = "SET LOCAL ROLE "
+	}
+	if _, err := db.ExecContext(ctx, query+quoteIdentifiers([]string{role})); err != nil {
+		return nil, fmt.Errorf("failed to set role %q: %w", role, err)
+	}
+	return func(err error) error {
+		if err != nil && inTx {
+			return err
+		}
+		if _, resetErr := db.ExecContext(context.WithoutCancel(ctx), "RESET ROLE"); resetErr != nil {
+			return multierr.Append(err, fmt.Errorf("failed to reset role %q: %w", role, resetErr))
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,75 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose role dba_migrator\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"00002_b.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose role dba_migrator\n-- +goose Up\nCREATE INDEX CONCURRENTLY a_id ON a (id);\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n"),
+	}
+	store := goosetest.NewStore("goose_db_version")
+	db, rec := goosetest.NewDB()
+	p, err := goose.NewProvider("", db, fsys, goose.WithStore(store))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		goosetest.Begin, goosetest.Commit, // version table
+		// In a transaction, the role is reset before the version is recorded.
+		goosetest.Begin, `SET LOCAL ROLE "dba_migrator"`, "CREATE TABLE a (id INTEGER);", "RESET ROLE", goosetest.Commit,
+		`SET ROLE "dba_migrator"`, "CREATE INDEX CONCURRENTLY a_id ON a (id);", "RESET ROLE",
+		goosetest.Begin, "CREATE TABLE c (id INTEGER);", goosetest.Commit,
+	}, rec.Queries())
+
+	t.Run("failed", func(t *testing.T) {
+		failure := errors.New("permission denied")
+		db, rec := goosetest.NewDB(goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+			if strings.HasPrefix(query, "CREATE") {
+				return nil, failure
+			}
+			return nil, nil
+		}))
+		p, err := goose.NewProvider("", db, fsys, goose.WithStore(goosetest.NewStore("goose_db_version")))
+		require.NoError(t, err)
+		_, err = p.ApplyVersion(ctx, 2, true)
+		require.ErrorIs(t, err, failure)
+		// Outside a transaction, the role is reset even if the migration fails.
+		require.Equal(t, []string{
+			goosetest.Begin, goosetest.Commit,
+			`SET ROLE "dba_migrator"`, "CREATE INDEX CONCURRENTLY a_id ON a (id);", "RESET ROLE",
+		}, rec.Queries())
+	})
+	t.Run("go", func(t *testing.T) {
+		m := goose.NewGoMigration(1, &goose.GoFunc{RunDB: newDBFn("SELECT 1")}, nil)
+		m.Role = "dba_migrator"
+		db, _ := goosetest.NewDB()
+		p, err := goose.NewProvider("", db, nil, goose.WithStore(goosetest.NewStore("goose_db_version")), goose.WithGoMigrations(m))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `with role "dba_migrator" must run with a *sql.Tx or *sql.Conn`)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose role dba migrator\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `invalid role directive "dba migrator"`)
+	})
+}
@@ -0,0 +1,109 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// rollbackTimeout bounds the rollback of a failed run. The rollback runs even if the context of the
+// run is done, since the run may have failed because of it.
+const rollbackTimeout = 10 * time.Minute
+
+// rollbackFailed handles err, the error of a run that applied the given migrations before it
+// failed. With [WithAutoRollbackOnFailure], or [WithSmokeTestRollback] for a failed smoke test, the
+// versioned migrations applied by the run are rolled back and err is returned in a
+// [RollbackError]. Otherwise err is returned as is.
+func (p *Provider) rollbackFailed(ctx context.Context, conn *sql.Conn, applied []*MigrationResult, err error) error {
+	var partialErr *PartialError
+	if errors.As(err, &partialErr) {
+		applied = partialErr.Applied
+	}
+	var smokeErr *SmokeTestError
+	if !p.cfg.autoRollback && !(p.cfg.smokeTestRollback && errors.As(err, &smokeErr)) {
+		return err
+	}
+	if p.cfg.disableVersioning || len(applied) == 0 {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+	report := p.rollbackBatch(ctx, conn, applied)
+	p.cfg.logger.Printf("goose: warning: run failed, %s", report)
+	return &RollbackError{Err: err, Report: report}
+}
+
+// rollbackBatch rolls back the versioned migrations applied by a run, newest first, with the
+// session lock held. The rollback is part of the failed run: it is not subject to the maintenance
+// window or the approval check, and notifiers are not called again. It stops at the first
+// migration without a Down section, since rolling back the migrations before it would leave the
+// version history with gaps; that migration and the ones before it are kept. Repeatable migrations
+// have no Down section and are always kept.
+func (p *Provider) rollbackBatch(ctx context.Context, conn *sql.Conn, applied []*MigrationResult) *RollbackReport {
+	report := new(RollbackReport)
+	var err error
+	if report.FromVersion, err = p.getDBMaxVersion(ctx, conn); err != nil {
+		report.Err = err
+		return report
+	}
+	var steps []migrationStep
+	var kept []*Source
+	var stopped bool
+	for i := len(applied) - 1; i >= 0; i-- {
+		result := applied[i]
+		if !stopped && !result.Repeatable {
+			m, err := p.getMigration(result.Source.Version)
+			if stopped = err != nil || !hasDown(m); !stopped {
+				steps = append(steps, migrationStep{m: m, direction: false})
+				continue
+			}
+		}
+		kept = append(kept, result.Source)
+	}
+	report.RolledBack, err = p.executeSteps(ctx, conn, steps, atomicNever, false)
+	if err != nil {
+		var partialErr *PartialError
+		if errors.As(err, &partialErr) {
+			report.RolledBack = partialErr.Applied
+		}
+		for _, step := range steps[len(report.RolledBack):] {
+			report.Kept = append(report.Kept, &Source{Type: step.m.Type, Path: step.m.Source, Version: step.m.Version})
+		}
+		report.Err = err
+	}
+	report.Kept = append(report.Kept, kept...)
+	report.ToVersion, err = p.getDBMaxVersion(ctx, conn)
+	if err != nil && report.Err == nil {
+		report.Err = err
+	}
+	return report
+}
+
+// hasDown reports whether m can be rolled back: a Go migration with a down function, or a SQL
+// migration with Down statements that are not irreversible, see [WithAutoDown].
+func hasDown(m *Migration) bool {
+	if m.Type == TypeSQL && (!m.sql.Parsed || len(m.sql.Irreversible) > 0) {
+		return false
+	}
+	return !isEmpty(m, false)
+}
+
+// String describes what the rollback reverted and kept, e.g., for logs.
+func (r *RollbackReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rolled back %d migrations from version %d to %d", len(r.RolledBack), r.FromVersion, r.ToVersion)
+	for _, result := range r.RolledBack {
+		fmt.Fprintf(&b, "\n  reverted %s", result)
+	}
+	for _, source := range r.Kept {
+		fmt.Fprintf(&b, "\n  kept     %s", filepath.Base(source.Path))
+	}
+	if r.Err != nil {
+		fmt.Fprintf(&b, "\n  failed to roll back: %v", r.Err)
+	}
+	return b.String()
+}
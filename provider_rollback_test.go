@@ -0,0 +1,133 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/notify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoRollbackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	newFsys := func(down2 string) fstest.MapFS {
+		return fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\n" + down2),
+			"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n-- +goose Down\nDROP TABLE c;\n"),
+			"00004_d.sql": newMapFile("-- +goose Up\nINSERT INTO missing VALUES (1);\n"),
+		}
+	}
+	t.Run("rolled back", func(t *testing.T) {
+		db := newDB(t)
+		logger := &bufferLogger{}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, newFsys("DROP TABLE b;\n"),
+			goose.WithLogger(logger), goose.WithAutoRollbackOnFailure(true))
+		require.NoError(t, err)
+		_, err = p.UpTo(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "no such table: missing (1); rolled back 2 migrations from version 3 to 1")
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Len(t, partialErr.Applied, 2)
+		var rollbackErr *goose.RollbackError
+		require.ErrorAs(t, err, &rollbackErr)
+		report := rollbackErr.Report
+		require.NoError(t, report.Err)
+		require.Len(t, report.RolledBack, 2)
+		require.EqualValues(t, 3, report.RolledBack[0].Source.Version)
+		require.EqualValues(t, 2, report.RolledBack[1].Source.Version)
+		require.Empty(t, report.Kept)
+		require.Contains(t, logger.String(), "goose: warning: run failed, rolled back 2 migrations from version 3 to 1\n  reverted ")
+		version, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, version)
+		require.True(t, tableExists(t, db, "a"))
+		require.False(t, tableExists(t, db, "b"))
+		require.False(t, tableExists(t, db, "c"))
+	})
+	t.Run("kept without down", func(t *testing.T) {
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, newFsys(""),
+			goose.WithLogger(&bufferLogger{}), goose.WithAutoRollbackOnFailure(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		var rollbackErr *goose.RollbackError
+		require.ErrorAs(t, err, &rollbackErr)
+		report := rollbackErr.Report
+		require.NoError(t, report.Err)
+		require.Len(t, report.RolledBack, 1)
+		require.EqualValues(t, 3, report.RolledBack[0].Source.Version)
+		require.Len(t, report.Kept, 2)
+		require.EqualValues(t, 2, report.Kept[0].Version)
+		require.EqualValues(t, 1, report.Kept[1].Version)
+		require.Contains(t, report.String(), "\n  kept     00002_b.sql\n  kept     00001_a.sql")
+		version, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, version)
+		require.True(t, tableExists(t, db, "b"))
+		require.False(t, tableExists(t, db, "c"))
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		// The rollback runs even though the run failed because its context was cancelled. It is not
+		// approved or notified again.
+		db := newDB(t)
+		fsys := newFsys("DROP TABLE b;\n")
+		delete(fsys, "00004_d.sql")
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		cancelRun := goose.NewGoMigration(4, &goose.GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			cancel()
+			return ctx.Err()
+		}}, nil)
+		var approvals, notifications int
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithLogger(&bufferLogger{}),
+			goose.WithAutoRollbackOnFailure(true),
+			goose.WithGoMigrations(cancelRun),
+			goose.WithApproval(func(context.Context, []*goose.Migration) error {
+				approvals++
+				return nil
+			}),
+			goose.WithNotifier(notifierFunc(func(context.Context, notify.Summary) error {
+				notifications++
+				return nil
+			})),
+		)
+		require.NoError(t, err)
+		_, err = p.UpTo(ctx, 1)
+		require.NoError(t, err)
+
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, context.Canceled)
+		var rollbackErr *goose.RollbackError
+		require.ErrorAs(t, err, &rollbackErr)
+		require.NoError(t, rollbackErr.Report.Err)
+		require.Len(t, rollbackErr.Report.RolledBack, 2)
+		require.Equal(t, 2, approvals)
+		require.Equal(t, 2, notifications)
+		version, err := p.GetDBVersion(context.Background())
+		require.NoError(t, err)
+		require.EqualValues(t, 1, version)
+		require.False(t, tableExists(t, db, "b"))
+	})
+	t.Run("disabled", func(t *testing.T) {
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys("DROP TABLE b;\n"))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "no such table: missing")
+		var rollbackErr *goose.RollbackError
+		require.False(t, errors.As(err, &rollbackErr))
+		version, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, version)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"path"
 	"runtime/debug"
 	"strings"
 	"time"
@@ -18,6 +19,21 @@ import (
 
 var (
 	errMissingZeroVersion = errors.New("missing zero version migration")
+	errNotAtomic          = errors.New("migrations cannot be run in a single transaction")
+)
+
+// atomicity controls whether [Provider.runSteps] runs all steps in a single transaction.
+type atomicity int
+
+const (
+	// atomicNever runs each step on its own.
+	atomicNever atomicity = iota
+	// atomicIfSupported runs all steps in a single transaction if possible, and falls back to
+	// running each step on its own otherwise.
+	atomicIfSupported
+	// atomicRequired runs all steps in a single transaction, and returns an error if that is not
+	// possible.
+	atomicRequired
 )
 
 func (p *Provider) prepareMigration(fsys fs.FS, m *Migration, direction bool) error {
@@ -45,27 +61,184 @@ func (p *Provider) prepareMigration(fsys fs.FS, m *Migration, direction bool) er
 		// a bit of an edge case. For now, we guard against this scenario by checking the max open
 		// connections and returning an error.
 		if p.cfg.lockEnabled && p.cfg.sessionLocker != nil && p.db.Stats().MaxOpenConnections == 1 {
-			if !useTx {
+			if !useTx && !usesConn(m, direction) {
 				return errors.New("potential deadlock detected: cannot run Go migration without a transaction when max open connections set to 1")
 			}
 		}
+		if m.Role != "" && !useTx && !usesConn(m, direction) {
+			return fmt.Errorf("go migration %s with role %q must run with a *sql.Tx or *sql.Conn", m.ref(), m.Role)
+		}
 		return nil
 	case TypeSQL:
-		if m.sql.Parsed {
-			return nil
+		if !m.sql.Parsed {
+			stream, err := p.streamSQL(fsys, m)
+			if err != nil {
+				return err
+			}
+			parse := sqlparser.ParseAllFromFS
+			if stream {
+				parse = sqlparser.ScanAllFromFS
+			}
+			parsed, err := parse(fsys, m.Source, false)
+			if err != nil {
+				return err
+			}
+			if err := checkRequiresGoose(parsed.Directives); err != nil {
+				return err
+			}
+			requiresDB, err := parseRequiresDB(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			extensions, err := parseExtensions(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			partitions, err := parsePartitions(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			refresh, err := parseMaterializedViews(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			expected, err := parseExpectDuration(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			role, err := parseRole(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			maxAffected, err := parseMaxAffected(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			title, err := parseTitle(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			owner, err := parseOwner(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			hints, err := parseHints(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			snapshot, err := parseSnapshots(parsed.Directives)
+			if err != nil {
+				return err
+			}
+			loads, err := parseLoads(fsys, m.Source, parsed.Directives)
+			if err != nil {
+				return err
+			}
+			preconditions, err := parseConditions(parsed.Directives, sqlparser.DirectivePrecondition)
+			if err != nil {
+				return err
+			}
+			postConditions, err := parseConditions(parsed.Directives, sqlparser.DirectiveVerify)
+			if err != nil {
+				return err
+			}
+			m.sql.Parsed = true
+			m.sql.UseTx = parsed.UseTx
+			m.sql.Up, m.sql.Down = parsed.Up, parsed.Down
+			m.sql.Stream = stream
+			m.sql.UpCount, m.sql.DownCount = parsed.UpCount, parsed.DownCount
+			m.sql.Tombstone = parsed.Tombstone
+			m.sql.Refresh = refresh
+			m.sql.ExpectDuration = expected
+			m.sql.Role = role
+			m.sql.RequiresDB = requiresDB
+			m.sql.Extensions = extensions
+			m.sql.Partitions = partitions
+			if p.cfg.concurrentIndexes && !stream {
+				m.sql.Up, m.sql.Indexes = concurrentIndexes(m.sql.Up, m.sql.UseTx)
+			}
+			if _, ok := sqlparser.LookupDirective(parsed.Directives, sqlparser.DirectiveNotValid); ok {
+				if stream {
+					return fmt.Errorf("%s directive cannot be used with streamed migration %s", sqlparser.DirectiveNotValid, m.ref())
+				}
+				for i, stmt := range m.sql.Up {
+					if rewritten, validate, ok := sqlparser.SplitNotValid(stmt); ok {
+						m.sql.Up[i] = rewritten
+						m.sql.Validate = append(m.sql.Validate, validate...)
+					}
+				}
+			}
+			m.sql.Title = title
+			m.sql.Owner = owner
+			m.sql.MaxAffected = maxAffected
+			_, m.sql.Destructive = sqlparser.LookupDirective(parsed.Directives, sqlparser.DirectiveDestructive)
+			if hints != nil {
+				m.sql.Affects = hints.Affects
+			}
+			m.sql.Snapshot = snapshot
+			m.sql.Loads = loads
+			if !parsed.Tombstone {
+				m.sql.Preconditions, m.sql.PostConditions = preconditions, postConditions
+			}
+			if p.cfg.autoDown && !parsed.HasDown && !parsed.Tombstone && !stream {
+				m.sql.Down, m.sql.Irreversible = reverseStatements(p.dialect, parsed.Up)
+				if len(m.sql.Irreversible) > 0 && direction {
+					p.cfg.logger.Printf("goose: warning: migration %s cannot be rolled back: %s",
+						m.ref(), irreversibleStatements(m))
+				}
+			}
 		}
-		parsed, err := sqlparser.ParseAllFromFS(fsys, m.Source, false)
-		if err != nil {
-			return err
+		if !direction && len(m.sql.Irreversible) > 0 {
+			return fmt.Errorf("%w: cannot derive the down migration of %s: %s",
+				ErrIrreversible, m.ref(), irreversibleStatements(m))
+		}
+		if len(m.sql.Snapshot) > 0 {
+			if p.cfg.disableVersioning || m.Version <= 0 {
+				return fmt.Errorf("%s requires a versioned migration", sqlparser.DirectiveSnapshot)
+			}
+			if !m.sql.UseTx {
+				return fmt.Errorf("%s requires a transaction to restore atomically: migration %s must not use NO TRANSACTION",
+					sqlparser.DirectiveSnapshot, m.ref())
+			}
+		}
+		if m.sql.MaxAffected > 0 && !m.sql.UseTx {
+			return fmt.Errorf("%s requires a transaction to roll back: migration %s must not use NO TRANSACTION",
+				sqlparser.DirectiveMaxAffected, m.ref())
+		}
+		if direction && len(m.sql.Refresh) > 0 && p.dialect != DialectPostgres {
+			return fmt.Errorf("refreshing materialized views requires the %s dialect", DialectPostgres)
+		}
+		if direction && len(m.sql.Extensions) > 0 && p.dialect != DialectPostgres {
+			return fmt.Errorf("%s directive requires the %s dialect", sqlparser.DirectiveExtension, DialectPostgres)
+		}
+		if direction && len(m.sql.Validate) > 0 && p.dialect != "" && p.dialect != DialectPostgres {
+			return fmt.Errorf("%s directive requires the %s dialect", sqlparser.DirectiveNotValid, DialectPostgres)
+		}
+		if direction && len(m.sql.Partitions) > 0 && p.dialect != "" && p.dialect != DialectPostgres {
+			return fmt.Errorf("%s directive requires the %s dialect", sqlparser.DirectivePartition, DialectPostgres)
 		}
-		m.sql.Parsed = true
-		m.sql.UseTx = parsed.UseTx
-		m.sql.Up, m.sql.Down = parsed.Up, parsed.Down
 		return nil
 	}
 	return fmt.Errorf("invalid migration type: %+v", m)
 }
 
+// streamSQL reports whether the statements of the SQL migration m are streamed from fsys, instead
+// of being loaded, see [WithStreamSQL]. Compressed migrations are always streamed, they are
+// decompressed as they are read.
+func (p *Provider) streamSQL(fsys fs.FS, m *Migration) (bool, error) {
+	if isCompressed(m.Source) {
+		return true, nil
+	}
+	if p.cfg.streamSize <= 0 {
+		return false, nil
+	}
+	info, err := fs.Stat(fsys, m.Source)
+	if err != nil {
+		return false, err
+	}
+	return info.Size() >= p.cfg.streamSize, nil
+}
+
 // printf is a helper function that prints the given message if verbose is enabled. It also prepends
 // the "goose: " prefix to the message.
 func (p *Provider) printf(msg string, args ...interface{}) {
@@ -103,12 +276,107 @@ func (p *Provider) runMigrations(
 		apply = migrations[:1]
 	}
 
+	steps := make([]migrationStep, 0, len(apply))
+	for _, m := range apply {
+		steps = append(steps, migrationStep{m: m, direction: direction.ToBool()})
+	}
+	atomic := atomicNever
+	if p.cfg.atomicUp && direction == sqlparser.DirectionUp && !byOne {
+		atomic = atomicRequired
+	}
+	results, err := p.runSteps(ctx, conn, steps, atomic)
+	if err != nil {
+		return nil, err
+	}
+	if !p.cfg.disableVersioning && !byOne {
+		maxVersion, err := p.getDBMaxVersion(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		p.printf("successfully migrated database, current version: %d", maxVersion)
+	}
+	return results, nil
+}
+
+// migrationStep is a single migration to run in the given direction.
+type migrationStep struct {
+	m         *Migration
+	direction bool
+}
+
+// runSteps runs the given steps in order within the maintenance window, see
+// [Provider.executeSteps], and notifies the configured notifiers, if any, once they completed.
+func (p *Provider) runSteps(
+	ctx context.Context,
+	conn *sql.Conn,
+	steps []migrationStep,
+	atomic atomicity,
+) ([]*MigrationResult, error) {
+	if p.cfg.notifier == nil && p.cfg.failureNotifier == nil {
+		return p.executeSteps(ctx, conn, steps, atomic, true)
+	}
+	start := time.Now()
+	results, err := p.executeSteps(ctx, conn, steps, atomic, true)
+	summary := newSummary(results, err, time.Since(start))
+	if p.cfg.notifier != nil {
+		p.notify(ctx, p.cfg.notifier, summary)
+	}
+	if err != nil && p.cfg.failureNotifier != nil {
+		p.notify(ctx, p.cfg.failureNotifier, summary)
+	}
+	return results, err
+}
+
+// executeSteps runs the given steps in order. Depending on atomic, and if all steps are safe to run
+// in a transaction on a dialect with transactional DDL, the steps are run in a single transaction
+// and either all or none of them take effect. Otherwise, each step is run on its own. If windowed
+// is true, the maintenance window is checked before each step, or before the transaction.
+func (p *Provider) executeSteps(
+	ctx context.Context,
+	conn *sql.Conn,
+	steps []migrationStep,
+	atomic atomicity,
+	windowed bool,
+) ([]*MigrationResult, error) {
 	// SQL migrations are lazily parsed in both directions. This is done before attempting to run
 	// any migrations to catch errors early and prevent leaving the database in an incomplete state.
-
-	for _, m := range apply {
-		if err := p.prepareMigration(p.fsys, m, direction.ToBool()); err != nil {
-			return nil, fmt.Errorf("failed to prepare migration %s: %w", m.ref(), err)
+	for _, step := range steps {
+		if err := p.prepareMigration(p.fsys, step.m, step.direction); err != nil {
+			return nil, fmt.Errorf("failed to prepare migration %s: %w", step.m.ref(), err)
+		}
+	}
+	if err := p.checkRequiresDB(ctx, conn, steps); err != nil {
+		return nil, err
+	}
+	// Snapshots, pending indexes and skips of migrations by their preconditions are recorded in the
+	// metadata table.
+	required := hasSkipPrecondition(steps)
+	for _, step := range steps {
+		required = required || (step.m.Type == TypeSQL && len(step.m.sql.Snapshot) > 0) ||
+			(step.direction && step.m.Type == TypeSQL && len(step.m.sql.Indexes) > 0)
+	}
+	if err := p.prepareMetadata(ctx, conn, required); err != nil {
+		return nil, fmt.Errorf("failed to prepare metadata table: %w", err)
+	}
+	p.backups, p.snapshots = nil, nil
+	if atomic != atomicNever {
+		err := p.checkAtomic(steps)
+		if err == nil {
+			// Backups cannot be part of the transaction, so all are taken before it begins.
+			for _, step := range steps {
+				if _, err := p.backup(ctx, step.m, step.direction); err != nil {
+					return nil, err
+				}
+			}
+			if windowed {
+				if err := p.checkWindow(); err != nil {
+					return nil, err
+				}
+			}
+			return p.runAtomically(ctx, conn, steps)
+		}
+		if atomic == atomicRequired || !errors.Is(err, errNotAtomic) {
+			return nil, err
 		}
 	}
 
@@ -116,28 +384,44 @@ func (p *Provider) runMigrations(
 	// be a good place to acquire the lock. However, we need to be sure that ALL migrations are safe
 	// to run in a transaction.
 
-	// feat(mf): this is where we can (optionally) group multiple migrations to be run in a single
-	// transaction. The default is to apply each migration sequentially on its own. See the
-	// following issues for more details:
-	//  - https://github.com/pressly/goose/issues/485
-	//  - https://github.com/pressly/goose/issues/222
-	//
-	// Be careful, we can't use a single transaction for all migrations because some may be marked
-	// as not using a transaction.
-
 	var results []*MigrationResult
-	for _, m := range apply {
-		result := &MigrationResult{
-			Source: &Source{
-				Type:    m.Type,
-				Path:    m.Source,
-				Version: m.Version,
-			},
-			Direction: direction.String(),
-			Empty:     isEmpty(m, direction.ToBool()),
+	for i, step := range steps {
+		result := newMigrationResult(step)
+		if i > 0 {
+			if err := p.pace(ctx, results[i-1]); err != nil {
+				result.Error = err
+				return nil, &PartialError{
+					Applied: results,
+					Failed:  result,
+					Err:     err,
+				}
+			}
 		}
+		if windowed {
+			if err := p.checkWindow(); err != nil {
+				result.Error = err
+				return nil, &PartialError{
+					Applied: results,
+					Failed:  result,
+					Err:     err,
+				}
+			}
+		}
+		backup, err := p.backup(ctx, step.m, step.direction)
+		if err != nil {
+			result.Error = err
+			return nil, &PartialError{
+				Applied: results,
+				Failed:  result,
+				Err:     err,
+			}
+		}
+		result.Backup = backup
 		start := time.Now()
-		if err := p.runIndividually(ctx, conn, m, direction.ToBool()); err != nil {
+		if err := p.runIndividually(ctx, conn, step.m, step.direction); err != nil {
+			if errors.Is(err, ErrPreconditionSkipped) {
+				return nil, p.skipRemaining(ctx, conn, steps, i, results, 0, err)
+			}
 			// TODO(mf): we should also return the pending migrations here, the remaining items in
 			// the apply slice.
 			result.Error = err
@@ -152,16 +436,223 @@ func (p *Provider) runMigrations(
 		results = append(results, result)
 		p.printf("%s", result)
 	}
-	if !p.cfg.disableVersioning && !byOne {
-		maxVersion, err := p.getDBMaxVersion(ctx, conn)
+	return results, nil
+}
+
+// approve returns an error if the approval check vetoes running the pending migrations. Callers
+// pass every migration of the run, versioned and repeatable, so that a run is approved only once.
+func (p *Provider) approve(ctx context.Context, pending []*Migration) error {
+	if len(pending) == 0 {
+		return nil
+	}
+	if p.cfg.approval != nil {
+		if err := p.cfg.approval(ctx, pending); err != nil {
+			return fmt.Errorf("migrations not approved: %w", err)
+		}
+	}
+	return nil
+}
+
+// pace delays the next migration after prev ran, or returns an error to abort the run.
+func (p *Provider) pace(ctx context.Context, prev *MigrationResult) error {
+	if p.cfg.pause > 0 {
+		timer := time.NewTimer(p.cfg.pause)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("pausing between migrations: %w", ctx.Err())
+		case <-timer.C:
+		}
+	}
+	if err := p.throttle(ctx); err != nil {
+		return err
+	}
+	if p.cfg.pacing != nil {
+		if err := p.cfg.pacing(ctx, prev); err != nil {
+			return fmt.Errorf("pacing aborted the run: %w", err)
+		}
+	}
+	return nil
+}
+
+// throttle waits while the replication lag exceeds the threshold of the throttler, if any.
+func (p *Provider) throttle(ctx context.Context) error {
+	if p.cfg.throttler == nil {
+		return nil
+	}
+	waited, err := p.cfg.throttler.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if waited > 0 {
+		p.printf("throttled for %s on %s", truncateDuration(waited), p.cfg.throttler)
+	}
+	return nil
+}
+
+// waitForWindow waits for the maintenance window to open, if configured with [WithWaitForWindow].
+// Runs wait before taking the session lock, so that other runs are not blocked meanwhile.
+func (p *Provider) waitForWindow(ctx context.Context) error {
+	w := p.cfg.window
+	if w == nil || !p.cfg.waitForWindow {
+		return nil
+	}
+	now := time.Now()
+	if w.Contains(now) {
+		return nil
+	}
+	next := w.Next(now)
+	p.printf("waiting for maintenance window %s, opens at %s", w, next.Format(time.RFC3339))
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for maintenance window %s: %w", w, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+// checkWindow returns an error wrapping [ErrOutsideWindow] if the maintenance window is closed. It
+// is checked before each migration, since the window may close while a run is in progress.
+func (p *Provider) checkWindow() error {
+	w := p.cfg.window
+	if w == nil {
+		return nil
+	}
+	now := time.Now()
+	if w.Contains(now) {
+		return nil
+	}
+	return fmt.Errorf("%w %s: opens at %s", ErrOutsideWindow, w, w.Next(now).Format(time.RFC3339))
+}
+
+// checkAtomic returns an error wrapping errNotAtomic if the steps cannot be run in a single
+// transaction. This requires a dialect with transactional DDL and that every step is marked to run
+// in a transaction, without indexes to build concurrently. Steps must be prepared before calling
+// this function.
+func (p *Provider) checkAtomic(steps []migrationStep) error {
+	if !supportsTransactionalDDL(p.dialect) {
+		if p.dialect == "" {
+			return fmt.Errorf("%w: dialect unknown when using a custom store", errNotAtomic)
+		}
+		return fmt.Errorf("%w: dialect %q does not support transactional DDL", errNotAtomic, p.dialect)
+	}
+	for _, step := range steps {
+		ok, err := useTx(step.m, step.direction)
 		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("%w: migration %s is marked to run without a transaction", errNotAtomic, step.m.ref())
+		}
+		if step.direction && step.m.Type == TypeSQL && len(step.m.sql.Indexes) > 0 {
+			return fmt.Errorf("%w: migration %s builds indexes concurrently, outside of its transaction",
+				errNotAtomic, step.m.ref())
+		}
+	}
+	return nil
+}
+
+// runAtomically runs all steps in a single transaction. If any step fails, the transaction is
+// rolled back and the returned [PartialError] has no applied migrations. If a precondition skips a
+// step, the transaction is rolled back too, and the returned [PreconditionSkipError] has no applied
+// migrations. Constraints added NOT
+// VALID are validated after the transaction committed.
+func (p *Provider) runAtomically(
+	ctx context.Context,
+	conn *sql.Conn,
+	steps []migrationStep,
+) ([]*MigrationResult, error) {
+	var results []*MigrationResult
+	var failed *MigrationResult
+	var skipped int
+	err := beginTx(ctx, conn, func(tx *sql.Tx) error {
+		for i, step := range steps {
+			result := newMigrationResult(step)
+			result.Backup = p.backups[step.m.Version]
+			start := time.Now()
+			err := p.runMigration(ctx, tx, step.m, step.direction)
+			if errors.Is(err, ErrPreconditionSkipped) {
+				// The whole run is rolled back, so none of it takes effect.
+				skipped = i
+				return err
+			}
+			if err == nil {
+				err = p.maybeInsertOrDelete(ctx, tx, step.m, step.direction)
+			}
+			result.Duration = time.Since(start)
+			if err != nil {
+				result.Error = err
+				failed = result
+				return err
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrPreconditionSkipped) {
+		return nil, p.skipRemaining(ctx, conn, steps, skipped, nil, len(results), err)
+	}
+	if err != nil {
+		if failed == nil {
 			return nil, err
 		}
-		p.printf("successfully migrated database, current version: %d", maxVersion)
+		return nil, &PartialError{
+			Failed: failed,
+			Err:    err,
+		}
+	}
+	// Constraints added NOT VALID are validated once the transaction committed, as when each
+	// migration runs on its own.
+	for i, result := range results {
+		if !steps[i].direction {
+			continue
+		}
+		if err := p.validateConstraints(ctx, conn, steps[i].m); err != nil {
+			result.Error = err
+			return nil, &PartialError{
+				Applied: results[:i],
+				Failed:  result,
+				Err:     err,
+			}
+		}
+	}
+	for _, result := range results {
+		p.printf("%s", result)
 	}
 	return results, nil
 }
 
+func newMigrationResult(step migrationStep) *MigrationResult {
+	direction := sqlparser.DirectionDown
+	if step.direction {
+		direction = sqlparser.DirectionUp
+	}
+	return &MigrationResult{
+		Source: &Source{
+			Type:    step.m.Type,
+			Path:    step.m.Source,
+			Version: step.m.Version,
+		},
+		Direction: direction.String(),
+		Title:     migrationTitle(step.m),
+		Owner:     migrationOwner(step.m),
+		Empty:     isEmpty(step.m, step.direction),
+		Tombstone: step.m.Type == TypeSQL && step.m.sql.Tombstone,
+	}
+}
+
+// supportsTransactionalDDL reports whether the dialect can run schema changes inside a transaction
+// and roll them back. Dialects such as MySQL implicitly commit on DDL statements.
+func supportsTransactionalDDL(d Dialect) bool {
+	switch d {
+	case DialectPostgres, DialectSQLite3, DialectMSSQL, database.DialectTurso:
+		return true
+	}
+	return false
+}
+
 func (p *Provider) runIndividually(
 	ctx context.Context,
 	conn *sql.Conn,
@@ -172,16 +663,60 @@ func (p *Provider) runIndividually(
 	if err != nil {
 		return err
 	}
+	if useTx && direction && m.Type == TypeSQL && len(m.sql.Indexes) > 0 {
+		// The version is recorded together with the other statements, and the indexes as pending
+		// until they are built, so that a failed build is resumed instead of running the migration
+		// again.
+		if err := p.retryLocked(ctx, m, func() error {
+			return beginTx(ctx, conn, func(tx *sql.Tx) error {
+				if err := p.runMigration(ctx, tx, m, direction); err != nil {
+					return err
+				}
+				if err := p.maybeInsertOrDelete(ctx, tx, m, direction); err != nil {
+					return err
+				}
+				if !p.metadata {
+					return nil
+				}
+				return p.savePendingIndexes(ctx, tx, m, m.sql.Indexes)
+			})
+		}); err != nil {
+			return err
+		}
+		if err := p.createIndexes(ctx, conn, m, m.sql.Indexes, p.metadata); err != nil {
+			return err
+		}
+		return p.validateConstraints(ctx, conn, m)
+	}
 	if useTx {
-		return beginTx(ctx, conn, func(tx *sql.Tx) error {
-			if err := p.runMigration(ctx, tx, m, direction); err != nil {
-				return err
-			}
-			return p.maybeInsertOrDelete(ctx, tx, m.Version, direction)
-		})
+		run := func() error {
+			return beginTx(ctx, conn, func(tx *sql.Tx) error {
+				if err := p.runMigration(ctx, tx, m, direction); err != nil {
+					return err
+				}
+				return p.maybeInsertOrDelete(ctx, tx, m, direction)
+			})
+		}
+		if m.Type == TypeSQL {
+			err = p.retryLocked(ctx, m, run)
+		} else {
+			err = run()
+		}
+		if err != nil || !direction {
+			return err
+		}
+		return p.validateConstraints(ctx, conn, m)
 	}
 	switch m.Type {
 	case TypeGo:
+		if usesConn(m, direction) {
+			// Go migrations registered with a *sql.Conn run on the same connection as goose, which
+			// also makes them safe to use with a session locker and max open connections set to 1.
+			if err := p.runMigration(ctx, conn, m, direction); err != nil {
+				return err
+			}
+			return p.maybeInsertOrDelete(ctx, conn, m, direction)
+		}
 		// Note, we are using *sql.DB instead of *sql.Conn because it's the Go migration contract.
 		// This may be a deadlock scenario if max open connections is set to 1 AND a lock is
 		// acquired on the database. In this case, the migration will block forever unable to
@@ -192,12 +727,19 @@ func (p *Provider) runIndividually(
 		if err := p.runMigration(ctx, p.db, m, direction); err != nil {
 			return err
 		}
-		return p.maybeInsertOrDelete(ctx, p.db, m.Version, direction)
+		if len(p.cfg.searchPath) > 0 {
+			// The version table is only found with the search path set on conn.
+			return p.maybeInsertOrDelete(ctx, conn, m, direction)
+		}
+		return p.maybeInsertOrDelete(ctx, p.db, m, direction)
 	case TypeSQL:
 		if err := p.runMigration(ctx, conn, m, direction); err != nil {
 			return err
 		}
-		return p.maybeInsertOrDelete(ctx, conn, m.Version, direction)
+		if err := p.maybeInsertOrDelete(ctx, conn, m, direction); err != nil || !direction {
+			return err
+		}
+		return p.validateConstraints(ctx, conn, m)
 	}
 	return fmt.Errorf("failed to run individual migration: neither sql or go: %v", m)
 }
@@ -205,7 +747,7 @@ func (p *Provider) runIndividually(
 func (p *Provider) maybeInsertOrDelete(
 	ctx context.Context,
 	db database.DBTxConn,
-	version int64,
+	m *Migration,
 	direction bool,
 ) error {
 	// If versioning is disabled, we don't need to insert or delete the migration version.
@@ -213,9 +755,13 @@ func (p *Provider) maybeInsertOrDelete(
 		return nil
 	}
 	if direction {
-		return p.store.Insert(ctx, db, database.InsertRequest{Version: version})
+		if err := p.store.Insert(ctx, db, database.InsertRequest{Version: m.Version}); err != nil {
+			return err
+		}
+	} else if err := p.store.Delete(ctx, db, m.Version); err != nil {
+		return err
 	}
-	return p.store.Delete(ctx, db, version)
+	return p.syncMetadata(ctx, db, m, direction)
 }
 
 // beginTx begins a transaction and runs the given function. If the function returns an error, the
@@ -238,30 +784,67 @@ func beginTx(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) (re
 
 func (p *Provider) initialize(ctx context.Context, useSessionLocker bool) (*sql.Conn, func() error, error) {
 	p.mu.Lock()
+	restorePool := p.applyPoolLimits()
 	conn, err := p.db.Conn(ctx)
 	if err != nil {
+		restorePool()
 		p.mu.Unlock()
 		return nil, nil, err
 	}
 	// cleanup is a function that cleans up the connection, and optionally, the session lock.
 	cleanup := func() error {
-		p.mu.Unlock()
+		defer p.mu.Unlock()
+		defer restorePool()
 		return conn.Close()
 	}
 	if useSessionLocker && p.cfg.sessionLocker != nil && p.cfg.lockEnabled {
 		l := p.cfg.sessionLocker
-		if err := l.SessionLock(ctx, conn); err != nil {
+		if err := p.sessionLock(ctx, conn); err != nil {
 			return nil, nil, multierr.Append(err, cleanup())
 		}
 		// A lock was acquired, so we need to unlock the session when we're done. This is done by
 		// returning a cleanup function that unlocks the session and closes the connection.
 		cleanup = func() error {
-			p.mu.Unlock()
+			defer p.mu.Unlock()
+			defer restorePool()
 			// Use a detached context to unlock the session. This is because the context passed to
 			// SessionLock may have been canceled, and we don't want to cancel the unlock.
 			return multierr.Append(l.SessionUnlock(context.WithoutCancel(ctx), conn), conn.Close())
 		}
 	}
+	if len(p.cfg.searchPath) > 0 {
+		if _, err := conn.ExecContext(ctx, "SET search_path TO "+quoteIdentifiers(p.cfg.searchPath)); err != nil {
+			return nil, nil, multierr.Append(fmt.Errorf("failed to set search path: %w", err), cleanup())
+		}
+		// Reset the search path before the connection is returned to the pool.
+		release := cleanup
+		cleanup = func() error {
+			_, err := conn.ExecContext(context.WithoutCancel(ctx), "RESET search_path")
+			return multierr.Append(err, release())
+		}
+	}
+	if p.cfg.applicationName != "" {
+		if _, err := conn.ExecContext(ctx, "SET application_name TO "+quoteLiteral(p.cfg.applicationName)); err != nil {
+			return nil, nil, multierr.Append(fmt.Errorf("failed to set application name: %w", err), cleanup())
+		}
+		// Reset the application name before the connection is returned to the pool.
+		release := cleanup
+		cleanup = func() error {
+			_, err := conn.ExecContext(context.WithoutCancel(ctx), "RESET application_name")
+			return multierr.Append(err, release())
+		}
+	}
+	if p.cfg.sessionTag {
+		if _, err := conn.ExecContext(ctx, "SET "+SessionTag+" TO 'on'"); err != nil {
+			return nil, nil, multierr.Append(fmt.Errorf("failed to set session tag: %w", err), cleanup())
+		}
+		// Reset the session tag before the connection is returned to the pool.
+		release := cleanup
+		cleanup = func() error {
+			_, err := conn.ExecContext(context.WithoutCancel(ctx), "RESET "+SessionTag)
+			return multierr.Append(err, release())
+		}
+	}
 	// If versioning is enabled, ensure the version table exists. For ad-hoc migrations, we don't
 	// need the version table because no versions are being tracked.
 	if !p.cfg.disableVersioning {
@@ -272,6 +855,47 @@ func (p *Provider) initialize(ctx context.Context, useSessionLocker bool) (*sql.
 	return conn, cleanup, nil
 }
 
+// applyPoolLimits sets the pool limits of [WithPoolLimits] on the provider's database and returns
+// a function that restores them.
+func (p *Provider) applyPoolLimits() (restore func()) {
+	limits := p.cfg.poolLimits
+	if limits == nil {
+		return func() {}
+	}
+	maxOpen := p.db.Stats().MaxOpenConnections
+	if limits.MaxOpenConns > 0 {
+		p.db.SetMaxOpenConns(limits.MaxOpenConns)
+	}
+	if limits.MaxIdleConns > 0 {
+		p.db.SetMaxIdleConns(limits.MaxIdleConns)
+	}
+	return func() {
+		p.db.SetMaxOpenConns(maxOpen)
+		if limits.MaxIdleConns == 0 && limits.RestoreMaxIdleConns == 0 {
+			return
+		}
+		maxIdle := limits.RestoreMaxIdleConns
+		if maxIdle == 0 {
+			maxIdle = 2 // The database/sql default.
+		}
+		p.db.SetMaxIdleConns(maxIdle)
+	}
+}
+
+// quoteLiteral returns s as a quoted string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdentifiers returns the names as a comma-separated list of quoted identifiers.
+func quoteIdentifiers(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, `"`+strings.ReplaceAll(name, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func (p *Provider) ensureVersionTable(
 	ctx context.Context,
 	conn *sql.Conn,
@@ -366,34 +990,63 @@ func useTx(m *Migration, direction bool) (bool, error) {
 	return false, fmt.Errorf("use tx: invalid migration type: %q", m.Type)
 }
 
+// usesConn reports whether the Go migration runs with a *sql.Conn in the given direction.
+func usesConn(m *Migration, direction bool) bool {
+	if m.Type != TypeGo {
+		return false
+	}
+	if direction {
+		return m.goUp.RunConn != nil
+	}
+	return m.goDown.RunConn != nil
+}
+
 // isEmpty is a helper function that returns true if the migration has no functions or no statements
 // to execute. It must only be called after the migration has been parsed and initialized.
 func isEmpty(m *Migration, direction bool) bool {
 	switch m.Type {
 	case TypeGo:
 		if direction {
-			return m.goUp.RunTx == nil && m.goUp.RunDB == nil
+			return m.goUp.RunTx == nil && m.goUp.RunDB == nil && m.goUp.RunConn == nil
 		}
-		return m.goDown.RunTx == nil && m.goDown.RunDB == nil
+		return m.goDown.RunTx == nil && m.goDown.RunDB == nil && m.goDown.RunConn == nil
 	case TypeSQL:
 		if direction {
-			return len(m.sql.Up) == 0
+			return countStatements(m, direction) == 0 && len(m.sql.Loads) == 0
 		}
-		return len(m.sql.Down) == 0
+		return countStatements(m, direction) == 0
 	}
 	return true
 }
 
+// countStatements returns the number of statements of the SQL migration in the given direction.
+func countStatements(m *Migration, direction bool) int {
+	switch {
+	case m.sql.Stream && direction:
+		return m.sql.UpCount
+	case m.sql.Stream:
+		return m.sql.DownCount
+	case direction:
+		return len(m.sql.Up)
+	}
+	return len(m.sql.Down)
+}
+
 // runMigration is a helper function that runs the migration in the given direction. It must only be
 // called after the migration has been parsed and initialized.
 func (p *Provider) runMigration(ctx context.Context, db database.DBTxConn, m *Migration, direction bool) error {
+	ctx, done := p.watchDuration(ctx, m)
+	resetRole, err := p.setRole(ctx, db, m)
+	if err != nil {
+		return done(err)
+	}
 	switch m.Type {
 	case TypeGo:
-		return p.runGo(ctx, db, m, direction)
+		return done(resetRole(p.runGo(ctx, db, m, direction)))
 	case TypeSQL:
-		return p.runSQL(ctx, db, m, direction)
+		return done(resetRole(p.runSQL(ctx, db, m, direction)))
 	}
-	return fmt.Errorf("invalid migration type: %q", m.Type)
+	return done(resetRole(fmt.Errorf("invalid migration type: %q", m.Type)))
 }
 
 // runGo is a helper function that runs the given Go functions in the given direction. It must only
@@ -405,9 +1058,21 @@ func (p *Provider) runGo(ctx context.Context, db database.DBTxConn, m *Migration
 		}
 	}()
 
+	if p.cfg.deps != nil {
+		ctx = ContextWithMigrationDeps(ctx, p.cfg.deps)
+	}
+	if p.dialect != "" {
+		ctx = ContextWithCapabilities(ctx, NewCapabilities(db, p.dialect))
+	}
 	switch db := db.(type) {
 	case *sql.Conn:
-		return fmt.Errorf("go migrations are not supported with *sql.Conn")
+		if direction && m.goUp.RunConn != nil {
+			return m.goUp.RunConn(ctx, db)
+		}
+		if !direction && m.goDown.RunConn != nil {
+			return m.goDown.RunConn(ctx, db)
+		}
+		return nil
 	case *sql.DB:
 		if direction && m.goUp.RunDB != nil {
 			return m.goUp.RunDB(ctx, db)
@@ -430,7 +1095,7 @@ func (p *Provider) runGo(ctx context.Context, db database.DBTxConn, m *Migration
 
 // runSQL is a helper function that runs the given SQL statements in the given direction. It must
 // only be called after the migration has been parsed.
-func (p *Provider) runSQL(ctx context.Context, db database.DBTxConn, m *Migration, direction bool) error {
+func (p *Provider) runSQL(ctx context.Context, db database.DBTxConn, m *Migration, direction bool) (retErr error) {
 
 	if !m.sql.Parsed {
 		return fmt.Errorf("sql migrations must be parsed")
@@ -438,16 +1103,161 @@ func (p *Provider) runSQL(ctx context.Context, db database.DBTxConn, m *Migratio
 	var statements []string
 	if direction {
 		statements = m.sql.Up
+		if err := checkPreconditions(ctx, db, m.sql.Preconditions); err != nil {
+			return err
+		}
+		if err := p.takeSnapshots(ctx, db, m); err != nil {
+			return err
+		}
+		if !m.sql.Tombstone {
+			if err := ensureExtensions(ctx, db, m.sql.Extensions); err != nil {
+				return err
+			}
+		}
 	} else {
 		statements = m.sql.Down
 	}
-	for _, stmt := range statements {
+	exec := ExecFunc(func(ctx context.Context, m *Migration, query string) (sql.Result, error) {
+		if direction && (p.cfg.tableCharset != "" || p.cfg.tableCollation != "") {
+			query = sqlparser.InjectTableCharset(query, p.cfg.tableCharset, p.cfg.tableCollation)
+		}
+		if p.cfg.statementLog == nil {
+			return db.ExecContext(ctx, query)
+		}
+		start := time.Now()
+		result, err := db.ExecContext(ctx, query)
+		if logErr := p.writeStatementLog(m, direction, query, start, err); logErr != nil && err == nil {
+			return nil, logErr
+		}
+		return result, err
+	})
+	for i := len(p.cfg.middleware) - 1; i >= 0; i-- {
+		exec = p.cfg.middleware[i](exec)
+	}
+	if p.cfg.statementTags {
+		next := exec
+		exec = func(ctx context.Context, m *Migration, query string) (sql.Result, error) {
+			return next(ctx, m, statementTag(m)+query)
+		}
+	}
+	_, inTx := db.(*sql.Tx)
+	checkpoints := p.usesCheckpoints(m, inTx)
+	var skip int
+	if checkpoints {
+		var err error
+		skip, err = p.loadCheckpoint(ctx, db, m, direction, countStatements(m, direction), func(n int) (string, error) {
+			if m.sql.Stream {
+				return p.streamedCheckpointValue(m, direction, n)
+			}
+			return checkpointValue(statements[:n]), nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	var completed *checkpointHash
+	if checkpoints {
+		completed = newCheckpointHash()
+	}
+	if p.cfg.lockTimeout > 0 {
+		reset, err := p.setLockTimeout(ctx, db)
+		if err != nil {
+			return err
+		}
+		defer func() { retErr = multierr.Append(retErr, reset()) }()
+	}
+	var session int64
+	if p.cfg.lockDiagnostics > 0 {
+		var err error
+		if session, err = sessionID(ctx, db, p.dialect); err != nil {
+			return err
+		}
+	}
+	var i int
+	runStatement := func(stmt string) error {
+		defer func() { i++ }()
+		if completed != nil {
+			completed.add(stmt)
+		}
+		if i < skip {
+			return nil
+		}
+		if i > 0 && !inTx {
+			if err := p.throttle(ctx); err != nil {
+				return err
+			}
+		}
 		if p.cfg.verbose {
 			p.cfg.logger.Printf("Excuting statement: %s", stmt)
 		}
-		if _, err := db.ExecContext(ctx, stmt); err != nil {
+		if err := p.explainDML(ctx, db, m, stmt); err != nil {
+			return err
+		}
+		stop := func() {}
+		if p.cfg.lockDiagnostics > 0 {
+			stop = p.watchLocks(ctx, m, stmt, session)
+		}
+		var result sql.Result
+		run := func() error {
+			var err error
+			result, err = exec(ctx, m, stmt)
 			return err
 		}
+		var err error
+		if inTx {
+			// A statement that timed out waiting on a lock aborted the transaction, so the whole
+			// migration is retried.
+			err = run()
+		} else {
+			err = p.retryLocked(ctx, m, run)
+		}
+		if err != nil && direction && !inTx && p.cfg.concurrentIndexes {
+			if _, name, ok := sqlparser.ConcurrentIndex(stmt); ok {
+				err = p.retryIndex(ctx, db, name, err, run)
+			}
+		}
+		stop()
+		if err != nil {
+			return err
+		}
+		if err := checkAffected(m.sql.MaxAffected, stmt, result); err != nil {
+			return err
+		}
+		// The checkpoint is cleared with the rest of the version's metadata once the migration
+		// completes.
+		if completed != nil {
+			if err := p.saveCheckpoint(ctx, db, m, direction, completed.value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if m.sql.Stream {
+		// Statements are read from the file as they are executed, so the migration is never held
+		// in memory. It was validated when it was prepared.
+		if err := sqlparser.StreamFromFS(p.fsys, m.Source, sqlparser.FromBool(direction), false, runStatement); err != nil {
+			return err
+		}
+	} else {
+		for _, stmt := range statements {
+			if err := runStatement(stmt); err != nil {
+				return err
+			}
+		}
+	}
+	if direction {
+		if err := p.loadData(ctx, db, m); err != nil {
+			return err
+		}
+		return checkPostConditions(ctx, db, m.sql.PostConditions)
+	}
+	if len(m.sql.Snapshot) > 0 {
+		return p.restoreSnapshots(ctx, db, m)
 	}
 	return nil
 }
+
+// statementTag returns the comment [WithStatementTags] prepends to the statements of m.
+func statementTag(m *Migration) string {
+	return fmt.Sprintf("/* goose:version=%d source=%s */ ", m.Version, path.Base(m.Source))
+}
@@ -0,0 +1,75 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pressly/goose/v3/internal/gooseutil"
+	"github.com/stretchr/testify/require"
+)
+
+// windowFrom returns a daily window from now+from to now+to, in UTC.
+func windowFrom(t *testing.T, from, to time.Duration) string {
+	t.Helper()
+	now := time.Now().UTC()
+	return now.Add(from).Format("15:04") + "-" + now.Add(to).Format("15:04") + " UTC"
+}
+
+type countingLocker struct {
+	locks int
+}
+
+func (l *countingLocker) SessionLock(context.Context, *sql.Conn) error {
+	l.locks++
+	return nil
+}
+
+func (l *countingLocker) SessionUnlock(context.Context, *sql.Conn) error {
+	return nil
+}
+
+func TestWindowPerMigration(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n")},
+		"00002_b.sql": &fstest.MapFile{Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n")},
+	}
+	t.Run("closes", func(t *testing.T) {
+		closed, err := gooseutil.ParseWindow(windowFrom(t, time.Hour, 2*time.Hour))
+		require.NoError(t, err)
+		var p *Provider
+		p, _, store := newAtomicProvider(t, fsys,
+			WithWindow(windowFrom(t, -time.Hour, time.Hour)),
+			WithPacing(func(context.Context, *MigrationResult) error {
+				// The window closes while the run is in progress.
+				p.cfg.window = &closed
+				return nil
+			}),
+		)
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, ErrOutsideWindow)
+		var partialErr *PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Len(t, partialErr.Applied, 1)
+		require.EqualValues(t, 2, partialErr.Failed.Source.Version)
+		require.Equal(t, []int64{0, 1}, store.Versions())
+	})
+	t.Run("wait before lock", func(t *testing.T) {
+		locker := new(countingLocker)
+		p, _, _ := newAtomicProvider(t, fsys,
+			WithWindow(windowFrom(t, time.Hour, 2*time.Hour)),
+			WithWaitForWindow(true),
+			WithSessionLocker(locker),
+		)
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := p.Up(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Zero(t, locker.locks)
+	})
+}
@@ -10,12 +10,15 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/pressly/goose/v3"
 	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/throttle"
 	"github.com/stretchr/testify/require"
 )
 
@@ -772,6 +775,164 @@ func TestProviderApply(t *testing.T) {
 	require.ErrorIs(t, err, goose.ErrNotApplied)
 }
 
+func TestProviderRedo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	t.Run("last_and_to", func(t *testing.T) {
+		p, db := newProviderWithDB(t)
+		_, err := p.Up(ctx)
+		require.NoError(t, err)
+		res, err := p.RedoLast(ctx, 2)
+		require.NoError(t, err)
+		require.Len(t, res, 4)
+		assertResult(t, res[0], newSource(goose.TypeSQL, "00007_empty_up_down.sql", 7), "down", true)
+		assertResult(t, res[1], newSource(goose.TypeSQL, "00006_empty_up.sql", 6), "down", true)
+		assertResult(t, res[2], newSource(goose.TypeSQL, "00006_empty_up.sql", 6), "up", true)
+		assertResult(t, res[3], newSource(goose.TypeSQL, "00007_empty_up_down.sql", 7), "up", true)
+
+		res, err = p.RedoTo(ctx, 4)
+		require.NoError(t, err)
+		require.Len(t, res, 6)
+		for i, version := range []int64{7, 6, 5, 5, 6, 7} {
+			require.Equal(t, version, res[i].Source.Version)
+		}
+		currentVersion, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 7, currentVersion)
+		gotVersions, err := getGooseVersionCount(db, goose.DefaultTablename)
+		require.NoError(t, err)
+		require.EqualValues(t, 7, gotVersions)
+
+		// Nothing to redo below the current version.
+		res, err = p.RedoTo(ctx, 7)
+		require.NoError(t, err)
+		require.Empty(t, res)
+		_, err = p.RedoLast(ctx, 0)
+		require.Error(t, err)
+	})
+	t.Run("atomic", func(t *testing.T) {
+		// The down migration of version 2 does not drop the table, so re-applying it fails.
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"),
+			"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nSELECT 1;\n"),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		_, err = p.RedoTo(ctx, 0)
+		require.Error(t, err)
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Empty(t, partialErr.Applied)
+		require.EqualValues(t, 2, partialErr.Failed.Source.Version)
+		require.Equal(t, "up", partialErr.Failed.Direction)
+		// Everything was rolled back, including the down migrations.
+		currentVersion, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, currentVersion)
+		require.True(t, tableExists(t, db, "a"))
+	})
+	t.Run("not_atomic_without_tx", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"),
+			"00002_b.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nSELECT 1;\n"),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		_, err = p.RedoTo(ctx, 0)
+		require.Error(t, err)
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Len(t, partialErr.Applied, 3)
+		currentVersion, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 1, currentVersion)
+	})
+}
+
+func TestTombstone(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n"),
+		// Retired: the original statements were removed and must never run.
+		"00002_b.sql": newMapFile("-- +goose tombstone replaced by 00003\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n-- +goose Down\nDROP TABLE c;\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	require.True(t, res[1].Tombstone)
+	require.True(t, res[1].Empty)
+	require.False(t, res[0].Tombstone)
+	// The tombstone is versioned, so it is not reported as pending.
+	status, err := p.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, goose.StateApplied, status[1].State)
+	hasPending, err := p.HasPending(ctx)
+	require.NoError(t, err)
+	require.False(t, hasPending)
+
+	res, err = p.DownTo(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	require.True(t, res[1].Tombstone)
+	currentVersion, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, currentVersion)
+}
+
+func TestStrictOrdering(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nSELECT 1;\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nSELECT 3;\n"),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStrictOrdering(true))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+
+	// Version 2 is merged after version 3 was applied.
+	fsys["00002_b.sql"] = newMapFile("-- +goose Up\nSELECT 2;\n")
+	fsys["00004_d.sql"] = newMapFile("-- +goose Up\nSELECT 4;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStrictOrdering(true))
+	require.NoError(t, err)
+	for name, fn := range map[string]func() error{
+		// Without strict ordering, these operations succeed.
+		"down":  func() error { _, err := p.Down(ctx); return err },
+		"apply": func() error { _, err := p.ApplyVersion(ctx, 4, true); return err },
+		"redo":  func() error { _, err := p.RedoLast(ctx, 1); return err },
+	} {
+		err := fn()
+		require.Error(t, err, name)
+		require.Contains(t, err.Error(), "strict ordering: detected 1 missing (out-of-order) migration", name)
+	}
+	current, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, current)
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys,
+		goose.WithStrictOrdering(true),
+		goose.WithAllowOutofOrder(true),
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "mutually exclusive")
+}
+
 func TestPending(t *testing.T) {
 	t.Parallel()
 	t.Run("allow_out_of_order", func(t *testing.T) {
@@ -795,6 +956,12 @@ func TestPending(t *testing.T) {
 		hasPending, err := p.HasPending(ctx)
 		require.NoError(t, err)
 		require.True(t, hasPending)
+		var pendingErr *goose.PendingMigrationsError
+		require.ErrorAs(t, p.CheckUpToDate(ctx), &pendingErr)
+		require.ErrorIs(t, pendingErr, goose.ErrPendingMigrations)
+		require.NotContains(t, pendingErr.Versions, int64(1))
+		require.NotContains(t, pendingErr.Versions, int64(3))
+		require.Len(t, pendingErr.Versions, len(fsys)-2)
 		// Apply the missing migrations.
 		_, err = p.Up(ctx)
 		require.NoError(t, err)
@@ -802,6 +969,7 @@ func TestPending(t *testing.T) {
 		hasPending, err = p.HasPending(ctx)
 		require.NoError(t, err)
 		require.False(t, hasPending)
+		require.NoError(t, p.CheckUpToDate(ctx))
 		current, target, err = p.GetVersions(ctx)
 		require.NoError(t, err)
 		require.Equal(t, current, target)
@@ -989,6 +1157,486 @@ func TestGoOnly(t *testing.T) {
 	})
 }
 
+func TestGoMigrationConn(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	// Temporary tables are only visible to the session that created them, so this migration only
+	// succeeds if all statements run on the same connection.
+	up := func(ctx context.Context, conn *sql.Conn) error {
+		for _, q := range []string{
+			"CREATE TABLE users (id INTEGER PRIMARY KEY)",
+			"CREATE TEMP TABLE staging (id INTEGER)",
+			"INSERT INTO staging (id) VALUES (1), (2), (3)",
+			"INSERT INTO users (id) SELECT id FROM staging",
+			"DROP TABLE staging",
+		} {
+			if _, err := conn.ExecContext(ctx, q); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	down := func(ctx context.Context, conn *sql.Conn) error {
+		_, err := conn.ExecContext(ctx, "DROP TABLE users")
+		return err
+	}
+	db := newDB(t)
+	// A single connection would deadlock if goose ran the migration on a different connection.
+	db.SetMaxOpenConns(1)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, nil,
+		goose.WithGoMigrations(goose.NewGoMigration(1, &goose.GoFunc{RunConn: up}, &goose.GoFunc{RunConn: down})),
+	)
+	require.NoError(t, err)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assertResult(t, res[0], newSource(goose.TypeGo, "", 1), "up", false)
+	var count int
+	require.NoError(t, db.QueryRow("SELECT count(*) FROM users").Scan(&count))
+	require.Equal(t, 3, count)
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.False(t, tableExists(t, db, "users"))
+
+	// RunConn is mutually exclusive with the other run functions and never runs in a transaction.
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, nil,
+		goose.WithGoMigrations(goose.NewGoMigration(1, &goose.GoFunc{RunConn: up, RunDB: newDBFn("SELECT 1")}, nil)),
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "must specify exactly one of RunTx, RunDB or RunConn")
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, nil,
+		goose.WithGoMigrations(goose.NewGoMigration(1, &goose.GoFunc{RunConn: up, Mode: goose.TransactionEnabled}, nil)),
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "transaction mode must be disabled or unspecified when RunConn is set")
+}
+
+func TestMigrationDeps(t *testing.T) {
+	t.Parallel()
+
+	type appDeps struct{ greeting string }
+
+	ctx := context.Background()
+	var got []string
+	up := func(ctx context.Context, tx *sql.Tx) error {
+		deps, ok := goose.MigrationDeps[*appDeps](ctx)
+		if !ok {
+			return errors.New("missing migration deps")
+		}
+		got = append(got, deps.greeting)
+		return nil
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), nil,
+		goose.WithGoMigrations(goose.NewGoMigration(1, &goose.GoFunc{RunTx: up}, nil)),
+		goose.WithMigrationDeps(&appDeps{greeting: "hello"}),
+	)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"hello"}, got)
+
+	// The accessor reports false for a missing or mismatched type.
+	_, ok := goose.MigrationDeps[*appDeps](ctx)
+	require.False(t, ok)
+	_, ok = goose.MigrationDeps[string](goose.ContextWithMigrationDeps(ctx, &appDeps{}))
+	require.False(t, ok)
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), nil, goose.WithMigrationDeps(nil))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "migration deps must not be nil")
+}
+
+func TestApproval(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	errClosed := errors.New("maintenance window closed")
+	var approved [][]int64
+	windowOpen := false
+	approval := func(ctx context.Context, pending []*goose.Migration) error {
+		var versions []int64
+		for _, m := range pending {
+			versions = append(versions, m.Version)
+		}
+		approved = append(approved, versions)
+		if !windowOpen {
+			return errClosed
+		}
+		return nil
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, newFsys(), goose.WithApproval(approval))
+	require.NoError(t, err)
+
+	_, err = p.UpTo(ctx, 2)
+	require.ErrorIs(t, err, errClosed)
+	current, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, current)
+
+	windowOpen = true
+	_, err = p.UpTo(ctx, 2)
+	require.NoError(t, err)
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.Equal(t, [][]int64{{1, 2}, {1, 2}, {2}}, approved)
+
+	// Nothing to run, nothing to approve.
+	_, err = p.DownTo(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, approved, 3)
+
+	// Repeatable migrations are approved in the same call, before any migration runs.
+	var sources [][]string
+	fsys := fstest.MapFS{
+		"00001_a.sql":  newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"R__views.sql": newMapFile("-- +goose Up\nCREATE VIEW v AS SELECT id FROM a;\n"),
+	}
+	p, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys,
+		goose.WithRepeatable(true),
+		goose.WithApproval(func(ctx context.Context, pending []*goose.Migration) error {
+			var paths []string
+			for _, m := range pending {
+				paths = append(paths, m.Source)
+			}
+			sources = append(sources, paths)
+			return errClosed
+		}),
+	)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, errClosed)
+	require.Equal(t, [][]string{{"00001_a.sql", "R__views.sql"}}, sources)
+	current, err = p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, current)
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, newFsys(), goose.WithApproval(nil))
+	require.ErrorContains(t, err, "approval func must not be nil")
+}
+
+func TestWindow(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	window := func(from, to time.Duration) string {
+		now := time.Now().UTC()
+		return now.Add(from).Format("15:04") + "-" + now.Add(to).Format("15:04") + " UTC"
+	}
+	t.Run("inside", func(t *testing.T) {
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithWindow(window(-time.Hour, time.Hour)))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+	})
+	t.Run("outside", func(t *testing.T) {
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithWindow(window(time.Hour, 2*time.Hour)))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, goose.ErrOutsideWindow)
+		current, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 0, current)
+	})
+	t.Run("wait", func(t *testing.T) {
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(),
+			goose.WithWindow(window(time.Hour, 2*time.Hour)),
+			goose.WithWaitForWindow(true),
+		)
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithWindow("2am-4am"))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithWaitForWindow(true))
+		require.ErrorContains(t, err, "requires WithWindow")
+	})
+}
+
+func TestPacing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	t.Run("pause", func(t *testing.T) {
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithPause(10*time.Millisecond))
+		require.NoError(t, err)
+		start := time.Now()
+		res, err := p.UpTo(ctx, 3)
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		// Two pauses, none before the first or after the last migration.
+		require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	})
+	t.Run("abort", func(t *testing.T) {
+		errTooSlow := errors.New("too slow")
+		var seen []int64
+		pacing := func(ctx context.Context, prev *goose.MigrationResult) error {
+			seen = append(seen, prev.Source.Version)
+			if prev.Source.Version == 2 {
+				return errTooSlow
+			}
+			return nil
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithPacing(pacing))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, errTooSlow)
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Len(t, partialErr.Applied, 2)
+		require.EqualValues(t, 3, partialErr.Failed.Source.Version)
+		require.Equal(t, []int64{1, 2}, seen)
+		current, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, current)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithPause(-time.Second))
+		require.Error(t, err)
+		_, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithPacing(nil))
+		require.ErrorContains(t, err, "pacing func must not be nil")
+	})
+}
+
+type lagFunc func(ctx context.Context) (time.Duration, error)
+
+func (f lagFunc) ReplicationLag(ctx context.Context) (time.Duration, error) { return f(ctx) }
+
+func TestThrottler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var checks int
+	checker := lagFunc(func(context.Context) (time.Duration, error) {
+		checks++
+		// Every other check reports a lagging replica.
+		if checks%2 == 1 {
+			return time.Minute, nil
+		}
+		return 0, nil
+	})
+	th, err := throttle.New(checker, time.Second, throttle.WithInterval(time.Millisecond))
+	require.NoError(t, err)
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		// Statements outside a transaction are throttled too.
+		"00002_b.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nINSERT INTO a VALUES (1);\nINSERT INTO a VALUES (2);\n"),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithThrottler(th))
+	require.NoError(t, err)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	// Once between the migrations, once between the statements.
+	require.Equal(t, 4, checks)
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithThrottler(nil))
+	require.ErrorContains(t, err, "throttler must not be nil")
+}
+
+func TestStreamSQL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var sb strings.Builder
+	sb.WriteString("-- +goose Up\nCREATE TABLE a (id INTEGER);\n")
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&sb, "INSERT INTO a VALUES (%d);\n", i)
+	}
+	sb.WriteString("-- +goose Down\nDROP TABLE a;\n")
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile(sb.String()),
+		"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n-- +goose Down\nDROP TABLE b;\n"),
+	}
+	db := newDB(t)
+	// Only the first migration is large enough to be streamed.
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStreamSQL(1024))
+	require.NoError(t, err)
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM a").Scan(&n))
+	require.Equal(t, 100, n)
+	_, err = p.DownTo(ctx, 0)
+	require.NoError(t, err)
+	require.False(t, tableExists(t, db, "a"))
+
+	t.Run("invalid_file", func(t *testing.T) {
+		db := newDB(t)
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose StatementBegin\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStreamSQL(1))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "failed to parse")
+		// The file is validated before any statement runs.
+		require.False(t, tableExists(t, db, "a"))
+	})
+	t.Run("checkpoints", func(t *testing.T) {
+		db := newDB(t)
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE a (id INTEGER);\nINSERT INTO b VALUES (1);\nCREATE TABLE c (id INTEGER);\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStreamSQL(1), goose.WithCheckpoints(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "no such table: b")
+		_, err = db.ExecContext(ctx, "CREATE TABLE b (id INTEGER)")
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.True(t, tableExists(t, db, "c"))
+	})
+	t.Run("invalid_threshold", func(t *testing.T) {
+		_, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithStreamSQL(0))
+		require.ErrorContains(t, err, "stream threshold must be positive")
+	})
+}
+
+func TestPoolLimits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	db.SetMaxOpenConns(10)
+	var during int
+	m := goose.NewGoMigration(1, &goose.GoFunc{RunDB: func(ctx context.Context, db *sql.DB) error {
+		during = db.Stats().MaxOpenConnections
+		return nil
+	}}, nil)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, nil,
+		goose.WithGoMigrations(m),
+		goose.WithPoolLimits(goose.PoolLimits{MaxOpenConns: 2, MaxIdleConns: 1}),
+	)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, during)
+	// The application's limit is restored after the run.
+	require.Equal(t, 10, db.Stats().MaxOpenConnections)
+
+	t.Run("idle unchanged", func(t *testing.T) {
+		db := newDB(t)
+		db.SetMaxOpenConns(20)
+		db.SetMaxIdleConns(8)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, newFsys(),
+			goose.WithPoolLimits(goose.PoolLimits{MaxOpenConns: 10}),
+		)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		_, err = p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		// The idle limit set by the application still keeps more than the default of 2.
+		var conns []*sql.Conn
+		for i := 0; i < 5; i++ {
+			conn, err := db.Conn(ctx)
+			require.NoError(t, err)
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			require.NoError(t, conn.Close())
+		}
+		require.Equal(t, 5, db.Stats().Idle)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\nINSERT INTO a VALUES (1);\n-- +goose Down\nDROP TABLE a;\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nINSERT INTO a VALUES (2);\n"),
+	}
+	var executed []string
+	record := func(next goose.ExecFunc) goose.ExecFunc {
+		return func(ctx context.Context, m *goose.Migration, query string) (sql.Result, error) {
+			executed = append(executed, query)
+			return next(ctx, m, query)
+		}
+	}
+	tag := func(next goose.ExecFunc) goose.ExecFunc {
+		return func(ctx context.Context, m *goose.Migration, query string) (sql.Result, error) {
+			return next(ctx, m, fmt.Sprintf("/* migration:%d */ %s", m.Version, query))
+		}
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithMiddleware(tag), goose.WithMiddleware(record))
+	require.NoError(t, err)
+	_, err = p.UpTo(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"/* migration:1 */ CREATE TABLE a (id INTEGER);",
+		"/* migration:1 */ INSERT INTO a VALUES (1);",
+	}, executed)
+
+	// A middleware may fail the migration, which is rolled back.
+	veto := func(next goose.ExecFunc) goose.ExecFunc {
+		return func(ctx context.Context, m *goose.Migration, query string) (sql.Result, error) {
+			if strings.Contains(query, "VALUES (2)") {
+				return nil, errors.New("statement vetoed")
+			}
+			return next(ctx, m, query)
+		}
+	}
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithMiddleware(veto))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorContains(t, err, "statement vetoed")
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM a").Scan(&n))
+	require.Equal(t, 1, n)
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithMiddleware(nil))
+	require.Error(t, err)
+}
+
+func TestStatementTags(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_users.sql": newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n"),
+		"00002_seed.sql":  newMapFile("-- +goose Up\nINSERT INTO users VALUES (1);\n"),
+	}
+	var executed []string
+	record := func(next goose.ExecFunc) goose.ExecFunc {
+		return func(ctx context.Context, m *goose.Migration, query string) (sql.Result, error) {
+			executed = append(executed, query)
+			return next(ctx, m, query)
+		}
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+		goose.WithStatementTags(true), goose.WithMiddleware(record))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"/* goose:version=1 source=00001_users.sql */ CREATE TABLE users (id INTEGER);",
+		"/* goose:version=2 source=00002_seed.sql */ INSERT INTO users VALUES (1);",
+	}, executed)
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n))
+	require.Equal(t, 1, n)
+
+	// Statements are not tagged by default.
+	executed = nil
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithMiddleware(record))
+	require.NoError(t, err)
+	_, err = p.DownTo(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, []string{"DROP TABLE users;"}, executed)
+}
+
 func newDBFn(query string) func(context.Context, *sql.DB) error {
 	return func(ctx context.Context, db *sql.DB) error {
 		_, err := db.ExecContext(ctx, query)
@@ -1003,6 +1651,13 @@ func newTxFn(query string) func(context.Context, *sql.Tx) error {
 	}
 }
 
+func viewExists(t *testing.T, db *sql.DB, view string) bool {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'view' AND name = ?`, view).Scan(&n)
+	require.NoError(t, err)
+	return n > 0
+}
+
 func tableExists(t *testing.T, db *sql.DB, table string) bool {
 	q := fmt.Sprintf(`SELECT CASE WHEN COUNT(*) > 0 THEN 1 ELSE 0 END AS table_exists FROM sqlite_master WHERE type = 'table' AND name = '%s'`, table)
 	var b string
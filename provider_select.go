@@ -0,0 +1,185 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pressly/goose/v3/database"
+)
+
+// allowMissing reports whether missing (out-of-order) migrations up to the target version are
+// applied instead of reported as an error. Selecting versions only relaxes the check for the
+// selected versions: an included version is applied out of order deliberately, and a skipped or
+// not included version is not applied at all. Any other missing version is still an error.
+func (p *Provider) allowMissing(fsysVersions, dbVersions []int64, target int64) bool {
+	if p.cfg.allowMissing {
+		return true
+	}
+	if p.cfg.includeVersions == nil && p.cfg.skipVersions == nil {
+		return false
+	}
+	applied := make(map[int64]bool, len(dbVersions))
+	var dbMaxVersion int64
+	for _, v := range dbVersions {
+		applied[v] = true
+		dbMaxVersion = max(dbMaxVersion, v)
+	}
+	for _, v := range fsysVersions {
+		if applied[v] || v >= dbMaxVersion || v > target {
+			continue
+		}
+		if p.selected(v) && !p.cfg.includeVersions[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// selected reports whether a pending version is applied by runs, given the versions included with
+// [WithIncludeVersions] and skipped with [WithSkipVersions].
+func (p *Provider) selected(version int64) bool {
+	if p.cfg.includeVersions != nil && !p.cfg.includeVersions[version] {
+		return false
+	}
+	return !p.cfg.skipVersions[version]
+}
+
+// excludedPending returns the excluded migration files that are not applied, up to the target
+// version, see [WithExcludeVersions] and [WithExcludeNames].
+func (p *Provider) excludedPending(dbMigrations []*database.ListMigrationsResult, target int64) []*excludedSource {
+	applied := make(map[int64]bool, len(dbMigrations))
+	for _, m := range dbMigrations {
+		applied[m.Version] = true
+	}
+	var pending []*excludedSource
+	for _, e := range p.excluded {
+		if e.Version > target || applied[e.Version] {
+			continue
+		}
+		// A Go migration registered globally is still known, even if its file was excluded.
+		if _, err := p.getMigration(e.Version); err == nil {
+			continue
+		}
+		pending = append(pending, e)
+	}
+	return pending
+}
+
+// recordSkipped records a [SkipRecord] for each pending version skipped by a run, with its reason,
+// and reports the migrations about to be applied that were skipped by an earlier run. Records of
+// versions skipped again for the same reason are kept, so they show when the version was first
+// skipped.
+//
+// Versions left out with [WithSkipVersions] are always recorded. Excluded versions are only
+// recorded if the provider keeps a metadata table, since exclusions are often permanent.
+func (p *Provider) recordSkipped(
+	ctx context.Context,
+	conn *sql.Conn,
+	skipped map[int64]string,
+	apply []*Migration,
+) error {
+	if err := p.prepareMetadata(ctx, conn, false); err != nil {
+		return fmt.Errorf("failed to prepare metadata table: %w", err)
+	}
+	if !p.metadata {
+		return nil
+	}
+	records, err := p.listSkipRecords(ctx, conn)
+	if err != nil {
+		return err
+	}
+	for _, m := range apply {
+		if r, ok := records[m.Version]; ok {
+			p.printf("applying %s, skipped since %s: %s", m.ref(), r.SkippedAt.Format(time.RFC3339), r.Reason)
+		}
+	}
+	versions := make([]int64, 0, len(skipped))
+	for v := range skipped {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	for _, v := range versions {
+		reason := skipped[v]
+		p.printf("skipping version %d: %s", v, reason)
+		if err := p.recordSkip(ctx, conn, records, v, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordSkip records a [SkipRecord] for a skipped version, unless records, the skip records of
+// earlier runs, have one with the same reason.
+func (p *Provider) recordSkip(
+	ctx context.Context,
+	conn *sql.Conn,
+	records map[int64]*SkipRecord,
+	version int64,
+	reason string,
+) error {
+	if r, ok := records[version]; ok && r.Reason == reason {
+		return nil
+	}
+	data, err := json.Marshal(&SkipRecord{Reason: reason, SkippedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to record skipped version %d: %w", version, err)
+	}
+	if err := p.store.DeleteMetadataKey(ctx, conn, version, skippedKey); err != nil {
+		return fmt.Errorf("failed to record skipped version %d: %w", version, err)
+	}
+	if err := p.store.InsertMetadata(ctx, conn, version, skippedKey, string(data)); err != nil {
+		return fmt.Errorf("failed to record skipped version %d: %w", version, err)
+	}
+	return nil
+}
+
+// listSkipRecords returns the skip records by version, see [decodeSkipRecord].
+func (p *Provider) listSkipRecords(ctx context.Context, db database.DBTxConn) (map[int64]*SkipRecord, error) {
+	exists, err := p.store.MetadataTableExists(ctx, db)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+	results, err := p.store.ListMetadata(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[int64]*SkipRecord)
+	for _, r := range results {
+		if r.Key != skippedKey {
+			continue
+		}
+		records[r.Version] = decodeSkipRecord(r.Value)
+	}
+	return records, nil
+}
+
+// decodeSkipRecord decodes a skip record from the metadata table. A record that cannot be decoded,
+// e.g., written by a newer version of goose, is returned with the raw value as the reason.
+func decodeSkipRecord(value string) *SkipRecord {
+	record := new(SkipRecord)
+	if err := json.Unmarshal([]byte(value), record); err != nil {
+		return &SkipRecord{Reason: value}
+	}
+	return record
+}
+
+// lookupExcluded returns the excluded migration file with the given version, or nil.
+func (p *Provider) lookupExcluded(version int64) *excludedSource {
+	for _, e := range p.excluded {
+		if e.Version == version {
+			return e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectVersions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE c (id INTEGER);\n"),
+	}
+	metadata := func(t *testing.T, db *sql.DB, version int64, key string) string {
+		t.Helper()
+		var value string
+		err := db.QueryRowContext(ctx, "SELECT meta_value FROM goose_db_version_meta WHERE version_id = ? AND meta_key = ?", version, key).Scan(&value)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ""
+		}
+		require.NoError(t, err)
+		return value
+	}
+
+	t.Run("include", func(t *testing.T) {
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithIncludeVersions([]int64{2}, "hotfix OPS-1"))
+		require.NoError(t, err)
+		res, err := p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.EqualValues(t, 2, res[0].Source.Version)
+		require.False(t, tableExists(t, db, "a"))
+		require.Equal(t, "hotfix OPS-1", metadata(t, db, 2, "included"))
+		// Version 1 is now missing, a later run must allow out-of-order migrations.
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "missing (out-of-order) migration")
+		// Selecting other versions does not apply it out of order either.
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithSkipVersions([]int64{3}, "OPS-3"))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "missing (out-of-order) migration")
+		require.False(t, tableExists(t, db, "a"))
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithIncludeVersions([]int64{3}, "OPS-3"))
+		require.NoError(t, err)
+		res, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.EqualValues(t, 3, res[0].Source.Version)
+		require.False(t, tableExists(t, db, "a"))
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithAllowOutofOrder(true))
+		require.NoError(t, err)
+		res, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+	})
+	t.Run("skip", func(t *testing.T) {
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithSkipVersions([]int64{2}, "broken, see OPS-2"))
+		require.NoError(t, err)
+		res, err := p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 2)
+		require.False(t, tableExists(t, db, "b"))
+		status, err := p.Status(ctx)
+		require.NoError(t, err)
+		require.Equal(t, goose.StatePending, status[1].State)
+		require.Equal(t, "broken, see OPS-2", status[1].Skipped.Reason)
+		skippedAt := status[1].Skipped.SkippedAt
+		require.False(t, skippedAt.IsZero())
+		require.Nil(t, status[2].Skipped)
+		// The skipped version is still pending.
+		var pendingErr *goose.PendingMigrationsError
+		require.ErrorAs(t, p.CheckUpToDate(ctx), &pendingErr)
+		require.Equal(t, []int64{2}, pendingErr.Versions)
+		res, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.Empty(t, res)
+		// Skipping again for the same reason keeps the time it was first skipped.
+		report, err := p.VerifyReport(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Withheld, 1)
+		require.Equal(t, &goose.SkippedMigration{
+			Source:     &goose.Source{Type: goose.TypeSQL, Path: "00002_b.sql", Version: 2},
+			SkipRecord: goose.SkipRecord{Reason: "broken, see OPS-2", SkippedAt: skippedAt},
+		}, report.Withheld[0])
+		require.NoError(t, report.Err())
+		// Applying the version clears the reason it was skipped.
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithIncludeVersions([]int64{2}, "fixed"))
+		require.NoError(t, err)
+		res, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		require.Empty(t, metadata(t, db, 2, "skipped"))
+		require.Equal(t, "fixed", metadata(t, db, 2, "included"))
+	})
+	t.Run("excluded", func(t *testing.T) {
+		db := newDB(t)
+		// Excluded versions are only recorded in a metadata table kept for other reasons.
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+			goose.WithExcludeVersions([]int64{2}),
+			goose.WithExcludeNames([]string{"00003_c.sql"}),
+			goose.WithRecordChecksums(true),
+		)
+		require.NoError(t, err)
+		res, err := p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+		report, err := p.VerifyReport(ctx)
+		require.NoError(t, err)
+		require.Len(t, report.Withheld, 2)
+		require.Equal(t, &goose.Source{Type: goose.TypeSQL, Path: "00002_b.sql", Version: 2}, report.Withheld[0].Source)
+		require.Equal(t, "excluded by version", report.Withheld[0].Reason)
+		require.Equal(t, "excluded by name", report.Withheld[1].Reason)
+		// Without a metadata table, nothing is recorded.
+		db = newDB(t)
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithExcludeVersions([]int64{2}))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.False(t, tableExists(t, db, "goose_db_version_meta"))
+	})
+}
@@ -0,0 +1,174 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+// ShadowAuto is the value of [WithShadow] that creates the shadow database from the template0
+// template. "auto:NAME" creates it from the template database NAME instead.
+const ShadowAuto = "auto"
+
+// verifyOnShadow applies the migrations up to the highest version in apply to the shadow database
+// set with [WithShadowDB] or [WithShadow], and returns an error wrapping [ErrShadowFailed] if any
+// of them fails or does not end up applied.
+func (p *Provider) verifyOnShadow(ctx context.Context, apply []*Migration) (retErr error) {
+	if len(apply) == 0 {
+		return nil
+	}
+	db, cleanup, err := p.openShadow(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrShadowFailed, err)
+	}
+	if db == nil {
+		return nil
+	}
+	defer func() {
+		retErr = multierr.Append(retErr, cleanup())
+	}()
+	var target int64
+	for _, m := range apply {
+		target = max(target, m.Version)
+	}
+	shadow := p.shadowProvider(db)
+	p.printf("verifying migrations up to version %d on the shadow database", target)
+	if _, err := shadow.up(ctx, false, target); err != nil {
+		return fmt.Errorf("%w: %w", ErrShadowFailed, err)
+	}
+	if p.cfg.disableVersioning {
+		return nil
+	}
+	statuses, err := shadow.status(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to get status: %w", ErrShadowFailed, err)
+	}
+	applied := make(map[int64]bool, len(statuses))
+	for _, s := range statuses {
+		applied[s.Source.Version] = s.State == StateApplied
+	}
+	for _, m := range apply {
+		if !applied[m.Version] {
+			return fmt.Errorf("%w: migration %s is not applied", ErrShadowFailed, m.ref())
+		}
+	}
+	return nil
+}
+
+// shadowProvider returns a provider for the shadow database with the same migrations and store as
+// p. Options with effects outside the database, such as locking, backups, approval, pacing,
+// statement middleware and notifiers, are left out, so only the migrations themselves run.
+func (p *Provider) shadowProvider(db *sql.DB) *Provider {
+	cfg := p.cfg
+	cfg.shadowDB, cfg.shadowDSN, cfg.shadowServer = nil, "", ""
+	cfg.lockEnabled, cfg.sessionLocker = false, nil
+	cfg.approval, cfg.window, cfg.waitForWindow = nil, nil, false
+	cfg.pause, cfg.pacing, cfg.throttler = 0, nil, nil
+	cfg.slowMigration, cfg.cancelSlowMigrations = nil, false
+	cfg.explainDML = false
+	cfg.backuper = nil
+	cfg.middleware, cfg.statementTags, cfg.statementLog = nil, false, nil
+	cfg.notifier, cfg.failureNotifier = nil, nil
+	return &Provider{
+		db:          db,
+		dialect:     p.dialect,
+		store:       p.store,
+		fsys:        p.fsys,
+		cfg:         cfg,
+		migrations:  p.migrations,
+		repeatables: p.repeatables,
+	}
+}
+
+// openShadow returns the shadow database, or nil if there is none, and a function that releases
+// it. A shadow database created from a template is dropped on release.
+func (p *Provider) openShadow(ctx context.Context) (*sql.DB, func() error, error) {
+	noop := func() error { return nil }
+	switch {
+	case p.cfg.shadowDB != nil:
+		return p.cfg.shadowDB, noop, nil
+	case p.cfg.shadowDSN == "":
+		return nil, noop, nil
+	}
+	template, auto := parseShadowAuto(p.cfg.shadowDSN)
+	if !auto {
+		db := sql.OpenDB(dsnConnector{dsn: p.cfg.shadowDSN, driver: p.db.Driver()})
+		return db, db.Close, nil
+	}
+	name := "goose_shadow_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	dsn, err := shadowServerDSN(p.cfg.shadowServer, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	q := fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", quoteIdentifiers([]string{name}), quoteIdentifiers([]string{template}))
+	if _, err := p.db.ExecContext(ctx, q); err != nil {
+		return nil, nil, fmt.Errorf("failed to create shadow database from template %q: %w", template, err)
+	}
+	p.printf("created shadow database %s from template %s", name, template)
+	db := sql.OpenDB(dsnConnector{dsn: dsn, driver: p.db.Driver()})
+	return db, func() error {
+		var errs error
+		errs = multierr.Append(errs, db.Close())
+		// The run may have been canceled, but the shadow database must still be dropped.
+		q := "DROP DATABASE IF EXISTS " + quoteIdentifiers([]string{name})
+		if _, err := p.db.ExecContext(context.WithoutCancel(ctx), q); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to drop shadow database %s: %w", name, err))
+		}
+		return errs
+	}, nil
+}
+
+// parseShadowAuto returns the template database of a [ShadowAuto] value, and whether dsn is one.
+func parseShadowAuto(dsn string) (template string, ok bool) {
+	if dsn == ShadowAuto {
+		return "template0", true
+	}
+	template, ok = strings.CutPrefix(dsn, ShadowAuto+":")
+	return template, ok && template != ""
+}
+
+// shadowServerDSN returns the Postgres connection string server with the database replaced by
+// database. Both URL and key/value connection strings are supported.
+func shadowServerDSN(server, database string) (string, error) {
+	if strings.HasPrefix(server, "postgres://") || strings.HasPrefix(server, "postgresql://") {
+		u, err := url.Parse(server)
+		if err != nil {
+			return "", fmt.Errorf("invalid shadow server connection string: %w", err)
+		}
+		u.Path = "/" + database
+		return u.String(), nil
+	}
+	if server == "" {
+		return "", errors.New("shadow server connection string must not be empty")
+	}
+	// Later keys take precedence over earlier ones.
+	return server + " dbname=" + database, nil
+}
+
+// dsnConnector opens connections to dsn with the driver of the target database, so the shadow
+// database needs no driver name.
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	if dc, ok := c.driver.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(c.dsn)
+		if err != nil {
+			return nil, err
+		}
+		return connector.Connect(ctx)
+	}
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver { return c.driver }
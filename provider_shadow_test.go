@@ -0,0 +1,107 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/notify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShadowDB(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_users.sql":  newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER);\n"),
+		"00002_orders.sql": newMapFile("-- +goose Up\nCREATE TABLE orders (id INTEGER);\n"),
+	}
+	db, shadow := newDB(t), newDB(t)
+	newProvider := func() *goose.Provider {
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithShadowDB(shadow))
+		require.NoError(t, err)
+		return p
+	}
+	// UpByOne verifies only the next migration.
+	_, err := newProvider().UpByOne(ctx)
+	require.NoError(t, err)
+	require.True(t, tableExists(t, shadow, "users"))
+	require.False(t, tableExists(t, shadow, "orders"))
+	require.True(t, tableExists(t, db, "users"))
+
+	_, err = newProvider().Up(ctx)
+	require.NoError(t, err)
+	for _, conn := range []*sql.DB{db, shadow} {
+		version, err := getMaxVersionID(conn, goose.DefaultTablename)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, version)
+	}
+
+	// A failing migration never reaches the target database.
+	fsys["00003_items.sql"] = newMapFile("-- +goose Up\nCREATE TABLE items (id INTEGER);\n")
+	fsys["00004_bad.sql"] = newMapFile("-- +goose Up\nINSERT INTO missing VALUES (1);\n")
+	_, err = newProvider().Up(ctx)
+	require.ErrorIs(t, err, goose.ErrShadowFailed)
+	require.ErrorContains(t, err, "no such table: missing")
+	require.True(t, tableExists(t, shadow, "items"))
+	require.False(t, tableExists(t, db, "items"))
+	version, err := getMaxVersionID(db, goose.DefaultTablename)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, version)
+
+	// Migrations verified earlier are not run again on the shadow database.
+	delete(fsys, "00004_bad.sql")
+	_, err = newProvider().Up(ctx)
+	require.NoError(t, err)
+	require.True(t, tableExists(t, db, "items"))
+
+	// The shadow database may also be opened from a connection string with the target's driver.
+	path := filepath.Join(t.TempDir(), "shadow.db")
+	fsys["00005_tags.sql"] = newMapFile("-- +goose Up\nCREATE TABLE tags (id INTEGER);\n")
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithShadow(path))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	require.True(t, tableExists(t, db, "tags"))
+	opened, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { opened.Close() })
+	require.True(t, tableExists(t, opened, "tags"))
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithShadowDB(db))
+	require.Error(t, err)
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithShadowDB(nil))
+	require.Error(t, err)
+
+	t.Run("notifier", func(t *testing.T) {
+		// Only the run on the target database is notified.
+		var summaries, alerts []notify.Summary
+		notifier := notifierFunc(func(_ context.Context, s notify.Summary) error {
+			summaries = append(summaries, s)
+			return nil
+		})
+		failureNotifier := notifierFunc(func(_ context.Context, s notify.Summary) error {
+			alerts = append(alerts, s)
+			return nil
+		})
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithShadowDB(newDB(t)),
+			goose.WithNotifier(notifier), goose.WithFailureNotifier(failureNotifier))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, summaries, 1)
+
+		// A migration failing on the shadow database never touched the target: nobody is paged.
+		fsys := fstest.MapFS{"00001_bad.sql": newMapFile("-- +goose Up\nINSERT INTO missing VALUES (1);\n")}
+		p, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithShadowDB(newDB(t)),
+			goose.WithFailureNotifier(failureNotifier))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, goose.ErrShadowFailed)
+		require.Empty(t, alerts)
+	})
+}
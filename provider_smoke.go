@@ -0,0 +1,25 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// smokeTest runs the smoke tests of [WithSmokeTest] once the migrations of a run were applied, the
+// first failure vetoing the run with a [SmokeTestError].
+func (p *Provider) smokeTest(ctx context.Context, conn *sql.Conn, results []*MigrationResult) error {
+	if len(p.cfg.smokeTests) == 0 || len(results) == 0 {
+		return nil
+	}
+	var err error
+	for _, fn := range p.cfg.smokeTests {
+		if err = fn(ctx, p.db, results); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		return nil
+	}
+	return &SmokeTestError{Applied: results, Err: fmt.Errorf("%w: %w", ErrSmokeTestFailed, err)}
+}
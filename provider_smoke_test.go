@@ -0,0 +1,67 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmokeTest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER, email TEXT);\n-- +goose Down\nDROP TABLE users;\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nALTER TABLE users RENAME COLUMN email TO mail;\n-- +goose Down\nALTER TABLE users RENAME COLUMN mail TO email;\n"),
+		"00003_c.sql": newMapFile("-- +goose Up\nCREATE TABLE orders (id INTEGER);\n-- +goose Down\nDROP TABLE orders;\n"),
+	}
+	// The application still selects the renamed column.
+	var calls int
+	smokeTest := func(ctx context.Context, db *sql.DB, applied []*goose.MigrationResult) error {
+		calls++
+		_, err := db.ExecContext(ctx, "SELECT id, email FROM users")
+		return err
+	}
+	for _, rollback := range []bool{false, true} {
+		calls = 0
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithLogger(&bufferLogger{}),
+			goose.WithSmokeTest(smokeTest), goose.WithSmokeTestRollback(rollback))
+		require.NoError(t, err)
+		_, err = p.UpTo(ctx, 1)
+		require.NoError(t, err)
+		_, err = p.UpTo(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, calls)
+
+		_, upErr := p.Up(ctx)
+		require.ErrorIs(t, upErr, goose.ErrSmokeTestFailed)
+		require.ErrorContains(t, upErr, "smoke test failed: ")
+		require.ErrorContains(t, upErr, "no such column: email")
+		var smokeErr *goose.SmokeTestError
+		require.ErrorAs(t, upErr, &smokeErr)
+		require.Len(t, smokeErr.Applied, 2)
+		version, err := p.GetDBVersion(ctx)
+		require.NoError(t, err)
+		var rollbackErr *goose.RollbackError
+		if !rollback {
+			require.False(t, errors.As(upErr, &rollbackErr))
+			require.EqualValues(t, 3, version)
+			continue
+		}
+		require.ErrorAs(t, upErr, &rollbackErr)
+		require.ErrorContains(t, rollbackErr, "; rolled back 2 migrations from version 3 to 1")
+		require.Len(t, rollbackErr.Report.RolledBack, 2)
+		require.EqualValues(t, 3, rollbackErr.Report.RolledBack[0].Source.Version)
+		require.NoError(t, rollbackErr.Report.Err)
+		require.EqualValues(t, 1, version)
+		require.False(t, tableExists(t, db, "orders"))
+		_, err = db.ExecContext(ctx, "SELECT id, email FROM users")
+		require.NoError(t, err)
+	}
+}
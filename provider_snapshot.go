@@ -0,0 +1,219 @@
+package goose
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+const (
+	// snapshotKeyPrefix is the prefix of the metadata key that records the snapshot of a table
+	// taken before a version was applied, followed by the table name, e.g., "snapshot:roles".
+	snapshotKeyPrefix = "snapshot:"
+	// maxSnapshotRows is the most rows a table may have to be snapshotted. Snapshots are meant
+	// for small lookup tables and are stored in the metadata table.
+	maxSnapshotRows = 1000
+)
+
+// tableSnapshot is the contents of a table, as recorded in the metadata table.
+type tableSnapshot struct {
+	Columns []string          `json:"columns"`
+	Rows    [][]snapshotValue `json:"rows"`
+}
+
+// snapshotValue is a single value of a snapshot, tagged with its kind so it is restored with the
+// same Go type it was read with.
+type snapshotValue struct {
+	Kind  string `json:"k"`
+	Value string `json:"v,omitempty"`
+}
+
+// parseSnapshots returns the tables declared by snapshot directives, in the order they appear.
+func parseSnapshots(directives []sqlparser.Directive) ([]string, error) {
+	var tables []string
+	for _, d := range directives {
+		if d.Name != sqlparser.DirectiveSnapshot {
+			continue
+		}
+		names := strings.FieldsFunc(d.Value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		if len(names) == 0 {
+			return nil, fmt.Errorf("invalid %s directive: must list at least one table", sqlparser.DirectiveSnapshot)
+		}
+		for _, name := range names {
+			if strings.Contains(name, ";") {
+				return nil, fmt.Errorf("invalid snapshot table name: %q", name)
+			}
+		}
+		tables = append(tables, names...)
+	}
+	return tables, nil
+}
+
+// takeSnapshots reads the tables m declares to snapshot, before m is applied. The snapshots are
+// recorded with the version once it is applied, see syncMetadata.
+func (p *Provider) takeSnapshots(ctx context.Context, db database.DBTxConn, m *Migration) error {
+	for _, table := range m.sql.Snapshot {
+		snapshot, err := readSnapshot(ctx, db, table)
+		if err != nil {
+			return fmt.Errorf("failed to snapshot table %s: %w", table, err)
+		}
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return fmt.Errorf("failed to encode snapshot of table %s: %w", table, err)
+		}
+		if p.snapshots == nil {
+			p.snapshots = make(map[int64]map[string]string)
+		}
+		if p.snapshots[m.Version] == nil {
+			p.snapshots[m.Version] = make(map[string]string)
+		}
+		p.snapshots[m.Version][table] = string(data)
+	}
+	return nil
+}
+
+// restoreSnapshots replaces the contents of the tables m declares to snapshot with the snapshots
+// recorded when m was applied, after m was rolled back.
+func (p *Provider) restoreSnapshots(ctx context.Context, db database.DBTxConn, m *Migration) error {
+	metadata, err := p.store.ListMetadata(ctx, db)
+	if err != nil {
+		return err
+	}
+	recorded := make(map[string]string)
+	for _, r := range metadata {
+		if r.Version != m.Version {
+			continue
+		}
+		if table, ok := strings.CutPrefix(r.Key, snapshotKeyPrefix); ok {
+			recorded[table] = r.Value
+		}
+	}
+	for _, table := range m.sql.Snapshot {
+		data, ok := recorded[table]
+		if !ok {
+			return fmt.Errorf("no snapshot of table %s recorded for version %d", table, m.Version)
+		}
+		var snapshot tableSnapshot
+		if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+			return fmt.Errorf("failed to decode snapshot of table %s: %w", table, err)
+		}
+		if err := p.writeSnapshot(ctx, db, table, &snapshot); err != nil {
+			return fmt.Errorf("failed to restore table %s: %w", table, err)
+		}
+		p.printf("restored %d rows of table %s", len(snapshot.Rows), table)
+	}
+	return nil
+}
+
+func readSnapshot(ctx context.Context, db database.DBTxConn, table string) (_ *tableSnapshot, retErr error) {
+	rows, err := db.QueryContext(ctx, "SELECT * FROM "+table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil && retErr == nil {
+			retErr = err
+		}
+	}()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &tableSnapshot{Columns: columns, Rows: [][]snapshotValue{}}
+	for rows.Next() {
+		if len(snapshot.Rows) == maxSnapshotRows {
+			return nil, fmt.Errorf("table has more than %d rows", maxSnapshotRows)
+		}
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		row := make([]snapshotValue, len(values))
+		for i, v := range values {
+			if row[i], err = encodeSnapshotValue(v); err != nil {
+				return nil, fmt.Errorf("column %s: %w", columns[i], err)
+			}
+		}
+		snapshot.Rows = append(snapshot.Rows, row)
+	}
+	return snapshot, rows.Err()
+}
+
+func (p *Provider) writeSnapshot(ctx context.Context, db database.DBTxConn, table string, snapshot *tableSnapshot) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+		return err
+	}
+	placeholders := make([]string, len(snapshot.Columns))
+	for i := range placeholders {
+		placeholders[i] = "?"
+		if p.dialect == DialectPostgres || p.dialect == DialectRedshift {
+			placeholders[i] = "$" + strconv.Itoa(i+1)
+		}
+	}
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		table, strings.Join(snapshot.Columns, ", "), strings.Join(placeholders, ", "))
+	for _, row := range snapshot.Rows {
+		args := make([]any, len(row))
+		for i, v := range row {
+			var err error
+			if args[i], err = decodeSnapshotValue(v); err != nil {
+				return fmt.Errorf("column %s: %w", snapshot.Columns[i], err)
+			}
+		}
+		if _, err := db.ExecContext(ctx, q, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeSnapshotValue(v any) (snapshotValue, error) {
+	switch v := v.(type) {
+	case nil:
+		return snapshotValue{Kind: "null"}, nil
+	case int64:
+		return snapshotValue{Kind: "int", Value: strconv.FormatInt(v, 10)}, nil
+	case float64:
+		return snapshotValue{Kind: "float", Value: strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case bool:
+		return snapshotValue{Kind: "bool", Value: strconv.FormatBool(v)}, nil
+	case string:
+		return snapshotValue{Kind: "string", Value: v}, nil
+	case []byte:
+		return snapshotValue{Kind: "bytes", Value: base64.StdEncoding.EncodeToString(v)}, nil
+	case time.Time:
+		return snapshotValue{Kind: "time", Value: v.Format(time.RFC3339Nano)}, nil
+	}
+	return snapshotValue{}, fmt.Errorf("unsupported value type %T", v)
+}
+
+func decodeSnapshotValue(v snapshotValue) (any, error) {
+	switch v.Kind {
+	case "null":
+		return nil, nil
+	case "int":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(v.Value, 64)
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "string":
+		return v.Value, nil
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	}
+	return nil, fmt.Errorf("unknown value kind %q", v.Kind)
+}
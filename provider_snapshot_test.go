@@ -0,0 +1,77 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE roles (id INTEGER, name TEXT, weight REAL);\nINSERT INTO roles VALUES (1, 'admin', 1.5), (2, 'viewer', NULL);\n"),
+		"00002_b.sql": newMapFile("-- +goose snapshot roles\n-- +goose Up\nUPDATE roles SET name = upper(name);\nINSERT INTO roles VALUES (3, 'EDITOR', 2);\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.NoError(t, err)
+	roles := func() []string {
+		rows, err := db.QueryContext(ctx, "SELECT id, name, weight FROM roles ORDER BY id")
+		require.NoError(t, err)
+		defer rows.Close()
+		var out []string
+		for rows.Next() {
+			var id int
+			var name string
+			var weight sql.NullFloat64
+			require.NoError(t, rows.Scan(&id, &name, &weight))
+			out = append(out, fmt.Sprintf("%d:%s:%v", id, name, weight))
+		}
+		require.NoError(t, rows.Err())
+		return out
+	}
+	require.Equal(t, []string{"1:ADMIN:{1.5 true}", "2:VIEWER:{0 false}", "3:EDITOR:{2 true}"}, roles())
+
+	// Rolling back restores the table without a Down section, and removes the snapshot.
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"1:admin:{1.5 true}", "2:viewer:{0 false}"}, roles())
+	var n int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM goose_db_version_meta WHERE meta_key LIKE 'snapshot:%'").Scan(&n))
+	require.Zero(t, n)
+
+	t.Run("missing_snapshot", func(t *testing.T) {
+		// The version was applied before it declared a snapshot.
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE roles (id INTEGER);\n"),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.NoError(t, err)
+		fsys["00001_a.sql"] = newMapFile("-- +goose snapshot roles\n-- +goose Up\nCREATE TABLE roles (id INTEGER);\n")
+		p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+		require.NoError(t, err)
+		_, err = p.Down(ctx)
+		require.ErrorContains(t, err, "no snapshot of table roles recorded for version 1")
+	})
+	t.Run("no_transaction", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose snapshot roles\n-- +goose NO TRANSACTION\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, "snapshot requires a transaction")
+	})
+}
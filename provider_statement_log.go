@@ -0,0 +1,35 @@
+package goose
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// writeStatementLog appends an entry for a statement of m to the log set with [WithStatementLog].
+// Each entry is a comment line with the start time, direction, migration, duration and outcome,
+// followed by the statement as executed. Failed statements are commented out, so the log can be
+// replayed as is.
+func (p *Provider) writeStatementLog(m *Migration, direction bool, query string, start time.Time, execErr error) error {
+	outcome := "ok"
+	if execErr != nil {
+		outcome = "error: " + firstLine(execErr.Error())
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- %s %s version=%d source=%s duration=%s %s\n",
+		start.UTC().Format(time.RFC3339Nano), sqlparser.FromBool(direction), m.Version, path.Base(m.Source),
+		time.Since(start).Round(time.Microsecond), outcome)
+	query = strings.TrimRight(query, "\n")
+	if execErr != nil {
+		query = "-- " + strings.ReplaceAll(query, "\n", "\n-- ")
+	}
+	b.WriteString(query)
+	b.WriteString("\n\n")
+	if _, err := p.cfg.statementLog.Write([]byte(b.String())); err != nil {
+		return fmt.Errorf("failed to write statement log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package goose_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementLog(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_users.sql": newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER);\n-- +goose Down\nDROP TABLE users;\n"),
+		"00002_bad.sql":   newMapFile("-- +goose Up\nINSERT INTO users\nVALUES (1);\nINSERT INTO missing VALUES (1);\n"),
+	}
+	var buf bytes.Buffer
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStatementLog(&buf))
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.Error(t, err)
+
+	entries := strings.Split(strings.TrimSuffix(buf.String(), "\n\n"), "\n\n")
+	require.Len(t, entries, 3)
+	entry := regexp.MustCompile(`^-- \S+ up version=(\d+) source=(\S+) duration=\S+ (ok|error: .+)\n`)
+	for i, want := range []struct {
+		version, source, outcome, statement string
+	}{
+		{"1", "00001_users.sql", "ok", "CREATE TABLE users (id INTEGER);"},
+		{"2", "00002_bad.sql", "ok", "INSERT INTO users\nVALUES (1);"},
+		{"2", "00002_bad.sql", "error: SQL logic error: no such table: missing (1)", "-- INSERT INTO missing VALUES (1);"},
+	} {
+		match := entry.FindStringSubmatch(entries[i])
+		require.NotNil(t, match, entries[i])
+		require.Equal(t, []string{want.version, want.source, want.outcome}, match[1:])
+		require.Equal(t, want.statement, entries[i][len(match[0]):])
+	}
+
+	// Failing to write the log fails the migration.
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStatementLog(failingWriter{}))
+	require.NoError(t, err)
+	_, err = p.DownTo(ctx, 0)
+	require.ErrorContains(t, err, "failed to write statement log")
+	require.True(t, tableExists(t, db, "users"))
+
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithStatementLog(nil))
+	require.Error(t, err)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
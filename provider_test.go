@@ -1,11 +1,14 @@
 package goose_test
 
 import (
+	"context"
 	"database/sql"
 	"io/fs"
 	"path/filepath"
+	"regexp"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/pressly/goose/v3"
 	"github.com/stretchr/testify/require"
@@ -75,7 +78,97 @@ ALTER TABLE my_foo RENAME TO foo;
 `
 )
 
+func TestProviderRegistryScope(t *testing.T) {
+	t.Cleanup(goose.SnapshotGlobalMigrations())
+
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"001_foo.sql": {Data: []byte(`-- +goose Up`)},
+	}
+	err := goose.SetGlobalMigrations("", goose.NewGoMigration(2, nil, nil))
+	require.NoError(t, err)
+	err = goose.SetGlobalMigrations("tenant", goose.NewGoMigration(3, nil, nil))
+	require.NoError(t, err)
+
+	// Default scope.
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	sources := p.ListSources()
+	require.Len(t, sources, 2)
+	require.EqualValues(t, 2, sources[1].Version)
+	// Named scope.
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRegistryScope("tenant"))
+	require.NoError(t, err)
+	sources = p.ListSources()
+	require.Len(t, sources, 2)
+	require.EqualValues(t, 3, sources[1].Version)
+	// Unknown scope contributes no Go migrations.
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithRegistryScope("unknown"))
+	require.NoError(t, err)
+	require.Len(t, p.ListSources(), 1)
+}
+
+func TestProviderFilenameParser(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"V001__create_a.sql":   {Data: []byte("-- +goose Up\nCREATE TABLE a (id INTEGER);\n")},
+		"V002__create_b.sql":   {Data: []byte("-- +goose Up\nCREATE TABLE b (id INTEGER);\n")},
+		"00003_not_flyway.sql": {Data: []byte("-- +goose Up\nCREATE TABLE c (id INTEGER);\n")},
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys,
+		goose.WithFilenameParser(goose.RegexpFilenameParser(regexp.MustCompile(`^V(\d+)__`))),
+	)
+	require.NoError(t, err)
+	sources := p.ListSources()
+	require.Len(t, sources, 2)
+	require.Equal(t, &goose.Source{Type: goose.TypeSQL, Path: "V002__create_b.sql", Version: 2}, sources[1])
+	res, err := p.Up(ctx)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	// Backslash paths, as on Windows, are parsed like NumericComponent parses them.
+	version, err := goose.RegexpFilenameParser(regexp.MustCompile(`^V(\d+)__`))(`C:\app\migrations\V042__add_users.sql`)
+	require.NoError(t, err)
+	require.EqualValues(t, 42, version)
+
+	// Versions would be ambiguous if parsers returned zero for files they accept.
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithFilenameParser(func(string) (int64, error) {
+		return 0, nil
+	}))
+	require.ErrorContains(t, err, "invalid version 0")
+	_, err = goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithFilenameParser(nil))
+	require.Error(t, err)
+}
+
 func TestPartialErrorUnwrap(t *testing.T) {
 	err := &goose.PartialError{Err: goose.ErrNoCurrentVersion}
 	require.ErrorIs(t, err, goose.ErrNoCurrentVersion)
 }
+
+func TestFilterStatus(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	statuses := []*goose.MigrationStatus{
+		{Source: newSource(goose.TypeSQL, "001_a.sql", 1), State: goose.StateApplied, AppliedAt: now.Add(-2 * time.Hour)},
+		{Source: newSource(goose.TypeSQL, "002_b.sql", 2), State: goose.StateApplied, AppliedAt: now.Add(-time.Minute)},
+		{Source: newSource(goose.TypeSQL, "003_c.sql", 3), State: goose.StatePending},
+		{Source: newSource(goose.TypeSQL, "004_d.sql", 4), State: goose.StatePending},
+	}
+	versions := func(statuses []*goose.MigrationStatus) []int64 {
+		var versions []int64
+		for _, s := range statuses {
+			versions = append(versions, s.Source.Version)
+		}
+		return versions
+	}
+	require.Equal(t, []int64{1, 2, 3, 4}, versions(goose.FilterStatus(statuses, goose.StatusFilter{})))
+	require.Equal(t, []int64{3, 4}, versions(goose.FilterStatus(statuses, goose.StatusFilter{OnlyPending: true})))
+	require.Equal(t, []int64{4}, versions(goose.FilterStatus(statuses, goose.StatusFilter{OnlyPending: true, Last: 1})))
+	require.Equal(t, []int64{3, 4}, versions(goose.FilterStatus(statuses, goose.StatusFilter{Last: 2})))
+	require.Equal(t, []int64{2}, versions(goose.FilterStatus(statuses, goose.StatusFilter{Since: now.Add(-time.Hour)})))
+	require.Equal(t, []int64{1, 2, 3, 4}, versions(goose.FilterStatus(statuses, goose.StatusFilter{Last: 10})))
+}
@@ -3,7 +3,10 @@ package goose
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"go.uber.org/multierr"
 )
 
 // MigrationType is the type of migration.
@@ -19,9 +22,9 @@ const (
 // The Path field may be empty if the migration was registered manually. This is typically the case
 // for Go migrations registered using the [WithGoMigration] option.
 type Source struct {
-	Type    MigrationType
-	Path    string
-	Version int64
+	Type    MigrationType `json:"type"`
+	Path    string        `json:"path"`
+	Version int64         `json:"version"`
 }
 
 // MigrationResult is the result of a single migration operation.
@@ -29,9 +32,22 @@ type MigrationResult struct {
 	Source    *Source
 	Duration  time.Duration
 	Direction string
+	// Title is the title of the migration, or empty, see [MigrationStatus.Title].
+	Title string
+	// Owner is the team owning the migration, or empty, see [MigrationStatus.Owner].
+	Owner string
 	// Empty indicates no action was taken during the migration, but it was still versioned. For
 	// SQL, it means no statements; for Go, it's a nil function.
 	Empty bool
+	// Tombstone indicates the migration was retired with a "-- +goose tombstone" directive. It was
+	// versioned, but no statements were run. Empty is always true for tombstones.
+	Tombstone bool
+	// Repeatable indicates a repeatable migration, see [WithRepeatable]. Repeatable migrations are
+	// not versioned and Source.Version is always 0.
+	Repeatable bool
+	// Backup is where the affected tables were backed up before a destructive migration was
+	// applied, see [WithBackup]. It is empty if no backup was taken.
+	Backup string
 	// Error is only set if the migration failed.
 	Error error
 }
@@ -85,7 +101,134 @@ const (
 
 // MigrationStatus represents the status of a single migration.
 type MigrationStatus struct {
-	Source    *Source
-	State     State
-	AppliedAt time.Time
+	Source    *Source   `json:"source"`
+	State     State     `json:"state"`
+	AppliedAt time.Time `json:"applied_at"`
+	// Labels are the run labels recorded when the migration was applied, see [WithRunLabels].
+	Labels map[string]string `json:"labels,omitempty"`
+	// Title is the title declared by a SQL migration with a "-- +goose title" directive, or the
+	// description of a Go migration set with [WithDescription]. It is empty if neither is set.
+	Title string `json:"title,omitempty"`
+	// Owner is the team owning the migration, declared by a SQL migration with a "-- +goose owner"
+	// directive, or set for a Go migration with [WithOwner]. It is empty if neither is set.
+	Owner string `json:"owner,omitempty"`
+	// Hints are the resource hints declared by the migration, or nil, see [Hints].
+	Hints *Hints `json:"hints,omitempty"`
+	// Skipped is set if the migration is pending because a run deliberately skipped it, see
+	// [SkipRecord].
+	Skipped *SkipRecord `json:"skipped,omitempty"`
+}
+
+// RollbackReport describes the rollback of the migrations applied by a failed run, see
+// [RollbackError].
+type RollbackReport struct {
+	// FromVersion is the version the failed run left the database at, and ToVersion the version
+	// after the rollback, the version before the run if all migrations were rolled back.
+	FromVersion int64
+	ToVersion   int64
+	// RolledBack are the migrations rolled back, newest first.
+	RolledBack []*MigrationResult
+	// Kept are the migrations applied by the run that were left applied, newest first: repeatable
+	// migrations, the first migration without a Down section and the ones before it, and those
+	// left after a migration failed to roll back.
+	Kept []*Source
+	// Err is the error that stopped the rollback, if any.
+	Err error
+}
+
+// SkipRecord is recorded in the metadata table when a run deliberately skips a pending migration,
+// with [WithSkipVersions], [WithExcludeVersions] or [WithExcludeNames], or because a precondition
+// with on-fail=skip did not hold, to tell it apart from a migration that was never attempted. It
+// is cleared once the migration is applied.
+type SkipRecord struct {
+	Reason    string    `json:"reason"`
+	SkippedAt time.Time `json:"skipped_at"`
+}
+
+// FreezeRecord is recorded in the metadata table by [Provider.Freeze] to stop runs from applying
+// migrations during an incident, until [Provider.Unfreeze] lifts it.
+type FreezeRecord struct {
+	// Scope is the frozen scope, see [WithRegistryScope], or empty if all scopes are frozen.
+	Scope    string    `json:"scope"`
+	Reason   string    `json:"reason"`
+	FrozenAt time.Time `json:"frozen_at"`
+}
+
+// SkippedMigration is a pending migration with a [SkipRecord].
+type SkippedMigration struct {
+	Source *Source `json:"source"`
+	SkipRecord
+}
+
+// VerifyReport is the combined result of verifying the checksums of applied migrations, see
+// [Provider.VerifyReport]. Migrations are listed in version order.
+type VerifyReport struct {
+	// Verified are the applied migrations whose checksum matches the recorded checksum.
+	Verified []*Source `json:"verified"`
+	// Modified are the applied migrations that were modified after being applied.
+	Modified []*Source `json:"modified"`
+	// Skipped are the applied migrations that cannot be verified: tombstones and Go migrations
+	// without an embedded checksum.
+	Skipped []*Source `json:"skipped"`
+	// Failed are the applied migrations that could not be read or parsed.
+	Failed []*VerifyFailure `json:"failed"`
+	// Withheld are the pending migrations deliberately skipped by earlier runs. They are not
+	// verified and do not cause an error.
+	Withheld []*SkippedMigration `json:"withheld"`
+}
+
+// VerifyFailure is an applied migration whose checksum could not be computed.
+type VerifyFailure struct {
+	Source *Source `json:"source"`
+	Err    error   `json:"-"`
+}
+
+// Err returns nil if no migration was modified or failed to be read. Otherwise, it returns the
+// errors of the failed migrations combined with an error wrapping [ErrChecksumMismatch] that lists
+// the modified migrations.
+func (r *VerifyReport) Err() error {
+	var err error
+	for _, f := range r.Failed {
+		err = multierr.Append(err, f.Err)
+	}
+	if len(r.Modified) > 0 {
+		refs := make([]string, 0, len(r.Modified))
+		for _, s := range r.Modified {
+			refs = append(refs, fmt.Sprintf("(type:%s,version:%d)", s.Type, s.Version))
+		}
+		err = multierr.Append(err, fmt.Errorf("%w: modified after being applied: %s", ErrChecksumMismatch, strings.Join(refs, ", ")))
+	}
+	return err
+}
+
+// StatusFilter narrows down a list of migration statuses, see [FilterStatus]. The zero value
+// matches all migrations. When multiple fields are set, a migration must match all of them.
+type StatusFilter struct {
+	// OnlyPending keeps only pending migrations.
+	OnlyPending bool
+	// Since keeps only migrations applied at or after the given time. Pending migrations are
+	// excluded when Since is set.
+	Since time.Time
+	// Last keeps only the last N migrations, by version, after all other filters are applied. Zero
+	// means no limit.
+	Last int
+}
+
+// FilterStatus returns the statuses that match the filter, preserving their order. The input is
+// expected to be ordered by version, as returned by [Provider.Status].
+func FilterStatus(statuses []*MigrationStatus, filter StatusFilter) []*MigrationStatus {
+	filtered := make([]*MigrationStatus, 0, len(statuses))
+	for _, s := range statuses {
+		if filter.OnlyPending && s.State != StatePending {
+			continue
+		}
+		if !filter.Since.IsZero() && (s.State != StateApplied || s.AppliedAt.Before(filter.Since)) {
+			continue
+		}
+		filtered = append(filtered, s)
+	}
+	if filter.Last > 0 && len(filtered) > filter.Last {
+		filtered = filtered[len(filtered)-filter.Last:]
+	}
+	return filtered
 }
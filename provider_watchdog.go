@@ -0,0 +1,64 @@
+package goose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+// parseExpectDuration returns the duration declared by an expect-duration directive, or zero if
+// there is none.
+func parseExpectDuration(directives []sqlparser.Directive) (time.Duration, error) {
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveExpectDuration)
+	if !ok {
+		return 0, nil
+	}
+	expected, err := time.ParseDuration(d.Value)
+	if err != nil || expected <= 0 {
+		return 0, fmt.Errorf("invalid %s directive %q: must be a positive duration, e.g., 30s",
+			sqlparser.DirectiveExpectDuration, d.Value)
+	}
+	return expected, nil
+}
+
+// expectedDuration returns how long m is expected to run, or zero if there is no expectation.
+func expectedDuration(m *Migration) time.Duration {
+	if m.Type == TypeSQL {
+		return m.sql.ExpectDuration
+	}
+	return m.ExpectDuration
+}
+
+// watchDuration starts a watchdog for m, which warns once m runs longer than its expected duration
+// and, with [WithCancelSlowMigrations], cancels the returned context. The returned function must
+// be called with the result of the migration; it stops the watchdog and, if the migration failed
+// because it was cancelled, wraps the error with [ErrDurationExceeded].
+func (p *Provider) watchDuration(ctx context.Context, m *Migration) (context.Context, func(error) error) {
+	expected := expectedDuration(m)
+	if expected <= 0 {
+		return ctx, func(err error) error { return err }
+	}
+	parent := ctx
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(expected, func() {
+		p.printf("migration %s is running longer than its expected duration of %s", m.ref(), expected)
+		if p.cfg.slowMigration != nil {
+			p.cfg.slowMigration(parent, m, expected)
+		}
+		if p.cfg.cancelSlowMigrations {
+			cancel(fmt.Errorf("%w of %s", ErrDurationExceeded, expected))
+		}
+	})
+	return ctx, func(err error) error {
+		timer.Stop()
+		cause := context.Cause(ctx)
+		cancel(nil)
+		if err != nil && errors.Is(cause, ErrDurationExceeded) {
+			return fmt.Errorf("%w: %w", cause, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,89 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlowMigration(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sleepTx := func(d time.Duration) func(context.Context, *sql.Tx) error {
+		return func(ctx context.Context, tx *sql.Tx) error {
+			select {
+			case <-time.After(d):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	t.Run("warn", func(t *testing.T) {
+		var reported []int64
+		var expectations []time.Duration
+		var mu sync.Mutex
+		slow := func(ctx context.Context, m *goose.Migration, expected time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, m.Version)
+			expectations = append(expectations, expected)
+		}
+		m1 := goose.NewGoMigration(1, &goose.GoFunc{RunTx: sleepTx(50 * time.Millisecond)}, nil)
+		m1.ExpectDuration = 10 * time.Millisecond
+		m2 := goose.NewGoMigration(2, &goose.GoFunc{RunTx: sleepTx(0)}, nil)
+		m2.ExpectDuration = time.Minute
+		m3 := goose.NewGoMigration(3, &goose.GoFunc{RunTx: sleepTx(20 * time.Millisecond)}, nil)
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), nil,
+			goose.WithGoMigrations(m1, m2, m3),
+			goose.WithSlowMigration(slow),
+		)
+		require.NoError(t, err)
+		res, err := p.Up(ctx)
+		require.NoError(t, err)
+		require.Len(t, res, 3)
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, []int64{1}, reported)
+		require.Equal(t, []time.Duration{10 * time.Millisecond}, expectations)
+	})
+	t.Run("cancel", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+			"00002_backfill.sql": newMapFile(`-- +goose expect-duration 1ms
+-- +goose Up
+CREATE TABLE b (id INTEGER);
+INSERT INTO b WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c WHERE x < 100000000) SELECT x FROM c;
+`),
+		}
+		db := newDB(t)
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys, goose.WithCancelSlowMigrations(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorIs(t, err, goose.ErrDurationExceeded)
+		require.ErrorContains(t, err, "expected duration of 1ms")
+		var partialErr *goose.PartialError
+		require.ErrorAs(t, err, &partialErr)
+		require.Len(t, partialErr.Applied, 1)
+		// The migration ran in a transaction, which was rolled back.
+		require.False(t, tableExists(t, db, "b"))
+	})
+	t.Run("invalid", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"00001_a.sql": newMapFile("-- +goose expect-duration soon\n-- +goose Up\nSELECT 1;\n"),
+		}
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys)
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `invalid expect-duration directive "soon"`)
+		_, err = goose.NewProvider(goose.DialectSQLite3, newDB(t), newFsys(), goose.WithSlowMigration(nil))
+		require.ErrorContains(t, err, "slow migration func must not be nil")
+	})
+}
@@ -3,6 +3,7 @@ package goose
 import (
 	"context"
 	"database/sql"
+	"fmt"
 )
 
 // Redo rolls back the most recently applied migration, then runs it again.
@@ -17,7 +18,7 @@ func RedoContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFun
 	for _, f := range opts {
 		f(option)
 	}
-	migrations, err := CollectMigrations(option.scope, dir, minVersion, maxVersion)
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
 	if err != nil {
 		return err
 	}
@@ -40,6 +41,9 @@ func RedoContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFun
 		return err
 	}
 	current.noVersioning = option.noVersioning
+	if err := checkOptionFreeze(ctx, db, option); err != nil {
+		return err
+	}
 
 	if err := current.DownContext(ctx, db); err != nil {
 		return err
@@ -49,3 +53,98 @@ func RedoContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFun
 	}
 	return nil
 }
+
+// RedoLast rolls back the n most recently applied migrations, then runs them again in their
+// original order.
+func RedoLast(db *sql.DB, dir string, n int, opts ...OptionsFunc) error {
+	ctx := context.Background()
+	return RedoLastContext(ctx, db, dir, n, opts...)
+}
+
+// RedoLastContext rolls back the n most recently applied migrations, then runs them again in their
+// original order.
+func RedoLastContext(ctx context.Context, db *sql.DB, dir string, n int, opts ...OptionsFunc) error {
+	if n < 1 {
+		return fmt.Errorf("invalid count: must be greater than zero: %d", n)
+	}
+	return redo(ctx, db, dir, n, 0, opts...)
+}
+
+// RedoTo rolls back migrations down to, but not including, the given version, then runs them again
+// in their original order.
+func RedoTo(db *sql.DB, dir string, version int64, opts ...OptionsFunc) error {
+	ctx := context.Background()
+	return RedoToContext(ctx, db, dir, version, opts...)
+}
+
+// RedoToContext rolls back migrations down to, but not including, the given version, then runs
+// them again in their original order.
+//
+// Each migration runs in its own transaction. Use [Provider.RedoTo] to redo the whole set in a
+// single transaction on dialects that support it.
+func RedoToContext(ctx context.Context, db *sql.DB, dir string, version int64, opts ...OptionsFunc) error {
+	if version < 0 {
+		return fmt.Errorf("invalid version: must be a valid number or zero: %d", version)
+	}
+	return redo(ctx, db, dir, 0, version, opts...)
+}
+
+// redo rolls back either the count most recently applied migrations, or, if count is zero, all
+// migrations after version. The rolled back migrations are then run again.
+func redo(ctx context.Context, db *sql.DB, dir string, count int, version int64, opts ...OptionsFunc) error {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
+	if err != nil {
+		return err
+	}
+	if err := checkOptionFreeze(ctx, db, option); err != nil {
+		return err
+	}
+	done := func(n int) bool { return count > 0 && n == count }
+
+	var rolledBack Migrations
+	if option.noVersioning {
+		for i := len(migrations) - 1; i >= 0 && !done(len(rolledBack)); i-- {
+			current := migrations[i]
+			if current.Version <= version {
+				break
+			}
+			current.noVersioning = true
+			if err := current.DownContext(ctx, db); err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, current)
+		}
+	} else {
+		for !done(len(rolledBack)) {
+			currentVersion, err := GetDBVersionContext(ctx, db)
+			if err != nil {
+				return err
+			}
+			if currentVersion == 0 || currentVersion <= version {
+				break
+			}
+			current, err := migrations.Current(currentVersion)
+			if err != nil {
+				return fmt.Errorf("migration %v: %w", currentVersion, err)
+			}
+			if err := current.DownContext(ctx, db); err != nil {
+				return err
+			}
+			rolledBack = append(rolledBack, current)
+		}
+	}
+	if len(rolledBack) == 0 {
+		log.Printf("goose: no migrations to redo\n")
+		return nil
+	}
+	for i := len(rolledBack) - 1; i >= 0; i-- {
+		if err := rolledBack[i].UpContext(ctx, db); err != nil {
+			return err
+		}
+	}
+	return nil
+}
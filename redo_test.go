@@ -0,0 +1,51 @@
+package goose_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestRedoLastAndTo(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_redo.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	// Each up migration appends a row to the log table, so re-runs can be counted.
+	files := map[string]string{
+		"00001_log.sql": "-- +goose Up\nCREATE TABLE log (version INTEGER);\n-- +goose Down\nDROP TABLE log;\n",
+		"00002_a.sql":   "-- +goose Up\nINSERT INTO log VALUES (2);\n-- +goose Down\nSELECT 1;\n",
+		"00003_b.sql":   "-- +goose Up\nINSERT INTO log VALUES (3);\n-- +goose Down\nSELECT 1;\n",
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+	}
+	countRows := func() int {
+		var n int
+		require.NoError(t, db.QueryRow("SELECT count(*) FROM log").Scan(&n))
+		return n
+	}
+	require.NoError(t, goose.Up(db, dir))
+	require.Equal(t, 2, countRows())
+
+	require.NoError(t, goose.RedoLast(db, dir, 2))
+	require.Equal(t, 4, countRows())
+	require.NoError(t, goose.RedoTo(db, dir, 2))
+	require.Equal(t, 5, countRows())
+	ver, err := goose.GetDBVersion(db)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, ver)
+
+	// Redoing everything drops and recreates the log table.
+	require.NoError(t, goose.RedoTo(db, dir, 0))
+	require.Equal(t, 2, countRows())
+}
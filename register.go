@@ -25,7 +25,7 @@ func AddNamedMigrationContext(filename string, up, down GoMigrationContext, opts
 	}
 
 	if err := register(
-		mc.Scope,
+		mc,
 		filename,
 		true,
 		&GoFunc{RunTx: up, Mode: TransactionEnabled},
@@ -35,6 +35,37 @@ func AddNamedMigrationContext(filename string, up, down GoMigrationContext, opts
 	}
 }
 
+// GoMigrationConnContext is a Go migration func that is run outside a transaction on a single
+// connection and receives a context.
+type GoMigrationConnContext func(ctx context.Context, conn *sql.Conn) error
+
+// AddMigrationConnContext adds Go migrations that will be run outside transaction on a single
+// *sql.Conn. Unlike [AddMigrationNoTxContext], all statements are guaranteed to use the same
+// database session, e.g., for temporary tables, session variables or advisory locks.
+func AddMigrationConnContext(up, down GoMigrationConnContext, opts ...MigrationOption) {
+	_, filename, _, _ := runtime.Caller(1)
+	AddNamedMigrationConnContext(filename, up, down, opts...)
+}
+
+// AddNamedMigrationConnContext adds named Go migrations that will be run outside transaction on
+// a single *sql.Conn.
+func AddNamedMigrationConnContext(filename string, up, down GoMigrationConnContext, opts ...MigrationOption) {
+	var mc MigrationConfig
+	for _, opt := range opts {
+		opt(&mc)
+	}
+
+	if err := register(
+		mc,
+		filename,
+		false,
+		&GoFunc{RunConn: up, Mode: TransactionDisabled},
+		&GoFunc{RunConn: down, Mode: TransactionDisabled},
+	); err != nil {
+		panic(err)
+	}
+}
+
 // GoMigrationNoTxContext is a Go migration func that is run outside a transaction and receives a
 // context.
 type GoMigrationNoTxContext func(ctx context.Context, db *sql.DB) error
@@ -53,7 +84,7 @@ func AddNamedMigrationNoTxContext(filename string, up, down GoMigrationNoTxConte
 	}
 
 	if err := register(
-		mc.Scope,
+		mc,
 		filename,
 		false,
 		&GoFunc{RunDB: up, Mode: TransactionDisabled},
@@ -63,8 +94,14 @@ func AddNamedMigrationNoTxContext(filename string, up, down GoMigrationNoTxConte
 	}
 }
 
-func register(scope, filename string, useTx bool, up, down *GoFunc) error {
+func register(mc MigrationConfig, filename string, useTx bool, up, down *GoFunc) error {
+	scope := mc.Scope
+	filename = normalizePath(filename)
 	v, _ := NumericComponent(filename)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
 	if versionMap, ok := registeredGoMigrations[scope]; ok {
 		if existing, ok := versionMap[v]; ok {
 			return fmt.Errorf("failed to add migration %q: version %d conflicts with %q",
@@ -77,6 +114,12 @@ func register(scope, filename string, useTx bool, up, down *GoFunc) error {
 	// Add to global as a registered migration.
 	m := NewGoMigration(v, up, down)
 	m.Source = filename
+	m.Checksum = mc.Checksum
+	m.ExpectDuration = mc.ExpectDuration
+	m.Role = mc.Role
+	m.Description = mc.Description
+	m.Owner = mc.Owner
+	m.scope = scope
 	// We explicitly set transaction to maintain existing behavior. Both up and down may be nil, but
 	// we know based on the register function what the user is requesting.
 	m.UseTx = useTx
@@ -0,0 +1,268 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultRenameBatchSize is the number of rows copied per statement by the backfill step of a
+// [ColumnRename], if BatchSize is not set.
+const defaultRenameBatchSize = 1000
+
+// ColumnRename renames a column of a Postgres table without downtime, as a sequence of migrations
+// that are applied in separate deployments, see [RenameColumnSafely]:
+//
+//  1. Expand adds the new column, with the type and default of the old one, and a trigger that
+//     keeps both columns in sync on every insert and update. Deploy the application reading the
+//     old column and writing either.
+//  2. Backfill copies the old column into the new one for existing rows, in batches, each in its
+//     own transaction. Deploy the application reading and writing the new column only.
+//  3. Contract drops the trigger and the old column, and, if the old column was NOT NULL, makes the
+//     new column NOT NULL.
+//
+// Indexes, constraints other than NOT NULL, and views using the old column are not carried over.
+// Every step can be rolled back; rolling back Contract adds the old column back, nullable, and
+// copies the new column into it.
+type ColumnRename struct {
+	// Table is the name of the table, optionally qualified by its schema.
+	Table string
+	// From and To are the old and the new name of the column.
+	From, To string
+	// BatchSize is the number of rows copied per statement by Backfill. Defaults to 1000.
+	BatchSize int
+}
+
+// RenameColumnSafely returns the steps renaming column from of table to to without downtime. Each
+// step is a Go migration, registered with a version of its own, e.g., with [WithGoMigrations]:
+//
+//	rename := goose.RenameColumnSafely("users", "name", "full_name")
+//	provider, err := goose.NewProvider(goose.DialectPostgres, db, fsys, goose.WithGoMigrations(
+//		rename.Expand(20261014100000),
+//		rename.Backfill(20261014100100),
+//	))
+//
+// and, once the application no longer uses the old column, rename.Contract(20261021090000). Use
+// [ColumnRename.Migrations] to register all steps with consecutive versions at once.
+func RenameColumnSafely(table, from, to string) *ColumnRename {
+	return &ColumnRename{Table: table, From: from, To: to}
+}
+
+// Migrations returns the Expand, Backfill and Contract steps as migrations with consecutive
+// versions starting at version.
+func (r *ColumnRename) Migrations(version int64) []*Migration {
+	return []*Migration{r.Expand(version), r.Backfill(version + 1), r.Contract(version + 2)}
+}
+
+// Expand returns the migration adding the new column and the trigger keeping it in sync with the
+// old one.
+func (r *ColumnRename) Expand(version int64) *Migration {
+	return NewGoMigration(version,
+		&GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			if err := r.check(ctx); err != nil {
+				return err
+			}
+			column, err := r.lookupColumn(ctx, tx, r.From)
+			if err != nil {
+				return err
+			}
+			q := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", r.table(), quoteIdentifiers([]string{r.To}), column.typ)
+			if column.def != "" {
+				q += " DEFAULT " + column.def
+			}
+			if _, err := tx.ExecContext(ctx, q); err != nil {
+				return fmt.Errorf("failed to add column %s: %w", r.To, err)
+			}
+			return r.createTrigger(ctx, tx, r.From, r.To)
+		}},
+		&GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			if err := r.check(ctx); err != nil {
+				return err
+			}
+			if err := r.dropTrigger(ctx, tx); err != nil {
+				return err
+			}
+			return r.dropColumn(ctx, tx, r.To)
+		}},
+	)
+}
+
+// Backfill returns the migration copying the old column into the new one for the rows written
+// before Expand. Rolling it back is a no-op.
+func (r *ColumnRename) Backfill(version int64) *Migration {
+	return NewGoMigration(version,
+		&GoFunc{RunDB: func(ctx context.Context, db *sql.DB) error {
+			if err := r.check(ctx); err != nil {
+				return err
+			}
+			batch := r.BatchSize
+			if batch <= 0 {
+				batch = defaultRenameBatchSize
+			}
+			from, to := quoteIdentifiers([]string{r.From}), quoteIdentifiers([]string{r.To})
+			q := fmt.Sprintf("UPDATE %[1]s SET %[3]s = %[2]s WHERE ctid = ANY (ARRAY(SELECT ctid FROM %[1]s WHERE %[3]s IS DISTINCT FROM %[2]s LIMIT %[4]d))",
+				r.table(), from, to, batch)
+			for {
+				res, err := db.ExecContext(ctx, q)
+				if err != nil {
+					return fmt.Errorf("failed to backfill column %s: %w", r.To, err)
+				}
+				n, err := res.RowsAffected()
+				if err != nil {
+					return fmt.Errorf("failed to backfill column %s: %w", r.To, err)
+				}
+				if n == 0 {
+					return nil
+				}
+			}
+		}},
+		&GoFunc{Mode: TransactionDisabled},
+	)
+}
+
+// Contract returns the migration dropping the trigger and the old column.
+func (r *ColumnRename) Contract(version int64) *Migration {
+	return NewGoMigration(version,
+		&GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			if err := r.check(ctx); err != nil {
+				return err
+			}
+			column, err := r.lookupColumn(ctx, tx, r.From)
+			if err != nil {
+				return err
+			}
+			if err := r.dropTrigger(ctx, tx); err != nil {
+				return err
+			}
+			if column.notNull {
+				q := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", r.table(), quoteIdentifiers([]string{r.To}))
+				if _, err := tx.ExecContext(ctx, q); err != nil {
+					return fmt.Errorf("failed to set column %s NOT NULL: %w", r.To, err)
+				}
+			}
+			return r.dropColumn(ctx, tx, r.From)
+		}},
+		&GoFunc{RunTx: func(ctx context.Context, tx *sql.Tx) error {
+			if err := r.check(ctx); err != nil {
+				return err
+			}
+			column, err := r.lookupColumn(ctx, tx, r.To)
+			if err != nil {
+				return err
+			}
+			from, to := quoteIdentifiers([]string{r.From}), quoteIdentifiers([]string{r.To})
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", r.table(), from, column.typ)); err != nil {
+				return fmt.Errorf("failed to add column %s: %w", r.From, err)
+			}
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s = %s", r.table(), from, to)); err != nil {
+				return fmt.Errorf("failed to copy column %s: %w", r.To, err)
+			}
+			return r.createTrigger(ctx, tx, r.From, r.To)
+		}},
+	)
+}
+
+// check returns an error if the rename is incomplete, or if the migration runs on a dialect other
+// than Postgres. Without capabilities, e.g., with a custom store, Postgres is assumed.
+func (r *ColumnRename) check(ctx context.Context) error {
+	if r.Table == "" || r.From == "" || r.To == "" {
+		return errors.New("column rename requires a table and the old and new column names")
+	}
+	if r.From == r.To {
+		return fmt.Errorf("column rename of %s: old and new column names are both %q", r.Table, r.From)
+	}
+	if caps, ok := MigrationCapabilities(ctx); ok && caps.Dialect() != DialectPostgres {
+		return fmt.Errorf("column rename is not supported by the %s dialect: %w", caps.Dialect(), errors.ErrUnsupported)
+	}
+	return nil
+}
+
+// table returns the quoted, optionally schema-qualified, name of the table.
+func (r *ColumnRename) table() string {
+	parts := strings.Split(r.Table, ".")
+	for i, part := range parts {
+		parts[i] = quoteIdentifiers([]string{part})
+	}
+	return strings.Join(parts, ".")
+}
+
+// trigger returns the name of the trigger and of its function keeping the columns in sync.
+func (r *ColumnRename) trigger() string {
+	return "goose_rename_" + strings.ReplaceAll(r.Table, ".", "_") + "_" + r.From + "_" + r.To
+}
+
+type renamedColumn struct {
+	typ     string
+	def     string
+	notNull bool
+}
+
+// lookupColumn returns the type, default and nullability of a column of the table.
+func (r *ColumnRename) lookupColumn(ctx context.Context, tx *sql.Tx, name string) (*renamedColumn, error) {
+	q := `SELECT format_type(a.atttypid, a.atttypmod), COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), a.attnotnull
+FROM pg_catalog.pg_attribute a
+LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE a.attrelid = $1::regclass AND a.attname = $2 AND NOT a.attisdropped`
+	var c renamedColumn
+	if err := tx.QueryRowContext(ctx, q, r.table(), name).Scan(&c.typ, &c.def, &c.notNull); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("column %s of table %s not found", name, r.Table)
+		}
+		return nil, fmt.Errorf("failed to look up column %s of table %s: %w", name, r.Table, err)
+	}
+	return &c, nil
+}
+
+// createTrigger creates the trigger that copies whichever of the columns a statement writes into
+// the other, so that applications writing the old or the new column, or both, see the same data.
+func (r *ColumnRename) createTrigger(ctx context.Context, tx *sql.Tx, from, to string) error {
+	name := quoteIdentifiers([]string{r.trigger()})
+	from, to = quoteIdentifiers([]string{from}), quoteIdentifiers([]string{to})
+	fn := fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger LANGUAGE plpgsql AS $goose$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		IF NEW.%[3]s IS NULL THEN
+			NEW.%[3]s := NEW.%[2]s;
+		ELSIF NEW.%[2]s IS NULL THEN
+			NEW.%[2]s := NEW.%[3]s;
+		END IF;
+	ELSIF NEW.%[3]s IS DISTINCT FROM OLD.%[3]s THEN
+		NEW.%[2]s := NEW.%[3]s;
+	ELSE
+		NEW.%[3]s := NEW.%[2]s;
+	END IF;
+	RETURN NEW;
+END
+$goose$`, name, from, to)
+	if _, err := tx.ExecContext(ctx, fn); err != nil {
+		return fmt.Errorf("failed to create function %s: %w", r.trigger(), err)
+	}
+	q := fmt.Sprintf("CREATE TRIGGER %s BEFORE INSERT OR UPDATE ON %s FOR EACH ROW EXECUTE FUNCTION %s()", name, r.table(), name)
+	if _, err := tx.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("failed to create trigger %s: %w", r.trigger(), err)
+	}
+	return nil
+}
+
+// dropTrigger drops the trigger and its function, if they exist.
+func (r *ColumnRename) dropTrigger(ctx context.Context, tx *sql.Tx) error {
+	name := quoteIdentifiers([]string{r.trigger()})
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", name, r.table())); err != nil {
+		return fmt.Errorf("failed to drop trigger %s: %w", r.trigger(), err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DROP FUNCTION IF EXISTS %s()", name)); err != nil {
+		return fmt.Errorf("failed to drop function %s: %w", r.trigger(), err)
+	}
+	return nil
+}
+
+// dropColumn drops a column of the table.
+func (r *ColumnRename) dropColumn(ctx context.Context, tx *sql.Tx, name string) error {
+	q := fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", r.table(), quoteIdentifiers([]string{name}))
+	if _, err := tx.ExecContext(ctx, q); err != nil {
+		return fmt.Errorf("failed to drop column %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/goosetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameColumnSafely(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	rename := goose.RenameColumnSafely("app.users", "name", "full_name")
+	rename.BatchSize = 500
+	migrations := rename.Migrations(10)
+	require.Len(t, migrations, 3)
+	for i, m := range migrations {
+		require.Equal(t, int64(10+i), m.Version)
+		require.Equal(t, goose.TypeGo, m.Type)
+	}
+
+	// The backfill runs outside a transaction, in batches until no row is left to copy.
+	batches := 2
+	db, rec := goosetest.NewDB(goosetest.WithExec(func(ctx context.Context, query string, args []any) (sql.Result, error) {
+		if strings.HasPrefix(query, "UPDATE") && batches > 0 {
+			batches--
+			return driver.RowsAffected(500), nil
+		}
+		return nil, nil
+	}))
+	store := goosetest.NewStore("goose_db_version")
+	store.SetVersions(0, 10)
+	p, err := goose.NewProvider("", db, nil, goose.WithStore(store), goose.WithGoMigrations(migrations...))
+	require.NoError(t, err)
+	_, err = p.UpByOne(ctx)
+	require.NoError(t, err)
+	update := `UPDATE "app"."users" SET "full_name" = "name" WHERE ctid = ANY (ARRAY(SELECT ctid FROM "app"."users" WHERE "full_name" IS DISTINCT FROM "name" LIMIT 500))`
+	require.Equal(t, []string{update, update, update}, rec.Queries())
+
+	// Rolling back the expand step drops the trigger, its function and the new column.
+	rec.Reset()
+	store.SetVersions(0, 10)
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		goosetest.Begin,
+		`DROP TRIGGER IF EXISTS "goose_rename_app_users_name_full_name" ON "app"."users"`,
+		`DROP FUNCTION IF EXISTS "goose_rename_app_users_name_full_name"()`,
+		`ALTER TABLE "app"."users" DROP COLUMN "full_name"`,
+		goosetest.Commit,
+	}, rec.Queries())
+
+	// The expand and contract steps look up the old column first.
+	_, err = p.UpByOne(ctx)
+	require.ErrorContains(t, err, "column name of table app.users not found")
+
+	t.Run("invalid", func(t *testing.T) {
+		db, _ := goosetest.NewDB()
+		m := goose.RenameColumnSafely("users", "name", "name").Migrations(1)
+		p, err := goose.NewProvider("", db, nil, goose.WithStore(goosetest.NewStore("goose_db_version")), goose.WithGoMigrations(m...))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.ErrorContains(t, err, `old and new column names are both "name"`)
+	})
+	t.Run("dialect", func(t *testing.T) {
+		m := goose.RenameColumnSafely("users", "name", "full_name").Migrations(1)
+		p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), nil, goose.WithGoMigrations(m...))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.True(t, errors.Is(err, errors.ErrUnsupported), err)
+	})
+}
@@ -0,0 +1,153 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// Renumber renames the migrations in dir to sequential versions starting at 1, keeping their
+// order, e.g., 00001_a.sql, 00002_b.sql, for both timestamped and gapped versions.
+//
+// If db is not nil, the version table, and the metadata table if any, are updated to the new
+// versions in a single transaction, so databases that already applied the migrations keep working
+// after renumbering. The transaction is only committed once all files are renamed, and renamed
+// files are restored if anything fails. Renumbering is refused if the version table records a
+// version without a migration file, such as an archived migration, that a new version would
+// collide with.
+//
+// Go migrations are renamed like SQL migrations, but, since their versions are taken from the file
+// name at compile time, binaries that register them must be rebuilt.
+func Renumber(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFunc) error {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if db != nil && option.noVersioning {
+		return errors.New("renumber requires versioning: applied migrations must be tracked in the version table")
+	}
+	// always use osFS here because it's modifying operation
+	migrations, err := collectMigrationsFS(option.scope, osFS{}, dir, minVersion, maxVersion, globalMigrationsSnapshot())
+	if err != nil {
+		return err
+	}
+	renames := make(map[int64]int64)
+	for i, m := range migrations {
+		if newVersion := int64(i + 1); newVersion != m.Version {
+			renames[m.Version] = newVersion
+		}
+	}
+	if len(renames) == 0 {
+		log.Printf("goose: migrations are already numbered sequentially\n")
+		return nil
+	}
+	var tx *sql.Tx
+	if db != nil {
+		if tx, err = renumberVersions(ctx, db, migrations, renames); err != nil {
+			return err
+		}
+	}
+	var renamed [][2]string
+	restore := func() error {
+		var errs error
+		for i := len(renamed) - 1; i >= 0; i-- {
+			errs = multierr.Append(errs, os.Rename(renamed[i][1], renamed[i][0]))
+		}
+		if tx != nil {
+			errs = multierr.Append(errs, tx.Rollback())
+		}
+		return errs
+	}
+	for _, m := range migrations {
+		newVersion, ok := renames[m.Version]
+		if !ok {
+			continue
+		}
+		oldPath := m.Source
+		base := filepath.Base(oldPath)
+		_, desc, _ := strings.Cut(base, "_")
+		newPath := filepath.Join(filepath.Dir(oldPath), fmt.Sprintf(seqVersionTemplate, newVersion)+"_"+desc)
+		if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+			return multierr.Append(
+				fmt.Errorf("failed to renumber migration %q: %s already exists", oldPath, newPath),
+				restore(),
+			)
+		}
+		if err := os.Rename(oldPath, newPath); err != nil {
+			return multierr.Append(fmt.Errorf("failed to renumber migration: %w", err), restore())
+		}
+		renamed = append(renamed, [2]string{oldPath, newPath})
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			tx = nil
+			return multierr.Append(fmt.Errorf("failed to commit version changes: %w", err), restore())
+		}
+	}
+	for _, r := range renamed {
+		log.Printf("RENAMED %s => %s\n", filepath.Base(r[0]), filepath.Base(r[1]))
+	}
+	return nil
+}
+
+// renumberVersions updates the version and metadata tables of db to the new versions, in a
+// transaction that is returned uncommitted.
+func renumberVersions(ctx context.Context, db *sql.DB, migrations Migrations, renames map[int64]int64) (*sql.Tx, error) {
+	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to ensure DB version: %w", err)
+	}
+	records, err := getStore().ListMigrations(ctx, db, TableName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+	}
+	for _, r := range records {
+		if r.VersionID == 0 || known[r.VersionID] {
+			continue
+		}
+		if r.VersionID <= int64(len(migrations)) {
+			return nil, fmt.Errorf("version %d is recorded in the database without a migration file and collides with a new version", r.VersionID)
+		}
+	}
+	store, err := getMetadataStore()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	hasMetadata, err := store.MetadataTableExists(ctx, tx)
+	if err != nil && !errors.Is(err, errors.ErrUnsupported) {
+		return nil, multierr.Append(err, tx.Rollback())
+	}
+	// Versions are updated in ascending order. New versions are never greater than old ones, so a
+	// version is only ever moved onto a version that was already moved out of the way.
+	for _, m := range migrations {
+		newVersion, ok := renames[m.Version]
+		if !ok {
+			continue
+		}
+		if err := store.UpdateVersion(ctx, tx, m.Version, newVersion); err != nil {
+			if errors.Is(err, errors.ErrUnsupported) {
+				err = fmt.Errorf("renumber is not supported by the current dialect: %w", err)
+			}
+			return nil, multierr.Append(err, tx.Rollback())
+		}
+		if hasMetadata {
+			if err := store.UpdateMetadataVersion(ctx, tx, m.Version, newVersion); err != nil {
+				return nil, multierr.Append(err, tx.Rollback())
+			}
+		}
+	}
+	return tx, nil
+}
@@ -0,0 +1,88 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestRenumber(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	writeMigrations := func(t *testing.T, names ...string) string {
+		t.Helper()
+		dir := filepath.Join(t.TempDir(), "migrations")
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		for _, name := range names {
+			data := []byte("-- +goose Up\nSELECT 1;\n-- +goose Down\nSELECT 1;\n")
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0644))
+		}
+		return dir
+	}
+	listFiles := func(t *testing.T, dir string) []string {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+	t.Run("files", func(t *testing.T) {
+		dir := writeMigrations(t, "00001_a.sql", "00003_b.sql", "20240101000000_c.sql")
+		require.NoError(t, goose.Renumber(ctx, nil, dir))
+		require.Equal(t, []string{"00001_a.sql", "00002_b.sql", "00003_c.sql"}, listFiles(t, dir))
+		// Already sequential.
+		require.NoError(t, goose.Renumber(ctx, nil, dir))
+	})
+	t.Run("database", func(t *testing.T) {
+		dir := writeMigrations(t, "00002_a.sql", "00005_b.sql", "20240101000000_c.sql")
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "renumber.db"))
+		require.NoError(t, err)
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { _ = db.Close() })
+		require.NoError(t, goose.UpTo(db, dir, 5, goose.WithOptionRunLabels(map[string]string{"sha": "abc"})))
+
+		require.NoError(t, goose.Renumber(ctx, db, dir))
+		require.Equal(t, []string{"00001_a.sql", "00002_b.sql", "00003_c.sql"}, listFiles(t, dir))
+		ver, err := goose.GetDBVersion(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 2, ver)
+		var n int
+		require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM goose_db_version_meta WHERE version_id IN (1, 2)").Scan(&n))
+		require.Equal(t, 2, n)
+		// The renumbered database still matches the files.
+		require.NoError(t, goose.Up(db, dir))
+		ver, err = goose.GetDBVersion(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, ver)
+	})
+	t.Run("collision", func(t *testing.T) {
+		dir := writeMigrations(t, "00001_a.sql", "00002_b.sql", "00004_c.sql")
+		db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "renumber_collision.db"))
+		require.NoError(t, err)
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { _ = db.Close() })
+		require.NoError(t, goose.Up(db, dir))
+		// Version 2 is applied, but its file is gone, e.g., it was archived.
+		require.NoError(t, os.Remove(filepath.Join(dir, "00002_b.sql")))
+
+		err = goose.Renumber(ctx, db, dir)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "version 2 is recorded in the database without a migration file")
+		require.Equal(t, []string{"00001_a.sql", "00004_c.sql"}, listFiles(t, dir))
+		ver, err := goose.GetDBVersion(db)
+		require.NoError(t, err)
+		require.EqualValues(t, 4, ver)
+	})
+}
@@ -0,0 +1,151 @@
+package goose
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WriteJUnit writes the results of a run as a JUnit XML report to w, for CI systems that annotate
+// builds with test results. Each migration is a test case, and the migration that failed, if any,
+// is reported as a failure.
+//
+// results and err are what a [Provider] run returned, e.g., by [Provider.Up]. If err is a
+// [PartialError], its applied and failed migrations are reported. Other errors, which occur before
+// any migration is run, are reported as a single failed test case named "run".
+func WriteJUnit(w io.Writer, results []*MigrationResult, err error) error {
+	results, err = reportResults(results, err)
+	suite := junitSuite{Name: "goose"}
+	var total time.Duration
+	for _, r := range results {
+		tc := junitCase{
+			ClassName: "goose." + r.Direction,
+			Name:      reportName(r),
+			Time:      junitSeconds(r.Duration),
+		}
+		if r.Error != nil {
+			tc.Failure = &junitFailure{Message: firstLine(r.Error.Error()), Text: r.Error.Error()}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+		total += r.Duration
+	}
+	if err != nil {
+		suite.Cases = append(suite.Cases, junitCase{
+			ClassName: "goose",
+			Name:      "run",
+			Time:      junitSeconds(0),
+			Failure:   &junitFailure{Message: firstLine(err.Error()), Text: err.Error()},
+		})
+		suite.Failures++
+	}
+	suite.Tests = len(suite.Cases)
+	suite.Time = junitSeconds(total)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return fmt.Errorf("failed to write junit report: %w", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// WriteMarkdown writes the results of a run as a Markdown table to w, e.g., for a pull request
+// comment or a CI job summary, followed by the error of the run, if any. If err is a
+// [PartialError], its applied and failed migrations are listed, like [WriteJUnit].
+//
+// Example:
+//
+//	| State | Direction | Migration | Duration |
+//	| --- | --- | --- | --- |
+//	| OK | up | `00001_users.sql` | 1.25ms |
+//	| FAILED | up | `00002_posts.sql` | 310µs |
+func WriteMarkdown(w io.Writer, results []*MigrationResult, err error) error {
+	results, _ = reportResults(results, err)
+	var b strings.Builder
+	if len(results) == 0 {
+		b.WriteString("No migrations were run.\n")
+	} else {
+		b.WriteString("| State | Direction | Migration | Duration |\n")
+		b.WriteString("| --- | --- | --- | --- |\n")
+		for _, r := range results {
+			state := "OK"
+			switch {
+			case r.Error != nil:
+				state = "FAILED"
+			case r.Empty:
+				state = "EMPTY"
+			}
+			fmt.Fprintf(&b, "| %s | %s | `%s` | %s |\n",
+				state,
+				r.Direction,
+				markdownEscape(reportName(r)),
+				truncateDuration(r.Duration),
+			)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(&b, "\n**Error:**\n\n```\n%s\n```\n", strings.ReplaceAll(err.Error(), "```", "` ` `"))
+	}
+	_, werr := io.WriteString(w, b.String())
+	return werr
+}
+
+// reportResults returns the migrations of a run to report, and the error of the run if it is not
+// reported by the failed migration.
+func reportResults(results []*MigrationResult, err error) ([]*MigrationResult, error) {
+	var partialErr *PartialError
+	if errors.As(err, &partialErr) {
+		return append(append([]*MigrationResult(nil), partialErr.Applied...), partialErr.Failed), nil
+	}
+	return results, err
+}
+
+// reportName returns the name of the migration of r, its file name or version.
+func reportName(r *MigrationResult) string {
+	if r.Source.Path == "" {
+		return fmt.Sprintf("version %d", r.Source.Version)
+	}
+	return filepath.Base(r.Source.Path)
+}
+
+// markdownEscape escapes characters that would end an inline code span in a table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "`", "'", "\n", " ").Replace(s)
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
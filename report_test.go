@@ -0,0 +1,69 @@
+package goose
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReports(t *testing.T) {
+	t.Parallel()
+
+	applied := &MigrationResult{
+		Source:    &Source{Type: TypeSQL, Path: "migrations/00001_users.sql", Version: 1},
+		Direction: "up",
+		Duration:  1250 * time.Microsecond,
+	}
+	empty := &MigrationResult{
+		Source:    &Source{Type: TypeGo, Version: 2},
+		Direction: "up",
+		Duration:  310 * time.Microsecond,
+		Empty:     true,
+	}
+	failed := &MigrationResult{
+		Source:    &Source{Type: TypeSQL, Path: "migrations/00003_a|b.sql", Version: 3},
+		Direction: "up",
+		Duration:  2 * time.Millisecond,
+		Error:     errors.New("no such table: users\nnear line 2"),
+	}
+	partialErr := &PartialError{Applied: []*MigrationResult{applied, empty}, Failed: failed, Err: failed.Error}
+
+	t.Run("junit", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteJUnit(&buf, nil, partialErr))
+		require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="goose" tests="3" failures="1" time="0.004">
+    <testcase classname="goose.up" name="00001_users.sql" time="0.001"></testcase>
+    <testcase classname="goose.up" name="version 2" time="0.000"></testcase>
+    <testcase classname="goose.up" name="00003_a|b.sql" time="0.002">
+      <failure message="no such table: users">no such table: users&#xA;near line 2</failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+
+		// An error before any migration was run is a failed test case of its own.
+		buf.Reset()
+		require.NoError(t, WriteJUnit(&buf, nil, errors.New("failed to initialize")))
+		require.Contains(t, buf.String(), `tests="1" failures="1"`)
+		require.Contains(t, buf.String(), `<testcase classname="goose" name="run" time="0.000">`)
+	})
+	t.Run("markdown", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, WriteMarkdown(&buf, nil, partialErr))
+		require.Equal(t, "| State | Direction | Migration | Duration |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| OK | up | `00001_users.sql` | 1.25ms |\n"+
+			"| EMPTY | up | `version 2` | 310µs |\n"+
+			"| FAILED | up | `00003_a\\|b.sql` | 2ms |\n"+
+			"\n**Error:**\n\n```\n"+partialErr.Error()+"\n```\n", buf.String())
+
+		buf.Reset()
+		require.NoError(t, WriteMarkdown(&buf, []*MigrationResult{}, nil))
+		require.Equal(t, "No migrations were run.\n", buf.String())
+	})
+}
@@ -0,0 +1,222 @@
+package goose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/pressly/goose/v3/database"
+	"github.com/pressly/goose/v3/internal/gooseutil"
+	"github.com/pressly/goose/v3/internal/sqlparser"
+)
+
+const modulePath = "github.com/pressly/goose/v3"
+
+// develVersion is the version reported by development builds of goose itself, where the module
+// version is not recorded in the build info. It must be the next release.
+const develVersion = "v3.25.0-dev"
+
+// runningVersion returns the version of the goose module linked into the running binary. This is
+// the version checked against the "-- +goose requires-goose" directive.
+var runningVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return develVersion
+	}
+	if info.Main.Path == modulePath && isRelease(info.Main.Version) {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		if isRelease(dep.Version) {
+			return dep.Version
+		}
+	}
+	return develVersion
+})
+
+func isRelease(version string) bool {
+	return version != "" && version != "(devel)"
+}
+
+// checkRequiresGoose returns an error if the running goose version does not satisfy a
+// requires-goose directive. This fails fast before any statements run, rather than letting an older
+// goose silently ignore directives it does not understand.
+func checkRequiresGoose(directives []sqlparser.Directive) error {
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveRequiresGoose)
+	if !ok {
+		return nil
+	}
+	if err := gooseutil.CheckVersionConstraint(d.Value, runningVersion()); err != nil {
+		return fmt.Errorf("goose version requirement not met: %w", err)
+	}
+	return nil
+}
+
+// dbRequirement is a database version requirement declared with a requires-db directive, e.g.,
+// "postgres >= 14".
+type dbRequirement struct {
+	Engine string
+	// Constraint is the version constraint, normalized for [gooseutil.CheckVersionConstraint],
+	// e.g., ">= v14".
+	Constraint string
+	// Value is the value of the directive, as written.
+	Value string
+}
+
+// dbEngines maps the engines of requires-db directives to the dialects they are checked on.
+var dbEngines = map[string][]Dialect{
+	"postgres":   {DialectPostgres},
+	"mysql":      {DialectMySQL},
+	"sqlite":     {DialectSQLite3, database.DialectTurso},
+	"sqlite3":    {DialectSQLite3, database.DialectTurso},
+	"clickhouse": {DialectClickHouse},
+	"mssql":      {DialectMSSQL},
+	"sqlserver":  {DialectMSSQL},
+}
+
+// serverVersionQueries are the queries returning the version of the connected server, by dialect.
+var serverVersionQueries = map[Dialect]string{
+	DialectPostgres:       "SHOW server_version",
+	DialectMySQL:          "SELECT VERSION()",
+	DialectSQLite3:        "SELECT sqlite_version()",
+	database.DialectTurso: "SELECT sqlite_version()",
+	DialectClickHouse:     "SELECT version()",
+	DialectMSSQL:          "SELECT CAST(SERVERPROPERTY('ProductVersion') AS NVARCHAR(128))",
+}
+
+var (
+	// dbConstraintRe matches the version constraint of a requires-db directive, e.g., ">= 14.2".
+	dbConstraintRe = regexp.MustCompile(`^(>=|<=|>|<|=)?\s*v?(\d+(?:\.\d+){0,2})$`)
+	// serverVersionRe matches the leading version reported by a server, e.g., "14.5" in
+	// "14.5 (Debian 14.5-1.pgdg110+1)" or "8.0.34" in "8.0.34-0ubuntu0.22.04.1".
+	serverVersionRe = regexp.MustCompile(`\d+(?:\.\d+){0,2}`)
+)
+
+// parseRequiresDB returns the requirement declared by a requires-db directive, or nil if there is
+// none.
+func parseRequiresDB(directives []sqlparser.Directive) (*dbRequirement, error) {
+	d, ok := sqlparser.LookupDirective(directives, sqlparser.DirectiveRequiresDB)
+	if !ok {
+		return nil, nil
+	}
+	engine, constraint, _ := strings.Cut(d.Value, " ")
+	engine = strings.ToLower(engine)
+	if _, ok := dbEngines[engine]; !ok {
+		return nil, fmt.Errorf("invalid %s directive %q: unknown database %q, must be one of clickhouse, mssql, mysql, postgres or sqlite",
+			sqlparser.DirectiveRequiresDB, d.Value, engine)
+	}
+	match := dbConstraintRe.FindStringSubmatch(strings.TrimSpace(constraint))
+	if match == nil {
+		return nil, fmt.Errorf("invalid %s directive %q: must be a database followed by a version constraint, e.g., postgres >= 14",
+			sqlparser.DirectiveRequiresDB, d.Value)
+	}
+	op := match[1]
+	if op == "" {
+		op = ">="
+	}
+	return &dbRequirement{Engine: engine, Constraint: op + " v" + match[2], Value: d.Value}, nil
+}
+
+// serverVersion returns the version of the server db is connected to, e.g., "v14.5".
+func serverVersion(ctx context.Context, db database.DBTxConn, d Dialect) (string, error) {
+	q, ok := serverVersionQueries[d]
+	if !ok {
+		return "", fmt.Errorf("querying the server version is not supported by the %s dialect: %w", d, errors.ErrUnsupported)
+	}
+	var reported string
+	if err := db.QueryRowContext(ctx, q).Scan(&reported); err != nil {
+		return "", fmt.Errorf("failed to query server version: %w", err)
+	}
+	v := serverVersionRe.FindString(reported)
+	if v == "" {
+		return "", fmt.Errorf("failed to parse server version %q", reported)
+	}
+	return "v" + v, nil
+}
+
+// dbVersionChecker checks requires-db directives against a database, querying its version once.
+type dbVersionChecker struct {
+	db      database.DBTxConn
+	dialect Dialect
+	version string
+}
+
+// check returns an error wrapping [ErrDBRequirement] if the database does not satisfy req.
+func (c *dbVersionChecker) check(ctx context.Context, req *dbRequirement) error {
+	if req == nil {
+		return nil
+	}
+	if c.dialect == "" {
+		return fmt.Errorf("%s directive requires a dialect, it cannot be checked with a custom store", sqlparser.DirectiveRequiresDB)
+	}
+	var engine bool
+	for _, d := range dbEngines[req.Engine] {
+		engine = engine || d == c.dialect
+	}
+	if !engine {
+		return fmt.Errorf("%w: requires %s, but the dialect is %q", ErrDBRequirement, req.Value, c.dialect)
+	}
+	if c.version == "" {
+		v, err := serverVersion(ctx, c.db, c.dialect)
+		if err != nil {
+			return err
+		}
+		c.version = v
+	}
+	if err := gooseutil.CheckVersionConstraint(req.Constraint, c.version); err != nil {
+		return fmt.Errorf("%w: requires %s, but the server is %s", ErrDBRequirement, req.Value, strings.TrimPrefix(c.version, "v"))
+	}
+	return nil
+}
+
+// checkFile checks the requires-db directive of the migration at source in fsys, if it is a SQL
+// migration.
+func (c *dbVersionChecker) checkFile(ctx context.Context, fsys fs.FS, source string) error {
+	if !isSQLFile(source) {
+		return nil
+	}
+	data, err := fs.ReadFile(decompressing(fsys), source)
+	if err != nil {
+		return fmt.Errorf("failed to read migration %s: %w", filepath.Base(source), err)
+	}
+	directives, err := sqlparser.ParseDirectives(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to parse migration %s: %w", filepath.Base(source), err)
+	}
+	req, err := parseRequiresDB(directives)
+	if err != nil {
+		return fmt.Errorf("migration %s: %w", filepath.Base(source), err)
+	}
+	if err := c.check(ctx, req); err != nil {
+		return fmt.Errorf("migration %s: %w", filepath.Base(source), err)
+	}
+	return nil
+}
+
+// checkRequiresDB checks the requires-db directives of the migrations to apply against the
+// connected database, before any of them run.
+func (p *Provider) checkRequiresDB(ctx context.Context, conn database.DBTxConn, steps []migrationStep) error {
+	checker := &dbVersionChecker{db: conn, dialect: p.dialect}
+	for _, step := range steps {
+		if !step.direction || step.m.Type != TypeSQL {
+			continue
+		}
+		if err := checker.check(ctx, step.m.sql.RequiresDB); err != nil {
+			return fmt.Errorf("migration %s: %w", step.m.ref(), err)
+		}
+	}
+	return nil
+}
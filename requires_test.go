@@ -0,0 +1,81 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequiresGoose(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose requires-goose >= v3.0\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"00002_b.sql": newMapFile("-- +goose requires-goose >= v99.0\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	// The check runs before any migration is applied.
+	_, err = p.Up(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "goose version requirement not met")
+	require.False(t, tableExists(t, db, "a"))
+	// Migrations within the supported range still run on their own.
+	_, err = p.UpByOne(ctx)
+	require.NoError(t, err)
+	require.True(t, tableExists(t, db, "a"))
+
+	fsys["00003_c.sql"] = newMapFile("-- +goose requires-goose >= 3.0\n-- +goose Up\nSELECT 1;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.ApplyVersion(ctx, 3, true)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid version constraint")
+}
+
+func TestRequiresDB(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose requires-db sqlite >= 3.0\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"00002_b.sql": newMapFile("-- +goose requires-db sqlite >= 99\n-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+	}
+	db := newDB(t)
+	p, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	// The check runs before any migration is applied.
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, goose.ErrDBRequirement)
+	require.Contains(t, err.Error(), "requires sqlite >= 99, but the server is 3.")
+	require.False(t, tableExists(t, db, "a"))
+	_, err = p.UpByOne(ctx)
+	require.NoError(t, err)
+	require.True(t, tableExists(t, db, "a"))
+
+	fsys["00002_b.sql"] = newMapFile("-- +goose requires-db postgres >= 14\n-- +goose Up\nSELECT 1;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorIs(t, err, goose.ErrDBRequirement)
+	require.Contains(t, err.Error(), `requires postgres >= 14, but the dialect is "sqlite3"`)
+
+	fsys["00002_b.sql"] = newMapFile("-- +goose requires-db sqlite latest\n-- +goose Up\nSELECT 1;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.Up(ctx)
+	require.ErrorContains(t, err, `invalid requires-db directive "sqlite latest"`)
+
+	// Rolling back does not check the requirement.
+	fsys["00001_a.sql"] = newMapFile("-- +goose requires-db sqlite >= 99\n-- +goose Up\nCREATE TABLE a (id INTEGER);\n-- +goose Down\nDROP TABLE a;\n")
+	p, err = goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	_, err = p.Down(ctx)
+	require.NoError(t, err)
+	require.False(t, tableExists(t, db, "a"))
+}
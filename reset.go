@@ -19,7 +19,7 @@ func ResetContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFu
 	for _, f := range opts {
 		f(option)
 	}
-	migrations, err := CollectMigrations(option.scope, dir, minVersion, maxVersion)
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
 	if err != nil {
 		return fmt.Errorf("failed to collect migrations: %w", err)
 	}
@@ -46,7 +46,7 @@ func ResetContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFu
 }
 
 func dbMigrationsStatus(ctx context.Context, db *sql.DB) (map[int64]bool, error) {
-	dbMigrations, err := store.ListMigrations(ctx, db, TableName())
+	dbMigrations, err := getStore().ListMigrations(ctx, db, TableName())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,50 @@
+package goose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pressly/goose/v3/schema"
+)
+
+// SchemaHeader is the first line of the schema file written by [Provider.EmitSchema].
+const SchemaHeader = "-- Code generated by goose schema emit. DO NOT EDIT."
+
+// EmitSchema applies all pending migrations and writes the resulting schema, as dumped by d, to w
+// as a single file of DDL statements, e.g., a schema.sql for sqlc and similar code generators. The
+// version and metadata tables are left out.
+//
+// The database is meant to be empty or ephemeral, such as a sqlite3 :memory: database or a
+// database created for the run in CI, since migrations are applied to it. Statements are
+// terminated with a semicolon and separated by blank lines, in the order given by d, so the file
+// only changes when the schema does. Since each connection to an in-memory SQLite database has
+// its own database, db must then be limited to a single open connection.
+func (p *Provider) EmitSchema(ctx context.Context, w io.Writer, d schema.Dumper) error {
+	if d == nil {
+		return errors.New("dumper must not be nil")
+	}
+	if _, err := p.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	table := p.store.Tablename()
+	statements, err := d.Dump(ctx, p.db, []string{table, table + "_meta"})
+	if err != nil {
+		return fmt.Errorf("failed to dump schema: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString(SchemaHeader + "\n")
+	for _, stmt := range statements {
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		if stmt == "" {
+			continue
+		}
+		b.WriteString("\n" + stmt + ";\n")
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write schema: %w", err)
+	}
+	return nil
+}
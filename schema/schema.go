@@ -0,0 +1,246 @@
+// Package schema defines the Dumper interface and implements schema dumps for SQLite, MySQL and,
+// with pg_dump, Postgres. Dumps are used by goose to emit a consolidated schema file for code
+// generators such as sqlc.
+package schema
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Dumper returns the schema of a database as DDL statements.
+type Dumper interface {
+	// Dump returns the statements that create the schema of db, in an order they can be run in.
+	// Tables named in exclude, such as the goose version table, are left out. Statements are
+	// returned without a trailing semicolon.
+	Dump(ctx context.Context, db *sql.DB, exclude []string) ([]string, error)
+}
+
+// DumperFunc is an adapter to allow the use of an ordinary function as a Dumper.
+type DumperFunc func(ctx context.Context, db *sql.DB, exclude []string) ([]string, error)
+
+// Dump calls f(ctx, db, exclude).
+func (f DumperFunc) Dump(ctx context.Context, db *sql.DB, exclude []string) ([]string, error) {
+	return f(ctx, db, exclude)
+}
+
+// NewSQLite returns a Dumper that reads the schema of a SQLite database from sqlite_master, in the
+// order the objects were created.
+func NewSQLite() Dumper {
+	return DumperFunc(func(ctx context.Context, db *sql.DB, exclude []string) ([]string, error) {
+		rows, err := db.QueryContext(ctx, `SELECT name, tbl_name, sql FROM sqlite_master
+WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY rowid`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+		defer rows.Close()
+		skip := excluded(exclude)
+		var statements []string
+		for rows.Next() {
+			var name, table, stmt string
+			if err := rows.Scan(&name, &table, &stmt); err != nil {
+				return nil, fmt.Errorf("failed to read schema: %w", err)
+			}
+			if skip[name] || skip[table] {
+				continue
+			}
+			statements = append(statements, strings.TrimSpace(stmt))
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+		return statements, nil
+	})
+}
+
+// NewMySQL returns a Dumper that reads the schema of the current MySQL database with SHOW CREATE
+// TABLE and SHOW CREATE VIEW. Tables come first, in name order, followed by views.
+func NewMySQL() Dumper {
+	return DumperFunc(func(ctx context.Context, db *sql.DB, exclude []string) ([]string, error) {
+		rows, err := db.QueryContext(ctx, `SELECT table_name, table_type FROM information_schema.tables
+WHERE table_schema = DATABASE() ORDER BY table_type, table_name`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		skip := excluded(exclude)
+		var tables, views []string
+		for rows.Next() {
+			var name, kind string
+			if err := rows.Scan(&name, &kind); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to list tables: %w", err)
+			}
+			switch {
+			case skip[name]:
+			case kind == "VIEW":
+				views = append(views, name)
+			default:
+				tables = append(tables, name)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to list tables: %w", err)
+		}
+		var statements []string
+		for _, name := range append(tables, views...) {
+			rows, err := db.QueryContext(ctx, "SHOW CREATE TABLE `"+strings.ReplaceAll(name, "`", "``")+"`")
+			if err != nil {
+				return nil, fmt.Errorf("failed to show table %s: %w", name, err)
+			}
+			// Tables return 2 columns and views 4, the second being the statement in both.
+			columns, err := rows.Columns()
+			if err != nil || len(columns) < 2 || !rows.Next() {
+				rows.Close()
+				return nil, fmt.Errorf("failed to show table %s: %w", name, errors.Join(err, rows.Err()))
+			}
+			values := make([]sql.RawBytes, len(columns))
+			dest := make([]any, len(columns))
+			for i := range values {
+				dest[i] = &values[i]
+			}
+			if err := rows.Scan(dest...); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to show table %s: %w", name, err)
+			}
+			statements = append(statements, string(values[1]))
+			rows.Close()
+		}
+		return statements, nil
+	})
+}
+
+// NewPgDump returns a Dumper that dumps the schema of the Postgres database at dsn with pg_dump
+// --schema-only, without owners, privileges, comments and session settings.
+func NewPgDump(dsn string, opts ...CommandOption) (Dumper, error) {
+	if dsn == "" {
+		return nil, errors.New("dsn must not be empty")
+	}
+	c := &command{name: "pg_dump"}
+	for _, opt := range opts {
+		if err := opt.apply(c); err != nil {
+			return nil, err
+		}
+	}
+	return DumperFunc(func(ctx context.Context, _ *sql.DB, exclude []string) ([]string, error) {
+		args := []string{"--dbname=" + dsn, "--schema-only", "--no-owner", "--no-privileges", "--no-comments"}
+		for _, t := range exclude {
+			args = append(args, "--exclude-table="+t)
+		}
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, c.name, args...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%s failed: %w: %s", filepath.Base(c.name), err, msg)
+			}
+			return nil, fmt.Errorf("%s failed: %w", filepath.Base(c.name), err)
+		}
+		return splitPgDump(stdout.String()), nil
+	}), nil
+}
+
+// CommandOption is used to configure the dump command of a Dumper.
+type CommandOption interface {
+	apply(*command) error
+}
+
+// WithCommand sets the path of the dump command, if it is not found in the PATH.
+func WithCommand(path string) CommandOption {
+	return commandFunc(func(c *command) error {
+		if path == "" {
+			return errors.New("command path must not be empty")
+		}
+		c.name = path
+		return nil
+	})
+}
+
+var _ CommandOption = (commandFunc)(nil)
+
+type commandFunc func(*command) error
+
+func (f commandFunc) apply(c *command) error {
+	return f(c)
+}
+
+type command struct {
+	name string
+}
+
+// splitPgDump returns the statements of a plain pg_dump, leaving out comments and the session
+// settings at the top, which code generators do not need.
+func splitPgDump(dump string) []string {
+	var statements []string
+	var stmt strings.Builder
+	for _, line := range strings.Split(dump, "\n") {
+		if stmt.Len() == 0 {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "--") || strings.HasPrefix(trimmed, `\`) {
+				continue
+			}
+		}
+		stmt.WriteString(line)
+		stmt.WriteString("\n")
+		if strings.HasSuffix(strings.TrimSpace(line), ";") && !inDollarQuote(stmt.String()) {
+			s := strings.TrimSuffix(strings.TrimSpace(stmt.String()), ";")
+			stmt.Reset()
+			if strings.HasPrefix(s, "SET ") || strings.HasPrefix(s, "SELECT pg_catalog.set_config") {
+				continue
+			}
+			statements = append(statements, s)
+		}
+	}
+	return statements
+}
+
+// inDollarQuote reports whether s ends inside a dollar-quoted string, such as a function body.
+func inDollarQuote(s string) bool {
+	var tag string
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			continue
+		}
+		j := strings.IndexByte(s[i+1:], '$')
+		if j < 0 {
+			return tag != ""
+		}
+		candidate := s[i : i+j+2]
+		if !validTag(candidate) {
+			continue
+		}
+		switch tag {
+		case "":
+			tag = candidate
+		case candidate:
+			tag = ""
+		}
+		i += j + 1
+	}
+	return tag != ""
+}
+
+// validTag reports whether tag, including its dollar signs, is a dollar quote tag, such as $$ or
+// $body$. Parameters such as $1 are not.
+func validTag(tag string) bool {
+	for i, r := range tag[1 : len(tag)-1] {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func excluded(names []string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, name := range names {
+		m[name] = true
+	}
+	return m
+}
@@ -0,0 +1,89 @@
+package schema_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pressly/goose/v3/schema"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLite(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	for _, q := range []string{
+		"CREATE TABLE goose_db_version (id INTEGER PRIMARY KEY AUTOINCREMENT, version_id INTEGER)",
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)",
+		"CREATE INDEX users_email ON users (email)",
+		"CREATE VIEW emails AS SELECT email FROM users",
+	} {
+		_, err := db.ExecContext(ctx, q)
+		require.NoError(t, err)
+	}
+	statements, err := schema.NewSQLite().Dump(ctx, db, []string{"goose_db_version"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT)",
+		"CREATE INDEX users_email ON users (email)",
+		"CREATE VIEW emails AS SELECT email FROM users",
+	}, statements)
+}
+
+func TestPgDump(t *testing.T) {
+	t.Parallel()
+
+	// The fake pg_dump prints a plain dump with session settings, comments and a function body.
+	path := filepath.Join(t.TempDir(), "pg_dump")
+	script := `#!/bin/sh
+cat <<'DUMP'
+--
+-- PostgreSQL database dump
+--
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+--
+-- Name: touch(); Type: FUNCTION; Schema: public
+--
+
+CREATE FUNCTION public.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$;
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    updated_at timestamp with time zone
+);
+
+\unrestrict abc
+DUMP
+`
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+	d, err := schema.NewPgDump("postgres://localhost/app", schema.WithCommand(path))
+	require.NoError(t, err)
+	statements, err := d.Dump(context.Background(), nil, []string{"goose_db_version"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"CREATE FUNCTION public.touch() RETURNS trigger\n    LANGUAGE plpgsql\n    AS $$\nBEGIN\n    NEW.updated_at = now();\n    RETURN NEW;\nEND;\n$$",
+		"CREATE TABLE public.users (\n    id integer NOT NULL,\n    updated_at timestamp with time zone\n)",
+	}, statements)
+
+	_, err = schema.NewPgDump("")
+	require.Error(t, err)
+	_, err = schema.NewPgDump("postgres://localhost/app", schema.WithCommand(""))
+	require.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package goose_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmitSchema(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"00001_users.sql": newMapFile("-- +goose Up\nCREATE TABLE users (id INTEGER PRIMARY KEY);\n"),
+		"00002_index.sql": newMapFile("-- +goose Up\nCREATE INDEX users_id ON users (id);\n"),
+	}
+	p, err := goose.NewProvider(goose.DialectSQLite3, newDB(t), fsys, goose.WithTableName("versions"))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, p.EmitSchema(ctx, &buf, schema.NewSQLite()))
+	require.Equal(t, goose.SchemaHeader+`
+
+CREATE TABLE users (id INTEGER PRIMARY KEY);
+
+CREATE INDEX users_id ON users (id);
+`, buf.String())
+	current, err := p.GetDBVersion(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, current)
+
+	// The version and metadata tables are excluded from the dump.
+	var exclude []string
+	dumper := schema.DumperFunc(func(_ context.Context, _ *sql.DB, ex []string) ([]string, error) {
+		exclude = ex
+		return nil, nil
+	})
+	require.NoError(t, p.EmitSchema(ctx, io.Discard, dumper))
+	require.Equal(t, []string{"versions", "versions_meta"}, exclude)
+
+	require.Error(t, p.EmitSchema(ctx, io.Discard, nil))
+}
@@ -0,0 +1,138 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DBState is the migration state of a database, as returned by [GetState]. It is meant to be
+// embedded in health or readiness endpoints, and encodes to JSON.
+type DBState struct {
+	// Version is the current version of the database, see [GetDBVersion].
+	Version int64 `json:"version"`
+	// Pending is the number of migrations in the migrations directory that are not applied.
+	Pending int `json:"pending"`
+	// LastAppliedAt is when the most recently applied migration was applied. It is the zero time
+	// if no migrations have been applied.
+	LastAppliedAt time.Time `json:"last_applied_at"`
+	// Dirty indicates a migration run outside a transaction failed part way and was not completed
+	// since, leaving the database partially migrated. See [WithCheckpoints].
+	Dirty bool `json:"dirty"`
+	// Scopes breaks down the Go migrations registered globally by scope, see [WithScope], sorted by
+	// scope. SQL migrations are not scoped and are not included.
+	Scopes []*ScopeState `json:"scopes"`
+}
+
+// ScopeState is the state of the Go migrations registered globally under a scope.
+type ScopeState struct {
+	Scope   string `json:"scope"`
+	Applied int    `json:"applied"`
+	Pending int    `json:"pending"`
+}
+
+// GetState returns the migration state of the database, for the migrations in dir. Unlike
+// [Version] and [Status], it does not print anything.
+func GetState(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFunc) (*DBState, error) {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.noVersioning {
+		return nil, errors.New("state requires versioning: applied migrations must be tracked in the version table")
+	}
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	current, err := GetDBVersionContext(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	// ListMigrations returns records in descending order by id, so the first record of a version
+	// is its latest, and the first applied record is the most recently applied migration.
+	records, err := getStore().ListMigrations(ctx, db, TableName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	state := &DBState{
+		Version: current,
+		Scopes:  []*ScopeState{},
+	}
+	applied := make(map[int64]bool)
+	seen := make(map[int64]bool)
+	var last int64
+	for _, r := range records {
+		if seen[r.VersionID] {
+			continue
+		}
+		seen[r.VersionID] = true
+		if !r.IsApplied || r.VersionID == 0 {
+			continue
+		}
+		if len(applied) == 0 {
+			last = r.VersionID
+		}
+		applied[r.VersionID] = true
+	}
+	if last != 0 {
+		m, err := getStore().GetMigration(ctx, db, TableName(), last)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query the latest migration: %w", err)
+		}
+		state.LastAppliedAt = m.Timestamp
+	}
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			state.Pending++
+		}
+	}
+	if state.Dirty, err = hasRunCheckpoints(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	for _, scope := range GlobalMigrationScopes() {
+		s := &ScopeState{Scope: scope}
+		for _, m := range ListGlobalMigrations(scope) {
+			if applied[m.Version] {
+				s.Applied++
+			} else {
+				s.Pending++
+			}
+		}
+		state.Scopes = append(state.Scopes, s)
+	}
+	return state, nil
+}
+
+// CheckUpToDate returns a [PendingMigrationsError] listing the pending versions if migrations in
+// dir are left to apply, otherwise, it returns nil. It is meant for readiness probes of processes
+// that must not serve traffic until another process finished migrating the database.
+func CheckUpToDate(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFunc) error {
+	option := &options{}
+	for _, f := range opts {
+		f(option)
+	}
+	if option.noVersioning {
+		return errors.New("checking for pending migrations requires versioning: applied migrations must be tracked in the version table")
+	}
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
+	if err != nil {
+		return fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	applied, err := appliedState(ctx, db)
+	if err != nil {
+		return err
+	}
+	var pending []int64
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; !ok {
+			pending = append(pending, m.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return &PendingMigrationsError{Versions: pending}
+	}
+	return nil
+}
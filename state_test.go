@@ -0,0 +1,80 @@
+package goose_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetState(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_state.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+	require.NoError(t, goose.SetGlobalMigrations("state",
+		goose.NewGoMigration(1, nil, nil),
+		goose.NewGoMigration(5, nil, nil),
+	))
+	t.Cleanup(func() { goose.ResetGlobalMigrationsScope("state") })
+
+	for _, name := range []string{"00001_a.sql", "00002_b.sql", "00003_c.sql"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("-- +goose Up\nSELECT 1;\n"), 0644))
+	}
+	state, err := goose.GetState(ctx, db, dir)
+	require.NoError(t, err)
+	require.Zero(t, state.Version)
+	require.Equal(t, 3, state.Pending)
+	require.True(t, state.LastAppliedAt.IsZero())
+	require.False(t, state.Dirty)
+
+	require.NoError(t, goose.UpTo(db, dir, 2))
+	state, err = goose.GetState(ctx, db, dir)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, state.Version)
+	require.Equal(t, 1, state.Pending)
+	require.False(t, state.LastAppliedAt.IsZero())
+	require.False(t, state.Dirty)
+	require.Contains(t, state.Scopes, &goose.ScopeState{Scope: "state", Applied: 1, Pending: 1})
+	_, err = json.Marshal(state)
+	require.NoError(t, err)
+
+	var pendingErr *goose.PendingMigrationsError
+	err = goose.CheckUpToDate(ctx, db, dir)
+	require.ErrorAs(t, err, &pendingErr)
+	require.ErrorIs(t, err, goose.ErrPendingMigrations)
+	require.Equal(t, []int64{3}, pendingErr.Versions)
+	require.EqualError(t, err, "pending migrations: 3")
+	require.NoError(t, goose.Up(db, dir))
+	require.NoError(t, goose.CheckUpToDate(ctx, db, dir))
+	require.NoError(t, goose.WaitForNoPending(ctx, db, dir, time.Millisecond))
+
+	t.Run("dirty", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "00004_d.sql"),
+			[]byte("-- +goose NO TRANSACTION\n-- +goose Up\nCREATE TABLE d (id INTEGER);\nINSERT INTO missing VALUES (1);\n"), 0644))
+		p, err := goose.NewProvider(goose.DialectSQLite3, db, os.DirFS(dir), goose.WithCheckpoints(true))
+		require.NoError(t, err)
+		_, err = p.Up(ctx)
+		require.Error(t, err)
+		state, err := goose.GetState(ctx, db, dir)
+		require.NoError(t, err)
+		require.EqualValues(t, 3, state.Version)
+		require.Equal(t, 1, state.Pending)
+		require.True(t, state.Dirty)
+		require.ErrorIs(t, goose.CheckUpToDate(ctx, db, dir), goose.ErrPendingMigrations)
+	})
+	t.Run("no_versioning", func(t *testing.T) {
+		_, err := goose.GetState(ctx, db, dir, goose.WithNoVersioning())
+		require.ErrorContains(t, err, "state requires versioning")
+	})
+}
@@ -3,12 +3,26 @@ package goose
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
+// WithStatusFilter limits the migrations printed by [Status] to those matching the filter.
+func WithStatusFilter(filter StatusFilter) OptionsFunc {
+	return func(o *options) { o.statusFilter = filter }
+}
+
+// WithStatusJSON prints the status of migrations as a JSON array instead of a table. Each element
+// has the same shape as a JSON encoded [MigrationStatus].
+func WithStatusJSON() OptionsFunc {
+	return func(o *options) { o.statusJSON = true }
+}
+
 // Status prints the status of all migrations.
 func Status(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	ctx := context.Background()
@@ -21,44 +35,113 @@ func StatusContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsF
 	for _, f := range opts {
 		f(option)
 	}
-	migrations, err := CollectMigrations(option.scope, dir, minVersion, maxVersion)
+	if option.compareDB != nil {
+		if option.noVersioning {
+			return errors.New("compare requires versioning: applied migrations must be tracked in the version table")
+		}
+		return printCompare(ctx, db, dir, option)
+	}
+	migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
 	if err != nil {
 		return fmt.Errorf("failed to collect migrations: %w", err)
 	}
-	if option.noVersioning {
-		log.Printf("    Applied At                  Migration\n")
-		log.Printf("    =======================================\n")
-		for _, current := range migrations {
-			log.Printf("    %-24s -- %v\n", "no versioning", filepath.Base(current.Source))
+	if !option.noVersioning {
+		// must ensure that the version table exists if we're running on a pristine DB
+		if _, err := EnsureDBVersionContext(ctx, db); err != nil {
+			return fmt.Errorf("failed to ensure DB version: %w", err)
 		}
-		return nil
 	}
 
-	// must ensure that the version table exists if we're running on a pristine DB
-	if _, err := EnsureDBVersionContext(ctx, db); err != nil {
-		return fmt.Errorf("failed to ensure DB version: %w", err)
+	var labels map[int64]map[string]string
+	if !option.noVersioning {
+		if labels, err = listRunLabels(ctx, db); err != nil {
+			return fmt.Errorf("failed to list run labels: %w", err)
+		}
+		option.labelProtection.reveal(ctx, labels)
+	}
+	statuses := make([]*MigrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := &MigrationStatus{
+			Source: &Source{
+				Type:    typeFromSource(migration.Source),
+				Path:    migration.Source,
+				Version: migration.Version,
+			},
+			State: StatePending,
+		}
+		a, err := option.collectCache.readAnnotations(getBaseFS(), migration.Source)
+		if err != nil {
+			return err
+		}
+		a = a.resolve(migration)
+		status.Title, status.Owner, status.Hints = a.title, a.owner, a.hints
+		if !option.noVersioning {
+			m, err := getStore().GetMigration(ctx, db, TableName(), migration.Version)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("failed to print status: failed to query the latest migration: %w", err)
+			}
+			if m != nil && m.IsApplied {
+				status.State = StateApplied
+				status.AppliedAt = m.Timestamp
+				status.Labels = labels[migration.Version]
+			}
+		}
+		statuses = append(statuses, status)
 	}
+	statuses = FilterStatus(statuses, option.statusFilter)
 
+	if option.statusJSON {
+		data, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode status: %w", err)
+		}
+		if _, err := fmt.Fprintln(output(), string(data)); err != nil {
+			return fmt.Errorf("failed to write status: %w", err)
+		}
+		return nil
+	}
 	log.Printf("    Applied At                  Migration\n")
 	log.Printf("    =======================================\n")
-	for _, migration := range migrations {
-		if err := printMigrationStatus(ctx, db, migration.Version, filepath.Base(migration.Source)); err != nil {
-			return fmt.Errorf("failed to print status: %w", err)
+	for _, status := range statuses {
+		appliedAt := "Pending"
+		if option.noVersioning {
+			appliedAt = "no versioning"
+		} else if status.State == StateApplied {
+			appliedAt = status.AppliedAt.Format(time.ANSIC)
+		}
+		name := filepath.Base(status.Source.Path)
+		if status.Title != "" {
+			name += " (" + status.Title + ")"
+		}
+		if status.Owner != "" {
+			name += " @" + status.Owner
 		}
+		if len(status.Labels) > 0 {
+			log.Printf("    %-24s -- %v [%s]\n", appliedAt, name, formatLabels(status.Labels))
+			continue
+		}
+		log.Printf("    %-24s -- %v\n", appliedAt, name)
 	}
-
 	return nil
 }
 
-func printMigrationStatus(ctx context.Context, db *sql.DB, version int64, script string) error {
-	m, err := store.GetMigration(ctx, db, TableName(), version)
-	if err != nil && !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("failed to query the latest migration: %w", err)
+// formatLabels returns the labels as space-separated key=value pairs, sorted by key.
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
 	}
-	appliedAt := "Pending"
-	if m != nil && m.IsApplied {
-		appliedAt = m.Timestamp.Format(time.ANSIC)
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+labels[k])
 	}
-	log.Printf("    %-24s -- %v\n", appliedAt, script)
-	return nil
+	return strings.Join(pairs, " ")
+}
+
+func typeFromSource(source string) MigrationType {
+	if filepath.Ext(source) == ".go" {
+		return TypeGo
+	}
+	return TypeSQL
 }
@@ -0,0 +1,118 @@
+package goose_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestStatusJSON(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_status.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	for _, name := range []string{"00001_a.sql", "00002_b.sql", "00003_c.sql"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("-- +goose Up\nSELECT 1;\n"), 0644))
+	}
+	require.NoError(t, goose.UpTo(db, dir, 1))
+
+	// JSON is written to the output, not logged, so it can be piped.
+	logger := &bufferLogger{}
+	goose.SetLogger(logger)
+	t.Cleanup(func() { goose.SetLogger(log.Default()) })
+	var out strings.Builder
+	goose.SetOutput(&out)
+	t.Cleanup(func() { goose.SetOutput(os.Stdout) })
+	err = goose.Status(db, dir,
+		goose.WithStatusJSON(),
+		goose.WithStatusFilter(goose.StatusFilter{OnlyPending: true, Last: 1}),
+	)
+	require.NoError(t, err)
+	require.Empty(t, logger.String())
+	var got []*goose.MigrationStatus
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &got))
+	require.Len(t, got, 1)
+	require.Equal(t, goose.StatePending, got[0].State)
+	require.EqualValues(t, 3, got[0].Source.Version)
+	require.Equal(t, goose.TypeSQL, got[0].Source.Type)
+}
+
+func TestStatusRunLabels(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_status_labels.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	for _, name := range []string{"00001_a.sql", "00002_b.sql"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("-- +goose Up\nSELECT 1;\n"), 0644))
+	}
+	labels := map[string]string{"ticket": "OPS-1"}
+	require.NoError(t, goose.Up(db, dir, goose.WithOptionRunLabels(labels)))
+	require.NoError(t, goose.Down(db, dir))
+
+	var out strings.Builder
+	goose.SetOutput(&out)
+	t.Cleanup(func() { goose.SetOutput(os.Stdout) })
+	require.NoError(t, goose.Status(db, dir, goose.WithStatusJSON()))
+	var got []*goose.MigrationStatus
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &got))
+	require.Len(t, got, 2)
+	require.Equal(t, labels, got[0].Labels)
+	require.Nil(t, got[1].Labels)
+}
+
+func TestStatusTitle(t *testing.T) {
+	// not using t.Parallel here to avoid races
+	dir := t.TempDir()
+	db, err := sql.Open("sqlite", filepath.Join(dir, "sql_status_title.db"))
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	require.NoError(t, goose.SetDialect("sqlite3"))
+	t.Cleanup(func() { require.NoError(t, goose.SetDialect("postgres")) })
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00001_a.sql"),
+		[]byte("-- +goose title Add users table\n-- +goose owner identity-team\n-- +goose Up\nSELECT 1;\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "00002_b.sql"), []byte("-- +goose Up\nSELECT 1;\n"), 0644))
+
+	logger := &bufferLogger{}
+	goose.SetLogger(logger)
+	t.Cleanup(func() { goose.SetLogger(log.Default()) })
+	require.NoError(t, goose.Status(db, dir))
+	require.Contains(t, logger.String(), "-- 00001_a.sql (Add users table) @identity-team\n")
+	require.Contains(t, logger.String(), "-- 00002_b.sql\n")
+
+	var out strings.Builder
+	goose.SetOutput(&out)
+	t.Cleanup(func() { goose.SetOutput(os.Stdout) })
+	require.NoError(t, goose.Status(db, dir, goose.WithStatusJSON()))
+	var got []*goose.MigrationStatus
+	require.NoError(t, json.Unmarshal([]byte(out.String()), &got))
+	require.Len(t, got, 2)
+	require.Equal(t, "Add users table", got[0].Title)
+	require.Equal(t, "identity-team", got[0].Owner)
+	require.Empty(t, got[1].Title)
+	require.Empty(t, got[1].Owner)
+}
+
+type bufferLogger struct {
+	strings.Builder
+}
+
+func (l *bufferLogger) Printf(format string, v ...interface{}) { fmt.Fprintf(l, format, v...) }
+func (l *bufferLogger) Fatalf(format string, v ...interface{}) { panic(fmt.Sprintf(format, v...)) }
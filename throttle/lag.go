@@ -0,0 +1,91 @@
+package throttle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// NewQueryLagChecker returns a LagChecker that runs query against db. The query must return a
+// single row with a single column: the replication lag in seconds, possibly fractional. A NULL lag
+// is treated as no lag.
+func NewQueryLagChecker(db *sql.DB, query string) LagChecker {
+	return &queryLagChecker{db: db, query: query}
+}
+
+// NewPostgresLagChecker returns a LagChecker for Postgres that reports the largest replay lag of the
+// replicas connected to the primary db, from pg_stat_replication. The lag is zero when no replica
+// is connected.
+func NewPostgresLagChecker(db *sql.DB) LagChecker {
+	return NewQueryLagChecker(db, `SELECT COALESCE(EXTRACT(EPOCH FROM MAX(replay_lag)), 0) FROM pg_stat_replication`)
+}
+
+// NewMySQLLagChecker returns a LagChecker for MySQL that reports the Seconds_Behind_Source of the
+// replica db, from SHOW REPLICA STATUS. Unlike Postgres, replication lag is only known to the
+// replica, so db must be a connection to the replica rather than to the database being migrated.
+// An error is returned if replication is not running on the replica. Requires MySQL 8.0.22 or
+// later, or MariaDB 10.5.1 or later.
+func NewMySQLLagChecker(replica *sql.DB) LagChecker {
+	return &mysqlLagChecker{db: replica}
+}
+
+type queryLagChecker struct {
+	db    *sql.DB
+	query string
+}
+
+func (c *queryLagChecker) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	var seconds sql.NullFloat64
+	if err := c.db.QueryRowContext(ctx, c.query).Scan(&seconds); err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds.Float64 * float64(time.Second)), nil
+}
+
+type mysqlLagChecker struct {
+	db *sql.DB
+}
+
+func (c *mysqlLagChecker) ReplicationLag(ctx context.Context) (time.Duration, error) {
+	rows, err := c.db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return 0, err
+		}
+		return 0, errors.New("not a replica: SHOW REPLICA STATUS returned no rows")
+	}
+	values := make([]sql.RawBytes, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, err
+	}
+	for i, name := range columns {
+		// MariaDB still reports Seconds_Behind_Master.
+		if name != "Seconds_Behind_Source" && name != "Seconds_Behind_Master" {
+			continue
+		}
+		if values[i] == nil {
+			return 0, errors.New("replication is not running")
+		}
+		seconds, err := strconv.ParseInt(string(values[i]), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("SHOW REPLICA STATUS has no Seconds_Behind_Source column")
+}
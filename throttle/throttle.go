@@ -0,0 +1,73 @@
+// Package throttle defines the LagChecker interface and implements throttling on replication lag.
+package throttle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LagChecker reports how far the replicas of a database lag behind the primary.
+type LagChecker interface {
+	ReplicationLag(ctx context.Context) (time.Duration, error)
+}
+
+// Throttler pauses migrations while replication lag exceeds a threshold, similar to gh-ost's
+// throttling.
+type Throttler struct {
+	checker  LagChecker
+	maxLag   time.Duration
+	interval time.Duration
+}
+
+// New returns a Throttler that pauses while the lag reported by checker exceeds maxLag.
+func New(checker LagChecker, maxLag time.Duration, opts ...ThrottlerOption) (*Throttler, error) {
+	if checker == nil {
+		return nil, errors.New("lag checker must not be nil")
+	}
+	if maxLag <= 0 {
+		return nil, fmt.Errorf("max lag must be greater than 0: %s", maxLag)
+	}
+	t := &Throttler{
+		checker:  checker,
+		maxLag:   maxLag,
+		interval: DefaultInterval,
+	}
+	for _, opt := range opts {
+		if err := opt.apply(t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Wait blocks until the replication lag is at most the max lag, checking it every interval. It
+// returns how long it waited, which is zero if the lag was within bounds on the first check.
+func (t *Throttler) Wait(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	for i := 0; ; i++ {
+		lag, err := t.checker.ReplicationLag(ctx)
+		if err != nil {
+			return time.Since(start), fmt.Errorf("failed to check replication lag: %w", err)
+		}
+		if lag <= t.maxLag {
+			if i == 0 {
+				return 0, nil
+			}
+			return time.Since(start), nil
+		}
+		timer := time.NewTimer(t.interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return time.Since(start), fmt.Errorf("replication lag %s exceeds %s: %w", lag, t.maxLag, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// String returns a description of the throttler for logging.
+func (t *Throttler) String() string {
+	return fmt.Sprintf("replication lag above %s", t.maxLag)
+}
@@ -0,0 +1,35 @@
+package throttle
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultInterval is how often the replication lag is checked while throttling.
+const DefaultInterval = time.Second
+
+// ThrottlerOption is used to configure a Throttler.
+type ThrottlerOption interface {
+	apply(*Throttler) error
+}
+
+// WithInterval sets how often the replication lag is checked while throttling.
+//
+// If WithInterval is not called, the DefaultInterval is used.
+func WithInterval(d time.Duration) ThrottlerOption {
+	return throttlerFunc(func(t *Throttler) error {
+		if d <= 0 {
+			return fmt.Errorf("interval must be greater than 0: %s", d)
+		}
+		t.interval = d
+		return nil
+	})
+}
+
+var _ ThrottlerOption = (throttlerFunc)(nil)
+
+type throttlerFunc func(*Throttler) error
+
+func (f throttlerFunc) apply(t *Throttler) error {
+	return f(t)
+}
@@ -0,0 +1,77 @@
+package throttle_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3/throttle"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+type lagFunc func(ctx context.Context) (time.Duration, error)
+
+func (f lagFunc) ReplicationLag(ctx context.Context) (time.Duration, error) { return f(ctx) }
+
+func TestThrottler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	t.Run("within_bounds", func(t *testing.T) {
+		th, err := throttle.New(lagFunc(func(context.Context) (time.Duration, error) {
+			return time.Second, nil
+		}), time.Second)
+		require.NoError(t, err)
+		waited, err := th.Wait(ctx)
+		require.NoError(t, err)
+		require.Zero(t, waited)
+	})
+	t.Run("waits_for_lag", func(t *testing.T) {
+		lags := []time.Duration{10 * time.Second, 5 * time.Second, 0}
+		var checks int
+		th, err := throttle.New(lagFunc(func(context.Context) (time.Duration, error) {
+			lag := lags[checks]
+			checks++
+			return lag, nil
+		}), time.Second, throttle.WithInterval(time.Millisecond))
+		require.NoError(t, err)
+		waited, err := th.Wait(ctx)
+		require.NoError(t, err)
+		require.Positive(t, waited)
+		require.Equal(t, 3, checks)
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		th, err := throttle.New(lagFunc(func(context.Context) (time.Duration, error) {
+			return time.Hour, nil
+		}), time.Second, throttle.WithInterval(time.Millisecond))
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = th.Wait(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := throttle.New(nil, time.Second)
+		require.Error(t, err)
+		_, err = throttle.New(throttle.NewQueryLagChecker(nil, ""), 0)
+		require.Error(t, err)
+		_, err = throttle.New(throttle.NewQueryLagChecker(nil, ""), time.Second, throttle.WithInterval(0))
+		require.Error(t, err)
+	})
+}
+
+func TestQueryLagChecker(t *testing.T) {
+	t.Parallel()
+
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+	lag, err := throttle.NewQueryLagChecker(db, "SELECT 1.5").ReplicationLag(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1500*time.Millisecond, lag)
+	lag, err = throttle.NewQueryLagChecker(db, "SELECT NULL").ReplicationLag(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, lag)
+}
@@ -3,17 +3,34 @@ package goose
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/pressly/goose/v3/policy"
 )
 
 type options struct {
 	allowMissing bool
 	applyUpByOne bool
 	noVersioning bool
+	ignoreFreeze bool
+
+	scope           string
+	runLabels       map[string]string
+	labelProtection *LabelProtection
+
+	statusFilter StatusFilter
+	statusJSON   bool
+	compareDB    *sql.DB
 
-	scope string
+	collectCache *CollectCache
+	policy       policy.Policy
+
+	importFrom ImportFormat
+	exportTo   ExportFormat
 }
 
 type OptionsFunc func(o *options)
@@ -26,12 +43,31 @@ func WithOptionScope(scope string) OptionsFunc {
 	return func(o *options) { o.scope = scope }
 }
 
+// WithOptionRunLabels records labels, such as a git SHA, deployer or ticket, with each version
+// applied by [Up], [UpTo] or [UpByOne]. They are reported by [Status]. See the provider option
+// [WithRunLabels] for details.
+func WithOptionRunLabels(labels map[string]string) OptionsFunc {
+	return func(o *options) { o.runLabels = labels }
+}
+
+// WithOptionLabelProtection redacts or encrypts the values of the configured run labels before they
+// are recorded. See the provider option [WithLabelProtection] for details.
+func WithOptionLabelProtection(lp *LabelProtection) OptionsFunc {
+	return func(o *options) { o.labelProtection = lp }
+}
+
+// WithOptionCollectCache caches the migration files found in the migrations directory and the hints
+// parsed from them across calls. See the provider option [WithCollectCache] for details.
+func WithOptionCollectCache(cache *CollectCache) OptionsFunc {
+	return func(o *options) { o.collectCache = cache }
+}
+
 func WithNoVersioning() OptionsFunc {
 	return func(o *options) { o.noVersioning = true }
 }
 
 func WithNoColor(b bool) OptionsFunc {
-	return func(o *options) { noColor = b }
+	return func(o *options) { setNoColor(b) }
 }
 
 func withApplyUpByOne() OptionsFunc {
@@ -49,10 +85,20 @@ func UpToContext(ctx context.Context, db *sql.DB, dir string, version int64, opt
 	for _, f := range opts {
 		f(option)
 	}
-	foundMigrations, err := CollectMigrations(option.scope, dir, minVersion, version)
+	if err := checkRunLabels(option.runLabels); err != nil {
+		return err
+	}
+	runLabels, err := option.labelProtection.protect(ctx, option.runLabels)
 	if err != nil {
 		return err
 	}
+	foundMigrations, err := collectMigrations(option, dir, minVersion, version)
+	if err != nil {
+		return err
+	}
+	if err := checkOptionFreeze(ctx, db, option); err != nil {
+		return err
+	}
 
 	if option.noVersioning {
 		if len(foundMigrations) == 0 {
@@ -73,6 +119,57 @@ func UpToContext(ctx context.Context, db *sql.DB, dir string, version int64, opt
 	if err != nil {
 		return err
 	}
+	migrationsToApply, err := pendingUpMigrations(dbMigrations, foundMigrations, version, option.allowMissing)
+	if err != nil {
+		return err
+	}
+
+	var current int64
+	for _, m := range migrationsToApply {
+		if err := m.UpContext(ctx, db); err != nil {
+			if errors.Is(err, ErrPreconditionSkipped) {
+				return preconditionSkipError(m, err)
+			}
+			return err
+		}
+		if len(runLabels) > 0 {
+			if err := recordRunLabels(ctx, db, m.Version, runLabels); err != nil {
+				return fmt.Errorf("failed to record run labels for version %d: %w", m.Version, err)
+			}
+		}
+		if option.applyUpByOne {
+			return nil
+		}
+		current = m.Version
+	}
+
+	if len(migrationsToApply) == 0 {
+		current, err = GetDBVersionContext(ctx, db)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("goose: no migrations to run. current version: %d\n", current)
+	} else {
+		log.Printf("goose: successfully migrated database to version: %d\n", current)
+	}
+
+	// At this point there are no more migrations to apply. But we need to maintain
+	// the following behaviour:
+	// UpByOne returns an error to signifying there are no more migrations.
+	// Up and UpTo return nil
+
+	if option.applyUpByOne {
+		return ErrNoNextVersion
+	}
+
+	return nil
+}
+
+// pendingUpMigrations returns the found migrations to apply, in order, to migrate a database with
+// the given applied migrations up to version. Missing (out-of-order) migrations are an error,
+// unless allowMissing is true.
+func pendingUpMigrations(dbMigrations, foundMigrations Migrations, version int64, allowMissing bool) (Migrations, error) {
 	dbMaxVersion := dbMigrations[len(dbMigrations)-1].Version
 	// lookupAppliedInDB is a map of all applied migrations in the database.
 	lookupAppliedInDB := make(map[int64]bool)
@@ -85,17 +182,17 @@ func UpToContext(ctx context.Context, db *sql.DB, dir string, version int64, opt
 	// feature(mf): It is very possible someone may want to apply ONLY new migrations
 	// and skip missing migrations altogether. At the moment this is not supported,
 	// but leaving this comment because that's where that logic will be handled.
-	if len(missingMigrations) > 0 && !option.allowMissing {
+	if len(missingMigrations) > 0 && !allowMissing {
 		var collected []string
 		for _, m := range missingMigrations {
 			output := fmt.Sprintf("version %d: %s", m.Version, m.Source)
 			collected = append(collected, output)
 		}
-		return fmt.Errorf("error: found %d missing migrations before current version %d:\n\t%s",
+		return nil, fmt.Errorf("error: found %d missing migrations before current version %d:\n\t%s",
 			len(missingMigrations), dbMaxVersion, strings.Join(collected, "\n\t"))
 	}
 	var migrationsToApply Migrations
-	if option.allowMissing {
+	if allowMissing {
 		migrationsToApply = missingMigrations
 	}
 	// filter all migrations with a version greater than the supplied version (min) and less than or
@@ -110,39 +207,13 @@ func UpToContext(ctx context.Context, db *sql.DB, dir string, version int64, opt
 			migrationsToApply = append(migrationsToApply, m)
 		}
 	}
+	return migrationsToApply, nil
+}
 
-	var current int64
-	for _, m := range migrationsToApply {
-		if err := m.UpContext(ctx, db); err != nil {
-			return err
-		}
-		if option.applyUpByOne {
-			return nil
-		}
-		current = m.Version
-	}
-
-	if len(migrationsToApply) == 0 {
-		current, err = GetDBVersionContext(ctx, db)
-		if err != nil {
-			return err
-		}
-
-		log.Printf("goose: no migrations to run. current version: %d\n", current)
-	} else {
-		log.Printf("goose: successfully migrated database to version: %d\n", current)
-	}
-
-	// At this point there are no more migrations to apply. But we need to maintain
-	// the following behaviour:
-	// UpByOne returns an error to signifying there are no more migrations.
-	// Up and UpTo return nil
-
-	if option.applyUpByOne {
-		return ErrNoNextVersion
-	}
-
-	return nil
+// preconditionSkipError returns the error of a run that stopped at migration m, whose
+// precondition with on-fail=skip failed with err, leaving it and the pending migrations after it.
+func preconditionSkipError(m *Migration, err error) error {
+	return fmt.Errorf("skipped %s and the pending migrations after it: %w", filepath.Base(m.Source), err)
 }
 
 // upToNoVersioning applies up migrations up to, and including, the
@@ -155,6 +226,9 @@ func upToNoVersioning(ctx context.Context, db *sql.DB, migrations Migrations, ve
 		}
 		current.noVersioning = true
 		if err := current.UpContext(ctx, db); err != nil {
+			if errors.Is(err, ErrPreconditionSkipped) {
+				return preconditionSkipError(current, err)
+			}
 			return err
 		}
 		finalVersion = current.Version
@@ -188,7 +262,7 @@ func UpByOneContext(ctx context.Context, db *sql.DB, dir string, opts ...Options
 
 // listAllDBVersions returns a list of all migrations, ordered ascending.
 func listAllDBVersions(ctx context.Context, db *sql.DB) (Migrations, error) {
-	dbMigrations, err := store.ListMigrations(ctx, db, TableName())
+	dbMigrations, err := getStore().ListMigrations(ctx, db, TableName())
 	if err != nil {
 		return nil, err
 	}
@@ -225,3 +299,16 @@ func findMissingMigrations(knownMigrations, newMigrations Migrations, dbMaxVersi
 	})
 	return missing
 }
+
+// checkOptionFreeze returns a [FrozenError] if all scopes or the scope of option are frozen, unless
+// the freeze is ignored, see [WithIgnoreFreeze] for providers.
+func checkOptionFreeze(ctx context.Context, db *sql.DB, option *options) error {
+	if option.ignoreFreeze {
+		return nil
+	}
+	store, err := getMetadataStore()
+	if err != nil {
+		return err
+	}
+	return checkFreeze(ctx, store, db, option.scope)
+}
@@ -6,13 +6,14 @@ import (
 	"fmt"
 )
 
-// Version prints the current version of the database.
+// Version prints the current version of the database. Use [GetState] to read the version, along
+// with the rest of the migration state, without printing.
 func Version(db *sql.DB, dir string, opts ...OptionsFunc) error {
 	ctx := context.Background()
 	return VersionContext(ctx, db, dir, opts...)
 }
 
-// VersionContext prints the current version of the database.
+// VersionContext prints the current version of the database, see [Version].
 func VersionContext(ctx context.Context, db *sql.DB, dir string, opts ...OptionsFunc) error {
 	option := &options{}
 	for _, f := range opts {
@@ -20,7 +21,7 @@ func VersionContext(ctx context.Context, db *sql.DB, dir string, opts ...Options
 	}
 	if option.noVersioning {
 		var current int64
-		migrations, err := CollectMigrations(option.scope, dir, minVersion, maxVersion)
+		migrations, err := collectMigrations(option, dir, minVersion, maxVersion)
 		if err != nil {
 			return fmt.Errorf("failed to collect migrations: %w", err)
 		}
@@ -43,10 +44,16 @@ var tableName = "goose_db_version"
 
 // TableName returns goose db version table name
 func TableName() string {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
 	return tableName
 }
 
 // SetTableName set goose db version table name
+//
+// Deprecated: Use [NewProvider] with [WithTableName].
 func SetTableName(n string) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
 	tableName = n
 }
@@ -0,0 +1,47 @@
+package goose
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaitForNoPending blocks until all migrations in dir are applied, checking every pollInterval with
+// [CheckUpToDate]. It is meant for replicas that start alongside a leader applying the migrations:
+// instead of every replica contending for the lock, they wait for the leader to finish.
+//
+// If ctx is done first, the returned error wraps both the context error and the last
+// [PendingMigrationsError]. Any other error is returned immediately.
+func WaitForNoPending(ctx context.Context, db *sql.DB, dir string, pollInterval time.Duration, opts ...OptionsFunc) error {
+	return waitForNoPending(ctx, pollInterval, func(ctx context.Context) error {
+		return CheckUpToDate(ctx, db, dir, opts...)
+	})
+}
+
+// waitForNoPending calls check every interval until it reports no pending migrations.
+func waitForNoPending(ctx context.Context, interval time.Duration, check func(context.Context) error) error {
+	if interval <= 0 {
+		return errors.New("poll interval must be greater than 0")
+	}
+	var pending error
+	for {
+		err := check(ctx)
+		if err != nil && ctx.Err() != nil && pending != nil {
+			// The check was cut short by ctx, report the migrations still pending.
+			err = pending
+		}
+		if !errors.Is(err, ErrPendingMigrations) {
+			return err
+		}
+		pending = err
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("gave up waiting for migrations: %w: %w", pending, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package goose_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForNoPending(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := newDB(t)
+	fsys := fstest.MapFS{
+		"00001_a.sql": newMapFile("-- +goose Up\nCREATE TABLE a (id INTEGER);\n"),
+		"00002_b.sql": newMapFile("-- +goose Up\nCREATE TABLE b (id INTEGER);\n"),
+	}
+	leader, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+	replica, err := goose.NewProvider(goose.DialectSQLite3, db, fsys)
+	require.NoError(t, err)
+
+	// The replica gives up once the context is done.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err = replica.WaitForNoPending(timeoutCtx, 10*time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.ErrorIs(t, err, goose.ErrPendingMigrations)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- replica.WaitForNoPending(ctx, 10*time.Millisecond)
+	}()
+	_, err = leader.Up(ctx)
+	require.NoError(t, err)
+	require.NoError(t, <-done)
+	require.ErrorContains(t, replica.WaitForNoPending(ctx, 0), "poll interval must be greater than 0")
+}